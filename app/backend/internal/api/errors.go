@@ -0,0 +1,121 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"sohoaas-backend/internal/services"
+	"sohoaas-backend/internal/storage"
+	"sohoaas-backend/internal/types"
+	"sohoaas-backend/internal/workflowparser"
+)
+
+// respondError writes the standard error envelope with the status mapped from code
+func respondError(c *gin.Context, code, message, details string) {
+	respondErrorWith(c, types.ErrorResponse{
+		Code:    code,
+		Message: message,
+		Details: details,
+	}, nil)
+}
+
+// respondErrorWith writes the error envelope plus endpoint-specific context
+// (e.g. execution_id, queue_depth) alongside the "error" key
+func respondErrorWith(c *gin.Context, errResp types.ErrorResponse, extra gin.H) {
+	body := gin.H{"error": errResp}
+	for key, value := range extra {
+		body[key] = value
+	}
+	c.JSON(types.HTTPStatusForErrorCode(errResp.Code), body)
+}
+
+// respondExecutionSlotError reports why an execution got no slot: the user's queue is full,
+// or the request ended while it was queued
+func respondExecutionSlotError(c *gin.Context, err error, queueDepth int) {
+	message := "Too many concurrent executions"
+	if errors.Is(err, services.ErrExecutionCancelled) {
+		message = "Execution cancelled while queued"
+	}
+	respondErrorWith(c, types.ErrorResponse{
+		Code:    errorCodeFor(err, types.ErrorCodeRateLimited),
+		Message: message,
+		Details: err.Error(),
+	}, gin.H{
+		"queue_depth": queueDepth,
+	})
+}
+
+// serviceAuthorizationResponse is the error body of a run whose services the user's tokens
+// don't authorize, listing the missing services
+func serviceAuthorizationResponse(err error) types.ErrorResponse {
+	response := types.ErrorResponse{
+		Code:    errorCodeFor(err, types.ErrorCodeForbidden),
+		Message: "Workflow services are not authorized",
+		Details: err.Error(),
+	}
+	var authErr *services.ServiceAuthorizationError
+	if errors.As(err, &authErr) {
+		response.Fields = authErr.Missing
+	}
+	return response
+}
+
+// executionFailedResponse is the error body of a failed run; the plan's sensitive parameter
+// values are masked in the message, e.g. an API key a provider echoed back
+func executionFailedResponse(err error, plan *services.ExecutionPlan) types.ErrorResponse {
+	return types.ErrorResponse{
+		Code:    errorCodeFor(err, types.ErrorCodeInternal),
+		Message: "Workflow execution failed",
+		Details: plan.RedactError(err),
+	}
+}
+
+// errorCodeFor maps an internal error to an error code, using fallback when it is not recognized
+func errorCodeFor(err error, fallback string) string {
+	if err == nil {
+		return fallback
+	}
+	if errors.Is(err, services.ErrInvalidWorkflow) || errors.Is(err, services.ErrInvalidWorkflowJSON) || errors.Is(err, services.ErrServiceNotAllowed) || errors.Is(err, services.ErrServiceNotInCatalog) || errors.Is(err, services.ErrScopeNotPermitted) || errors.Is(err, services.ErrInvalidGeneratorInput) || errors.Is(err, services.ErrUnresolvedPlaceholder) || errors.Is(err, storage.ErrInvalidWorkflowLabels) || errors.Is(err, workflowparser.ErrSchemaOutdated) {
+		return types.ErrorCodeValidation
+	}
+	if errors.Is(err, services.ErrExecutionCancelled) {
+		return types.ErrorCodeCancelled
+	}
+	if errors.Is(err, services.ErrServicesNotAuthorized) {
+		return types.ErrorCodeForbidden
+	}
+	if errors.Is(err, storage.ErrWorkflowNotFound) || errors.Is(err, services.ErrStepNotFound) || errors.Is(err, services.ErrSecretNotFound) {
+		return types.ErrorCodeNotFound
+	}
+	// A missing or rejected Google token calls for reconnecting the service, not signing in again
+	if errors.Is(err, services.ErrTokenNotFound) || errors.Is(err, services.ErrTokenExpired) {
+		return types.ErrorCodeReconnectService
+	}
+	if errors.Is(err, services.ErrWorkflowAlreadyRunning) || errors.Is(err, services.ErrExecutionIDInUse) {
+		return types.ErrorCodeConflict
+	}
+	if errors.Is(err, services.ErrExecutionQueueFull) {
+		return types.ErrorCodeRateLimited
+	}
+	var timeoutErr *services.TimeoutError
+	if errors.Is(err, services.ErrWorkflowTimedOut) || errors.As(err, &timeoutErr) {
+		return types.ErrorCodeTimeout
+	}
+
+	// Typed MCP action errors say what went wrong without matching on the message
+	var authErr *services.AuthError
+	var rateLimitErr *services.RateLimitError
+	var validationErr *services.ValidationError
+	var upstreamErr *services.UpstreamError
+	switch {
+	case errors.As(err, &authErr):
+		return types.ErrorCodeReconnectService
+	case errors.As(err, &rateLimitErr):
+		return types.ErrorCodeRateLimited
+	case errors.As(err, &validationErr):
+		return types.ErrorCodeValidation
+	case errors.As(err, &upstreamErr), errors.Is(err, services.ErrCatalogUnavailable):
+		return types.ErrorCodeUpstream
+	}
+	return fallback
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"sohoaas-backend/internal/services"
+	"sohoaas-backend/internal/storage"
+	"sohoaas-backend/internal/types"
+)
+
+const testAPIKey = "sk-live-4f9a2c7e"
+
+// TestExecutionFailedResponseMasksSensitiveValues verifies an execution error echoing a
+// sensitive parameter doesn't leak it in the API response
+func TestExecutionFailedResponseMasksSensitiveValues(t *testing.T) {
+	plan := &services.ExecutionPlan{
+		SensitiveParameters: []string{"api_key"},
+		ParameterContext: &services.ParameterContext{
+			UserParameters: map[string]interface{}{"api_key": testAPIKey},
+		},
+	}
+	err := fmt.Errorf("step call failed: %w", errors.New("provider rejected key "+testAPIKey))
+
+	response := executionFailedResponse(err, plan)
+	if strings.Contains(response.Details, testAPIKey) {
+		t.Errorf("Expected the API key to be masked, got %q", response.Details)
+	}
+	if !strings.Contains(response.Details, "provider rejected key") {
+		t.Errorf("Expected the rest of the error to be kept, got %q", response.Details)
+	}
+}
+
+// TestErrorCodeFor verifies errors are mapped by their type or sentinel, never by their message
+func TestErrorCodeFor(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"workflow not found", fmt.Errorf("%w: wf_1", storage.ErrWorkflowNotFound), types.ErrorCodeNotFound},
+		{"step not found", fmt.Errorf("%w: step_9", services.ErrStepNotFound), types.ErrorCodeNotFound},
+		{"missing token", fmt.Errorf("failed to get token: %w", services.ErrTokenNotFound), types.ErrorCodeReconnectService},
+		{"expired token", services.ErrTokenExpired, types.ErrorCodeReconnectService},
+		{"MCP auth error", fmt.Errorf("not authorized for gmail: %w", &services.AuthError{}), types.ErrorCodeReconnectService},
+		{"catalog unavailable", fmt.Errorf("%w: connection refused", services.ErrCatalogUnavailable), types.ErrorCodeUpstream},
+		{"unparseable workflow", fmt.Errorf("%w: failed to parse CUE workflow: %w", services.ErrInvalidWorkflow, errors.New("expected '}'")), types.ErrorCodeValidation},
+		{"scope not permitted", fmt.Errorf("workflow service validation failed: %w", services.ErrScopeNotPermitted), types.ErrorCodeValidation},
+		{"unknown action", fmt.Errorf("%w: unknown action 'send'", services.ErrServiceNotInCatalog), types.ErrorCodeValidation},
+		{"message mentioning not found", errors.New("output field not found"), types.ErrorCodeInternal},
+		{"message mentioning a token", errors.New("unexpected token in JSON"), types.ErrorCodeInternal},
+		{"message mentioning MCP", errors.New("MCP response had no content"), types.ErrorCodeInternal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errorCodeFor(tt.err, types.ErrorCodeInternal); got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+
+	// A Google connection problem must not look like an invalid session to the client
+	if status := types.HTTPStatusForErrorCode(types.ErrorCodeReconnectService); status != http.StatusFailedDependency {
+		t.Errorf("Expected %s to map to 424, got %d", types.ErrorCodeReconnectService, status)
+	}
+}
+
+// TestServiceAuthorizationResponse verifies a rejected run lists the missing services and is
+// answered as forbidden
+func TestServiceAuthorizationResponse(t *testing.T) {
+	missing := []services.MissingServiceScope{{Service: "gmail", Connection: "default", Scope: "https://www.googleapis.com/auth/gmail.send"}}
+	err := &services.ServiceAuthorizationError{Missing: missing}
+
+	response := serviceAuthorizationResponse(err)
+	if response.Code != types.ErrorCodeForbidden {
+		t.Errorf("Expected %s, got %s", types.ErrorCodeForbidden, response.Code)
+	}
+	fields, ok := response.Fields.([]services.MissingServiceScope)
+	if !ok || len(fields) != 1 || fields[0].Service != "gmail" {
+		t.Errorf("Expected the missing gmail scope in fields, got %v", response.Fields)
+	}
+
+	if response := serviceAuthorizationResponse(services.ErrTokenNotFound); response.Code != types.ErrorCodeReconnectService {
+		t.Errorf("Expected %s for a missing token, got %s", types.ErrorCodeReconnectService, response.Code)
+	}
+}
@@ -13,15 +13,6 @@ import (
 	"sohoaas-backend/internal/types"
 )
 
-// getMapKeys returns the keys of a map[string]interface{} for logging
-func getMapKeys(m map[string]interface{}) []string {
-	keys := make([]string, 0, len(m))
-	for k := range m {
-		keys = append(keys, k)
-	}
-	return keys
-}
-
 // Handler contains all the dependencies needed for API handlers
 type Handler struct {
 	agentManager    *manager.AgentManager
@@ -29,19 +20,28 @@ type Handler struct {
 	workflowStorage storage.WorkflowStorage
 	executionEngine *services.ExecutionEngine
 	tokenManager    *services.TokenManager
+	userPreferences *services.UserPreferencesService
+	inlineExecution bool // accept raw CUE on /workflow/execute-inline
 }
 
 // NewHandler creates a new API handler instance
-func NewHandler(agentManager *manager.AgentManager, mcpService *services.MCPService, workflowStorage storage.WorkflowStorage, executionEngine *services.ExecutionEngine, tokenManager *services.TokenManager) *Handler {
+func NewHandler(agentManager *manager.AgentManager, mcpService *services.MCPService, workflowStorage storage.WorkflowStorage, executionEngine *services.ExecutionEngine, tokenManager *services.TokenManager, userPreferences *services.UserPreferencesService) *Handler {
 	return &Handler{
 		agentManager:    agentManager,
 		mcpService:      mcpService,
 		workflowStorage: workflowStorage,
 		executionEngine: executionEngine,
 		tokenManager:    tokenManager,
+		userPreferences: userPreferences,
 	}
 }
 
+// SetInlineExecution enables executing posted CUE without saving it first. It bypasses
+// validation on save, so it is meant for debugging and stays off in production.
+func (h *Handler) SetInlineExecution(enabled bool) {
+	h.inlineExecution = enabled
+}
+
 // HealthCheck returns the health status of the service
 func (h *Handler) HealthCheck(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
@@ -65,9 +65,7 @@ func (h *Handler) GetAgents(c *gin.Context) {
 func (h *Handler) GetPersonalCapabilities(c *gin.Context) {
 	user, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "User not found in context",
-		})
+		respondError(c, types.ErrorCodeUnauthorized, "User not found in context", "")
 		return
 	}
 	
@@ -75,14 +73,19 @@ func (h *Handler) GetPersonalCapabilities(c *gin.Context) {
 	
 	response, err := h.agentManager.GetPersonalCapabilities(userObj.ID, userObj)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to get personal capabilities",
-		})
+		respondError(c, types.ErrorCodeInternal, "Failed to get personal capabilities", "")
 		return
 	}
 	
+	// Per-service status from the stored tokens, so the UI knows which services need (re)authentication
+	catalog, err := h.mcpService.GetServiceCatalog()
+	if err != nil {
+		log.Printf("[API] WARNING: Service catalog unavailable, no connection status reported: %v", err)
+	}
+	
 	c.JSON(http.StatusOK, gin.H{
-		"agent_response": response,
+		"agent_response":    response,
+		"connection_status": h.tokenManager.ServiceConnectionStatuses(userObj.ID, catalog),
 	})
 }
 
@@ -93,17 +96,13 @@ func (h *Handler) StartWorkflowDiscovery(c *gin.Context) {
 	}
 	
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request format",
-		})
+		respondError(c, types.ErrorCodeInvalidRequest, "Invalid request format", "")
 		return
 	}
 	
 	user, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "User not found in context",
-		})
+		respondError(c, types.ErrorCodeUnauthorized, "User not found in context", "")
 		return
 	}
 	
@@ -120,9 +119,7 @@ func (h *Handler) StartWorkflowDiscovery(c *gin.Context) {
 	
 	response, err := h.agentManager.ProcessUserMessage(userObj.ID, request.Message, conversationHistory, userObj)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to process user message",
-		})
+		respondError(c, types.ErrorCodeInternal, "Failed to process user message", "")
 		return
 	}
 	
@@ -141,17 +138,13 @@ func (h *Handler) ContinueWorkflowDiscovery(c *gin.Context) {
 	}
 	
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid request format",
-		})
+		respondError(c, types.ErrorCodeInvalidRequest, "Invalid request format", "")
 		return
 	}
 	
 	user, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "User not found in context",
-		})
+		respondError(c, types.ErrorCodeUnauthorized, "User not found in context", "")
 		return
 	}
 	
@@ -166,9 +159,7 @@ func (h *Handler) ContinueWorkflowDiscovery(c *gin.Context) {
 	
 	response, err := h.agentManager.ProcessUserMessage(userObj.ID, request.Message, request.ConversationHistory, userObj)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to process user message",
-		})
+		respondError(c, types.ErrorCodeInternal, "Failed to process user message", "")
 		return
 	}
 	
@@ -185,17 +176,13 @@ func (h *Handler) AnalyzeIntent(c *gin.Context) {
 	}
 	
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid workflow intent format",
-		})
+		respondError(c, types.ErrorCodeInvalidRequest, "Invalid workflow intent format", "")
 		return
 	}
 	
 	user, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "User not found in context",
-		})
+		respondError(c, types.ErrorCodeUnauthorized, "User not found in context", "")
 		return
 	}
 	
@@ -203,9 +190,7 @@ func (h *Handler) AnalyzeIntent(c *gin.Context) {
 	
 	response, err := h.agentManager.AnalyzeIntent(userObj.ID, &request.WorkflowIntent, userObj)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to analyze intent",
-		})
+		respondError(c, types.ErrorCodeInternal, "Failed to analyze intent", "")
 		return
 	}
 	
@@ -223,13 +208,19 @@ func (h *Handler) GenerateWorkflow(c *gin.Context) {
 	var request struct {
 		UserIntent      string                 `json:"user_intent"`
 		ValidatedIntent map[string]interface{} `json:"validated_intent" binding:"required"`
+		Tags            []string               `json:"tags"`
+		Category        string                 `json:"category"`
 	}
 	
 	if err := c.ShouldBindJSON(&request); err != nil {
 		log.Printf("[API] ERROR: Failed to bind JSON request: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid validated intent format",
-		})
+		respondError(c, types.ErrorCodeInvalidRequest, "Invalid validated intent format", "")
+		return
+	}
+
+	labels, err := storage.NormalizeWorkflowLabels(types.WorkflowLabels{Tags: request.Tags, Category: request.Category})
+	if err != nil {
+		respondError(c, types.ErrorCodeValidation, "Invalid workflow labels", err.Error())
 		return
 	}
 	
@@ -237,9 +228,7 @@ func (h *Handler) GenerateWorkflow(c *gin.Context) {
 	
 	user, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "User not found in context",
-		})
+		respondError(c, types.ErrorCodeUnauthorized, "User not found in context", "")
 		return
 	}
 	
@@ -250,9 +239,11 @@ func (h *Handler) GenerateWorkflow(c *gin.Context) {
 	response, err := h.agentManager.GenerateWorkflow(userObj.ID, request.UserIntent, request.ValidatedIntent, userObj)
 	if err != nil {
 		log.Printf("[API] ERROR: GenerateWorkflow failed: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to generate workflow",
-		})
+		if code := errorCodeFor(err, types.ErrorCodeInternal); code == types.ErrorCodeValidation {
+			respondError(c, code, "Incomplete input for workflow generation", err.Error())
+			return
+		}
+		respondError(c, types.ErrorCodeInternal, "Failed to generate workflow", "")
 		return
 	}
 	
@@ -260,61 +251,114 @@ func (h *Handler) GenerateWorkflow(c *gin.Context) {
 	log.Printf("[API] Response AgentID: %s", response.AgentID)
 	log.Printf("[API] Response Error: %s", response.Error)
 	if response.Output != nil {
-		log.Printf("[API] Response Output keys: %+v", getMapKeys(response.Output))
-		if workflowFile, exists := response.Output["workflow_file"]; exists {
-			log.Printf("[API] Workflow file saved: %+v", workflowFile)
+		log.Printf("[API] Response Output workflow: %s (%d steps)", response.Output.Name, len(response.Output.Steps))
+		if response.Output.WorkflowFile != nil {
+			log.Printf("[API] Workflow file saved: %+v", response.Output.WorkflowFile)
+			if len(labels.Tags) > 0 || labels.Category != "" {
+				if err := h.workflowStorage.SaveWorkflowLabels(userObj.ID, response.Output.WorkflowFile.ID, labels); err != nil {
+					log.Printf("[API] ERROR: Failed to save labels of workflow %s: %v", response.Output.WorkflowFile.ID, err)
+				}
+			}
+		}
+		if len(response.Output.ValidationErrors) > 0 {
+			log.Printf("[API] Generated workflow rejected: %v", response.Output.ValidationErrors)
+			respondErrorWith(c, types.ErrorResponse{
+				Code:    types.ErrorCodeValidation,
+				Message: "Generated workflow failed validation",
+				Fields:  response.Output.ValidationErrors,
+			}, gin.H{
+				"agent_response": response,
+			})
+			return
 		}
 	}
 	
-	c.JSON(http.StatusOK, gin.H{
+	// Commonly paired follow-on actions, so the user can extend the workflow, and where each
+	// step input's value comes from
+	result := gin.H{
 		"agent_response": response,
+	}
+	if response.Output != nil {
+		result["parameter_report"] = services.BuildParameterReport(response.Output.Steps)
+		if suggestions, err := h.mcpService.SuggestNextSteps(response.Output.Steps); err != nil {
+			log.Printf("[API] WARNING: No next step suggestions for generated workflow: %v", err)
+		} else {
+			result["suggested_next_steps"] = suggestions
+		}
+	}
+	
+	c.JSON(http.StatusOK, result)
+}
+
+// SuggestNextSteps suggests follow-on actions for a workflow's steps from the service catalog
+func (h *Handler) SuggestNextSteps(c *gin.Context) {
+	var request struct {
+		Steps []types.WorkflowStep `json:"steps" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, types.ErrorCodeInvalidRequest, "Invalid next step suggestion request", "")
+		return
+	}
+
+	suggestions, err := h.mcpService.SuggestNextSteps(request.Steps)
+	if err != nil {
+		log.Printf("[API] ERROR: Failed to suggest next steps: %v", err)
+		respondError(c, types.ErrorCodeUpstream, "Service catalog unavailable", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"suggestions": suggestions,
 	})
 }
 
-// StoreGoogleToken securely stores a Google OAuth2 token for the authenticated user
+// StoreGoogleToken securely stores a Google OAuth2 token for one of the authenticated user's connections
 func (h *Handler) StoreGoogleToken(c *gin.Context) {
 	var request struct {
 		GoogleAccessToken string `json:"google_access_token" binding:"required"`
+		Connection        string `json:"connection"` // e.g. "work"; defaults to "default"
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid token storage request",
-		})
+		respondError(c, types.ErrorCodeInvalidRequest, "Invalid token storage request", "")
 		return
 	}
 
+	if err := services.ValidateConnectionName(request.Connection); err != nil {
+		respondError(c, types.ErrorCodeInvalidRequest, "Invalid connection name", err.Error())
+		return
+	}
+	connection := request.Connection
+	if connection == "" {
+		connection = services.DefaultConnection
+	}
+
 	user, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "User not found in context",
-		})
+		respondError(c, types.ErrorCodeUnauthorized, "User not found in context", "")
 		return
 	}
 
 	userObj, ok := user.(*types.User)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Invalid user object",
-		})
+		respondError(c, types.ErrorCodeInternal, "Invalid user object", "")
 		return
 	}
 
 	// Store token securely in backend
-	err := h.tokenManager.StoreGoogleToken(userObj.ID, userObj.Email, request.GoogleAccessToken)
+	err := h.tokenManager.StoreToken(userObj.ID, connection, userObj.Email, request.GoogleAccessToken)
 	if err != nil {
-		log.Printf("[API] Failed to store Google token for user %s: %v", userObj.ID, err)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Failed to store Google token",
-			"details": err.Error(),
-		})
+		log.Printf("[API] Failed to store Google token for user %s connection %s: %v", userObj.ID, connection, err)
+		respondError(c, types.ErrorCodeInvalidRequest, "Failed to store Google token", err.Error())
 		return
 	}
 
-	log.Printf("[API] Successfully stored Google token for user %s (%s)", userObj.ID, userObj.Email)
+	log.Printf("[API] Successfully stored Google token for user %s connection %s (%s)", userObj.ID, connection, userObj.Email)
 	c.JSON(http.StatusOK, gin.H{
-		"message": "Google token stored successfully",
-		"user_id": userObj.ID,
+		"message":    "Google token stored successfully",
+		"user_id":    userObj.ID,
+		"connection": connection,
 	})
 }
 
@@ -322,26 +366,19 @@ func (h *Handler) StoreGoogleToken(c *gin.Context) {
 func (h *Handler) GetTokenInfo(c *gin.Context) {
 	user, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "User not found in context",
-		})
+		respondError(c, types.ErrorCodeUnauthorized, "User not found in context", "")
 		return
 	}
 
 	userObj, ok := user.(*types.User)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Invalid user object",
-		})
+		respondError(c, types.ErrorCodeInternal, "Invalid user object", "")
 		return
 	}
 
-	tokenInfo, err := h.tokenManager.GetTokenInfo(userObj.ID)
+	tokenInfo, err := h.tokenManager.GetTokenInfo(userObj.ID, c.Query("connection"))
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Google token required",
-			"details": err.Error(),
-		})
+		respondError(c, types.ErrorCodeReconnectService, "Google token required", err.Error())
 		return
 	}
 
@@ -350,43 +387,84 @@ func (h *Handler) GetTokenInfo(c *gin.Context) {
 	})
 }
 
+// GetConnections lists the authenticated user's named workspace connections
+func (h *Handler) GetConnections(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		respondError(c, types.ErrorCodeUnauthorized, "User not found in context", "")
+		return
+	}
+
+	userObj, ok := user.(*types.User)
+	if !ok {
+		respondError(c, types.ErrorCodeInternal, "Invalid user object", "")
+		return
+	}
+
+	connections := h.tokenManager.ListConnections(userObj.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"connections": connections,
+		"count":       len(connections),
+	})
+}
+
 // ExecuteWorkflow executes a stored workflow by ID
 func (h *Handler) ExecuteWorkflow(c *gin.Context) {
 	var request struct {
 		WorkflowID     string                 `json:"workflow_id" binding:"required"`
 		UserParameters map[string]interface{} `json:"user_parameters"`
 		UserTimezone   string                 `json:"user_timezone"`
+		ApprovedSteps  []string               `json:"approved_steps"`
+		CallbackURL    string                 `json:"callback_url"`
+		Connection     string                 `json:"connection"`   // connection for steps without their own binding
+		ExecutionID    string                 `json:"execution_id"` // client-chosen ID, so the run can be cancelled in flight
 	}
 	
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid workflow execution request",
-		})
+		respondError(c, types.ErrorCodeInvalidRequest, "Invalid workflow execution request", "")
+		return
+	}
+	
+	if err := services.ValidateConnectionName(request.Connection); err != nil {
+		respondError(c, types.ErrorCodeInvalidRequest, "Invalid connection name", err.Error())
 		return
 	}
 	
+	if request.CallbackURL != "" {
+		if !h.executionEngine.WebhooksEnabled() {
+			respondError(c, types.ErrorCodeInvalidRequest, "Completion callbacks are not enabled", "WEBHOOK_SECRET is not configured")
+			return
+		}
+		if err := h.executionEngine.ValidateCallbackURL(request.CallbackURL); err != nil {
+			respondError(c, types.ErrorCodeInvalidRequest, "Invalid callback URL", err.Error())
+			return
+		}
+	}
+	
 	user, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "User not found in context",
-		})
+		respondError(c, types.ErrorCodeUnauthorized, "User not found in context", "")
 		return
 	}
 	
 	userObj := user.(*types.User)
 	
+	executionID, err := h.executionIDFor(request.ExecutionID)
+	if err != nil {
+		respondError(c, errorCodeFor(err, types.ErrorCodeInvalidRequest), "Invalid execution ID", err.Error())
+		return
+	}
+	
 	log.Printf("[API] === WORKFLOW EXECUTION STARTED ===")
 	log.Printf("[API] User: %s", userObj.ID)
 	log.Printf("[API] Workflow ID: %s", request.WorkflowID)
-	log.Printf("[API] User parameters: %+v", request.UserParameters)
+	log.Printf("[API] User parameters: %d provided", len(request.UserParameters))
 	
 	// Load workflow from storage
 	workflow, err := h.workflowStorage.GetWorkflow(userObj.ID, request.WorkflowID)
 	if err != nil {
 		log.Printf("[API] Failed to load workflow %s: %v", request.WorkflowID, err)
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": fmt.Sprintf("Workflow not found: %s", request.WorkflowID),
-		})
+		respondError(c, types.ErrorCodeNotFound, fmt.Sprintf("Workflow not found: %s", request.WorkflowID), "")
 		return
 	}
 	
@@ -394,7 +472,7 @@ func (h *Handler) ExecuteWorkflow(c *gin.Context) {
 	
 	// Create workflow execution
 	execution := &types.WorkflowExecution{
-		ID:          "exec_" + userObj.ID + "_" + time.Now().Format("20060102150405"),
+		ID:          executionID,
 		UserID:      userObj.ID,
 		WorkflowCUE: workflow.Content,
 		Status:      "pending",
@@ -406,13 +484,10 @@ func (h *Handler) ExecuteWorkflow(c *gin.Context) {
 	log.Printf("[API] Created execution plan: %s", execution.ID)
 	
 	// Get Google access token from secure backend storage
-	mcpToken, err := h.tokenManager.GetGoogleToken(userObj.ID)
+	mcpToken, err := h.tokenManager.GetToken(userObj.ID, request.Connection)
 	if err != nil {
 		log.Printf("[API] No Google token found for user %s: %v", userObj.ID, err)
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Google token required for workflow execution",
-			"details": "Please authenticate with Google Workspace first",
-		})
+		respondError(c, types.ErrorCodeReconnectService, "Google token required for workflow execution", "Please authenticate with Google Workspace first")
 		return
 	}
 
@@ -429,39 +504,122 @@ func (h *Handler) ExecuteWorkflow(c *gin.Context) {
 	)
 	if err != nil {
 		log.Printf("[API] ERROR: Failed to prepare execution plan: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to prepare workflow execution",
-			"details": err.Error(),
-		})
+		respondError(c, errorCodeFor(err, types.ErrorCodeInternal), "Failed to prepare workflow execution", err.Error())
 		return
 	}
 	
+	executionPlan.CallbackURL = request.CallbackURL
+	executionPlan.SourceWorkflowID = request.WorkflowID
+	executionPlan.Connection = request.Connection
+	
 	log.Printf("[API] Execution plan prepared successfully")
 	log.Printf("[API] Workflow: %s (%s)", executionPlan.Name, executionPlan.Description)
 	log.Printf("[API] Steps to execute: %d", len(executionPlan.ResolvedSteps))
 	
+	h.runExecutionPlan(c, userObj, execution, executionPlan, executionRunOptions{
+		workflowID:     request.WorkflowID,
+		userParameters: request.UserParameters,
+		approvedSteps:  request.ApprovedSteps,
+	})
+}
+
+// executionRunOptions are the request settings shared by stored and inline executions
+type executionRunOptions struct {
+	workflowID     string // stored workflow the plan was prepared from; empty for inline workflows
+	userParameters map[string]interface{}
+	approvedSteps  []string
+}
+
+// runExecutionPlan rejects a prepared plan with parameter or validation errors, then holds it
+// for confirmation or runs it and writes the response, including any validation warnings
+func (h *Handler) runExecutionPlan(c *gin.Context, userObj *types.User, execution *types.WorkflowExecution, executionPlan *services.ExecutionPlan, options executionRunOptions) {
+	if len(executionPlan.ParameterErrors) > 0 {
+		log.Printf("[API] WARNING: Invalid user parameters: %v", executionPlan.ParameterErrors)
+		respondErrorWith(c, types.ErrorResponse{
+			Code:    types.ErrorCodeValidation,
+			Message: "Invalid user parameters",
+			Fields:  executionPlan.ParameterErrors,
+		}, nil)
+		return
+	}
+	
 	if len(executionPlan.ValidationErrors) > 0 {
 		log.Printf("[API] WARNING: Validation errors found: %v", executionPlan.ValidationErrors)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Workflow validation failed",
-			"validation_errors": executionPlan.ValidationErrors,
+		respondErrorWith(c, types.ErrorResponse{
+			Code:    types.ErrorCodeValidation,
+			Message: "Workflow validation failed",
+			Fields:  executionPlan.ValidationErrors,
+		}, gin.H{
+			"validation_warnings": executionPlan.ValidationWarnings,
+		})
+		return
+	}
+	
+	// Warnings don't block the run; they are returned with its result
+	if len(executionPlan.ValidationWarnings) > 0 {
+		log.Printf("[API] WARNING: Validation warnings found: %v", executionPlan.ValidationWarnings)
+	}
+	
+	// Every service the workflow touches must be authorized with sufficient scopes before any step runs
+	if err := h.executionEngine.CheckServiceAuthorization(userObj.ID, executionPlan); err != nil {
+		log.Printf("[API] Execution of workflow %s rejected: %v", executionPlan.Name, err)
+		respondErrorWith(c, serviceAuthorizationResponse(err), nil)
+		return
+	}
+	
+	// Remember the submitted values so the next run of this workflow can be prefilled
+	if options.workflowID != "" {
+		h.executionEngine.RecordParameters(userObj.ID, options.workflowID, execution.ID, options.userParameters, executionPlan.SensitiveParameters...)
+	}
+	
+	// Pause for human approval of risky steps that were not pre-approved
+	if pendingSteps := h.executionEngine.ApproveSteps(executionPlan, options.approvedSteps); len(pendingSteps) > 0 {
+		h.executionEngine.HoldForConfirmation(execution.ID, userObj.ID, executionPlan)
+		c.JSON(http.StatusAccepted, gin.H{
+			"execution_id":        execution.ID,
+			"status":              "pending_confirmation",
+			"message":             "Workflow requires confirmation before execution",
+			"pending_steps":       pendingSteps,
+			"execution_plan":      executionPlan,
+			"validation_warnings": executionPlan.ValidationWarnings,
 		})
 		return
 	}
 	
+	// Exclusive workflows run one at a time; reject this run while another is in progress
+	unlock, err := h.executionEngine.LockWorkflow(userObj.ID, executionPlan)
+	if err != nil {
+		log.Printf("[API] Execution of workflow %s rejected: %v", executionPlan.Name, err)
+		respondError(c, errorCodeFor(err, types.ErrorCodeConflict), "Workflow is already running", err.Error())
+		return
+	}
+	defer unlock()
+	
+	// Wait for a per-user execution slot so bursts don't exhaust Google API quotas
+	release, queueDepth, err := h.executionEngine.AcquireExecutionSlot(c.Request.Context(), userObj.ID, executionPlan.Environment)
+	if err != nil {
+		log.Printf("[API] Execution rejected for user %s: %v", userObj.ID, err)
+		respondExecutionSlotError(c, err, queueDepth)
+		return
+	}
+	defer release()
+	
 	// Execute the workflow
 	log.Printf("[API] Starting workflow execution...")
 	execution.Status = "running"
 	
-	err = h.executionEngine.ExecuteWorkflow(executionPlan)
+	err = h.executionEngine.RunExecution(execution.ID, userObj.ID, executionPlan)
+	h.executionEngine.NotifyCompletion(execution.ID, executionPlan, err)
+	h.executionEngine.RecordFailure(execution.ID, userObj.ID, executionPlan, err)
 	if err != nil {
-		log.Printf("[API] ERROR: Workflow execution failed: %v", err)
-		execution.Status = "failed"
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"execution_id": execution.ID,
-			"status": "failed",
-			"error": err.Error(),
-			"execution_plan": executionPlan,
+		log.Printf("[API] ERROR: Workflow execution failed: %s", executionPlan.RedactError(err))
+		execution.Status = services.ExecutionStatus(err)
+		respondErrorWith(c, executionFailedResponse(err, executionPlan), gin.H{
+			"execution_id":        execution.ID,
+			"status":              execution.Status,
+			"execution_plan":      executionPlan,
+			"queue_depth":         queueDepth,
+			"validation_warnings": executionPlan.ValidationWarnings,
 		})
 		return
 	}
@@ -477,186 +635,1309 @@ func (h *Handler) ExecuteWorkflow(c *gin.Context) {
 		"message": "Workflow executed successfully",
 		"execution_plan": executionPlan,
 		"steps_completed": len(executionPlan.ResolvedSteps),
+		"queue_depth": queueDepth,
+		"validation_warnings": executionPlan.ValidationWarnings,
 	})
 }
 
-// GetUserServices retrieves user's connected MCP services
-func (h *Handler) GetUserServices(c *gin.Context) {
-	user, exists := c.Get("user")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "User not found in context",
-		})
+// ExecuteInlineWorkflow parses, validates and executes a posted CUE workflow without saving it,
+// for debugging workflows before they are stored. Disabled unless inline execution is enabled.
+func (h *Handler) ExecuteInlineWorkflow(c *gin.Context) {
+	if !h.inlineExecution {
+		respondError(c, types.ErrorCodeNotFound, "Inline workflow execution is not enabled", "Set EXECUTION_ALLOW_INLINE_WORKFLOWS=true outside production")
 		return
 	}
-	
-	token, exists := c.Get("token")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Token not found in context",
-		})
+
+	var request struct {
+		CUEContent     string                 `json:"cue_content" binding:"required"`
+		UserParameters map[string]interface{} `json:"user_parameters"`
+		UserTimezone   string                 `json:"user_timezone"`
+		ApprovedSteps  []string               `json:"approved_steps"`
+		Connection     string                 `json:"connection"`   // connection for steps without their own binding
+		ExecutionID    string                 `json:"execution_id"` // client-chosen ID, so the run can be cancelled in flight
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, types.ErrorCodeInvalidRequest, "Invalid inline workflow execution request", "")
 		return
 	}
-	
-	userObj := user.(*types.User)
-	tokenStr := token.(string)
-	
-	services, err := h.mcpService.GetUserServices(userObj.ID, tokenStr)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to get user services",
-		})
+
+	if err := services.ValidateConnectionName(request.Connection); err != nil {
+		respondError(c, types.ErrorCodeInvalidRequest, "Invalid connection name", err.Error())
 		return
 	}
-	
-	c.JSON(http.StatusOK, gin.H{
-		"services": services,
-		"count":    len(services),
-	})
-}
 
-// GetUserWorkflows retrieves user's saved CUE workflow files
-func (h *Handler) GetUserWorkflows(c *gin.Context) {
-	log.Printf("[API] === GET USER WORKFLOWS REQUEST ===")
-	log.Printf("[API] Request Method: %s, URL: %s", c.Request.Method, c.Request.URL.String())
-	
 	user, exists := c.Get("user")
 	if !exists {
-		log.Printf("[API] ERROR: User not found in context")
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "User not found in context",
-		})
+		respondError(c, types.ErrorCodeUnauthorized, "User not found in context", "")
 		return
 	}
 
-	userObj, ok := user.(*types.User)
-	if !ok {
-		log.Printf("[API] ERROR: Invalid user type in context: %T", user)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Invalid user object",
-		})
+	userObj := user.(*types.User)
+
+	executionID, err := h.executionIDFor(request.ExecutionID)
+	if err != nil {
+		respondError(c, errorCodeFor(err, types.ErrorCodeInvalidRequest), "Invalid execution ID", err.Error())
 		return
 	}
 
-	log.Printf("[API] User ID: %s, Email: %s", userObj.ID, userObj.Email)
-	log.Printf("[API] Storage type: %s", h.workflowStorage.GetStorageType())
+	log.Printf("[API] === INLINE WORKFLOW EXECUTION STARTED ===")
+	log.Printf("[API] User: %s (CUE length: %d characters)", userObj.ID, len(request.CUEContent))
 
-	workflows, err := h.workflowStorage.ListUserWorkflows(userObj.ID)
+	execution := &types.WorkflowExecution{
+		ID:          executionID,
+		UserID:      userObj.ID,
+		WorkflowCUE: request.CUEContent,
+		Status:      "pending",
+		Steps:       []types.WorkflowStep{},
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+
+	mcpToken, err := h.tokenManager.GetToken(userObj.ID, request.Connection)
 	if err != nil {
-		log.Printf("[API] ERROR: Failed to list workflows for user %s: %v", userObj.ID, err)
-		log.Printf("[API] Error type: %T", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to get user workflows",
-			"details": err.Error(),
-		})
+		log.Printf("[API] No Google token found for user %s: %v", userObj.ID, err)
+		respondError(c, types.ErrorCodeReconnectService, "Google token required for workflow execution", "Please authenticate with Google Workspace first")
 		return
 	}
 
-	log.Printf("[API] SUCCESS: Found %d workflows for user %s", len(workflows), userObj.ID)
-	for i, workflow := range workflows {
-		log.Printf("[API] Workflow %d: ID=%s, Name=%s, Content length=%d", i+1, workflow.ID, workflow.Name, len(workflow.Content))
-		contentPreview := workflow.Content
-		if len(contentPreview) > 200 {
-			contentPreview = contentPreview[:200] + "..."
-		}
-		log.Printf("[API] Workflow %d Content preview: %s", i+1, contentPreview)
+	// Parsing and service validation happen here, as they would before saving the workflow
+	executionPlan, err := h.executionEngine.PrepareExecution(
+		request.CUEContent,
+		userObj.ID,
+		userObj,
+		request.UserParameters,
+		mcpToken,
+		request.UserTimezone,
+	)
+	if err != nil {
+		log.Printf("[API] ERROR: Failed to prepare inline workflow: %v", err)
+		respondError(c, types.ErrorCodeValidation, "Invalid workflow", err.Error())
+		return
 	}
+	executionPlan.Connection = request.Connection
 
-	response := gin.H{
-		"count":     len(workflows),
-		"workflows": workflows,
-	}
-	log.Printf("[API] Response structure: %+v", response)
+	log.Printf("[API] Inline workflow %s prepared with %d steps", executionPlan.Name, len(executionPlan.ResolvedSteps))
 
-	c.JSON(http.StatusOK, response)
+	h.runExecutionPlan(c, userObj, execution, executionPlan, executionRunOptions{
+		userParameters: request.UserParameters,
+		approvedSteps:  request.ApprovedSteps,
+	})
 }
 
-// DeleteWorkflow deletes a specific workflow by ID for the authenticated user
-func (h *Handler) DeleteWorkflow(c *gin.Context) {
-    workflowID := c.Param("id")
-    if workflowID == "" {
-        c.JSON(http.StatusBadRequest, gin.H{
-            "error": "Workflow ID is required",
-        })
-        return
-    }
-
-    user, exists := c.Get("user")
-    if !exists {
-        c.JSON(http.StatusUnauthorized, gin.H{
-            "error": "User not found in context",
-        })
-        return
-    }
-    userObj := user.(*types.User)
-
-    if err := h.workflowStorage.DeleteWorkflow(userObj.ID, workflowID); err != nil {
-        c.JSON(http.StatusNotFound, gin.H{
-            "error": "Failed to delete workflow",
-            "details": err.Error(),
-        })
-        return
-    }
-
-    c.JSON(http.StatusOK, gin.H{
-        "message": "Workflow deleted",
-        "workflow_id": workflowID,
-    })
-}
+// ConfirmWorkflowExecution approves held steps and runs the workflow once all are approved
+func (h *Handler) ConfirmWorkflowExecution(c *gin.Context) {
+	var request struct {
+		ExecutionID     string   `json:"execution_id" binding:"required"`
+		ApprovedStepIDs []string `json:"approved_step_ids"`
+	}
 
-// GetWorkflow retrieves a specific workflow file by ID
-func (h *Handler) GetWorkflow(c *gin.Context) {
-	workflowID := c.Param("id")
-	log.Printf("[API] === GET SPECIFIC WORKFLOW REQUEST ===")
-	log.Printf("[API] Workflow ID: %s", workflowID)
-	
-	if workflowID == "" {
-		log.Printf("[API] ERROR: Workflow ID is required")
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Workflow ID is required",
-		})
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, types.ErrorCodeInvalidRequest, "Invalid workflow confirmation request", "")
 		return
 	}
 
 	user, exists := c.Get("user")
 	if !exists {
-		log.Printf("[API] ERROR: User not found in context")
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "User not found in context",
-		})
+		respondError(c, types.ErrorCodeUnauthorized, "User not found in context", "")
 		return
 	}
 
 	userObj := user.(*types.User)
-	log.Printf("[API] User ID: %s, Email: %s", userObj.ID, userObj.Email)
 
-	workflow, err := h.workflowStorage.GetWorkflow(userObj.ID, workflowID)
+	log.Printf("[API] Confirming execution %s for user %s (approved: %v)", request.ExecutionID, userObj.ID, request.ApprovedStepIDs)
+
+	executionPlan, pendingSteps, err := h.executionEngine.ConfirmExecution(request.ExecutionID, userObj.ID, request.ApprovedStepIDs)
 	if err != nil {
-		log.Printf("[API] ERROR: Failed to get workflow %s for user %s: %v", workflowID, userObj.ID, err)
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": "Workflow not found",
-		})
+		respondError(c, types.ErrorCodeNotFound, "Execution not found", err.Error())
 		return
 	}
 
-	log.Printf("[API] SUCCESS: Retrieved workflow %s", workflow.ID)
-	log.Printf("[API] Workflow Name: %s", workflow.Name)
-	log.Printf("[API] Workflow Content length: %d", len(workflow.Content))
-	
-	contentPreview := workflow.Content
-	if len(contentPreview) > 300 {
-		contentPreview = contentPreview[:300] + "..."
+	if len(pendingSteps) > 0 {
+		c.JSON(http.StatusAccepted, gin.H{
+			"execution_id":  request.ExecutionID,
+			"status":        "pending_confirmation",
+			"message":       "Some steps still require confirmation",
+			"pending_steps": pendingSteps,
+		})
+		return
 	}
-	log.Printf("[API] Workflow Content preview: %s", contentPreview)
 
-	response := gin.H{
-		"workflow": workflow,
-	}
-	log.Printf("[API] Sending workflow response to frontend")
+	log.Printf("[API] All steps approved, starting workflow execution %s", request.ExecutionID)
+
+	unlock, err := h.executionEngine.LockWorkflow(userObj.ID, executionPlan)
+	if err != nil {
+		// Keep the approved plan available so the user can confirm again once the other run ends
+		h.executionEngine.HoldForConfirmation(request.ExecutionID, userObj.ID, executionPlan)
+		respondError(c, errorCodeFor(err, types.ErrorCodeConflict), "Workflow is already running", err.Error())
+		return
+	}
+	defer unlock()
+
+	release, queueDepth, err := h.executionEngine.AcquireExecutionSlot(c.Request.Context(), userObj.ID, executionPlan.Environment)
+	if err != nil {
+		// Keep the approved plan available so the user can retry the confirmation
+		h.executionEngine.HoldForConfirmation(request.ExecutionID, userObj.ID, executionPlan)
+		respondExecutionSlotError(c, err, queueDepth)
+		return
+	}
+	defer release()
+
+	err = h.executionEngine.RunExecution(request.ExecutionID, userObj.ID, executionPlan)
+	h.executionEngine.NotifyCompletion(request.ExecutionID, executionPlan, err)
+	h.executionEngine.RecordFailure(request.ExecutionID, userObj.ID, executionPlan, err)
+	if err != nil {
+		log.Printf("[API] ERROR: Workflow execution failed: %s", executionPlan.RedactError(err))
+		respondErrorWith(c, executionFailedResponse(err, executionPlan), gin.H{
+			"execution_id":   request.ExecutionID,
+			"status":         services.ExecutionStatus(err),
+			"execution_plan": executionPlan,
+			"queue_depth":    queueDepth,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"execution_id":    request.ExecutionID,
+		"status":          "completed",
+		"message":         "Workflow executed successfully",
+		"execution_plan":  executionPlan,
+		"steps_completed": len(executionPlan.ResolvedSteps),
+		"queue_depth":     queueDepth,
+	})
+}
+
+// GetFailedExecutions lists the user's failed executions, newest first
+func (h *Handler) GetFailedExecutions(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		respondError(c, types.ErrorCodeUnauthorized, "User not found in context", "")
+		return
+	}
+
+	userObj := user.(*types.User)
+
+	deadLetters := h.executionEngine.DeadLetters()
+	if deadLetters == nil {
+		respondError(c, types.ErrorCodeInternal, "Failed execution store is not configured", "")
+		return
+	}
+
+	failed := deadLetters.List(userObj.ID)
+	c.JSON(http.StatusOK, gin.H{
+		"executions": failed,
+		"count":      len(failed),
+	})
+}
+
+// GetAuditLog returns audit entries of executions and their steps, oldest first. Users read
+// their own entries; service accounts may pass ?user= for any user. ?from= and ?to= take RFC3339 times.
+func (h *Handler) GetAuditLog(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		respondError(c, types.ErrorCodeUnauthorized, "User not found in context", "")
+		return
+	}
+
+	userObj := user.(*types.User)
+
+	auditSink := h.executionEngine.AuditSink()
+	if auditSink == nil {
+		respondError(c, types.ErrorCodeInternal, "Audit log is not configured", "")
+		return
+	}
+
+	filter := services.AuditFilter{UserID: userObj.ID}
+	if requested := c.Query("user"); requested != "" && requested != userObj.ID {
+		if !userObj.ServiceAccount {
+			respondError(c, types.ErrorCodeForbidden, "Only service accounts may read other users' audit entries", "")
+			return
+		}
+		filter.UserID = requested
+	}
+	for param, bound := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		if value := c.Query(param); value != "" {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				respondError(c, types.ErrorCodeInvalidRequest, fmt.Sprintf("Invalid %s time", param), "use RFC3339, e.g. 2026-01-31T00:00:00Z")
+				return
+			}
+			*bound = parsed
+		}
+	}
+
+	entries, err := auditSink.Query(filter)
+	if err != nil {
+		log.Printf("[API] ERROR: Failed to read audit log: %v", err)
+		respondError(c, types.ErrorCodeInternal, "Failed to read audit log", "")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"entries": entries,
+		"count":   len(entries),
+	})
+}
+
+// ReplayExecution re-runs a failed execution with the same inputs, optionally resuming
+// from the failed step with the outputs of the steps that had completed
+func (h *Handler) ReplayExecution(c *gin.Context) {
+	var request struct {
+		FromFailedStep bool   `json:"from_failed_step"`
+		ExecutionID    string `json:"execution_id"` // client-chosen ID, so the replay can be cancelled in flight
+	}
+
+	// The body is optional; an empty body replays the whole workflow
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			respondError(c, types.ErrorCodeInvalidRequest, "Invalid replay request", "")
+			return
+		}
+	}
+
+	user, exists := c.Get("user")
+	if !exists {
+		respondError(c, types.ErrorCodeUnauthorized, "User not found in context", "")
+		return
+	}
+
+	userObj := user.(*types.User)
+	failedID := c.Param("id")
+
+	executionID, err := h.executionIDFor(request.ExecutionID)
+	if err != nil {
+		respondError(c, errorCodeFor(err, types.ErrorCodeInvalidRequest), "Invalid execution ID", err.Error())
+		return
+	}
+
+	deadLetters := h.executionEngine.DeadLetters()
+	if deadLetters == nil {
+		respondError(c, types.ErrorCodeInternal, "Failed execution store is not configured", "")
+		return
+	}
+
+	failed, err := deadLetters.Get(failedID, userObj.ID)
+	if err != nil {
+		respondError(c, types.ErrorCodeNotFound, "Failed execution not found", err.Error())
+		return
+	}
+
+	log.Printf("[API] Replaying failed execution %s for user %s (from failed step: %t)", failedID, userObj.ID, request.FromFailedStep)
+
+	mcpToken, err := h.tokenManager.GetToken(userObj.ID, failed.Connection)
+	if err != nil {
+		log.Printf("[API] No Google token found for user %s: %v", userObj.ID, err)
+		respondError(c, types.ErrorCodeReconnectService, "Google token required for workflow execution", "Please authenticate with Google Workspace first")
+		return
+	}
+
+	var executionPlan *services.ExecutionPlan
+	if request.FromFailedStep {
+		executionPlan, err = failed.ResumePlan(mcpToken)
+		if err != nil {
+			respondError(c, types.ErrorCodeInvalidRequest, "Failed execution cannot be resumed", err.Error())
+			return
+		}
+	} else {
+		workflow, err := h.workflowStorage.GetWorkflow(userObj.ID, failed.WorkflowID)
+		if err != nil {
+			log.Printf("[API] Failed to load workflow %s: %v", failed.WorkflowID, err)
+			respondError(c, types.ErrorCodeNotFound, fmt.Sprintf("Workflow not found: %s", failed.WorkflowID), "")
+			return
+		}
+
+		replayParameters, err := h.executionEngine.ReplayParameters(failed)
+		if err != nil {
+			respondError(c, types.ErrorCodeInvalidRequest, "Failed execution parameters cannot be restored", err.Error())
+			return
+		}
+
+		executionPlan, err = h.executionEngine.PrepareExecution(
+			workflow.Content,
+			userObj.ID,
+			userObj,
+			replayParameters,
+			mcpToken,
+			failed.UserTimezone,
+		)
+		if err != nil {
+			log.Printf("[API] ERROR: Failed to prepare replay plan: %v", err)
+			respondError(c, errorCodeFor(err, types.ErrorCodeInternal), "Failed to prepare workflow execution", err.Error())
+			return
+		}
+		if len(executionPlan.ParameterErrors) > 0 || len(executionPlan.ValidationErrors) > 0 {
+			respondErrorWith(c, types.ErrorResponse{
+				Code:    types.ErrorCodeValidation,
+				Message: "Workflow validation failed",
+				Fields:  executionPlan.ValidationErrors,
+			}, gin.H{
+				"parameter_errors": executionPlan.ParameterErrors,
+			})
+			return
+		}
+
+		// The user approved the original run; a replay is an explicit request to run it again
+		h.executionEngine.ApproveSteps(executionPlan, executionPlan.PendingConfirmation)
+		executionPlan.SourceWorkflowID = failed.WorkflowID
+		executionPlan.Connection = failed.Connection
+		executionPlan.CallbackURL = failed.CallbackURL
+	}
+
+	// Scopes may have been revoked since the original run
+	if err := h.executionEngine.CheckServiceAuthorization(userObj.ID, executionPlan); err != nil {
+		log.Printf("[API] Replay of failed execution %s rejected: %v", failedID, err)
+		respondErrorWith(c, serviceAuthorizationResponse(err), nil)
+		return
+	}
+
+	unlock, err := h.executionEngine.LockWorkflow(userObj.ID, executionPlan)
+	if err != nil {
+		respondError(c, errorCodeFor(err, types.ErrorCodeConflict), "Workflow is already running", err.Error())
+		return
+	}
+	defer unlock()
+
+	release, queueDepth, err := h.executionEngine.AcquireExecutionSlot(c.Request.Context(), userObj.ID, executionPlan.Environment)
+	if err != nil {
+		respondExecutionSlotError(c, err, queueDepth)
+		return
+	}
+	defer release()
+
+	err = h.executionEngine.RunExecution(executionID, userObj.ID, executionPlan)
+	h.executionEngine.NotifyCompletion(executionID, executionPlan, err)
+
+	// The replay supersedes the failed entry; a new failure is recorded in its place
+	deadLetters.Remove(failedID)
+	if err != nil {
+		entry := services.NewFailedExecution(executionID, userObj.ID, executionPlan, err)
+		entry.ReplayOf = failedID
+		entry.ReplayCount = failed.ReplayCount + 1
+		h.executionEngine.ProtectFailedExecution(entry)
+		deadLetters.Record(entry)
+	}
+
+	if err != nil {
+		log.Printf("[API] ERROR: Replay of %s failed: %s", failedID, executionPlan.RedactError(err))
+		respondErrorWith(c, executionFailedResponse(err, executionPlan), gin.H{
+			"execution_id":   executionID,
+			"replay_of":      failedID,
+			"status":         services.ExecutionStatus(err),
+			"execution_plan": executionPlan,
+			"queue_depth":    queueDepth,
+		})
+		return
+	}
+
+	log.Printf("[API] Replay of %s completed as %s", failedID, executionID)
+	c.JSON(http.StatusOK, gin.H{
+		"execution_id":    executionID,
+		"replay_of":       failedID,
+		"status":          "completed",
+		"message":         "Workflow executed successfully",
+		"execution_plan":  executionPlan,
+		"steps_completed": len(executionPlan.ResolvedSteps),
+		"queue_depth":     queueDepth,
+	})
+}
+
+
+// executionIDFor returns the client-supplied execution ID, or a random one when none is sent.
+// A known ID lets the client cancel the run while the execute request is still in flight.
+func (h *Handler) executionIDFor(requested string) (string, error) {
+	if requested == "" {
+		return services.NewExecutionID(), nil
+	}
+	if err := services.ValidateExecutionID(requested); err != nil {
+		return "", err
+	}
+	if h.executionEngine.ExecutionIDInUse(requested) {
+		return "", fmt.Errorf("%w: %s", services.ErrExecutionIDInUse, requested)
+	}
+	return requested, nil
+}
+
+// CancelExecution stops a running execution of the user; the request running it ends with status cancelled
+func (h *Handler) CancelExecution(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		respondError(c, types.ErrorCodeUnauthorized, "User not found in context", "")
+		return
+	}
+
+	userObj := user.(*types.User)
+	executionID := c.Param("id")
+
+	if err := h.executionEngine.CancelExecution(executionID, userObj.ID); err != nil {
+		respondError(c, types.ErrorCodeNotFound, "Running execution not found", err.Error())
+		return
+	}
+
+	log.Printf("[API] Cancellation requested for execution %s by user %s", executionID, userObj.ID)
+	c.JSON(http.StatusAccepted, gin.H{
+		"execution_id": executionID,
+		"status":       "cancelling",
+		"message":      "Execution is being cancelled",
+	})
+}
+
+// EstimateWorkflow previews the impact of a workflow without calling MCP actions
+func (h *Handler) EstimateWorkflow(c *gin.Context) {
+	var request struct {
+		WorkflowID     string                 `json:"workflow_id" binding:"required"`
+		UserParameters map[string]interface{} `json:"user_parameters"`
+		UserTimezone   string                 `json:"user_timezone"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, types.ErrorCodeInvalidRequest, "Invalid workflow estimate request", "")
+		return
+	}
+
+	user, exists := c.Get("user")
+	if !exists {
+		respondError(c, types.ErrorCodeUnauthorized, "User not found in context", "")
+		return
+	}
+
+	userObj := user.(*types.User)
+
+	log.Printf("[API] Estimating workflow %s for user %s", request.WorkflowID, userObj.ID)
+
+	workflow, err := h.workflowStorage.GetWorkflow(userObj.ID, request.WorkflowID)
+	if err != nil {
+		log.Printf("[API] Failed to load workflow %s: %v", request.WorkflowID, err)
+		respondError(c, types.ErrorCodeNotFound, fmt.Sprintf("Workflow not found: %s", request.WorkflowID), "")
+		return
+	}
+
+	// Resolve the plan exactly as execution would; no token is needed since nothing is executed
+	executionPlan, err := h.executionEngine.PrepareExecution(
+		workflow.Content,
+		userObj.ID,
+		userObj,
+		request.UserParameters,
+		"",
+		request.UserTimezone,
+	)
+	if err != nil {
+		log.Printf("[API] ERROR: Failed to prepare execution plan for estimate: %v", err)
+		respondError(c, errorCodeFor(err, types.ErrorCodeInternal), "Failed to prepare workflow estimate", err.Error())
+		return
+	}
+
+	estimate, err := h.executionEngine.EstimateWorkflow(executionPlan)
+	if err != nil {
+		respondError(c, types.ErrorCodeInternal, "Failed to estimate workflow", err.Error())
+		return
+	}
+
+	log.Printf("[API] Workflow estimate: %s", estimate.Summary)
+
+	c.JSON(http.StatusOK, gin.H{
+		"workflow_id":       request.WorkflowID,
+		"estimate":          estimate,
+		"validation_errors":   executionPlan.ValidationErrors,
+		"validation_warnings": executionPlan.ValidationWarnings,
+		"parameter_errors":    executionPlan.ParameterErrors,
+	})
+}
+
+// SimulateWorkflow runs a workflow in simulate mode and returns the ordered MCP calls it
+// would make. Step output references resolve against mock outputs derived from each
+// action's output schema; no MCP actions are called.
+func (h *Handler) SimulateWorkflow(c *gin.Context) {
+	var request struct {
+		WorkflowID     string                 `json:"workflow_id" binding:"required"`
+		UserParameters map[string]interface{} `json:"user_parameters"`
+		UserTimezone   string                 `json:"user_timezone"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, types.ErrorCodeInvalidRequest, "Invalid workflow simulation request", "")
+		return
+	}
+
+	user, exists := c.Get("user")
+	if !exists {
+		respondError(c, types.ErrorCodeUnauthorized, "User not found in context", "")
+		return
+	}
+
+	userObj := user.(*types.User)
+
+	log.Printf("[API] Simulating workflow %s for user %s", request.WorkflowID, userObj.ID)
+
+	workflow, err := h.workflowStorage.GetWorkflow(userObj.ID, request.WorkflowID)
+	if err != nil {
+		log.Printf("[API] Failed to load workflow %s: %v", request.WorkflowID, err)
+		respondError(c, types.ErrorCodeNotFound, fmt.Sprintf("Workflow not found: %s", request.WorkflowID), "")
+		return
+	}
+
+	// No token is needed since nothing is executed
+	executionPlan, err := h.executionEngine.PrepareExecution(
+		workflow.Content,
+		userObj.ID,
+		userObj,
+		request.UserParameters,
+		"",
+		request.UserTimezone,
+	)
+	if err != nil {
+		log.Printf("[API] ERROR: Failed to prepare execution plan for simulation: %v", err)
+		respondError(c, errorCodeFor(err, types.ErrorCodeInternal), "Failed to prepare workflow simulation", err.Error())
+		return
+	}
+
+	if len(executionPlan.ParameterErrors) > 0 {
+		respondErrorWith(c, types.ErrorResponse{
+			Code:    types.ErrorCodeValidation,
+			Message: "Invalid user parameters",
+			Fields:  executionPlan.ParameterErrors,
+		}, nil)
+		return
+	}
+
+	if len(executionPlan.ValidationErrors) > 0 {
+		respondErrorWith(c, types.ErrorResponse{
+			Code:    types.ErrorCodeValidation,
+			Message: "Workflow validation failed",
+			Fields:  executionPlan.ValidationErrors,
+		}, nil)
+		return
+	}
+
+	executionPlan.Simulate = true
+	if err := h.executionEngine.ExecuteWorkflow(executionPlan); err != nil {
+		log.Printf("[API] Workflow simulation stopped: %s", executionPlan.RedactError(err))
+		respondErrorWith(c, types.ErrorResponse{
+			Code:    types.ErrorCodeValidation,
+			Message: "Workflow simulation failed",
+			Details: executionPlan.RedactError(err),
+		}, gin.H{
+			"workflow_id": request.WorkflowID,
+			"calls":       executionPlan.SimulationLog,
+		})
+		return
+	}
+
+	log.Printf("[API] Workflow simulation recorded %d calls", len(executionPlan.SimulationLog))
+
+	c.JSON(http.StatusOK, gin.H{
+		"workflow_id":         request.WorkflowID,
+		"calls":               executionPlan.SimulationLog,
+		"validation_warnings": executionPlan.ValidationWarnings,
+	})
+}
+
+// PreviewWorkflowStep renders one step's string inputs (e.g. an email body) with user
+// parameters substituted and step outputs taken from the dry-run's mock values
+func (h *Handler) PreviewWorkflowStep(c *gin.Context) {
+	var request struct {
+		WorkflowID     string                 `json:"workflow_id" binding:"required"`
+		StepID         string                 `json:"step_id" binding:"required"`
+		UserParameters map[string]interface{} `json:"user_parameters"`
+		UserTimezone   string                 `json:"user_timezone"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, types.ErrorCodeInvalidRequest, "Invalid step preview request", "")
+		return
+	}
+
+	user, exists := c.Get("user")
+	if !exists {
+		respondError(c, types.ErrorCodeUnauthorized, "User not found in context", "")
+		return
+	}
+
+	userObj := user.(*types.User)
+
+	workflow, err := h.workflowStorage.GetWorkflow(userObj.ID, request.WorkflowID)
+	if err != nil {
+		respondError(c, types.ErrorCodeNotFound, fmt.Sprintf("Workflow not found: %s", request.WorkflowID), "")
+		return
+	}
+
+	// No token is needed since nothing is executed
+	executionPlan, err := h.executionEngine.PrepareExecution(
+		workflow.Content,
+		userObj.ID,
+		userObj,
+		request.UserParameters,
+		"",
+		request.UserTimezone,
+	)
+	if err != nil {
+		respondError(c, errorCodeFor(err, types.ErrorCodeInternal), "Failed to prepare step preview", err.Error())
+		return
+	}
+
+	if len(executionPlan.ParameterErrors) > 0 {
+		respondErrorWith(c, types.ErrorResponse{
+			Code:    types.ErrorCodeValidation,
+			Message: "Invalid user parameters",
+			Fields:  executionPlan.ParameterErrors,
+		}, nil)
+		return
+	}
+
+	if len(executionPlan.ValidationErrors) > 0 {
+		respondErrorWith(c, types.ErrorResponse{
+			Code:    types.ErrorCodeValidation,
+			Message: "Workflow validation failed",
+			Fields:  executionPlan.ValidationErrors,
+		}, nil)
+		return
+	}
+
+	inputs, err := h.executionEngine.PreviewStepInputs(executionPlan, request.StepID)
+	if err != nil {
+		respondError(c, errorCodeFor(err, types.ErrorCodeValidation), "Failed to preview step", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"workflow_id": request.WorkflowID,
+		"step_id":     request.StepID,
+		"inputs":      inputs,
+	})
+}
+
+// GetUserPreferences returns the authenticated user's stored preferences
+func (h *Handler) GetUserPreferences(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		respondError(c, types.ErrorCodeUnauthorized, "User not found in context", "")
+		return
+	}
+
+	userObj := user.(*types.User)
+
+	c.JSON(http.StatusOK, gin.H{
+		"preferences": h.userPreferences.GetPreferences(userObj.ID),
+	})
+}
+
+// UpdateUserPreferences stores the authenticated user's preferences
+func (h *Handler) UpdateUserPreferences(c *gin.Context) {
+	var request struct {
+		Timezone string `json:"timezone" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, types.ErrorCodeInvalidRequest, "Invalid preferences request", "")
+		return
+	}
+
+	user, exists := c.Get("user")
+	if !exists {
+		respondError(c, types.ErrorCodeUnauthorized, "User not found in context", "")
+		return
+	}
+
+	userObj := user.(*types.User)
+
+	prefs, err := h.userPreferences.SetTimezone(userObj.ID, request.Timezone)
+	if err != nil {
+		respondError(c, types.ErrorCodeInvalidRequest, "Invalid timezone", err.Error())
+		return
+	}
+
+	log.Printf("[API] Stored timezone %s for user %s", prefs.Timezone, userObj.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"preferences": prefs,
+	})
+}
+
+// GetUserProfile returns the authenticated user's profile
+func (h *Handler) GetUserProfile(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		respondError(c, types.ErrorCodeUnauthorized, "User not found in context", "")
+		return
+	}
+
+	userObj := user.(*types.User)
+
+	profiles := h.executionEngine.UserProfiles()
+	if profiles == nil {
+		respondError(c, types.ErrorCodeInternal, "User profile store is not configured", "")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"profile": profiles.GetProfile(userObj.ID),
+	})
+}
+
+// UpdateUserProfile replaces the authenticated user's profile
+func (h *Handler) UpdateUserProfile(c *gin.Context) {
+	var request struct {
+		DisplayName string            `json:"display_name"`
+		TeamEmail   string            `json:"team_email"`
+		Values      map[string]string `json:"values"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, types.ErrorCodeInvalidRequest, "Invalid profile request", "")
+		return
+	}
+
+	user, exists := c.Get("user")
+	if !exists {
+		respondError(c, types.ErrorCodeUnauthorized, "User not found in context", "")
+		return
+	}
+
+	userObj := user.(*types.User)
+
+	profiles := h.executionEngine.UserProfiles()
+	if profiles == nil {
+		respondError(c, types.ErrorCodeInternal, "User profile store is not configured", "")
+		return
+	}
+
+	profile, err := profiles.UpdateProfile(userObj.ID, services.UserProfile{
+		DisplayName: request.DisplayName,
+		TeamEmail:   request.TeamEmail,
+		Values:      request.Values,
+	})
+	if err != nil {
+		respondError(c, types.ErrorCodeInvalidRequest, "Invalid profile", err.Error())
+		return
+	}
+
+	log.Printf("[API] Stored profile for user %s", userObj.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"profile": profile,
+	})
+}
+
+// ReloadPrompts reloads agent dotprompt files and reports which ones were swapped in
+func (h *Handler) ReloadPrompts(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		respondError(c, types.ErrorCodeUnauthorized, "User not found in context", "")
+		return
+	}
+
+	userObj := user.(*types.User)
+	log.Printf("[API] Prompt reload requested by user %s", userObj.ID)
+
+	results := h.agentManager.ReloadPrompts()
+
+	allReloaded := true
+	for _, result := range results {
+		if !result.Reloaded {
+			allReloaded = false
+			log.Printf("[API] Prompt %s failed to reload: %s", result.Name, result.Error)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"prompts":      results,
+		"all_reloaded": allReloaded,
+	})
+}
+
+// ReloadRaCContexts re-validates the RaC context files and swaps them in when all are valid
+func (h *Handler) ReloadRaCContexts(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		respondError(c, types.ErrorCodeUnauthorized, "User not found in context", "")
+		return
+	}
+
+	userObj := user.(*types.User)
+	log.Printf("[API] RaC context reload requested by user %s", userObj.ID)
+
+	if err := h.agentManager.ReloadRaCContexts(); err != nil {
+		log.Printf("[API] RaC context reload failed: %v", err)
+		respondError(c, types.ErrorCodeValidation, "RaC context reload failed; previous contexts remain active", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"reloaded": true,
+	})
+}
+
+// InvalidateCatalog drops the cached MCP service catalog so validation uses the current
+// functions right after a provider deploy; the MCP backend calls it with the internal token
+func (h *Handler) InvalidateCatalog(c *gin.Context) {
+	log.Printf("[API] Service catalog invalidation requested")
+	h.mcpService.InvalidateCatalog()
+
+	c.JSON(http.StatusOK, gin.H{
+		"invalidated": true,
+	})
+}
+
+// GetUserServices retrieves user's connected MCP services
+func (h *Handler) GetUserServices(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		respondError(c, types.ErrorCodeUnauthorized, "User not found in context", "")
+		return
+	}
+	
+	token, exists := c.Get("token")
+	if !exists {
+		respondError(c, types.ErrorCodeUnauthorized, "Token not found in context", "")
+		return
+	}
+	
+	userObj := user.(*types.User)
+	tokenStr := token.(string)
+	
+	services, err := h.mcpService.GetUserServices(userObj.ID, tokenStr)
+	if err != nil {
+		respondError(c, types.ErrorCodeUpstream, "Failed to get user services", "")
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{
+		"services": services,
+		"count":    len(services),
+	})
+}
+
+// GetUserWorkflows retrieves user's saved CUE workflow files
+func (h *Handler) GetUserWorkflows(c *gin.Context) {
+	log.Printf("[API] === GET USER WORKFLOWS REQUEST ===")
+	log.Printf("[API] Request Method: %s, URL: %s", c.Request.Method, c.Request.URL.String())
+	
+	user, exists := c.Get("user")
+	if !exists {
+		log.Printf("[API] ERROR: User not found in context")
+		respondError(c, types.ErrorCodeUnauthorized, "User not found in context", "")
+		return
+	}
+
+	userObj, ok := user.(*types.User)
+	if !ok {
+		log.Printf("[API] ERROR: Invalid user type in context: %T", user)
+		respondError(c, types.ErrorCodeInternal, "Invalid user object", "")
+		return
+	}
+
+	log.Printf("[API] User ID: %s, Email: %s", userObj.ID, userObj.Email)
+	log.Printf("[API] Storage type: %s", h.workflowStorage.GetStorageType())
+
+	workflows, err := h.workflowStorage.ListUserWorkflows(userObj.ID)
+	if err != nil {
+		log.Printf("[API] ERROR: Failed to list workflows for user %s: %v", userObj.ID, err)
+		log.Printf("[API] Error type: %T", err)
+		respondError(c, types.ErrorCodeInternal, "Failed to get user workflows", err.Error())
+		return
+	}
+
+	// Narrow the list to a tag and/or category when asked
+	if tag, category := c.Query("tag"), c.Query("category"); tag != "" || category != "" {
+		workflows = storage.FilterWorkflows(workflows, tag, category)
+	}
+
+	log.Printf("[API] SUCCESS: Found %d workflows for user %s", len(workflows), userObj.ID)
+	for i, workflow := range workflows {
+		log.Printf("[API] Workflow %d: ID=%s, Name=%s, Content length=%d", i+1, workflow.ID, workflow.Name, len(workflow.Content))
+		contentPreview := workflow.Content
+		if len(contentPreview) > 200 {
+			contentPreview = contentPreview[:200] + "..."
+		}
+		log.Printf("[API] Workflow %d Content preview: %s", i+1, contentPreview)
+	}
+
+	response := gin.H{
+		"count":     len(workflows),
+		"workflows": workflows,
+	}
+	log.Printf("[API] Response structure: %+v", response)
+
+	c.JSON(http.StatusOK, response)
+}
+
+// CreateWorkflow saves a hand-authored workflow JSON (the format served by GET /schema/workflow)
+// after validating it and converting it to CUE, the same way generated workflows are saved
+func (h *Handler) CreateWorkflow(c *gin.Context) {
+	var workflowJSON map[string]interface{}
+	if err := c.ShouldBindJSON(&workflowJSON); err != nil {
+		respondError(c, types.ErrorCodeInvalidRequest, "Invalid workflow JSON", err.Error())
+		return
+	}
+
+	user, exists := c.Get("user")
+	if !exists {
+		respondError(c, types.ErrorCodeUnauthorized, "User not found in context", "")
+		return
+	}
+	userObj := user.(*types.User)
+
+	// Tags and category are metadata, so keep them out of the workflow JSON converted to CUE
+	labels, err := takeWorkflowLabels(workflowJSON)
+	if err != nil {
+		respondError(c, types.ErrorCodeValidation, "Invalid workflow labels", err.Error())
+		return
+	}
+
+	workflowFile, cueContent, err := h.agentManager.SaveAuthoredWorkflow(userObj.ID, workflowJSON)
+	if err != nil {
+		log.Printf("[API] ERROR: Failed to save authored workflow for user %s: %v", userObj.ID, err)
+		respondError(c, errorCodeFor(err, types.ErrorCodeInternal), "Failed to save workflow", err.Error())
+		return
+	}
+
+	if len(labels.Tags) > 0 || labels.Category != "" {
+		if err := h.workflowStorage.SaveWorkflowLabels(userObj.ID, workflowFile.ID, labels); err != nil {
+			log.Printf("[API] ERROR: Failed to save labels of workflow %s: %v", workflowFile.ID, err)
+		} else {
+			workflowFile.Tags = labels.Tags
+			workflowFile.Category = labels.Category
+		}
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"workflow_file": workflowFile,
+		"workflow_cue":  cueContent,
+	})
+}
+
+// takeWorkflowLabels removes the optional "tags" and "category" from a workflow JSON and
+// returns them normalized
+func takeWorkflowLabels(workflowJSON map[string]interface{}) (types.WorkflowLabels, error) {
+	var labels types.WorkflowLabels
+	if rawTags, exists := workflowJSON["tags"]; exists {
+		tags, ok := rawTags.([]interface{})
+		if !ok && rawTags != nil {
+			return labels, fmt.Errorf("%w: tags must be an array of strings", storage.ErrInvalidWorkflowLabels)
+		}
+		for _, rawTag := range tags {
+			tag, ok := rawTag.(string)
+			if !ok {
+				return labels, fmt.Errorf("%w: tags must be an array of strings", storage.ErrInvalidWorkflowLabels)
+			}
+			labels.Tags = append(labels.Tags, tag)
+		}
+		delete(workflowJSON, "tags")
+	}
+	if rawCategory, exists := workflowJSON["category"]; exists {
+		category, ok := rawCategory.(string)
+		if !ok && rawCategory != nil {
+			return labels, fmt.Errorf("%w: category must be a string", storage.ErrInvalidWorkflowLabels)
+		}
+		labels.Category = category
+		delete(workflowJSON, "category")
+	}
+	return storage.NormalizeWorkflowLabels(labels)
+}
+
+// DeleteWorkflow deletes a specific workflow by ID for the authenticated user
+func (h *Handler) DeleteWorkflow(c *gin.Context) {
+    workflowID := c.Param("id")
+    if workflowID == "" {
+        respondError(c, types.ErrorCodeInvalidRequest, "Workflow ID is required", "")
+        return
+    }
+
+    user, exists := c.Get("user")
+    if !exists {
+        respondError(c, types.ErrorCodeUnauthorized, "User not found in context", "")
+        return
+    }
+    userObj := user.(*types.User)
+
+    if err := h.workflowStorage.DeleteWorkflow(userObj.ID, workflowID); err != nil {
+        respondError(c, errorCodeFor(err, types.ErrorCodeInternal), "Failed to delete workflow", err.Error())
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "message": "Workflow deleted",
+        "workflow_id": workflowID,
+    })
+}
+
+// GetWorkflow retrieves a specific workflow file by ID
+func (h *Handler) GetWorkflow(c *gin.Context) {
+	workflowID := c.Param("id")
+	log.Printf("[API] === GET SPECIFIC WORKFLOW REQUEST ===")
+	log.Printf("[API] Workflow ID: %s", workflowID)
+	
+	if workflowID == "" {
+		log.Printf("[API] ERROR: Workflow ID is required")
+		respondError(c, types.ErrorCodeInvalidRequest, "Workflow ID is required", "")
+		return
+	}
+
+	user, exists := c.Get("user")
+	if !exists {
+		log.Printf("[API] ERROR: User not found in context")
+		respondError(c, types.ErrorCodeUnauthorized, "User not found in context", "")
+		return
+	}
+
+	userObj := user.(*types.User)
+	log.Printf("[API] User ID: %s, Email: %s", userObj.ID, userObj.Email)
+
+	workflow, err := h.workflowStorage.GetWorkflow(userObj.ID, workflowID)
+	if err != nil {
+		log.Printf("[API] ERROR: Failed to get workflow %s for user %s: %v", workflowID, userObj.ID, err)
+		respondError(c, types.ErrorCodeNotFound, "Workflow not found", "")
+		return
+	}
+
+	log.Printf("[API] SUCCESS: Retrieved workflow %s", workflow.ID)
+	log.Printf("[API] Workflow Name: %s", workflow.Name)
+	log.Printf("[API] Workflow Content length: %d", len(workflow.Content))
+	
+	contentPreview := workflow.Content
+	if len(contentPreview) > 300 {
+		contentPreview = contentPreview[:300] + "..."
+	}
+	log.Printf("[API] Workflow Content preview: %s", contentPreview)
+
+	response := gin.H{
+		"workflow": workflow,
+	}
+	log.Printf("[API] Sending workflow response to frontend")
 
 	c.JSON(http.StatusOK, response)
 }
 
+// UpdateWorkflowLabels changes the tags and/or category of a stored workflow; fields left out
+// of the request are kept
+func (h *Handler) UpdateWorkflowLabels(c *gin.Context) {
+	workflowID := c.Param("id")
+
+	var request struct {
+		Tags     *[]string `json:"tags"`
+		Category *string   `json:"category"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, types.ErrorCodeInvalidRequest, "Invalid workflow labels", err.Error())
+		return
+	}
+
+	user, exists := c.Get("user")
+	if !exists {
+		respondError(c, types.ErrorCodeUnauthorized, "User not found in context", "")
+		return
+	}
+
+	userObj := user.(*types.User)
+
+	workflow, err := h.workflowStorage.GetWorkflow(userObj.ID, workflowID)
+	if err != nil {
+		log.Printf("[API] Failed to load workflow %s for labels: %v", workflowID, err)
+		respondError(c, types.ErrorCodeNotFound, "Workflow not found", "")
+		return
+	}
+
+	labels := types.WorkflowLabels{Tags: workflow.Tags, Category: workflow.Category}
+	if request.Tags != nil {
+		labels.Tags = *request.Tags
+	}
+	if request.Category != nil {
+		labels.Category = *request.Category
+	}
+	labels, err = storage.NormalizeWorkflowLabels(labels)
+	if err != nil {
+		respondError(c, types.ErrorCodeValidation, "Invalid workflow labels", err.Error())
+		return
+	}
+
+	if err := h.workflowStorage.SaveWorkflowLabels(userObj.ID, workflowID, labels); err != nil {
+		log.Printf("[API] ERROR: Failed to save labels of workflow %s: %v", workflowID, err)
+		respondError(c, errorCodeFor(err, types.ErrorCodeInternal), "Failed to update workflow", err.Error())
+		return
+	}
+	workflow.Tags = labels.Tags
+	workflow.Category = labels.Category
+
+	c.JSON(http.StatusOK, gin.H{
+		"workflow": workflow,
+	})
+}
+
+// GetWorkflowInputs returns the user parameters a stored workflow prompts for, so the
+// frontend can build its input form without parsing CUE
+func (h *Handler) GetWorkflowInputs(c *gin.Context) {
+	workflowID := c.Param("id")
+
+	user, exists := c.Get("user")
+	if !exists {
+		respondError(c, types.ErrorCodeUnauthorized, "User not found in context", "")
+		return
+	}
+
+	userObj := user.(*types.User)
+
+	workflow, err := h.workflowStorage.GetWorkflow(userObj.ID, workflowID)
+	if err != nil {
+		log.Printf("[API] Failed to load workflow %s for inputs: %v", workflowID, err)
+		respondError(c, types.ErrorCodeNotFound, "Workflow not found", "")
+		return
+	}
+
+	parsed, err := h.executionEngine.ParseCUEWorkflow(workflow.Content)
+	if err != nil {
+		log.Printf("[API] ERROR: Failed to parse workflow %s for inputs: %v", workflowID, err)
+		respondError(c, types.ErrorCodeValidation, "Failed to parse workflow", err.Error())
+		return
+	}
+
+	inputs := parsed.UserParameters
+	if inputs == nil {
+		inputs = []services.WorkflowInput{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"workflow_id": workflowID,
+		"inputs":      inputs,
+	})
+}
+
+// GetLastWorkflowParameters returns the parameters last submitted to execute a stored workflow,
+// with sensitive values redacted, so a re-run can be prefilled
+func (h *Handler) GetLastWorkflowParameters(c *gin.Context) {
+	user, exists := c.Get("user")
+	if !exists {
+		respondError(c, types.ErrorCodeUnauthorized, "User not found in context", "")
+		return
+	}
+
+	userObj := user.(*types.User)
+	workflowID := c.Param("id")
+
+	history := h.executionEngine.ParameterHistory()
+	if history == nil {
+		respondError(c, types.ErrorCodeInternal, "Parameter history is not configured", "")
+		return
+	}
+
+	lastParameters, err := history.Get(userObj.ID, workflowID)
+	if err != nil {
+		respondError(c, types.ErrorCodeNotFound, "No previous execution parameters", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"last_parameters": lastParameters,
+	})
+}
+
+// GetWorkflowGraph returns the step DAG of a stored workflow for rendering: steps as nodes,
+// depends_on and ${steps.*} references as edges, with any cycles flagged
+func (h *Handler) GetWorkflowGraph(c *gin.Context) {
+	workflowID := c.Param("id")
+
+	user, exists := c.Get("user")
+	if !exists {
+		respondError(c, types.ErrorCodeUnauthorized, "User not found in context", "")
+		return
+	}
+
+	userObj := user.(*types.User)
+
+	workflow, err := h.workflowStorage.GetWorkflow(userObj.ID, workflowID)
+	if err != nil {
+		log.Printf("[API] Failed to load workflow %s for graph: %v", workflowID, err)
+		respondError(c, types.ErrorCodeNotFound, "Workflow not found", "")
+		return
+	}
+
+	graph, err := h.executionEngine.BuildWorkflowGraph(workflow.Content)
+	if err != nil {
+		log.Printf("[API] ERROR: Failed to build graph for workflow %s: %v", workflowID, err)
+		respondError(c, types.ErrorCodeValidation, "Failed to parse workflow", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"workflow_id": workflowID,
+		"graph":       graph,
+	})
+}
+
+// LintWorkflow checks a stored workflow's step graph for orphan steps, unconsumed outputs and
+// disconnected subgraphs. Findings are warnings, or errors with ?strict=true;
+// ?allow_disconnected=true accepts independent subgraphs.
+func (h *Handler) LintWorkflow(c *gin.Context) {
+	workflowID := c.Param("id")
+	strict := c.Query("strict") == "true"
+	allowDisconnected := c.Query("allow_disconnected") == "true"
+
+	user, exists := c.Get("user")
+	if !exists {
+		respondError(c, types.ErrorCodeUnauthorized, "User not found in context", "")
+		return
+	}
+
+	userObj := user.(*types.User)
+
+	workflow, err := h.workflowStorage.GetWorkflow(userObj.ID, workflowID)
+	if err != nil {
+		log.Printf("[API] Failed to load workflow %s for lint: %v", workflowID, err)
+		respondError(c, types.ErrorCodeNotFound, "Workflow not found", "")
+		return
+	}
+
+	report, err := h.executionEngine.LintWorkflow(workflow.Content, strict, allowDisconnected)
+	if err != nil {
+		log.Printf("[API] ERROR: Failed to lint workflow %s: %v", workflowID, err)
+		respondError(c, types.ErrorCodeValidation, "Failed to parse workflow", err.Error())
+		return
+	}
+
+	status := http.StatusOK
+	if !report.Valid {
+		status = http.StatusBadRequest
+	}
+	c.JSON(status, gin.H{
+		"workflow_id": workflowID,
+		"lint":        report,
+	})
+}
+
+// GetServiceActionSchema returns the catalog schema of one service action, with the allowed
+// values of its enum input fields for autocompletion; ?provider= picks the provider
+func (h *Handler) GetServiceActionSchema(c *gin.Context) {
+	serviceName := c.Param("service")
+	action := c.Param("action")
+
+	catalog, err := h.mcpService.GetServiceCatalog()
+	if err != nil {
+		respondError(c, errorCodeFor(err, types.ErrorCodeUpstream), "Failed to get service catalog", err.Error())
+		return
+	}
+	provider, serviceDefinition, err := catalog.ResolveService(c.Query("provider"), serviceName)
+	if err != nil {
+		respondError(c, types.ErrorCodeNotFound, "Service not found", err.Error())
+		return
+	}
+	functionSchema, exists := serviceDefinition.Functions[action]
+	if !exists {
+		respondError(c, types.ErrorCodeNotFound, "Action not found", fmt.Sprintf("service %s has no action %s", serviceName, action))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"provider":     provider,
+		"service":      serviceName,
+		"action":       action,
+		"schema":       functionSchema,
+		"enum_options": services.EnumInputOptions(functionSchema),
+	})
+}
+
+// GetWorkflowSchema returns the JSON Schema of the workflow JSON format, for validating
+// hand-authored workflows before they are submitted
+func (h *Handler) GetWorkflowSchema(c *gin.Context) {
+	c.JSON(http.StatusOK, services.WorkflowJSONSchema())
+}
+
 // TestCompleteWorkflowPipeline tests the complete end-to-end workflow pipeline
 func (h *Handler) TestCompleteWorkflowPipeline(c *gin.Context) {
 	start := time.Now()
@@ -666,14 +1947,14 @@ func (h *Handler) TestCompleteWorkflowPipeline(c *gin.Context) {
 	userInterface, exists := c.Get("user")
 	if !exists {
 		log.Printf("[API] ERROR: No user found in context")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		respondError(c, types.ErrorCodeUnauthorized, "User not authenticated", "")
 		return
 	}
 	
 	user, ok := userInterface.(*types.User)
 	if !ok {
 		log.Printf("[API] ERROR: Invalid user type in context")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user data"})
+		respondError(c, types.ErrorCodeInternal, "Invalid user data", "")
 		return
 	}
 	
@@ -681,12 +1962,18 @@ func (h *Handler) TestCompleteWorkflowPipeline(c *gin.Context) {
 	token, exists := c.Get("token")
 	if !exists {
 		log.Printf("[API] ERROR: No OAuth token found in context")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "OAuth token not found"})
+		respondError(c, types.ErrorCodeUnauthorized, "OAuth token not found", "")
 		return
 	}
 	
 	tokenStr := token.(string)
 	
+	// ?mock=true skips the LLM agents and prepares a canned workflow against a fake MCP client
+	if c.Query("mock") == "true" {
+		h.testMockWorkflowPipeline(c, user, tokenStr, start)
+		return
+	}
+	
 	// Create test workflow intent
 	testIntent := &types.WorkflowIntent{
 		WorkflowPattern: "Send weekly reports to my team every Friday",
@@ -714,9 +2001,11 @@ func (h *Handler) TestCompleteWorkflowPipeline(c *gin.Context) {
 	intentResponse, err := h.agentManager.AnalyzeIntent(user.ID, testIntent, user)
 	if err != nil {
 		log.Printf("[API] ERROR: Intent analysis failed: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Intent analysis failed",
-			"details": err.Error(),
+		respondErrorWith(c, types.ErrorResponse{
+			Code:    types.ErrorCodeInternal,
+			Message: "Intent analysis failed",
+			Details: err.Error(),
+		}, gin.H{
 			"phase": "intent_analysis",
 		})
 		return
@@ -724,9 +2013,11 @@ func (h *Handler) TestCompleteWorkflowPipeline(c *gin.Context) {
 	
 	if intentResponse.Error != "" {
 		log.Printf("[API] WARNING: Intent analysis returned error: %s", intentResponse.Error)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Intent analysis error",
-			"details": intentResponse.Error,
+		respondErrorWith(c, types.ErrorResponse{
+			Code:    types.ErrorCodeUpstream,
+			Message: "Intent analysis error",
+			Details: intentResponse.Error,
+		}, gin.H{
 			"phase": "intent_analysis",
 		})
 		return
@@ -736,13 +2027,15 @@ func (h *Handler) TestCompleteWorkflowPipeline(c *gin.Context) {
 	
 	// Phase 2: Test Workflow Generation
 	log.Printf("[API] Phase 2: Testing Workflow Generation")
-	workflowResponse, err := h.agentManager.GenerateWorkflow(user.ID, testIntent.WorkflowPattern, intentResponse.Output, user)
+	workflowResponse, err := h.agentManager.GenerateWorkflow(user.ID, testIntent.WorkflowPattern, intentResponse.Output.ToMap(), user)
 	if err != nil {
 		log.Printf("[API] ERROR: Workflow generation failed: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Workflow generation failed",
-			"details": err.Error(),
-			"phase": "workflow_generation",
+		respondErrorWith(c, types.ErrorResponse{
+			Code:    types.ErrorCodeInternal,
+			Message: "Workflow generation failed",
+			Details: err.Error(),
+		}, gin.H{
+			"phase":           "workflow_generation",
 			"intent_analysis": intentResponse.Output,
 		})
 		return
@@ -750,10 +2043,12 @@ func (h *Handler) TestCompleteWorkflowPipeline(c *gin.Context) {
 	
 	if workflowResponse.Error != "" {
 		log.Printf("[API] WARNING: Workflow generation returned error: %s", workflowResponse.Error)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Workflow generation error",
-			"details": workflowResponse.Error,
-			"phase": "workflow_generation",
+		respondErrorWith(c, types.ErrorResponse{
+			Code:    types.ErrorCodeUpstream,
+			Message: "Workflow generation error",
+			Details: workflowResponse.Error,
+		}, gin.H{
+			"phase":           "workflow_generation",
 			"intent_analysis": intentResponse.Output,
 		})
 		return
@@ -764,25 +2059,24 @@ func (h *Handler) TestCompleteWorkflowPipeline(c *gin.Context) {
 	// Phase 3: Test Execution Engine Preparation
 	log.Printf("[API] Phase 3: Testing Execution Engine")
 	var cueworkflow string
-	if workflowCue, ok := workflowResponse.Output["workflow_cue"].(string); ok && workflowCue != "" {
-		cueworkflow = workflowCue
+	if workflowResponse.Output != nil && workflowResponse.Output.WorkflowCUE != "" {
+		cueworkflow = workflowResponse.Output.WorkflowCUE
 	} else {
 		log.Printf("[API] ERROR: No workflow_cue generated - workflow generation failed")
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Workflow generation failed - no CUE content produced",
-			"details": "The workflow generator must produce valid CUE content using live MCP catalog services",
-		})
+		respondError(c, types.ErrorCodeInternal, "Workflow generation failed - no CUE content produced", "The workflow generator must produce valid CUE content using live MCP catalog services")
 		return
 	}
 	
-	executionPlan, err := h.executionEngine.PrepareExecution(cueworkflow, user.ID, user, intentResponse.Output, tokenStr, "")
+	executionPlan, err := h.executionEngine.PrepareExecution(cueworkflow, user.ID, user, intentResponse.Output.ToMap(), tokenStr, "")
 	if err != nil {
 		log.Printf("[API] ERROR: Execution preparation failed: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Execution preparation failed",
-			"details": err.Error(),
-			"phase": "execution_preparation",
-			"intent_analysis": intentResponse.Output,
+		respondErrorWith(c, types.ErrorResponse{
+			Code:    types.ErrorCodeInternal,
+			Message: "Execution preparation failed",
+			Details: err.Error(),
+		}, gin.H{
+			"phase":               "execution_preparation",
+			"intent_analysis":     intentResponse.Output,
 			"workflow_generation": workflowResponse.Output,
 		})
 		return
@@ -821,9 +2115,68 @@ func (h *Handler) TestCompleteWorkflowPipeline(c *gin.Context) {
 	})
 }
 
-// ValidateServiceCatalog validates the service catalog integrity
+// testMockWorkflowPipeline prepares a canned validated intent and workflow with a fake MCP
+// client, exercising parameter resolution and validation without LLM or MCP access
+func (h *Handler) testMockWorkflowPipeline(c *gin.Context, user *types.User, token string, start time.Time) {
+	log.Printf("[API] Testing mock pipeline for user %s", user.ID)
+
+	intent := services.MockPipelineIntent()
+	engine := services.NewExecutionEngine(services.NewFakeMCPService(services.MockPipelineCatalog()))
+	executionPlan, err := engine.PrepareExecution(services.MockPipelineWorkflowCUE, user.ID, user, intent, token, "")
+	if err != nil {
+		log.Printf("[API] ERROR: Mock execution preparation failed: %v", err)
+		respondErrorWith(c, types.ErrorResponse{
+			Code:    types.ErrorCodeInternal,
+			Message: "Execution preparation failed",
+			Details: err.Error(),
+		}, gin.H{
+			"phase": "execution_preparation",
+			"mock":  true,
+		})
+		return
+	}
+
+	valid := len(executionPlan.ValidationErrors) == 0 && len(executionPlan.ParameterErrors) == 0
+	status := "success"
+	if !valid {
+		status = "failed"
+	}
+	duration := time.Since(start)
+	log.Printf("[API] MOCK PIPELINE COMPLETE: valid=%v in %v", valid, duration)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":     valid,
+		"mock":        true,
+		"message":     "Mock workflow pipeline test completed",
+		"duration_ms": duration.Milliseconds(),
+		"phases": gin.H{
+			"intent_analysis": gin.H{
+				"status": "mocked",
+				"output": intent,
+			},
+			"workflow_generation": gin.H{
+				"status":       "mocked",
+				"workflow_cue": services.MockPipelineWorkflowCUE,
+			},
+			"execution_preparation": gin.H{
+				"status":              status,
+				"workflow_id":         executionPlan.WorkflowID,
+				"steps_count":         len(executionPlan.ResolvedSteps),
+				"resolved_steps":      executionPlan.ResolvedSteps,
+				"validation_errors":   executionPlan.ValidationErrors,
+				"parameter_errors":    executionPlan.ParameterErrors,
+				"validation_warnings": executionPlan.ValidationWarnings,
+			},
+		},
+		"user_id": user.ID,
+	})
+}
+
+// ValidateServiceCatalog validates the service catalog integrity. Functions without an
+// output schema are reported as warnings, or as errors with ?strict=true.
 func (h *Handler) ValidateServiceCatalog(c *gin.Context) {
-	log.Printf("[API] Starting service catalog validation")
+	strict := c.Query("strict") == "true"
+	log.Printf("[API] Starting service catalog validation (strict: %t)", strict)
 	
 	serviceCatalog := h.agentManager.GetServiceCatalog()
 	serviceSchemas := h.agentManager.GetServiceSchemas()
@@ -834,9 +2187,12 @@ func (h *Handler) ValidateServiceCatalog(c *gin.Context) {
 		"services_count": len(serviceCatalog.Services),
 		"services": gin.H{},
 		"validation_errors": []string{},
+		"validation_warnings": []string{},
+		"strict": strict,
 	}
 	
 	var validationErrors []string
+	var validationWarnings []string
 	
 	// Test each service in the catalog
 	for serviceName, serviceSchema := range serviceSchemas {
@@ -878,11 +2234,24 @@ func (h *Handler) ValidateServiceCatalog(c *gin.Context) {
 	mcpServices, err := h.mcpService.GetServiceCatalog()
 	if err != nil {
 		validationErrors = append(validationErrors, "Failed to query MCP service catalog")
-	} else if len(mcpServices.Providers.Workspace.Services) == 0 {
+	} else if len(mcpServices.AllServices()) == 0 {
 		validationErrors = append(validationErrors, "No services available in MCP catalog")
+	} else {
+		// References to outputs of these functions pass validation unchecked
+		missingOutputSchemas := mcpServices.FunctionsWithoutOutputSchema()
+		for _, function := range missingOutputSchemas {
+			message := fmt.Sprintf("Function %s has no output schema", function)
+			if strict {
+				validationErrors = append(validationErrors, message)
+			} else {
+				validationWarnings = append(validationWarnings, message)
+			}
+		}
+		validationResults["missing_output_schemas"] = len(missingOutputSchemas)
 	}
 	
 	validationResults["validation_errors"] = validationErrors
+	validationResults["validation_warnings"] = validationWarnings
 	validationResults["catalog_valid"] = len(validationErrors) == 0
 	
 	status := http.StatusOK
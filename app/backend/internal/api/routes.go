@@ -4,8 +4,10 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// SetupRoutes configures all API routes for the SOHOAAS backend
-func SetupRoutes(router *gin.Engine, handler *Handler, authMiddleware gin.HandlerFunc) {
+// SetupRoutes configures all API routes for the SOHOAAS backend; internalAuthMiddleware
+// guards service-to-service endpoints called by the MCP backend and adminMiddleware the
+// administration endpoints of authenticated users
+func SetupRoutes(router *gin.Engine, handler *Handler, authMiddleware gin.HandlerFunc, internalAuthMiddleware gin.HandlerFunc, adminMiddleware gin.HandlerFunc) {
 	// Health check endpoint (no auth required)
 	router.GET("/health", handler.HealthCheck)
 	
@@ -18,6 +20,13 @@ func SetupRoutes(router *gin.Engine, handler *Handler, authMiddleware gin.Handle
 			public.GET("/health", handler.HealthCheck)
 		}
 		
+		// Internal routes (shared internal token, no user)
+		internal := v1.Group("/")
+		internal.Use(internalAuthMiddleware)
+		{
+			internal.POST("/catalog/invalidate", handler.InvalidateCatalog)
+		}
+		
 		// Protected routes (auth required)
 		protected := v1.Group("/")
 		protected.Use(authMiddleware)
@@ -25,6 +34,7 @@ func SetupRoutes(router *gin.Engine, handler *Handler, authMiddleware gin.Handle
 			// Token management endpoints
 			protected.POST("/auth/store-google-token", handler.StoreGoogleToken)
 			protected.GET("/auth/token-info", handler.GetTokenInfo)
+			protected.GET("/auth/connections", handler.GetConnections)
 			
 			// Agent management
 			protected.GET("/agents", handler.GetAgents)
@@ -41,17 +51,53 @@ func SetupRoutes(router *gin.Engine, handler *Handler, authMiddleware gin.Handle
 			
 			// Workflow generation
 			protected.POST("/workflow/generate", handler.GenerateWorkflow)
+			protected.POST("/workflow/suggest-next-steps", handler.SuggestNextSteps)
 			
 			// Workflow execution
 			protected.POST("/workflow/execute", handler.ExecuteWorkflow)
+			protected.POST("/workflow/execute-inline", handler.ExecuteInlineWorkflow)
+			protected.POST("/workflow/execute/confirm", handler.ConfirmWorkflowExecution)
+			protected.POST("/workflow/estimate", handler.EstimateWorkflow)
+			protected.POST("/workflow/simulate", handler.SimulateWorkflow)
+			protected.POST("/workflow/preview", handler.PreviewWorkflowStep)
+			
+			// Failed executions (dead-letter), replay and cancellation
+			protected.GET("/executions/failed", handler.GetFailedExecutions)
+			protected.POST("/executions/:id/replay", handler.ReplayExecution)
+			protected.POST("/executions/:id/cancel", handler.CancelExecution)
+			
+			// Audit log of executions and their side effects
+			protected.GET("/audit", handler.GetAuditLog)
 			
 			// Workflow management
 			protected.GET("/workflows", handler.GetUserWorkflows)
+			protected.POST("/workflows", handler.CreateWorkflow)
 			protected.GET("/workflows/:id", handler.GetWorkflow)
+			protected.PATCH("/workflows/:id", handler.UpdateWorkflowLabels)
+			protected.GET("/workflows/:id/inputs", handler.GetWorkflowInputs)
+			protected.GET("/workflows/:id/last-parameters", handler.GetLastWorkflowParameters)
+			protected.GET("/workflows/:id/graph", handler.GetWorkflowGraph)
+			protected.GET("/workflows/:id/lint", handler.LintWorkflow)
 			protected.DELETE("/workflows/:id", handler.DeleteWorkflow)
+			protected.GET("/schema/workflow", handler.GetWorkflowSchema)
 			
 			// User services
 			protected.GET("/services", handler.GetUserServices)
+			protected.GET("/services/:service/:action", handler.GetServiceActionSchema)
+			
+			// User preferences
+			protected.GET("/user/preferences", handler.GetUserPreferences)
+			protected.PUT("/user/preferences", handler.UpdateUserPreferences)
+			protected.GET("/user/profile", handler.GetUserProfile)
+			protected.PUT("/user/profile", handler.UpdateUserProfile)
+			
+			// Administration (admin users only)
+			admin := protected.Group("/admin")
+			admin.Use(adminMiddleware)
+			{
+				admin.POST("/prompts/reload", handler.ReloadPrompts)
+				admin.POST("/rac/reload", handler.ReloadRaCContexts)
+			}
 			
 			// Testing and validation
 			protected.POST("/test/pipeline", handler.TestCompleteWorkflowPipeline)
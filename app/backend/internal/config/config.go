@@ -2,6 +2,9 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Config holds all configuration for the SOHOAAS backend
@@ -10,10 +13,18 @@ type Config struct {
 	Environment  string
 	LogLevel     string
 	WorkflowsDir string
-	OpenAI       OpenAIConfig
-	MCP          MCPConfig
-	OAuth2       OAuth2Config
-	Genkit       GenkitConfig
+
+	// Field names masked in step input/output logs, in addition to token, password and secret keys
+	LogRedactFields []string
+
+	OpenAI    OpenAIConfig
+	MCP       MCPConfig
+	OAuth2    OAuth2Config
+	Genkit    GenkitConfig
+	Execution ExecutionConfig
+	Limits    LimitsConfig
+	Webhook   WebhookConfig
+	Auth      AuthConfig
 }
 
 // OpenAIConfig holds OpenAI-specific configuration
@@ -23,41 +34,164 @@ type OpenAIConfig struct {
 
 // MCPConfig holds MCP service configuration
 type MCPConfig struct {
-	BaseURL      string
-	AuthEndpoint string
+	BaseURL           string
+	AuthEndpoint      string
+	StaticCatalogPath string            // fallback catalog when the MCP backend is unreachable
+	RequestTimeout    time.Duration     // bound of each catalog and action request
+	AllowedServices   []string          // services workflows may use; empty allows the whole catalog
+	RetryAttempts     int               // attempts per catalog request or safe action call; 1 disables retries
+	RetryBaseDelay    time.Duration     // delay before the first retry, doubled for each further one
+	RetryMaxDelay     time.Duration     // cap of the delay between attempts
+	RetryJitter       float64           // fraction (0-1) of each delay that is randomized
+	ProviderURLs      map[string]string // MCP endpoints of catalog providers served elsewhere, e.g. a staging proxy
+	CatalogCacheTTL   time.Duration     // how long a fetched catalog is reused; 0 queries MCP for every validation
 }
 
 // OAuth2Config holds OAuth2 configuration
 type OAuth2Config struct {
 	GoogleClientID     string
 	GoogleClientSecret string
+	Scopes             []string // OAuth scopes workflows may request; empty allows any scope
+	TokenStorePath     string   // JSON file users' Google tokens are persisted to; empty keeps them in memory
 }
 
 // GenkitConfig holds Genkit-specific configuration
 type GenkitConfig struct {
-	Environment string
+	Environment       string
+	IntentGatherer    GenerationConfig
+	IntentAnalyst     GenerationConfig
+	WorkflowGenerator GenerationConfig
+
+	// Generated CUE workflows
+	CUEPackageName      string // package clause of generated workflows
+	CUESchemaImportPath string // CUE module path the workflow schema is imported from; empty embeds the schema
+
+	SynthesizeUserParameters bool // declare ${user.*} references missing from generated user_parameters
+}
+
+// GenerationConfig holds model settings for a single Genkit flow
+type GenerationConfig struct {
+	Temperature     float64
+	MaxOutputTokens int
+}
+
+// ExecutionConfig holds workflow execution limits
+type ExecutionConfig struct {
+	MaxConcurrentPerUser int           // executions running at once per user
+	MaxQueuedPerUser     int           // executions waiting for a slot before new ones are rejected
+	MaxBinaryOutputBytes int           // largest decoded base64 output a step may pass to later steps
+	DefaultTimeout       time.Duration // runtime budget of workflows without execution_config.timeout
+	WarnOnEmptyOutputs   bool          // warn instead of failing when a step returns none of its declared outputs
+	SecretsEnvPrefix     string        // prefix of the environment variables holding service binding secrets
+	StepCacheTTL         time.Duration // how long outputs of cacheable read steps are reused
+	AllowInlineWorkflows bool          // execute posted CUE without saving it; debugging only, never in production
+	AuditLogPath         string        // JSON Lines file the audit log is appended to; empty keeps it in memory
+	RequireConnected     bool          // reject runs whose services the user's tokens don't grant scopes for
+	ParameterKey         string        // secret encrypting sensitive parameters of failed executions; empty uses a random key
+
+	// Policies for workflows tagged with execution_config.environment
+	Environments map[string]EnvironmentPolicyConfig
+}
+
+// EnvironmentPolicyConfig holds the execution policy of one environment (development, staging, production)
+type EnvironmentPolicyConfig struct {
+	ConfirmDestructiveSteps bool // require approval for delete-style steps
+	SandboxWrites           bool // record write steps instead of sending them
+	MaxConcurrentPerUser    int  // 0 uses the default execution limits
+	MaxQueuedPerUser        int
+}
+
+// LimitsConfig holds request size limits applied to API handlers
+type LimitsConfig struct {
+	MaxBodyBytes int64 // largest accepted request body
+	MaxJSONDepth int   // deepest accepted JSON nesting
+}
+
+// WebhookConfig holds completion callback settings
+type WebhookConfig struct {
+	Secret       string   // HMAC key for signing callback payloads; callbacks are disabled when empty
+	AllowedHosts []string // callback hosts allowed even though they resolve to internal addresses
+}
+
+// AuthConfig holds authentication settings besides Firebase
+type AuthConfig struct {
+	ServiceAccountKeys map[string]string // service account name -> API key; API key auth is disabled when empty
+	InternalToken      string            // shared token of service-to-service endpoints; they are disabled when empty
+	AdminUsers         []string          // user IDs or emails allowed to call admin endpoints; they are disabled when empty
 }
 
 // New creates a new configuration instance from environment variables
 func New() *Config {
 	return &Config{
-		Port:         getEnv("PORT", "8080"),
-		Environment:  getEnv("ENVIRONMENT", "development"),
-		LogLevel:     getEnv("LOG_LEVEL", "info"),
-		WorkflowsDir: getEnv("ARTIFACT_OUTPUT_DIR", "./generated_workflows"),
+		Port:            getEnv("PORT", "8080"),
+		Environment:     getEnv("ENVIRONMENT", "development"),
+		LogLevel:        getEnv("LOG_LEVEL", "info"),
+		WorkflowsDir:    getEnv("ARTIFACT_OUTPUT_DIR", "./generated_workflows"),
+		LogRedactFields: getEnvList("LOG_REDACT_FIELDS"),
 		OpenAI: OpenAIConfig{
 			APIKey: getEnv("OPENAI_API_KEY", ""),
 		},
 		MCP: MCPConfig{
-			BaseURL:      getEnv("MCP_SERVICE_URL", "http://localhost:3000"),
-			AuthEndpoint: getEnv("MCP_AUTH_ENDPOINT", "/api/auth/token"),
+			BaseURL:           getEnv("MCP_SERVICE_URL", "http://localhost:3000"),
+			AuthEndpoint:      getEnv("MCP_AUTH_ENDPOINT", "/api/auth/token"),
+			StaticCatalogPath: getEnv("MCP_STATIC_CATALOG_PATH", ""),
+			RequestTimeout:    time.Duration(getEnvInt("MCP_TIMEOUT_SECONDS", 30)) * time.Second,
+			AllowedServices:   getEnvList("MCP_ALLOWED_SERVICES"),
+			RetryAttempts:     getEnvInt("MCP_RETRY_ATTEMPTS", 3),
+			RetryBaseDelay:    getEnvDuration("MCP_RETRY_BASE_DELAY", 250*time.Millisecond),
+			RetryMaxDelay:     getEnvDuration("MCP_RETRY_MAX_DELAY", 2*time.Second),
+			RetryJitter:       getEnvFloat("MCP_RETRY_JITTER", 0.2),
+			ProviderURLs:      getEnvPairs("MCP_PROVIDER_URLS"),
+			CatalogCacheTTL:   getEnvDuration("MCP_CATALOG_CACHE_TTL", 0),
 		},
 		OAuth2: OAuth2Config{
 			GoogleClientID:     getEnv("GOOGLE_CLIENT_ID", ""),
 			GoogleClientSecret: getEnv("GOOGLE_CLIENT_SECRET", ""),
+			Scopes:             getEnvList("OAUTH_SCOPES"),
+			TokenStorePath:     getEnv("TOKEN_STORE_PATH", ""),
 		},
 		Genkit: GenkitConfig{
 			Environment: getEnv("GENKIT_ENV", "dev"),
+			// Defaults match the front matter of the corresponding prompt files
+			IntentGatherer:    getGenerationConfig("GENKIT_INTENT_GATHERER", 0.3, 800),
+			IntentAnalyst:     getGenerationConfig("GENKIT_INTENT_ANALYST", 0.1, 600),
+			WorkflowGenerator: getGenerationConfig("GENKIT_WORKFLOW_GENERATOR", 0.1, 1500),
+
+			CUEPackageName:      getEnv("CUE_PACKAGE_NAME", "workflow"),
+			CUESchemaImportPath: getEnv("CUE_SCHEMA_IMPORT_PATH", ""),
+
+			SynthesizeUserParameters: getEnvBool("GENKIT_SYNTHESIZE_USER_PARAMETERS", true),
+		},
+		Execution: ExecutionConfig{
+			MaxConcurrentPerUser: getEnvInt("EXECUTION_MAX_CONCURRENT_PER_USER", 3),
+			MaxQueuedPerUser:     getEnvInt("EXECUTION_MAX_QUEUED_PER_USER", 10),
+			MaxBinaryOutputBytes: getEnvInt("EXECUTION_MAX_BINARY_OUTPUT_BYTES", 25<<20),
+			DefaultTimeout:       getEnvDuration("EXECUTION_DEFAULT_TIMEOUT", 5*time.Minute),
+			WarnOnEmptyOutputs:   getEnvBool("EXECUTION_WARN_ON_EMPTY_OUTPUTS", false),
+			SecretsEnvPrefix:     getEnv("SECRETS_ENV_PREFIX", "SECRET_"),
+			StepCacheTTL:         getEnvDuration("EXECUTION_STEP_CACHE_TTL", 5*time.Minute),
+			AllowInlineWorkflows: getEnvBool("EXECUTION_ALLOW_INLINE_WORKFLOWS", false),
+			AuditLogPath:         getEnv("AUDIT_LOG_PATH", ""),
+			RequireConnected:     getEnvBool("EXECUTION_REQUIRE_CONNECTED_SERVICES", false),
+			ParameterKey:         getEnv("EXECUTION_PARAMETER_ENCRYPTION_KEY", ""),
+			Environments: map[string]EnvironmentPolicyConfig{
+				"development": getEnvironmentPolicy("DEVELOPMENT", false),
+				"staging":     getEnvironmentPolicy("STAGING", false),
+				"production":  getEnvironmentPolicy("PRODUCTION", true),
+			},
+		},
+		Limits: LimitsConfig{
+			MaxBodyBytes: int64(getEnvInt("MAX_REQUEST_BODY_BYTES", 1<<20)),
+			MaxJSONDepth: getEnvInt("MAX_JSON_DEPTH", 32),
+		},
+		Webhook: WebhookConfig{
+			Secret:       getEnv("WEBHOOK_SECRET", ""),
+			AllowedHosts: getEnvList("WEBHOOK_ALLOWED_HOSTS"),
+		},
+		Auth: AuthConfig{
+			ServiceAccountKeys: getEnvPairs("SERVICE_ACCOUNT_API_KEYS"),
+			InternalToken:      getEnv("INTERNAL_API_TOKEN", ""),
+			AdminUsers:         getEnvList("ADMIN_USERS"),
 		},
 	}
 }
@@ -69,3 +203,86 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvInt gets an integer environment variable with a default fallback
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+// getEnvBool gets a boolean environment variable with a default fallback
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+// getEnvDuration gets a duration environment variable (e.g. "5m") with a default fallback
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil && parsed > 0 {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+// getEnvPairs parses a comma-separated list of name:value pairs, skipping malformed entries
+func getEnvPairs(key string) map[string]string {
+	pairs := make(map[string]string)
+	for _, entry := range strings.Split(os.Getenv(key), ",") {
+		name, value, found := strings.Cut(strings.TrimSpace(entry), ":")
+		if found && name != "" && value != "" {
+			pairs[name] = value
+		}
+	}
+	return pairs
+}
+
+// getEnvList parses a comma-separated list, skipping empty entries
+func getEnvList(key string) []string {
+	var values []string
+	for _, entry := range strings.Split(os.Getenv(key), ",") {
+		if value := strings.TrimSpace(entry); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getEnvironmentPolicy reads EXECUTION_<ENV>_CONFIRM_DESTRUCTIVE, _SANDBOX_WRITES,
+// _MAX_CONCURRENT_PER_USER and _MAX_QUEUED_PER_USER
+func getEnvironmentPolicy(environment string, confirmDestructive bool) EnvironmentPolicyConfig {
+	prefix := "EXECUTION_" + environment
+	return EnvironmentPolicyConfig{
+		ConfirmDestructiveSteps: getEnvBool(prefix+"_CONFIRM_DESTRUCTIVE", confirmDestructive),
+		SandboxWrites:           getEnvBool(prefix+"_SANDBOX_WRITES", false),
+		MaxConcurrentPerUser:    getEnvInt(prefix+"_MAX_CONCURRENT_PER_USER", 0),
+		MaxQueuedPerUser:        getEnvInt(prefix+"_MAX_QUEUED_PER_USER", 0),
+	}
+}
+
+// getEnvFloat gets a float environment variable with a default fallback
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+// getGenerationConfig reads <prefix>_TEMPERATURE and <prefix>_MAX_TOKENS
+func getGenerationConfig(prefix string, temperature float64, maxOutputTokens int) GenerationConfig {
+	return GenerationConfig{
+		Temperature:     getEnvFloat(prefix+"_TEMPERATURE", temperature),
+		MaxOutputTokens: getEnvInt(prefix+"_MAX_TOKENS", maxOutputTokens),
+	}
+}
@@ -21,6 +21,9 @@ type AgentManager struct {
 	cachedMCPCatalog *types.MCPServiceCatalog // Strongly-typed MCP catalog cached from initialization
 	agents           map[string]*types.Agent
 	mu               sync.RWMutex
+
+	// Stored Google connections, used to gate analyzed intents on connected services; optional
+	tokenManager *services.TokenManager
 }
 
 // NewAgentManager creates a new Agent Manager instance
@@ -42,12 +45,17 @@ func NewAgentManager(genkitService *services.GenkitService, mcpService *services
 	return am
 }
 
+// SetTokenManager enables the check that an analyzed intent's required services are connected
+func (am *AgentManager) SetTokenManager(tokenManager *services.TokenManager) {
+	am.tokenManager = tokenManager
+}
+
 // loadServiceCatalogFromMCP loads the service catalog from MCP service (single source of truth)
 func (am *AgentManager) loadServiceCatalogFromMCP() {
 	log.Printf("[AgentManager] Loading service catalog from MCP...")
 
-	// Get strongly-typed MCP catalog
-	mcpCatalog, err := am.mcpService.GetServiceCatalog()
+	// Get strongly-typed MCP catalog, limited to the services this deployment allows
+	mcpCatalog, err := am.mcpService.GetAllowedServiceCatalog()
 	if err != nil {
 		log.Printf("[AgentManager] Warning: Failed to load MCP catalog: %v", err)
 		return
@@ -292,7 +300,7 @@ func (am *AgentManager) ValidateServices(serviceNames ...string) (bool, []string
 }
 
 // AnalyzeIntent analyzes and validates a workflow intent
-func (am *AgentManager) AnalyzeIntent(userID string, workflowIntent *types.WorkflowIntent, user *types.User) (*types.AgentResponse, error) {
+func (am *AgentManager) AnalyzeIntent(userID string, workflowIntent *types.WorkflowIntent, user *types.User) (*types.IntentAnalysisResponse, error) {
 	start := time.Now()
 	log.Printf("[AgentManager] Starting intent analysis for user %s", userID)
 
@@ -334,6 +342,7 @@ func (am *AgentManager) AnalyzeIntent(userID string, workflowIntent *types.Workf
 		"user_capabilities": userCapabilities,
 		"service_schemas":   serviceSchemas,
 		"oauth_tokens":      user.OAuthTokens,
+		"tenant_id":         user.TenantID,
 	}
 
 	// Execute Intent Analyst Agent
@@ -351,11 +360,40 @@ func (am *AgentManager) AnalyzeIntent(userID string, workflowIntent *types.Workf
 		log.Printf("[AgentManager] SUCCESS: Intent analysis completed for user %s in %v", userID, duration)
 	}
 
-	return response, nil
+	typed := &types.IntentAnalysisResponse{
+		AgentID:  response.AgentID,
+		Error:    response.Error,
+		Metadata: response.Metadata,
+	}
+	if response.Output != nil {
+		typed.Output = &types.IntentAnalysisOutput{}
+		if err := types.DecodeAgentOutput(response.Output, typed.Output); err != nil {
+			return nil, fmt.Errorf("intent analysis returned unexpected output: %w", err)
+		}
+
+		// Services outside the allowlist can't be used at all, whether connected or not
+		if disallowed := am.mcpService.DisallowedServices(typed.Output.RequiredServices); len(disallowed) > 0 && typed.Output.IsAutomationRequest {
+			log.Printf("[AgentManager] Intent for user %s requires services not allowed in this deployment: %v", userID, disallowed)
+			typed.Output.CanFulfill = false
+			typed.Output.NextAction = types.NextActionServicesNotAllowed
+			typed.Output.DisallowedServices = disallowed
+		} else if am.tokenManager != nil && typed.Output.IsAutomationRequest {
+			// Generation would fail on services the user hasn't connected, so ask for them first
+			if missing := am.tokenManager.MissingConnections(userID, catalog, typed.Output.RequiredServices); len(missing) > 0 {
+				log.Printf("[AgentManager] Intent for user %s requires services that are not connected: %v", userID, missing)
+				typed.Output.CanFulfill = false
+				typed.Output.NextAction = types.NextActionConnectServices
+				typed.Output.MissingConnections = missing
+				typed.Output.ConnectionsInMemory = !am.tokenManager.Persistent()
+			}
+		}
+	}
+
+	return typed, nil
 }
 
 // GenerateWorkflow generates a deterministic workflow from validated intent
-func (am *AgentManager) GenerateWorkflow(userID string, userInput string, validatedIntent map[string]interface{}, user *types.User) (*types.AgentResponse, error) {
+func (am *AgentManager) GenerateWorkflow(userID string, userInput string, validatedIntent map[string]interface{}, user *types.User) (*types.WorkflowGenerationResponse, error) {
 	start := time.Now()
 	log.Printf("[AgentManager] Starting workflow generation for user %s", userID)
 
@@ -375,11 +413,19 @@ func (am *AgentManager) GenerateWorkflow(userID string, userInput string, valida
 	if !ok {
 		log.Printf("[AgentManager] WARNING: no required_services found in validated intent for user %s", userID)
 	} else {
+		if disallowed := am.mcpService.DisallowedServices(requiredServices); len(disallowed) > 0 {
+			log.Printf("[AgentManager] ERROR: Required services not allowed for user %s: %v", userID, disallowed)
+			return &types.WorkflowGenerationResponse{
+				AgentID: "workflow_generator",
+				Error:   fmt.Sprintf("Required services not allowed in this deployment: %v (allowed: %v)", disallowed, am.mcpService.AllowedServices()),
+			}, nil
+		}
+
 		// Validate all required services are available
 		allAvailable, missingServices := am.ValidateServices(requiredServices...)
 		if !allAvailable {
 			log.Printf("[AgentManager] ERROR: Missing required services for user %s: %v", userID, missingServices)
-			return &types.AgentResponse{
+			return &types.WorkflowGenerationResponse{
 				AgentID: "workflow_generator",
 				Error:   fmt.Sprintf("Required services not available: %v", missingServices),
 			}, nil
@@ -415,6 +461,7 @@ func (am *AgentManager) GenerateWorkflow(userID string, userInput string, valida
 		"service_schemas":    serviceSchemas,
 		"available_services": availableServices,
 		"oauth_tokens":       user.OAuthTokens,
+		"tenant_id":          user.TenantID,
 	}
 
 	log.Printf("[AgentManager] Workflow generation available services input: %v", input["available_services"])
@@ -427,20 +474,30 @@ func (am *AgentManager) GenerateWorkflow(userID string, userInput string, valida
 		return nil, fmt.Errorf("workflow generation failed: %w", err)
 	}
 
-	if response.Error != "" {
-		log.Printf("[AgentManager] WARNING: Workflow generation returned error for user %s: %s", userID, response.Error)
+	typed := &types.WorkflowGenerationResponse{
+		AgentID:  response.AgentID,
+		Error:    response.Error,
+		Metadata: response.Metadata,
+	}
+	if response.Output != nil {
+		typed.Output = &types.WorkflowGenerationOutput{}
+		if err := types.DecodeAgentOutput(response.Output, typed.Output); err != nil {
+			return nil, fmt.Errorf("workflow generation returned unexpected output: %w", err)
+		}
+	}
+
+	if typed.Error != "" {
+		log.Printf("[AgentManager] WARNING: Workflow generation returned error for user %s: %s", userID, typed.Error)
 	} else {
 		log.Printf("[AgentManager] SUCCESS: Workflow generation completed for user %s in %v", userID, duration)
 
 		// Log workflow details if available
-		if response.Output != nil {
-			if workflowName, exists := response.Output["workflow_name"].(string); exists {
-				log.Printf("[AgentManager] Generated workflow: %s for user %s", workflowName, userID)
-			}
+		if typed.Output != nil && typed.Output.Name != "" {
+			log.Printf("[AgentManager] Generated workflow: %s for user %s", typed.Output.Name, userID)
 		}
 	}
 
-	return response, nil
+	return typed, nil
 }
 
 // buildAvailableServicesString creates a human-readable string of available services from catalog
@@ -453,8 +510,8 @@ func (am *AgentManager) buildAvailableServicesString(catalog *types.MCPServiceCa
 	var services []string
 
 	// Process strongly-typed catalog with parameter information
-	if catalog.Providers.Workspace.Services != nil {
-		for serviceName, serviceDefinition := range catalog.Providers.Workspace.Services {
+	if allServices := catalog.AllServices(); len(allServices) > 0 {
+		for serviceName, serviceDefinition := range allServices {
 			description := serviceName
 			if serviceDefinition.Description != "" {
 				description = fmt.Sprintf("%s: %s", serviceName, serviceDefinition.Description)
@@ -513,6 +570,24 @@ func (am *AgentManager) formatServicesString(services []string) string {
 	return strings.Join(services, "\n")
 }
 
+// SaveAuthoredWorkflow validates, converts and saves a hand-authored workflow JSON for a user
+func (am *AgentManager) SaveAuthoredWorkflow(userID string, workflowJSON map[string]interface{}) (*types.WorkflowFile, string, error) {
+	log.Printf("[AgentManager] Saving authored workflow for user %s", userID)
+	return am.genkitService.SaveAuthoredWorkflow(userID, workflowJSON)
+}
+
+// ReloadPrompts re-reads agent prompt files without restarting the service
+func (am *AgentManager) ReloadPrompts() []services.PromptReloadResult {
+	log.Printf("[AgentManager] Reloading agent prompts")
+	return am.genkitService.ReloadPrompts()
+}
+
+// ReloadRaCContexts re-validates and reloads the RaC context files used by the agents
+func (am *AgentManager) ReloadRaCContexts() error {
+	log.Printf("[AgentManager] Reloading RaC contexts")
+	return am.genkitService.LoadRaCContexts()
+}
+
 // GetAgents returns all registered agents
 func (am *AgentManager) GetAgents() map[string]*types.Agent {
 	am.mu.RLock()
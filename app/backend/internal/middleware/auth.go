@@ -1,59 +1,173 @@
 package middleware
 
 import (
+	"crypto/subtle"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"sohoaas-backend/internal/services"
+	"sohoaas-backend/internal/types"
 )
 
-// FirebaseAuthMiddleware validates Firebase ID tokens
-func FirebaseAuthMiddleware(firebaseAuth *services.FirebaseAuthService) gin.HandlerFunc {
+// APIKeyHeader carries a service account API key; requests that send it use API key auth
+const APIKeyHeader = "X-API-Key"
+
+// InternalTokenHeader carries the shared token of service-to-service calls, e.g. from the MCP backend
+const InternalTokenHeader = "X-Internal-Token"
+
+// Authenticator validates the credential of one auth scheme
+type Authenticator interface {
+	// Header is the request header carrying the scheme's credential
+	Header() string
+	// Authenticate validates the header value, returning the caller and the token stored for handlers
+	Authenticate(value string) (*types.User, string, *types.ErrorResponse)
+}
+
+// AuthMiddleware authenticates each request with the first authenticator whose header it
+// sends, so callers select the scheme by header. The last authenticator is the fallback
+// that reports a missing credential.
+func AuthMiddleware(authenticators ...Authenticator) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Extract token from Authorization header
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Authorization header required",
-			})
-			c.Abort()
+		if len(authenticators) == 0 {
+			abortWithError(c, types.ErrorCodeInternal, "No authentication scheme configured", "")
 			return
 		}
 
-		// Check if it's a Bearer token
-		if !strings.HasPrefix(authHeader, "Bearer ") {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Invalid authorization header format",
-			})
-			c.Abort()
-			return
+		authenticator := authenticators[len(authenticators)-1]
+		for _, candidate := range authenticators {
+			if c.GetHeader(candidate.Header()) != "" {
+				authenticator = candidate
+				break
+			}
 		}
 
-		// Extract the token
-		idToken := strings.TrimPrefix(authHeader, "Bearer ")
-		if idToken == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Token is required",
-			})
-			c.Abort()
+		value := c.GetHeader(authenticator.Header())
+		if value == "" {
+			abortWithError(c, types.ErrorCodeUnauthorized, authenticator.Header()+" header required", "")
 			return
 		}
 
-		// Validate Firebase ID token
-		user, err := firebaseAuth.ValidateIDToken(idToken)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Invalid or expired token",
-				"details": err.Error(),
-			})
-			c.Abort()
+		user, token, authErr := authenticator.Authenticate(value)
+		if authErr != nil {
+			abortWithError(c, authErr.Code, authErr.Message, authErr.Details)
 			return
 		}
 
 		// Store user and token in context for use by handlers
 		c.Set("user", user)
-		c.Set("token", idToken)
+		c.Set("token", token)
+		c.Next()
+	}
+}
+
+// FirebaseAuthMiddleware validates Firebase ID tokens
+func FirebaseAuthMiddleware(firebaseAuth *services.FirebaseAuthService) gin.HandlerFunc {
+	return AuthMiddleware(NewFirebaseAuthenticator(firebaseAuth))
+}
+
+// firebaseAuthenticator validates "Authorization: Bearer <Firebase ID token>"
+type firebaseAuthenticator struct {
+	firebaseAuth *services.FirebaseAuthService
+}
+
+// NewFirebaseAuthenticator creates the Firebase ID token auth scheme
+func NewFirebaseAuthenticator(firebaseAuth *services.FirebaseAuthService) Authenticator {
+	return &firebaseAuthenticator{firebaseAuth: firebaseAuth}
+}
+
+func (a *firebaseAuthenticator) Header() string {
+	return "Authorization"
+}
+
+func (a *firebaseAuthenticator) Authenticate(authHeader string) (*types.User, string, *types.ErrorResponse) {
+	// Check if it's a Bearer token
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		return nil, "", &types.ErrorResponse{Code: types.ErrorCodeUnauthorized, Message: "Invalid authorization header format"}
+	}
+
+	// Extract the token
+	idToken := strings.TrimPrefix(authHeader, "Bearer ")
+	if idToken == "" {
+		return nil, "", &types.ErrorResponse{Code: types.ErrorCodeUnauthorized, Message: "Token is required"}
+	}
+
+	// Validate Firebase ID token
+	user, err := a.firebaseAuth.ValidateIDToken(idToken)
+	if err != nil {
+		return nil, "", &types.ErrorResponse{Code: types.ErrorCodeUnauthorized, Message: "Invalid or expired token", Details: err.Error()}
+	}
+	return user, idToken, nil
+}
+
+// apiKeyAuthenticator validates service account keys sent in the X-API-Key header
+type apiKeyAuthenticator struct {
+	apiKeyAuth *services.APIKeyAuthService
+}
+
+// NewAPIKeyAuthenticator creates the service account API key auth scheme
+func NewAPIKeyAuthenticator(apiKeyAuth *services.APIKeyAuthService) Authenticator {
+	return &apiKeyAuthenticator{apiKeyAuth: apiKeyAuth}
+}
+
+func (a *apiKeyAuthenticator) Header() string {
+	return APIKeyHeader
+}
+
+func (a *apiKeyAuthenticator) Authenticate(key string) (*types.User, string, *types.ErrorResponse) {
+	user, err := a.apiKeyAuth.ValidateAPIKey(key)
+	if err != nil {
+		return nil, "", &types.ErrorResponse{Code: types.ErrorCodeUnauthorized, Message: "Invalid API key"}
+	}
+	return user, "", nil
+}
+
+// InternalAuth admits only requests sending the shared internal token. Without a configured
+// token internal endpoints are disabled and answer not found.
+func InternalAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			abortWithError(c, types.ErrorCodeNotFound, "Internal endpoints are disabled", "")
+			return
+		}
+		value := c.GetHeader(InternalTokenHeader)
+		if value == "" {
+			abortWithError(c, types.ErrorCodeUnauthorized, InternalTokenHeader+" header required", "")
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(value), []byte(token)) != 1 {
+			abortWithError(c, types.ErrorCodeUnauthorized, "Invalid internal token", "")
+			return
+		}
+		c.Next()
+	}
+}
+
+// AdminOnly admits only authenticated users whose ID or verified email is in admins; it runs
+// after AuthMiddleware. Without configured admins admin endpoints are disabled and answer not found.
+func AdminOnly(admins []string) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(admins))
+	for _, admin := range admins {
+		allowed[strings.ToLower(admin)] = true
+	}
+
+	return func(c *gin.Context) {
+		if len(allowed) == 0 {
+			abortWithError(c, types.ErrorCodeNotFound, "Admin endpoints are disabled", "")
+			return
+		}
+		value, exists := c.Get("user")
+		user, ok := value.(*types.User)
+		if !exists || !ok {
+			abortWithError(c, types.ErrorCodeUnauthorized, "User not found in context", "")
+			return
+		}
+		// An unverified email could have been registered by anyone, so it never grants admin access
+		adminEmail := user.Email != "" && user.EmailVerified && allowed[strings.ToLower(user.Email)]
+		if !allowed[strings.ToLower(user.ID)] && !adminEmail {
+			abortWithError(c, types.ErrorCodeForbidden, "Admin access required", "")
+			return
+		}
 		c.Next()
 	}
 }
@@ -62,8 +176,8 @@ func FirebaseAuthMiddleware(firebaseAuth *services.FirebaseAuthService) gin.Hand
 func CORS() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
+		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-API-Key")
 		c.Header("Access-Control-Expose-Headers", "Content-Length")
 		c.Header("Access-Control-Allow-Credentials", "true")
 
@@ -75,3 +189,14 @@ func CORS() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// abortWithError stops the chain with the standard error envelope
+func abortWithError(c *gin.Context, code, message, details string) {
+	c.AbortWithStatusJSON(types.HTTPStatusForErrorCode(code), gin.H{
+		"error": types.ErrorResponse{
+			Code:    code,
+			Message: message,
+			Details: details,
+		},
+	})
+}
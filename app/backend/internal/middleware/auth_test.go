@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"sohoaas-backend/internal/types"
+)
+
+// TestCORSAllowsRouteMethods verifies the preflight allows every method the API routes use
+func TestCORSAllowsRouteMethods(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(CORS())
+	router.PATCH("/workflows/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodOptions, "/workflows/wf_1", nil)
+	request.Header.Set("Access-Control-Request-Method", http.MethodPatch)
+	router.ServeHTTP(recorder, request)
+
+	if recorder.Code != http.StatusNoContent {
+		t.Errorf("Expected 204 for the preflight, got %d", recorder.Code)
+	}
+	allowed := recorder.Header().Get("Access-Control-Allow-Methods")
+	for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+		if !strings.Contains(allowed, method) {
+			t.Errorf("Expected %s in Access-Control-Allow-Methods, got %q", method, allowed)
+		}
+	}
+}
+
+// TestAdminOnly verifies admins are matched by ID, or by email only once it is verified
+func TestAdminOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		admins   []string
+		user     *types.User
+		expected int
+	}{
+		{"admin by ID", []string{"uid-admin"}, &types.User{ID: "uid-admin"}, http.StatusOK},
+		{"admin by verified email", []string{"Admin@Example.com"}, &types.User{ID: "uid-1", Email: "admin@example.com", EmailVerified: true}, http.StatusOK},
+		{"unverified email", []string{"admin@example.com"}, &types.User{ID: "uid-2", Email: "admin@example.com"}, http.StatusForbidden},
+		{"not an admin", []string{"uid-admin"}, &types.User{ID: "uid-3", Email: "user@example.com", EmailVerified: true}, http.StatusForbidden},
+		{"no admins configured", nil, &types.User{ID: "uid-admin"}, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(func(c *gin.Context) { c.Set("user", tt.user) }, AdminOnly(tt.admins))
+			router.GET("/admin", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/admin", nil))
+			if recorder.Code != tt.expected {
+				t.Errorf("Expected %d, got %d", tt.expected, recorder.Code)
+			}
+		})
+	}
+}
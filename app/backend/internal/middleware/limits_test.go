@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"sohoaas-backend/internal/types"
+)
+
+// newLimitsTestRouter routes POST /small and /large through BodyLimit, echoing the body it passes on;
+// /large has its own byte limit
+func newLimitsTestRouter(maxBytes int64, maxDepth int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(BodyLimit(maxBytes, maxDepth, map[string]int64{"/large": 4 * maxBytes}))
+	echo := func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, string(body))
+	}
+	router.POST("/small", echo)
+	router.POST("/large", echo)
+	return router
+}
+
+// TestBodyLimit verifies oversized and deeply nested bodies are rejected before the handler runs
+func TestBodyLimit(t *testing.T) {
+	router := newLimitsTestRouter(32, 3)
+
+	tests := []struct {
+		name       string
+		path       string
+		body       string
+		wantStatus int
+		wantCode   string
+	}{
+		{"within limits", "/small", `{"a":{"b":[1]}}`, http.StatusOK, ""},
+		{"at byte limit", "/small", strings.Repeat("x", 32), http.StatusOK, ""},
+		{"over byte limit", "/small", strings.Repeat("x", 33), http.StatusRequestEntityTooLarge, types.ErrorCodePayloadTooLarge},
+		{"route override", "/large", strings.Repeat("x", 100), http.StatusOK, ""},
+		{"over route override", "/large", strings.Repeat("x", 129), http.StatusRequestEntityTooLarge, types.ErrorCodePayloadTooLarge},
+		{"too deeply nested", "/small", `{"a":{"b":[[1]]}}`, http.StatusBadRequest, types.ErrorCodeInvalidRequest},
+		{"malformed JSON left to the handler", "/small", `{"a":`, http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body)))
+
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, recorder.Code, recorder.Body.String())
+			}
+			if tt.wantCode == "" {
+				if recorder.Body.String() != tt.body {
+					t.Errorf("Expected the handler to receive the full body, got %q", recorder.Body.String())
+				}
+				return
+			}
+			var response struct {
+				Error types.ErrorResponse `json:"error"`
+			}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil || response.Error.Code != tt.wantCode {
+				t.Errorf("Expected error code %s, got %s (%v)", tt.wantCode, recorder.Body.String(), err)
+			}
+		})
+	}
+}
+
+// TestJSONDepthExceeds verifies nesting is counted across objects and arrays
+func TestJSONDepthExceeds(t *testing.T) {
+	tests := []struct {
+		body     string
+		maxDepth int
+		want     bool
+	}{
+		{`{"a":1}`, 1, false},
+		{`{"a":{"b":1}}`, 1, true},
+		{`[[],[],[]]`, 2, false},
+		{`[[[]]]`, 2, true},
+		{`{"a":"{{{{"}`, 1, false},
+		{`not json`, 1, false},
+	}
+	for _, tt := range tests {
+		if got := jsonDepthExceeds([]byte(tt.body), tt.maxDepth); got != tt.want {
+			t.Errorf("jsonDepthExceeds(%s, %d) = %t, want %t", tt.body, tt.maxDepth, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package services
+
+import "testing"
+
+// TestAPIKeyAuthValidatesServiceAccountKeys verifies keys map to their service account's synthetic user
+func TestAPIKeyAuthValidatesServiceAccountKeys(t *testing.T) {
+	auth, err := NewAPIKeyAuthService(map[string]string{"scheduler": "sched-key", "ci": "ci-key"})
+	if err != nil {
+		t.Fatalf("Failed to create API key auth: %v", err)
+	}
+
+	user, err := auth.ValidateAPIKey("ci-key")
+	if err != nil {
+		t.Fatalf("Expected key to be accepted, got %v", err)
+	}
+	if user.ID != "service-account:ci" || user.Name != "ci" || !user.ServiceAccount {
+		t.Errorf("Unexpected service account user: %+v", user)
+	}
+
+	if _, err := auth.ValidateAPIKey("wrong-key"); err == nil {
+		t.Error("Expected unknown key to be rejected")
+	}
+	if _, err := auth.ValidateAPIKey(""); err == nil {
+		t.Error("Expected empty key to be rejected")
+	}
+}
+
+// TestAPIKeyAuthRejectsInvalidKeys verifies misconfigured keys fail at startup
+func TestAPIKeyAuthRejectsInvalidKeys(t *testing.T) {
+	if _, err := NewAPIKeyAuthService(map[string]string{"scheduler": " "}); err == nil {
+		t.Error("Expected empty key to be rejected")
+	}
+	if _, err := NewAPIKeyAuthService(map[string]string{"scheduler": "shared", "ci": "shared"}); err == nil {
+		t.Error("Expected shared key to be rejected")
+	}
+}
@@ -0,0 +1,282 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Audit entry events
+const (
+	AuditEventExecution = "execution" // one per run, with its outcome
+	AuditEventStep      = "step"      // one per step that ran, with its key parameters
+)
+
+// auditedParameters are the step inputs and outputs recorded in the audit log: who a side
+// effect reached and which resources it touched, never bodies, content or credentials
+var auditedParameters = map[string]bool{
+	"to": true, "cc": true, "bcc": true, "email": true, "recipients": true, "attendees": true,
+	"message_id": true, "thread_id": true, "document_id": true, "template_id": true,
+	"file_id": true, "folder_id": true, "permission_id": true, "role": true,
+	"event_id": true, "calendar_id": true,
+}
+
+// AuditEntry is one append-only record of who did what and when
+type AuditEntry struct {
+	Timestamp   time.Time              `json:"timestamp"`
+	Event       string                 `json:"event"` // AuditEventExecution or AuditEventStep
+	UserID      string                 `json:"user_id"`
+	WorkflowID  string                 `json:"workflow_id,omitempty"`
+	ExecutionID string                 `json:"execution_id"`
+	StepID      string                 `json:"step_id,omitempty"`
+	Action      string                 `json:"action,omitempty"` // e.g. gmail.send_message
+	Status      string                 `json:"status"`
+	Sandboxed   bool                   `json:"sandboxed,omitempty"` // recorded instead of sent
+	Simulated   bool                   `json:"simulated,omitempty"`
+	Parameters  map[string]interface{} `json:"parameters,omitempty"` // key non-sensitive inputs and outputs
+	Error       string                 `json:"error,omitempty"`
+}
+
+// AuditFilter selects audit entries; empty fields match everything
+type AuditFilter struct {
+	UserID string
+	From   time.Time // inclusive
+	To     time.Time // exclusive
+}
+
+// Matches reports whether entry passes the filter
+func (f AuditFilter) Matches(entry AuditEntry) bool {
+	if f.UserID != "" && entry.UserID != f.UserID {
+		return false
+	}
+	if !f.From.IsZero() && entry.Timestamp.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !entry.Timestamp.Before(f.To) {
+		return false
+	}
+	return true
+}
+
+// AuditSink stores audit entries. Entries are only ever appended, never changed or removed.
+type AuditSink interface {
+	Append(entry AuditEntry) error
+	Query(filter AuditFilter) ([]AuditEntry, error)
+}
+
+// MemoryAuditSink keeps audit entries in process; they are lost on restart
+type MemoryAuditSink struct {
+	entries []AuditEntry
+	mu      sync.RWMutex
+}
+
+// NewMemoryAuditSink creates an empty in-process audit sink
+func NewMemoryAuditSink() *MemoryAuditSink {
+	return &MemoryAuditSink{}
+}
+
+// Append records an entry
+func (s *MemoryAuditSink) Append(entry AuditEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, entry)
+	return nil
+}
+
+// Query returns the matching entries in the order they were appended
+func (s *MemoryAuditSink) Query(filter AuditFilter) ([]AuditEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	matching := []AuditEntry{}
+	for _, entry := range s.entries {
+		if filter.Matches(entry) {
+			matching = append(matching, entry)
+		}
+	}
+	return matching, nil
+}
+
+// FileAuditSink appends audit entries to a JSON Lines file opened in append-only mode
+type FileAuditSink struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileAuditSink creates a sink writing to path, creating the file if needed
+func NewFileAuditSink(path string) (*FileAuditSink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log %s: %w", path, err)
+	}
+	file.Close()
+	return &FileAuditSink{path: path}, nil
+}
+
+// Append writes entry as one JSON line
+func (s *FileAuditSink) Append(entry AuditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log %s: %w", s.path, err)
+	}
+	defer file.Close()
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log %s: %w", s.path, err)
+	}
+	return nil
+}
+
+// Query reads the file and returns the matching entries in the order they were written
+func (s *FileAuditSink) Query(filter AuditFilter) ([]AuditEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, err := os.Open(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log %s: %w", s.path, err)
+	}
+	defer file.Close()
+
+	matching := []AuditEntry{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("corrupt audit log %s: %w", s.path, err)
+		}
+		if filter.Matches(entry) {
+			matching = append(matching, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log %s: %w", s.path, err)
+	}
+	return matching, nil
+}
+
+// SetAuditSink enables the audit log of executions and their steps
+func (ee *ExecutionEngine) SetAuditSink(sink AuditSink) {
+	ee.auditSink = sink
+}
+
+// AuditSink returns the audit sink, or nil when auditing is not configured
+func (ee *ExecutionEngine) AuditSink() AuditSink {
+	return ee.auditSink
+}
+
+// auditScopeKey carries the execution that step audit entries belong to
+type auditScopeKey struct{}
+
+type auditScope struct {
+	executionID string
+	userID      string
+}
+
+// withAuditScope returns a context whose steps are audited under executionID and userID
+func withAuditScope(ctx context.Context, executionID, userID string) context.Context {
+	return context.WithValue(ctx, auditScopeKey{}, auditScope{executionID: executionID, userID: userID})
+}
+
+// auditWorkflowID is the stored workflow a plan's audit entries refer to
+func auditWorkflowID(plan *ExecutionPlan) string {
+	if plan.SourceWorkflowID != "" {
+		return plan.SourceWorkflowID
+	}
+	return plan.WorkflowID
+}
+
+// auditUserID is the user a plan was prepared for
+func auditUserID(plan *ExecutionPlan) string {
+	if plan.ParameterContext == nil {
+		return ""
+	}
+	userID, _ := plan.ParameterContext.SystemParameters["user_id"].(string)
+	return userID
+}
+
+// appendAudit writes entry, logging failures; auditing never fails the execution
+func (ee *ExecutionEngine) appendAudit(entry AuditEntry) {
+	if err := ee.auditSink.Append(entry); err != nil {
+		log.Printf("[ExecutionEngine] ERROR: Failed to write audit entry for execution %s: %v", entry.ExecutionID, err)
+	}
+}
+
+// auditStep appends the entry of a step right after it ran, with the inputs it was called
+// with. Steps run outside an audit scope are not recorded.
+func (ee *ExecutionEngine) auditStep(ctx context.Context, plan *ExecutionPlan, step *ResolvedStep, resolvedInputs map[string]interface{}) {
+	scope, ok := ctx.Value(auditScopeKey{}).(auditScope)
+	if ee.auditSink == nil || !ok {
+		return
+	}
+	ee.appendAudit(AuditEntry{
+		Timestamp:   time.Now().UTC(),
+		Event:       AuditEventStep,
+		UserID:      scope.userID,
+		WorkflowID:  auditWorkflowID(plan),
+		ExecutionID: scope.executionID,
+		StepID:      step.ID,
+		Action:      qualifiedAction(step.Service, step.Action),
+		Status:      string(step.Status),
+		Sandboxed:   step.Sandboxed,
+		Simulated:   plan.Simulate,
+		Parameters:  auditParameters(resolvedInputs, step.Outputs, plan.sensitiveValues()),
+	})
+}
+
+// recordAudit appends the entry of a finished execution, after the entries of its steps
+func (ee *ExecutionEngine) recordAudit(executionID, userID string, plan *ExecutionPlan, execErr error) {
+	if ee.auditSink == nil || plan == nil {
+		return
+	}
+
+	execution := AuditEntry{
+		Timestamp:   time.Now().UTC(),
+		Event:       AuditEventExecution,
+		UserID:      userID,
+		WorkflowID:  auditWorkflowID(plan),
+		ExecutionID: executionID,
+		Status:      "completed",
+		Simulated:   plan.Simulate,
+	}
+	if execErr != nil {
+		execution.Status = "failed"
+		execution.Error = plan.RedactError(execErr)
+	}
+	ee.appendAudit(execution)
+}
+
+// qualifiedAction returns service.action, leaving actions that already name their service as is
+func qualifiedAction(service, action string) string {
+	if service == "" || len(action) > len(service) && action[:len(service)+1] == service+"." {
+		return action
+	}
+	return service + "." + action
+}
+
+// auditParameters keeps the audited fields of a step's inputs and outputs, with the secrets
+// masked; outputs win on conflict
+func auditParameters(inputs, outputs map[string]interface{}, secrets []string) map[string]interface{} {
+	parameters := make(map[string]interface{})
+	for _, values := range []map[string]interface{}{inputs, outputs} {
+		for name, value := range values {
+			if auditedParameters[name] {
+				parameters[name] = maskSensitiveValues(value, secrets)
+			}
+		}
+	}
+	if len(parameters) == 0 {
+		return nil
+	}
+	return parameters
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newAuditTestServer returns an MCP server that completes every action except share_file,
+// pausing briefly so consecutive steps get distinct timestamps
+func newAuditTestServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`{"providers": {"workspace": {"services": {}}}}`))
+			return
+		}
+		var request struct {
+			Name string `json:"name"`
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		time.Sleep(5 * time.Millisecond)
+		if request.Name == "drive.share_file" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]interface{}{"error": "no such file"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"result": map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": `{"message_id": "msg_1"}`}}},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newAuditTestPlan returns a plan whose inputs reference user parameters, so the audit log
+// must record the values the steps were called with
+func newAuditTestPlan() *ExecutionPlan {
+	return &ExecutionPlan{
+		WorkflowID: "wf_1",
+		ResolvedSteps: []ResolvedStep{
+			{ID: "send", Service: "gmail", Action: "send_message",
+				Inputs:  map[string]interface{}{"to": "${user.recipient}", "subject": "Report", "body": "private"},
+				Outputs: map[string]interface{}{}},
+			{ID: "share", Service: "drive", Action: "share_file", DependsOn: []string{"send"},
+				Inputs:  map[string]interface{}{"file_id": "${user.file_id}", "email": "bob@example.com", "role": "reader"},
+				Outputs: map[string]interface{}{}},
+			{ID: "later", Service: "calendar", Action: "create_event", DependsOn: []string{"share"},
+				Inputs:  map[string]interface{}{},
+				Outputs: map[string]interface{}{}},
+		},
+		ParameterContext: &ParameterContext{
+			UserParameters:    map[string]interface{}{"recipient": "team@example.com", "file_id": "file_1"},
+			RuntimeParameters: map[string]interface{}{},
+			SystemParameters:  map[string]interface{}{"oauth_token": "token", "user_id": "user_1"},
+			StepOutputs:       map[string]interface{}{},
+		},
+	}
+}
+
+// TestRecordAudit verifies each step that ran is audited as it runs, with its resolved key
+// parameters and its own timestamp, followed by the execution
+func TestRecordAudit(t *testing.T) {
+	sink := NewMemoryAuditSink()
+	engine := NewExecutionEngine(NewMCPService(newAuditTestServer(t).URL))
+	engine.SetAuditSink(sink)
+
+	if err := engine.RunExecution("exec_1", "user_1", newAuditTestPlan()); err == nil {
+		t.Fatal("Expected the share step to fail")
+	}
+
+	entries, err := sink.Query(AuditFilter{UserID: "user_1"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("Expected two step entries and one execution entry, got %+v", entries)
+	}
+	send := entries[0]
+	if send.Action != "gmail.send_message" || send.Parameters["to"] != "team@example.com" || send.Parameters["message_id"] != "msg_1" {
+		t.Errorf("Expected the resolved recipient and message ID of the send step, got %+v", send)
+	}
+	if _, exists := send.Parameters["body"]; exists {
+		t.Error("Expected the email body to be left out of the audit log")
+	}
+	share := entries[1]
+	if share.Action != "drive.share_file" || share.Status != string(StepFailed) || share.Parameters["file_id"] != "file_1" {
+		t.Errorf("Expected the failed share step with its resolved file ID, got %+v", share)
+	}
+	if !share.Timestamp.After(send.Timestamp) {
+		t.Errorf("Expected each step to carry its own timestamp, got %s and %s", send.Timestamp, share.Timestamp)
+	}
+	if execution := entries[2]; execution.Event != AuditEventExecution || execution.Status != "failed" || execution.WorkflowID != "wf_1" || execution.ExecutionID != "exec_1" {
+		t.Errorf("Expected the failed execution entry, got %+v", execution)
+	}
+}
+
+// TestAuditStepWrittenBeforeRunEnds verifies a step's entry is in the log while later steps still run
+func TestAuditStepWrittenBeforeRunEnds(t *testing.T) {
+	sink := NewMemoryAuditSink()
+	ee, plan, called := newBlockingExecution(t)
+	ee.SetAuditSink(sink)
+	plan.ResolvedSteps = append([]ResolvedStep{{ID: "simulated", Service: "gmail", Action: "send_message", Sandboxed: true,
+		Inputs: map[string]interface{}{"to": "team@example.com"}, Outputs: map[string]interface{}{}}}, plan.ResolvedSteps...)
+
+	done := make(chan error, 1)
+	go func() { done <- ee.RunExecution("exec_1", "user_1", plan) }()
+	<-called
+
+	entries, _ := sink.Query(AuditFilter{UserID: "user_1"})
+	if len(entries) != 1 || entries[0].StepID != "simulated" || !entries[0].Sandboxed || entries[0].Parameters["to"] != "team@example.com" {
+		t.Errorf("Expected the sandboxed step audited while the next step runs, got %+v", entries)
+	}
+	ee.CancelExecution("exec_1", "user_1")
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the run to end once cancelled")
+	}
+}
+
+// TestExecuteWorkflowIsAudited verifies runs outside RunExecution, such as simulations, are audited too
+func TestExecuteWorkflowIsAudited(t *testing.T) {
+	sink := NewMemoryAuditSink()
+	engine := NewExecutionEngine(NewMCPService(newAuditTestServer(t).URL))
+	engine.SetAuditSink(sink)
+
+	plan := newAuditTestPlan()
+	plan.Simulate = true
+	if err := engine.ExecuteWorkflow(plan); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	entries, _ := sink.Query(AuditFilter{UserID: "user_1"})
+	if len(entries) != 4 {
+		t.Fatalf("Expected three simulated steps and the execution, got %+v", entries)
+	}
+	for _, entry := range entries {
+		if !entry.Simulated || entry.ExecutionID == "" || entry.ExecutionID != entries[0].ExecutionID {
+			t.Errorf("Expected simulated entries under one execution ID, got %+v", entry)
+		}
+	}
+}
+
+// TestFileAuditSinkQuery verifies entries are appended to the file and filtered by user and time range
+func TestFileAuditSinkQuery(t *testing.T) {
+	sink, err := NewFileAuditSink(filepath.Join(t.TempDir(), "audit.jsonl"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, userID := range []string{"user_1", "user_2", "user_1"} {
+		entry := AuditEntry{Timestamp: start.Add(time.Duration(i) * time.Hour), Event: AuditEventExecution, UserID: userID, ExecutionID: "exec", Status: "completed"}
+		if err := sink.Append(entry); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	entries, err := sink.Query(AuditFilter{UserID: "user_1", From: start.Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(entries) != 1 || !entries[0].Timestamp.Equal(start.Add(2*time.Hour)) {
+		t.Errorf("Expected only the later entry of user_1, got %+v", entries)
+	}
+	if entries, _ := sink.Query(AuditFilter{To: start.Add(time.Hour)}); len(entries) != 1 {
+		t.Errorf("Expected the end of the range to be exclusive, got %+v", entries)
+	}
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"sohoaas-backend/internal/types"
+)
+
+// ErrInvalidWorkflowJSON is returned when a submitted workflow JSON can't be converted to a
+// runnable workflow
+var ErrInvalidWorkflowJSON = errors.New("invalid workflow JSON")
+
+var workflowFileNameChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// SaveAuthoredWorkflow saves a hand-authored workflow JSON for a user, bypassing the LLM: the
+// JSON goes through the same structure check, CUE conversion and dry validation against the
+// MCP catalog as a generated workflow, and is stored with its workflow.json the same way.
+// Returns the saved workflow file and its CUE content.
+func (g *GenkitService) SaveAuthoredWorkflow(userID string, workflowJSON map[string]interface{}) (*types.WorkflowFile, string, error) {
+	if !g.isValidWorkflowJSON(workflowJSON) {
+		return nil, "", fmt.Errorf("%w: version, name, description, steps, user_parameters and services are required, with at least one step", ErrInvalidWorkflowJSON)
+	}
+
+	cueContent := g.sanitizeCUEContent(g.convertJSONToCUE(workflowJSON))
+	log.Printf("[GenkitService] Converted authored workflow JSON to CUE (%d characters)", len(cueContent))
+
+	// A catalog outage isn't the workflow's fault, so check it before validating
+	if _, err := g.mcpService.GetServiceCatalog(); err != nil {
+		return nil, "", fmt.Errorf("failed to query MCP service catalog for validation: %w", err)
+	}
+	if err := g.validateGeneratedWorkflow(cueContent); err != nil {
+		log.Printf("[GenkitService] ERROR: Authored workflow failed validation, not saving: %v", err)
+		return nil, "", fmt.Errorf("%w: %v", ErrInvalidWorkflowJSON, err)
+	}
+
+	workflowName := authoredWorkflowFileName(g.extractStringField(workflowJSON, "name", ""))
+	workflowFile, err := g.workflowStorage.SaveWorkflow(userID, workflowName, cueContent)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to save workflow: %w", err)
+	}
+	log.Printf("[GenkitService] SUCCESS: Authored workflow saved, File ID: %s", workflowFile.ID)
+
+	workflowID := strings.TrimPrefix(workflowFile.ID, userID+"_")
+	if jsonContent, err := json.MarshalIndent(workflowJSON, "", "  "); err == nil {
+		if saveErr := g.workflowStorage.SaveWorkflowArtifact(userID, workflowID, ".", "workflow.json", string(jsonContent)); saveErr != nil {
+			log.Printf("[GenkitService] ERROR: Failed to save workflow.json: %v", saveErr)
+		}
+	}
+
+	audit := map[string]interface{}{
+		"version":       "1.0",
+		"generated_at":  time.Now().Format(time.RFC3339),
+		"user_id":       userID,
+		"workflow_id":   workflowID,
+		"workflow_name": workflowName,
+		"source":        "authored",
+	}
+	if auditJSON, err := json.MarshalIndent(audit, "", "  "); err == nil {
+		if saveErr := g.workflowStorage.SaveWorkflowArtifact(userID, workflowID, "metadata", "audit.json", string(auditJSON)); saveErr != nil {
+			log.Printf("[GenkitService] ERROR: Failed to save audit.json: %v", saveErr)
+		}
+	}
+
+	return workflowFile, cueContent, nil
+}
+
+// authoredWorkflowFileName turns a workflow name into the name its file is saved under
+func authoredWorkflowFileName(name string) string {
+	name = workflowFileNameChars.ReplaceAllString(strings.ToLower(name), "_")
+	if len(name) > 30 {
+		name = name[:30]
+	}
+	if name = strings.Trim(name, "_"); name != "" {
+		return name
+	}
+	return "workflow"
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"sohoaas-backend/internal/storage"
+)
+
+// authoredWorkflowJSON returns a hand-authored workflow calling the given action
+func authoredWorkflowJSON(action string) map[string]interface{} {
+	return map[string]interface{}{
+		"version":     "1.0",
+		"name":        "Weekly Report",
+		"description": "Email the weekly report to the team",
+		"steps": []interface{}{
+			map[string]interface{}{
+				"id":     "send_report",
+				"action": action,
+				"parameters": map[string]interface{}{
+					"to":      "${user.recipient_email}",
+					"subject": "Weekly Report",
+					"body":    "See the attached report",
+				},
+			},
+		},
+		"user_parameters": map[string]interface{}{
+			"recipient_email": map[string]interface{}{"type": "string", "prompt": "Recipient email"},
+		},
+		"services": []interface{}{
+			map[string]interface{}{"service": "gmail", "provider": "workspace"},
+		},
+	}
+}
+
+// TestSaveAuthoredWorkflowRejectsInvalidJSON verifies incomplete or unrunnable workflows are
+// reported as invalid and not saved
+func TestSaveAuthoredWorkflowRejectsInvalidJSON(t *testing.T) {
+	missingSteps := authoredWorkflowJSON("gmail.send_message")
+	delete(missingSteps, "steps")
+
+	testCases := []struct {
+		name         string
+		workflowJSON map[string]interface{}
+	}{
+		{"missing steps", missingSteps},
+		{"unknown action", authoredWorkflowJSON("gmail.unknown_action")},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			workflowStorage := storage.NewMockStorage()
+			service := &GenkitService{
+				mcpService:      NewFakeMCPService(MockPipelineCatalog()),
+				workflowStorage: workflowStorage,
+			}
+
+			_, _, err := service.SaveAuthoredWorkflow("author", tc.workflowJSON)
+			if !errors.Is(err, ErrInvalidWorkflowJSON) {
+				t.Fatalf("Expected ErrInvalidWorkflowJSON, got %v", err)
+			}
+			if workflows, _ := workflowStorage.ListUserWorkflows("author"); len(workflows) != 0 {
+				t.Errorf("Expected nothing saved, got %d workflows", len(workflows))
+			}
+		})
+	}
+}
+
+// TestSaveAuthoredWorkflowCatalogUnavailable verifies a catalog outage isn't blamed on the workflow
+func TestSaveAuthoredWorkflowCatalogUnavailable(t *testing.T) {
+	service := &GenkitService{
+		mcpService:      NewMCPService(""),
+		workflowStorage: storage.NewMockStorage(),
+	}
+
+	_, _, err := service.SaveAuthoredWorkflow("author", authoredWorkflowJSON("gmail.send_message"))
+	if err == nil {
+		t.Fatal("Expected an error without an MCP catalog")
+	}
+	if errors.Is(err, ErrInvalidWorkflowJSON) {
+		t.Errorf("Expected a catalog error, not an invalid workflow: %v", err)
+	}
+}
+
+// TestAuthoredWorkflowFileName verifies workflow names are turned into safe file names
+func TestAuthoredWorkflowFileName(t *testing.T) {
+	testCases := map[string]string{
+		"Weekly Report":     "weekly_report",
+		"  Send -> Team!  ": "send_team",
+		"":                  "workflow",
+		"A very long workflow name that keeps going": "a_very_long_workflow_name_that",
+	}
+	for name, expected := range testCases {
+		if got := authoredWorkflowFileName(name); got != expected {
+			t.Errorf("authoredWorkflowFileName(%q) = %q, expected %q", name, got, expected)
+		}
+	}
+}
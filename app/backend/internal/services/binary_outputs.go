@@ -0,0 +1,105 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"sohoaas-backend/internal/types"
+)
+
+// defaultMaxBinaryOutputBytes caps the decoded size of a binary step output (Gmail's attachment limit)
+const defaultMaxBinaryOutputBytes = 25 << 20
+
+// BinaryOutput is a base64 step output kept by reference in StepOutputs. A parameter that is
+// exactly ${steps.<id>.outputs.<field>} receives it unchanged instead of a stringified copy,
+// and it marshals back to the original base64 (or data URL) when sent to MCP.
+type BinaryOutput struct {
+	Base64   string // encoded content without any data URL prefix
+	MimeType string // from a data URL prefix, if any
+	Size     int    // decoded size in bytes
+	dataURL  bool
+}
+
+// MarshalJSON encodes the output exactly as the MCP server returned it
+func (b *BinaryOutput) MarshalJSON() ([]byte, error) {
+	if b.dataURL {
+		return json.Marshal(fmt.Sprintf("data:%s;base64,%s", b.MimeType, b.Base64))
+	}
+	return json.Marshal(b.Base64)
+}
+
+// String summarizes the output so logging never prints the payload
+func (b *BinaryOutput) String() string {
+	if b.MimeType != "" {
+		return fmt.Sprintf("<binary %s, %d bytes>", b.MimeType, b.Size)
+	}
+	return fmt.Sprintf("<binary %d bytes>", b.Size)
+}
+
+// SetMaxBinaryOutputBytes sets the largest decoded binary output a step may return; 0 restores the default
+func (ee *ExecutionEngine) SetMaxBinaryOutputBytes(maxBytes int) {
+	ee.maxBinaryOutputBytes = maxBytes
+}
+
+// newBinaryOutput wraps a base64 string or base64 data URL
+func newBinaryOutput(value string) *BinaryOutput {
+	output := &BinaryOutput{Base64: value}
+	if strings.HasPrefix(value, "data:") {
+		if comma := strings.Index(value, ","); comma >= 0 && strings.HasSuffix(value[:comma], ";base64") {
+			output.MimeType = strings.TrimSuffix(strings.TrimPrefix(value[:comma], "data:"), ";base64")
+			output.Base64 = value[comma+1:]
+			output.dataURL = true
+		}
+	}
+	output.Size = base64.RawStdEncoding.DecodedLen(len(strings.TrimRight(output.Base64, "=")))
+	return output
+}
+
+// isBinaryOutputField reports whether an output is base64 content: declared with format
+// "byte" or "binary" in the action's output schema, or returned as a base64 data URL
+func isBinaryOutputField(property types.MCPParameterProperty, value string) bool {
+	if property.Format == "byte" || property.Format == "binary" {
+		return true
+	}
+	if comma := strings.Index(value, ","); strings.HasPrefix(value, "data:") && comma >= 0 {
+		return strings.HasSuffix(value[:comma], ";base64")
+	}
+	return false
+}
+
+// wrapBinaryOutputs replaces base64 outputs of a step response with BinaryOutput references,
+// rejecting any larger than the engine's binary output cap
+func (ee *ExecutionEngine) wrapBinaryOutputs(step *ResolvedStep, data map[string]interface{}) (map[string]interface{}, error) {
+	var properties map[string]types.MCPParameterProperty
+	if ee.mcpService != nil {
+		if catalog, err := ee.mcpService.GetServiceCatalog(); err == nil {
+			if _, serviceDefinition, err := catalog.ResolveService(step.Provider, step.Service); err == nil {
+				if functionSchema, exists := serviceDefinition.Functions[step.Action]; exists && functionSchema.OutputSchema != nil {
+					properties = functionSchema.OutputSchema.Properties
+				}
+			}
+		}
+	}
+
+	maxBytes := ee.maxBinaryOutputBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxBinaryOutputBytes
+	}
+
+	wrapped := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		str, ok := value.(string)
+		if !ok || !isBinaryOutputField(properties[key], str) {
+			wrapped[key] = value
+			continue
+		}
+		output := newBinaryOutput(str)
+		if output.Size > maxBytes {
+			return nil, fmt.Errorf("binary output %s is %d bytes, exceeding the %d byte limit", key, output.Size, maxBytes)
+		}
+		wrapped[key] = output
+	}
+	return wrapped, nil
+}
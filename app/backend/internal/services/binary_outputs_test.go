@@ -0,0 +1,147 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newBinaryMCPServer serves a catalog where drive.export_file returns base64 content and
+// records the arguments gmail.send_message receives
+func newBinaryMCPServer(t *testing.T, content string, received *map[string]interface{}) *httptest.Server {
+	catalogJSON := `{"providers": {"workspace": {"services": {
+		"drive": {"functions": {"export_file": {"name": "export_file", "output_schema": {"type": "object", "properties": {
+			"content": {"type": "string", "format": "byte"},
+			"mime_type": {"type": "string"}
+		}}}}},
+		"gmail": {"functions": {"send_message": {"name": "send_message"}}}
+	}}}}`
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			w.Write([]byte(catalogJSON))
+			return
+		}
+
+		var request struct {
+			Name      string                 `json:"name"`
+			Arguments map[string]interface{} `json:"arguments"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("Failed to decode tools/call request: %v", err)
+		}
+
+		result := map[string]interface{}{"message_id": "msg_1"}
+		if request.Name == "drive.export_file" {
+			result = map[string]interface{}{"content": content, "mime_type": "application/pdf"}
+		} else {
+			*received = request.Arguments
+		}
+		resultText, _ := json.Marshal(result)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"result": map[string]interface{}{
+				"content": []map[string]interface{}{{"type": "text", "text": string(resultText)}},
+			},
+		})
+	}))
+}
+
+// TestBinaryOutputPassesBetweenSteps verifies a multi-MB base64 export reaches the next step unchanged
+func TestBinaryOutputPassesBetweenSteps(t *testing.T) {
+	payload := make([]byte, 4<<20)
+	for i := range payload {
+		payload[i] = byte(i % 251)
+	}
+	content := base64.StdEncoding.EncodeToString(payload)
+
+	var received map[string]interface{}
+	server := newBinaryMCPServer(t, content, &received)
+	defer server.Close()
+
+	ee := NewExecutionEngine(NewMCPService(server.URL))
+	plan := &ExecutionPlan{
+		ResolvedSteps: []ResolvedStep{
+			{ID: "export", Service: "drive", Action: "export_file", Status: StepPending,
+				Inputs: map[string]interface{}{"file_id": "file_1"}, Outputs: map[string]interface{}{}},
+			{ID: "send", Service: "gmail", Action: "send_message", Status: StepPending, DependsOn: []string{"export"},
+				Inputs: map[string]interface{}{
+					"to": "team@example.com",
+					"attachment": map[string]interface{}{
+						"base64":    "${steps.export.outputs.content}",
+						"mime_type": "${steps.export.outputs.mime_type}",
+					},
+				},
+				Outputs: map[string]interface{}{}},
+		},
+		ParameterContext: &ParameterContext{
+			UserParameters:    map[string]interface{}{},
+			StepOutputs:       map[string]interface{}{},
+			SystemParameters:  map[string]interface{}{"oauth_token": "token"},
+			RuntimeParameters: map[string]interface{}{},
+		},
+	}
+
+	if err := ee.ExecuteWorkflow(plan); err != nil {
+		t.Fatalf("ExecuteWorkflow failed: %v", err)
+	}
+
+	outputs, _ := plan.ParameterContext.StepOutput("export")
+	binary, ok := outputs["content"].(*BinaryOutput)
+	if !ok {
+		t.Fatalf("Expected content to be stored as a binary reference, got %T", outputs["content"])
+	}
+	if binary.Size != len(payload) {
+		t.Errorf("Expected size %d, got %d", len(payload), binary.Size)
+	}
+
+	attachment, _ := received["attachment"].(map[string]interface{})
+	if attachment["base64"] != content {
+		t.Errorf("Expected the attachment to carry the exported content unchanged (got %d chars)", len(attachment["base64"].(string)))
+	}
+	if attachment["mime_type"] != "application/pdf" {
+		t.Errorf("Expected mime type application/pdf, got %v", attachment["mime_type"])
+	}
+
+	// Binary content cannot be spliced into text
+	if _, err := ee.resolveStringParameter("PDF: ${steps.export.outputs.content}", plan.ParameterContext); err == nil || !strings.Contains(err.Error(), "binary") {
+		t.Errorf("Expected embedding binary content in text to fail, got %v", err)
+	}
+}
+
+// TestBinaryOutputSizeCap verifies outputs above the configured cap fail the step
+func TestBinaryOutputSizeCap(t *testing.T) {
+	content := base64.StdEncoding.EncodeToString(make([]byte, 2<<20))
+	var received map[string]interface{}
+	server := newBinaryMCPServer(t, content, &received)
+	defer server.Close()
+
+	ee := NewExecutionEngine(NewMCPService(server.URL))
+	ee.SetMaxBinaryOutputBytes(1 << 20)
+	step := &ResolvedStep{ID: "export", Service: "drive", Action: "export_file", Outputs: map[string]interface{}{}}
+	context := &ParameterContext{StepOutputs: map[string]interface{}{}, SystemParameters: map[string]interface{}{"oauth_token": "token"}}
+
+	err := ee.executeStep(step, context)
+	if err == nil || !strings.Contains(err.Error(), "exceeding the 1048576 byte limit") {
+		t.Fatalf("Expected size cap error, got %v", err)
+	}
+	if context.HasStepOutputs() {
+		t.Error("Expected rejected outputs not to be recorded")
+	}
+}
+
+// TestNewBinaryOutputDataURL verifies data URLs keep their mime type and marshal back unchanged
+func TestNewBinaryOutputDataURL(t *testing.T) {
+	dataURL := "data:application/pdf;base64,JVBERi0xLjQ="
+	output := newBinaryOutput(dataURL)
+	if output.MimeType != "application/pdf" || output.Base64 != "JVBERi0xLjQ=" || output.Size != 8 {
+		t.Errorf("Unexpected binary output: %+v", output)
+	}
+	encoded, err := json.Marshal(output)
+	if err != nil || string(encoded) != `"`+dataURL+`"` {
+		t.Errorf("Expected data URL round trip, got %s (err: %v)", encoded, err)
+	}
+}
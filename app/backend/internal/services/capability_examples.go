@@ -0,0 +1,92 @@
+package services
+
+import (
+	"sort"
+	"strings"
+
+	"sohoaas-backend/internal/types"
+)
+
+// maxCapabilityExamples caps the starter ideas returned with personal capabilities
+const maxCapabilityExamples = 6
+
+// capabilityExampleTemplates are example intents for two actions used together, as
+// "service.function" of the first and second step. An example is only suggested when the
+// user's connected services offer both actions. Every action must be a function of the MCP
+// catalog captured in mcp_response.json.
+var capabilityExampleTemplates = []struct {
+	first, second, example string
+}{
+	{"gmail.get_message", "docs.create_document", "Save an email as a Google Doc"},
+	{"docs.create_document", "gmail.send_message", "Create a report document and email the link to your team"},
+	{"calendar.list_events", "gmail.send_message", "Email yourself a summary of today's meetings"},
+	{"docs.create_document", "drive.share_file", "Create a document and share it with a colleague"},
+	{"calendar.create_event", "gmail.send_message", "Schedule a meeting and email the agenda to the attendees"},
+	{"drive.create_folder", "docs.create_document", "Create a project folder with a kickoff document"},
+	{"gmail.search_messages", "docs.create_document", "Collect invoices from your inbox into a Google Doc"},
+	{"calendar.list_events", "docs.create_document", "Create meeting notes for today's events"},
+	{"drive.list_files", "gmail.send_message", "Email yourself a list of your recent Drive files"},
+}
+
+// capabilityExamples derives example intents from the functions of the connected services:
+// the templates whose actions are all available, then one example per connected service no
+// template covered, taken from its first function's catalog description
+func capabilityExamples(catalog *types.MCPServiceCatalog, connectedServices []string) []string {
+	if catalog == nil || len(connectedServices) == 0 {
+		return []string{"Connect Google Workspace to see automation examples"}
+	}
+
+	services := append([]string(nil), connectedServices...)
+	sort.Strings(services)
+	available := make(map[string]types.MCPServiceDefinition)
+	for _, service := range services {
+		if _, definition, exists := catalog.LookupService(service); exists {
+			available[service] = definition
+		}
+	}
+	hasAction := func(action string) bool {
+		service, function, _ := strings.Cut(action, ".")
+		_, exists := available[service].Functions[function]
+		return exists
+	}
+
+	var examples []string
+	covered := make(map[string]bool)
+	for _, template := range capabilityExampleTemplates {
+		if len(examples) == maxCapabilityExamples {
+			return examples
+		}
+		if hasAction(template.first) && hasAction(template.second) {
+			examples = append(examples, template.example)
+			covered[strings.Split(template.first, ".")[0]] = true
+			covered[strings.Split(template.second, ".")[0]] = true
+		}
+	}
+
+	for _, service := range services {
+		definition, exists := available[service]
+		if !exists || covered[service] || len(definition.Functions) == 0 || len(examples) == maxCapabilityExamples {
+			continue
+		}
+		functions := make([]string, 0, len(definition.Functions))
+		for function := range definition.Functions {
+			functions = append(functions, function)
+		}
+		sort.Strings(functions)
+		function := definition.Functions[functions[0]]
+
+		example := function.Description
+		if example == "" {
+			example = function.DisplayName
+		}
+		if example == "" {
+			example = strings.ReplaceAll(functions[0], "_", " ")
+		}
+		serviceName := definition.DisplayName
+		if serviceName == "" {
+			serviceName = service
+		}
+		examples = append(examples, example+" with "+serviceName)
+	}
+	return examples
+}
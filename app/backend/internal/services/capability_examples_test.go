@@ -0,0 +1,55 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"sohoaas-backend/internal/types"
+)
+
+// TestCapabilityExamples verifies examples follow the functions of the connected services
+func TestCapabilityExamples(t *testing.T) {
+	catalog := MockPipelineCatalog()
+	catalog.Providers.Workspace.Services["calendar"] = types.MCPServiceDefinition{
+		DisplayName: "Google Calendar",
+		Functions: map[string]types.MCPFunctionSchema{
+			"create_event": {Name: "create_event", Description: "Create a new calendar event"},
+		},
+	}
+
+	examples := capabilityExamples(catalog, []string{"gmail", "docs"})
+	expected := []string{"Create a report document and email the link to your team"}
+	if !reflect.DeepEqual(examples, expected) {
+		t.Errorf("Expected %v for Gmail and Docs, got %v", expected, examples)
+	}
+
+	examples = capabilityExamples(catalog, []string{"calendar"})
+	expected = []string{"Create a new calendar event with Google Calendar"}
+	if !reflect.DeepEqual(examples, expected) {
+		t.Errorf("Expected %v for Calendar alone, got %v", expected, examples)
+	}
+
+	examples = capabilityExamples(catalog, nil)
+	if len(examples) != 1 || examples[0] != "Connect Google Workspace to see automation examples" {
+		t.Errorf("Expected the connect hint without connected services, got %v", examples)
+	}
+}
+
+// TestCapabilityExampleTemplatesUseCatalogActions verifies every template names a function the
+// MCP catalog actually offers, so examples never point the generator at a nonexistent action
+func TestCapabilityExampleTemplatesUseCatalogActions(t *testing.T) {
+	catalog, err := LoadStaticCatalog("../../mcp_response.json")
+	if err != nil {
+		t.Fatalf("Failed to load the captured catalog: %v", err)
+	}
+	for _, template := range capabilityExampleTemplates {
+		for _, action := range []string{template.first, template.second} {
+			service, function, _ := strings.Cut(action, ".")
+			_, definition, exists := catalog.LookupService(service)
+			if _, found := definition.Functions[function]; !exists || !found {
+				t.Errorf("Example %q uses %s, which is not in the catalog", template.example, action)
+			}
+		}
+	}
+}
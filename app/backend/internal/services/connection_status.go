@@ -0,0 +1,79 @@
+package services
+
+import (
+	"sort"
+	"time"
+
+	"sohoaas-backend/internal/types"
+)
+
+// Connection statuses of a service for a user
+const (
+	ConnectionStatusConnected    = "connected"
+	ConnectionStatusExpired      = "expired"
+	ConnectionStatusNotConnected = "not_connected"
+)
+
+// ServiceConnectionStatus tells whether a user can run a service's actions or must (re)authenticate
+type ServiceConnectionStatus struct {
+	Provider   string     `json:"provider"`
+	Service    string     `json:"service"`
+	Status     string     `json:"status"` // connected, expired or not_connected
+	Connection string     `json:"connection,omitempty"`
+	Email      string     `json:"email,omitempty"`
+	Expiry     *time.Time `json:"expiry,omitempty"` // when the stored token expires, if one is stored
+}
+
+// ServiceConnectionStatuses returns the status of every workspace service in the catalog for
+// the user's default connection, sorted by service. Workspace services share the connection's
+// Google token, so they all report its validity. Other providers' credentials aren't stored
+// by the token manager and aren't reported.
+func (tm *TokenManager) ServiceConnectionStatuses(userID string, catalog *types.MCPServiceCatalog) []ServiceConnectionStatus {
+	tm.mutex.RLock()
+	userTokens, _ := tm.lookup(userID, DefaultConnection)
+	tm.mutex.RUnlock()
+
+	base := ServiceConnectionStatus{Provider: "workspace", Status: ConnectionStatusNotConnected}
+	if userTokens != nil {
+		info := userTokens.info()
+		base.Status = ConnectionStatusConnected
+		if info.IsExpired {
+			base.Status = ConnectionStatusExpired
+		}
+		base.Connection = info.Connection
+		base.Email = info.Email
+		base.Expiry = &info.Expiry
+	}
+
+	statuses := []ServiceConnectionStatus{}
+	if catalog == nil {
+		return statuses
+	}
+	for service := range catalog.Providers.Workspace.Services {
+		status := base
+		status.Service = service
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Service < statuses[j].Service
+	})
+	return statuses
+}
+
+// MissingConnections returns the required services the user can't run yet because their
+// connection is missing or expired, in the order given. Services the token manager doesn't
+// report (unknown or non-workspace) are not gated.
+func (tm *TokenManager) MissingConnections(userID string, catalog *types.MCPServiceCatalog, requiredServices []string) []string {
+	statuses := make(map[string]string)
+	for _, status := range tm.ServiceConnectionStatuses(userID, catalog) {
+		statuses[status.Service] = status.Status
+	}
+
+	var missing []string
+	for _, service := range requiredServices {
+		if status, reported := statuses[service]; reported && status != ConnectionStatusConnected {
+			missing = append(missing, service)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+// TestServiceConnectionStatuses verifies each workspace service reports the default connection's token validity
+func TestServiceConnectionStatuses(t *testing.T) {
+	tm := NewTokenManager()
+	catalog := MockPipelineCatalog()
+
+	statuses := tm.ServiceConnectionStatuses("user-1", catalog)
+	if len(statuses) != 2 || statuses[0].Service != "docs" || statuses[1].Service != "gmail" {
+		t.Fatalf("Expected docs and gmail statuses, got %+v", statuses)
+	}
+	for _, status := range statuses {
+		if status.Status != ConnectionStatusNotConnected || status.Expiry != nil {
+			t.Errorf("Expected %s not connected without a stored token, got %+v", status.Service, status)
+		}
+	}
+
+	expiry := time.Now().Add(30 * time.Minute)
+	tm.tokens["user-1"] = map[string]*UserTokens{
+		DefaultConnection: {AccessToken: "token", Expiry: expiry, UserID: "user-1", Connection: DefaultConnection, Email: "owner@example.com"},
+	}
+	for _, status := range tm.ServiceConnectionStatuses("user-1", catalog) {
+		if status.Status != ConnectionStatusConnected || status.Email != "owner@example.com" || status.Expiry == nil || !status.Expiry.Equal(expiry) {
+			t.Errorf("Expected %s connected until %v, got %+v", status.Service, expiry, status)
+		}
+	}
+
+	tm.tokens["user-1"][DefaultConnection].Expiry = time.Now().Add(-time.Minute)
+	for _, status := range tm.ServiceConnectionStatuses("user-1", catalog) {
+		if status.Status != ConnectionStatusExpired {
+			t.Errorf("Expected %s expired, got %s", status.Service, status.Status)
+		}
+	}
+}
+
+// TestMissingConnections verifies only reported services without a valid connection are missing
+func TestMissingConnections(t *testing.T) {
+	tm := NewTokenManager()
+	catalog := MockPipelineCatalog()
+
+	missing := tm.MissingConnections("user-1", catalog, []string{"gmail", "slack", "docs"})
+	if len(missing) != 2 || missing[0] != "gmail" || missing[1] != "docs" {
+		t.Errorf("Expected gmail and docs missing, got %v", missing)
+	}
+
+	tm.tokens["user-1"] = map[string]*UserTokens{
+		DefaultConnection: {AccessToken: "token", Expiry: time.Now().Add(time.Hour), UserID: "user-1", Connection: DefaultConnection},
+	}
+	if missing := tm.MissingConnections("user-1", catalog, []string{"gmail", "docs"}); len(missing) != 0 {
+		t.Errorf("Expected no missing connections, got %v", missing)
+	}
+}
@@ -174,7 +174,7 @@ func (cb *CUEBuilder) generateServiceBindings(workflow *WorkflowJSON) map[string
 		if scopes, exists := serviceScopes[service]; exists {
 			bindings[service] = ServiceBindingJSON{
 				Service:     service,
-				OAuthScopes: scopes,
+				OAuthScopes: normalizeOAuthScopes(scopes),
 			}
 		}
 	}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"sohoaas-backend/internal/workflowparser"
+)
+
+// DefaultCUEPackageName is the package of generated CUE workflows
+const DefaultCUEPackageName = "workflow"
+
+// cueSchemaImportAlias names the imported schema package in generated CUE
+const cueSchemaImportAlias = "schemas"
+
+var (
+	validCUEPackageName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+	validCUEImportPath  = regexp.MustCompile(`^[A-Za-z0-9._~/-]+(:[A-Za-z][A-Za-z0-9_]*)?$`)
+)
+
+// SetCUEOutput sets the package name of generated CUE workflows and how they reference the
+// workflow schema: with an empty schemaImportPath the schema is embedded in each workflow,
+// otherwise it is imported from that CUE module path (e.g. "example.com/rac/schemas").
+// An empty packageName uses DefaultCUEPackageName.
+func (g *GenkitService) SetCUEOutput(packageName, schemaImportPath string) error {
+	if packageName == "" {
+		packageName = DefaultCUEPackageName
+	}
+	if !validCUEPackageName.MatchString(packageName) {
+		return fmt.Errorf("invalid CUE package name %q", packageName)
+	}
+	if schemaImportPath != "" && !validCUEImportPath.MatchString(schemaImportPath) {
+		return fmt.Errorf("invalid CUE schema import path %q", schemaImportPath)
+	}
+	g.cuePackageName = packageName
+	g.cueSchemaImportPath = schemaImportPath
+	return nil
+}
+
+// writeCUEHeader writes the package clause and the schema (embedded or imported) of a
+// generated workflow, and returns the reference to use for #DeterministicWorkflow
+func (g *GenkitService) writeCUEHeader(cueBuilder *strings.Builder) string {
+	packageName := g.cuePackageName
+	if packageName == "" {
+		packageName = DefaultCUEPackageName
+	}
+	cueBuilder.WriteString(fmt.Sprintf("package %s\n\n", packageName))
+
+	// Record the schema the workflow is generated under, so parsing can tell when it changed
+	schemaContent := workflowparser.LoadDeterministicSchema()
+	if schemaContent != "" {
+		cueBuilder.WriteString(workflowparser.SchemaHashComment + workflowparser.SchemaHash(schemaContent) + "\n\n")
+	}
+
+	if g.cueSchemaImportPath != "" {
+		cueBuilder.WriteString(fmt.Sprintf("import %s %q\n\n", cueSchemaImportAlias, g.cueSchemaImportPath))
+		return cueSchemaImportAlias + ".#DeterministicWorkflow"
+	}
+
+	if schemaContent == "" {
+		// ParseCUEWorkflow inlines the schema, so the workflow still executes
+		log.Printf("[GenkitService] Warning: workflow schema not found, generated CUE does not embed it")
+		return "#DeterministicWorkflow"
+	}
+	cueBuilder.WriteString(workflowparser.EmbeddedSchemaStart + "\n")
+	cueBuilder.WriteString(workflowparser.RemovePackageClause(schemaContent))
+	cueBuilder.WriteString("\n" + workflowparser.EmbeddedSchemaEnd + "\n\n")
+	return "#DeterministicWorkflow"
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"sohoaas-backend/internal/workflowparser"
+)
+
+// generateNotifyWorkflow converts a one-step workflow to CUE with g's output settings
+func generateNotifyWorkflow(g *GenkitService) string {
+	return g.convertJSONToCUE(map[string]interface{}{
+		"workflow_name": "Notify",
+		"description":   "Email the team",
+		"steps": []interface{}{
+			map[string]interface{}{
+				"id":         "send",
+				"action":     "gmail.send_message",
+				"parameters": map[string]interface{}{"to": "team@example.com", "subject": "Done"},
+			},
+		},
+	})
+}
+
+// TestConvertJSONToCUEEmbedsSchema verifies generated workflows embed the schema by default and parse
+func TestConvertJSONToCUEEmbedsSchema(t *testing.T) {
+	cueContent := generateNotifyWorkflow(&GenkitService{})
+
+	if !strings.HasPrefix(cueContent, "package workflow\n") {
+		t.Errorf("Expected the default package, got:\n%.80s", cueContent)
+	}
+	if strings.Contains(cueContent, "import ") {
+		t.Error("Expected no import in embed mode")
+	}
+	if !strings.Contains(cueContent, workflowparser.EmbeddedSchemaStart) || !strings.Contains(cueContent, "#DeterministicWorkflow: {") {
+		t.Error("Expected the deterministic workflow schema to be embedded")
+	}
+
+	workflow, err := NewExecutionEngine(nil).ParseCUEWorkflow(cueContent)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if workflow.Name != "Notify" || len(workflow.Steps) != 1 {
+		t.Errorf("Expected the Notify workflow with one step, got %+v", workflow)
+	}
+}
+
+// TestConvertJSONToCUEImportsSchema verifies a configured package and schema module path are used
+func TestConvertJSONToCUEImportsSchema(t *testing.T) {
+	g := &GenkitService{}
+	if err := g.SetCUEOutput("automations", "example.com/sohoaas/rac/schemas"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	cueContent := generateNotifyWorkflow(g)
+
+	for _, expected := range []string{
+		"package automations\n",
+		`import schemas "example.com/sohoaas/rac/schemas"`,
+		"workflow: schemas.#DeterministicWorkflow & {",
+	} {
+		if !strings.Contains(cueContent, expected) {
+			t.Errorf("Expected %q in generated CUE:\n%s", expected, cueContent)
+		}
+	}
+	if strings.Contains(cueContent, workflowparser.EmbeddedSchemaStart) {
+		t.Error("Expected the schema not to be embedded in import mode")
+	}
+
+	workflow, err := NewExecutionEngine(nil).ParseCUEWorkflow(cueContent)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if workflow.Steps[0].Action != "send_message" {
+		t.Errorf("Expected the step to parse, got %+v", workflow.Steps[0])
+	}
+}
+
+// TestSetCUEOutputRejectsInvalidSettings verifies bad package names and import paths are refused
+func TestSetCUEOutputRejectsInvalidSettings(t *testing.T) {
+	g := &GenkitService{}
+	if err := g.SetCUEOutput("my-package", ""); err == nil {
+		t.Error("Expected an invalid package name to be rejected")
+	}
+	if err := g.SetCUEOutput("workflow", `bad "path"`); err == nil {
+		t.Error("Expected an invalid import path to be rejected")
+	}
+	if err := g.SetCUEOutput("", ""); err != nil || g.cuePackageName != DefaultCUEPackageName {
+		t.Errorf("Expected the default package name, got %q (%v)", g.cuePackageName, err)
+	}
+}
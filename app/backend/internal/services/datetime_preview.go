@@ -0,0 +1,86 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// DateTimePreview shows how a datetime input will be sent after timezone resolution
+type DateTimePreview struct {
+	Input    string `json:"input"`    // input path, e.g. "start_time" or "event.start"
+	Original string `json:"original"` // value as written in the workflow
+	Timezone string `json:"timezone"`
+	Resolved string `json:"resolved"` // value sent to MCP
+	Display  string `json:"display"`  // e.g. "2025-01-15 08:00 Europe/Sofia → 2025-01-15T08:00:00+02:00"
+}
+
+// buildDateTimePreviews compares raw and resolved step inputs and reports every
+// datetime value that was resolved with a timezone offset
+func (ee *ExecutionEngine) buildDateTimePreviews(rawInputs, resolvedInputs map[string]interface{}, context *ParameterContext) []DateTimePreview {
+	timezone, _ := context.SystemParameters["user_timezone"].(string)
+	loc := time.UTC
+	if timezone != "" {
+		if loaded, err := time.LoadLocation(timezone); err == nil {
+			loc = loaded
+		}
+	} else {
+		timezone = "UTC"
+	}
+
+	var previews []DateTimePreview
+	keys := make([]string, 0, len(rawInputs))
+	for key := range rawInputs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		previews = ee.collectDateTimePreviews(key, rawInputs[key], resolvedInputs[key], timezone, loc, previews)
+	}
+	return previews
+}
+
+// collectDateTimePreviews walks raw and resolved values in parallel, appending previews for datetime leaves
+func (ee *ExecutionEngine) collectDateTimePreviews(path string, raw, resolved interface{}, timezone string, loc *time.Location, previews []DateTimePreview) []DateTimePreview {
+	switch r := raw.(type) {
+	case string:
+		resolvedStr, ok := resolved.(string)
+		if !ok || resolvedStr == r {
+			return previews
+		}
+		parsed, err := time.Parse(time.RFC3339, resolvedStr)
+		if err != nil {
+			return previews
+		}
+		previews = append(previews, DateTimePreview{
+			Input:    path,
+			Original: r,
+			Timezone: timezone,
+			Resolved: resolvedStr,
+			Display:  fmt.Sprintf("%s %s → %s", parsed.In(loc).Format("2006-01-02 15:04"), timezone, resolvedStr),
+		})
+	case map[string]interface{}:
+		resolvedMap, ok := resolved.(map[string]interface{})
+		if !ok {
+			return previews
+		}
+		keys := make([]string, 0, len(r))
+		for key := range r {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			previews = ee.collectDateTimePreviews(path+"."+key, r[key], resolvedMap[key], timezone, loc, previews)
+		}
+	case []interface{}:
+		resolvedList, ok := resolved.([]interface{})
+		if !ok || len(resolvedList) != len(r) {
+			return previews
+		}
+		for i := range r {
+			previews = ee.collectDateTimePreviews(fmt.Sprintf("%s[%d]", path, i), r[i], resolvedList[i], timezone, loc, previews)
+		}
+	}
+	return previews
+}
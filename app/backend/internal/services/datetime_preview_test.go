@@ -0,0 +1,55 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestDateTimePreviewsOnResolvedSteps verifies timezone-applied datetimes are surfaced per step
+func TestDateTimePreviewsOnResolvedSteps(t *testing.T) {
+	ee := NewExecutionEngine(nil)
+
+	context := &ParameterContext{
+		UserParameters: map[string]interface{}{
+			"meeting_start": "2025-01-15T08:00:00",
+		},
+		RuntimeParameters: map[string]interface{}{},
+		SystemParameters: map[string]interface{}{
+			"user_timezone": "Europe/Sofia",
+		},
+		StepOutputs: map[string]interface{}{},
+	}
+
+	steps := []WorkflowStep{
+		{
+			ID:      "schedule",
+			Service: "calendar",
+			Action:  "create_event",
+			Inputs: map[string]interface{}{
+				"title":      "Standup",
+				"start_time": "${user.meeting_start}",
+			},
+		},
+	}
+
+	resolved, validationErrors := ee.resolveWorkflowParameters(steps, context)
+	if len(validationErrors) > 0 {
+		t.Fatalf("Unexpected validation errors: %v", validationErrors)
+	}
+
+	previews := resolved[0].DateTimePreviews
+	if len(previews) != 1 {
+		t.Fatalf("Expected 1 datetime preview, got %d: %+v", len(previews), previews)
+	}
+
+	preview := previews[0]
+	if preview.Input != "start_time" {
+		t.Errorf("Expected preview for start_time, got %s", preview.Input)
+	}
+	if preview.Resolved != "2025-01-15T08:00:00+02:00" {
+		t.Errorf("Unexpected resolved value: %s", preview.Resolved)
+	}
+	if !strings.Contains(preview.Display, "08:00 Europe/Sofia → 2025-01-15T08:00:00+02:00") {
+		t.Errorf("Unexpected display: %s", preview.Display)
+	}
+}
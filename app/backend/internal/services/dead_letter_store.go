@@ -0,0 +1,184 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxFailedExecutionsPerUser bounds the dead-letter entries kept per user; the oldest are dropped first
+const maxFailedExecutionsPerUser = 50
+
+// FailedExecution is a dead-letter record of an execution that failed, kept so it can be replayed
+type FailedExecution struct {
+	ExecutionID    string                 `json:"execution_id"`
+	UserID         string                 `json:"user_id"`
+	WorkflowID     string                 `json:"workflow_id"` // stored workflow to re-run
+	WorkflowName   string                 `json:"workflow_name"`
+	UserParameters map[string]interface{} `json:"user_parameters"`
+	UserTimezone   string                 `json:"user_timezone,omitempty"`
+	Connection     string                 `json:"connection,omitempty"`
+	CallbackURL    string                 `json:"callback_url,omitempty"`
+	FailedStep     string                 `json:"failed_step,omitempty"`
+	CompletedSteps []string               `json:"completed_steps,omitempty"`
+	Error          string                 `json:"error"`
+	FailedAt       time.Time              `json:"failed_at"`
+	ReplayOf       string                 `json:"replay_of,omitempty"` // execution this one replayed
+	ReplayCount    int                    `json:"replay_count"`
+
+	// Plan as it stood at failure, with completed step outputs; not exposed since it holds the OAuth token
+	plan *ExecutionPlan
+}
+
+// DeadLetterStore keeps failed executions in memory for inspection and replay
+type DeadLetterStore struct {
+	entries map[string]*FailedExecution // executionID -> entry
+	mutex   sync.RWMutex
+}
+
+// NewDeadLetterStore creates an empty dead-letter store
+func NewDeadLetterStore() *DeadLetterStore {
+	return &DeadLetterStore{
+		entries: make(map[string]*FailedExecution),
+	}
+}
+
+// NewFailedExecution builds a dead-letter record from a failed plan
+func NewFailedExecution(executionID, userID string, plan *ExecutionPlan, execErr error) *FailedExecution {
+	entry := &FailedExecution{
+		ExecutionID:    executionID,
+		UserID:         userID,
+		WorkflowID:     plan.SourceWorkflowID,
+		WorkflowName:   plan.Name,
+		UserParameters: make(map[string]interface{}),
+		Connection:     plan.Connection,
+		CallbackURL:    plan.CallbackURL,
+		FailedAt:       time.Now(),
+		plan:           plan,
+	}
+	if execErr != nil {
+		entry.Error = execErr.Error()
+	}
+	if plan.ParameterContext != nil {
+		for key, value := range plan.ParameterContext.UserParameters {
+			entry.UserParameters[key] = value
+		}
+		entry.UserTimezone, _ = plan.ParameterContext.SystemParameters["user_timezone"].(string)
+	}
+	for _, step := range plan.ResolvedSteps {
+		switch step.Status {
+		case StepCompleted:
+			entry.CompletedSteps = append(entry.CompletedSteps, step.ID)
+		case StepFailed:
+			if entry.FailedStep == "" {
+				entry.FailedStep = step.ID
+			}
+		}
+	}
+	return entry
+}
+
+// Record stores a failed execution, dropping the user's oldest entries beyond the cap
+func (s *DeadLetterStore) Record(entry *FailedExecution) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.entries[entry.ExecutionID] = entry
+	log.Printf("[DeadLetterStore] Recorded failed execution %s for user %s (step: %s): %s", entry.ExecutionID, entry.UserID, entry.FailedStep, entry.Error)
+
+	userEntries := s.listLocked(entry.UserID)
+	for _, stale := range userEntries[min(len(userEntries), maxFailedExecutionsPerUser):] {
+		delete(s.entries, stale.ExecutionID)
+	}
+}
+
+// List returns the user's failed executions, newest first
+func (s *DeadLetterStore) List(userID string) []*FailedExecution {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.listLocked(userID)
+}
+
+// listLocked returns the user's entries newest first; caller must hold the mutex
+func (s *DeadLetterStore) listLocked(userID string) []*FailedExecution {
+	var entries []*FailedExecution
+	for _, entry := range s.entries {
+		if entry.UserID == userID {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].FailedAt.After(entries[j].FailedAt)
+	})
+	return entries
+}
+
+// Get returns a user's failed execution
+func (s *DeadLetterStore) Get(executionID, userID string) (*FailedExecution, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	entry, exists := s.entries[executionID]
+	if !exists || entry.UserID != userID {
+		return nil, fmt.Errorf("no failed execution found: %s", executionID)
+	}
+	return entry, nil
+}
+
+// Remove deletes a failed execution, e.g. once it has been replayed
+func (s *DeadLetterStore) Remove(executionID string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.entries, executionID)
+}
+
+// ResumePlan returns a copy of the failed plan that re-runs only the steps that did not
+// complete, reusing the recorded outputs of completed steps. oauthToken replaces the
+// token captured at failure time, which may have expired.
+func (f *FailedExecution) ResumePlan(oauthToken string) (*ExecutionPlan, error) {
+	if f.plan == nil || f.plan.ParameterContext == nil {
+		return nil, fmt.Errorf("execution %s has no recorded plan to resume", f.ExecutionID)
+	}
+
+	plan := *f.plan
+	plan.ValidationErrors = nil
+	plan.ValidationWarnings = append([]string(nil), f.plan.ValidationWarnings...)
+	plan.ParameterErrors = nil
+	plan.PendingConfirmation = nil
+	plan.SimulationLog = nil
+
+	plan.ResolvedSteps = make([]ResolvedStep, len(f.plan.ResolvedSteps))
+	for i, step := range f.plan.ResolvedSteps {
+		step.Outputs = copyMap(step.Outputs)
+		step.StatusHistory = append([]StepStatusChange(nil), step.StatusHistory...)
+		if step.Status == StepFailed {
+			if err := step.transitionTo(StepPending); err != nil {
+				return nil, err
+			}
+		}
+		plan.ResolvedSteps[i] = step
+	}
+
+	recorded := f.plan.ParameterContext
+	context := &ParameterContext{
+		UserParameters:    copyMap(recorded.UserParameters),
+		RuntimeParameters: copyMap(recorded.RuntimeParameters),
+		SystemParameters:  copyMap(recorded.SystemParameters),
+		StepOutputs:       recorded.StepOutputsSnapshot(),
+	}
+	context.SystemParameters["oauth_token"] = oauthToken
+	plan.ParameterContext = context
+
+	return &plan, nil
+}
+
+// copyMap returns a shallow copy of a map
+func copyMap(source map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(source))
+	for key, value := range source {
+		copied[key] = value
+	}
+	return copied
+}
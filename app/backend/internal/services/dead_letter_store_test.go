@@ -0,0 +1,92 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestDeadLetterStoreRecordsAndResumes verifies failed executions are recorded and resumed from the failed step
+func TestDeadLetterStoreRecordsAndResumes(t *testing.T) {
+	store := NewDeadLetterStore()
+	plan := &ExecutionPlan{
+		Name:             "weekly_report",
+		SourceWorkflowID: "wf_1",
+		ResolvedSteps: []ResolvedStep{
+			{ID: "create", Status: "completed", Outputs: map[string]interface{}{"document_id": "doc_1"}},
+			{ID: "share", Status: "failed", Outputs: map[string]interface{}{}},
+			{ID: "notify", Status: "pending", Outputs: map[string]interface{}{}},
+		},
+		ParameterContext: &ParameterContext{
+			UserParameters:    map[string]interface{}{"recipient": "team@example.com"},
+			RuntimeParameters: map[string]interface{}{},
+			SystemParameters:  map[string]interface{}{"oauth_token": "expired_token", "user_timezone": "Europe/Sofia"},
+			StepOutputs: map[string]interface{}{
+				"create": map[string]interface{}{"document_id": "doc_1"},
+			},
+		},
+	}
+
+	entry := NewFailedExecution("exec_1", "user_1", plan, errors.New("step share failed: quota exceeded"))
+	store.Record(entry)
+
+	if entry.FailedStep != "share" || entry.WorkflowID != "wf_1" || entry.UserTimezone != "Europe/Sofia" {
+		t.Errorf("Unexpected dead-letter entry: %+v", entry)
+	}
+	if len(entry.CompletedSteps) != 1 || entry.CompletedSteps[0] != "create" {
+		t.Errorf("Expected create to be recorded as completed, got %v", entry.CompletedSteps)
+	}
+	if _, err := store.Get("exec_1", "other_user"); err == nil {
+		t.Error("Expected other users not to see the entry")
+	}
+
+	resumed, err := entry.ResumePlan("fresh_token")
+	if err != nil {
+		t.Fatalf("ResumePlan failed: %v", err)
+	}
+	expectedStatus := map[string]StepStatus{"create": StepCompleted, "share": StepPending, "notify": StepPending}
+	for _, step := range resumed.ResolvedSteps {
+		if step.Status != expectedStatus[step.ID] {
+			t.Errorf("Step %s: expected status %s, got %s", step.ID, expectedStatus[step.ID], step.Status)
+		}
+	}
+	if resumed.ParameterContext.SystemParameters["oauth_token"] != "fresh_token" {
+		t.Error("Expected resumed plan to use the fresh token")
+	}
+
+	// Mutating the resumed plan must not change the recorded one
+	resumed.ParameterContext.StepOutputs["create"].(map[string]interface{})["document_id"] = "changed"
+	resumed.ResolvedSteps[1].Outputs["link"] = "https://example.com"
+	if plan.ParameterContext.StepOutputs["create"].(map[string]interface{})["document_id"] != "doc_1" || len(plan.ResolvedSteps[1].Outputs) != 0 {
+		t.Error("Expected resumed plan to be a copy of the recorded plan")
+	}
+}
+
+// TestDeadLetterStoreListsNewestFirstWithCap verifies ordering and the per-user cap
+func TestDeadLetterStoreListsNewestFirstWithCap(t *testing.T) {
+	store := NewDeadLetterStore()
+	start := time.Now()
+	for i := 0; i < maxFailedExecutionsPerUser+5; i++ {
+		entry := NewFailedExecution(fmt.Sprintf("exec_%d", i), "user_1", &ExecutionPlan{}, errors.New("failed"))
+		entry.FailedAt = start.Add(time.Duration(i) * time.Second)
+		store.Record(entry)
+	}
+	store.Record(NewFailedExecution("exec_other", "user_2", &ExecutionPlan{}, errors.New("failed")))
+
+	entries := store.List("user_1")
+	if len(entries) != maxFailedExecutionsPerUser {
+		t.Fatalf("Expected %d entries, got %d", maxFailedExecutionsPerUser, len(entries))
+	}
+	if !entries[0].FailedAt.After(entries[len(entries)-1].FailedAt) {
+		t.Error("Expected newest entries first")
+	}
+	if !entries[len(entries)-1].FailedAt.Equal(start.Add(5 * time.Second)) {
+		t.Errorf("Expected the oldest entries to be dropped, last is %v", entries[len(entries)-1].FailedAt)
+	}
+
+	store.Remove(entries[0].ExecutionID)
+	if len(store.List("user_1")) != maxFailedExecutionsPerUser-1 {
+		t.Error("Expected entry to be removed")
+	}
+}
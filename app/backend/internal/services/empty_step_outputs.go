@@ -0,0 +1,101 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// ErrStepProducedNoOutput is returned when a step completed without any of its declared
+// outputs while later steps reference them
+var ErrStepProducedNoOutput = errors.New("step produced no output")
+
+// SetWarnOnEmptyStepOutputs reports steps that produced none of their declared outputs as
+// validation warnings instead of failing the execution
+func (ee *ExecutionEngine) SetWarnOnEmptyStepOutputs(warn bool) {
+	ee.warnOnEmptyStepOutputs = warn
+}
+
+// checkStepProducedOutputs verifies a completed step returned at least one of the output
+// fields its action's schema declares. Otherwise later ${steps.<id>.outputs.<field>}
+// references would be passed on unresolved: the execution fails when later steps reference
+// the step's outputs, unless the engine warns instead. Steps of actions without an output
+// schema can't be checked and pass.
+func (ee *ExecutionEngine) checkStepProducedOutputs(step *ResolvedStep, plan *ExecutionPlan) error {
+	declared := ee.declaredOutputFields(step)
+	if len(declared) == 0 {
+		return nil
+	}
+	outputs, _ := plan.ParameterContext.StepOutput(step.ID)
+	for _, field := range declared {
+		if value, exists := outputs[field]; exists && value != nil {
+			return nil
+		}
+	}
+
+	references := referencingSteps(step.ID, plan.ResolvedSteps)
+	problem := fmt.Sprintf("step %s (%s.%s) produced none of its declared outputs (%s)", step.ID, step.Service, step.Action, strings.Join(declared, ", "))
+	if len(references) > 0 {
+		problem += "; referenced by " + strings.Join(references, ", ")
+	}
+	log.Printf("[ExecutionEngine] WARNING: %s", problem)
+
+	if len(references) > 0 && !ee.warnOnEmptyStepOutputs {
+		return fmt.Errorf("%w: %s", ErrStepProducedNoOutput, problem)
+	}
+	step.ValidationWarnings = append(step.ValidationWarnings, problem)
+	return nil
+}
+
+// declaredOutputFields returns the output properties the catalog declares for a step's action, sorted
+func (ee *ExecutionEngine) declaredOutputFields(step *ResolvedStep) []string {
+	if ee.mcpService == nil {
+		return nil
+	}
+	catalog, err := ee.mcpService.GetServiceCatalog()
+	if err != nil {
+		log.Printf("[ExecutionEngine] Skipping output check of step %s, catalog unavailable: %v", step.ID, err)
+		return nil
+	}
+	_, serviceDefinition, err := catalog.ResolveService(step.Provider, step.Service)
+	if err != nil {
+		return nil
+	}
+	functionSchema, exists := serviceDefinition.Functions[step.Action]
+	if !exists || functionSchema.OutputSchema == nil {
+		return nil
+	}
+
+	fields := make([]string, 0, len(functionSchema.OutputSchema.Properties))
+	for field := range functionSchema.OutputSchema.Properties {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// referencingSteps describes the references to a step's outputs in other steps' inputs,
+// e.g. "send.body (steps.export.outputs.document_url)"
+func referencingSteps(stepID string, steps []ResolvedStep) []string {
+	var references []string
+	for _, other := range steps {
+		if other.ID == stepID {
+			continue
+		}
+		inputNames := make([]string, 0, len(other.Inputs))
+		for name := range other.Inputs {
+			inputNames = append(inputNames, name)
+		}
+		sort.Strings(inputNames)
+		for _, name := range inputNames {
+			for _, ref := range referencedStepOutputs(other.Inputs[name]) {
+				if ref.stepID == stepID {
+					references = append(references, fmt.Sprintf("%s.%s (steps.%s.outputs.%s)", other.ID, name, stepID, ref.field))
+				}
+			}
+		}
+	}
+	return references
+}
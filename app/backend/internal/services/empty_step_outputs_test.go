@@ -0,0 +1,98 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newEmptyOutputMCPServer serves a catalog where docs.create_document declares outputs but returns no data
+func newEmptyOutputMCPServer() *httptest.Server {
+	catalogJSON := `{"providers": {"workspace": {"services": {
+		"docs": {"functions": {"create_document": {"name": "create_document", "output_schema": {"type": "object", "properties": {
+			"document_id": {"type": "string"},
+			"document_url": {"type": "string"}
+		}}}}},
+		"gmail": {"functions": {"send_message": {"name": "send_message"}}}
+	}}}}`
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			w.Write([]byte(catalogJSON))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"result": map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": `{}`}}},
+		})
+	}))
+}
+
+// newEmptyOutputPlan creates a plan whose send step references the create step's document_url when referenced is true
+func newEmptyOutputPlan(referenced bool) *ExecutionPlan {
+	body := "Report ready"
+	if referenced {
+		body = "Report: ${steps.create.outputs.document_url}"
+	}
+	return &ExecutionPlan{
+		Name: "report",
+		ResolvedSteps: []ResolvedStep{
+			{ID: "create", Service: "docs", Action: "create_document", Inputs: map[string]interface{}{"title": "Report"}, Outputs: map[string]interface{}{}},
+			{ID: "send", Service: "gmail", Action: "send_message", Inputs: map[string]interface{}{"body": body}, Outputs: map[string]interface{}{}, DependsOn: []string{"create"}},
+		},
+		ParameterContext: &ParameterContext{
+			StepOutputs:      map[string]interface{}{},
+			SystemParameters: map[string]interface{}{"oauth_token": "token"},
+		},
+	}
+}
+
+// TestEmptyStepOutputsFailReferencedSteps verifies a step without its declared outputs fails when later steps use them
+func TestEmptyStepOutputsFailReferencedSteps(t *testing.T) {
+	server := newEmptyOutputMCPServer()
+	defer server.Close()
+	ee := NewExecutionEngine(NewMCPService(server.URL))
+
+	plan := newEmptyOutputPlan(true)
+	err := ee.ExecuteWorkflow(plan)
+	if !errors.Is(err, ErrStepProducedNoOutput) {
+		t.Fatalf("Expected ErrStepProducedNoOutput, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "send.body (steps.create.outputs.document_url)") {
+		t.Errorf("Expected the error to name the referencing step and field, got %v", err)
+	}
+	if plan.ResolvedSteps[0].Status != StepFailed {
+		t.Errorf("Expected the empty step to fail, got %s", plan.ResolvedSteps[0].Status)
+	}
+	if plan.ResolvedSteps[1].Status != "" && plan.ResolvedSteps[1].Status != StepPending {
+		t.Errorf("Expected the referencing step not to run, got %s", plan.ResolvedSteps[1].Status)
+	}
+}
+
+// TestEmptyStepOutputsWarn verifies unreferenced empty outputs, or all of them when configured, only warn
+func TestEmptyStepOutputsWarn(t *testing.T) {
+	server := newEmptyOutputMCPServer()
+	defer server.Close()
+	ee := NewExecutionEngine(NewMCPService(server.URL))
+
+	plan := newEmptyOutputPlan(false)
+	if err := ee.ExecuteWorkflow(plan); err != nil {
+		t.Fatalf("Expected unreferenced empty outputs not to fail, got %v", err)
+	}
+	if !strings.Contains(strings.Join(plan.ValidationWarnings, "\n"), "produced none of its declared outputs (document_id, document_url)") {
+		t.Errorf("Expected an empty output warning, got %v", plan.ValidationWarnings)
+	}
+
+	ee.SetWarnOnEmptyStepOutputs(true)
+	plan = newEmptyOutputPlan(true)
+	ee.ExecuteWorkflow(plan)
+	if plan.ResolvedSteps[0].Status != StepCompleted {
+		t.Errorf("Expected the empty step to complete when warning, got %s", plan.ResolvedSteps[0].Status)
+	}
+	if !strings.Contains(strings.Join(plan.ValidationWarnings, "\n"), "referenced by send.body") {
+		t.Errorf("Expected a warning naming the reference, got %v", plan.ValidationWarnings)
+	}
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"sohoaas-backend/internal/types"
+)
+
+// EnumInputOptions returns the allowed values of an action's enum input fields, keyed by
+// field, e.g. {"role": ["reader", "commenter", "writer"]} for drive.share_file
+func EnumInputOptions(functionSchema types.MCPFunctionSchema) map[string][]string {
+	options := make(map[string][]string)
+	if functionSchema.InputSchema == nil {
+		return options
+	}
+	for field, property := range functionSchema.InputSchema.Properties {
+		if len(property.Enum) > 0 {
+			options[field] = property.Enum
+		}
+	}
+	return options
+}
+
+// validateEnumInputs reports step inputs whose value is outside the allowed values the
+// catalog declares for the field, listing the allowed values. Inputs still holding a
+// ${steps.*} reference are only known at runtime and are skipped.
+func (ee *ExecutionEngine) validateEnumInputs(steps []ResolvedStep) []string {
+	if ee.mcpService == nil {
+		return nil
+	}
+	catalog, err := ee.mcpService.GetServiceCatalog()
+	if err != nil {
+		log.Printf("[ExecutionEngine] Skipping enum input validation, catalog unavailable: %v", err)
+		return nil
+	}
+
+	var validationErrors []string
+	for _, step := range steps {
+		_, serviceDefinition, err := catalog.ResolveService(step.Provider, step.Service)
+		if err != nil {
+			continue
+		}
+		options := EnumInputOptions(serviceDefinition.Functions[step.Action])
+
+		fields := make([]string, 0, len(options))
+		for field := range options {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		for _, field := range fields {
+			value, provided := step.Inputs[field]
+			if !provided || value == nil {
+				continue
+			}
+			if text, ok := value.(string); ok && strings.Contains(text, "${") {
+				continue
+			}
+			if !enumAllows(options[field], value) {
+				validationErrors = append(validationErrors, fmt.Sprintf("Step %s: %s %q is not allowed, expected one of: %s", step.ID, field, fmt.Sprint(value), strings.Join(options[field], ", ")))
+			}
+		}
+	}
+	return validationErrors
+}
+
+// enumAllows reports whether value is one of the allowed values
+func enumAllows(allowed []string, value interface{}) bool {
+	text := fmt.Sprint(value)
+	for _, option := range allowed {
+		if text == option {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"sohoaas-backend/internal/types"
+)
+
+// enumCatalog returns a catalog whose drive.share_file declares the allowed roles
+func enumCatalog() *types.MCPServiceCatalog {
+	return &types.MCPServiceCatalog{
+		SchemaVersion: types.CatalogSchemaVersion,
+		Providers: types.MCPProviders{
+			Workspace: types.MCPWorkspaceProvider{
+				Services: map[string]types.MCPServiceDefinition{
+					"drive": {
+						Functions: map[string]types.MCPFunctionSchema{
+							"share_file": {
+								Name:           "share_file",
+								RequiredFields: []string{"file_id", "email", "role"},
+								InputSchema: &types.MCPParameterSchema{
+									Type: "object",
+									Properties: map[string]types.MCPParameterProperty{
+										"file_id": {Type: "string"},
+										"role":    {Type: "string", Enum: []string{"reader", "commenter", "writer"}},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// TestEnumInputOptions verifies only enum fields are listed
+func TestEnumInputOptions(t *testing.T) {
+	options := EnumInputOptions(enumCatalog().Providers.Workspace.Services["drive"].Functions["share_file"])
+	expected := map[string][]string{"role": {"reader", "commenter", "writer"}}
+	if !reflect.DeepEqual(options, expected) {
+		t.Errorf("Expected %v, got %v", expected, options)
+	}
+	if options := EnumInputOptions(types.MCPFunctionSchema{}); len(options) != 0 {
+		t.Errorf("Expected no options without an input schema, got %v", options)
+	}
+}
+
+// TestValidateEnumInputs verifies values outside the allowed set fail with the allowed values listed
+func TestValidateEnumInputs(t *testing.T) {
+	executionEngine := NewExecutionEngine(NewFakeMCPService(enumCatalog()))
+	share := func(id string, role interface{}) ResolvedStep {
+		return ResolvedStep{
+			ID:      id,
+			Service: "drive",
+			Action:  "share_file",
+			Inputs:  map[string]interface{}{"file_id": "file-1", "email": "a@example.com", "role": role},
+		}
+	}
+
+	validationErrors := executionEngine.validateEnumInputs([]ResolvedStep{
+		share("valid", "writer"),
+		share("invalid", "owner"),
+		share("runtime", "${steps.lookup.outputs.role}"),
+	})
+	if len(validationErrors) != 1 {
+		t.Fatalf("Expected 1 validation error, got %v", validationErrors)
+	}
+	for _, expected := range []string{"Step invalid", `"owner"`, "reader, commenter, writer"} {
+		if !strings.Contains(validationErrors[0], expected) {
+			t.Errorf("Expected %q in %q", expected, validationErrors[0])
+		}
+	}
+}
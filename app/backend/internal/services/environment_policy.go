@@ -0,0 +1,59 @@
+package services
+
+import (
+	"log"
+	"strings"
+
+	"sohoaas-backend/internal/workflowparser"
+)
+
+// Execution environments a workflow can declare in execution_config.environment
+const (
+	EnvironmentDevelopment = workflowparser.EnvironmentDevelopment
+	EnvironmentStaging     = workflowparser.EnvironmentStaging
+	EnvironmentProduction  = workflowparser.EnvironmentProduction
+)
+
+// destructiveActionPrefixes lists action name prefixes that delete or revoke user data
+var destructiveActionPrefixes = []string{"delete_", "remove_", "trash_", "clear_", "cancel_", "revoke_"}
+
+// EnvironmentPolicy controls how workflows tagged with an environment are executed
+type EnvironmentPolicy struct {
+	ConfirmDestructiveSteps bool              // hold delete-style steps for user approval
+	SandboxWrites           bool              // record write steps instead of sending them to MCP
+	Limiter                 *ExecutionLimiter // per-user quotas; nil uses the engine's default limiter
+}
+
+// isDestructiveAction reports whether an action deletes or revokes user data
+func isDestructiveAction(action string) bool {
+	for _, prefix := range destructiveActionPrefixes {
+		if strings.HasPrefix(action, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetEnvironmentPolicy sets the execution policy for workflows tagged with environment
+func (ee *ExecutionEngine) SetEnvironmentPolicy(environment string, policy EnvironmentPolicy) {
+	if ee.environmentPolicies == nil {
+		ee.environmentPolicies = make(map[string]EnvironmentPolicy)
+	}
+	ee.environmentPolicies[environment] = policy
+	log.Printf("[ExecutionEngine] Environment %s: confirm_destructive=%t sandbox_writes=%t custom_quotas=%t",
+		environment, policy.ConfirmDestructiveSteps, policy.SandboxWrites, policy.Limiter != nil)
+}
+
+// applyEnvironmentPolicy flags the plan's steps according to its environment's policy
+func (ee *ExecutionEngine) applyEnvironmentPolicy(plan *ExecutionPlan) {
+	policy := ee.environmentPolicies[plan.Environment]
+	for i := range plan.ResolvedSteps {
+		step := &plan.ResolvedSteps[i]
+		if policy.ConfirmDestructiveSteps && isDestructiveAction(step.Action) {
+			step.RequiresConfirmation = true
+		}
+		if policy.SandboxWrites && classifyStepOperation(step.Action) == OperationWrite {
+			step.Sandboxed = true
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// TestParseCUEWorkflowEnvironment verifies execution_config.environment is parsed and defaulted
+func TestParseCUEWorkflowEnvironment(t *testing.T) {
+	ee := NewExecutionEngine(nil)
+
+	workflowWithEnvironment := func(executionConfig string) string {
+		return `workflow: {
+	name:        "cleanup"
+	description: "Delete old drafts"
+	steps: [{
+		id:     "delete"
+		name:   "Delete draft"
+		action: "gmail.delete_draft"
+		inputs: {draft_id: "d1"}
+	}]
+	` + executionConfig + `
+}`
+	}
+
+	tests := []struct {
+		name            string
+		executionConfig string
+		expected        string
+	}{
+		{"default", "", EnvironmentDevelopment},
+		{"production", `execution_config: {mode: "sequential", environment: "production"}`, EnvironmentProduction},
+		{"staging", `execution_config: {mode: "sequential", environment: "staging"}`, EnvironmentStaging},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workflow, err := ee.ParseCUEWorkflow(workflowWithEnvironment(tt.executionConfig))
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if workflow.Environment != tt.expected {
+				t.Errorf("Expected environment %q, got %q", tt.expected, workflow.Environment)
+			}
+		})
+	}
+
+	if _, err := ee.ParseCUEWorkflow(workflowWithEnvironment(`execution_config: {mode: "sequential", environment: "qa"}`)); err == nil {
+		t.Error("Expected unknown environment to be rejected")
+	}
+}
+
+// TestApplyEnvironmentPolicy verifies destructive steps are held and writes sandboxed per environment
+func TestApplyEnvironmentPolicy(t *testing.T) {
+	ee := NewExecutionEngine(nil)
+	ee.SetEnvironmentPolicy(EnvironmentProduction, EnvironmentPolicy{ConfirmDestructiveSteps: true})
+	ee.SetEnvironmentPolicy(EnvironmentDevelopment, EnvironmentPolicy{SandboxWrites: true})
+
+	newPlan := func(environment string) *ExecutionPlan {
+		return &ExecutionPlan{
+			Environment: environment,
+			ResolvedSteps: []ResolvedStep{
+				{ID: "list", Service: "gmail", Action: "list_messages"},
+				{ID: "send", Service: "gmail", Action: "send_message"},
+				{ID: "delete", Service: "drive", Action: "delete_file"},
+			},
+		}
+	}
+
+	production := newPlan(EnvironmentProduction)
+	ee.applyEnvironmentPolicy(production)
+	for _, step := range production.ResolvedSteps {
+		if step.RequiresConfirmation != (step.ID == "delete") {
+			t.Errorf("Production step %s: unexpected requires_confirmation %t", step.ID, step.RequiresConfirmation)
+		}
+		if step.Sandboxed {
+			t.Errorf("Production step %s should not be sandboxed", step.ID)
+		}
+	}
+
+	development := newPlan(EnvironmentDevelopment)
+	ee.applyEnvironmentPolicy(development)
+	for _, step := range development.ResolvedSteps {
+		if step.Sandboxed != (step.ID != "list") {
+			t.Errorf("Development step %s: unexpected sandboxed %t", step.ID, step.Sandboxed)
+		}
+		if step.RequiresConfirmation {
+			t.Errorf("Development step %s should not require confirmation", step.ID)
+		}
+	}
+
+	staging := newPlan(EnvironmentStaging)
+	ee.applyEnvironmentPolicy(staging)
+	for _, step := range staging.ResolvedSteps {
+		if step.Sandboxed || step.RequiresConfirmation {
+			t.Errorf("Staging step %s should be unaffected without a policy", step.ID)
+		}
+	}
+}
+
+// TestAcquireExecutionSlotUsesEnvironmentQuotas verifies environments with quotas use their own limiter
+func TestAcquireExecutionSlotUsesEnvironmentQuotas(t *testing.T) {
+	ee := NewExecutionEngine(nil)
+	ee.SetEnvironmentPolicy(EnvironmentProduction, EnvironmentPolicy{Limiter: NewExecutionLimiter(1, 0)})
+
+	release, _, err := ee.AcquireExecutionSlot(context.Background(), "user_1", EnvironmentProduction)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer release()
+
+	if _, _, err := ee.AcquireExecutionSlot(context.Background(), "user_1", EnvironmentProduction); !errors.Is(err, ErrExecutionQueueFull) {
+		t.Errorf("Expected production quota to be exhausted, got %v", err)
+	}
+
+	releaseDev, _, err := ee.AcquireExecutionSlot(context.Background(), "user_1", EnvironmentDevelopment)
+	if err != nil {
+		t.Fatalf("Expected default limiter for development, got %v", err)
+	}
+	releaseDev()
+}
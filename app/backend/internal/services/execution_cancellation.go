@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+)
+
+// ErrExecutionCancelled is returned when an execution is stopped with CancelExecution
+var ErrExecutionCancelled = errors.New("workflow execution cancelled")
+
+// ErrExecutionIDInUse is returned when an execution ID is already running or held for confirmation
+var ErrExecutionIDInUse = errors.New("execution ID already in use")
+
+// validExecutionID restricts client-supplied execution IDs to URL-safe tokens
+var validExecutionID = regexp.MustCompile(`^[A-Za-z0-9_-]{8,64}$`)
+
+// NewExecutionID returns a random execution ID
+func NewExecutionID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		panic(fmt.Sprintf("failed to generate execution ID: %v", err))
+	}
+	return "exec_" + hex.EncodeToString(buf)
+}
+
+// ValidateExecutionID checks a client-supplied execution ID
+func ValidateExecutionID(id string) error {
+	if !validExecutionID.MatchString(id) {
+		return fmt.Errorf("execution ID must be 8-64 letters, digits, '-' or '_'")
+	}
+	return nil
+}
+
+// ExecutionIDInUse reports whether an execution with the ID is running or awaiting confirmation
+func (ee *ExecutionEngine) ExecutionIDInUse(executionID string) bool {
+	ee.runningMu.Lock()
+	_, running := ee.runningExecutions[executionID]
+	ee.runningMu.Unlock()
+
+	ee.pendingMu.Lock()
+	_, pending := ee.pendingConfirmations[executionID]
+	ee.pendingMu.Unlock()
+
+	return running || pending
+}
+
+// runningExecution is an execution started with RunExecution that can still be cancelled
+type runningExecution struct {
+	UserID string
+	cancel context.CancelCauseFunc
+}
+
+// RunExecution executes a prepared plan like ExecuteWorkflow while registering it under
+// executionID, so the user can stop it with CancelExecution until it finishes. The run is
+// written to the audit log when one is configured. An executionID that is already running is
+// rejected with ErrExecutionIDInUse.
+func (ee *ExecutionEngine) RunExecution(executionID, userID string, plan *ExecutionPlan) error {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+
+	ee.runningMu.Lock()
+	if _, exists := ee.runningExecutions[executionID]; exists {
+		ee.runningMu.Unlock()
+		return fmt.Errorf("%w: %s", ErrExecutionIDInUse, executionID)
+	}
+	ee.runningExecutions[executionID] = &runningExecution{UserID: userID, cancel: cancel}
+	ee.runningMu.Unlock()
+	defer func() {
+		ee.runningMu.Lock()
+		delete(ee.runningExecutions, executionID)
+		ee.runningMu.Unlock()
+	}()
+
+	err := ee.executeWorkflowContext(withAuditScope(ctx, executionID, userID), plan)
+	ee.recordAudit(executionID, userID, plan, err)
+	return err
+}
+
+// CancelExecution stops a running execution of the user. The step in flight is aborted and
+// later steps are not run; the execution then ends with ErrExecutionCancelled.
+func (ee *ExecutionEngine) CancelExecution(executionID, userID string) error {
+	ee.runningMu.Lock()
+	defer ee.runningMu.Unlock()
+
+	running, exists := ee.runningExecutions[executionID]
+	if !exists || running.UserID != userID {
+		return fmt.Errorf("no running execution: %s", executionID)
+	}
+	running.cancel(ErrExecutionCancelled)
+	log.Printf("[ExecutionEngine] Execution %s cancelled by user %s", executionID, userID)
+	return nil
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newBlockingExecution returns an engine whose MCP calls block until cancelled, a two-step
+// plan and a channel signalled when the first call is in flight
+func newBlockingExecution(t *testing.T) (*ExecutionEngine, *ExecutionPlan, chan struct{}) {
+	called := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`{"providers": {"workspace": {"services": {}}}}`))
+			return
+		}
+		called <- struct{}{}
+		// Hold the call until the execution is cancelled; the request context is only cancelled
+		// once the body has been read
+		io.Copy(io.Discard, r.Body)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"result": map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": `{}`}}},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	ee := NewExecutionEngine(NewMCPService(server.URL))
+	plan := &ExecutionPlan{
+		Name: "slow",
+		ResolvedSteps: []ResolvedStep{
+			{ID: "first", Service: "gmail", Action: "send_message", Inputs: map[string]interface{}{}, Outputs: map[string]interface{}{}},
+			{ID: "second", Service: "gmail", Action: "send_message", Inputs: map[string]interface{}{}, Outputs: map[string]interface{}{}, DependsOn: []string{"first"}},
+		},
+		ParameterContext: &ParameterContext{
+			StepOutputs:      map[string]interface{}{},
+			SystemParameters: map[string]interface{}{"oauth_token": "token"},
+		},
+	}
+	return ee, plan, called
+}
+
+// TestCancelExecution verifies cancelling aborts the in-flight step and leaves later steps pending
+func TestCancelExecution(t *testing.T) {
+	ee, plan, called := newBlockingExecution(t)
+
+	done := make(chan error, 1)
+	started := time.Now()
+	go func() { done <- ee.RunExecution("exec-1", "user-1", plan) }()
+	<-called
+
+	if err := ee.CancelExecution("exec-1", "user-2"); err == nil {
+		t.Error("Expected another user's execution not to be cancellable")
+	}
+	if err := ee.CancelExecution("exec-1", "user-1"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	err := <-done
+	if !errors.Is(err, ErrExecutionCancelled) {
+		t.Fatalf("Expected ErrExecutionCancelled, got %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > 2*time.Second {
+		t.Errorf("Expected the in-flight call to be canceled, execution took %s", elapsed)
+	}
+	if status := ExecutionStatus(err); status != ExecutionStatusCancelled {
+		t.Errorf("Expected status %s, got %s", ExecutionStatusCancelled, status)
+	}
+	if plan.ResolvedSteps[0].Status != StepFailed {
+		t.Errorf("Expected the aborted step to fail, got %s", plan.ResolvedSteps[0].Status)
+	}
+	if plan.ResolvedSteps[1].Status != "" && plan.ResolvedSteps[1].Status != StepPending {
+		t.Errorf("Expected the remaining step not to run, got %s", plan.ResolvedSteps[1].Status)
+	}
+	if err := ee.CancelExecution("exec-1", "user-1"); err == nil {
+		t.Error("Expected a finished execution not to be cancellable")
+	}
+}
+
+// TestRunExecutionRejectsExecutionIDInUse verifies a client-chosen ID identifies one in-flight
+// run: a second run under it is refused and the first can be cancelled by it
+func TestRunExecutionRejectsExecutionIDInUse(t *testing.T) {
+	ee, plan, called := newBlockingExecution(t)
+
+	done := make(chan error, 1)
+	go func() { done <- ee.RunExecution("client-run-1", "user-1", plan) }()
+	<-called
+
+	if !ee.ExecutionIDInUse("client-run-1") {
+		t.Error("Expected the running execution ID to be in use")
+	}
+	if err := ee.RunExecution("client-run-1", "user-1", &ExecutionPlan{}); !errors.Is(err, ErrExecutionIDInUse) {
+		t.Errorf("Expected ErrExecutionIDInUse, got %v", err)
+	}
+	if err := ee.CancelExecution("client-run-1", "user-1"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := <-done; !errors.Is(err, ErrExecutionCancelled) {
+		t.Fatalf("Expected ErrExecutionCancelled, got %v", err)
+	}
+	if ee.ExecutionIDInUse("client-run-1") {
+		t.Error("Expected the execution ID to be free once the run ended")
+	}
+}
+
+// TestNewExecutionID verifies generated IDs are unique and pass ValidateExecutionID
+func TestNewExecutionID(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		id := NewExecutionID()
+		if seen[id] {
+			t.Fatalf("Duplicate execution ID %s", id)
+		}
+		seen[id] = true
+		if err := ValidateExecutionID(id); err != nil {
+			t.Errorf("Generated ID %s is invalid: %v", id, err)
+		}
+	}
+}
+
+// TestValidateExecutionID verifies client-supplied IDs must be URL-safe tokens
+func TestValidateExecutionID(t *testing.T) {
+	for id, valid := range map[string]bool{
+		"client-run-1":          true,
+		"exec_0123456789abcdef": true,
+		"short":                 false,
+		"has space in it":       false,
+		"../../etc/passwd":      false,
+		"":                      false,
+	} {
+		if err := ValidateExecutionID(id); (err == nil) != valid {
+			t.Errorf("ValidateExecutionID(%q) = %v, expected valid=%t", id, err, valid)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// confirmationTTL bounds how long a plan may wait for user approval
+const confirmationTTL = 1 * time.Hour
+
+// pendingConfirmation holds a prepared plan until the user approves its risky steps
+type pendingConfirmation struct {
+	UserID    string
+	Plan      *ExecutionPlan
+	CreatedAt time.Time
+}
+
+// ApproveSteps removes the given step IDs from the plan's pending confirmation list
+// and returns the step IDs that still require approval
+func (ee *ExecutionEngine) ApproveSteps(plan *ExecutionPlan, approvedStepIDs []string) []string {
+	if plan == nil || len(plan.PendingConfirmation) == 0 {
+		return nil
+	}
+
+	approved := make(map[string]bool, len(approvedStepIDs))
+	for _, id := range approvedStepIDs {
+		approved[id] = true
+	}
+
+	var remaining []string
+	for _, id := range plan.PendingConfirmation {
+		if !approved[id] {
+			remaining = append(remaining, id)
+		}
+	}
+	plan.PendingConfirmation = remaining
+	return remaining
+}
+
+// HoldForConfirmation parks a plan until ConfirmExecution is called for the same execution
+func (ee *ExecutionEngine) HoldForConfirmation(executionID, userID string, plan *ExecutionPlan) {
+	ee.pendingMu.Lock()
+	defer ee.pendingMu.Unlock()
+
+	ee.cleanupExpiredConfirmations()
+	ee.pendingConfirmations[executionID] = &pendingConfirmation{
+		UserID:    userID,
+		Plan:      plan,
+		CreatedAt: time.Now(),
+	}
+	log.Printf("[ExecutionEngine] Execution %s held for confirmation of steps: %v", executionID, plan.PendingConfirmation)
+}
+
+// ConfirmExecution applies the user's approvals to a held plan. The plan is released
+// for execution only when every pending step has been approved; otherwise it stays held
+// and the remaining step IDs are returned.
+func (ee *ExecutionEngine) ConfirmExecution(executionID, userID string, approvedStepIDs []string) (*ExecutionPlan, []string, error) {
+	ee.pendingMu.Lock()
+	defer ee.pendingMu.Unlock()
+
+	ee.cleanupExpiredConfirmations()
+	pending, exists := ee.pendingConfirmations[executionID]
+	if !exists || pending.UserID != userID {
+		return nil, nil, fmt.Errorf("no execution awaiting confirmation: %s", executionID)
+	}
+
+	remaining := ee.ApproveSteps(pending.Plan, approvedStepIDs)
+	if len(remaining) > 0 {
+		return nil, remaining, nil
+	}
+
+	delete(ee.pendingConfirmations, executionID)
+	return pending.Plan, nil, nil
+}
+
+// cleanupExpiredConfirmations drops held plans older than confirmationTTL; caller must hold pendingMu
+func (ee *ExecutionEngine) cleanupExpiredConfirmations() {
+	for id, pending := range ee.pendingConfirmations {
+		if time.Since(pending.CreatedAt) > confirmationTTL {
+			log.Printf("[ExecutionEngine] Confirmation for execution %s expired", id)
+			delete(ee.pendingConfirmations, id)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"testing"
+
+	"sohoaas-backend/internal/types"
+)
+
+// TestPrepareExecutionCollectsConfirmationSteps verifies requires_confirmation steps are held
+func TestPrepareExecutionCollectsConfirmationSteps(t *testing.T) {
+	mockServer := NewMockMCPServer(t)
+	defer mockServer.Close()
+	mockServer.SetDefaultGoogleWorkspaceResponses()
+
+	ee := NewExecutionEngine(NewMCPService(mockServer.URL()))
+	user := &types.User{ID: "test_user_123", Email: "test@example.com"}
+
+	workflowCUE := `
+workflow: {
+	name: "confirm_workflow"
+	description: "Workflow with a confirmation gate"
+	steps: [
+		{
+			id: "notify"
+			action: "gmail.send_message"
+			parameters: {
+				to: "client@example.com"
+				subject: "Hello"
+				body: "External email"
+			}
+			requires_confirmation: true
+		}
+	]
+}
+`
+
+	plan, err := ee.PrepareExecution(workflowCUE, user.ID, user, map[string]interface{}{}, "mock_oauth_token_valid", "UTC")
+	if err != nil {
+		t.Fatalf("PrepareExecution failed: %v", err)
+	}
+
+	if len(plan.PendingConfirmation) != 1 || plan.PendingConfirmation[0] != "notify" {
+		t.Fatalf("Expected notify to await confirmation, got %v", plan.PendingConfirmation)
+	}
+
+	if err := ee.ExecuteWorkflow(plan); err == nil {
+		t.Error("Expected execution to be refused while confirmation is pending")
+	}
+}
+
+// TestConfirmExecution verifies held plans are released only after all steps are approved
+func TestConfirmExecution(t *testing.T) {
+	ee := NewExecutionEngine(nil)
+
+	plan := &ExecutionPlan{
+		ResolvedSteps: []ResolvedStep{
+			{ID: "notify", Service: "gmail", Action: "send_message", RequiresConfirmation: true},
+			{ID: "invite", Service: "calendar", Action: "create_event", RequiresConfirmation: true},
+		},
+		PendingConfirmation: []string{"notify", "invite"},
+	}
+
+	ee.HoldForConfirmation("exec_1", "user_1", plan)
+
+	if _, _, err := ee.ConfirmExecution("exec_1", "other_user", []string{"notify", "invite"}); err == nil {
+		t.Error("Expected error when a different user confirms")
+	}
+
+	released, remaining, err := ee.ConfirmExecution("exec_1", "user_1", []string{"notify"})
+	if err != nil {
+		t.Fatalf("ConfirmExecution failed: %v", err)
+	}
+	if released != nil {
+		t.Error("Plan should stay held while steps are pending")
+	}
+	if len(remaining) != 1 || remaining[0] != "invite" {
+		t.Errorf("Expected invite to remain pending, got %v", remaining)
+	}
+
+	released, remaining, err = ee.ConfirmExecution("exec_1", "user_1", []string{"invite"})
+	if err != nil {
+		t.Fatalf("ConfirmExecution failed: %v", err)
+	}
+	if released == nil || len(remaining) != 0 {
+		t.Fatalf("Expected plan to be released, remaining: %v", remaining)
+	}
+
+	if _, _, err := ee.ConfirmExecution("exec_1", "user_1", nil); err == nil {
+		t.Error("Expected error once the plan has been released")
+	}
+}
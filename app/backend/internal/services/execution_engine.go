@@ -1,17 +1,17 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
-	"os"
-	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
-	"cuelang.org/go/cue"
-	"cuelang.org/go/cue/cuecontext"
 	"sohoaas-backend/internal/types"
+	"sohoaas-backend/internal/workflowparser"
 )
 
 // ExecutionEngine handles workflow execution with parameter replacement
@@ -19,49 +19,71 @@ type ExecutionEngine struct {
 	mcpService     *MCPService
 	mcpParser      *MCPCatalogParser
 	serviceCatalog types.ServiceCatalog
-}
 
-// inlineDeterministicSchema attempts to prepend the deterministic workflow schema
-// to the provided CUE content so references like #DeterministicWorkflow resolve.
-func (ee *ExecutionEngine) inlineDeterministicSchema(cueContent string) string {
-    // Try to load schema content
-    schemaContent := ee.loadDeterministicSchema()
-    if schemaContent == "" {
-        // Nothing to inline; return original content
-        return cueContent
-    }
-
-    // Remove package declarations from both to avoid conflicts
-    cleanedSchema := ee.removePackageDeclaration(schemaContent)
-    cleanedContent := ee.removePackageDeclaration(cueContent)
-
-    // Concatenate schema then content
-    return cleanedSchema + "\n\n" + cleanedContent
-}
+	// Plans waiting for explicit user approval, keyed by execution ID
+	pendingConfirmations map[string]*pendingConfirmation
+	pendingMu            sync.Mutex
+
+	// Executions started with RunExecution, keyed by execution ID, so they can be cancelled
+	runningExecutions map[string]*runningExecution
+	runningMu         sync.Mutex
+
+	// Per-user cap on concurrently running executions
+	limiter *ExecutionLimiter
+
+	// Stored workflows with execution_config.exclusive that are running, keyed by user and workflow
+	workflowLocks   map[string]*workflowLock
+	workflowLocksMu sync.Mutex
+
+	// Stored user preferences (e.g. default timezone), optional
+	userPreferences *UserPreferencesService
+
+	// Stored user profiles (display name, team list) seeding declared parameters, optional
+	userProfiles *UserProfileService
+
+	// Completion callbacks for executions with a callback URL, optional
+	webhookNotifier *WebhookNotifier
+
+	// Named workspace connections for steps bound to a non-default account, optional
+	tokenManager *TokenManager
+
+	// Named secrets for service bindings with auth.secret_ref, optional
+	secretStore SecretStore
+
+	// Outputs of cacheable read steps and how long they are reused, optional
+	stepCache    StepOutputCache
+	stepCacheTTL time.Duration
+
+	// Failed executions kept for replay, optional
+	deadLetters *DeadLetterStore
+
+	// Append-only record of executions and their side effects, optional
+	auditSink AuditSink
+
+	// Last parameters submitted per stored workflow, for prefilling re-runs; optional
+	parameterHistory *ParameterHistoryStore
+
+	// Policies keyed by execution_config.environment, optional
+	environmentPolicies map[string]EnvironmentPolicy
+
+	// Largest decoded binary step output; 0 uses defaultMaxBinaryOutputBytes
+	maxBinaryOutputBytes int
+
+	// Runtime budget of workflows without execution_config.timeout; 0 uses defaultWorkflowTimeout
+	defaultWorkflowTimeout time.Duration
+
+	// Report steps that returned none of their declared outputs instead of failing the execution
+	warnOnEmptyStepOutputs bool
 
-// loadDeterministicSchema loads rac/schemas/deterministic_workflow.cue using RAC_CONTEXT_PATH
-// if available, with sensible fallbacks relative to current working directory.
-func (ee *ExecutionEngine) loadDeterministicSchema() string {
-    // Preferred: RAC_CONTEXT_PATH env var points to repo root containing rac/
-    if root := os.Getenv("RAC_CONTEXT_PATH"); root != "" {
-        if content := ee.readFileIfExists(filepath.Join(root, "rac", "schemas", "deterministic_workflow.cue")); content != "" {
-            return content
-        }
-    }
-
-    // Fallbacks: common relative paths when running backend from app/backend/
-    candidates := []string{
-        filepath.Join("..", "..", "..", "rac", "schemas", "deterministic_workflow.cue"), // from app/backend/internal/services/
-        filepath.Join("..", "..", "rac", "schemas", "deterministic_workflow.cue"),        // from app/backend/internal/
-        filepath.Join("rac", "schemas", "deterministic_workflow.cue"),                        // from repo root
-    }
-    for _, p := range candidates {
-        if content := ee.readFileIfExists(p); content != "" {
-            return content
-        }
-    }
-    log.Printf("[ExecutionEngine] Warning: deterministic_workflow.cue not found; proceeding without inlined schema")
-    return ""
+	// Check before running that the user's tokens grant every bound service's scopes
+	requireConnectedServices bool
+
+	// Custom reference kinds such as ${vault.*}, tried in order before the built-ins
+	parameterResolvers []ParameterResolver
+	resolversMu        sync.RWMutex
+
+	// Encrypts sensitive user parameters kept for replay, optional
+	parameterCipher *ParameterCipher
 }
 
 // NewExecutionEngine creates a new execution engine
@@ -70,9 +92,82 @@ func NewExecutionEngine(mcpService *MCPService) *ExecutionEngine {
 		mcpService:     mcpService,
 		mcpParser:      NewMCPCatalogParser(),
 		serviceCatalog: types.ServiceCatalog{}, // Will be populated dynamically from MCP
+
+		pendingConfirmations: make(map[string]*pendingConfirmation),
+		runningExecutions:    make(map[string]*runningExecution),
+		workflowLocks:        make(map[string]*workflowLock),
+		limiter:              NewExecutionLimiter(3, 10),
 	}
 }
 
+// SetExecutionLimiter replaces the default per-user concurrency limiter
+func (ee *ExecutionEngine) SetExecutionLimiter(limiter *ExecutionLimiter) {
+	ee.limiter = limiter
+}
+
+// SetUserPreferences enables defaults from stored user preferences
+func (ee *ExecutionEngine) SetUserPreferences(prefs *UserPreferencesService) {
+	ee.userPreferences = prefs
+}
+
+// SetWebhookNotifier enables signed completion callbacks
+func (ee *ExecutionEngine) SetWebhookNotifier(notifier *WebhookNotifier) {
+	ee.webhookNotifier = notifier
+}
+
+// SetTokenManager enables per-step connection bindings (e.g. connection: "work")
+func (ee *ExecutionEngine) SetTokenManager(tokenManager *TokenManager) {
+	ee.tokenManager = tokenManager
+}
+
+// SetDeadLetterStore enables recording of failed executions for replay
+func (ee *ExecutionEngine) SetDeadLetterStore(store *DeadLetterStore) {
+	ee.deadLetters = store
+}
+
+// DeadLetters returns the failed execution store, or nil when not configured
+func (ee *ExecutionEngine) DeadLetters() *DeadLetterStore {
+	return ee.deadLetters
+}
+
+// RecordFailure stores a failed execution in the dead-letter store, if one is configured
+func (ee *ExecutionEngine) RecordFailure(executionID, userID string, plan *ExecutionPlan, execErr error) *FailedExecution {
+	if ee.deadLetters == nil || plan == nil || execErr == nil {
+		return nil
+	}
+	entry := NewFailedExecution(executionID, userID, plan, execErr)
+	ee.ProtectFailedExecution(entry)
+	ee.deadLetters.Record(entry)
+	return entry
+}
+
+// WebhooksEnabled reports whether callback URLs can be honored
+func (ee *ExecutionEngine) WebhooksEnabled() bool {
+	return ee.webhookNotifier.Enabled()
+}
+
+// ValidateCallbackURL checks a completion callback URL against the webhook notifier's rules
+func (ee *ExecutionEngine) ValidateCallbackURL(callbackURL string) error {
+	return ee.webhookNotifier.ValidateCallbackURL(callbackURL)
+}
+
+// NotifyCompletion sends the completion callback for a finished plan, if it has a callback URL
+func (ee *ExecutionEngine) NotifyCompletion(executionID string, plan *ExecutionPlan, execErr error) {
+	if plan == nil || plan.CallbackURL == "" || !ee.webhookNotifier.Enabled() {
+		return
+	}
+	ee.webhookNotifier.NotifyAsync(plan.CallbackURL, BuildWebhookPayload(executionID, plan, execErr))
+}
+
+// AcquireExecutionSlot waits for a free per-user execution slot; see ExecutionLimiter.Acquire.
+// Environments with their own quotas use their policy's limiter.
+func (ee *ExecutionEngine) AcquireExecutionSlot(ctx context.Context, userID, environment string) (func(), int, error) {
+	if policy, exists := ee.environmentPolicies[environment]; exists && policy.Limiter != nil {
+		return policy.Limiter.Acquire(ctx, userID)
+	}
+	return ee.limiter.Acquire(ctx, userID)
+}
+
 // ValidateWorkflowServices validates that all services in a workflow exist in the MCP service catalog
 // and validates output field references against MCP response schemas
 func (ee *ExecutionEngine) ValidateWorkflowServices(workflow *ParsedWorkflow) error {
@@ -80,6 +175,11 @@ func (ee *ExecutionEngine) ValidateWorkflowServices(workflow *ParsedWorkflow) er
 		return fmt.Errorf("workflow is nil")
 	}
 	
+	// Reject service bindings requesting OAuth scopes this deployment doesn't permit
+	if err := ee.mcpService.CheckOAuthScopes(workflow.OAuthScopes); err != nil {
+		return err
+	}
+	
 	// Query live MCP service catalog for validation using centralized MCPService
 	mcpServices, err := ee.mcpService.GetServiceCatalog()
 	if err != nil {
@@ -103,21 +203,45 @@ func (ee *ExecutionEngine) validateWorkflowServicesInternal(mcpCatalog interface
 		return err
 	}
 	
+	typedCatalog, _ := mcpCatalog.(*types.MCPServiceCatalog)
 	for i, step := range workflow.Steps {
+		// Typed catalogs resolve the service in the step's provider and reject ambiguous names
+		if typedCatalog != nil {
+			// Map action name variations to the catalog's functions before checking them
+			service, action, err := ee.mcpParser.NormalizeAction(typedCatalog, step.Service, step.Action)
+			if err != nil {
+				return fmt.Errorf("invalid action in step %d (%s): %w", i, step.ID, err)
+			}
+			if service != step.Service || action != step.Action {
+				log.Printf("[ExecutionEngine] Normalized step %s action %s.%s to %s.%s", step.ID, step.Service, step.Action, service, action)
+				step.Service, step.Action = service, action
+				workflow.Steps[i] = step
+			}
+			if err := ee.mcpService.CheckServiceAllowed(step.Service); err != nil {
+				return fmt.Errorf("step %d (%s): %w", i, step.ID, err)
+			}
+			
+			provider, serviceDefinition, err := typedCatalog.ResolveService(step.Provider, step.Service)
+			if err != nil {
+				return fmt.Errorf("invalid service '%s' in step %d (%s): %w: %w", step.Service, i, step.ID, ErrServiceNotInCatalog, err)
+			}
+			if _, actionExists := serviceDefinition.Functions[step.Action]; !actionExists {
+				return fmt.Errorf("%w: unknown action '%s' for service '%s' (provider %s) in step %d (%s)", ErrServiceNotInCatalog, step.Action, step.Service, provider, i, step.ID)
+			}
+			continue
+		}
+		
+		if err := ee.mcpService.CheckServiceAllowed(step.Service); err != nil {
+			return fmt.Errorf("step %d (%s): %w", i, step.ID, err)
+		}
+		if step.Provider != "" {
+			return fmt.Errorf("provider '%s' in step %d (%s) can't be checked: the MCP catalog lists no providers", step.Provider, i, step.ID)
+		}
+		
 		// Validate service exists in MCP catalog
 		serviceData, exists := servicesData[step.Service]
 		if !exists {
-			return fmt.Errorf("unknown service '%s' in step %d (%s) - service not found in MCP catalog", step.Service, i, step.ID)
-		}
-		
-		// Handle strongly-typed service definition
-		if serviceDefinition, ok := serviceData.(types.MCPServiceDefinition); ok {
-			// Check if action exists in the service's functions
-			_, actionExists := serviceDefinition.Functions[step.Action]
-			if !actionExists {
-				return fmt.Errorf("unknown action '%s' for service '%s' in step %d (%s) - action not found in MCP catalog", step.Action, step.Service, i, step.ID)
-			}
-			continue
+			return fmt.Errorf("%w: unknown service '%s' in step %d (%s)", ErrServiceNotInCatalog, step.Service, i, step.ID)
 		}
 		
 		// Fallback: Handle legacy map format for backward compatibility
@@ -133,7 +257,7 @@ func (ee *ExecutionEngine) validateWorkflowServicesInternal(mcpCatalog interface
 		
 		_, actionExists := functions[step.Action]
 		if !actionExists {
-			return fmt.Errorf("unknown action '%s' for service '%s' in step %d (%s) - action not found in MCP catalog", step.Action, step.Service, i, step.ID)
+			return fmt.Errorf("%w: unknown action '%s' for service '%s' in step %d (%s)", ErrServiceNotInCatalog, step.Action, step.Service, i, step.ID)
 		}
 	}
 	
@@ -144,10 +268,10 @@ func (ee *ExecutionEngine) validateWorkflowServicesInternal(mcpCatalog interface
 func (ee *ExecutionEngine) validateOutputFieldReferences(mcpCatalog *types.MCPServiceCatalog, workflow *ParsedWorkflow) error {
 	stepOutputRegex := regexp.MustCompile(`\$\{steps\.([^.]+)\.outputs\.([^}]+)\}`)
 	
-	// Build map of step ID to service/action for lookup
-	stepServiceMap := make(map[string]struct{service, action string})
+	// Build map of step ID to provider/service/action for lookup
+	stepServiceMap := make(map[string]stepTarget)
 	for _, step := range workflow.Steps {
-		stepServiceMap[step.ID] = struct{service, action string}{step.Service, step.Action}
+		stepServiceMap[step.ID] = stepTarget{provider: step.Provider, service: step.Service, action: step.Action}
 	}
 	
 	// Check each step's parameters for output field references
@@ -161,8 +285,13 @@ func (ee *ExecutionEngine) validateOutputFieldReferences(mcpCatalog *types.MCPSe
 	return nil
 }
 
+// stepTarget identifies the catalog function a step calls
+type stepTarget struct {
+	provider, service, action string
+}
+
 // validateParameterOutputReferences recursively validates output field references in parameters
-func (ee *ExecutionEngine) validateParameterOutputReferences(params map[string]interface{}, stepOutputRegex *regexp.Regexp, stepServiceMap map[string]struct{service, action string}, mcpCatalog *types.MCPServiceCatalog) error {
+func (ee *ExecutionEngine) validateParameterOutputReferences(params map[string]interface{}, stepOutputRegex *regexp.Regexp, stepServiceMap map[string]stepTarget, mcpCatalog *types.MCPServiceCatalog) error {
 	for paramName, paramValue := range params {
 		switch v := paramValue.(type) {
 		case string:
@@ -170,7 +299,7 @@ func (ee *ExecutionEngine) validateParameterOutputReferences(params map[string]i
 			matches := stepOutputRegex.FindAllStringSubmatch(v, -1)
 			for _, match := range matches {
 				stepID := match[1]
-				outputField := match[2]
+				outputField := stepOutputBaseField(match[2])
 				
 				// Get service and action for the referenced step
 				stepInfo, exists := stepServiceMap[stepID]
@@ -179,7 +308,7 @@ func (ee *ExecutionEngine) validateParameterOutputReferences(params map[string]i
 				}
 				
 				// Validate that the output field exists in the MCP function's output schema
-				if err := ee.validateOutputFieldExists(stepInfo.service, stepInfo.action, outputField, mcpCatalog); err != nil {
+				if err := ee.validateOutputFieldExists(stepInfo.provider, stepInfo.service, stepInfo.action, outputField, mcpCatalog); err != nil {
 					return fmt.Errorf("parameter %s references invalid output field %s.%s: %w", paramName, stepID, outputField, err)
 				}
 			}
@@ -202,12 +331,13 @@ func (ee *ExecutionEngine) validateParameterOutputReferences(params map[string]i
 	return nil
 }
 
-// validateOutputFieldExists checks if an output field exists in the MCP function's output schema
-func (ee *ExecutionEngine) validateOutputFieldExists(service, action, outputField string, mcpCatalog *types.MCPServiceCatalog) error {
-	// Check if service exists
-	serviceDefinition, exists := mcpCatalog.Providers.Workspace.Services[service]
-	if !exists {
-		return fmt.Errorf("service '%s' not found in MCP catalog", service)
+// validateOutputFieldExists checks if an output field exists in the MCP function's output schema.
+// provider may be empty, in which case the service is looked up across all providers.
+func (ee *ExecutionEngine) validateOutputFieldExists(provider, service, action, outputField string, mcpCatalog *types.MCPServiceCatalog) error {
+	// Resolve the service in the step's provider, or in the only provider that defines it
+	_, serviceDefinition, err := mcpCatalog.ResolveService(provider, service)
+	if err != nil {
+		return err
 	}
 	
 	// Check if function exists
@@ -239,37 +369,117 @@ type ParameterContext struct {
 	UserParameters    map[string]interface{} `json:"user_parameters"`
 	RuntimeParameters map[string]interface{} `json:"runtime_parameters"`
 	SystemParameters  map[string]interface{} `json:"system_parameters"`
-	StepOutputs       map[string]interface{} `json:"step_outputs"`
+	StepOutputs       map[string]interface{} `json:"step_outputs"` // access through the step output methods below
+
+	stepOutputsMutex sync.RWMutex
+}
+
+// StepOutput returns a copy of the outputs recorded for a step
+func (pc *ParameterContext) StepOutput(stepID string) (map[string]interface{}, bool) {
+	pc.stepOutputsMutex.RLock()
+	defer pc.stepOutputsMutex.RUnlock()
+
+	outputMap, ok := pc.StepOutputs[stepID].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	return copyMap(outputMap), true
+}
+
+// SetStepOutputs merges outputs into those recorded for a step
+func (pc *ParameterContext) SetStepOutputs(stepID string, outputs map[string]interface{}) {
+	pc.stepOutputsMutex.Lock()
+	defer pc.stepOutputsMutex.Unlock()
+
+	if pc.StepOutputs == nil {
+		pc.StepOutputs = make(map[string]interface{})
+	}
+	stepOutputs, ok := pc.StepOutputs[stepID].(map[string]interface{})
+	if !ok {
+		stepOutputs = make(map[string]interface{}, len(outputs))
+		pc.StepOutputs[stepID] = stepOutputs
+	}
+	for key, value := range outputs {
+		stepOutputs[key] = value
+	}
+}
+
+// HasStepOutputs reports whether any step has recorded outputs, i.e. execution has started
+func (pc *ParameterContext) HasStepOutputs() bool {
+	pc.stepOutputsMutex.RLock()
+	defer pc.stepOutputsMutex.RUnlock()
+	return len(pc.StepOutputs) > 0
+}
+
+// StepOutputsSnapshot returns a copy of every step's outputs keyed by step id
+func (pc *ParameterContext) StepOutputsSnapshot() map[string]interface{} {
+	pc.stepOutputsMutex.RLock()
+	defer pc.stepOutputsMutex.RUnlock()
+
+	snapshot := make(map[string]interface{}, len(pc.StepOutputs))
+	for stepID, outputs := range pc.StepOutputs {
+		if outputMap, ok := outputs.(map[string]interface{}); ok {
+			outputs = copyMap(outputMap)
+		}
+		snapshot[stepID] = outputs
+	}
+	return snapshot
 }
 
 // ExecutionPlan represents a workflow ready for execution with resolved parameters
 type ExecutionPlan struct {
-	WorkflowID       string                 `json:"workflow_id"`
-	Name             string                 `json:"name"`
-	Description      string                 `json:"description"`
-	ResolvedSteps    []ResolvedStep         `json:"resolved_steps"`
-	ParameterContext *ParameterContext      `json:"parameter_context"`
-	ValidationErrors []string               `json:"validation_errors,omitempty"`
+	WorkflowID          string              `json:"workflow_id"`
+	Name                string              `json:"name"`
+	Description         string              `json:"description"`
+	ResolvedSteps       []ResolvedStep      `json:"resolved_steps"`
+	ParameterContext    *ParameterContext   `json:"parameter_context"`
+	ValidationErrors    []string            `json:"validation_errors,omitempty"`    // block execution
+	ValidationWarnings  []string            `json:"validation_warnings,omitempty"`  // non-blocking; returned with the execution result
+	ParameterErrors     map[string]string   `json:"parameter_errors,omitempty"`     // user parameter -> validation rule failure
+	PendingConfirmation []string            `json:"pending_confirmation,omitempty"` // step IDs awaiting user approval
+	CallbackURL         string              `json:"callback_url,omitempty"`         // notified when execution finishes
+	SourceWorkflowID    string              `json:"source_workflow_id,omitempty"`   // stored workflow the plan was prepared from
+	Connection          string              `json:"connection,omitempty"`           // connection of the execution's default token
+	Environment         string              `json:"environment,omitempty"`          // execution_config.environment; selects the policy
+	Simulate            bool                `json:"simulate,omitempty"`             // record MCP calls instead of making them
+	SimulationLog       []SimulatedCall     `json:"simulation_log,omitempty"`       // calls recorded in simulate mode, in order
+	Timeout             time.Duration       `json:"-"`                              // execution_config.timeout; 0 uses the engine default
+	Exclusive           bool                `json:"exclusive,omitempty"`            // execution_config.exclusive; one run of the stored workflow at a time
+	OAuthScopes         map[string][]string `json:"oauth_scopes,omitempty"`         // scopes declared by each service binding
+	SensitiveParameters []string            `json:"sensitive_parameters,omitempty"` // user parameters whose values are masked, see MarshalJSON
 }
 
 // ResolvedStep represents a workflow step with all parameters resolved
 type ResolvedStep struct {
-	ID          string                 `json:"id"`
-	Name        string                 `json:"name"`
-	Service     string                 `json:"service"`
-	Action      string                 `json:"action"`
-	Inputs      map[string]interface{} `json:"inputs"`
-	Outputs     map[string]interface{} `json:"outputs"`
-	DependsOn   []string               `json:"depends_on,omitempty"`
-	Status      string                 `json:"status"` // pending, running, completed, failed
+	ID                   string                 `json:"id"`
+	Name                 string                 `json:"name"`
+	Service              string                 `json:"service"`
+	Action               string                 `json:"action"`
+	Inputs               map[string]interface{} `json:"inputs"`
+	Outputs              map[string]interface{} `json:"outputs"`
+	DependsOn            []string               `json:"depends_on,omitempty"`
+	Status               StepStatus             `json:"status"`
+	StatusHistory        []StepStatusChange     `json:"status_history,omitempty"` // transitions in order, for execution history
+	RequiresConfirmation bool                   `json:"requires_confirmation,omitempty"`
+	DateTimePreviews     []DateTimePreview      `json:"datetime_previews,omitempty"`   // datetime inputs after timezone resolution
+	Connection           string                 `json:"connection,omitempty"`          // named workspace connection; empty uses the execution's token
+	Sandboxed            bool                   `json:"sandboxed,omitempty"`           // write recorded instead of sent (environment policy)
+	Provider             string                 `json:"provider,omitempty"`            // catalog provider; empty searches all providers
+	SecretRef            string                 `json:"secret_ref,omitempty"`          // named secret sent instead of the OAuth token; never the value
+	Cacheable            bool                   `json:"cacheable,omitempty"`           // read step whose outputs may be reused within the cache TTL
+	CacheHit             bool                   `json:"cache_hit,omitempty"`           // outputs came from the step output cache
+	ValidationWarnings   []string               `json:"validation_warnings,omitempty"` // non-fatal findings while executing, e.g. response schema mismatches
 }
 
+// ErrInvalidWorkflow is returned when a workflow's CUE can't be parsed into a runnable workflow
+var ErrInvalidWorkflow = errors.New("invalid workflow")
+
 // PrepareExecution analyzes a CUE workflow and creates an execution plan
 func (ee *ExecutionEngine) PrepareExecution(cueworkflow string, userID string, user *types.User, intentAnalysis map[string]interface{}, oauthToken string, userTimezone string) (*ExecutionPlan, error) {
 	// Parse the CUE workflow (simplified - would use actual CUE parser in production)
 	workflow, err := ee.ParseCUEWorkflow(cueworkflow)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse CUE workflow: %w", err)
+		return nil, fmt.Errorf("%w: failed to parse CUE workflow: %w", ErrInvalidWorkflow, err)
 	}
 
 	// Validate all services in workflow against service catalog
@@ -278,10 +488,17 @@ func (ee *ExecutionEngine) PrepareExecution(cueworkflow string, userID string, u
 	}
 
 	// Create parameter context from intent analysis and user data
-	paramContext := ee.createParameterContext(intentAnalysis, user, oauthToken, userTimezone)
+	paramContext := ee.createParameterContext(intentAnalysis, workflow.UserParameterDefaults, user, oauthToken, userTimezone)
+	ee.applyProfileParameters(paramContext, workflow.UserParameters, user)
+
+	// Enforce declared validation rules (email, url, regex) on user parameter values
+	parameterErrors := validateUserParameters(workflow.UserParameterValidation, paramContext.UserParameters)
 
 	// Resolve all parameters in workflow steps
 	resolvedSteps, validationErrors := ee.resolveWorkflowParameters(workflow.Steps, paramContext)
+	validationErrors = append(validationErrors, ee.validateStepConnections(userID, resolvedSteps)...)
+	validationErrors = append(validationErrors, ee.validateStepSecrets(userID, resolvedSteps)...)
+	validationErrors = append(validationErrors, ee.validateEnumInputs(resolvedSteps)...)
 
 	executionPlan := &ExecutionPlan{
 		WorkflowID:       fmt.Sprintf("%s_%d", userID, time.Now().Unix()),
@@ -290,13 +507,41 @@ func (ee *ExecutionEngine) PrepareExecution(cueworkflow string, userID string, u
 		ResolvedSteps:    resolvedSteps,
 		ParameterContext: paramContext,
 		ValidationErrors: validationErrors,
+		Environment:      workflow.Environment,
+		Timeout:          workflow.Timeout,
+		Exclusive:        workflow.Exclusive,
+		OAuthScopes:      workflow.OAuthScopes,
+
+		SensitiveParameters: workflow.SensitiveParameters,
+	}
+	if warnings := ee.collectValidationWarnings(workflow); len(warnings) > 0 {
+		executionPlan.ValidationWarnings = warnings
+	}
+	if len(parameterErrors) > 0 {
+		// Validation messages quote the rejected value
+		for name, message := range parameterErrors {
+			parameterErrors[name] = maskSensitiveValues(message, executionPlan.sensitiveValues()).(string)
+		}
+		executionPlan.ParameterErrors = parameterErrors
+	}
+
+	// Apply the environment's policy (confirmation of destructive steps, sandboxed writes)
+	ee.applyEnvironmentPolicy(executionPlan)
+
+	// Collect steps that must be approved by the user before execution
+	for _, step := range executionPlan.ResolvedSteps {
+		if step.RequiresConfirmation {
+			executionPlan.PendingConfirmation = append(executionPlan.PendingConfirmation, step.ID)
+		}
 	}
 
 	return executionPlan, nil
 }
 
-// createParameterContext builds the parameter context from various sources
-func (ee *ExecutionEngine) createParameterContext(intentAnalysis map[string]interface{}, user *types.User, oauthToken string, userTimezone string) *ParameterContext {
+// createParameterContext builds the parameter context from various sources.
+// parameterDefaults holds the workflow's declared user_parameters defaults; they apply
+// to parameters the request doesn't supply.
+func (ee *ExecutionEngine) createParameterContext(intentAnalysis map[string]interface{}, parameterDefaults map[string]interface{}, user *types.User, oauthToken string, userTimezone string) *ParameterContext {
 	context := &ParameterContext{
 		UserParameters:    make(map[string]interface{}),
 		RuntimeParameters: make(map[string]interface{}),
@@ -329,12 +574,23 @@ func (ee *ExecutionEngine) createParameterContext(intentAnalysis map[string]inte
 		}
 	}
 
+	// Apply declared defaults for parameters the request omitted
+	for paramName, defaultValue := range parameterDefaults {
+		if value, exists := context.UserParameters[paramName]; !exists || value == nil {
+			context.UserParameters[paramName] = defaultValue
+		}
+	}
+
 	// Set system parameters
 	context.SystemParameters["current_date"] = time.Now().Format("2006-01-02")
 	context.SystemParameters["current_datetime"] = time.Now().Format("2006-01-02T15:04:05")
 	context.SystemParameters["user_email"] = user.Email
 	context.SystemParameters["user_id"] = user.ID
 	context.SystemParameters["oauth_token"] = oauthToken
+	// Fall back to the user's stored timezone when the request omits one
+	if userTimezone == "" && ee.userPreferences != nil {
+		userTimezone = ee.userPreferences.GetTimezone(user.ID)
+	}
 	context.SystemParameters["user_timezone"] = userTimezone
 
 	return context
@@ -351,10 +607,13 @@ func (ee *ExecutionEngine) resolveUserParameter(paramDef map[string]interface{},
 	case "user_id":
 		return user.ID
 	default:
-		// Return default value if available, otherwise placeholder
+		// Return default value if available, then the user's profile value, otherwise placeholder
 		if defaultVal, exists := paramDef["default"]; exists {
 			return defaultVal
 		}
+		if profileVal, found := ee.profileParameterValue(paramName, user); found {
+			return profileVal
+		}
 		return fmt.Sprintf("${user.%s}", paramName)
 	}
 }
@@ -371,9 +630,15 @@ func (ee *ExecutionEngine) resolveWorkflowParameters(steps []WorkflowStep, conte
 			Service:   step.Service,
 			Action:    step.Action,
 			DependsOn: step.DependsOn,
-			Status:    "pending",
+			Status:    StepPending,
 			Inputs:    make(map[string]interface{}),
 			Outputs:   make(map[string]interface{}),
+
+			RequiresConfirmation: step.RequiresConfirmation,
+			Connection:           step.Connection,
+			Provider:             step.Provider,
+			SecretRef:            step.SecretRef,
+			Cacheable:            step.Cacheable,
 		}
 
 		// Resolve input parameters
@@ -384,6 +649,7 @@ func (ee *ExecutionEngine) resolveWorkflowParameters(steps []WorkflowStep, conte
 			}
 			resolvedStep.Inputs[key] = resolvedValue
 		}
+		resolvedStep.DateTimePreviews = ee.buildDateTimePreviews(step.Inputs, resolvedStep.Inputs, context)
 
 		// Resolve output parameters
 		for key, value := range step.Outputs {
@@ -470,17 +736,22 @@ func (ee *ExecutionEngine) resolveParameterValue(value interface{}, context *Par
 
 // resolveStringParameter resolves string parameters with various expression types
 func (ee *ExecutionEngine) resolveStringParameter(value string, context *ParameterContext) (interface{}, error) {
+	// Custom references registered with RegisterParameterResolver come first
+	custom, done, err := ee.applyParameterResolvers(value, context)
+	if done || err != nil {
+		return custom, err
+	}
+	value = custom.(string)
+
 	// Handle runtime expressions: $(step.output.field)
 	runtimeExpr := regexp.MustCompile(`\$\(([^.]+)\.outputs\.([^)]+)\)`)
 	if matches := runtimeExpr.FindStringSubmatch(value); len(matches) == 3 {
 		stepID := matches[1]
 		outputField := matches[2]
 		
-		if stepOutputs, exists := context.StepOutputs[stepID]; exists {
-			if outputMap, ok := stepOutputs.(map[string]interface{}); ok {
-				if fieldValue, exists := outputMap[outputField]; exists {
-					return fieldValue, nil
-				}
+		if outputMap, exists := context.StepOutput(stepID); exists {
+			if fieldValue, exists := outputMap[outputField]; exists {
+				return fieldValue, nil
 			}
 		}
 		
@@ -519,7 +790,7 @@ func (ee *ExecutionEngine) resolveStringParameter(value string, context *Paramet
 		result := userParamRegex.ReplaceAllStringFunc(value, func(match string) string {
 			paramName := userParamRegex.FindStringSubmatch(match)[1]
 			if userValue, exists := context.UserParameters[paramName]; exists {
-				return fmt.Sprintf("%v", userValue)
+				return interpolationText(userValue)
 			}
 			// Return original match if parameter not found (will cause validation error later)
 			return match
@@ -544,38 +815,61 @@ func (ee *ExecutionEngine) resolveStringParameter(value string, context *Paramet
 		return result, nil
 	}
 
-	// Handle step output references: ${steps.step_id.outputs.field}
+	// Handle step output references: ${steps.step_id.outputs.field}, optionally with
+	// transforms such as ${steps.step_id.outputs.items | first | .id}
 	stepOutputRegex := regexp.MustCompile(`\$\{steps\.([^.]+)\.outputs\.([^}]+)\}`)
 	if stepOutputRegex.MatchString(value) {
+		// A value that is exactly one transformed reference, or one reference to a binary
+		// output or an object or list, keeps the referenced value's type
+		if matches := stepOutputRegex.FindAllStringSubmatch(value, -1); len(matches) == 1 && matches[0][0] == value {
+			if outputMap, ok := context.StepOutput(matches[0][1]); ok {
+				_, transforms := splitStepOutputExpression(matches[0][2])
+				outputValue, found, err := evaluateStepOutputExpression(outputMap, matches[0][2])
+				if err != nil && len(transforms) > 0 {
+					return value, fmt.Errorf("step output reference %s: %w", matches[0][1], err)
+				}
+				if _, binary := outputValue.(*BinaryOutput); found && err == nil && (binary || len(transforms) > 0 || isStructuredValue(outputValue)) {
+					return outputValue, nil
+				}
+			}
+		}
+		
+		var transformErr error
 		result := stepOutputRegex.ReplaceAllStringFunc(value, func(match string) string {
 			matches := stepOutputRegex.FindStringSubmatch(match)
 			stepID := matches[1]
 			outputField := matches[2]
 			
-			if stepOutputs, exists := context.StepOutputs[stepID]; exists {
-				if outputMap, ok := stepOutputs.(map[string]interface{}); ok {
-					if outputValue, exists := outputMap[outputField]; exists {
-						return fmt.Sprintf("%v", outputValue)
-					}
+			if outputMap, exists := context.StepOutput(stepID); exists {
+				outputValue, found, err := evaluateStepOutputExpression(outputMap, outputField)
+				if err != nil && transformErr == nil {
+					transformErr = fmt.Errorf("step output reference %s: %w", stepID, err)
+				}
+				if _, binary := outputValue.(*BinaryOutput); binary && transformErr == nil {
+					transformErr = fmt.Errorf("step output reference %s.%s is binary content and must be the whole parameter value", stepID, outputField)
+				}
+				if found && err == nil {
+					return interpolationText(outputValue)
 				}
 			}
 			return match // Keep original if not found during execution
 		})
+		if transformErr != nil {
+			return value, transformErr
+		}
 		
 		// Only validate step output availability during actual execution, not pre-validation
 		// During validation phase, step outputs won't exist yet - this is expected
-		if strings.Contains(result, "${steps.") && len(context.StepOutputs) > 0 {
+		if strings.Contains(result, "${steps.") && context.HasStepOutputs() {
 			// Only check for missing outputs if we're in execution phase (StepOutputs populated)
 			unresolvedMatches := stepOutputRegex.FindAllStringSubmatch(value, -1)
 			var missingRefs []string
 			for _, match := range unresolvedMatches {
 				stepID := match[1]
 				outputField := match[2]
-				if stepOutputs, exists := context.StepOutputs[stepID]; exists {
-					if outputMap, ok := stepOutputs.(map[string]interface{}); ok {
-						if _, exists := outputMap[outputField]; !exists {
-							missingRefs = append(missingRefs, fmt.Sprintf("%s.%s", stepID, outputField))
-						}
+				if outputMap, exists := context.StepOutput(stepID); exists {
+					if _, found, _ := evaluateStepOutputExpression(outputMap, outputField); !found {
+						missingRefs = append(missingRefs, fmt.Sprintf("%s.%s", stepID, outputField))
 					}
 				} else {
 					missingRefs = append(missingRefs, fmt.Sprintf("%s.%s", stepID, outputField))
@@ -633,16 +927,6 @@ func (ee *ExecutionEngine) resolveStringParameter(value string, context *Paramet
 	return value, nil
 }
 
-// readFileIfExists reads a file path if it exists and returns its contents as string.
-// Returns empty string if the file cannot be read.
-func (ee *ExecutionEngine) readFileIfExists(path string) string {
-    data, err := os.ReadFile(path)
-    if err != nil {
-        return ""
-    }
-    return string(data)
-}
-
 // isDateTimeValue checks if a string value looks like a datetime
 func (ee *ExecutionEngine) isDateTimeValue(value string) bool {
 	// Check if the value matches datetime patterns
@@ -663,238 +947,84 @@ func (ee *ExecutionEngine) isDateTimeValue(value string) bool {
 }
 
 
-// WorkflowStep represents a step in the workflow (simplified CUE parsing)
-type WorkflowStep struct {
-	ID        string                 `json:"id"`
-	Name      string                 `json:"name"`
-	Service   string                 `json:"service"`
-	Action    string                 `json:"action"`
-	Inputs    map[string]interface{} `json:"inputs"`
-	Outputs   map[string]interface{} `json:"outputs"`
-	DependsOn []string               `json:"depends_on,omitempty"`
-}
-
-// ParsedWorkflow represents a parsed CUE workflow
-type ParsedWorkflow struct {
-	Name        string         `json:"name"`
-	Description string         `json:"description"`
-	Steps       []WorkflowStep `json:"steps"`
-}
-
-// ParseCUEWorkflow parses a CUE workflow string using the CUE library (public for testing)
-func (ee *ExecutionEngine) ParseCUEWorkflow(cueContent string) (*ParsedWorkflow, error) {
-	// Sanitize CUE content to remove illegal characters
-	sanitizedContent := ee.sanitizeCUEContent(cueContent)
-
-	// Ensure deterministic workflow schema is available by inlining it
-	combinedContent := ee.inlineDeterministicSchema(sanitizedContent)
-
-	// Create CUE context
-	ctx := cuecontext.New()
-
-	// Parse the CUE content with schema inlined
-	value := ctx.CompileString(combinedContent)
-	if err := value.Err(); err != nil {
-		return nil, fmt.Errorf("failed to compile CUE content: %w", err)
-	}
-	
-	// Extract the workflow from the CUE value
-	workflowValue := value.LookupPath(cue.ParsePath("workflow"))
-	if !workflowValue.Exists() {
-		return nil, fmt.Errorf("workflow field not found in CUE content")
-	}
-	
-	// Parse workflow name
-	nameValue := workflowValue.LookupPath(cue.ParsePath("name"))
-	name, err := nameValue.String()
-	if err != nil {
-		return nil, fmt.Errorf("failed to extract workflow name: %w", err)
-	}
-	
-	// Parse workflow description
-	descValue := workflowValue.LookupPath(cue.ParsePath("description"))
-	description, err := descValue.String()
-	if err != nil {
-		return nil, fmt.Errorf("failed to extract workflow description: %w", err)
-	}
-	
-	// Parse workflow steps
-	stepsValue := workflowValue.LookupPath(cue.ParsePath("steps"))
-	if !stepsValue.Exists() {
-		return nil, fmt.Errorf("steps field not found in workflow")
-	}
-	
-	var steps []WorkflowStep
-	stepsIter, err := stepsValue.List()
-	if err != nil {
-		return nil, fmt.Errorf("failed to iterate over steps: %w", err)
-	}
-	
-	for stepsIter.Next() {
-		stepValue := stepsIter.Value()
-		
-		// Parse step fields
-		step := WorkflowStep{
-			Inputs:  make(map[string]interface{}),
-			Outputs: make(map[string]interface{}),
-		}
-		
-		// Extract step ID
-		if idValue := stepValue.LookupPath(cue.ParsePath("id")); idValue.Exists() {
-			if id, err := idValue.String(); err != nil {
-				return nil, fmt.Errorf("failed to extract id from step %d: %w", len(steps), err)
-			} else {
-				step.ID = id
-			}
-		}
-		
-		// Extract step name
-		if nameValue := stepValue.LookupPath(cue.ParsePath("name")); nameValue.Exists() {
-			if name, err := nameValue.String(); err != nil {
-				return nil, fmt.Errorf("failed to extract name from step %d: %w", len(steps), err)
-			} else {
-				step.Name = name
-			}
-		}
-		
-		// Extract service field first (if exists)
-		if serviceValue := stepValue.LookupPath(cue.ParsePath("service")); serviceValue.Exists() {
-			if service, err := serviceValue.String(); err != nil {
-				return nil, fmt.Errorf("failed to extract service from step %d: %w", len(steps), err)
-			} else {
-				step.Service = service
-			}
-		}
-		
-		// Extract action field
-		if actionValue := stepValue.LookupPath(cue.ParsePath("action")); actionValue.Exists() {
-			if action, err := actionValue.String(); err != nil {
-				return nil, fmt.Errorf("failed to extract action from step %d: %w", len(steps), err)
-			} else {
-				// If action contains a dot and no service was set, split it
-				if step.Service == "" && strings.Contains(action, ".") {
-					parts := strings.SplitN(action, ".", 2)
-					if len(parts) == 2 {
-						step.Service = parts[0]  // e.g., "gmail"
-						step.Action = parts[1]   // e.g., "get_messages"
-					} else {
-						step.Action = action
-					}
-				} else {
-					step.Action = action
-				}
-			}
-		}
-		
-		// Extract parameters/inputs (try both "parameters" and "inputs" fields)
-		var inputsMap map[string]interface{}
-		if parametersValue := stepValue.LookupPath(cue.ParsePath("parameters")); parametersValue.Exists() {
-			inputsMap = make(map[string]interface{})
-			parametersIter, _ := parametersValue.Fields()
-			for parametersIter.Next() {
-				key := parametersIter.Label()
-				val := parametersIter.Value()
-				
-				// Convert CUE value to Go interface{}
-				if goVal, err := ee.cueValueToInterface(val); err == nil {
-					inputsMap[key] = goVal
-				}
-			}
-		} else if inputsValue := stepValue.LookupPath(cue.ParsePath("inputs")); inputsValue.Exists() {
-			inputsMap = make(map[string]interface{})
-			inputsIter, _ := inputsValue.Fields()
-			for inputsIter.Next() {
-				key := inputsIter.Label()
-				val := inputsIter.Value()
-				
-				// Convert CUE value to Go interface{}
-				if goVal, err := ee.cueValueToInterface(val); err == nil {
-					inputsMap[key] = goVal
-				}
-			}
-		}
-		if inputsMap != nil {
-			step.Inputs = inputsMap // Store in Inputs field for execution engine compatibility
+// validateStepConnections reports steps bound to connections the user hasn't registered
+func (ee *ExecutionEngine) validateStepConnections(userID string, steps []ResolvedStep) []string {
+	var validationErrors []string
+	for _, step := range steps {
+		if step.Connection == "" {
+			continue
 		}
-		
-		// Extract outputs (usually empty in workflow definition)
-		if outputsValue := stepValue.LookupPath(cue.ParsePath("outputs")); outputsValue.Exists() {
-			outputsMap := make(map[string]interface{})
-			outputsIter, _ := outputsValue.Fields()
-			for outputsIter.Next() {
-				key := outputsIter.Label()
-				val := outputsIter.Value()
-				
-				if goVal, err := ee.cueValueToInterface(val); err == nil {
-					outputsMap[key] = goVal
-				}
-			}
-			step.Outputs = outputsMap
+		if ee.tokenManager == nil {
+			validationErrors = append(validationErrors, fmt.Sprintf("Step %s: connection %q requested but named connections are not available", step.ID, step.Connection))
+		} else if !ee.tokenManager.HasConnection(userID, step.Connection) {
+			validationErrors = append(validationErrors, fmt.Sprintf("Step %s: connection %q is not registered", step.ID, step.Connection))
 		}
-		
-		// Extract dependencies
-		if depsValue := stepValue.LookupPath(cue.ParsePath("depends_on")); depsValue.Exists() {
-			var deps []string
-			depsIter, _ := depsValue.List()
-			for depsIter.Next() {
-				if depStr, err := depsIter.Value().String(); err == nil {
-					deps = append(deps, depStr)
-				}
-			}
-			step.DependsOn = deps
-		}
-		
-		steps = append(steps, step)
 	}
-	
-	return &ParsedWorkflow{
-		Name:        name,
-		Description: description,
-		Steps:       steps,
-	}, nil
+	return validationErrors
 }
 
-// cueValueToInterface converts a CUE value to a Go interface{}
-func (ee *ExecutionEngine) cueValueToInterface(val cue.Value) (interface{}, error) {
-	switch val.Kind() {
-	case cue.StringKind:
-		return val.String()
-	case cue.IntKind:
-		return val.Int64()
-	case cue.FloatKind:
-		return val.Float64()
-	case cue.BoolKind:
-		return val.Bool()
-	case cue.ListKind:
-		var list []interface{}
-		iter, _ := val.List()
-		for iter.Next() {
-			if item, err := ee.cueValueToInterface(iter.Value()); err == nil {
-				list = append(list, item)
-			}
+// stepOAuthToken returns the token for a step: the secret of its api_key service binding, its
+// bound connection, a connection named after its provider (e.g. an account connected as
+// "workspace_staging"), or the execution's token
+func (ee *ExecutionEngine) stepOAuthToken(step *ResolvedStep, context *ParameterContext) (string, error) {
+	userID, _ := context.SystemParameters["user_id"].(string)
+	if step.SecretRef != "" {
+		return ee.stepSecret(userID, step)
+	}
+	if ee.usesProviderConnection(userID, step) {
+		token, err := ee.tokenManager.GetToken(userID, step.Provider)
+		if err != nil {
+			return "", fmt.Errorf("failed to get token for provider %q: %w", step.Provider, err)
 		}
-		return list, nil
-	case cue.StructKind:
-		obj := make(map[string]interface{})
-		iter, _ := val.Fields()
-		for iter.Next() {
-			key := iter.Label()
-			if item, err := ee.cueValueToInterface(iter.Value()); err == nil {
-				obj[key] = item
-			}
+		return token, nil
+	}
+	if step.Connection != "" {
+		if ee.tokenManager == nil {
+			return "", fmt.Errorf("connection %q requested but named connections are not available", step.Connection)
 		}
-		return obj, nil
-	default:
-		// For other types, try to get as string
-		if str, err := val.String(); err == nil {
-			return str, nil
+		token, err := ee.tokenManager.GetToken(userID, step.Connection)
+		if err != nil {
+			return "", fmt.Errorf("failed to get token for connection %q: %w", step.Connection, err)
 		}
-		return nil, fmt.Errorf("unsupported CUE value kind: %v", val.Kind())
+		return token, nil
 	}
+
+	oauthToken, ok := context.SystemParameters["oauth_token"].(string)
+	if !ok || oauthToken == "" {
+		return "", fmt.Errorf("%w for MCP service execution", ErrTokenNotFound)
+	}
+	return oauthToken, nil
+}
+
+// usesProviderConnection reports whether a step without its own connection runs on the
+// user's connection named after the step's provider
+func (ee *ExecutionEngine) usesProviderConnection(userID string, step *ResolvedStep) bool {
+	return step.Connection == "" && step.Provider != "" && ee.tokenManager != nil && ee.tokenManager.HasConnection(userID, step.Provider)
 }
 
-// ExecuteWorkflow executes a prepared workflow plan
+// WorkflowStep represents a step in the workflow
+type WorkflowStep = workflowparser.WorkflowStep
+
+// ParsedWorkflow represents a parsed CUE workflow
+type ParsedWorkflow = workflowparser.ParsedWorkflow
+
+// ParseCUEWorkflow parses a CUE workflow string using the CUE library (public for testing)
+func (ee *ExecutionEngine) ParseCUEWorkflow(cueContent string) (*ParsedWorkflow, error) {
+	return workflowparser.Parse(cueContent)
+}
+
+// ExecuteWorkflow executes a prepared workflow plan under a new execution ID, writing it to
+// the audit log when one is configured
 func (ee *ExecutionEngine) ExecuteWorkflow(plan *ExecutionPlan) error {
+	executionID := NewExecutionID()
+	userID := auditUserID(plan)
+	err := ee.executeWorkflowContext(withAuditScope(context.Background(), executionID, userID), plan)
+	ee.recordAudit(executionID, userID, plan, err)
+	return err
+}
+
+// executeWorkflowContext executes a prepared workflow plan, stopping when parent is cancelled
+func (ee *ExecutionEngine) executeWorkflowContext(parent context.Context, plan *ExecutionPlan) error {
 	log.Printf("[ExecutionEngine] === STARTING WORKFLOW EXECUTION ===")
 	log.Printf("[ExecutionEngine] Workflow: %s (%s)", plan.Name, plan.Description)
 	log.Printf("[ExecutionEngine] Total steps: %d", len(plan.ResolvedSteps))
@@ -904,38 +1034,100 @@ func (ee *ExecutionEngine) ExecuteWorkflow(plan *ExecutionPlan) error {
 		return fmt.Errorf("workflow has validation errors: %v", plan.ValidationErrors)
 	}
 
+	if len(plan.ParameterErrors) > 0 {
+		log.Printf("[ExecutionEngine] ERROR: Invalid user parameters: %v", plan.ParameterErrors)
+		return fmt.Errorf("invalid user parameters: %v", plan.ParameterErrors)
+	}
+
+	// Nothing is sent in simulate mode, so confirmation steps do not block it
+	if len(plan.PendingConfirmation) > 0 && !plan.Simulate {
+		log.Printf("[ExecutionEngine] ERROR: Steps awaiting confirmation: %v", plan.PendingConfirmation)
+		return fmt.Errorf("workflow has steps awaiting confirmation: %v", plan.PendingConfirmation)
+	}
+
+	// The budget covers all steps; remaining steps are left pending when it runs out
+	timeout := ee.workflowTimeout(plan)
+	ctx, cancel := context.WithTimeout(withSensitiveValues(parent, plan.sensitiveValues()), timeout)
+	defer cancel()
+	log.Printf("[ExecutionEngine] Runtime budget: %s", timeout)
+
 	// Execute steps in dependency order
 	for i := range plan.ResolvedSteps {
 		step := &plan.ResolvedSteps[i]
 		
+		// Steps completed in an earlier run are kept when a failed execution is resumed
+		if step.Status == StepCompleted {
+			log.Printf("[ExecutionEngine] Skipping step %s (already completed)", step.ID)
+			continue
+		}
+		
+		if errors.Is(context.Cause(ctx), ErrExecutionCancelled) {
+			log.Printf("[ExecutionEngine] Execution cancelled before step %s", step.ID)
+			return fmt.Errorf("%w: step %s and later steps not run", ErrExecutionCancelled, step.ID)
+		}
+		if ctx.Err() != nil {
+			log.Printf("[ExecutionEngine] ERROR: Runtime budget of %s exceeded before step %s", timeout, step.ID)
+			return fmt.Errorf("%w after %s: step %s and later steps not run", ErrWorkflowTimedOut, timeout, step.ID)
+		}
+		
 		log.Printf("[ExecutionEngine] === EXECUTING STEP %d/%d ===", i+1, len(plan.ResolvedSteps))
 		log.Printf("[ExecutionEngine] Step ID: %s", step.ID)
 		log.Printf("[ExecutionEngine] Step Name: %s", step.Name)
 		log.Printf("[ExecutionEngine] Service: %s", step.Service)
 		log.Printf("[ExecutionEngine] Action: %s", step.Action)
 		log.Printf("[ExecutionEngine] Dependencies: %v", step.DependsOn)
-		log.Printf("[ExecutionEngine] Inputs: %+v", step.Inputs)
+		log.Printf("[ExecutionEngine] Inputs: %+v", redactForLogContext(ctx, step.Inputs))
 		
 		// Check dependencies
 		if !ee.areDependenciesMet(step.DependsOn, plan.ResolvedSteps) {
 			log.Printf("[ExecutionEngine] ERROR: Dependencies not met for step %s", step.ID)
-			step.Status = "failed"
+			if err := ee.setStepStatus(step, StepFailed); err != nil {
+				return err
+			}
 			return fmt.Errorf("dependencies not met for step %s", step.ID)
 		}
 		
+		if err := ee.setStepStatus(step, StepRunning); err != nil {
+			return err
+		}
+		
 		log.Printf("[ExecutionEngine] Dependencies satisfied, executing step...")
 
-		// Execute step via MCP service
-		err := ee.executeStep(step, plan.ParameterContext)
+		// Execute step via MCP service, or record the call when simulating or sandboxed
+		var resolvedInputs map[string]interface{}
+		var err error
+		if plan.Simulate || step.Sandboxed {
+			resolvedInputs, err = ee.simulateStep(step, plan)
+		} else {
+			resolvedInputs, err = ee.executeStepContext(ctx, step, plan.ParameterContext)
+			if err == nil {
+				err = ee.checkStepProducedOutputs(step, plan)
+			}
+		}
 		if err != nil {
-			log.Printf("[ExecutionEngine] ERROR: Step %s failed: %v", step.ID, err)
-			step.Status = "failed"
+			log.Printf("[ExecutionEngine] ERROR: Step %s failed: %s", step.ID, plan.RedactError(err))
+			if statusErr := ee.setStepStatus(step, StepFailed); statusErr != nil {
+				return statusErr
+			}
+			ee.auditStep(ctx, plan, step, resolvedInputs)
+			if errors.Is(context.Cause(ctx), ErrExecutionCancelled) {
+				return fmt.Errorf("%w: step %s aborted: %v", ErrExecutionCancelled, step.ID, err)
+			}
+			if ctx.Err() != nil {
+				return fmt.Errorf("%w after %s: step %s aborted: %v", ErrWorkflowTimedOut, timeout, step.ID, err)
+			}
 			return fmt.Errorf("step %s failed: %w", step.ID, err)
 		}
 
-		step.Status = "completed"
+		if err := ee.setStepStatus(step, StepCompleted); err != nil {
+			return err
+		}
+		ee.auditStep(ctx, plan, step, resolvedInputs)
+		for _, warning := range step.ValidationWarnings {
+			plan.ValidationWarnings = append(plan.ValidationWarnings, fmt.Sprintf("Step %s: %s", step.ID, warning))
+		}
 		log.Printf("[ExecutionEngine] SUCCESS: Step %s completed", step.ID)
-		log.Printf("[ExecutionEngine] Step outputs: %+v", step.Outputs)
+		log.Printf("[ExecutionEngine] Step outputs: %+v", redactForLogContext(ctx, step.Outputs))
 	}
 
 	log.Printf("[ExecutionEngine] === WORKFLOW EXECUTION COMPLETED SUCCESSFULLY ===")
@@ -948,7 +1140,7 @@ func (ee *ExecutionEngine) areDependenciesMet(dependencies []string, steps []Res
 	for _, depID := range dependencies {
 		found := false
 		for _, step := range steps {
-			if step.ID == depID && step.Status == "completed" {
+			if step.ID == depID && step.Status == StepCompleted {
 				found = true
 				break
 			}
@@ -960,75 +1152,135 @@ func (ee *ExecutionEngine) areDependenciesMet(dependencies []string, steps []Res
 	return true
 }
 
+// setStepStatus is the single place the engine changes a step's status during execution
+func (ee *ExecutionEngine) setStepStatus(step *ResolvedStep, next StepStatus) error {
+	previous := step.Status
+	if err := step.transitionTo(next); err != nil {
+		log.Printf("[ExecutionEngine] ERROR: %v", err)
+		return err
+	}
+	log.Printf("[ExecutionEngine] Step %s status: %s -> %s", step.ID, previous, next)
+	return nil
+}
+
 // executeStep executes a single workflow step via MCP service
-func (ee *ExecutionEngine) executeStep(step *ResolvedStep, context *ParameterContext) error {
+func (ee *ExecutionEngine) executeStep(step *ResolvedStep, paramContext *ParameterContext) error {
+	_, err := ee.executeStepContext(context.Background(), step, paramContext)
+	return err
+}
+
+// executeStepContext executes a single workflow step via MCP service, aborting the call when ctx is done.
+// It returns the inputs the step was called with once they are resolved.
+func (ee *ExecutionEngine) executeStepContext(ctx context.Context, step *ResolvedStep, paramContext *ParameterContext) (map[string]interface{}, error) {
 	log.Printf("[ExecutionEngine] executeStep: Starting execution for step %s", step.ID)
-	step.Status = "running"
 	
-	// Get OAuth token from context (should be passed from user authentication)
-	oauthToken, ok := context.SystemParameters["oauth_token"].(string)
-	if !ok || oauthToken == "" {
-		log.Printf("[ExecutionEngine] executeStep: ERROR - Missing OAuth token for step %s", step.ID)
-		return fmt.Errorf("missing OAuth token for MCP service execution")
+	// Get OAuth token for the step's connection (defaults to the token passed from user authentication)
+	oauthToken, err := ee.stepOAuthToken(step, paramContext)
+	if err != nil {
+		log.Printf("[ExecutionEngine] executeStep: ERROR - No OAuth token for step %s: %v", step.ID, err)
+		return nil, err
 	}
 	
 	log.Printf("[ExecutionEngine] executeStep: OAuth token found, calling MCP service...")
 	log.Printf("[ExecutionEngine] executeStep: Service=%s, Action=%s", step.Service, step.Action)
-	log.Printf("[ExecutionEngine] executeStep: Input parameters (before resolution): %+v", step.Inputs)
+	log.Printf("[ExecutionEngine] executeStep: Input parameters (before resolution): %+v", redactForLogContext(ctx, step.Inputs))
 	
 	// Resolve parameter references in step inputs at runtime
-	resolvedInputs, err := ee.resolveStepInputs(step.Inputs, context)
+	resolvedInputs, err := ee.resolveStepInputs(step.Inputs, paramContext)
 	if err != nil {
 		log.Printf("[ExecutionEngine] executeStep: ERROR - Parameter resolution failed for step %s: %v", step.ID, err)
-		return fmt.Errorf("parameter resolution failed: %w", err)
+		return nil, fmt.Errorf("parameter resolution failed: %w", err)
+	}
+	log.Printf("[ExecutionEngine] executeStep: Input parameters (after resolution): %+v", redactForLogContext(ctx, resolvedInputs))
+	
+	// Never send a placeholder to MCP as a literal value
+	if err := checkResolvedInputs(step, resolvedInputs); err != nil {
+		log.Printf("[ExecutionEngine] executeStep: ERROR - %v", err)
+		return resolvedInputs, err
 	}
-	log.Printf("[ExecutionEngine] executeStep: Input parameters (after resolution): %+v", resolvedInputs)
 	
 	// Log the resolved inputs being sent to MCP for debugging
 	log.Printf("[ExecutionEngine] executeStep: Sending parameters to MCP service %s.%s:", step.Service, step.Action)
-	for key, value := range resolvedInputs {
+	for key, value := range redactForLogContext(ctx, resolvedInputs).(map[string]interface{}) {
 		log.Printf("[ExecutionEngine] executeStep:   %s: %v", key, value)
 	}
 
+	// Reuse the outputs of an identical cacheable read within the cache TTL
+	cacheKey := ee.stepCacheKey(step, resolvedInputs, paramContext)
+	var response *ExecuteActionResponse
+	if cacheKey != "" {
+		if cachedOutputs, hit := ee.stepCache.Get(cacheKey); hit {
+			log.Printf("[ExecutionEngine] executeStep: Reusing cached outputs for step %s", step.ID)
+			step.CacheHit = true
+			response = &ExecuteActionResponse{Success: true, Data: cachedOutputs}
+		}
+	}
+
 	// Execute the MCP action
-	response, err := ee.mcpService.ExecuteAction(step.Service, step.Action, resolvedInputs, oauthToken)
+	if response == nil {
+		response, err = ee.mcpService.ExecuteProviderActionContext(ctx, step.Provider, step.Service, step.Action, resolvedInputs, oauthToken)
+	}
 	if err != nil {
-		log.Printf("[ExecutionEngine] executeStep: ERROR - MCP action execution failed for step %s: %v", step.ID, err)
-		return fmt.Errorf("MCP action execution failed: %w", err)
+		log.Printf("[ExecutionEngine] executeStep: ERROR - MCP action execution failed for step %s: %v", step.ID, redactForLogContext(ctx, err.Error()))
+		var authErr *AuthError
+		var rateLimitErr *RateLimitError
+		var validationErr *ValidationError
+		var timeoutErr *TimeoutError
+		switch {
+		case errors.As(err, &timeoutErr):
+			return resolvedInputs, fmt.Errorf("%s did not respond in time: %w", step.Service, err)
+		case errors.As(err, &authErr):
+			return resolvedInputs, fmt.Errorf("not authorized for %s, reconnect the account or grant the missing scopes: %w", step.Service, err)
+		case errors.As(err, &rateLimitErr):
+			if rateLimitErr.RetryAfter > 0 {
+				return resolvedInputs, fmt.Errorf("rate limited by %s, retry after %s: %w", step.Service, rateLimitErr.RetryAfter, err)
+			}
+			return resolvedInputs, fmt.Errorf("rate limited by %s: %w", step.Service, err)
+		case errors.As(err, &validationErr):
+			return resolvedInputs, fmt.Errorf("step inputs rejected: %w", err)
+		default:
+			return resolvedInputs, fmt.Errorf("MCP action execution failed: %w", err)
+		}
 	}
 	
 	log.Printf("[ExecutionEngine] executeStep: MCP service call successful for step %s", step.ID)
 	log.Printf("[ExecutionEngine] executeStep: Response success: %t", response.Success)
-	log.Printf("[ExecutionEngine] executeStep: Response data: %+v", response.Data)
+	log.Printf("[ExecutionEngine] executeStep: Response data: %+v", redactForLogContext(ctx, response.Data))
 	log.Printf("[ExecutionEngine] executeStep: Response error: %s", response.Error)
 	
+	if cacheKey != "" && !step.CacheHit && response.Success && response.Data != nil {
+		ee.stepCache.Set(cacheKey, response.Data, ee.stepCacheTTL)
+	}
+	
 	// Validate and update step outputs with MCP response data
 	if response.Data != nil {
 		log.Printf("[ExecutionEngine] executeStep: Updating step outputs with %d data fields", len(response.Data))
 		
 		// Validate response against expected output schema
-		if err := ee.validateResponseSchema(step.Service, step.Action, response.Data); err != nil {
+		if err := ee.validateResponseSchema(step.Provider, step.Service, step.Action, response.Data); err != nil {
 			log.Printf("[ExecutionEngine] executeStep: WARNING - Response schema validation failed for step %s: %v", step.ID, err)
-			// Continue execution but log validation warning for observability
+			// Continue execution but report the warning with the execution result
+			step.ValidationWarnings = append(step.ValidationWarnings, err.Error())
 		}
 		
-		for key, value := range response.Data {
+		// Keep base64 content by reference so later steps receive it without stringification
+		outputs, err := ee.wrapBinaryOutputs(step, response.Data)
+		if err != nil {
+			log.Printf("[ExecutionEngine] executeStep: ERROR - %v", err)
+			return resolvedInputs, fmt.Errorf("step %s output rejected: %w", step.ID, err)
+		}
+		
+		for key, value := range outputs {
 			step.Outputs[key] = value
-			log.Printf("[ExecutionEngine] executeStep: Set output %s = %v", key, value)
 		}
+		log.Printf("[ExecutionEngine] executeStep: Set outputs %+v", redactForLogContext(ctx, outputs))
 		
 		// Update context for next steps
-		if context.StepOutputs[step.ID] == nil {
-			context.StepOutputs[step.ID] = make(map[string]interface{})
-		}
-		stepOutputs := context.StepOutputs[step.ID].(map[string]interface{})
-		for key, value := range response.Data {
-			stepOutputs[key] = value
-		}
+		paramContext.SetStepOutputs(step.ID, outputs)
 		log.Printf("[ExecutionEngine] executeStep: Updated context with step outputs for %s", step.ID)
 		log.Printf("[ExecutionEngine] executeStep: Available step outputs in context:")
-		for stepID, outputs := range context.StepOutputs {
-			if outputMap, ok := outputs.(map[string]interface{}); ok {
+		for stepID, outputs := range paramContext.StepOutputsSnapshot() {
+			if outputMap, ok := redactForLogContext(ctx, outputs).(map[string]interface{}); ok {
 				for outputKey, outputValue := range outputMap {
 					log.Printf("[ExecutionEngine] executeStep:   %s.%s = %v", stepID, outputKey, outputValue)
 				}
@@ -1039,7 +1291,7 @@ func (ee *ExecutionEngine) executeStep(step *ResolvedStep, context *ParameterCon
 	}
 	
 	log.Printf("[ExecutionEngine] executeStep: Step %s execution completed successfully", step.ID)
-	return nil
+	return resolvedInputs, nil
 }
 
 // resolveStepInputs resolves parameter references in step inputs at runtime
@@ -1058,17 +1310,17 @@ func (ee *ExecutionEngine) resolveStepInputs(inputs map[string]interface{}, cont
 }
 
 // validateResponseSchema validates MCP response data against expected output schema
-func (ee *ExecutionEngine) validateResponseSchema(service, action string, responseData map[string]interface{}) error {
+func (ee *ExecutionEngine) validateResponseSchema(provider, service, action string, responseData map[string]interface{}) error {
 	// Get MCP service catalog for validation
 	mcpCatalog, err := ee.mcpService.GetServiceCatalog()
 	if err != nil {
 		return fmt.Errorf("failed to get MCP catalog for response validation: %w", err)
 	}
 	
-	// Check if service exists
-	serviceDefinition, exists := mcpCatalog.Providers.Workspace.Services[service]
-	if !exists {
-		return fmt.Errorf("service '%s' not found in MCP catalog", service)
+	// Resolve the service in the step's provider, or in the only provider that defines it
+	_, serviceDefinition, err := mcpCatalog.ResolveService(provider, service)
+	if err != nil {
+		return err
 	}
 	
 	// Check if function exists
@@ -1117,39 +1369,3 @@ func (ee *ExecutionEngine) validateResponseSchema(service, action string, respon
 	log.Printf("[ExecutionEngine] validateResponseSchema: Response schema validation passed for %s.%s", service, action)
 	return nil
 }
-
-
-
-// sanitizeCUEContent removes illegal characters and formatting from CUE content
-func (ee *ExecutionEngine) sanitizeCUEContent(cueContent string) string {
-	// Remove backticks that cause CUE parsing errors
-	sanitized := strings.ReplaceAll(cueContent, "`", "'")
-	
-	// Remove any markdown code block markers that might have been generated
-	sanitized = strings.ReplaceAll(sanitized, "```cue", "")
-	sanitized = strings.ReplaceAll(sanitized, "```", "")
-	
-	// Remove any other problematic characters that could cause CUE parsing issues
-	sanitized = strings.ReplaceAll(sanitized, "\u0060", "'") // Unicode backtick
-	
-	return sanitized
-}
-
-// removePackageDeclaration removes the package declaration from CUE content to avoid conflicts
-func (ee *ExecutionEngine) removePackageDeclaration(cueContent string) string {
-	lines := strings.Split(cueContent, "\n")
-	var filteredLines []string
-	
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		// Skip package declarations
-		if strings.HasPrefix(trimmed, "package ") {
-			continue
-		}
-		filteredLines = append(filteredLines, line)
-	}
-	
-	return strings.Join(filteredLines, "\n")
-}
-
-
@@ -2,7 +2,9 @@ package services
 
 import (
 	"io/ioutil"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestParseCUEWorkflow(t *testing.T) {
@@ -61,3 +63,146 @@ func TestParseCUEWorkflow(t *testing.T) {
 		}
 	}
 }
+
+// TestCanonicalizeStepAction verifies service/action dot-notation handling
+func TestCanonicalizeStepAction(t *testing.T) {
+	tests := []struct {
+		name            string
+		service         string
+		action          string
+		expectedService string
+		expectedAction  string
+		expectError     bool
+	}{
+		{"service-less dotted action", "", "gmail.send_message", "gmail", "send_message", false},
+		{"matching service and dotted action", "gmail", "gmail.send_message", "gmail", "send_message", false},
+		{"separate service and action", "docs", "create_document", "docs", "create_document", false},
+		{"mismatching service and dotted action", "gmail", "docs.create_document", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, action, err := canonicalizeStepAction(tt.service, tt.action)
+			if tt.expectError {
+				if err == nil {
+					t.Fatalf("Expected error for service %q with action %q", tt.service, tt.action)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if service != tt.expectedService || action != tt.expectedAction {
+				t.Errorf("Expected %s.%s, got %s.%s", tt.expectedService, tt.expectedAction, service, action)
+			}
+		})
+	}
+}
+
+// TestParseCUEWorkflowRejectsServiceMismatch verifies conflicting service/action steps fail to parse
+func TestParseCUEWorkflowRejectsServiceMismatch(t *testing.T) {
+	ee := NewExecutionEngine(nil)
+
+	cueContent := `workflow: {
+	name:        "mismatch"
+	description: "Service conflicts with dotted action"
+	steps: [{
+		id:      "create"
+		name:    "Create document"
+		service: "gmail"
+		action:  "docs.create_document"
+		inputs: {title: "Report"}
+	}]
+}`
+
+	if _, err := ee.ParseCUEWorkflow(cueContent); err == nil {
+		t.Fatal("Expected ParseCUEWorkflow to reject mismatched service and action")
+	}
+
+	matching := strings.Replace(cueContent, `service: "gmail"`, `service: "docs"`, 1)
+	workflow, err := ee.ParseCUEWorkflow(matching)
+	if err != nil {
+		t.Fatalf("Unexpected error for matching service: %v", err)
+	}
+	if workflow.Steps[0].Service != "docs" || workflow.Steps[0].Action != "create_document" {
+		t.Errorf("Expected docs.create_document, got %s.%s", workflow.Steps[0].Service, workflow.Steps[0].Action)
+	}
+}
+
+// TestParseCUEWorkflowConnectionBindings verifies step connections override service bindings
+func TestParseCUEWorkflowConnectionBindings(t *testing.T) {
+	ee := NewExecutionEngine(nil)
+
+	cueContent := `workflow: {
+	name:        "bindings"
+	description: "Steps bound to named connections"
+	service_bindings: gmail: {type: "mcp_service", connection: "work"}
+	steps: [{
+		id:     "send"
+		name:   "Send report"
+		action: "gmail.send_message"
+		inputs: {to: "team@example.com"}
+	}, {
+		id:         "share"
+		name:       "Share document"
+		action:     "gmail.send_message"
+		connection: "personal"
+		inputs: {to: "me@example.com"}
+	}, {
+		id:     "create"
+		name:   "Create document"
+		action: "docs.create_document"
+		inputs: {title: "Report"}
+	}]
+}`
+
+	workflow, err := ee.ParseCUEWorkflow(cueContent)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := map[string]string{"send": "work", "share": "personal", "create": ""}
+	for _, step := range workflow.Steps {
+		if step.Connection != expected[step.ID] {
+			t.Errorf("Step %s: expected connection %q, got %q", step.ID, expected[step.ID], step.Connection)
+		}
+	}
+
+	invalid := strings.Replace(cueContent, `connection: "personal"`, `connection: "Not Valid"`, 1)
+	if _, err := ee.ParseCUEWorkflow(invalid); err == nil {
+		t.Error("Expected invalid connection name to be rejected")
+	}
+}
+
+// TestStepOAuthTokenUsesConnection verifies steps use their bound connection's token
+func TestStepOAuthTokenUsesConnection(t *testing.T) {
+	tokenManager := NewTokenManager()
+	tokenManager.tokens["user_1"] = map[string]*UserTokens{
+		"work": {AccessToken: "work_token", Connection: "work", Expiry: time.Now().Add(time.Hour)},
+	}
+
+	ee := NewExecutionEngine(nil)
+	context := &ParameterContext{
+		SystemParameters: map[string]interface{}{"user_id": "user_1", "oauth_token": "default_token"},
+	}
+
+	if token, err := ee.stepOAuthToken(&ResolvedStep{ID: "a"}, context); err != nil || token != "default_token" {
+		t.Errorf("Expected default token, got %q (err: %v)", token, err)
+	}
+	if _, err := ee.stepOAuthToken(&ResolvedStep{ID: "b", Connection: "work"}, context); err == nil {
+		t.Error("Expected error for connection without a token manager")
+	}
+
+	ee.SetTokenManager(tokenManager)
+	if token, err := ee.stepOAuthToken(&ResolvedStep{ID: "b", Connection: "work"}, context); err != nil || token != "work_token" {
+		t.Errorf("Expected work token, got %q (err: %v)", token, err)
+	}
+
+	errors := ee.validateStepConnections("user_1", []ResolvedStep{
+		{ID: "b", Connection: "work"},
+		{ID: "c", Connection: "personal"},
+	})
+	if len(errors) != 1 || !strings.Contains(errors[0], "personal") {
+		t.Errorf("Expected one error for unregistered connection, got %v", errors)
+	}
+}
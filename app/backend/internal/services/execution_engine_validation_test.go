@@ -12,7 +12,7 @@ func TestExecutionEngineValidationMethods(t *testing.T) {
 	engine := NewExecutionEngine(mcpService)
 
 	// Test 1: Verify validateResponseSchema method exists and handles missing service
-	err := engine.validateResponseSchema("unknown_service", "unknown_action", map[string]interface{}{
+	err := engine.validateResponseSchema("", "unknown_service", "unknown_action", map[string]interface{}{
 		"test_field": "test_value",
 	})
 	if err == nil {
@@ -28,7 +28,7 @@ func TestExecutionEngineValidationMethods(t *testing.T) {
 			},
 		},
 	}
-	err = engine.validateOutputFieldExists("unknown_service", "unknown_action", "test_field", catalog)
+	err = engine.validateOutputFieldExists("", "unknown_service", "unknown_action", "test_field", catalog)
 	if err == nil {
 		t.Error("Expected error for unknown service in validateOutputFieldExists, but got none")
 	}
@@ -85,7 +85,7 @@ func TestExecutionEngineResponseSchemaValidation(t *testing.T) {
 	engine := NewExecutionEngine(mcpService)
 
 	// Test valid response schema validation with direct method call
-	err := engine.validateOutputFieldExists("gmail", "send_message", "message_id", catalog)
+	err := engine.validateOutputFieldExists("", "gmail", "send_message", "message_id", catalog)
 	if err != nil {
 		t.Errorf("Expected valid field 'message_id' to pass validation, but got error: %v", err)
 	} else {
@@ -93,7 +93,7 @@ func TestExecutionEngineResponseSchemaValidation(t *testing.T) {
 	}
 
 	// Test invalid output field validation
-	err = engine.validateOutputFieldExists("gmail", "send_message", "invalid_field", catalog)
+	err = engine.validateOutputFieldExists("", "gmail", "send_message", "invalid_field", catalog)
 	if err == nil {
 		t.Error("Expected error for invalid field 'invalid_field', but got none")
 	} else {
@@ -117,7 +117,7 @@ func TestExecutionEngineResponseSchemaValidation(t *testing.T) {
 		},
 	}
 
-	err = engine.validateOutputFieldExists("legacy_service", "legacy_action", "any_field", legacyCatalog)
+	err = engine.validateOutputFieldExists("", "legacy_service", "legacy_action", "any_field", legacyCatalog)
 	if err != nil {
 		t.Errorf("Legacy service without output schema should allow any field, but got error: %v", err)
 	} else {
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"sohoaas-backend/internal/workflowparser"
+)
+
+// Operation kinds used when estimating the impact of a workflow
+const (
+	OperationRead  = "read"
+	OperationWrite = "write"
+)
+
+// serviceWriteNouns maps services to the singular/plural nouns used in estimate summaries
+var serviceWriteNouns = map[string][2]string{
+	"gmail":    {"email", "emails"},
+	"calendar": {"calendar event", "calendar events"},
+	"docs":     {"doc", "docs"},
+	"drive":    {"drive change", "drive changes"},
+}
+
+// StepEstimate describes the expected impact of a single resolved step
+type StepEstimate struct {
+	StepID    string `json:"step_id"`
+	Name      string `json:"name,omitempty"`
+	Service   string `json:"service"`
+	Action    string `json:"action"`
+	Operation string `json:"operation"` // read or write
+
+	DateTimePreviews []DateTimePreview `json:"datetime_previews,omitempty"`
+}
+
+// WorkflowEstimate summarizes the blast radius of a workflow before execution
+type WorkflowEstimate struct {
+	WorkflowName    string         `json:"workflow_name"`
+	TotalSteps      int            `json:"total_steps"`
+	ReadSteps       int            `json:"read_steps"`
+	WriteSteps      int            `json:"write_steps"`
+	WritesByService map[string]int `json:"writes_by_service"`
+	Steps           []StepEstimate `json:"steps"`
+	Summary         string         `json:"summary"`
+}
+
+// EstimateWorkflow walks the resolved steps of an execution plan and classifies
+// each one as a read or write operation. No MCP calls are made.
+func (ee *ExecutionEngine) EstimateWorkflow(plan *ExecutionPlan) (*WorkflowEstimate, error) {
+	if plan == nil {
+		return nil, fmt.Errorf("execution plan is nil")
+	}
+
+	estimate := &WorkflowEstimate{
+		WorkflowName:    plan.Name,
+		TotalSteps:      len(plan.ResolvedSteps),
+		WritesByService: make(map[string]int),
+		Steps:           make([]StepEstimate, 0, len(plan.ResolvedSteps)),
+	}
+
+	// Keep services in first-seen order so the summary is stable
+	var serviceOrder []string
+	for _, step := range plan.ResolvedSteps {
+		operation := classifyStepOperation(step.Action)
+		estimate.Steps = append(estimate.Steps, StepEstimate{
+			StepID:    step.ID,
+			Name:      step.Name,
+			Service:   step.Service,
+			Action:    step.Action,
+			Operation: operation,
+
+			DateTimePreviews: step.DateTimePreviews,
+		})
+
+		if operation == OperationRead {
+			estimate.ReadSteps++
+			continue
+		}
+
+		estimate.WriteSteps++
+		if _, seen := estimate.WritesByService[step.Service]; !seen {
+			serviceOrder = append(serviceOrder, step.Service)
+		}
+		estimate.WritesByService[step.Service]++
+	}
+
+	estimate.Summary = buildEstimateSummary(serviceOrder, estimate.WritesByService)
+	return estimate, nil
+}
+
+// classifyStepOperation determines whether an action reads or writes user data
+func classifyStepOperation(action string) string {
+	if workflowparser.IsReadAction(action) {
+		return OperationRead
+	}
+	return OperationWrite
+}
+
+// buildEstimateSummary renders write counts as e.g. "2 emails, 1 calendar event, 3 docs"
+func buildEstimateSummary(serviceOrder []string, writes map[string]int) string {
+	if len(serviceOrder) == 0 {
+		return "no write operations"
+	}
+
+	parts := make([]string, 0, len(serviceOrder))
+	for _, service := range serviceOrder {
+		count := writes[service]
+		nouns, ok := serviceWriteNouns[service]
+		if !ok {
+			nouns = [2]string{service + " operation", service + " operations"}
+		}
+		noun := nouns[1]
+		if count == 1 {
+			noun = nouns[0]
+		}
+		parts = append(parts, fmt.Sprintf("%d %s", count, noun))
+	}
+	return strings.Join(parts, ", ")
+}
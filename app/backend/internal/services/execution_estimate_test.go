@@ -0,0 +1,68 @@
+package services
+
+import (
+	"testing"
+)
+
+// TestEstimateWorkflow verifies read/write classification and summary rendering
+func TestEstimateWorkflow(t *testing.T) {
+	ee := NewExecutionEngine(nil) // estimate never calls MCP
+
+	plan := &ExecutionPlan{
+		Name: "weekly_report",
+		ResolvedSteps: []ResolvedStep{
+			{ID: "fetch", Service: "gmail", Action: "list_messages"},
+			{ID: "notify", Service: "gmail", Action: "send_message"},
+			{ID: "schedule", Service: "calendar", Action: "create_event"},
+			{ID: "report", Service: "docs", Action: "create_document"},
+			{ID: "followup", Service: "gmail", Action: "send_message"},
+			{ID: "append", Service: "docs", Action: "insert_text"},
+		},
+	}
+
+	estimate, err := ee.EstimateWorkflow(plan)
+	if err != nil {
+		t.Fatalf("EstimateWorkflow failed: %v", err)
+	}
+
+	if estimate.TotalSteps != 6 {
+		t.Errorf("Expected 6 steps, got %d", estimate.TotalSteps)
+	}
+	if estimate.ReadSteps != 1 {
+		t.Errorf("Expected 1 read step, got %d", estimate.ReadSteps)
+	}
+	if estimate.WriteSteps != 5 {
+		t.Errorf("Expected 5 write steps, got %d", estimate.WriteSteps)
+	}
+	if estimate.Steps[0].Operation != OperationRead {
+		t.Errorf("Expected list_messages to be a read, got %s", estimate.Steps[0].Operation)
+	}
+
+	expected := "2 emails, 1 calendar event, 2 docs"
+	if estimate.Summary != expected {
+		t.Errorf("Expected summary %q, got %q", expected, estimate.Summary)
+	}
+}
+
+// TestEstimateWorkflowReadOnly verifies the summary for workflows without writes
+func TestEstimateWorkflowReadOnly(t *testing.T) {
+	ee := NewExecutionEngine(nil)
+
+	plan := &ExecutionPlan{
+		ResolvedSteps: []ResolvedStep{
+			{ID: "fetch", Service: "drive", Action: "get_file"},
+		},
+	}
+
+	estimate, err := ee.EstimateWorkflow(plan)
+	if err != nil {
+		t.Fatalf("EstimateWorkflow failed: %v", err)
+	}
+	if estimate.Summary != "no write operations" {
+		t.Errorf("Unexpected summary: %q", estimate.Summary)
+	}
+
+	if _, err := ee.EstimateWorkflow(nil); err == nil {
+		t.Error("Expected error for nil plan")
+	}
+}
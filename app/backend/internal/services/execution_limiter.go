@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// ErrExecutionQueueFull is returned when a user already has the maximum number of queued executions
+var ErrExecutionQueueFull = errors.New("execution queue is full")
+
+// ExecutionLimiter caps concurrent workflow executions per user, queueing the rest
+type ExecutionLimiter struct {
+	maxConcurrent int
+	maxQueued     int
+	users         map[string]*userExecutionSlots
+	mu            sync.Mutex
+}
+
+// userExecutionSlots tracks one user's running slots and waiting executions
+type userExecutionSlots struct {
+	slots   chan struct{}
+	waiting int
+}
+
+// NewExecutionLimiter creates a limiter allowing maxConcurrent running executions
+// and maxQueued waiting executions per user
+func NewExecutionLimiter(maxConcurrent, maxQueued int) *ExecutionLimiter {
+	if maxConcurrent < 1 {
+		maxConcurrent = 1
+	}
+	if maxQueued < 0 {
+		maxQueued = 0
+	}
+	return &ExecutionLimiter{
+		maxConcurrent: maxConcurrent,
+		maxQueued:     maxQueued,
+		users:         make(map[string]*userExecutionSlots),
+	}
+}
+
+// Acquire blocks until the user has a free execution slot. It returns a release
+// function and the queue depth observed when the request arrived (0 if a slot
+// was immediately available). ErrExecutionQueueFull is returned without blocking
+// when the user's queue is already at capacity, and ErrExecutionCancelled when ctx
+// ends while waiting, e.g. because the client disconnected.
+func (l *ExecutionLimiter) Acquire(ctx context.Context, userID string) (func(), int, error) {
+	l.mu.Lock()
+	user, exists := l.users[userID]
+	if !exists {
+		user = &userExecutionSlots{slots: make(chan struct{}, l.maxConcurrent)}
+		l.users[userID] = user
+	}
+
+	// Fast path: a slot is free
+	select {
+	case user.slots <- struct{}{}:
+		l.mu.Unlock()
+		return l.releaseFunc(userID, user), 0, nil
+	default:
+	}
+
+	if user.waiting >= l.maxQueued {
+		l.mu.Unlock()
+		return nil, user.waiting, ErrExecutionQueueFull
+	}
+	user.waiting++
+	queueDepth := user.waiting
+	l.mu.Unlock()
+
+	log.Printf("[ExecutionLimiter] User %s queued (depth: %d, running: %d)", userID, queueDepth, l.maxConcurrent)
+	select {
+	case user.slots <- struct{}{}:
+	case <-ctx.Done():
+		l.mu.Lock()
+		user.waiting--
+		l.dropIdle(userID, user)
+		l.mu.Unlock()
+		log.Printf("[ExecutionLimiter] User %s left the queue: %v", userID, ctx.Err())
+		return nil, queueDepth, fmt.Errorf("%w while queued: %w", ErrExecutionCancelled, ctx.Err())
+	}
+
+	l.mu.Lock()
+	user.waiting--
+	l.mu.Unlock()
+
+	return l.releaseFunc(userID, user), queueDepth, nil
+}
+
+// QueueDepth returns how many executions are waiting for a slot for the user
+func (l *ExecutionLimiter) QueueDepth(userID string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if user, exists := l.users[userID]; exists {
+		return user.waiting
+	}
+	return 0
+}
+
+// releaseFunc frees a slot exactly once and drops idle user entries
+func (l *ExecutionLimiter) releaseFunc(userID string, user *userExecutionSlots) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			<-user.slots
+
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			l.dropIdle(userID, user)
+		})
+	}
+}
+
+// dropIdle removes a user entry with no running or waiting executions; callers must hold mu
+func (l *ExecutionLimiter) dropIdle(userID string, user *userExecutionSlots) {
+	if len(user.slots) == 0 && user.waiting == 0 && l.users[userID] == user {
+		delete(l.users, userID)
+	}
+}
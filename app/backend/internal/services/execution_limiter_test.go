@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestExecutionLimiterQueuesAndRejects verifies slots, queueing and queue-full rejection
+func TestExecutionLimiterQueuesAndRejects(t *testing.T) {
+	limiter := NewExecutionLimiter(1, 1)
+
+	release, depth, err := limiter.Acquire(context.Background(), "user_1")
+	if err != nil {
+		t.Fatalf("First acquire failed: %v", err)
+	}
+	if depth != 0 {
+		t.Errorf("Expected queue depth 0, got %d", depth)
+	}
+
+	// Second execution waits in the queue
+	acquired := make(chan int, 1)
+	go func() {
+		releaseQueued, queuedDepth, err := limiter.Acquire(context.Background(), "user_1")
+		if err != nil {
+			t.Errorf("Queued acquire failed: %v", err)
+			return
+		}
+		acquired <- queuedDepth
+		releaseQueued()
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for limiter.QueueDepth("user_1") != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for execution to queue")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// Third execution exceeds the queue and is rejected immediately
+	if _, _, err := limiter.Acquire(context.Background(), "user_1"); !errors.Is(err, ErrExecutionQueueFull) {
+		t.Errorf("Expected ErrExecutionQueueFull, got %v", err)
+	}
+
+	// Other users are unaffected
+	releaseOther, _, err := limiter.Acquire(context.Background(), "user_2")
+	if err != nil {
+		t.Fatalf("Acquire for another user failed: %v", err)
+	}
+	releaseOther()
+
+	release()
+	release() // release is idempotent
+
+	select {
+	case queuedDepth := <-acquired:
+		if queuedDepth != 1 {
+			t.Errorf("Expected queued depth 1, got %d", queuedDepth)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Queued execution never acquired a slot")
+	}
+}
+
+// TestExecutionLimiterCancelWhileQueued verifies a queued execution whose context ends leaves
+// the queue without taking a slot
+func TestExecutionLimiterCancelWhileQueued(t *testing.T) {
+	limiter := NewExecutionLimiter(1, 1)
+	release, _, err := limiter.Acquire(context.Background(), "user_1")
+	if err != nil {
+		t.Fatalf("First acquire failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() {
+		_, _, err := limiter.Acquire(ctx, "user_1")
+		result <- err
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for limiter.QueueDepth("user_1") != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for execution to queue")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+
+	select {
+	case err := <-result:
+		if !errors.Is(err, ErrExecutionCancelled) || !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected a cancelled execution, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Queued execution didn't stop waiting when its context was cancelled")
+	}
+	if depth := limiter.QueueDepth("user_1"); depth != 0 {
+		t.Errorf("Expected the queue to be empty, got depth %d", depth)
+	}
+
+	// The slot is still held by the first execution and freed by its release
+	release()
+	releaseNext, _, err := limiter.Acquire(context.Background(), "user_1")
+	if err != nil {
+		t.Fatalf("Acquire after release failed: %v", err)
+	}
+	releaseNext()
+	if len(limiter.users) != 0 {
+		t.Errorf("Expected idle users to be dropped, got %d", len(limiter.users))
+	}
+}
@@ -92,9 +92,10 @@ func (f *FirebaseAuthService) ValidateIDToken(idToken string) (*types.User, erro
 
 	// Create SOHOAAS user from Firebase user
 	user := &types.User{
-		ID:    token.UID,
-		Email: userRecord.Email,
-		Name:  userRecord.DisplayName,
+		ID:            token.UID,
+		Email:         userRecord.Email,
+		EmailVerified: userRecord.EmailVerified,
+		Name:          userRecord.DisplayName,
 		OAuthTokens: map[string]interface{}{
 			"google": map[string]interface{}{
 				"access_token": idToken,
@@ -102,6 +103,7 @@ func (f *FirebaseAuthService) ValidateIDToken(idToken string) (*types.User, erro
 			},
 		},
 		ConnectedServices: []string{"gmail", "calendar", "docs", "drive"},
+		TenantID:          token.Firebase.Tenant,
 	}
 
 	return user, nil
@@ -166,15 +168,17 @@ func (f *FirebaseAuthService) GetUserByUID(uid string) (*types.User, error) {
 	}
 
 	user := &types.User{
-		ID:    userRecord.UID,
-		Email: userRecord.Email,
-		Name:  userRecord.DisplayName,
+		ID:            userRecord.UID,
+		Email:         userRecord.Email,
+		EmailVerified: userRecord.EmailVerified,
+		Name:          userRecord.DisplayName,
 		OAuthTokens: map[string]interface{}{
 			"google": map[string]interface{}{
 				"token_type": "Bearer",
 			},
 		},
 		ConnectedServices: []string{"gmail", "calendar", "docs", "drive"},
+		TenantID:          userRecord.TenantID,
 	}
 
 	return user, nil
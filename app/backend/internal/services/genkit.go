@@ -6,11 +6,15 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"sohoaas-backend/internal/storage"
 	"sohoaas-backend/internal/types"
+	"sohoaas-backend/internal/workflowparser"
 
 	"github.com/firebase/genkit/go/ai"
 	"github.com/firebase/genkit/go/core"
@@ -30,21 +34,71 @@ type GenkitService struct {
 	intentAnalystFlow        *core.Flow[IntentAnalystInput, IntentAnalystOutput, struct{}]
 	workflowGeneratorFlow    *core.Flow[WorkflowGeneratorInput, WorkflowGeneratorOutput, struct{}]
 	promptsDir               string
-	// Pre-loaded prompts to avoid re-registration
+	// Pre-loaded prompts to avoid re-registration; swapped by ReloadPrompts
 	intentAnalystPrompt      interface{}
 	workflowGeneratorPrompt  interface{}
+	// Tenant prompt overrides by "<prompt>/<tenant>", loaded on first use; nil when the tenant has none
+	tenantPrompts            map[string]interface{}
+	// Incremented by ReloadPrompts so overrides loaded before a reload aren't cached after it
+	tenantPromptsGeneration  uint64
+	promptsMutex             sync.RWMutex
+	// Counter versioning the name each prompt load registers under
+	promptVersion            uint64
+	// Registers a dotprompt file under a name; nil uses genkit.LoadPrompt
+	promptRegistrar          func(path, name string) (interface{}, error)
+	// Validated RaC context files passed to agent prompts
+	racContexts              *RaCContextLoader
+	// Per-flow generation settings; flows without an entry use the prompt's front matter
+	generationConfigs        map[string]FlowGenerationConfig
+	// Package of generated CUE and the schema module it imports; empty embeds the schema
+	cuePackageName           string
+	cueSchemaImportPath      string
+	// Declare ${user.*} references generated workflows leave out of user_parameters
+	synthesizeUserParameters bool
 }
 
-// loadPrompt loads a Genkit dotprompt file with proper YAML front matter handling
+// FlowGenerationConfig holds the model settings applied to a single flow
+type FlowGenerationConfig struct {
+	Temperature     float64
+	MaxOutputTokens int
+}
+
+// PromptReloadResult reports the outcome of reloading a single prompt
+type PromptReloadResult struct {
+	Name     string `json:"name"`
+	Reloaded bool   `json:"reloaded"`
+	Error    string `json:"error,omitempty"`
+}
+
+// loadPrompt loads a Genkit dotprompt file with proper YAML front matter handling, using the
+// tenant's override when one exists (empty tenantID loads the default)
 // Returns the loaded prompt interface that can be executed
-func (g *GenkitService) loadPrompt(promptName string) (interface{}, error) {
-	promptPath := fmt.Sprintf("./prompts/%s.prompt", promptName)
-	prompt, err := genkit.LoadPrompt(g.genkit, promptPath, promptName)
+func (g *GenkitService) loadPrompt(promptName, tenantID string) (interface{}, error) {
+	promptPath, _ := g.promptPath(promptName, tenantID)
+	// Genkit rejects registering a prompt name twice, so every load gets a new version
+	name := promptName
+	if tenantID != "" {
+		name += "." + tenantID
+	}
+	name = fmt.Sprintf("%s.v%d", name, atomic.AddUint64(&g.promptVersion, 1))
+	prompt, err := g.registerPrompt(promptPath, name)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load prompt %s: %v", promptName, err)
 	}
+	return prompt, nil
+}
+
+// registerPrompt registers the dotprompt file at path under name in the Genkit registry
+func (g *GenkitService) registerPrompt(path, name string) (interface{}, error) {
+	if g.promptRegistrar != nil {
+		return g.promptRegistrar(path, name)
+	}
+	prompt, err := genkit.LoadPrompt(g.genkit, path, name)
+	if err != nil {
+		return nil, err
+	}
 	if prompt == nil {
-		return nil, fmt.Errorf("empty prompt loaded for %s", promptName)
+		return nil, fmt.Errorf("empty prompt loaded from %s", path)
 	}
 	return prompt, nil
 }
@@ -53,13 +107,14 @@ func (g *GenkitService) loadPrompt(promptName string) (interface{}, error) {
 func NewGenkitService(apiKey string, mcpService *MCPService, workflowStorage storage.WorkflowStorage) *GenkitService {
 	ctx := context.Background()
 
-	// Initialize Genkit with Google GenAI plugin and prompt directory
+	// Initialize Genkit with Google GenAI plugin and prompt directory (PROMPTS_DIR, default "prompts")
 	// Reflection port is configured via GENKIT_REFLECTION_PORT environment variable
+	promptsDir := promptsDirFromEnv()
 	g, err := genkit.Init(ctx,
 		genkit.WithPlugins(&openai.OpenAI{
 			APIKey: apiKey,
 		}),
-		genkit.WithPromptDir("prompts"),
+		genkit.WithPromptDir(promptsDir),
 	)
 	if err != nil {
 		panic(fmt.Sprintf("Failed to initialize Genkit: %v", err))
@@ -69,12 +124,17 @@ func NewGenkitService(apiKey string, mcpService *MCPService, workflowStorage sto
 	log.Printf("[GenkitService] Using workflow storage: %s", workflowStorage.GetStorageType())
 
 	service := &GenkitService{
-		ctx:             ctx,
-		genkit:          g,
-		mcpService:      mcpService,
-		mcpParser:       NewMCPCatalogParser(),
-		workflowStorage: workflowStorage,
-		promptsDir:      "./prompts",
+		ctx:               ctx,
+		genkit:            g,
+		mcpService:        mcpService,
+		mcpParser:         NewMCPCatalogParser(),
+		workflowStorage:   workflowStorage,
+		promptsDir:        promptsDir,
+		tenantPrompts:     make(map[string]interface{}),
+		racContexts:       NewRaCContextLoader(os.Getenv("RAC_CONTEXT_PATH"), DefaultRaCContexts),
+		generationConfigs: make(map[string]FlowGenerationConfig),
+
+		synthesizeUserParameters: true,
 	}
 
 	// Pre-load prompts to avoid re-registration during flow execution
@@ -88,19 +148,87 @@ func NewGenkitService(apiKey string, mcpService *MCPService, workflowStorage sto
 
 // preloadPrompts loads all prompts once during initialization to avoid re-registration
 func (g *GenkitService) preloadPrompts() {
-	var err error
-	
-	// Load intent analyst prompt
-	g.intentAnalystPrompt, err = g.loadPrompt("intent_analyst")
-	if err != nil {
-		log.Printf("Warning: Failed to preload intent_analyst prompt: %v", err)
+	for _, result := range g.ReloadPrompts() {
+		if !result.Reloaded {
+			log.Printf("Warning: Failed to preload %s prompt: %s", result.Name, result.Error)
+		}
 	}
-	
-	// Load workflow generator prompt
-	g.workflowGeneratorPrompt, err = g.loadPrompt("workflow_generator")
-	if err != nil {
-		log.Printf("Warning: Failed to preload workflow_generator prompt: %v", err)
+}
+
+// ReloadPrompts re-reads every flow's dotprompt file and swaps in the ones that load
+// successfully under a new versioned name; a prompt that fails to load keeps its previous
+// version. Tenant overrides are dropped and loaded again on next use. Flows already running
+// keep the prompt they started with.
+func (g *GenkitService) ReloadPrompts() []PromptReloadResult {
+	targets := []struct {
+		name   string
+		prompt *interface{}
+	}{
+		{"intent_analyst", &g.intentAnalystPrompt},
+		{"workflow_generator", &g.workflowGeneratorPrompt},
+	}
+
+	results := make([]PromptReloadResult, 0, len(targets))
+	for _, target := range targets {
+		prompt, err := g.loadPrompt(target.name, "")
+		if err != nil {
+			results = append(results, PromptReloadResult{Name: target.name, Error: err.Error()})
+			continue
+		}
+
+		g.promptsMutex.Lock()
+		*target.prompt = prompt
+		g.promptsMutex.Unlock()
+
+		log.Printf("[GenkitService] Loaded prompt %s", target.name)
+		results = append(results, PromptReloadResult{Name: target.name, Reloaded: true})
 	}
+
+	g.promptsMutex.Lock()
+	g.tenantPrompts = make(map[string]interface{})
+	g.tenantPromptsGeneration++
+	g.promptsMutex.Unlock()
+	return results
+}
+
+// SetFlowGenerationConfig sets the temperature and token limit used by a flow, e.g.
+// "workflow-generator"; call during startup before flows are served
+func (g *GenkitService) SetFlowGenerationConfig(flow string, config FlowGenerationConfig) {
+	g.generationConfigs[flow] = config
+	log.Printf("[GenkitService] Flow %s: temperature=%.2f max_output_tokens=%d", flow, config.Temperature, config.MaxOutputTokens)
+}
+
+// generationConfig returns the model config for a flow, or nil to keep the prompt defaults
+func (g *GenkitService) generationConfig(flow string) *ai.GenerationCommonConfig {
+	config, exists := g.generationConfigs[flow]
+	if !exists {
+		return nil
+	}
+	return &ai.GenerationCommonConfig{
+		Temperature:     config.Temperature,
+		MaxOutputTokens: config.MaxOutputTokens,
+	}
+}
+
+// promptOptions builds the Execute options for a flow's prompt
+func (g *GenkitService) promptOptions(flow string, input interface{}) []ai.PromptExecuteOption {
+	options := []ai.PromptExecuteOption{ai.WithInput(input)}
+	if config := g.generationConfig(flow); config != nil {
+		options = append(options, ai.WithConfig(config))
+	}
+	return options
+}
+
+// LoadRaCContexts validates and caches all RaC context files; call on startup to fail fast
+func (g *GenkitService) LoadRaCContexts() error {
+	return g.racContexts.Load()
+}
+
+// currentPrompt returns the cached prompt under the read lock
+func (g *GenkitService) currentPrompt(prompt *interface{}) interface{} {
+	g.promptsMutex.RLock()
+	defer g.promptsMutex.RUnlock()
+	return *prompt
 }
 
 // initializeFlows creates all Genkit flows during service initialization
@@ -137,7 +265,7 @@ func (g *GenkitService) initializeFlows() {
 			"service_catalog":    serviceCatalog,
 			"user_capabilities":  g.buildUserCapabilities(serviceCatalog),
 			"available_actions":  g.extractAvailableActions(serviceCatalog),
-			"examples":           g.generateCapabilityExamples(serviceCatalog),
+			"examples":           g.generateCapabilityExamples(mcpServices, serviceCatalog),
 			"status":             "ready",
 			"oauth_validated":    len(oauthTokens) > 0,
 			"connected_services": g.getConnectedServiceNames(serviceCatalog),
@@ -153,7 +281,7 @@ func (g *GenkitService) initializeFlows() {
 			return nil, fmt.Errorf("model openai/gpt-4o-mini not found")
 		}
 
-		resp, err := model.Generate(ctx, &ai.ModelRequest{
+		request := &ai.ModelRequest{
 			Messages: []*ai.Message{
 				{
 					Content: []*ai.Part{
@@ -162,7 +290,11 @@ func (g *GenkitService) initializeFlows() {
 					Role: ai.RoleUser,
 				},
 			},
-		}, nil)
+		}
+		if config := g.generationConfig("intent-gatherer"); config != nil {
+			request.Config = config
+		}
+		resp, err := model.Generate(ctx, request, nil)
 
 		if err != nil {
 			return nil, fmt.Errorf("failed to generate response: %w", err)
@@ -194,20 +326,18 @@ func (g *GenkitService) initializeFlows() {
 
 		// Template data is now directly used in the prompt formatting below
 
-		// Use pre-loaded prompt to avoid re-registration
-		intentPrompt := g.intentAnalystPrompt
+		// Use pre-loaded prompt (or the tenant's override) to avoid re-registration
+		intentPrompt := g.promptForTenant("intent_analyst", input.TenantID, &g.intentAnalystPrompt)
 		if intentPrompt == nil {
 			return IntentAnalystOutput{}, fmt.Errorf("intent analyst prompt not loaded")
 		}
 
-		// Load RaC context for Intent Analyst agent
-		racContextPath := "rac/agents/intent_analyst.cue"
-		racContextBytes, err := os.ReadFile(racContextPath)
+		// Load validated RaC context for Intent Analyst agent
+		racContext, err := g.racContexts.Get("intent_analyst")
 		if err != nil {
-			log.Printf("[DEBUG] Intent Analyst: Failed to load RaC context from %s: %v", racContextPath, err)
-			racContextBytes = []byte("// RaC context not available")
+			log.Printf("[DEBUG] Intent Analyst: Failed to load RaC context: %v", err)
+			racContext = "// RaC context not available"
 		}
-		racContext := string(racContextBytes)
 
 		// Execute prompt with input data (Genkit handles templating)
 		inputData := map[string]interface{}{
@@ -222,7 +352,7 @@ func (g *GenkitService) initializeFlows() {
 			return IntentAnalystOutput{}, fmt.Errorf("loaded prompt is not *ai.Prompt type")
 		}
 
-		resp, err := aiPrompt.Execute(ctx, ai.WithInput(inputData))
+		resp, err := aiPrompt.Execute(ctx, g.promptOptions("intent-analyst", inputData)...)
 		if err != nil {
 			return IntentAnalystOutput{}, fmt.Errorf("failed to generate response: %w", err)
 		}
@@ -296,8 +426,8 @@ func (g *GenkitService) initializeFlows() {
 			return WorkflowGeneratorOutput{}, fmt.Errorf("model openai/gpt-4o-mini not found")
 		}
 
-		// Use pre-loaded prompt to avoid re-registration
-		workflowPrompt := g.workflowGeneratorPrompt
+		// Use pre-loaded prompt (or the tenant's override) to avoid re-registration
+		workflowPrompt := g.promptForTenant("workflow_generator", input.TenantID, &g.workflowGeneratorPrompt)
 		if workflowPrompt == nil {
 			return WorkflowGeneratorOutput{}, fmt.Errorf("workflow generator prompt not loaded")
 		}
@@ -313,7 +443,10 @@ func (g *GenkitService) initializeFlows() {
 			return WorkflowGeneratorOutput{}, fmt.Errorf("loaded prompt is not *ai.Prompt type")
 		}
 		log.Printf("[=== DEBUG ===] Workflow Generator input: %v", input)
-		resp, err := aiPrompt.Execute(ctx, ai.WithInput(input))
+		// The tenant only selects the prompt; it isn't part of the prompt's input schema
+		promptInput := input
+		promptInput.TenantID = ""
+		resp, err := aiPrompt.Execute(ctx, g.promptOptions("workflow-generator", promptInput)...)
 
 		log.Printf("[GenkitService] Using flow-based execution with RaC context for workflow generator")
 
@@ -341,7 +474,7 @@ func (g *GenkitService) initializeFlows() {
 						Description:    "Generated from unparseable LLM response",
 						Steps:          []types.WorkflowStep{},
 						UserParameters: make(map[string]types.UserParameter),
-						Services:       make(map[string]interface{}),
+						Services:       make(map[string]WorkflowServiceBinding),
 					}
 				}
 			} else {
@@ -353,7 +486,7 @@ func (g *GenkitService) initializeFlows() {
 					Description:    "No valid JSON found in LLM response",
 					Steps:          []types.WorkflowStep{},
 					UserParameters: make(map[string]types.UserParameter),
-					Services:       make(map[string]interface{}),
+					Services:       make(map[string]WorkflowServiceBinding),
 				}
 			}
 		}
@@ -366,9 +499,24 @@ func (g *GenkitService) initializeFlows() {
 			output.UserParameters = make(map[string]types.UserParameter)
 		}
 		if output.Services == nil {
-			output.Services = make(map[string]interface{})
+			output.Services = make(map[string]WorkflowServiceBinding)
+		}
+
+		// Record the user's own words rather than the LLM's paraphrase of them
+		if input.UserIntent != "" {
+			output.OriginalIntent = input.UserIntent
+		}
+
+		// Declare the ${user.*} references the LLM forgot, so the workflow doesn't fail at execution
+		if g.synthesizeUserParameters {
+			if added := reconcileUserParameters(&output); len(added) > 0 {
+				log.Printf("[GenkitService] WARNING: Workflow %q referenced undeclared user parameters, added them as required strings: %s", output.Name, strings.Join(added, ", "))
+			}
 		}
 
+		// Convert the structured workflow to CUE here so callers never depend on the LLM emitting CUE
+		g.attachWorkflowCUE(&output)
+
 		log.Printf("[DEBUG] Workflow Generator: Parsed output: %+v", output)
 		return output, nil
 	})
@@ -405,25 +553,10 @@ func (g *GenkitService) extractAvailableActions(serviceCatalog map[string]interf
 	return actions
 }
 
-// generateCapabilityExamples creates example automation scenarios
-func (g *GenkitService) generateCapabilityExamples(serviceCatalog map[string]interface{}) []string {
-	examples := []string{}
-
-	// Generate examples dynamically based on available services from MCP catalog
-	serviceNames := g.getConnectedServiceNames(serviceCatalog)
-
-	// Generate generic examples for any available services
-	if len(serviceNames) > 0 {
-		examples = append(examples,
-			"Automate your daily workflows",
-			"Create recurring task automations",
-			"Set up notification systems",
-			"Build document processing workflows")
-	} else {
-		examples = append(examples, "Connect Google Workspace to see automation examples")
-	}
-
-	return examples
+// generateCapabilityExamples creates example automation scenarios from the functions of the
+// connected services in the catalog
+func (g *GenkitService) generateCapabilityExamples(catalog *types.MCPServiceCatalog, serviceCatalog map[string]interface{}) []string {
+	return capabilityExamples(catalog, g.getConnectedServiceNames(serviceCatalog))
 }
 
 // getConnectedServiceNames extracts service names from service catalog (using unified parser)
@@ -437,35 +570,49 @@ func (g *GenkitService) getConnectedServiceNames(serviceCatalog map[string]inter
 	return serviceNames
 }
 
+// attachWorkflowCUE sets the output's WorkflowJSON from its structured fields and, when that
+// JSON is a valid workflow, WorkflowCUE from convertJSONToCUE. Values the LLM put in either
+// field are discarded.
+func (g *GenkitService) attachWorkflowCUE(output *WorkflowGeneratorOutput) {
+	output.WorkflowJSON = nil
+	output.WorkflowCUE = ""
+
+	jsonBytes, err := json.Marshal(output)
+	if err != nil {
+		log.Printf("[GenkitService] WARNING: Failed to marshal structured workflow: %v", err)
+		return
+	}
+	var workflowJSON map[string]interface{}
+	if err := json.Unmarshal([]byte(cleanMarkdownFromJSON(string(jsonBytes))), &workflowJSON); err != nil {
+		log.Printf("[GenkitService] WARNING: Failed to unmarshal structured workflow: %v", err)
+		return
+	}
+	output.WorkflowJSON = workflowJSON
+
+	if !g.isValidWorkflowJSON(workflowJSON) {
+		log.Printf("[GenkitService] WARNING: Generated JSON does not look like valid workflow")
+		return
+	}
+	output.WorkflowCUE = g.sanitizeCUEContent(g.convertJSONToCUE(workflowJSON))
+	log.Printf("[GenkitService] Generated CUE from LLM JSON workflow (%d characters)", len(output.WorkflowCUE))
+}
+
 // convertJSONToCUE converts JSON workflow to CUE format following deterministic_workflow.cue schema
 func (g *GenkitService) convertJSONToCUE(workflowJSON map[string]interface{}) string {
 	log.Printf("[GenkitService] Converting JSON workflow to CUE format")
 
 	// Extract basic workflow information
-	workflowName := g.extractStringField(workflowJSON, "workflow_name", "Generated Workflow")
+	workflowName := g.extractStringField(workflowJSON, "workflow_name", g.extractStringField(workflowJSON, "name", "Generated Workflow"))
 	description := g.extractStringField(workflowJSON, "description", "Auto-generated workflow")
 
 	// Build CUE structure
 	var cueBuilder strings.Builder
 
-	// Package declaration
-	cueBuilder.WriteString("package workflow\n\n")
-
-	// Embed schema content directly instead of import (more robust)
-	schemaContent, err := g.loadSchemaContent()
-	if err != nil {
-		log.Printf("[GenkitService] Warning: Failed to load schema content: %v", err)
-		// Fallback to import if schema loading fails
-		cueBuilder.WriteString("import \"../../rac/schemas.cue\"\n\n")
-	} else {
-		// Embed schema directly
-		cueBuilder.WriteString("// === EMBEDDED RAC SCHEMAS ===\n")
-		cueBuilder.WriteString(schemaContent)
-		cueBuilder.WriteString("\n// === END EMBEDDED SCHEMAS ===\n\n")
-	}
+	// Package declaration and the embedded or imported schema
+	schemaRef := g.writeCUEHeader(&cueBuilder)
 
 	// Main workflow definition
-	cueBuilder.WriteString("workflow: #DeterministicWorkflow & {\n")
+	cueBuilder.WriteString(fmt.Sprintf("workflow: %s & {\n", schemaRef))
 	cueBuilder.WriteString(fmt.Sprintf("\tversion: \"1.0.0\"\n"))
 	cueBuilder.WriteString(fmt.Sprintf("\tname: %q\n", workflowName))
 	cueBuilder.WriteString(fmt.Sprintf("\tdescription: %q\n", description))
@@ -497,9 +644,24 @@ func (g *GenkitService) convertJSONToCUE(workflowJSON map[string]interface{}) st
 	cueBuilder.WriteString("\texecution_config: {\n")
 	cueBuilder.WriteString("\t\tmode: \"sequential\"\n")
 	cueBuilder.WriteString("\t\ttimeout: \"5m\"\n")
-	cueBuilder.WriteString("\t\tenvironment: \"development\"\n")
+	// Keep a declared environment; it selects the engine's execution policy
+	environment := EnvironmentDevelopment
+	exclusive := false
+	if executionConfig, ok := workflowJSON["execution_config"].(map[string]interface{}); ok {
+		if declared, err := workflowparser.NormalizeEnvironment(g.extractStringField(executionConfig, "environment", "")); err == nil {
+			environment = declared
+		}
+		exclusive = getBool(executionConfig, "exclusive")
+	}
+	cueBuilder.WriteString(fmt.Sprintf("\t\tenvironment: %q\n", environment))
+	if exclusive {
+		cueBuilder.WriteString("\t\texclusive: true\n")
+	}
 	cueBuilder.WriteString("\t}\n")
 
+	// Add the trigger; schedules are stored with their normalized cron for the scheduler
+	cueBuilder.WriteString(g.convertJSONTriggerToCUE(workflowJSON))
+
 	// Close workflow definition
 	cueBuilder.WriteString("}\n")
 
@@ -508,6 +670,40 @@ func (g *GenkitService) convertJSONToCUE(workflowJSON map[string]interface{}) st
 	return result
 }
 
+// convertJSONTriggerToCUE converts the workflow's trigger, if any. An invalid schedule is kept
+// without a cron so parsing the generated workflow rejects it before it is saved.
+func (g *GenkitService) convertJSONTriggerToCUE(workflowJSON map[string]interface{}) string {
+	triggerData, ok := workflowJSON["trigger"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	schedule := strings.TrimSpace(g.extractStringField(triggerData, "schedule", ""))
+	triggerType := g.extractStringField(triggerData, "type", "")
+	if triggerType == "" {
+		triggerType = workflowparser.TriggerManual
+		if schedule != "" {
+			triggerType = workflowparser.TriggerSchedule
+		}
+	}
+
+	var triggerBuilder strings.Builder
+	triggerBuilder.WriteString("\ttrigger: {\n")
+	triggerBuilder.WriteString(fmt.Sprintf("\t\ttype: %q\n", triggerType))
+	if schedule != "" {
+		triggerBuilder.WriteString(fmt.Sprintf("\t\tschedule: %q\n", schedule))
+		if cron, err := workflowparser.NormalizeSchedule(schedule); err == nil {
+			triggerBuilder.WriteString(fmt.Sprintf("\t\tcron: %q\n", cron))
+		} else {
+			log.Printf("[GenkitService] Warning: %v", err)
+		}
+	}
+	if event := g.extractStringField(triggerData, "event", ""); event != "" {
+		triggerBuilder.WriteString(fmt.Sprintf("\t\tevent: %q\n", event))
+	}
+	triggerBuilder.WriteString("\t}\n")
+	return triggerBuilder.String()
+}
+
 // extractStringField safely extracts a string field from JSON with default fallback
 func (g *GenkitService) extractStringField(data map[string]interface{}, field, defaultValue string) string {
 	if value, exists := data[field]; exists {
@@ -525,6 +721,9 @@ func (g *GenkitService) convertJSONStepsToCUE(workflowJSON map[string]interface{
 
 	if stepsData, exists := workflowJSON["steps"]; exists {
 		if stepsArray, ok := stepsData.([]interface{}); ok {
+			// Make ordering explicit: steps depend on every step whose outputs they reference
+			linkReferencedDependencies(stepsArray)
+
 			for i, stepData := range stepsArray {
 				if stepMap, ok := stepData.(map[string]interface{}); ok {
 					stepCUE := g.convertSingleStepToCUE(stepMap, i)
@@ -572,8 +771,8 @@ func (g *GenkitService) convertSingleStepToCUE(stepData map[string]interface{},
 	if paramsData, exists := stepData["parameters"]; exists {
 		if paramsMap, ok := paramsData.(map[string]interface{}); ok {
 			stepBuilder.WriteString("\t\t\tparameters: {\n")
-			for key, value := range paramsMap {
-				stepBuilder.WriteString(fmt.Sprintf("\t\t\t\t%s: %s\n", key, g.formatCUEValue(value)))
+			for _, key := range sortedKeys(paramsMap) {
+				stepBuilder.WriteString(fmt.Sprintf("\t\t\t\t%s: %s\n", key, g.formatCUEValue(paramsMap[key])))
 			}
 			stepBuilder.WriteString("\t\t\t}\n")
 		}
@@ -600,6 +799,26 @@ func (g *GenkitService) convertSingleStepToCUE(stepData map[string]interface{},
 		stepBuilder.WriteString(fmt.Sprintf("\t\t\ttimeout: %q\n", timeout))
 	}
 
+	// Carry over human-in-the-loop confirmation flag
+	if getBool(stepData, "requires_confirmation") {
+		stepBuilder.WriteString("\t\t\trequires_confirmation: true\n")
+	}
+
+	// Carry over the output caching flag of read steps
+	if getBool(stepData, "cacheable") {
+		stepBuilder.WriteString("\t\t\tcacheable: true\n")
+	}
+
+	// Carry over an explicit workspace connection binding
+	if connection := g.extractStringField(stepData, "connection", ""); connection != "" {
+		stepBuilder.WriteString(fmt.Sprintf("\t\t\tconnection: %q\n", connection))
+	}
+
+	// Carry over an explicit provider, e.g. a staging proxy of the service
+	if provider := g.extractStringField(stepData, "provider", ""); provider != "" {
+		stepBuilder.WriteString(fmt.Sprintf("\t\t\tprovider: %q\n", provider))
+	}
+
 	stepBuilder.WriteString("\t\t}")
 	return stepBuilder.String()
 }
@@ -611,8 +830,8 @@ func (g *GenkitService) convertJSONUserParametersToCUE(workflowJSON map[string]i
 
 	if paramsData, exists := workflowJSON["user_parameters"]; exists {
 		if paramsMap, ok := paramsData.(map[string]interface{}); ok {
-			for paramName, paramData := range paramsMap {
-				if paramMap, ok := paramData.(map[string]interface{}); ok {
+			for _, paramName := range sortedKeys(paramsMap) {
+				if paramMap, ok := paramsMap[paramName].(map[string]interface{}); ok {
 					paramCUE := g.convertSingleUserParameterToCUE(paramMap)
 					paramsBuilder.WriteString(fmt.Sprintf("\t\t%s: %s\n", paramName, paramCUE))
 				}
@@ -659,8 +878,14 @@ func (g *GenkitService) convertSingleUserParameterToCUE(paramData map[string]int
 		paramBuilder.WriteString(fmt.Sprintf("\t\t\tplaceholder: %q\n", placeholder))
 	}
 
+	// A sensitive parameter's default would be stored in the workflow in plaintext, so it is dropped
+	sensitive, _ := paramData["sensitive"].(bool)
+	if sensitive {
+		paramBuilder.WriteString("\t\t\tsensitive: true\n")
+	}
+
 	// Handle default field - this was missing!
-	if defaultValue, exists := paramData["default"]; exists && defaultValue != nil {
+	if defaultValue, exists := paramData["default"]; exists && defaultValue != nil && !sensitive {
 		switch v := defaultValue.(type) {
 		case string:
 			paramBuilder.WriteString(fmt.Sprintf("\t\t\tdefault: %q\n", v))
@@ -680,19 +905,33 @@ func (g *GenkitService) convertSingleUserParameterToCUE(paramData map[string]int
 	return paramBuilder.String()
 }
 
-// convertJSONServiceBindingsToCUE converts JSON service bindings to CUE format
+// convertJSONServiceBindingsToCUE converts JSON service bindings to CUE format. Bindings are
+// an object keyed by service name, as WorkflowJSONSchema describes; an array of bindings that
+// name their service is accepted too.
 func (g *GenkitService) convertJSONServiceBindingsToCUE(workflowJSON map[string]interface{}) string {
 	var servicesBuilder strings.Builder
 	servicesBuilder.WriteString("\tservice_bindings: {\n")
 
-	if servicesData, exists := workflowJSON["services"]; exists {
-		if servicesArray, ok := servicesData.([]interface{}); ok {
-			for _, serviceData := range servicesArray {
-				if serviceMap, ok := serviceData.(map[string]interface{}); ok {
-					serviceName := g.extractStringField(serviceMap, "service", "unknown")
-					serviceCUE := g.convertSingleServiceBindingToCUE(serviceMap)
-					servicesBuilder.WriteString(fmt.Sprintf("\t\t%s: %s\n", serviceName, serviceCUE))
-				}
+	switch servicesData := workflowJSON["services"].(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(servicesData))
+		for key := range servicesData {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if serviceMap, ok := servicesData[key].(map[string]interface{}); ok {
+				serviceName := g.extractStringField(serviceMap, "service", key)
+				serviceCUE := g.convertSingleServiceBindingToCUE(serviceMap)
+				servicesBuilder.WriteString(fmt.Sprintf("\t\t%s: %s\n", serviceName, serviceCUE))
+			}
+		}
+	case []interface{}:
+		for _, serviceData := range servicesData {
+			if serviceMap, ok := serviceData.(map[string]interface{}); ok {
+				serviceName := g.extractStringField(serviceMap, "service", "unknown")
+				serviceCUE := g.convertSingleServiceBindingToCUE(serviceMap)
+				servicesBuilder.WriteString(fmt.Sprintf("\t\t%s: %s\n", serviceName, serviceCUE))
 			}
 		}
 	}
@@ -707,25 +946,48 @@ func (g *GenkitService) convertSingleServiceBindingToCUE(serviceData map[string]
 
 	serviceBuilder.WriteString("{\n")
 	serviceBuilder.WriteString("\t\t\ttype: \"mcp_service\"\n")
-	serviceBuilder.WriteString("\t\t\tprovider: \"workspace\"\n")
+	// Only a declared provider is written; without one the engine finds the service in any provider
+	if provider := g.extractStringField(serviceData, "provider", ""); provider != "" {
+		serviceBuilder.WriteString(fmt.Sprintf("\t\t\tprovider: %q\n", provider))
+	}
+	if connection := g.extractStringField(serviceData, "connection", ""); connection != "" {
+		serviceBuilder.WriteString(fmt.Sprintf("\t\t\tconnection: %q\n", connection))
+	}
 
-	// Auth configuration
+	// Auth configuration in the schema's #AuthConfig shape: a named secret (resolved at
+	// execution, never inlined) or OAuth scopes
 	serviceBuilder.WriteString("\t\t\tauth: {\n")
-	serviceBuilder.WriteString("\t\t\t\ttype: \"oauth2\"\n")
+	if secretRef := g.extractStringField(serviceData, "secret_ref", ""); secretRef != "" {
+		serviceBuilder.WriteString(fmt.Sprintf("\t\t\t\tmethod: %q\n", AuthTypeAPIKey))
+		serviceBuilder.WriteString(fmt.Sprintf("\t\t\t\tsecret_ref: %q\n", secretRef))
+		serviceBuilder.WriteString("\t\t\t}\n")
+		serviceBuilder.WriteString("\t\t}")
+		return serviceBuilder.String()
+	}
+	serviceBuilder.WriteString("\t\t\t\tmethod: \"oauth2\"\n")
 
 	// OAuth scopes
 	if scopesData, exists := serviceData["oauth_scopes"]; exists {
 		if scopesArray, ok := scopesData.([]interface{}); ok {
-			serviceBuilder.WriteString("\t\t\t\tscopes: [")
-			for i, scope := range scopesArray {
+			var scopes []string
+			for _, scope := range scopesArray {
 				if scopeStr, ok := scope.(string); ok {
-					serviceBuilder.WriteString(fmt.Sprintf("%q", scopeStr))
-					if i < len(scopesArray)-1 {
-						serviceBuilder.WriteString(", ")
-					}
+					scopes = append(scopes, scopeStr)
+				}
+			}
+			// Drop duplicates and scopes already granted by a broader one
+			scopes = normalizeOAuthScopes(scopes)
+			serviceBuilder.WriteString("\t\t\t\toauth2: {\n")
+			serviceBuilder.WriteString("\t\t\t\t\tscopes: [")
+			for i, scope := range scopes {
+				serviceBuilder.WriteString(fmt.Sprintf("%q", scope))
+				if i < len(scopes)-1 {
+					serviceBuilder.WriteString(", ")
 				}
 			}
 			serviceBuilder.WriteString("]\n")
+			serviceBuilder.WriteString("\t\t\t\t\ttoken_source: \"user\"\n")
+			serviceBuilder.WriteString("\t\t\t\t}\n")
 		}
 	}
 
@@ -753,7 +1015,8 @@ func (g *GenkitService) sanitizeCUEContent(cueContent string) string {
 	return sanitized
 }
 
-// formatCUEValue formats a JSON value for CUE syntax
+// formatCUEValue formats a JSON value for CUE syntax. Objects and lists are written as CUE
+// structs and lists, so literal JSON object parameters stay structured through parsing.
 func (g *GenkitService) formatCUEValue(value interface{}) string {
 	switch v := value.(type) {
 	case string:
@@ -763,46 +1026,28 @@ func (g *GenkitService) formatCUEValue(value interface{}) string {
 		}
 		return fmt.Sprintf("%q", v)
 	case float64:
-		return fmt.Sprintf("%.0f", v)
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
 	case bool:
 		return fmt.Sprintf("%t", v)
 	case nil:
 		return "null"
+	case map[string]interface{}:
+		fields := make([]string, 0, len(v))
+		for _, key := range sortedKeys(v) {
+			fields = append(fields, fmt.Sprintf("%q: %s", key, g.formatCUEValue(v[key])))
+		}
+		return "{" + strings.Join(fields, ", ") + "}"
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, g.formatCUEValue(item))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
 	default:
 		return fmt.Sprintf("%q", fmt.Sprintf("%v", v))
 	}
 }
-
-
-
-// loadSchemaContent loads the RaC schema content for embedding in generated CUE files
-func (g *GenkitService) loadSchemaContent() (string, error) {
-	// Get RaC context path from environment or use default
-	racContextPath := os.Getenv("RAC_CONTEXT_PATH")
-	if racContextPath == "" {
-		racContextPath = "../../rac" // Default relative path
-	}
-
-	schemaPath := filepath.Join(racContextPath, "schemas.cue")
-
-	// Read schema file content
-	content, err := os.ReadFile(schemaPath)
-	if err != nil {
-		return "", fmt.Errorf("failed to read schema file %s: %w", schemaPath, err)
-	}
-
-	// Convert to string and remove package declaration (we'll use our own)
-	schemaContent := string(content)
-
-	// Remove the package declaration line since we're embedding
-	lines := strings.Split(schemaContent, "\n")
-	var filteredLines []string
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		if !strings.HasPrefix(trimmed, "package ") {
-			filteredLines = append(filteredLines, line)
-		}
-	}
-
-	return strings.Join(filteredLines, "\n"), nil
-}
@@ -57,6 +57,7 @@ func (g *GenkitService) ExecuteIntentAnalystAgent(input map[string]interface{})
 		}
 	}
 	typedInput.AvailableServices = availableServices
+	typedInput.TenantID, _ = input["tenant_id"].(string)
 
 	log.Printf("[DEBUG] ExecuteIntentAnalystAgent: Simplified input: %+v", typedInput)
 
@@ -69,6 +70,9 @@ func (g *GenkitService) ExecuteIntentAnalystAgent(input map[string]interface{})
 		}, nil
 	}
 
+	// Don't trust the LLM's self-assessment of which services exist
+	validateRequiredServices(&result, availableServices)
+
 	// Convert typed output back to map[string]interface{} for compatibility
 	outputMap := map[string]interface{}{
 		"is_automation_request": result.IsAutomationRequest,
@@ -6,6 +6,7 @@ import "sohoaas-backend/internal/types"
 type IntentAnalystInput struct {
 	UserMessage       string   `json:"user_message"`
 	AvailableServices []string `json:"available_services"`
+	TenantID          string   `json:"tenant_id,omitempty"` // selects the tenant's prompt override
 }
 
 type IntentAnalystOutput struct {
@@ -33,17 +34,47 @@ type WorkflowGeneratorInput struct {
 	ValidatedIntent   ValidatedIntent `json:"validated_intent"`
 	AvailableServices string          `json:"available_services"`
 	RacContext        string          `json:"rac_context"`
+	TenantID          string          `json:"tenant_id,omitempty"` // selects the tenant's prompt override
 }
 
 type WorkflowGeneratorOutput struct {
-	Version        string                         `json:"version"`
-	Name           string                         `json:"name"`
-	Description    string                         `json:"description"`
-	OriginalIntent string                         `json:"original_intent"`
-	Steps          []types.WorkflowStep           `json:"steps"`
-	UserParameters map[string]types.UserParameter `json:"user_parameters"`
-	Services       map[string]interface{}         `json:"services"`
-	DecisionLog    *DecisionLog                   `json:"decision_log,omitempty"`
+	Version        string                            `json:"version"`
+	Name           string                            `json:"name"`
+	Description    string                            `json:"description"`
+	OriginalIntent string                            `json:"original_intent"`
+	Steps          []types.WorkflowStep              `json:"steps"`
+	UserParameters map[string]types.UserParameter    `json:"user_parameters"`
+	Services       map[string]WorkflowServiceBinding `json:"services"` // keyed by service name
+	DecisionLog    *DecisionLog                      `json:"decision_log,omitempty"`
+
+	ExecutionConfig *WorkflowExecutionConfig `json:"execution_config,omitempty"`
+	Trigger         *WorkflowTriggerConfig   `json:"trigger,omitempty"`
+
+	// Set by the flow, not the LLM: the structured workflow above as JSON and its CUE conversion
+	WorkflowJSON map[string]interface{} `json:"workflow_json,omitempty"`
+	WorkflowCUE  string                 `json:"workflow_cue,omitempty"` // empty when the structured workflow is invalid
+}
+
+// WorkflowServiceBinding is how a workflow authenticates one service; it becomes the CUE
+// service_bindings entry of the service it is keyed by
+type WorkflowServiceBinding struct {
+	Service     string   `json:"service,omitempty"`    // defaults to the key
+	Provider    string   `json:"provider,omitempty"`   // catalog provider; any provider offering the service when empty
+	Connection  string   `json:"connection,omitempty"` // named account connection
+	SecretRef   string   `json:"secret_ref,omitempty"` // named secret for api_key auth instead of OAuth
+	OAuthScopes []string `json:"oauth_scopes,omitempty"`
+}
+
+// WorkflowExecutionConfig is the authored part of a workflow's execution_config
+type WorkflowExecutionConfig struct {
+	Environment string `json:"environment,omitempty"` // development (default), staging or production
+	Exclusive   bool   `json:"exclusive,omitempty"`   // reject a new run while one is running
+}
+
+// WorkflowTriggerConfig is the authored trigger of a workflow; omitted for manual workflows
+type WorkflowTriggerConfig struct {
+	Type     string `json:"type"`               // manual, schedule or event
+	Schedule string `json:"schedule,omitempty"` // cron or a phrase like "every weekday at 8 AM"
 }
 
 // DecisionLog captures a concise, non-authoritative trace emitted by the LLM
@@ -1,6 +1,9 @@
 package services
 
-import "strings"
+import (
+	"sort"
+	"strings"
+)
 
 // getInputKeys returns the keys of a map[string]interface{} for logging
 func getInputKeys(m map[string]interface{}) []string {
@@ -20,6 +23,13 @@ func getMapKeys(m map[string]interface{}) []string {
 	return keys
 }
 
+// sortedKeys returns the keys of m in sorted order, so output built from a map is stable
+func sortedKeys(m map[string]interface{}) []string {
+	keys := getMapKeys(m)
+	sort.Strings(keys)
+	return keys
+}
+
 func getString(m map[string]interface{}, key string) string {
 	if val, exists := m[key]; exists {
 		if str, ok := val.(string); ok {
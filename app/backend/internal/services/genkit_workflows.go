@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"os"
 	"regexp"
 	"strings"
 	"time"
@@ -22,80 +21,27 @@ func (g *GenkitService) ExecuteWorkflowGeneratorAgent(input map[string]interface
         log.Printf("[GenkitService] Validated intent: %+v", validatedIntent)
     }
 
-	// Convert map input to typed struct with safe defaults
-	userIntent := getString(input, "user_intent")
-	validatedIntentMap := getMap(input, "validated_intent")
-	availableServices := getString(input, "available_services")
-
-	// Convert validated_intent map to typed struct
-	validatedIntent := ValidatedIntent{
-		IsAutomationRequest: getBool(validatedIntentMap, "is_automation_request"),
-		RequiredServices:    getStringSlice(validatedIntentMap, "required_services"),
-		CanFulfill:          getBool(validatedIntentMap, "can_fulfill"),
-		MissingInfo:         getStringSlice(validatedIntentMap, "missing_info"),
-		NextAction:          getStringFromMap(validatedIntentMap, "next_action"),
-		Explanation:         getStringFromMap(validatedIntentMap, "explanation"),
-		Confidence:          getFloat64(validatedIntentMap, "confidence"),
-		WorkflowPattern:     getStringFromMap(validatedIntentMap, "workflow_pattern"),
-	}
-
-	// Load focused RaC context from workflow-prompt.cue (streamlined for LLM)
-	racBasePath := os.Getenv("RAC_CONTEXT_PATH")
-	if racBasePath == "" {
-		racBasePath = "rac" // Default fallback
-	}
+	// Load focused RaC context from workflow-prompt.cue (streamlined for LLM, validated on load)
 	log.Printf("[GenkitService] === RAC CONTEXT LOADING ===")
-	log.Printf("[GenkitService] RAC_CONTEXT_PATH env: %s", os.Getenv("RAC_CONTEXT_PATH"))
-	log.Printf("[GenkitService] Using RaC base path: %s", racBasePath)
-
-	// Load workflow-prompt.cue (focused schema for LLM workflow generation)
-	racContextPath := fmt.Sprintf("%s/agents/prompts/workflow-prompt.cue", racBasePath)
-	log.Printf("[GenkitService] Loading focused workflow context from: %s", racContextPath)
-	racContextBytes, err := os.ReadFile(racContextPath)
+	racContext, err := g.racContexts.Get("workflow_prompt")
 	if err != nil {
-		log.Printf("[GenkitService] ERROR: Failed to load workflow prompt context from %s: %v", racContextPath, err)
-		return nil, fmt.Errorf("failed to load RaC workflow prompt context from %s: %w", racContextPath, err)
+		log.Printf("[GenkitService] ERROR: Failed to load workflow prompt context: %v", err)
+		return nil, fmt.Errorf("failed to load RaC workflow prompt context: %w", err)
 	}
-	log.Printf("[GenkitService] SUCCESS: Loaded focused workflow context (%d bytes)", len(racContextBytes))
-
-	// Use focused workflow-prompt.cue directly (no combination needed)
-	racContext := string(racContextBytes)
-	log.Printf("[GenkitService] SUCCESS: Using focused RaC workflow context (%d total bytes)", len(racContext))
+	log.Printf("[GenkitService] SUCCESS: Loaded focused workflow context (%d bytes)", len(racContext))
 
-	workflowInput := WorkflowGeneratorInput{
-		UserIntent:        userIntent,
-		ValidatedIntent:   validatedIntent,
-		AvailableServices: availableServices,
-		RacContext:        racContext,
+	// Convert the map input to a typed struct, rejecting missing or malformed inputs before the LLM sees them
+	workflowInput, err := NewWorkflowGeneratorInputBuilder().FromMap(input).WithRaCContext(racContext).Build()
+	if err != nil {
+		log.Printf("[GenkitService] ERROR: %v", err)
+		return nil, err
 	}
+	userIntent := workflowInput.UserIntent
 	log.Printf("[GenkitService] === WORKFLOW INPUT PREPARED ===")
-	log.Printf("[GenkitService] UserIntent length: %d chars", len(userIntent))
-	log.Printf("[GenkitService] AvailableServices length: %d chars", len(availableServices))
-	log.Printf("[GenkitService] RacContext length: %d chars", len(racContext))
-	log.Printf("[GenkitService] ValidatedIntent services: %+v", validatedIntent.RequiredServices)
-
-	// Additional validation for completely empty original input
-	allEmpty := getString(input, "user_intent") == "" &&
-		len(getMap(input, "validated_intent")) == 0 &&
-		getString(input, "available_services") == ""
-
-	if allEmpty {
-		log.Printf("[GenkitService] WARNING: All inputs empty, returning minimal fallback workflow")
-		// Return minimal valid workflow instead of calling LLM
-		return &types.AgentResponse{
-			AgentID: "workflow_generator",
-			Output: map[string]interface{}{
-				"version":         "1.0",
-				"name":            "empty_input_workflow",
-				"description":     "Generated from empty input - no user intent provided",
-				"steps":           []types.WorkflowStep{},
-				"user_parameters": make(map[string]types.UserParameter),
-				"services":        make(map[string]interface{}),
-				"workflow_cue":    "// Empty workflow - no user input provided\nworkflow: {}\n",
-				"original_cue":    "// Empty workflow - no user input provided\nworkflow: {}\n",
-			},
-		}, nil
-	}
+	log.Printf("[GenkitService] UserIntent length: %d chars", len(workflowInput.UserIntent))
+	log.Printf("[GenkitService] AvailableServices length: %d chars", len(workflowInput.AvailableServices))
+	log.Printf("[GenkitService] RacContext length: %d chars", len(workflowInput.RacContext))
+	log.Printf("[GenkitService] ValidatedIntent services: %+v", workflowInput.ValidatedIntent.RequiredServices)
 
 	// Execute the pre-defined flow to get JSON workflow with error recovery
 	log.Printf("[GenkitService] === EXECUTING WORKFLOW GENERATOR FLOW ===")
@@ -131,37 +77,9 @@ func (g *GenkitService) ExecuteWorkflowGeneratorAgent(input map[string]interface
 	log.Printf("[=== GenkitService] LLM flow completed successfully")
 	log.Printf("[GenkitService] Workflow Generator result: %+v", result)
 
-	// Extract workflow JSON from LLM result and convert to CUE (following RaC specification)
-	var cueContent string
-
-	log.Printf("[GenkitService] Extracting workflow JSON from LLM result (RaC-compliant)")
+	// RaC Specification: LLM returns structured JSON, the flow converts it to CUE
 	log.Printf("[GenkitService] Result version: %s, name: %s", result.Version, result.Name)
-
-	// RaC Specification: LLM should return structured JSON, system converts to CUE
-	// Convert typed struct to map for JSON processing
-	var workflowJSON map[string]interface{}
-
-	// Method 1: Convert typed struct to JSON map with markdown cleaning
-	if jsonBytes, err := json.Marshal(result); err == nil {
-		// Clean any markdown formatting from the JSON before parsing
-		cleanedJSON := cleanMarkdownFromJSON(string(jsonBytes))
-		log.Printf("[GenkitService] Cleaned JSON length: %d characters", len(cleanedJSON))
-
-		if err := json.Unmarshal([]byte(cleanedJSON), &workflowJSON); err == nil {
-			// Validate that this looks like a workflow JSON (has required fields)
-			if g.isValidWorkflowJSON(workflowJSON) {
-				cueContent = g.convertJSONToCUE(workflowJSON)
-				// Sanitize CUE content to remove illegal characters
-				cueContent = g.sanitizeCUEContent(cueContent)
-				log.Printf("[GenkitService] Generated CUE from LLM JSON workflow (%d characters)", len(cueContent))
-			} else {
-				log.Printf("[GenkitService] WARNING: Generated JSON does not look like valid workflow")
-			}
-		} else {
-			log.Printf("[GenkitService] WARNING: Failed to unmarshal cleaned JSON: %v", err)
-			log.Printf("[GenkitService] Cleaned JSON content: %s", cleanedJSON)
-		}
-	}
+	cueContent := result.WorkflowCUE
 
 	// No fallback methods - LLM must generate valid JSON workflow according to schema
 
@@ -172,6 +90,26 @@ func (g *GenkitService) ExecuteWorkflowGeneratorAgent(input map[string]interface
 		}, nil
 	}
 
+	// Dry-validate the generated CUE against the MCP catalog before persisting,
+	// so unrunnable workflows are rejected instead of saved
+	if validationErr := g.validateGeneratedWorkflow(cueContent); validationErr != nil {
+		log.Printf("[GenkitService] ERROR: Generated workflow failed validation, not saving: %v", validationErr)
+
+		resultMap := make(map[string]interface{})
+		if jsonBytes, err := json.Marshal(result); err == nil {
+			json.Unmarshal(jsonBytes, &resultMap)
+		}
+		resultMap["workflow_cue"] = cueContent
+		resultMap["status"] = "invalid"
+		resultMap["validation_errors"] = []string{validationErr.Error()}
+
+		return &types.AgentResponse{
+			AgentID: "workflow_generator",
+			Output:  resultMap,
+			Error:   fmt.Sprintf("generated workflow failed validation: %v", validationErr),
+		}, nil
+	}
+
 	// Extract user ID from input
 	userID := "authenticated_user" // Default fallback
 	if uid, exists := input["user_id"]; exists {
@@ -216,8 +154,8 @@ func (g *GenkitService) ExecuteWorkflowGeneratorAgent(input map[string]interface
 			}
 		}
 
-		// Save original JSON workflow (convert struct to JSON)
-		if jsonContent, err := json.MarshalIndent(result, "", "  "); err == nil {
+		// Save the structured JSON workflow the CUE was converted from
+		if jsonContent, err := json.MarshalIndent(result.WorkflowJSON, "", "  "); err == nil {
 			if saveErr := g.workflowStorage.SaveWorkflowArtifact(userID, workflowID, ".", "workflow.json", string(jsonContent)); saveErr != nil {
 				log.Printf("[GenkitService] ERROR: Failed to save workflow.json: %v", saveErr)
 			} else {
@@ -327,6 +265,28 @@ func (g *GenkitService) ExecuteWorkflowGeneratorAgent(input map[string]interface
 	}, nil
 }
 
+// validateGeneratedWorkflow parses generated CUE and runs the execution engine's
+// service and output-reference validation against the live MCP catalog
+func (g *GenkitService) validateGeneratedWorkflow(cueContent string) error {
+	engine := NewExecutionEngine(g.mcpService)
+
+	workflow, err := engine.ParseCUEWorkflow(cueContent)
+	if err != nil {
+		return fmt.Errorf("failed to parse generated CUE: %w", err)
+	}
+
+	// Reject services and actions the LLM made up before any other check
+	catalog, err := g.mcpService.GetServiceCatalog()
+	if err != nil {
+		return fmt.Errorf("failed to query MCP service catalog for validation: %w", err)
+	}
+	if err := NewWorkflowValidator().ContainsOnlyCatalogServices(cueContent, catalog); err != nil {
+		return err
+	}
+
+	return engine.ValidateWorkflowServices(workflow)
+}
+
 // extractWorkflowName extracts a meaningful workflow name from input or CUE content
 func (g *GenkitService) extractWorkflowName(input map[string]interface{}, cueContent string) string {
 	// Try to extract from input first
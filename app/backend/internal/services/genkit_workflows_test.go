@@ -0,0 +1,37 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestValidateGeneratedWorkflow verifies generated CUE is parsed and checked against the catalog
+// before it is saved
+func TestValidateGeneratedWorkflow(t *testing.T) {
+	service := &GenkitService{mcpService: NewFakeMCPService(MockPipelineCatalog())}
+
+	if err := service.validateGeneratedWorkflow(MockPipelineWorkflowCUE); err != nil {
+		t.Fatalf("Expected the catalog workflow to pass, got %v", err)
+	}
+
+	fabricated := strings.Replace(MockPipelineWorkflowCUE, "gmail.send_message", "gmail.generate_advice", 1)
+	if err := service.validateGeneratedWorkflow(fabricated); !errors.Is(err, ErrServiceNotInCatalog) {
+		t.Errorf("Expected ErrServiceNotInCatalog for a made-up action, got %v", err)
+	}
+
+	if err := service.validateGeneratedWorkflow(`workflow: {name: "broken"`); err == nil || !strings.Contains(err.Error(), "failed to parse generated CUE") {
+		t.Errorf("Expected a parse error for malformed CUE, got %v", err)
+	}
+}
+
+// TestValidateGeneratedWorkflowCatalogUnavailable verifies a catalog outage fails validation
+// as upstream, not as an invalid workflow
+func TestValidateGeneratedWorkflowCatalogUnavailable(t *testing.T) {
+	service := &GenkitService{mcpService: NewMCPService("")}
+
+	err := service.validateGeneratedWorkflow(MockPipelineWorkflowCUE)
+	if !errors.Is(err, ErrCatalogUnavailable) || errors.Is(err, ErrServiceNotInCatalog) {
+		t.Errorf("Expected ErrCatalogUnavailable, got %v", err)
+	}
+}
@@ -224,7 +224,7 @@ func TestIntentAnalystPromptLoading(t *testing.T) {
 	}
 
 	// Test prompt loading directly
-	prompt, err := genkitService.loadPrompt("intent_analyst")
+	prompt, err := genkitService.loadPrompt("intent_analyst", "")
 	if err != nil {
 		t.Fatalf("Failed to load intent_analyst prompt: %v", err)
 	}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"log"
+	"strings"
+
+	"sohoaas-backend/internal/workflowparser"
+)
+
+// UnsupportedServicePrefix marks missing_info entries naming a required service the catalog
+// doesn't provide, e.g. "unsupported_service:crm_system"
+const UnsupportedServicePrefix = "unsupported_service:"
+
+// nextActionUnsupportedRequest is the intent analyst's next_action for requests it can't fulfill
+const nextActionUnsupportedRequest = "unsupported_request"
+
+// validateRequiredServices checks the intent analyst's required_services against the services
+// of the live catalog instead of trusting the LLM's own can_fulfill. Aliases ("google_drive")
+// are rewritten to catalog names; any service still unknown sets can_fulfill to false and is
+// reported in missing_info. Without a catalog nothing can be checked and output is unchanged.
+func validateRequiredServices(output *IntentAnalystOutput, availableServices []string) {
+	if len(availableServices) == 0 {
+		log.Printf("[GenkitService] Intent Analyst: no catalog services available, required_services not validated")
+		return
+	}
+
+	available := make(map[string]bool, len(availableServices))
+	for _, service := range availableServices {
+		available[service] = true
+	}
+
+	requiredServices := make([]string, 0, len(output.RequiredServices))
+	seen := make(map[string]bool, len(output.RequiredServices))
+	var unsupported []string
+	for _, service := range output.RequiredServices {
+		service = workflowparser.CanonicalServiceName(strings.ToLower(service))
+		if service == "" || seen[service] {
+			continue
+		}
+		seen[service] = true
+		requiredServices = append(requiredServices, service)
+		if !available[service] {
+			unsupported = append(unsupported, service)
+		}
+	}
+	output.RequiredServices = requiredServices
+
+	if len(unsupported) == 0 {
+		return
+	}
+	log.Printf("[GenkitService] Intent Analyst: required services not in the catalog: %v", unsupported)
+	output.CanFulfill = false
+	output.NextAction = nextActionUnsupportedRequest
+	for _, service := range unsupported {
+		output.MissingInfo = append(output.MissingInfo, UnsupportedServicePrefix+service)
+	}
+}
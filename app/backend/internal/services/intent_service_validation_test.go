@@ -0,0 +1,61 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestValidateRequiredServicesUnsupported verifies a hallucinated service makes the intent unfulfillable
+func TestValidateRequiredServicesUnsupported(t *testing.T) {
+	output := IntentAnalystOutput{
+		IsAutomationRequest: true,
+		RequiredServices:    []string{"crm_system", "gmail"},
+		CanFulfill:          true,
+		MissingInfo:         []string{},
+		NextAction:          "generate_workflow",
+	}
+
+	validateRequiredServices(&output, []string{"gmail", "docs", "drive", "calendar"})
+
+	if output.CanFulfill {
+		t.Error("Expected can_fulfill to be false")
+	}
+	if !reflect.DeepEqual(output.MissingInfo, []string{"unsupported_service:crm_system"}) {
+		t.Errorf("Expected the unsupported service in missing_info, got %v", output.MissingInfo)
+	}
+	if output.NextAction != "unsupported_request" {
+		t.Errorf("Expected next_action unsupported_request, got %s", output.NextAction)
+	}
+}
+
+// TestValidateRequiredServicesAliases verifies aliases are rewritten to catalog names and accepted
+func TestValidateRequiredServicesAliases(t *testing.T) {
+	output := IntentAnalystOutput{
+		IsAutomationRequest: true,
+		RequiredServices:    []string{"Gmail", "google_drive", "drive"},
+		CanFulfill:          true,
+		MissingInfo:         []string{"recipient_email"},
+		NextAction:          "need_clarification",
+	}
+
+	validateRequiredServices(&output, []string{"gmail", "drive"})
+
+	if !output.CanFulfill || output.NextAction != "need_clarification" {
+		t.Errorf("Expected the LLM's assessment to stand, got can_fulfill=%t next_action=%s", output.CanFulfill, output.NextAction)
+	}
+	if !reflect.DeepEqual(output.RequiredServices, []string{"gmail", "drive"}) {
+		t.Errorf("Expected canonical required services, got %v", output.RequiredServices)
+	}
+	if !reflect.DeepEqual(output.MissingInfo, []string{"recipient_email"}) {
+		t.Errorf("Expected missing_info unchanged, got %v", output.MissingInfo)
+	}
+}
+
+// TestValidateRequiredServicesNoCatalog verifies nothing is rejected when the catalog is unavailable
+func TestValidateRequiredServicesNoCatalog(t *testing.T) {
+	output := IntentAnalystOutput{RequiredServices: []string{"crm_system"}, CanFulfill: true}
+	validateRequiredServices(&output, nil)
+	if !output.CanFulfill {
+		t.Error("Expected the intent to be left unchanged without a catalog")
+	}
+}
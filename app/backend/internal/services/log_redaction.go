@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// redactedLogValue replaces sensitive values in logged inputs and outputs
+const redactedLogValue = "[REDACTED]"
+
+// sensitiveKeyMarkers mask any field whose name contains one of them (case-insensitive)
+var sensitiveKeyMarkers = []string{"token", "password", "secret", "authorization", "api_key", "apikey"}
+
+var (
+	// Extra field names masked in logs regardless of their value, e.g. email bodies
+	logRedactedFields   = map[string]bool{}
+	logRedactedFieldsMu sync.RWMutex
+)
+
+// SetLogRedactedFields sets additional field names (case-insensitive) whose values are masked in logs
+func SetLogRedactedFields(fields []string) {
+	redacted := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		if field = strings.ToLower(strings.TrimSpace(field)); field != "" {
+			redacted[field] = true
+		}
+	}
+	logRedactedFieldsMu.Lock()
+	logRedactedFields = redacted
+	logRedactedFieldsMu.Unlock()
+}
+
+// isSensitiveLogField reports whether the value of a field must not be logged
+func isSensitiveLogField(name string) bool {
+	name = strings.ToLower(name)
+	for _, marker := range sensitiveKeyMarkers {
+		if strings.Contains(name, marker) {
+			return true
+		}
+	}
+	logRedactedFieldsMu.RLock()
+	defer logRedactedFieldsMu.RUnlock()
+	return logRedactedFields[name]
+}
+
+// redactForLog returns a copy of value safe to log: sensitive fields of nested maps are
+// masked and binary content is summarized. The value itself is never modified.
+func redactForLog(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		redacted := make(map[string]interface{}, len(v))
+		for key, field := range v {
+			if isSensitiveLogField(key) {
+				redacted[key] = redactedLogValue
+			} else {
+				redacted[key] = redactForLog(field)
+			}
+		}
+		return redacted
+	case []interface{}:
+		redacted := make([]interface{}, len(v))
+		for i, item := range v {
+			redacted[i] = redactForLog(item)
+		}
+		return redacted
+	case *BinaryOutput:
+		return v.String() // keep multi-MB content out of the logs
+	default:
+		return value
+	}
+}
+
+// sensitiveValuesKey carries the sensitive parameter values of an execution in its context
+type sensitiveValuesKey struct{}
+
+// withSensitiveValues returns a context whose logged data is masked for values too, e.g. an
+// API key that was interpolated into a field with an innocuous name
+func withSensitiveValues(ctx context.Context, values []string) context.Context {
+	if len(values) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, sensitiveValuesKey{}, values)
+}
+
+// redactForLogContext is redactForLog that also masks the context's sensitive values
+func redactForLogContext(ctx context.Context, value interface{}) interface{} {
+	values, _ := ctx.Value(sensitiveValuesKey{}).([]string)
+	return maskSensitiveValues(redactForLog(value), values)
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestRedactForLog verifies sensitive fields are masked at any depth without modifying the input
+func TestRedactForLog(t *testing.T) {
+	defer SetLogRedactedFields(nil)
+	SetLogRedactedFields([]string{" Body "})
+
+	inputs := map[string]interface{}{
+		"to":          "team@example.com",
+		"body":        "Quarterly numbers attached",
+		"oauth_token": "ya29.secret",
+		"headers": map[string]interface{}{
+			"Authorization": "Bearer ya29.secret",
+			"X-Request-ID":  "abc",
+		},
+		"recipients": []interface{}{
+			map[string]interface{}{"email": "a@example.com", "password": "hunter2"},
+		},
+	}
+
+	redacted := redactForLog(inputs)
+	expected := map[string]interface{}{
+		"to":          "team@example.com",
+		"body":        redactedLogValue,
+		"oauth_token": redactedLogValue,
+		"headers": map[string]interface{}{
+			"Authorization": redactedLogValue,
+			"X-Request-ID":  "abc",
+		},
+		"recipients": []interface{}{
+			map[string]interface{}{"email": "a@example.com", "password": redactedLogValue},
+		},
+	}
+	if !reflect.DeepEqual(redacted, expected) {
+		t.Errorf("Unexpected redaction:\n got  %+v\n want %+v", redacted, expected)
+	}
+	if inputs["oauth_token"] != "ya29.secret" || inputs["body"] != "Quarterly numbers attached" {
+		t.Error("Expected the original inputs to be left unchanged")
+	}
+
+	SetLogRedactedFields(nil)
+	if redactForLog(inputs).(map[string]interface{})["body"] != "Quarterly numbers attached" {
+		t.Error("Expected body to be logged once it is no longer configured as redacted")
+	}
+}
@@ -2,20 +2,50 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"os"
+	"path/filepath"
 	"time"
 
+	"cuelang.org/go/cue/cuecontext"
+
 	"sohoaas-backend/internal/types"
 )
 
+// defaultMCPRequestTimeout bounds each MCP HTTP request when no timeout is configured
+const defaultMCPRequestTimeout = 30 * time.Second
+
 // MCPService handles communication with the MCP service
 type MCPService struct {
 	baseURL string
 	client  *http.Client
+
+	// Static catalog file (JSON or CUE) used when the live catalog is unreachable, optional
+	staticCatalogPath string
+
+	// Catalog served without querying MCP (fake client for mock pipelines), optional
+	fixedCatalog *types.MCPServiceCatalog
+
+	// Services workflows may use; nil allows every catalog service
+	serviceAllowlist map[string]bool
+
+	// OAuth scopes workflows may request, canonicalized; nil allows any scope
+	allowedOAuthScopes []string
+
+	// Retries of transient catalog and safe action failures
+	retryPolicy MCPRetryPolicy
+
+	// Live catalog reused until its TTL expires or it is invalidated; disabled by default
+	catalogCache catalogCache
+
+	// MCP endpoints of catalog providers served elsewhere (e.g. a staging proxy), optional
+	providerURLs map[string]string
 }
 
 // NewMCPService creates a new MCP service instance
@@ -23,11 +53,21 @@ func NewMCPService(baseURL string) *MCPService {
 	return &MCPService{
 		baseURL: baseURL,
 		client: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout: defaultMCPRequestTimeout,
 		},
+		retryPolicy: DefaultMCPRetryPolicy,
 	}
 }
 
+// SetRequestTimeout bounds each catalog and action request to MCP; 0 restores the default.
+// Executions are additionally bounded by their workflow runtime budget.
+func (m *MCPService) SetRequestTimeout(timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = defaultMCPRequestTimeout
+	}
+	m.client.Timeout = timeout
+}
+
 // GetUserServices retrieves all available services for a user (PoC: all services available)
 func (m *MCPService) GetUserServices(userID, token string) ([]types.MCPService, error) {
 	log.Printf("[MCPService] Getting user services for user: %s", userID)
@@ -41,7 +81,7 @@ func (m *MCPService) GetUserServices(userID, token string) ([]types.MCPService,
 	
 	// Convert to MCPService slice - all services available for user
 	var userServices []types.MCPService
-	for serviceName, serviceDefinition := range catalog.Providers.Workspace.Services {
+	for serviceName, serviceDefinition := range catalog.AllServices() {
 		// Convert functions from catalog to MCPFunction slice
 		var functions []types.MCPFunction
 		for functionName, functionSchema := range serviceDefinition.Functions {
@@ -66,8 +106,102 @@ func (m *MCPService) GetUserServices(userID, token string) ([]types.MCPService,
 	return userServices, nil
 }
 
-// GetServiceCatalog retrieves the service catalog from MCP service
+// SetStaticCatalogPath enables a static catalog fallback for local development and tests
+func (m *MCPService) SetStaticCatalogPath(path string) {
+	m.staticCatalogPath = path
+}
+
+// GetServiceCatalog retrieves the service catalog from MCP service, falling back to the
+// static catalog file when one is configured and the live catalog is unreachable
 func (m *MCPService) GetServiceCatalog() (*types.MCPServiceCatalog, error) {
+	if m.fixedCatalog != nil {
+		return m.fixedCatalog, nil
+	}
+
+	cached, generation := m.cachedCatalog()
+	if cached != nil {
+		return cached, nil
+	}
+
+	catalog, err := m.fetchLiveCatalog()
+	if err == nil {
+		m.storeCatalog(catalog, generation)
+	}
+	if err == nil || m.staticCatalogPath == "" {
+		return catalog, err
+	}
+
+	staticCatalog, staticErr := LoadStaticCatalog(m.staticCatalogPath)
+	if staticErr != nil {
+		log.Printf("[MCPService] ERROR: Static catalog fallback failed: %v", staticErr)
+		return nil, fmt.Errorf("%w (static catalog fallback failed: %v)", err, staticErr)
+	}
+	log.Printf("[MCPService] WARNING: Live MCP catalog unavailable (%v); using static catalog from %s", err, m.staticCatalogPath)
+	return staticCatalog, nil
+}
+
+// LoadStaticCatalog reads a service catalog from a JSON or CUE (.cue) file
+func LoadStaticCatalog(path string) (*types.MCPServiceCatalog, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read static catalog %s: %w", path, err)
+	}
+
+	var catalog types.MCPServiceCatalog
+	if filepath.Ext(path) == ".cue" {
+		value := cuecontext.New().CompileBytes(content)
+		if err := value.Err(); err != nil {
+			return nil, fmt.Errorf("failed to compile static catalog %s: %w", path, err)
+		}
+		// Round-trip through JSON so every provider is decoded the same way as the live catalog
+		data, err := value.MarshalJSON()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode static catalog %s: %w", path, err)
+		}
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			return nil, fmt.Errorf("failed to decode static catalog %s: %w", path, err)
+		}
+	} else if err := json.Unmarshal(content, &catalog); err != nil {
+		return nil, fmt.Errorf("failed to decode static catalog %s: %w", path, err)
+	}
+	if err := CheckCatalogSchemaVersion(&catalog); err != nil {
+		return nil, fmt.Errorf("static catalog %s: %w", path, err)
+	}
+
+	if len(catalog.AllServices()) == 0 {
+		return nil, fmt.Errorf("static catalog %s defines no services", path)
+	}
+	return &catalog, nil
+}
+
+// ErrCatalogUnavailable is returned when the live MCP service catalog can't be fetched
+var ErrCatalogUnavailable = errors.New("MCP service catalog unavailable")
+
+// catalogStatusError is a non-200 response to the catalog request
+type catalogStatusError struct {
+	StatusCode int
+}
+
+func (e *catalogStatusError) Error() string {
+	return fmt.Sprintf("MCP service catalog returned status %d", e.StatusCode)
+}
+
+// fetchLiveCatalog queries the MCP backend's /api/services endpoint, retrying transient failures
+func (m *MCPService) fetchLiveCatalog() (*types.MCPServiceCatalog, error) {
+	var catalog *types.MCPServiceCatalog
+	err := m.withRetry(context.Background(), "service catalog request", func() error {
+		var err error
+		catalog, err = m.fetchLiveCatalogOnce()
+		return err
+	}, retryableCatalogError)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCatalogUnavailable, err)
+	}
+	return catalog, nil
+}
+
+// fetchLiveCatalogOnce makes a single request to the MCP backend's /api/services endpoint
+func (m *MCPService) fetchLiveCatalogOnce() (*types.MCPServiceCatalog, error) {
 	url := m.baseURL + "/api/services"
 	log.Printf("[MCPService] === CALLING MCP SERVICE CATALOG ===")
 	log.Printf("[MCPService] MCP URL: %s", url)
@@ -75,6 +209,9 @@ func (m *MCPService) GetServiceCatalog() (*types.MCPServiceCatalog, error) {
 	resp, err := m.client.Get(url)
 	if err != nil {
 		log.Printf("[MCPService] ERROR: Failed to call MCP service: %v", err)
+		if isClientTimeout(context.Background(), err) {
+			return nil, &TimeoutError{Operation: "service catalog", Timeout: m.client.Timeout, Err: err}
+		}
 		return nil, fmt.Errorf("failed to query MCP service catalog: %w", err)
 	}
 	defer resp.Body.Close()
@@ -82,7 +219,7 @@ func (m *MCPService) GetServiceCatalog() (*types.MCPServiceCatalog, error) {
 	log.Printf("[MCPService] MCP Response Status: %d", resp.StatusCode)
 	if resp.StatusCode != http.StatusOK {
 		log.Printf("[MCPService] ERROR: MCP service returned non-200 status: %d", resp.StatusCode)
-		return nil, fmt.Errorf("MCP service catalog returned status %d", resp.StatusCode)
+		return nil, &catalogStatusError{StatusCode: resp.StatusCode}
 	}
 	
 	var catalog types.MCPServiceCatalog
@@ -90,8 +227,12 @@ func (m *MCPService) GetServiceCatalog() (*types.MCPServiceCatalog, error) {
 		log.Printf("[MCPService] ERROR: Failed to decode MCP response: %v", err)
 		return nil, fmt.Errorf("failed to decode MCP service catalog: %w", err)
 	}
+	if err := CheckCatalogSchemaVersion(&catalog); err != nil {
+		log.Printf("[MCPService] ERROR: %v", err)
+		return nil, err
+	}
 	
-	log.Printf("[MCPService] SUCCESS: Retrieved MCP catalog with %d services", len(catalog.Providers.Workspace.Services))
+	log.Printf("[MCPService] SUCCESS: Retrieved MCP catalog with %d services from %d providers", len(catalog.AllServices()), len(catalog.Providers.Names()))
 	return &catalog, nil
 }
 
@@ -112,7 +253,33 @@ type ExecuteActionResponse struct {
 
 // ExecuteAction executes an action via the MCP service
 func (m *MCPService) ExecuteAction(service, action string, parameters map[string]interface{}, oauthToken string) (*ExecuteActionResponse, error) {
-	url := m.baseURL + "/api/mcp/tools/call"
+	return m.ExecuteActionContext(context.Background(), service, action, parameters, oauthToken)
+}
+
+// ExecuteActionContext executes an action via the MCP service, canceling the request when ctx is done.
+// Transient failures are retried when that can't repeat a write (see retryableActionError).
+func (m *MCPService) ExecuteActionContext(ctx context.Context, service, action string, parameters map[string]interface{}, oauthToken string) (*ExecuteActionResponse, error) {
+	return m.ExecuteProviderActionContext(ctx, "", service, action, parameters, oauthToken)
+}
+
+// ExecuteProviderActionContext executes an action on the MCP endpoint of the given catalog
+// provider (see SetProviderURLs); an empty or unrouted provider uses the base URL
+func (m *MCPService) ExecuteProviderActionContext(ctx context.Context, provider, service, action string, parameters map[string]interface{}, oauthToken string) (*ExecuteActionResponse, error) {
+	baseURL := m.providerBaseURL(provider)
+	var response *ExecuteActionResponse
+	err := m.withRetry(ctx, fmt.Sprintf("MCP action %s.%s", service, action), func() error {
+		var err error
+		response, err = m.executeActionOnce(ctx, baseURL, service, action, parameters, oauthToken)
+		return err
+	}, func(err error) bool {
+		return retryableActionError(action, err)
+	})
+	return response, err
+}
+
+// executeActionOnce makes a single tools/call request to the MCP service at baseURL
+func (m *MCPService) executeActionOnce(ctx context.Context, baseURL, service, action string, parameters map[string]interface{}, oauthToken string) (*ExecuteActionResponse, error) {
+	url := baseURL + "/api/mcp/tools/call"
 	
 	// Convert to MCP tools/call expected format
 	toolName := fmt.Sprintf("%s.%s", service, action)
@@ -137,7 +304,7 @@ func (m *MCPService) ExecuteAction(service, action string, parameters map[string
 	log.Printf("[MCPService] === EXECUTING MCP ACTION ===")
 	log.Printf("[MCPService] Service: %s, Action: %s", service, action)
 	log.Printf("[MCPService] URL: %s", url)
-	log.Printf("[MCPService] Parameters: %+v", parameters)
+	log.Printf("[MCPService] Parameters: %+v", redactForLogContext(ctx, parameters))
 	log.Printf("[MCPService] OAuth token length: %d characters", len(oauthToken))
 	
 	// Marshal request to JSON
@@ -148,18 +315,11 @@ func (m *MCPService) ExecuteAction(service, action string, parameters map[string
 	}
 	
 	log.Printf("[MCPService] Request body length: %d bytes", len(requestBody))
-	// Redact token value in logged JSON
-	redactedArgs := make(map[string]interface{}, len(arguments))
-	for k, v := range arguments {
-		if k == "token" {
-			redactedArgs[k] = "[REDACTED]"
-		} else {
-			redactedArgs[k] = v
-		}
-	}
+	// Never log the token or other sensitive argument values
+	redactedArgs := redactForLogContext(ctx, arguments)
 	redactedReq := struct {
 		Name      string                 `json:"name"`
-		Arguments map[string]interface{} `json:"arguments"`
+		Arguments interface{} `json:"arguments"`
 	}{
 		Name:      toolName,
 		Arguments: redactedArgs,
@@ -171,7 +331,7 @@ func (m *MCPService) ExecuteAction(service, action string, parameters map[string
 	}
 	
 	// Create HTTP request
-	req, err := http.NewRequest("POST", url, bytes.NewReader(requestBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(requestBody))
 	if err != nil {
 		log.Printf("[MCPService] ERROR: Failed to create HTTP request: %v", err)
 		return nil, fmt.Errorf("failed to create MCP execute request: %w", err)
@@ -184,7 +344,10 @@ func (m *MCPService) ExecuteAction(service, action string, parameters map[string
 	resp, err := m.client.Do(req)
 	if err != nil {
 		log.Printf("[MCPService] ERROR: Failed to execute MCP action: %v", err)
-		return nil, fmt.Errorf("failed to execute MCP action: %w", err)
+		if isClientTimeout(ctx, err) {
+			return nil, &TimeoutError{Operation: toolName, Timeout: m.client.Timeout, Err: err}
+		}
+		return nil, newMCPActionError(service, action, 0, "", err)
 	}
 	defer resp.Body.Close()
 	
@@ -195,38 +358,33 @@ func (m *MCPService) ExecuteAction(service, action string, parameters map[string
 	responseBody, err := io.ReadAll(resp.Body)
 	if err != nil {
 		log.Printf("[MCPService] ERROR: Failed to read response body: %v", err)
-		return nil, fmt.Errorf("failed to read MCP execute response: %w", err)
+		if isClientTimeout(ctx, err) {
+			return nil, &TimeoutError{Operation: toolName, Timeout: m.client.Timeout, Err: err}
+		}
+		return nil, newMCPActionError(service, action, resp.StatusCode, "", fmt.Errorf("failed to read MCP execute response: %w", err))
 	}
 	
+	// The raw body carries step outputs unredacted; only parsed, redacted data is logged below
 	log.Printf("[MCPService] Response body length: %d bytes", len(responseBody))
-	// Truncate very long responses in logs to keep them readable
-	const maxLogBody = 2000
-	if len(responseBody) > maxLogBody {
-		log.Printf("[MCPService] Response body (truncated): %s... [truncated %d bytes]", string(responseBody[:maxLogBody]), len(responseBody)-maxLogBody)
-	} else {
-		log.Printf("[MCPService] Response body: %s", string(responseBody))
-	}
 	
 	// Parse response from /api/mcp/tools/call
 	var toolResponse struct {
 		Result struct {
-			Content []struct {
-				Type string `json:"type"`
-				Text string `json:"text"`
-			} `json:"content"`
-			IsError bool `json:"isError"`
+			Content           []mcpToolContent       `json:"content"`
+			StructuredContent map[string]interface{} `json:"structuredContent"`
+			IsError           bool                   `json:"isError"`
 		} `json:"result"`
 	}
 	
+	if resp.StatusCode != http.StatusOK {
+		log.Printf("[MCPService] ERROR: MCP tools/call failed with status %d", resp.StatusCode)
+		return nil, newMCPActionError(service, action, resp.StatusCode, mcpErrorMessage(resp.StatusCode, responseBody), nil)
+	}
+	
 	if err := json.Unmarshal(responseBody, &toolResponse); err != nil {
 		log.Printf("[MCPService] ERROR: Failed to decode MCP tools/call response: %v", err)
 		log.Printf("[MCPService] Raw response: %s", string(responseBody))
-		return nil, fmt.Errorf("failed to decode MCP tools/call response: %w", err)
-	}
-	
-	if resp.StatusCode != http.StatusOK {
-		log.Printf("[MCPService] ERROR: MCP tools/call failed with status %d", resp.StatusCode)
-		return nil, fmt.Errorf("MCP tools/call failed with status %d", resp.StatusCode)
+		return nil, newMCPActionError(service, action, resp.StatusCode, "", fmt.Errorf("failed to decode MCP tools/call response: %w", err))
 	}
 	
 	// Convert tools/call response to ExecuteActionResponse
@@ -241,29 +399,43 @@ func (m *MCPService) ExecuteAction(service, action string, parameters map[string
 		// If there's an error, extract error message
 		if toolResponse.Result.IsError {
 			executeResponse.Error = toolResponse.Result.Content[0].Text
+		} else if toolResponse.Result.StructuredContent != nil {
+			executeResponse.Data = toolResponse.Result.StructuredContent
+			log.Printf("[MCPService] Using structured content: %+v", redactForLogContext(ctx, executeResponse.Data))
+		} else if resultData, found := toolTextData(toolResponse.Result.Content); found {
+			// Servers without structured content return the data as JSON text
+			executeResponse.Data = resultData
+			log.Printf("[MCPService] Successfully parsed JSON data: %+v", redactForLogContext(ctx, resultData))
 		} else {
-			// For successful responses, try to parse the result as JSON data
+			// If not JSON, store as plain text
 			resultText := toolResponse.Result.Content[0].Text
-			log.Printf("[MCPService] Parsing result text: %s", resultText)
-			var resultData map[string]interface{}
-			if err := json.Unmarshal([]byte(resultText), &resultData); err == nil {
-				executeResponse.Data = resultData
-				log.Printf("[MCPService] Successfully parsed JSON data: %+v", resultData)
-			} else {
-				log.Printf("[MCPService] Failed to parse as JSON, storing as plain text: %v", err)
-				// If not JSON, store as plain text
-				executeResponse.Data = map[string]interface{}{
-					"result": resultText,
-				}
+			log.Printf("[MCPService] Result is not JSON, storing as plain text (%d characters)", len(resultText))
+			executeResponse.Data = map[string]interface{}{
+				"result": resultText,
 			}
 		}
 	}
 	
 	if !executeResponse.Success {
 		log.Printf("[MCPService] ERROR: MCP tool execution failed: %s", executeResponse.Error)
-		return executeResponse, fmt.Errorf("MCP tool execution failed: %s", executeResponse.Error)
+		return executeResponse, newMCPActionError(service, action, resp.StatusCode, executeResponse.Error, nil)
 	}
 	
 	log.Printf("[MCPService] SUCCESS: MCP tool executed successfully")
 	return executeResponse, nil
 }
+
+// mcpErrorMessage extracts the error text of a non-200 tools/call response ({"error", "details"})
+func mcpErrorMessage(statusCode int, body []byte) string {
+	var errorBody struct {
+		Error   string `json:"error"`
+		Details string `json:"details"`
+	}
+	if err := json.Unmarshal(body, &errorBody); err != nil || errorBody.Error == "" {
+		return fmt.Sprintf("tools/call returned status %d", statusCode)
+	}
+	if errorBody.Details != "" {
+		return errorBody.Error + ": " + errorBody.Details
+	}
+	return errorBody.Error
+}
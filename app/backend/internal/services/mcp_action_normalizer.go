@@ -0,0 +1,64 @@
+package services
+
+import (
+	"sort"
+
+	"sohoaas-backend/internal/types"
+	"sohoaas-backend/internal/workflowparser"
+)
+
+// canonicalizeStepAction splits dotted actions ("gmail.send_message") into service and action;
+// see workflowparser.CanonicalizeStepAction
+func canonicalizeStepAction(service, action string) (string, string, error) {
+	return workflowparser.CanonicalizeStepAction(service, action)
+}
+
+// NormalizeAction canonicalizes a step's service and action to the catalog's "service.function"
+// form. The action may be dotted ("calendar.create_event") or bare with the service given
+// separately, and either part may be an alias ("email", "send_email"). With a catalog, names
+// the catalog defines are kept as-is and a bare function defined by exactly one service gets
+// that service; without one only the alias tables apply. The service is empty when it cannot
+// be determined. A dotted action whose service conflicts with the step's service is an error.
+func (p *MCPCatalogParser) NormalizeAction(catalog *types.MCPServiceCatalog, service, action string) (string, string, error) {
+	service, function, err := workflowparser.CanonicalizeAction(service, action)
+	if err != nil {
+		return "", "", err
+	}
+
+	if catalog != nil && service != "" && catalogHasFunction(catalog, service, function) {
+		return service, function, nil
+	}
+
+	service, function = workflowparser.ApplyActionAlias(service, function)
+
+	if service == "" && catalog != nil {
+		if services := servicesWithFunction(catalog, function); len(services) == 1 {
+			service = services[0]
+		}
+	}
+	return service, function, nil
+}
+
+// catalogHasFunction reports whether any provider's service defines the function
+func catalogHasFunction(catalog *types.MCPServiceCatalog, service, function string) bool {
+	for _, provider := range catalog.Providers.All() {
+		if serviceDefinition, exists := provider.Services[service]; exists {
+			if _, exists := serviceDefinition.Functions[function]; exists {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// servicesWithFunction returns the sorted names of the catalog services that define a function
+func servicesWithFunction(catalog *types.MCPServiceCatalog, function string) []string {
+	var services []string
+	for serviceName := range catalog.AllServices() {
+		if catalogHasFunction(catalog, serviceName, function) {
+			services = append(services, serviceName)
+		}
+	}
+	sort.Strings(services)
+	return services
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+
+	"sohoaas-backend/internal/types"
+)
+
+const normalizerCatalogJSON = `{"providers": {
+	"workspace": {"services": {
+		"gmail": {"functions": {"send_message": {"name": "send_message"}, "list_messages": {"name": "list_messages"}}},
+		"calendar": {"functions": {"create_event": {"name": "create_event"}}},
+		"drive": {"functions": {"upload_file": {"name": "upload_file"}, "create": {"name": "create"}}},
+		"docs": {"functions": {"create": {"name": "create"}}}
+	}},
+	"storage": {"services": {
+		"files": {"functions": {"upload": {"name": "upload"}}}
+	}}
+}}`
+
+// TestNormalizeAction verifies action variations map to the catalog's service.function form
+func TestNormalizeAction(t *testing.T) {
+	var catalog types.MCPServiceCatalog
+	if err := json.Unmarshal([]byte(normalizerCatalogJSON), &catalog); err != nil {
+		t.Fatalf("Failed to decode catalog: %v", err)
+	}
+	parser := NewMCPCatalogParser()
+
+	tests := []struct {
+		name             string
+		catalog          *types.MCPServiceCatalog
+		service          string
+		action           string
+		expectedService  string
+		expectedFunction string
+	}{
+		{"dotted action", nil, "", "gmail.send_message", "gmail", "send_message"},
+		{"bare alias", nil, "", "send_email", "gmail", "send_message"},
+		{"service alias", nil, "email", "send_email", "gmail", "send_message"},
+		{"dotted service alias", nil, "", "google_calendar.add_event", "calendar", "create_event"},
+		{"alias for another service is ignored", nil, "drive", "send_email", "drive", "send_email"},
+		{"bare function without catalog", nil, "", "create_event", "", "create_event"},
+		{"bare function unique in catalog", &catalog, "", "create_event", "calendar", "create_event"},
+		{"alias resolved with catalog", &catalog, "", "get_messages", "gmail", "list_messages"},
+		{"catalog function shadows alias", &catalog, "files", "upload", "files", "upload"},
+		{"bare alias not shadowed", &catalog, "", "upload", "drive", "upload_file"},
+		{"bare function in several services", &catalog, "", "create", "", "create"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, function, err := parser.NormalizeAction(tt.catalog, tt.service, tt.action)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if service != tt.expectedService || function != tt.expectedFunction {
+				t.Errorf("Expected %s.%s, got %s.%s", tt.expectedService, tt.expectedFunction, service, function)
+			}
+		})
+	}
+
+	if _, _, err := parser.NormalizeAction(nil, "gmail", "docs.create_document"); err == nil {
+		t.Error("Expected conflicting service and dotted action to be rejected")
+	}
+	if _, _, err := parser.NormalizeAction(nil, "email", "gmail.send_message"); err != nil {
+		t.Errorf("Expected service alias to match the dotted service, got %v", err)
+	}
+}
+
+// TestWorkflowActionsNormalized verifies parsing and validation map LLM action variations to catalog functions
+func TestWorkflowActionsNormalized(t *testing.T) {
+	var catalog types.MCPServiceCatalog
+	if err := json.Unmarshal([]byte(normalizerCatalogJSON), &catalog); err != nil {
+		t.Fatalf("Failed to decode catalog: %v", err)
+	}
+	ee := NewExecutionEngine(nil)
+
+	workflow, err := ee.ParseCUEWorkflow(`workflow: {
+	name:        "notify"
+	description: "Aliased actions"
+	steps: [{
+		id:     "send"
+		name:   "Send"
+		service: "email"
+		action: "send_email"
+		inputs: {to: "team@example.com"}
+	}, {
+		id:     "schedule"
+		name:   "Schedule"
+		action: "create_event"
+		inputs: {summary: "Sync"}
+	}]
+}`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if step := workflow.Steps[0]; step.Service != "gmail" || step.Action != "send_message" {
+		t.Errorf("Expected gmail.send_message, got %s.%s", step.Service, step.Action)
+	}
+
+	if err := ee.validateWorkflowServicesInternal(&catalog, workflow); err != nil {
+		t.Fatalf("Expected normalized workflow to validate, got %v", err)
+	}
+	if step := workflow.Steps[1]; step.Service != "calendar" || step.Action != "create_event" {
+		t.Errorf("Expected validation to resolve calendar.create_event, got %s.%s", step.Service, step.Action)
+	}
+
+	steps := []types.WorkflowStepValidation{{ID: "send", Action: "send_email"}, {ID: "list", Action: "email.get_messages"}}
+	if valid, errors := ee.mcpParser.ValidateMCPFunctionsTyped(&catalog, steps); !valid {
+		t.Errorf("Expected aliased actions to pass function validation, got %v", errors)
+	}
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"sohoaas-backend/internal/types"
+)
+
+// catalogCache holds the last live catalog fetched from MCP and when it was fetched.
+// generation changes on every invalidation, so a fetch that started before one can't
+// store the catalog it got.
+type catalogCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	catalog    *types.MCPServiceCatalog
+	fetchedAt  time.Time
+	generation uint64
+}
+
+// SetCatalogCacheTTL reuses a fetched live catalog for ttl instead of querying MCP on every
+// validation; 0 disables caching. Static fallback catalogs are never cached.
+func (m *MCPService) SetCatalogCacheTTL(ttl time.Duration) {
+	m.catalogCache.mu.Lock()
+	defer m.catalogCache.mu.Unlock()
+	m.catalogCache.ttl = ttl
+	m.catalogCache.catalog = nil
+	m.catalogCache.generation++
+}
+
+// InvalidateCatalog drops the cached catalog so the next request fetches it from MCP, e.g.
+// right after a provider deploy changed the available functions
+func (m *MCPService) InvalidateCatalog() {
+	m.catalogCache.mu.Lock()
+	defer m.catalogCache.mu.Unlock()
+	if m.catalogCache.catalog != nil {
+		log.Printf("[MCPService] Service catalog cache invalidated")
+	}
+	m.catalogCache.catalog = nil
+	m.catalogCache.generation++
+}
+
+// cachedCatalog returns the cached catalog while it is within the TTL, and the cache
+// generation to pass to storeCatalog when it has to be fetched
+func (m *MCPService) cachedCatalog() (*types.MCPServiceCatalog, uint64) {
+	m.catalogCache.mu.Lock()
+	defer m.catalogCache.mu.Unlock()
+	if m.catalogCache.catalog == nil || time.Since(m.catalogCache.fetchedAt) > m.catalogCache.ttl {
+		return nil, m.catalogCache.generation
+	}
+	return m.catalogCache.catalog, m.catalogCache.generation
+}
+
+// storeCatalog caches a freshly fetched live catalog when caching is enabled, unless the
+// cache was invalidated since generation was read
+func (m *MCPService) storeCatalog(catalog *types.MCPServiceCatalog, generation uint64) {
+	m.catalogCache.mu.Lock()
+	defer m.catalogCache.mu.Unlock()
+	if m.catalogCache.ttl <= 0 {
+		return
+	}
+	if generation != m.catalogCache.generation {
+		log.Printf("[MCPService] Not caching a service catalog fetched before the cache was invalidated")
+		return
+	}
+	m.catalogCache.catalog = catalog
+	m.catalogCache.fetchedAt = time.Now()
+}
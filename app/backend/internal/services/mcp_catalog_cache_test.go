@@ -0,0 +1,84 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestCatalogCacheInvalidate verifies a cached catalog is reused within its TTL and refetched after invalidation
+func TestCatalogCacheInvalidate(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"schema_version": "1.2", "providers": {"workspace": {"services": {}}}}`))
+	}))
+	defer server.Close()
+
+	service := NewMCPService(server.URL)
+	service.SetCatalogCacheTTL(time.Minute)
+	for i := 0; i < 3; i++ {
+		if _, err := service.GetServiceCatalog(); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Errorf("Expected one catalog request within the TTL, got %d", got)
+	}
+
+	service.InvalidateCatalog()
+	if _, err := service.GetServiceCatalog(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := atomic.LoadInt32(&requests); got != 2 {
+		t.Errorf("Expected the catalog to be refetched after invalidation, got %d requests", got)
+	}
+}
+
+// TestCatalogCacheInvalidateDuringFetch verifies a catalog fetched before an invalidation is
+// returned to its caller but not cached
+func TestCatalogCacheInvalidateDuringFetch(t *testing.T) {
+	var requests int32
+	firstRequest := make(chan struct{})
+	releaseFirst := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			close(firstRequest)
+			<-releaseFirst
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"schema_version": "1.2", "providers": {"workspace": {"services": {}}}}`))
+	}))
+	defer server.Close()
+
+	service := NewMCPService(server.URL)
+	service.SetCatalogCacheTTL(time.Minute)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := service.GetServiceCatalog()
+		done <- err
+	}()
+	<-firstRequest
+	service.InvalidateCatalog()
+	close(releaseFirst)
+	if err := <-done; err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, err := service.GetServiceCatalog(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := atomic.LoadInt32(&requests); got != 2 {
+		t.Errorf("Expected the stale catalog not to be cached, got %d requests", got)
+	}
+	if _, err := service.GetServiceCatalog(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := atomic.LoadInt32(&requests); got != 2 {
+		t.Errorf("Expected the catalog fetched after the invalidation to be cached, got %d requests", got)
+	}
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"sohoaas-backend/internal/types"
+)
+
+const multiProviderCatalogJSON = `{"providers": {
+	"workspace": {"services": {"gmail": {"functions": {"send_message": {"name": "send_message"}}}}},
+	"slack": {"services": {"slack": {"functions": {"post_message": {"name": "post_message"}}}}}
+}}`
+
+// TestGetServiceCatalogMultipleProviders verifies non-workspace providers survive catalog decoding and parsing
+func TestGetServiceCatalogMultipleProviders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(multiProviderCatalogJSON))
+	}))
+	defer server.Close()
+
+	catalog, err := NewMCPService(server.URL).GetServiceCatalog()
+	if err != nil {
+		t.Fatalf("GetServiceCatalog failed: %v", err)
+	}
+
+	if provider, _, found := catalog.LookupService("slack"); !found || provider != "slack" {
+		t.Errorf("Expected slack service under the slack provider, got %q (found: %t)", provider, found)
+	}
+
+	parser := NewMCPCatalogParser()
+	services, err := parser.ParseServicesFromCatalog(catalog)
+	if err != nil {
+		t.Fatalf("ParseServicesFromCatalog failed: %v", err)
+	}
+	if _, exists := services["gmail"]; !exists {
+		t.Error("Expected gmail in parsed services")
+	}
+	if _, exists := services["slack"]; !exists {
+		t.Error("Expected slack in parsed services")
+	}
+
+	// The legacy map format is merged across providers as well
+	var catalogMap map[string]interface{}
+	if err := json.Unmarshal([]byte(multiProviderCatalogJSON), &catalogMap); err != nil {
+		t.Fatalf("Failed to decode catalog map: %v", err)
+	}
+	services, err = parser.ParseServicesFromCatalog(catalogMap)
+	if err != nil {
+		t.Fatalf("ParseServicesFromCatalog (map) failed: %v", err)
+	}
+	if len(services) != 2 {
+		t.Errorf("Expected 2 services from the map catalog, got %d", len(services))
+	}
+
+	// Workflows may use actions of any provider
+	ee := NewExecutionEngine(nil)
+	workflow := &ParsedWorkflow{Steps: []WorkflowStep{
+		{ID: "notify", Service: "slack", Action: "post_message"},
+		{ID: "email", Service: "gmail", Action: "send_message"},
+	}}
+	if err := ee.validateWorkflowServicesInternal(catalog, workflow); err != nil {
+		t.Errorf("Expected steps of both providers to validate, got %v", err)
+	}
+}
+
+// twoProviderCatalogJSON defines "files" in both providers with different output fields
+const twoProviderCatalogJSON = `{"providers": {
+	"workspace": {"services": {
+		"files": {"functions": {"upload": {"name": "upload", "output_schema": {"type": "object", "properties": {"drive_id": {"type": "string"}}}}}},
+		"gmail": {"functions": {"send_message": {"name": "send_message"}}}
+	}},
+	"storage": {"services": {
+		"files": {"functions": {"upload": {"name": "upload", "output_schema": {"type": "object", "properties": {"object_key": {"type": "string"}}}}}},
+		"buckets": {"functions": {"create": {"name": "create", "output_schema": {"type": "object", "properties": {"bucket_id": {"type": "string"}}}}}}
+	}}
+}}`
+
+// TestOutputValidationAcrossProviders verifies services resolve per provider and ambiguous names are rejected
+func TestOutputValidationAcrossProviders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(twoProviderCatalogJSON))
+	}))
+	defer server.Close()
+
+	ee := NewExecutionEngine(NewMCPService(server.URL))
+	catalog, err := ee.mcpService.GetServiceCatalog()
+	if err != nil {
+		t.Fatalf("GetServiceCatalog failed: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		provider    string
+		service     string
+		action      string
+		outputField string
+		wantErr     string
+	}{
+		{"unique service without provider", "", "buckets", "create", "bucket_id", ""},
+		{"ambiguous service without provider", "", "files", "upload", "object_key", "ambiguous"},
+		{"ambiguous service with provider", "storage", "files", "upload", "object_key", ""},
+		{"field of the other provider", "workspace", "files", "upload", "object_key", "output field 'object_key' not found"},
+		{"unknown provider", "slack", "files", "upload", "object_key", "provider 'slack' not found"},
+		{"service missing from provider", "workspace", "buckets", "create", "bucket_id", "not found in provider"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ee.validateOutputFieldExists(tt.provider, tt.service, tt.action, tt.outputField, catalog)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	if err := ee.validateResponseSchema("storage", "files", "upload", map[string]interface{}{"object_key": "k1"}); err != nil {
+		t.Errorf("Expected storage response to validate, got %v", err)
+	}
+	if err := ee.validateResponseSchema("", "files", "upload", map[string]interface{}{"object_key": "k1"}); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Errorf("Expected ambiguous service error, got %v", err)
+	}
+
+	workflow := &ParsedWorkflow{Steps: []WorkflowStep{{ID: "upload", Service: "files", Action: "upload"}}}
+	if err := ee.validateWorkflowServicesInternal(catalog, workflow); err == nil {
+		t.Error("Expected ambiguous step service to be rejected")
+	}
+	workflow.Steps[0].Provider = "storage"
+	if err := ee.validateWorkflowServicesInternal(catalog, workflow); err != nil {
+		t.Errorf("Expected step with a provider to validate, got %v", err)
+	}
+}
+
+// TestFunctionsWithoutOutputSchema verifies functions lacking output schemas are listed per provider
+func TestFunctionsWithoutOutputSchema(t *testing.T) {
+	catalog := &types.MCPServiceCatalog{
+		Providers: types.MCPProviders{
+			Workspace: types.MCPWorkspaceProvider{
+				Services: map[string]types.MCPServiceDefinition{
+					"gmail": {Functions: map[string]types.MCPFunctionSchema{
+						"send_message": {Name: "send_message", OutputSchema: &types.MCPResponseSchema{Type: "object"}},
+						"search":       {Name: "search"},
+					}},
+				},
+			},
+			Others: map[string]types.MCPProvider{
+				"storage": {Services: map[string]types.MCPServiceDefinition{
+					"files": {Functions: map[string]types.MCPFunctionSchema{"upload": {Name: "upload"}}},
+				}},
+			},
+		},
+	}
+
+	missing := catalog.FunctionsWithoutOutputSchema()
+	expected := []string{"workspace/gmail.search", "storage/files.upload"}
+	if !reflect.DeepEqual(missing, expected) {
+		t.Errorf("Expected %v, got %v", expected, missing)
+	}
+}
+
+// TestResolveUnavailableService verifies services the MCP backend skipped are reported with the reason
+func TestResolveUnavailableService(t *testing.T) {
+	var catalog types.MCPServiceCatalog
+	if err := json.Unmarshal([]byte(`{"schema_version": "1.2", "providers": {"workspace": {
+		"services": {"docs": {"functions": {"create_document": {"name": "create_document"}}}},
+		"unavailable_services": {"gmail": "none of the scopes https://www.googleapis.com/auth/gmail.send is configured"}
+	}}}`), &catalog); err != nil {
+		t.Fatalf("Failed to decode catalog: %v", err)
+	}
+
+	for _, provider := range []string{"", "workspace"} {
+		_, _, err := catalog.ResolveService(provider, "gmail")
+		if err == nil || !strings.Contains(err.Error(), "is unavailable: none of the scopes") {
+			t.Errorf("Provider %q: expected the unavailability reason, got %v", provider, err)
+		}
+	}
+	if _, _, err := catalog.ResolveService("", "sheets"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Expected an unknown service to be not found, got %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sohoaas-backend/internal/types"
+)
+
+// TestCheckCatalogSchemaVersion verifies only catalogs of the supported major version are accepted
+func TestCheckCatalogSchemaVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		valid   bool
+	}{
+		{"", true},
+		{types.CatalogSchemaVersion, true},
+		{"1.7", true},
+		{"2.0", false},
+		{"0.9", false},
+		{"1", false},
+		{"v1.0", false},
+	}
+	for _, tt := range tests {
+		err := CheckCatalogSchemaVersion(&types.MCPServiceCatalog{SchemaVersion: tt.version})
+		if tt.valid && err != nil {
+			t.Errorf("Version %q: unexpected error %v", tt.version, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("Version %q: expected an error", tt.version)
+		}
+	}
+}
+
+// TestParseMCPCatalogSchemaVersion verifies the parser keeps the version and rejects unsupported ones
+func TestParseMCPCatalogSchemaVersion(t *testing.T) {
+	parser := NewMCPCatalogParser()
+	catalogMap := map[string]interface{}{
+		"schema_version": "1.0",
+		"providers": map[string]interface{}{
+			"workspace": map[string]interface{}{"services": map[string]interface{}{}},
+		},
+	}
+
+	catalog, err := parser.ParseMCPCatalog(catalogMap)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if catalog.SchemaVersion != "1.0" {
+		t.Errorf("Expected schema_version 1.0 to survive parsing, got %q", catalog.SchemaVersion)
+	}
+
+	catalogMap["schema_version"] = "2.0"
+	if _, err := parser.ParseMCPCatalog(catalogMap); err == nil {
+		t.Error("Expected schema_version 2.0 to be rejected")
+	}
+}
+
+// TestLiveCatalogSchemaVersionMismatch verifies a live catalog of an unsupported major version is rejected
+func TestLiveCatalogSchemaVersionMismatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"schema_version": "2.0", "providers": {"workspace": {"services": {}}}}`))
+	}))
+	defer server.Close()
+
+	if _, err := NewMCPService(server.URL).GetServiceCatalog(); err == nil {
+		t.Error("Expected a catalog with schema_version 2.0 to be rejected")
+	}
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// mcpActionFailure is the detail shared by the typed MCP action errors
+type mcpActionFailure struct {
+	Service    string
+	Action     string
+	StatusCode int    // HTTP status of the MCP call or of the Google API error it reports; 0 when unknown
+	Message    string // error text reported by MCP
+	Err        error  // underlying transport or decoding error, if any
+}
+
+func (f *mcpActionFailure) Error() string {
+	message := f.Message
+	if message == "" && f.Err != nil {
+		message = f.Err.Error()
+	}
+	return fmt.Sprintf("MCP action %s.%s failed: %s", f.Service, f.Action, message)
+}
+
+func (f *mcpActionFailure) Unwrap() error {
+	return f.Err
+}
+
+// AuthError means the OAuth token was missing, expired or lacked the required scopes;
+// a refreshed token or a reconnected account may succeed
+type AuthError struct{ mcpActionFailure }
+
+// RateLimitError means MCP or the Google API throttled the call; it may succeed after RetryAfter
+type RateLimitError struct {
+	mcpActionFailure
+	RetryAfter time.Duration // zero when no hint was given
+}
+
+// ValidationError means the action rejected its inputs; retrying with the same inputs fails again
+type ValidationError struct{ mcpActionFailure }
+
+// UpstreamError covers every other MCP or Google API failure, including unreachable servers
+type UpstreamError struct{ mcpActionFailure }
+
+// TimeoutError means MCP didn't answer within the request timeout (MCP_TIMEOUT_SECONDS);
+// an action may still have run on the MCP side
+type TimeoutError struct {
+	Operation string        // "<service>.<action>" or "service catalog"
+	Timeout   time.Duration // the request timeout that elapsed
+	Err       error
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("MCP %s timed out after %s", e.Operation, e.Timeout)
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// isClientTimeout reports whether err is the HTTP client's request timeout rather than ctx
+// being canceled or running out, e.g. by the workflow's runtime budget
+func isClientTimeout(ctx context.Context, err error) bool {
+	var netErr net.Error
+	return ctx.Err() == nil && errors.As(err, &netErr) && netErr.Timeout()
+}
+
+var (
+	// googleAPIStatusPattern captures the status of a "googleapi: Error 403: ..." message
+	googleAPIStatusPattern = regexp.MustCompile(`googleapi: Error (\d{3})`)
+	// retryAfterPattern captures the hint of a "retry after 30s" message
+	retryAfterPattern = regexp.MustCompile(`retry after ([0-9.]+[a-zµ]+)`)
+)
+
+// Error text fragments, checked in order, that identify a failure kind when no status is known
+var (
+	rateLimitMarkers  = []string{"RATE_LIMITED", "rate limit", "ratelimitexceeded", "quota exceeded"}
+	authMarkers       = []string{"AUTHENTICATION_FAILED", "invalid_grant", "invalid authentication credentials", "unauthorized", "insufficient authentication scopes", "token has been expired"}
+	validationMarkers = []string{"INVALID_PAYLOAD", "INVALID_FUNCTION", "missing required field", "unsupported function", "invalid payload"}
+)
+
+// newMCPActionError classifies a failed MCP call. statusCode is the HTTP status of the MCP
+// response (0 when no response was received); message is the error text MCP reported.
+func newMCPActionError(service, action string, statusCode int, message string, err error) error {
+	if googleStatus := googleAPIStatusPattern.FindStringSubmatch(message); googleStatus != nil && (statusCode == 0 || statusCode == http.StatusOK) {
+		statusCode, _ = strconv.Atoi(googleStatus[1])
+	}
+	failure := mcpActionFailure{Service: service, Action: action, StatusCode: statusCode, Message: message, Err: err}
+
+	// Rate limits come first: Google reports some of them as 403
+	if statusCode == http.StatusTooManyRequests || containsAnyFold(message, rateLimitMarkers) {
+		rateLimitErr := &RateLimitError{mcpActionFailure: failure}
+		if hint := retryAfterPattern.FindStringSubmatch(message); hint != nil {
+			rateLimitErr.RetryAfter, _ = time.ParseDuration(hint[1])
+		}
+		return rateLimitErr
+	}
+
+	switch {
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden || containsAnyFold(message, authMarkers):
+		return &AuthError{failure}
+	case statusCode == http.StatusBadRequest || statusCode == http.StatusUnprocessableEntity || containsAnyFold(message, validationMarkers):
+		return &ValidationError{failure}
+	default:
+		return &UpstreamError{failure}
+	}
+}
+
+// containsAnyFold reports whether text contains any of the markers, ignoring case
+func containsAnyFold(text string, markers []string) bool {
+	text = strings.ToLower(text)
+	for _, marker := range markers {
+		if strings.Contains(text, strings.ToLower(marker)) {
+			return true
+		}
+	}
+	return false
+}
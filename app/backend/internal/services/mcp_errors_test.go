@@ -0,0 +1,117 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestNewMCPActionErrorClassification verifies MCP failures map to the typed errors
+func TestNewMCPActionErrorClassification(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		message    string
+		check      func(error) bool
+	}{
+		{"unauthorized status", http.StatusUnauthorized, "Invalid OAuth token", isError[*AuthError]},
+		{"proxy auth code", http.StatusOK, "Failed to execute gmail.send_message: AUTHENTICATION_FAILED: Failed to initialize Gmail service", isError[*AuthError]},
+		{"google 401", http.StatusOK, "INTERNAL_ERROR: Function execution failed: googleapi: Error 401: Request had invalid authentication credentials", isError[*AuthError]},
+		{"google 403 rate limit", http.StatusOK, "googleapi: Error 403: User Rate Limit Exceeded, userRateLimitExceeded", isError[*RateLimitError]},
+		{"too many requests", http.StatusTooManyRequests, "slow down", isError[*RateLimitError]},
+		{"proxy payload code", http.StatusOK, "INVALID_PAYLOAD: missing required field: to", isError[*ValidationError]},
+		{"bad request", http.StatusBadRequest, "Invalid request format", isError[*ValidationError]},
+		{"server error", http.StatusInternalServerError, "Tool execution failed", isError[*UpstreamError]},
+		{"google 500", http.StatusOK, "googleapi: Error 500: Backend Error", isError[*UpstreamError]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newMCPActionError("gmail", "send_message", tt.statusCode, tt.message, nil)
+			if !tt.check(err) {
+				t.Errorf("Unexpected error type %T for %q", err, tt.message)
+			}
+		})
+	}
+
+	err := newMCPActionError("gmail", "send_message", http.StatusOK, "gmail rate limit exceeded (HTTP 429, reason: rateLimitExceeded) after 4 attempts, retry after 30s", nil)
+	var rateLimitErr *RateLimitError
+	if !errors.As(err, &rateLimitErr) || rateLimitErr.RetryAfter != 30*time.Second {
+		t.Errorf("Expected a rate limit error with a 30s retry hint, got %#v", err)
+	}
+
+	cause := errors.New("connection refused")
+	err = newMCPActionError("gmail", "send_message", 0, "", cause)
+	if !isError[*UpstreamError](err) || !errors.Is(err, cause) {
+		t.Errorf("Expected an upstream error wrapping the transport error, got %v", err)
+	}
+}
+
+// TestExecuteActionReturnsTypedErrors verifies ExecuteAction classifies tool errors and failed calls
+func TestExecuteActionReturnsTypedErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Arguments map[string]interface{} `json:"arguments"`
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+
+		w.Header().Set("Content-Type", "application/json")
+		if request.Arguments["token"] == "expired" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]interface{}{"error": "Invalid token"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"result": map[string]interface{}{
+				"content": []map[string]interface{}{{"type": "text", "text": "Failed to execute gmail.send_message: INVALID_PAYLOAD: missing required field: to"}},
+				"isError": true,
+			},
+		})
+	}))
+	defer server.Close()
+
+	mcpService := NewMCPService(server.URL)
+	if _, err := mcpService.ExecuteAction("gmail", "send_message", map[string]interface{}{}, "expired"); !isError[*AuthError](err) {
+		t.Errorf("Expected AuthError, got %T: %v", err, err)
+	}
+	response, err := mcpService.ExecuteAction("gmail", "send_message", map[string]interface{}{}, "token")
+	if !isError[*ValidationError](err) {
+		t.Errorf("Expected ValidationError, got %T: %v", err, err)
+	}
+	if response == nil || response.Success {
+		t.Errorf("Expected the failed tool response alongside the error, got %+v", response)
+	}
+}
+
+// isError reports whether err is or wraps an error of type T
+func isError[T error](err error) bool {
+	var target T
+	return errors.As(err, &target)
+}
+
+// TestMCPRequestTimeout verifies a hung MCP call returns a TimeoutError after the request timeout
+func TestMCPRequestTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	mcpService := NewMCPService(server.URL)
+	mcpService.SetRequestTimeout(50 * time.Millisecond)
+
+	var timeoutErr *TimeoutError
+	_, err := mcpService.ExecuteAction("gmail", "send_message", map[string]interface{}{}, "token")
+	if !errors.As(err, &timeoutErr) || timeoutErr.Operation != "gmail.send_message" || timeoutErr.Timeout != 50*time.Millisecond {
+		t.Errorf("Expected a gmail.send_message TimeoutError, got %T: %v", err, err)
+	}
+	if _, err := mcpService.GetServiceCatalog(); !errors.As(err, &timeoutErr) || timeoutErr.Operation != "service catalog" {
+		t.Errorf("Expected a service catalog TimeoutError, got %T: %v", err, err)
+	}
+}
@@ -3,7 +3,10 @@ package services
 import (
 	"encoding/json"
 	"fmt"
+	"log"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 
 	"sohoaas-backend/internal/types"
@@ -24,7 +27,7 @@ func (p *MCPCatalogParser) ParseServicesFromCatalog(mcpCatalog interface{}) (map
 	if catalog, ok := mcpCatalog.(*types.MCPServiceCatalog); ok {
 		// Direct access - no conversion needed since types match MCP response
 		servicesData := make(map[string]interface{})
-		for serviceName, serviceDefinition := range catalog.Providers.Workspace.Services {
+		for serviceName, serviceDefinition := range catalog.AllServices() {
 			servicesData[serviceName] = serviceDefinition
 		}
 		return servicesData, nil
@@ -38,22 +41,43 @@ func (p *MCPCatalogParser) ParseServicesFromCatalog(mcpCatalog interface{}) (map
 	return nil, fmt.Errorf("unsupported catalog type: %T", mcpCatalog)
 }
 
-// parseServicesFromMap handles legacy map format (for backward compatibility)
+// parseServicesFromMap handles legacy map format (for backward compatibility).
+// Services of every provider are merged, with workspace taking precedence on name clashes.
 func (p *MCPCatalogParser) parseServicesFromMap(mcpCatalog map[string]interface{}) (map[string]interface{}, error) {
-	// Parse MCP services structure: providers → workspace → services
+	// Parse MCP services structure: providers → <provider> → services
 	providersWrapper, ok := mcpCatalog["providers"].(map[string]interface{})
 	if !ok {
 		return nil, fmt.Errorf("invalid MCP catalog: missing 'providers' key")
 	}
 	
-	workspaceWrapper, ok := providersWrapper["workspace"].(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("invalid MCP catalog: missing 'workspace' key under 'providers'")
+	providerNames := make([]string, 0, len(providersWrapper))
+	for name := range providersWrapper {
+		if name != types.WorkspaceProvider {
+			providerNames = append(providerNames, name)
+		}
 	}
+	sort.Strings(providerNames)
+	providerNames = append([]string{types.WorkspaceProvider}, providerNames...)
 	
-	servicesData, ok := workspaceWrapper["services"].(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("invalid MCP catalog: missing 'services' key under 'workspace'")
+	servicesData := make(map[string]interface{})
+	for _, name := range providerNames {
+		providerWrapper, ok := providersWrapper[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		providerServices, ok := providerWrapper["services"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for serviceName, serviceData := range providerServices {
+			if _, exists := servicesData[serviceName]; !exists {
+				servicesData[serviceName] = serviceData
+			}
+		}
+	}
+	
+	if len(servicesData) == 0 {
+		return nil, fmt.Errorf("invalid MCP catalog: no provider under 'providers' has a 'services' key")
 	}
 	
 	return servicesData, nil
@@ -67,7 +91,7 @@ func (p *MCPCatalogParser) BuildUserCapabilities(mcpCatalog interface{}, connect
 		var capabilities []map[string]interface{}
 		
 		for _, serviceName := range connectedServices {
-			if serviceDefinition, exists := catalog.Providers.Workspace.Services[serviceName]; exists {
+			if _, serviceDefinition, exists := catalog.LookupService(serviceName); exists {
 				var actions []string
 				for functionName := range serviceDefinition.Functions {
 					// Build full action name as service.function for consistency
@@ -208,10 +232,48 @@ func (p *MCPCatalogParser) ParseMCPCatalog(mcpCatalog map[string]interface{}) (*
 	if err := json.Unmarshal(jsonData, &catalog); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal MCP catalog: %w", err)
 	}
+	if err := CheckCatalogSchemaVersion(&catalog); err != nil {
+		return nil, err
+	}
 
 	return &catalog, nil
 }
 
+// CheckCatalogSchemaVersion rejects catalogs whose schema_version has a different major
+// version than types.CatalogSchemaVersion, since their shape would be misparsed. Newer
+// minor versions and unversioned catalogs are accepted with a warning, as unknown fields
+// are dropped while decoding.
+func CheckCatalogSchemaVersion(catalog *types.MCPServiceCatalog) error {
+	if catalog.SchemaVersion == "" {
+		log.Printf("[MCPCatalogParser] WARNING: Catalog has no schema_version; assuming %s", types.CatalogSchemaVersion)
+		return nil
+	}
+
+	major, minor, err := parseCatalogSchemaVersion(catalog.SchemaVersion)
+	if err != nil {
+		return err
+	}
+	supportedMajor, supportedMinor, _ := parseCatalogSchemaVersion(types.CatalogSchemaVersion)
+	if major != supportedMajor {
+		return fmt.Errorf("unsupported MCP catalog schema_version %s: this backend supports %d.x", catalog.SchemaVersion, supportedMajor)
+	}
+	if minor > supportedMinor {
+		log.Printf("[MCPCatalogParser] WARNING: Catalog schema_version %s is newer than %s; fields added since are ignored", catalog.SchemaVersion, types.CatalogSchemaVersion)
+	}
+	return nil
+}
+
+// parseCatalogSchemaVersion splits a "major.minor" schema version
+func parseCatalogSchemaVersion(version string) (int, int, error) {
+	majorPart, minorPart, found := strings.Cut(version, ".")
+	major, majorErr := strconv.Atoi(majorPart)
+	minor, minorErr := strconv.Atoi(minorPart)
+	if !found || majorErr != nil || minorErr != nil || major < 0 || minor < 0 {
+		return 0, 0, fmt.Errorf("invalid MCP catalog schema_version %q: expected major.minor", version)
+	}
+	return major, minor, nil
+}
+
 // ParseWorkflowSteps converts map[string]interface{} steps to strongly-typed WorkflowStepValidation
 func (p *MCPCatalogParser) ParseWorkflowSteps(steps []map[string]interface{}) ([]types.WorkflowStepValidation, error) {
 	jsonData, err := json.Marshal(steps)
@@ -246,7 +308,7 @@ func (p *MCPCatalogParser) ParseServiceBindings(bindings map[string]interface{})
 // ValidateMCPFunctionsTyped validates workflow steps using strongly-typed structures
 func (p *MCPCatalogParser) ValidateMCPFunctionsTyped(catalog *types.MCPServiceCatalog, steps []types.WorkflowStepValidation) (bool, []string) {
 	var errors []string
-	services := catalog.Providers.Workspace.Services
+	services := catalog.AllServices()
 	
 	for i, step := range steps {
 		if step.Action == "" {
@@ -254,16 +316,14 @@ func (p *MCPCatalogParser) ValidateMCPFunctionsTyped(catalog *types.MCPServiceCa
 			continue
 		}
 
-		// Parse service.function format (e.g., "gmail.send_message")
-		parts := strings.Split(step.Action, ".")
-		if len(parts) < 2 {
+		// Parse service.function format (e.g., "gmail.send_message"), mapping aliases and
+		// bare function names to the catalog's functions
+		serviceName, functionName, err := p.NormalizeAction(catalog, "", step.Action)
+		if err != nil || serviceName == "" {
 			errors = append(errors, fmt.Sprintf("Step %d (%s): invalid action format '%s' - should be 'service.function'", i, step.ID, step.Action))
 			continue
 		}
 
-		serviceName := parts[0]
-		functionName := parts[1] // Just the function name part
-
 		// Check if service exists
 		serviceDefinition, exists := services[serviceName]
 		if !exists {
@@ -284,21 +344,18 @@ func (p *MCPCatalogParser) ValidateMCPFunctionsTyped(catalog *types.MCPServiceCa
 // ValidateMCPParametersTyped validates step parameters using strongly-typed structures
 func (p *MCPCatalogParser) ValidateMCPParametersTyped(catalog *types.MCPServiceCatalog, steps []types.WorkflowStepValidation) (bool, []string) {
 	var errors []string
-	services := catalog.Providers.Workspace.Services
+	services := catalog.AllServices()
 
 	for i, step := range steps {
 		if step.Action == "" {
 			continue // Skip validation if no action (handled by function validation)
 		}
 
-		// Parse service name
-		parts := strings.Split(step.Action, ".")
-		if len(parts) < 2 {
+		// Parse service name, mapping aliases the same way function validation does
+		serviceName, functionName, err := p.NormalizeAction(catalog, "", step.Action)
+		if err != nil || serviceName == "" {
 			continue // Skip if invalid format (handled by function validation)
 		}
-
-		serviceName := parts[0]
-		functionName := parts[1] // Just the function name part
 		
 		// Get service definition
 		serviceDefinition, exists := services[serviceName]
@@ -338,13 +395,13 @@ func (p *MCPCatalogParser) ValidateMCPParametersTyped(catalog *types.MCPServiceC
 // ValidateServiceBindingsTyped validates service bindings using strongly-typed structures
 func (p *MCPCatalogParser) ValidateServiceBindingsTyped(catalog *types.MCPServiceCatalog, serviceBindings map[string]types.ServiceBindingValidation, steps []types.WorkflowStepValidation) (bool, []string) {
 	var errors []string
-	services := catalog.Providers.Workspace.Services
+	services := catalog.AllServices()
 
 	// Extract required services from workflow steps
 	requiredServices := make(map[string]bool)
 	for _, step := range steps {
-		if parts := strings.Split(step.Action, "."); len(parts) >= 2 {
-			requiredServices[parts[0]] = true
+		if serviceName, _, err := p.NormalizeAction(catalog, "", step.Action); err == nil && serviceName != "" {
+			requiredServices[serviceName] = true
 		}
 	}
 
@@ -391,6 +448,7 @@ func (p *MCPCatalogParser) isValidParameterReference(paramRef string) bool {
 	validPatterns := []string{
 		`^\$\{user\.[a-zA-Z_][a-zA-Z0-9_]*\}$`,                             // ${user.param}
 		`^\$\{steps\.[a-zA-Z_][a-zA-Z0-9_]*\.outputs\.[a-zA-Z_][a-zA-Z0-9_]*\}$`, // ${steps.step_id.outputs.field}
+		`^\$\{steps\.[a-zA-Z_][a-zA-Z0-9_]*\.outputs\.[a-zA-Z_][a-zA-Z0-9_]*(\[-?[0-9]+\]|\.[a-zA-Z_][a-zA-Z0-9_]*)*(\s*\|\s*[^|}]+)*\}$`, // ${steps.step_id.outputs.items[0] | join(",")}
 		`^\$\{computed\.[a-zA-Z0-9_.]+\}$`,                                 // ${computed.expr}
 		`^\$\{[A-Z_][A-Z0-9_]*\}$`,                                         // ${ENV_VAR}
 	}
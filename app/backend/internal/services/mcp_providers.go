@@ -0,0 +1,33 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+)
+
+// SetProviderURLs routes the actions of the named catalog providers to their own MCP endpoints,
+// e.g. {"workspace_staging": "http://localhost:3003"} for a staging Gmail proxy. Providers
+// without an entry are served by the base URL.
+func (m *MCPService) SetProviderURLs(urls map[string]string) error {
+	providerURLs := make(map[string]string, len(urls))
+	for provider, providerURL := range urls {
+		parsed, err := url.Parse(providerURL)
+		if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			return fmt.Errorf("invalid MCP URL %q for provider %s", providerURL, provider)
+		}
+		providerURLs[provider] = strings.TrimSuffix(providerURL, "/")
+	}
+	m.providerURLs = providerURLs
+	return nil
+}
+
+// providerBaseURL returns the MCP endpoint serving a catalog provider
+func (m *MCPService) providerBaseURL(provider string) string {
+	if providerURL, exists := m.providerURLs[provider]; exists {
+		log.Printf("[MCPService] Routing provider %s to %s", provider, providerURL)
+		return providerURL
+	}
+	return m.baseURL
+}
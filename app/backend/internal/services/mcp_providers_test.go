@@ -0,0 +1,126 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"sohoaas-backend/internal/types"
+)
+
+// newToolCallServer records the token of each tools/call it receives
+func newToolCallServer(tokens *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`{"providers": {"workspace": {"services": {}}}}`))
+			return
+		}
+		var request struct {
+			Arguments map[string]interface{} `json:"arguments"`
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		token, _ := request.Arguments["token"].(string)
+		*tokens = append(*tokens, token)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"result": map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": `{"id": "1"}`}}},
+		})
+	}))
+}
+
+// TestExecuteStepRoutesProvider verifies a step's provider selects its MCP endpoint and connection token
+func TestExecuteStepRoutesProvider(t *testing.T) {
+	var productionTokens, stagingTokens []string
+	production := newToolCallServer(&productionTokens)
+	defer production.Close()
+	staging := newToolCallServer(&stagingTokens)
+	defer staging.Close()
+
+	mcpService := NewMCPService(production.URL)
+	if err := mcpService.SetProviderURLs(map[string]string{"workspace_staging": staging.URL + "/"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	tokenManager := NewTokenManager()
+	tokenManager.tokens["user_1"] = map[string]*UserTokens{
+		"workspace_staging": {AccessToken: "staging_token", Connection: "workspace_staging", Expiry: time.Now().Add(time.Hour)},
+	}
+	ee := NewExecutionEngine(mcpService)
+	ee.SetTokenManager(tokenManager)
+	paramContext := &ParameterContext{
+		StepOutputs:      map[string]interface{}{},
+		SystemParameters: map[string]interface{}{"user_id": "user_1", "oauth_token": "default_token"},
+	}
+
+	stagingStep := &ResolvedStep{ID: "staged", Provider: "workspace_staging", Service: "gmail", Action: "send_message", Inputs: map[string]interface{}{}, Outputs: map[string]interface{}{}}
+	if err := ee.executeStep(stagingStep, paramContext); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defaultStep := &ResolvedStep{ID: "default", Service: "gmail", Action: "send_message", Inputs: map[string]interface{}{}, Outputs: map[string]interface{}{}}
+	if err := ee.executeStep(defaultStep, paramContext); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(stagingTokens) != 1 || stagingTokens[0] != "staging_token" {
+		t.Errorf("Expected the staging step on the staging endpoint with its connection token, got %v", stagingTokens)
+	}
+	if len(productionTokens) != 1 || productionTokens[0] != "default_token" {
+		t.Errorf("Expected the default step on the base endpoint with the execution token, got %v", productionTokens)
+	}
+}
+
+// TestSetProviderURLsRejectsInvalidURLs verifies provider endpoints must be absolute URLs
+func TestSetProviderURLsRejectsInvalidURLs(t *testing.T) {
+	mcpService := NewMCPService("http://localhost:3000")
+	if err := mcpService.SetProviderURLs(map[string]string{"workspace_staging": "localhost:3003"}); err == nil {
+		t.Error("Expected a URL without scheme to be rejected")
+	}
+	if baseURL := mcpService.providerBaseURL("workspace_staging"); baseURL != "http://localhost:3000" {
+		t.Errorf("Expected the base URL after a rejected configuration, got %s", baseURL)
+	}
+}
+
+// TestValidateStepProvider verifies a step's (provider, service, action) must exist in the catalog
+func TestValidateStepProvider(t *testing.T) {
+	var catalog types.MCPServiceCatalog
+	if err := json.Unmarshal([]byte(twoProviderCatalogJSON), &catalog); err != nil {
+		t.Fatalf("Failed to decode catalog: %v", err)
+	}
+	ee := NewExecutionEngine(nil)
+
+	tests := []struct {
+		provider, service, action string
+		wantErr                   string
+	}{
+		{"storage", "buckets", "create", ""},
+		{"workspace", "files", "upload", ""},
+		{"workspace", "buckets", "create", "not found in provider"},
+		{"storage", "files", "delete", "unknown action"},
+		{"staging", "files", "upload", "provider 'staging' not found"},
+	}
+	for _, tt := range tests {
+		workflow := &ParsedWorkflow{Steps: []WorkflowStep{{ID: "step", Provider: tt.provider, Service: tt.service, Action: tt.action}}}
+		err := ee.validateWorkflowServicesInternal(&catalog, workflow)
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s.%s.%s: unexpected error: %v", tt.provider, tt.service, tt.action, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s.%s.%s: expected error containing %q, got %v", tt.provider, tt.service, tt.action, tt.wantErr, err)
+		}
+	}
+
+	// Catalogs without providers can't confirm a step's provider
+	var catalogMap map[string]interface{}
+	if err := json.Unmarshal([]byte(twoProviderCatalogJSON), &catalogMap); err != nil {
+		t.Fatalf("Failed to decode catalog map: %v", err)
+	}
+	workflow := &ParsedWorkflow{Steps: []WorkflowStep{{ID: "step", Provider: "storage", Service: "buckets", Action: "create"}}}
+	if err := ee.validateWorkflowServicesInternal(catalogMap, workflow); err == nil {
+		t.Error("Expected a provider to be rejected for a catalog without providers")
+	}
+}
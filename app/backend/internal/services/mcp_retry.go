@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"math/rand"
+	"net"
+	"time"
+)
+
+// MCPRetryPolicy controls how MCP requests that failed transiently are retried
+type MCPRetryPolicy struct {
+	MaxAttempts int           // total attempts per request; 1 disables retries
+	BaseDelay   time.Duration // delay before the first retry, doubled for each further one
+	MaxDelay    time.Duration // cap of the delay between attempts
+	Jitter      float64       // fraction (0-1) of each delay that is randomized
+}
+
+// DefaultMCPRetryPolicy is used until SetRetryPolicy is called
+var DefaultMCPRetryPolicy = MCPRetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   250 * time.Millisecond,
+	MaxDelay:    2 * time.Second,
+	Jitter:      0.2,
+}
+
+// SetRetryPolicy configures retries of the catalog fetch and of safe action calls.
+// Non-positive attempts disable retries.
+func (m *MCPService) SetRetryPolicy(policy MCPRetryPolicy) {
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+	if policy.Jitter < 0 {
+		policy.Jitter = 0
+	} else if policy.Jitter > 1 {
+		policy.Jitter = 1
+	}
+	m.retryPolicy = policy
+}
+
+// backoff returns the delay before retry number attempt (1-based), with jitter applied
+func (p MCPRetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay << (attempt - 1)
+	if delay > p.MaxDelay || delay <= 0 {
+		delay = p.MaxDelay
+	}
+	if p.Jitter > 0 && delay > 0 {
+		spread := float64(delay) * p.Jitter
+		delay = time.Duration(float64(delay) - spread + rand.Float64()*2*spread)
+	}
+	return delay
+}
+
+// withRetry runs attempt until it succeeds, fails with an error retryable rejects, runs out
+// of attempts or ctx is done; it returns the last error
+func (m *MCPService) withRetry(ctx context.Context, operation string, attempt func() error, retryable func(error) bool) error {
+	policy := m.retryPolicy
+	for n := 1; ; n++ {
+		err := attempt()
+		if err == nil || n >= policy.MaxAttempts || ctx.Err() != nil || !retryable(err) {
+			return err
+		}
+
+		delay := policy.backoff(n)
+		log.Printf("[MCPService] WARNING: %s failed (attempt %d of %d): %v; retrying in %v", operation, n, policy.MaxAttempts, err, delay)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// isConnectionError reports whether the request never reached MCP (e.g. connection refused),
+// so retrying can't repeat an action that already ran
+func isConnectionError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// isTransportError reports whether a request failed in the network (refused or reset
+// connections, a connection closed mid-response) rather than being rejected
+func isTransportError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// retryableCatalogError reports whether a catalog fetch failure is transient: transport
+// errors, timeouts and 5xx responses. The fetch is read-only, so it's always safe to repeat.
+func retryableCatalogError(err error) bool {
+	var statusErr *catalogStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 500
+	}
+	var timeoutErr *TimeoutError
+	return errors.As(err, &timeoutErr) || isTransportError(err)
+}
+
+// retryableActionError reports whether an action call may be repeated. Connection errors are
+// always retried as MCP never received the call. Other transient failures (timeouts, 5xx and
+// transport errors) are retried for read actions only: a write may already have run, and
+// without idempotency keys a retry could send an email or create a document twice.
+// Rejections (4xx, validation, auth, rate limits) are never retried.
+func retryableActionError(action string, err error) bool {
+	if isConnectionError(err) {
+		return true
+	}
+	if classifyStepOperation(action) != OperationRead {
+		return false
+	}
+
+	var timeoutErr *TimeoutError
+	var upstreamErr *UpstreamError
+	switch {
+	case errors.As(err, &timeoutErr):
+		return true
+	case errors.As(err, &upstreamErr):
+		return upstreamErr.StatusCode >= 500 || isTransportError(err)
+	}
+	return false
+}
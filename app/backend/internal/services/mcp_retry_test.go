@@ -0,0 +1,93 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fastRetryPolicy retries without noticeable delays in tests
+var fastRetryPolicy = MCPRetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond, Jitter: 0.5}
+
+// TestCatalogFetchRetries verifies 5xx catalog responses are retried and 4xx ones are not
+func TestCatalogFetchRetries(t *testing.T) {
+	requests := 0
+	failStatus := http.StatusServiceUnavailable
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(failStatus)
+			return
+		}
+		w.Write([]byte(`{"providers": {"workspace": {"services": {"gmail": {"functions": {"send_message": {"name": "send_message"}}}}}}}`))
+	}))
+	defer server.Close()
+
+	mcpService := NewMCPService(server.URL)
+	mcpService.SetRetryPolicy(fastRetryPolicy)
+	if _, err := mcpService.GetServiceCatalog(); err != nil {
+		t.Fatalf("Expected the catalog after a retry, got %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("Expected 2 catalog requests, got %d", requests)
+	}
+
+	requests, failStatus = 0, http.StatusNotFound
+	if _, err := mcpService.GetServiceCatalog(); err == nil {
+		t.Fatal("Expected the 404 to be returned")
+	}
+	if requests != 1 {
+		t.Errorf("Expected no retry of a 404, got %d requests", requests)
+	}
+}
+
+// TestActionRetriesOnlyReads verifies 5xx action failures are retried for reads but never for writes
+func TestActionRetriesOnlyReads(t *testing.T) {
+	calls := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Name string `json:"name"`
+		}
+		json.NewDecoder(r.Body).Decode(&request)
+		calls[request.Name]++
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte(`{"error": "backend unavailable"}`))
+	}))
+	defer server.Close()
+
+	mcpService := NewMCPService(server.URL)
+	mcpService.SetRetryPolicy(fastRetryPolicy)
+
+	if _, err := mcpService.ExecuteAction("gmail", "list_messages", map[string]interface{}{}, "token"); err == nil {
+		t.Fatal("Expected list_messages to fail")
+	}
+	if calls["gmail.list_messages"] != 3 {
+		t.Errorf("Expected 3 attempts of a read action, got %d", calls["gmail.list_messages"])
+	}
+
+	if _, err := mcpService.ExecuteAction("gmail", "send_message", map[string]interface{}{}, "token"); err == nil {
+		t.Fatal("Expected send_message to fail")
+	}
+	if calls["gmail.send_message"] != 1 {
+		t.Errorf("Expected a single attempt of a write action, got %d", calls["gmail.send_message"])
+	}
+}
+
+// TestRetryableActionError verifies connection errors are retried even for writes
+func TestRetryableActionError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := server.URL
+	server.Close()
+
+	mcpService := NewMCPService(url)
+	mcpService.SetRetryPolicy(MCPRetryPolicy{MaxAttempts: 1})
+	_, err := mcpService.ExecuteAction("gmail", "send_message", map[string]interface{}{}, "token")
+	if err == nil || !retryableActionError("send_message", err) {
+		t.Errorf("Expected a refused connection to be retryable, got %v", err)
+	}
+	if retryableActionError("send_message", newMCPActionError("gmail", "send_message", http.StatusBadRequest, "invalid payload", nil)) {
+		t.Error("Expected a validation error not to be retried")
+	}
+}
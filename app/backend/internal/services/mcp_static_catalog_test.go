@@ -0,0 +1,56 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestGetServiceCatalogStaticFallback verifies the static catalog is used when the live catalog is unreachable
+func TestGetServiceCatalogStaticFallback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "catalog.json")
+	jsonCatalog := `{"providers": {"workspace": {"services": {"gmail": {"functions": {"send_message": {"name": "send_message"}}}}}}}`
+	if err := os.WriteFile(jsonPath, []byte(jsonCatalog), 0644); err != nil {
+		t.Fatalf("Failed to write catalog: %v", err)
+	}
+
+	mcpService := NewMCPService(server.URL)
+	if _, err := mcpService.GetServiceCatalog(); err == nil {
+		t.Fatal("Expected error without a static catalog")
+	}
+
+	mcpService.SetStaticCatalogPath(jsonPath)
+	catalog, err := mcpService.GetServiceCatalog()
+	if err != nil {
+		t.Fatalf("Expected static catalog fallback, got error: %v", err)
+	}
+	if _, exists := catalog.Providers.Workspace.Services["gmail"].Functions["send_message"]; !exists {
+		t.Errorf("Expected gmail.send_message in static catalog, got %+v", catalog.Providers.Workspace.Services)
+	}
+
+	cuePath := filepath.Join(dir, "catalog.cue")
+	cueCatalog := `providers: workspace: services: docs: functions: create_document: name: "create_document"`
+	if err := os.WriteFile(cuePath, []byte(cueCatalog), 0644); err != nil {
+		t.Fatalf("Failed to write catalog: %v", err)
+	}
+	catalog, err = LoadStaticCatalog(cuePath)
+	if err != nil {
+		t.Fatalf("Failed to load CUE catalog: %v", err)
+	}
+	if _, exists := catalog.Providers.Workspace.Services["docs"]; !exists {
+		t.Errorf("Expected docs service in CUE catalog, got %+v", catalog.Providers.Workspace.Services)
+	}
+
+	mcpService.SetStaticCatalogPath(filepath.Join(dir, "missing.json"))
+	if _, err := mcpService.GetServiceCatalog(); err == nil {
+		t.Error("Expected error when the static catalog file is missing")
+	}
+}
@@ -0,0 +1,21 @@
+package services
+
+import "encoding/json"
+
+// mcpToolContent is one item of a tools/call result
+type mcpToolContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// toolTextData returns the outputs of the first text item holding a JSON object. Servers
+// without structuredContent return the outputs as JSON text, possibly after a summary.
+func toolTextData(content []mcpToolContent) (map[string]interface{}, bool) {
+	for _, item := range content {
+		var data map[string]interface{}
+		if item.Type == "text" && json.Unmarshal([]byte(item.Text), &data) == nil && data != nil {
+			return data, true
+		}
+	}
+	return nil, false
+}
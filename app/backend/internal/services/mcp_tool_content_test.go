@@ -0,0 +1,39 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestExecuteActionStructuredContent verifies step outputs are read from structuredContent, with
+// the text items left as a summary, and from JSON text for servers without structured content
+func TestExecuteActionStructuredContent(t *testing.T) {
+	tests := map[string]string{
+		"structured": `{"result": {"content": [
+			{"type": "text", "text": "docs.create_document executed successfully. document_id: doc_1"}
+		], "structuredContent": {"document_id": "doc_1", "url": "https://docs.example.com/doc_1"}}}`,
+		"summary then JSON text": `{"result": {"content": [
+			{"type": "text", "text": "docs.create_document executed successfully. document_id: doc_1"},
+			{"type": "text", "text": "{\"document_id\": \"doc_1\", \"url\": \"https://docs.example.com/doc_1\"}"}
+		]}}`,
+		"text only": `{"result": {"content": [
+			{"type": "text", "text": "{\"document_id\": \"doc_1\", \"url\": \"https://docs.example.com/doc_1\"}"}
+		]}}`,
+	}
+	for name, body := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(body))
+		}))
+
+		response, err := NewMCPService(server.URL).ExecuteAction("docs", "create_document", map[string]interface{}{"title": "Report"}, "token")
+		server.Close()
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if response.Data["document_id"] != "doc_1" || response.Data["url"] != "https://docs.example.com/doc_1" {
+			t.Errorf("%s: expected the document outputs, got %+v", name, response.Data)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"sohoaas-backend/internal/types"
+)
+
+// MockPipelineWorkflowCUE is the canned workflow prepared by the mock pipeline test;
+// it covers user parameters, declared defaults, validation rules and a step output reference
+const MockPipelineWorkflowCUE = `workflow: {
+	name:        "weekly_report"
+	description: "Create the weekly report document and email it to the team"
+	steps: [
+		{
+			id:     "create_report"
+			name:   "Create report document"
+			action: "docs.create_document"
+			parameters: {
+				title: "${user.report_title}"
+			}
+		},
+		{
+			id:     "send_report"
+			name:   "Email report link"
+			action: "gmail.send_message"
+			parameters: {
+				to:      "${user.recipient_email}"
+				subject: "${user.report_title}"
+				body:    "This week's report: ${steps.create_report.outputs.document_url}"
+			}
+			depends_on: ["create_report"]
+		},
+	]
+	user_parameters: {
+		recipient_email: {
+			type:     "string"
+			prompt:   "Who should receive the report?"
+			required: true
+			validation: "email"
+		}
+		report_title: {
+			type:     "string"
+			prompt:   "Report title"
+			required: false
+			default:  "Weekly Report"
+		}
+	}
+	service_bindings: {
+		docs: {type: "mcp_service", provider: "workspace", auth: {method: "oauth2"}}
+		gmail: {type: "mcp_service", provider: "workspace", auth: {method: "oauth2"}}
+	}
+	execution_config: {mode: "sequential", timeout: "1m"}
+}
+`
+
+// MockPipelineIntent returns the canned validated intent of the mock pipeline test
+func MockPipelineIntent() map[string]interface{} {
+	return map[string]interface{}{
+		"is_automation_request": true,
+		"required_services":     []string{"docs", "gmail"},
+		"can_fulfill":           true,
+		"missing_info":          []string{},
+		"next_action":           "generate_workflow",
+		"user_parameters": map[string]interface{}{
+			"recipient_email": "team@example.com",
+		},
+	}
+}
+
+// MockPipelineCatalog returns the service catalog the mock pipeline workflow is validated against
+func MockPipelineCatalog() *types.MCPServiceCatalog {
+	return &types.MCPServiceCatalog{
+		SchemaVersion: types.CatalogSchemaVersion,
+		Providers: types.MCPProviders{
+			Workspace: types.MCPWorkspaceProvider{
+				Description: "Google Workspace services",
+				DisplayName: "Google Workspace",
+				Services: map[string]types.MCPServiceDefinition{
+					"docs": {
+						Description: "Google Docs",
+						DisplayName: "Google Docs",
+						Functions: map[string]types.MCPFunctionSchema{
+							"create_document": {
+								Name:           "create_document",
+								DisplayName:    "Create Document",
+								Description:    "Create a new Google Doc",
+								ExamplePayload: map[string]interface{}{"title": "Weekly Report"},
+								RequiredFields: []string{"title"},
+								OutputSchema: &types.MCPResponseSchema{
+									Type: "object",
+									Properties: map[string]types.MCPParameterProperty{
+										"document_id":  {Type: "string"},
+										"document_url": {Type: "string", Format: "uri"},
+										"title":        {Type: "string"},
+									},
+								},
+							},
+						},
+					},
+					"gmail": {
+						Description: "Gmail",
+						DisplayName: "Gmail",
+						Functions: map[string]types.MCPFunctionSchema{
+							"send_message": {
+								Name:           "send_message",
+								DisplayName:    "Send Email",
+								Description:    "Send an email message",
+								ExamplePayload: map[string]interface{}{"to": "team@example.com", "subject": "Weekly Report", "body": "..."},
+								RequiredFields: []string{"to", "subject", "body"},
+								OutputSchema: &types.MCPResponseSchema{
+									Type: "object",
+									Properties: map[string]types.MCPParameterProperty{
+										"message_id": {Type: "string"},
+										"thread_id":  {Type: "string"},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// NewFakeMCPService creates an MCP client that serves catalog without contacting MCP.
+// It has no backend, so executing actions through it fails.
+func NewFakeMCPService(catalog *types.MCPServiceCatalog) *MCPService {
+	mcpService := NewMCPService("")
+	mcpService.fixedCatalog = catalog
+	return mcpService
+}
@@ -0,0 +1,40 @@
+package services
+
+import (
+	"testing"
+
+	"sohoaas-backend/internal/types"
+)
+
+// TestPrepareMockPipeline verifies the canned workflow prepares without LLM or MCP access
+func TestPrepareMockPipeline(t *testing.T) {
+	executionEngine := NewExecutionEngine(NewFakeMCPService(MockPipelineCatalog()))
+	user := &types.User{ID: "pipeline-user", Email: "owner@example.com"}
+
+	plan, err := executionEngine.PrepareExecution(MockPipelineWorkflowCUE, user.ID, user, MockPipelineIntent(), "mock_oauth_token", "UTC")
+	if err != nil {
+		t.Fatalf("PrepareExecution failed: %v", err)
+	}
+	if len(plan.ValidationErrors) > 0 || len(plan.ParameterErrors) > 0 {
+		t.Fatalf("Expected a valid plan, got validation errors %v and parameter errors %v", plan.ValidationErrors, plan.ParameterErrors)
+	}
+	if len(plan.ResolvedSteps) != 2 {
+		t.Fatalf("Expected 2 resolved steps, got %d", len(plan.ResolvedSteps))
+	}
+
+	send := plan.ResolvedSteps[1]
+	if send.Inputs["to"] != "team@example.com" {
+		t.Errorf("Expected recipient from the canned intent, got %v", send.Inputs["to"])
+	}
+	if send.Inputs["subject"] != "Weekly Report" {
+		t.Errorf("Expected the declared default title, got %v", send.Inputs["subject"])
+	}
+}
+
+// TestFakeMCPServiceDoesNotExecute verifies the fake client refuses to run actions
+func TestFakeMCPServiceDoesNotExecute(t *testing.T) {
+	mcpService := NewFakeMCPService(MockPipelineCatalog())
+	if _, err := mcpService.ExecuteAction("gmail", "send_message", map[string]interface{}{}, "token"); err == nil {
+		t.Error("Expected executing an action through the fake MCP client to fail")
+	}
+}
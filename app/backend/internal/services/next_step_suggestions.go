@@ -0,0 +1,122 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"sohoaas-backend/internal/types"
+)
+
+// maxNextStepSuggestions caps the follow-on actions suggested for a workflow
+const maxNextStepSuggestions = 5
+
+// actionAffinities are the actions commonly run after an action, as "service.function",
+// most common first. A follow-on is only suggested when the catalog offers it.
+var actionAffinities = map[string][]struct {
+	action, reason string
+}{
+	"docs.create_document": {
+		{"drive.share_file", "Share the new document with collaborators"},
+		{"gmail.send_message", "Email a link to the new document"},
+	},
+	"sheets.create_spreadsheet": {
+		{"drive.share_file", "Share the new spreadsheet with collaborators"},
+		{"gmail.send_message", "Email a link to the new spreadsheet"},
+	},
+	"drive.upload_file": {
+		{"drive.share_file", "Share the uploaded file"},
+		{"gmail.send_message", "Email a link to the uploaded file"},
+	},
+	"drive.create_folder": {
+		{"drive.share_file", "Share the new folder"},
+		{"docs.create_document", "Create a document in the new folder"},
+	},
+	"calendar.create_event": {
+		{"gmail.send_message", "Email the event details to the attendees"},
+		{"calendar.get_event", "Check which attendees have responded"},
+	},
+	"calendar.get_event": {
+		{"gmail.send_message", "Follow up with attendees who haven't responded"},
+	},
+	"calendar.list_events": {
+		{"gmail.send_message", "Email a summary of the events"},
+		{"docs.create_document", "Create notes for the events"},
+	},
+	"gmail.get_message": {
+		{"docs.create_document", "Save the message as a document"},
+	},
+	"gmail.search_messages": {
+		{"docs.create_document", "Collect the messages into a document"},
+	},
+}
+
+// NextStepSuggestion is an action commonly run after one of a workflow's steps
+type NextStepSuggestion struct {
+	Action      string `json:"action"` // service.function
+	Service     string `json:"service"`
+	Function    string `json:"function"`
+	DisplayName string `json:"display_name,omitempty"` // from the catalog
+	Reason      string `json:"reason"`
+	After       string `json:"after"` // the workflow action the suggestion follows
+}
+
+// SuggestNextSteps suggests follow-on actions for a workflow's steps from known action
+// affinities, limited to the actions the service catalog offers. No LLM is involved.
+func (m *MCPService) SuggestNextSteps(steps []types.WorkflowStep) ([]NextStepSuggestion, error) {
+	catalog, err := m.GetServiceCatalog()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load service catalog: %w", err)
+	}
+	return suggestNextSteps(catalog, steps), nil
+}
+
+// suggestNextSteps returns the affinities of the steps' actions in step order, skipping
+// actions the workflow already has and actions missing from the catalog
+func suggestNextSteps(catalog *types.MCPServiceCatalog, steps []types.WorkflowStep) []NextStepSuggestion {
+	suggestions := []NextStepSuggestion{}
+	if catalog == nil {
+		return suggestions
+	}
+
+	present := make(map[string]bool, len(steps))
+	actions := make([]string, 0, len(steps))
+	for _, step := range steps {
+		action := qualifiedAction(step.Service, step.Action)
+		if !strings.Contains(action, ".") || present[action] {
+			continue
+		}
+		present[action] = true
+		actions = append(actions, action)
+	}
+
+	suggested := make(map[string]bool)
+	for _, after := range actions {
+		for _, affinity := range actionAffinities[after] {
+			if len(suggestions) == maxNextStepSuggestions {
+				return suggestions
+			}
+			if present[affinity.action] || suggested[affinity.action] {
+				continue
+			}
+			service, function, _ := strings.Cut(affinity.action, ".")
+			_, definition, exists := catalog.LookupService(service)
+			if !exists {
+				continue
+			}
+			schema, exists := definition.Functions[function]
+			if !exists {
+				continue
+			}
+			suggested[affinity.action] = true
+			suggestions = append(suggestions, NextStepSuggestion{
+				Action:      affinity.action,
+				Service:     service,
+				Function:    function,
+				DisplayName: schema.DisplayName,
+				Reason:      affinity.reason,
+				After:       after,
+			})
+		}
+	}
+	return suggestions
+}
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"testing"
+
+	"sohoaas-backend/internal/types"
+)
+
+// TestSuggestNextSteps verifies follow-on actions come from affinities the catalog offers
+func TestSuggestNextSteps(t *testing.T) {
+	catalog := MockPipelineCatalog()
+	steps := []types.WorkflowStep{{ID: "create", Service: "docs", Action: "create_document"}}
+
+	suggestions := suggestNextSteps(catalog, steps)
+	if len(suggestions) != 1 || suggestions[0].Action != "gmail.send_message" || suggestions[0].After != "docs.create_document" {
+		t.Fatalf("Expected only the catalog's gmail.send_message after create_document, got %+v", suggestions)
+	}
+
+	catalog.Providers.Workspace.Services["drive"] = types.MCPServiceDefinition{
+		DisplayName: "Google Drive",
+		Functions: map[string]types.MCPFunctionSchema{
+			"share_file": {Name: "share_file", DisplayName: "Share File"},
+		},
+	}
+	suggestions = suggestNextSteps(catalog, steps)
+	if len(suggestions) != 2 || suggestions[0].Action != "drive.share_file" || suggestions[0].DisplayName != "Share File" || suggestions[1].Action != "gmail.send_message" {
+		t.Fatalf("Expected share_file then send_message, got %+v", suggestions)
+	}
+
+	steps = append(steps, types.WorkflowStep{ID: "email", Action: "gmail.send_message"})
+	suggestions = suggestNextSteps(catalog, steps)
+	if len(suggestions) != 1 || suggestions[0].Action != "drive.share_file" {
+		t.Errorf("Expected actions already in the workflow not to be suggested, got %+v", suggestions)
+	}
+
+	if suggestions := suggestNextSteps(catalog, []types.WorkflowStep{{ID: "send", Service: "gmail", Action: "send_message"}}); len(suggestions) != 0 {
+		t.Errorf("Expected no suggestions without known affinities, got %+v", suggestions)
+	}
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrScopeNotPermitted is returned for workflows whose service bindings request an OAuth scope the deployment doesn't permit
+var ErrScopeNotPermitted = errors.New("OAuth scope not permitted")
+
+// googleScopePrefix is prepended to short scope names such as "drive.file"
+const googleScopePrefix = "https://www.googleapis.com/auth/"
+
+// impliedScopes maps a broad Google OAuth scope to the narrower scopes it already grants
+var impliedScopes = map[string][]string{
+	"https://mail.google.com/": {
+		googleScopePrefix + "gmail.modify",
+	},
+	googleScopePrefix + "gmail.modify": {
+		googleScopePrefix + "gmail.compose",
+		googleScopePrefix + "gmail.readonly",
+		googleScopePrefix + "gmail.labels",
+		googleScopePrefix + "gmail.insert",
+	},
+	googleScopePrefix + "gmail.compose": {
+		googleScopePrefix + "gmail.send",
+	},
+	googleScopePrefix + "drive": {
+		googleScopePrefix + "drive.file",
+		googleScopePrefix + "drive.readonly",
+		googleScopePrefix + "drive.metadata",
+		googleScopePrefix + "drive.appdata",
+	},
+	googleScopePrefix + "drive.readonly": {
+		googleScopePrefix + "drive.metadata.readonly",
+	},
+	googleScopePrefix + "drive.metadata": {
+		googleScopePrefix + "drive.metadata.readonly",
+	},
+	googleScopePrefix + "calendar": {
+		googleScopePrefix + "calendar.events",
+		googleScopePrefix + "calendar.readonly",
+	},
+	googleScopePrefix + "calendar.events": {
+		googleScopePrefix + "calendar.events.readonly",
+	},
+	googleScopePrefix + "calendar.readonly": {
+		googleScopePrefix + "calendar.events.readonly",
+	},
+	googleScopePrefix + "documents": {
+		googleScopePrefix + "documents.readonly",
+	},
+	googleScopePrefix + "spreadsheets": {
+		googleScopePrefix + "spreadsheets.readonly",
+	},
+}
+
+// canonicalOAuthScope trims a scope and expands short names to the full Google scope URL
+func canonicalOAuthScope(scope string) string {
+	scope = strings.TrimSpace(scope)
+	if scope == "" || strings.Contains(scope, "://") {
+		return scope
+	}
+	return googleScopePrefix + scope
+}
+
+// scopeImplies reports whether broad grants narrow, directly or through another scope
+func scopeImplies(broad, narrow string) bool {
+	for _, implied := range impliedScopes[broad] {
+		if implied == narrow || scopeImplies(implied, narrow) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeOAuthScopes returns the minimal canonical scope set for a service binding:
+// scopes are canonicalized and deduplicated, and scopes granted by a broader scope in
+// the same set are dropped. First-seen order is preserved.
+func normalizeOAuthScopes(scopes []string) []string {
+	seen := make(map[string]bool, len(scopes))
+	canonical := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		scope = canonicalOAuthScope(scope)
+		if scope == "" || seen[scope] {
+			continue
+		}
+		seen[scope] = true
+		canonical = append(canonical, scope)
+	}
+
+	normalized := make([]string, 0, len(canonical))
+	for _, scope := range canonical {
+		implied := false
+		for _, other := range canonical {
+			if other != scope && scopeImplies(other, scope) {
+				implied = true
+				break
+			}
+		}
+		if !implied {
+			normalized = append(normalized, scope)
+		}
+	}
+	return normalized
+}
+
+// SetAllowedOAuthScopes restricts the OAuth scopes workflows may request (e.g. only
+// gmail.readonly in a least-privilege deployment). Short names are expanded; an empty
+// list allows any scope.
+func (m *MCPService) SetAllowedOAuthScopes(scopes []string) {
+	m.allowedOAuthScopes = nil
+	for _, scope := range scopes {
+		if scope = canonicalOAuthScope(scope); scope != "" {
+			m.allowedOAuthScopes = append(m.allowedOAuthScopes, scope)
+		}
+	}
+}
+
+// AllowedOAuthScopes returns the canonical allowed scopes, or nil when any scope is allowed
+func (m *MCPService) AllowedOAuthScopes() []string {
+	if m == nil {
+		return nil
+	}
+	return m.allowedOAuthScopes
+}
+
+// scopePermitted reports whether scope is allowed itself or granted by a broader allowed scope
+func scopePermitted(allowed []string, scope string) bool {
+	for _, allowedScope := range allowed {
+		if allowedScope == scope || scopeImplies(allowedScope, scope) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckOAuthScopes returns ErrScopeNotPermitted, naming each service's scopes outside the
+// deployment's allowed scopes, for the scopes a workflow's service bindings request
+func (m *MCPService) CheckOAuthScopes(bindingScopes map[string][]string) error {
+	if m == nil || m.allowedOAuthScopes == nil {
+		return nil
+	}
+
+	services := make([]string, 0, len(bindingScopes))
+	for service := range bindingScopes {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+
+	var denied []string
+	for _, service := range services {
+		for _, scope := range normalizeOAuthScopes(bindingScopes[service]) {
+			if !scopePermitted(m.allowedOAuthScopes, scope) {
+				denied = append(denied, fmt.Sprintf("%s needs %s", service, scope))
+			}
+		}
+	}
+	if len(denied) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %s (allowed: %s)", ErrScopeNotPermitted, strings.Join(denied, "; "), strings.Join(m.allowedOAuthScopes, ", "))
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestNormalizeOAuthScopes verifies duplicate and implied scopes are dropped from a binding
+func TestNormalizeOAuthScopes(t *testing.T) {
+	tests := []struct {
+		name     string
+		scopes   []string
+		expected []string
+	}{
+		{
+			name:     "drive covers drive.file",
+			scopes:   []string{googleScopePrefix + "drive", googleScopePrefix + "drive.file"},
+			expected: []string{googleScopePrefix + "drive"},
+		},
+		{
+			name:     "narrower scope listed first",
+			scopes:   []string{googleScopePrefix + "calendar.events", googleScopePrefix + "calendar"},
+			expected: []string{googleScopePrefix + "calendar"},
+		},
+		{
+			name:     "duplicates and short names",
+			scopes:   []string{"documents", googleScopePrefix + "documents", " drive.file ", googleScopePrefix + "drive.file"},
+			expected: []string{googleScopePrefix + "documents", googleScopePrefix + "drive.file"},
+		},
+		{
+			name:     "transitive implication",
+			scopes:   []string{googleScopePrefix + "gmail.send", googleScopePrefix + "gmail.readonly", googleScopePrefix + "gmail.modify"},
+			expected: []string{googleScopePrefix + "gmail.modify"},
+		},
+		{
+			name:     "unrelated scopes kept in order",
+			scopes:   []string{googleScopePrefix + "gmail.readonly", googleScopePrefix + "gmail.send", ""},
+			expected: []string{googleScopePrefix + "gmail.readonly", googleScopePrefix + "gmail.send"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeOAuthScopes(tt.scopes); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestServiceBindingScopesAreNormalized verifies overlapping scopes are reduced in generated CUE
+func TestServiceBindingScopesAreNormalized(t *testing.T) {
+	service := &GenkitService{}
+	bindingCUE := service.convertSingleServiceBindingToCUE(map[string]interface{}{
+		"service": "drive",
+		"oauth_scopes": []interface{}{
+			"https://www.googleapis.com/auth/drive",
+			"https://www.googleapis.com/auth/drive.file",
+			"https://www.googleapis.com/auth/drive",
+		},
+	})
+
+	if !strings.Contains(bindingCUE, `scopes: ["https://www.googleapis.com/auth/drive"]`) {
+		t.Errorf("Expected only the drive scope, got:\n%s", bindingCUE)
+	}
+
+	builder := NewCUEBuilder(nil)
+	bindings := builder.generateServiceBindings(&WorkflowJSON{
+		Steps: []StepJSON{{Service: "drive"}, {Service: "calendar"}},
+	})
+	if scopes := bindings["drive"].OAuthScopes; !reflect.DeepEqual(scopes, []string{googleScopePrefix + "drive"}) {
+		t.Errorf("Unexpected drive scopes: %v", scopes)
+	}
+	if scopes := bindings["calendar"].OAuthScopes; !reflect.DeepEqual(scopes, []string{googleScopePrefix + "calendar"}) {
+		t.Errorf("Unexpected calendar scopes: %v", scopes)
+	}
+}
+
+// TestCheckOAuthScopes verifies binding scopes must be allowed themselves or granted by a broader allowed scope
+func TestCheckOAuthScopes(t *testing.T) {
+	service := NewMCPService("http://localhost")
+	service.SetAllowedOAuthScopes([]string{"gmail.readonly", "drive"})
+
+	if err := service.CheckOAuthScopes(map[string][]string{
+		"gmail": {googleScopePrefix + "gmail.readonly"},
+		"drive": {"drive.file"},
+	}); err != nil {
+		t.Errorf("Expected allowed and implied scopes to pass, got %v", err)
+	}
+
+	err := service.CheckOAuthScopes(map[string][]string{"gmail": {"gmail.send"}})
+	if !errors.Is(err, ErrScopeNotPermitted) {
+		t.Fatalf("Expected ErrScopeNotPermitted, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "gmail needs "+googleScopePrefix+"gmail.send") {
+		t.Errorf("Expected the error to name the denied scope, got %v", err)
+	}
+
+	if err := NewMCPService("http://localhost").CheckOAuthScopes(map[string][]string{"gmail": {"gmail.send"}}); err != nil {
+		t.Errorf("Expected any scope to pass without an allowlist, got %v", err)
+	}
+}
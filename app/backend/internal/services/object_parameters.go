@@ -0,0 +1,27 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// isStructuredValue reports whether a parameter value is a JSON object or list, which is
+// passed to MCP actions as is rather than as text
+func isStructuredValue(value interface{}) bool {
+	switch value.(type) {
+	case map[string]interface{}, []interface{}:
+		return true
+	}
+	return false
+}
+
+// interpolationText is how a value appears when interpolated into a longer string: objects
+// and lists as JSON, everything else in its default format
+func interpolationText(value interface{}) string {
+	if isStructuredValue(value) {
+		if encoded, err := json.Marshal(value); err == nil {
+			return string(encoded)
+		}
+	}
+	return fmt.Sprintf("%v", value)
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestObjectParameterRoundTrip verifies a literal JSON object parameter stays structured
+// through CUE conversion, parsing and resolution
+func TestObjectParameterRoundTrip(t *testing.T) {
+	template := map[string]interface{}{
+		"title": "${user.title}",
+		"style": map[string]interface{}{"font_size": 11.5, "bold": true, "margins": []interface{}{1.0, 2.0}},
+		"sections": []interface{}{
+			map[string]interface{}{"heading": "Intro", "level": 1.0},
+			map[string]interface{}{"heading": "Summary", "level": 2.0},
+		},
+		"footer": nil,
+		"tags":   []interface{}{},
+	}
+	cueContent := (&GenkitService{}).convertJSONToCUE(map[string]interface{}{
+		"workflow_name": "Report Document",
+		"description":   "Create the report document",
+		"steps": []interface{}{
+			map[string]interface{}{
+				"id":         "create",
+				"action":     "docs.create_document",
+				"parameters": map[string]interface{}{"title": "Report", "template": template},
+			},
+		},
+	})
+
+	// Parse just the workflow block so the test doesn't depend on the embedded schema
+	workflowCUE := "workflow: {" + cueContent[strings.Index(cueContent, "workflow: #DeterministicWorkflow & {")+len("workflow: #DeterministicWorkflow & {"):]
+	ee := NewExecutionEngine(nil)
+	workflow, err := ee.ParseCUEWorkflow(workflowCUE)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n%s", err, workflowCUE)
+	}
+	parsed, ok := workflow.Steps[0].Inputs["template"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected the template parameter to parse as an object, got %#v", workflow.Steps[0].Inputs["template"])
+	}
+
+	resolved, err := ee.resolveParameterValue(parsed, &ParameterContext{UserParameters: map[string]interface{}{"title": "Q3 Report"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	encoded, _ := json.Marshal(resolved)
+	template["title"] = "Q3 Report"
+	expected, _ := json.Marshal(template)
+	if string(encoded) != string(expected) {
+		t.Errorf("Expected template %s, got %s", expected, encoded)
+	}
+}
+
+// TestStructuredValueReferences verifies object references keep their type as whole values
+// and are interpolated as JSON inside longer strings
+func TestStructuredValueReferences(t *testing.T) {
+	ee := NewExecutionEngine(nil)
+	context := &ParameterContext{
+		UserParameters: map[string]interface{}{"config": map[string]interface{}{"mode": "draft"}},
+		StepOutputs:    map[string]interface{}{"create": map[string]interface{}{"document": map[string]interface{}{"id": "doc_1"}}},
+	}
+
+	whole, err := ee.resolveParameterValue("${steps.create.outputs.document}", context)
+	if document, ok := whole.(map[string]interface{}); err != nil || !ok || document["id"] != "doc_1" {
+		t.Errorf("Expected the referenced object, got %#v (err: %v)", whole, err)
+	}
+
+	mixed, err := ee.resolveParameterValue("Settings: ${user.config}", context)
+	if err != nil || mixed != `Settings: {"mode":"draft"}` {
+		t.Errorf("Expected the object interpolated as JSON, got %#v (err: %v)", mixed, err)
+	}
+}
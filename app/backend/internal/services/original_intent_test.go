@@ -0,0 +1,56 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestOriginalIntentRoundTrip verifies the user's intent written to generated CUE is parsed back
+func TestOriginalIntentRoundTrip(t *testing.T) {
+	intent := `Email "weekly report" to my team every Friday`
+	cueContent := (&GenkitService{}).convertJSONToCUE(map[string]interface{}{
+		"workflow_name":   "Weekly Report",
+		"description":     "Send the weekly report",
+		"original_intent": intent,
+		"steps": []interface{}{
+			map[string]interface{}{
+				"id":         "send",
+				"action":     "gmail.send_message",
+				"parameters": map[string]interface{}{"to": "team@example.com", "subject": "Weekly report", "body": "Attached"},
+			},
+		},
+	})
+	if !strings.Contains(cueContent, "original_intent: ") {
+		t.Fatalf("Expected original_intent in generated CUE:\n%s", cueContent)
+	}
+
+	// Parse just the workflow block so the test doesn't depend on the embedded schema
+	workflowCUE := "workflow: {" + cueContent[strings.Index(cueContent, "workflow: #DeterministicWorkflow & {")+len("workflow: #DeterministicWorkflow & {"):]
+	workflow, err := NewExecutionEngine(nil).ParseCUEWorkflow(workflowCUE)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\n%s", err, workflowCUE)
+	}
+	if workflow.OriginalIntent != intent {
+		t.Errorf("Expected original intent %q, got %q", intent, workflow.OriginalIntent)
+	}
+}
+
+// TestParseCUEWorkflowWithoutOriginalIntent verifies authored workflows without an intent still parse
+func TestParseCUEWorkflowWithoutOriginalIntent(t *testing.T) {
+	workflow, err := NewExecutionEngine(nil).ParseCUEWorkflow(`workflow: {
+	name:        "cleanup"
+	description: "Delete old drafts"
+	steps: [{
+		id:     "delete"
+		name:   "Delete draft"
+		action: "gmail.delete_draft"
+		inputs: {draft_id: "d1"}
+	}]
+}`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if workflow.OriginalIntent != "" {
+		t.Errorf("Expected no original intent, got %q", workflow.OriginalIntent)
+	}
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// LastParameters are the user_parameters most recently submitted to execute a stored workflow.
+// Sensitive values (tokens, passwords, secrets) are redacted before they are stored.
+type LastParameters struct {
+	WorkflowID     string                 `json:"workflow_id"`
+	ExecutionID    string                 `json:"execution_id"`
+	UserParameters map[string]interface{} `json:"user_parameters"`
+	RedactedFields []string               `json:"redacted_fields,omitempty"` // parameters the user must enter again
+	SubmittedAt    time.Time              `json:"submitted_at"`
+}
+
+// ParameterHistoryStore keeps each user's last submitted parameters per workflow in memory
+type ParameterHistoryStore struct {
+	entries map[string]map[string]*LastParameters // userID -> workflowID -> entry
+	mutex   sync.RWMutex
+}
+
+// NewParameterHistoryStore creates an empty parameter history store
+func NewParameterHistoryStore() *ParameterHistoryStore {
+	return &ParameterHistoryStore{
+		entries: make(map[string]map[string]*LastParameters),
+	}
+}
+
+// Record replaces the user's last parameters for the workflow with a redacted copy of parameters.
+// The parameters named sensitive are left out entirely and listed in RedactedFields.
+func (s *ParameterHistoryStore) Record(userID, workflowID, executionID string, parameters map[string]interface{}, sensitive ...string) {
+	entry := &LastParameters{
+		WorkflowID:     workflowID,
+		ExecutionID:    executionID,
+		UserParameters: make(map[string]interface{}, len(parameters)),
+		SubmittedAt:    time.Now(),
+	}
+	omitted := make(map[string]bool, len(sensitive))
+	for _, name := range sensitive {
+		omitted[name] = true
+	}
+	for name, value := range parameters {
+		if omitted[name] {
+			entry.RedactedFields = append(entry.RedactedFields, name)
+			continue
+		}
+		if isSensitiveLogField(name) {
+			entry.UserParameters[name] = redactedLogValue
+			entry.RedactedFields = append(entry.RedactedFields, name)
+			continue
+		}
+		entry.UserParameters[name] = redactForLog(value)
+	}
+	sort.Strings(entry.RedactedFields)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.entries[userID] == nil {
+		s.entries[userID] = make(map[string]*LastParameters)
+	}
+	s.entries[userID][workflowID] = entry
+}
+
+// Get returns the user's last parameters for the workflow
+func (s *ParameterHistoryStore) Get(userID, workflowID string) (*LastParameters, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	entry, exists := s.entries[userID][workflowID]
+	if !exists {
+		return nil, fmt.Errorf("no previous execution parameters for workflow: %s", workflowID)
+	}
+	copied := *entry
+	return &copied, nil
+}
+
+// SetParameterHistory enables recording of the parameters submitted with each execution
+func (ee *ExecutionEngine) SetParameterHistory(store *ParameterHistoryStore) {
+	ee.parameterHistory = store
+}
+
+// ParameterHistory returns the parameter history store, or nil when not configured
+func (ee *ExecutionEngine) ParameterHistory() *ParameterHistoryStore {
+	return ee.parameterHistory
+}
+
+// RecordParameters stores the parameters submitted to execute a stored workflow, if a
+// parameter history store is configured. Parameters declared sensitive are never stored.
+func (ee *ExecutionEngine) RecordParameters(userID, workflowID, executionID string, parameters map[string]interface{}, sensitive ...string) {
+	if ee.parameterHistory == nil || workflowID == "" {
+		return
+	}
+	ee.parameterHistory.Record(userID, workflowID, executionID, parameters, sensitive...)
+}
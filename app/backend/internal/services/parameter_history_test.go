@@ -0,0 +1,30 @@
+package services
+
+import (
+	"testing"
+)
+
+// TestParameterHistoryRecordsLastSubmission verifies the latest submission per workflow is kept with secrets redacted
+func TestParameterHistoryRecordsLastSubmission(t *testing.T) {
+	store := NewParameterHistoryStore()
+	if _, err := store.Get("user_1", "wf_1"); err == nil {
+		t.Error("Expected no parameters before any execution")
+	}
+
+	store.Record("user_1", "wf_1", "exec_1", map[string]interface{}{"recipient": "old@example.com"})
+	store.Record("user_1", "wf_1", "exec_2", map[string]interface{}{"recipient": "team@example.com", "api_token": "s3cret"})
+
+	last, err := store.Get("user_1", "wf_1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if last.ExecutionID != "exec_2" || last.UserParameters["recipient"] != "team@example.com" {
+		t.Errorf("Expected the latest submission, got %+v", last)
+	}
+	if last.UserParameters["api_token"] != redactedLogValue || len(last.RedactedFields) != 1 || last.RedactedFields[0] != "api_token" {
+		t.Errorf("Expected api_token redacted, got %+v", last)
+	}
+	if _, err := store.Get("user_2", "wf_1"); err == nil {
+		t.Error("Expected another user's parameters not to be returned")
+	}
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"sohoaas-backend/internal/types"
+)
+
+// Where a step input's value comes from, in a parameter resolution report
+const (
+	InputSourceUser    = "user"    // ${user.*} parameters the user provides
+	InputSourceStep    = "step"    // ${steps.*.outputs.*} of earlier steps
+	InputSourceSystem  = "system"  // computed, environment and date values
+	InputSourceLiteral = "literal" // fixed in the workflow
+	InputSourceMixed   = "mixed"   // references of more than one source
+)
+
+var (
+	reportReference     = regexp.MustCompile(`\$\{([^}]+)\}`)
+	reportSystemCommand = regexp.MustCompile(`\$\(date\s+'[^']+'\)`)
+)
+
+// ParameterResolution explains where one step input's value comes from
+type ParameterResolution struct {
+	StepID     string   `json:"step_id"`
+	Input      string   `json:"input"` // nested object fields are dotted, list items indexed: template.sections[0].title
+	Source     string   `json:"source"`
+	References []string `json:"references,omitempty"` // e.g. user.team_emails, steps.create_doc.outputs.document_url
+	Summary    string   `json:"summary"`              // e.g. send_email.to ← user.team_emails
+}
+
+// BuildParameterReport classifies every input of every step by the references it resolves
+// from, so users can see which values they provide, which come from earlier steps and which
+// the system fills in. Steps keep their order; inputs are sorted.
+func BuildParameterReport(steps []types.WorkflowStep) []ParameterResolution {
+	report := []ParameterResolution{}
+	for _, step := range steps {
+		inputs := make([]string, 0, len(step.Parameters))
+		for input := range step.Parameters {
+			inputs = append(inputs, input)
+		}
+		sort.Strings(inputs)
+		for _, input := range inputs {
+			report = appendParameterResolutions(report, step.ID, input, step.Parameters[input])
+		}
+	}
+	return report
+}
+
+// appendParameterResolutions reports a value, descending into objects and lists so each
+// leaf input is classified on its own
+func appendParameterResolutions(report []ParameterResolution, stepID, input string, value interface{}) []ParameterResolution {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		fields := make([]string, 0, len(typed))
+		for field := range typed {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		for _, field := range fields {
+			report = appendParameterResolutions(report, stepID, input+"."+field, typed[field])
+		}
+		return report
+	case []interface{}:
+		for i, item := range typed {
+			report = appendParameterResolutions(report, stepID, fmt.Sprintf("%s[%d]", input, i), item)
+		}
+		return report
+	}
+
+	resolution := ParameterResolution{StepID: stepID, Input: input, Source: InputSourceLiteral}
+	if text, ok := value.(string); ok {
+		sources := make(map[string]bool)
+		for _, match := range reportReference.FindAllStringSubmatch(text, -1) {
+			reference := strings.TrimSpace(strings.Split(match[1], "|")[0])
+			resolution.References = append(resolution.References, reference)
+			sources[referenceSource(reference)] = true
+		}
+		for _, command := range reportSystemCommand.FindAllString(text, -1) {
+			resolution.References = append(resolution.References, command)
+			sources[InputSourceSystem] = true
+		}
+		switch len(sources) {
+		case 0:
+		case 1:
+			for source := range sources {
+				resolution.Source = source
+			}
+		default:
+			resolution.Source = InputSourceMixed
+		}
+	}
+
+	if len(resolution.References) > 0 {
+		resolution.Summary = fmt.Sprintf("%s.%s ← %s", stepID, input, strings.Join(resolution.References, ", "))
+	} else {
+		resolution.Summary = fmt.Sprintf("%s.%s ← literal", stepID, input)
+	}
+	return append(report, resolution)
+}
+
+// referenceSource classifies a reference by its first path element
+func referenceSource(reference string) string {
+	switch strings.SplitN(reference, ".", 2)[0] {
+	case "user":
+		return InputSourceUser
+	case "steps":
+		return InputSourceStep
+	}
+	return InputSourceSystem
+}
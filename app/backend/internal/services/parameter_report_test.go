@@ -0,0 +1,39 @@
+package services
+
+import (
+	"testing"
+
+	"sohoaas-backend/internal/types"
+)
+
+// TestBuildParameterReport verifies every step input is classified by where its value comes from
+func TestBuildParameterReport(t *testing.T) {
+	report := BuildParameterReport([]types.WorkflowStep{
+		{ID: "create_doc", Service: "docs", Action: "create_document", Parameters: map[string]interface{}{
+			"title":    "Report $(date '+%Y-%m-%d')",
+			"template": map[string]interface{}{"heading": "${user.heading}", "pages": []interface{}{1.0}},
+		}},
+		{ID: "send_email", Service: "gmail", Action: "send_message", Parameters: map[string]interface{}{
+			"to":      "${user.team_emails}",
+			"body":    "${steps.create_doc.outputs.document_url}",
+			"subject": "${user.subject} on ${steps.create_doc.outputs.title | upper}",
+		}},
+	})
+
+	expected := []struct{ input, source, summary string }{
+		{"template.heading", InputSourceUser, "create_doc.template.heading ← user.heading"},
+		{"template.pages[0]", InputSourceLiteral, "create_doc.template.pages[0] ← literal"},
+		{"title", InputSourceSystem, "create_doc.title ← $(date '+%Y-%m-%d')"},
+		{"body", InputSourceStep, "send_email.body ← steps.create_doc.outputs.document_url"},
+		{"subject", InputSourceMixed, "send_email.subject ← user.subject, steps.create_doc.outputs.title"},
+		{"to", InputSourceUser, "send_email.to ← user.team_emails"},
+	}
+	if len(report) != len(expected) {
+		t.Fatalf("Expected %d resolutions, got %+v", len(expected), report)
+	}
+	for i, want := range expected {
+		if got := report[i]; got.Input != want.input || got.Source != want.source || got.Summary != want.summary {
+			t.Errorf("Resolution %d: expected %s (%s) %q, got %+v", i, want.input, want.source, want.summary, got)
+		}
+	}
+}
@@ -1,8 +1,12 @@
 package services
 
 import (
+	"fmt"
 	"strings"
+	"sync"
 	"testing"
+
+	"sohoaas-backend/internal/types"
 )
 
 func TestResolveStepInputs(t *testing.T) {
@@ -602,3 +606,106 @@ func deepEqual(a, b interface{}) bool {
 		return a == b
 	}
 }
+
+// TestCreateParameterContextAppliesDefaults verifies declared user_parameters defaults
+func TestCreateParameterContextAppliesDefaults(t *testing.T) {
+	ee := NewExecutionEngine(nil)
+	user := &types.User{ID: "user_1", Email: "user@example.com"}
+	defaults := map[string]interface{}{
+		"recipient": "team@example.com",
+		"subject":   "Weekly Report",
+	}
+
+	t.Run("provided overrides default", func(t *testing.T) {
+		context := ee.createParameterContext(map[string]interface{}{
+			"user_parameters": map[string]interface{}{"recipient": "boss@example.com"},
+		}, defaults, user, "token", "UTC")
+
+		if context.UserParameters["recipient"] != "boss@example.com" {
+			t.Errorf("Expected provided recipient, got %v", context.UserParameters["recipient"])
+		}
+	})
+
+	t.Run("omitted uses default", func(t *testing.T) {
+		context := ee.createParameterContext(map[string]interface{}{
+			"user_parameters": map[string]interface{}{"recipient": "boss@example.com"},
+		}, defaults, user, "token", "UTC")
+
+		value, err := ee.resolveStringParameter("${user.subject}", context)
+		if err != nil || value != "Weekly Report" {
+			t.Errorf("Expected default subject, got %v (err: %v)", value, err)
+		}
+	})
+
+	t.Run("defaults parsed from workflow", func(t *testing.T) {
+		workflow, err := ee.ParseCUEWorkflow(`workflow: {
+	name:        "defaults"
+	description: "Workflow with parameter defaults"
+	user_parameters: {
+		subject: {type: "string", required: false, prompt: "Subject", default: "Weekly Report"}
+		count: {type: "number", required: false, prompt: "Count", default: 5}
+		recipient: {type: "email", required: true, prompt: "Recipient", validation: "email"}
+	}
+	steps: [{
+		id:     "send"
+		name:   "Send"
+		action: "gmail.send_message"
+		inputs: {subject: "${user.subject}"}
+	}]
+}`)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if workflow.UserParameterDefaults["subject"] != "Weekly Report" {
+			t.Errorf("Expected subject default, got %v", workflow.UserParameterDefaults["subject"])
+		}
+		if workflow.UserParameterDefaults["count"] != int64(5) {
+			t.Errorf("Expected count default 5, got %v", workflow.UserParameterDefaults["count"])
+		}
+		if _, exists := workflow.UserParameterDefaults["recipient"]; exists {
+			t.Error("Expected no default for recipient")
+		}
+		if workflow.UserParameterValidation["recipient"] != "email" {
+			t.Errorf("Expected email validation for recipient, got %v", workflow.UserParameterValidation)
+		}
+	})
+}
+
+// TestParameterContextStepOutputsConcurrentAccess verifies step outputs can be written and
+// resolved from several goroutines (run with -race)
+func TestParameterContextStepOutputsConcurrentAccess(t *testing.T) {
+	ee := &ExecutionEngine{}
+	context := &ParameterContext{StepOutputs: make(map[string]interface{})}
+	context.SetStepOutputs("create", map[string]interface{}{"document_id": "doc_1"})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			context.SetStepOutputs(fmt.Sprintf("step_%d", i), map[string]interface{}{"id": i})
+			context.SetStepOutputs("create", map[string]interface{}{fmt.Sprintf("field_%d", i): i})
+		}(i)
+		go func() {
+			defer wg.Done()
+			value, err := ee.resolveStringParameter("${steps.create.outputs.document_id}", context)
+			if err != nil || value != "doc_1" {
+				t.Errorf("Expected doc_1, got %v (err: %v)", value, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	outputs, exists := context.StepOutput("create")
+	if !exists || len(outputs) != 9 {
+		t.Errorf("Expected merged outputs for create, got %v", outputs)
+	}
+	outputs["document_id"] = "changed"
+	if value, _ := context.StepOutput("create"); value["document_id"] != "doc_1" {
+		t.Error("Expected StepOutput to return a copy")
+	}
+	if len(context.StepOutputsSnapshot()) != 9 {
+		t.Errorf("Expected 9 steps with outputs, got %d", len(context.StepOutputsSnapshot()))
+	}
+}
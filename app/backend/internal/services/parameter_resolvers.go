@@ -0,0 +1,163 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ErrInvalidParameterResolver is returned when registering a resolver without a usable prefix
+var ErrInvalidParameterResolver = errors.New("invalid parameter resolver")
+
+// builtinReferencePrefixes are the reference kinds resolveStringParameter handles itself, plus
+// the system, env and date kinds reserved for generated workflows (e.g. ${system.current_date})
+var builtinReferencePrefixes = map[string]bool{"user": true, "steps": true, "system": true, "env": true, "date": true}
+
+var (
+	resolverPrefixPattern  = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+	customReferencePattern = regexp.MustCompile(`\$\{([A-Za-z][A-Za-z0-9_]*)\.([^}]+)\}`)
+)
+
+// ParameterResolver resolves ${<prefix>.<path>} references of a custom kind, such as
+// ${vault.api_key} or ${lookup.regions.eu}, so deployments can add domain-specific values
+// without changing the engine. Transforms like ${lookup.regions | length} are applied by the
+// engine to the resolved value, the same way as for step outputs.
+type ParameterResolver interface {
+	// Prefix is the first element of the references handled, e.g. "vault"
+	Prefix() string
+	// Resolve returns the value at path (the reference after the prefix). found is false when
+	// the resolver doesn't know the path, so the next resolver for the prefix is tried.
+	Resolve(path string, context *ParameterContext) (value interface{}, found bool, err error)
+}
+
+// RegisterParameterResolver adds a resolver for custom references. Resolvers are tried in
+// registration order, before the built-in reference kinds; user, steps, system, env and date
+// can't be taken over.
+func (ee *ExecutionEngine) RegisterParameterResolver(resolver ParameterResolver) error {
+	if resolver == nil {
+		return fmt.Errorf("%w: nil resolver", ErrInvalidParameterResolver)
+	}
+	prefix := resolver.Prefix()
+	if !resolverPrefixPattern.MatchString(prefix) {
+		return fmt.Errorf("%w: prefix %q must be a letter followed by letters, digits or underscores", ErrInvalidParameterResolver, prefix)
+	}
+	if builtinReferencePrefixes[prefix] {
+		return fmt.Errorf("%w: prefix %q is a built-in reference kind", ErrInvalidParameterResolver, prefix)
+	}
+
+	ee.resolversMu.Lock()
+	defer ee.resolversMu.Unlock()
+	ee.parameterResolvers = append(ee.parameterResolvers, resolver)
+	return nil
+}
+
+// resolversFor returns the registered resolvers for a prefix in registration order
+func (ee *ExecutionEngine) resolversFor(prefix string) []ParameterResolver {
+	ee.resolversMu.RLock()
+	defer ee.resolversMu.RUnlock()
+
+	var matching []ParameterResolver
+	for _, resolver := range ee.parameterResolvers {
+		if resolver.Prefix() == prefix {
+			matching = append(matching, resolver)
+		}
+	}
+	return matching
+}
+
+// resolveCustomReference resolves one reference expression ("path | t1 | t2") with the
+// resolvers registered for its prefix. handled is false when no resolver is registered for it.
+func (ee *ExecutionEngine) resolveCustomReference(prefix, expr string, context *ParameterContext) (value interface{}, handled bool, err error) {
+	resolvers := ee.resolversFor(prefix)
+	if len(resolvers) == 0 {
+		return nil, false, nil
+	}
+	path, transforms := splitStepOutputExpression(expr)
+	for _, resolver := range resolvers {
+		value, found, err := resolver.Resolve(path, context)
+		if err != nil {
+			return nil, true, fmt.Errorf("%s reference %s: %w", prefix, path, err)
+		}
+		if found {
+			value, _, err = applyStepOutputTransforms(value, transforms, found)
+			if err != nil {
+				return nil, true, fmt.Errorf("%s reference %s: %w", prefix, path, err)
+			}
+			return value, true, nil
+		}
+	}
+	return nil, true, fmt.Errorf("%s reference %s not resolved", prefix, path)
+}
+
+// applyParameterResolvers substitutes the custom references in value. A value that is
+// exactly one custom reference returns the resolved value as-is (done is true); otherwise
+// the references are interpolated and the result is left for the built-in kinds.
+func (ee *ExecutionEngine) applyParameterResolvers(value string, context *ParameterContext) (resolved interface{}, done bool, err error) {
+	if !strings.Contains(value, "${") {
+		return value, false, nil
+	}
+
+	if matches := customReferencePattern.FindAllStringSubmatch(value, -1); len(matches) == 1 && matches[0][0] == value {
+		resolvedValue, handled, err := ee.resolveCustomReference(matches[0][1], matches[0][2], context)
+		if handled {
+			if err != nil {
+				return value, true, err
+			}
+			return resolvedValue, true, nil
+		}
+	}
+
+	var resolveErr error
+	result := customReferencePattern.ReplaceAllStringFunc(value, func(match string) string {
+		parts := customReferencePattern.FindStringSubmatch(match)
+		resolvedValue, handled, err := ee.resolveCustomReference(parts[1], parts[2], context)
+		if !handled {
+			return match
+		}
+		if err != nil {
+			if resolveErr == nil {
+				resolveErr = err
+			}
+			return match
+		}
+		return interpolationText(resolvedValue)
+	})
+	if resolveErr != nil {
+		return value, true, resolveErr
+	}
+	return result, false, nil
+}
+
+// LookupParameterResolver is a sample resolver serving references from a fixed table, e.g.
+// ${lookup.regions.eu} from {"regions": {"eu": "europe-west1"}}. Nested maps are walked one
+// dotted path element at a time.
+type LookupParameterResolver struct {
+	prefix string
+	values map[string]interface{}
+}
+
+// NewLookupParameterResolver creates a resolver for ${<prefix>.*} references into values
+func NewLookupParameterResolver(prefix string, values map[string]interface{}) *LookupParameterResolver {
+	return &LookupParameterResolver{prefix: prefix, values: values}
+}
+
+// Prefix returns the reference prefix the table is served under
+func (r *LookupParameterResolver) Prefix() string {
+	return r.prefix
+}
+
+// Resolve returns the table value at the dotted path
+func (r *LookupParameterResolver) Resolve(path string, context *ParameterContext) (interface{}, bool, error) {
+	var current interface{} = r.values
+	for _, key := range strings.Split(strings.TrimSpace(path), ".") {
+		table, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false, nil
+		}
+		if current, ok = table[key]; !ok {
+			return nil, false, nil
+		}
+	}
+	return current, true, nil
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// vaultResolver is a test resolver serving a couple of fixed secrets
+type vaultResolver struct {
+	secrets map[string]string
+	err     error
+}
+
+func (v *vaultResolver) Prefix() string { return "vault" }
+
+func (v *vaultResolver) Resolve(path string, context *ParameterContext) (interface{}, bool, error) {
+	if v.err != nil {
+		return nil, false, v.err
+	}
+	secret, ok := v.secrets[path]
+	return secret, ok, nil
+}
+
+func newResolverContext() *ParameterContext {
+	return &ParameterContext{
+		UserParameters:    map[string]interface{}{"name": "Ana"},
+		StepOutputs:       make(map[string]interface{}),
+		SystemParameters:  make(map[string]interface{}),
+		RuntimeParameters: make(map[string]interface{}),
+	}
+}
+
+func TestRegisterParameterResolverRejectsBadPrefixes(t *testing.T) {
+	ee := &ExecutionEngine{}
+
+	for _, prefix := range []string{"", "user", "steps", "system", "env", "date", "1st", "has.dot"} {
+		err := ee.RegisterParameterResolver(NewLookupParameterResolver(prefix, nil))
+		if !errors.Is(err, ErrInvalidParameterResolver) {
+			t.Errorf("prefix %q: expected ErrInvalidParameterResolver, got %v", prefix, err)
+		}
+	}
+	if err := ee.RegisterParameterResolver(nil); !errors.Is(err, ErrInvalidParameterResolver) {
+		t.Errorf("nil resolver: expected ErrInvalidParameterResolver, got %v", err)
+	}
+	if err := ee.RegisterParameterResolver(NewLookupParameterResolver("lookup", nil)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestCustomReferenceKeepsWholeValue(t *testing.T) {
+	ee := &ExecutionEngine{}
+	regions := map[string]interface{}{"eu": "europe-west1", "us": "us-central1"}
+	if err := ee.RegisterParameterResolver(NewLookupParameterResolver("lookup", map[string]interface{}{"regions": regions})); err != nil {
+		t.Fatal(err)
+	}
+
+	value, err := ee.resolveParameterValue("${lookup.regions}", newResolverContext())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, ok := value.(map[string]interface{}); !ok || got["eu"] != "europe-west1" {
+		t.Errorf("expected the regions table, got %#v", value)
+	}
+
+	value, err = ee.resolveParameterValue("${lookup.regions.us}", newResolverContext())
+	if err != nil || value != "us-central1" {
+		t.Errorf("expected us-central1, got %v (%v)", value, err)
+	}
+}
+
+func TestCustomReferenceMixedWithBuiltins(t *testing.T) {
+	ee := &ExecutionEngine{}
+	if err := ee.RegisterParameterResolver(&vaultResolver{secrets: map[string]string{"greeting": "Hello"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	value, err := ee.resolveParameterValue("${vault.greeting}, ${user.name}!", newResolverContext())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if value != "Hello, Ana!" {
+		t.Errorf("expected %q, got %q", "Hello, Ana!", value)
+	}
+}
+
+func TestCustomResolversTriedInOrder(t *testing.T) {
+	ee := &ExecutionEngine{}
+	first := &vaultResolver{secrets: map[string]string{"api_key": "first-key"}}
+	second := &vaultResolver{secrets: map[string]string{"api_key": "second-key", "bot_token": "second-token"}}
+	for _, resolver := range []ParameterResolver{first, second} {
+		if err := ee.RegisterParameterResolver(resolver); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if value, err := ee.resolveParameterValue("${vault.api_key}", newResolverContext()); err != nil || value != "first-key" {
+		t.Errorf("expected the first resolver to win, got %v (%v)", value, err)
+	}
+	if value, err := ee.resolveParameterValue("${vault.bot_token}", newResolverContext()); err != nil || value != "second-token" {
+		t.Errorf("expected a fallback to the second resolver, got %v (%v)", value, err)
+	}
+}
+
+func TestCustomReferenceErrors(t *testing.T) {
+	ee := &ExecutionEngine{}
+	if err := ee.RegisterParameterResolver(NewLookupParameterResolver("lookup", map[string]interface{}{"a": "b"})); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := ee.resolveParameterValue("Region ${lookup.missing}", newResolverContext())
+	if err == nil || !strings.Contains(err.Error(), "lookup reference missing not resolved") {
+		t.Errorf("expected an unresolved reference error, got %v", err)
+	}
+
+	failing := &ExecutionEngine{}
+	if err := failing.RegisterParameterResolver(&vaultResolver{err: errors.New("vault sealed")}); err != nil {
+		t.Fatal(err)
+	}
+	_, err = failing.resolveParameterValue("${vault.api_key}", newResolverContext())
+	if err == nil || !strings.Contains(err.Error(), "vault sealed") {
+		t.Errorf("expected the resolver error, got %v", err)
+	}
+}
+
+func TestUnregisteredPrefixLeftToBuiltins(t *testing.T) {
+	ee := &ExecutionEngine{}
+	if err := ee.RegisterParameterResolver(NewLookupParameterResolver("lookup", nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	value, err := ee.resolveParameterValue("${other.value} for ${user.name}", newResolverContext())
+	if err != nil || value != "${other.value} for Ana" {
+		t.Errorf("expected the unknown reference untouched, got %v (%v)", value, err)
+	}
+}
+
+func TestCustomReferenceTransforms(t *testing.T) {
+	ee := &ExecutionEngine{}
+	values := map[string]interface{}{"regions": []interface{}{"europe-west1", "us-central1"}}
+	if err := ee.RegisterParameterResolver(NewLookupParameterResolver("lookup", values)); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		value string
+		want  interface{}
+	}{
+		{"${lookup.regions | first}", "europe-west1"},
+		{"${lookup.regions | length}", 2},
+		{"Regions: ${lookup.regions | join(\", \")}", "Regions: europe-west1, us-central1"},
+	}
+	for _, tt := range tests {
+		value, err := ee.resolveParameterValue(tt.value, newResolverContext())
+		if err != nil || value != tt.want {
+			t.Errorf("%s: expected %v, got %v (%v)", tt.value, tt.want, value, err)
+		}
+	}
+
+	_, err := ee.resolveParameterValue("${lookup.regions | shout}", newResolverContext())
+	if err == nil || !strings.Contains(err.Error(), `transform "shout"`) {
+		t.Errorf("expected an unknown transform error, got %v", err)
+	}
+}
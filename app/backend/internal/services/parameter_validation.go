@@ -0,0 +1,67 @@
+package services
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"regexp"
+)
+
+// phonePattern accepts international and formatted phone numbers, e.g. "+1 (555) 123-4567"
+var phonePattern = regexp.MustCompile(`^\+?[0-9][0-9\s\-().]{5,19}$`)
+
+// validateUserParameters checks user parameter values against their declared validation
+// rules and returns an error message per invalid parameter
+func validateUserParameters(rules map[string]string, params map[string]interface{}) map[string]string {
+	fieldErrors := make(map[string]string)
+	for paramName, rule := range rules {
+		value, exists := params[paramName]
+		if !exists || value == nil {
+			continue
+		}
+		if err := validateUserParameterValue(rule, value); err != nil {
+			fieldErrors[paramName] = err.Error()
+		}
+	}
+	return fieldErrors
+}
+
+// validateUserParameterValue checks a value against a rule: "email", "url", "phone" or a
+// regular expression. List values are validated element by element.
+func validateUserParameterValue(rule string, value interface{}) error {
+	if list, err := asList(value); err == nil {
+		for i, item := range list {
+			if err := validateUserParameterValue(rule, item); err != nil {
+				return fmt.Errorf("item %d: %w", i, err)
+			}
+		}
+		return nil
+	}
+
+	str := fmt.Sprintf("%v", value)
+	switch rule {
+	case "email":
+		address, err := mail.ParseAddress(str)
+		if err != nil || address.Address != str {
+			return fmt.Errorf("%q is not a valid email address", str)
+		}
+	case "url":
+		parsed, err := url.ParseRequestURI(str)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("%q is not a valid http(s) URL", str)
+		}
+	case "phone":
+		if !phonePattern.MatchString(str) {
+			return fmt.Errorf("%q is not a valid phone number", str)
+		}
+	default:
+		pattern, err := regexp.Compile(rule)
+		if err != nil {
+			return fmt.Errorf("invalid validation pattern %q: %v", rule, err)
+		}
+		if !pattern.MatchString(str) {
+			return fmt.Errorf("%q does not match pattern %s", str, rule)
+		}
+	}
+	return nil
+}
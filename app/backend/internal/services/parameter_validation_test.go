@@ -0,0 +1,49 @@
+package services
+
+import (
+	"testing"
+)
+
+// TestValidateUserParameters verifies email, url, regex and list validation rules
+func TestValidateUserParameters(t *testing.T) {
+	rules := map[string]string{
+		"recipient":   "email",
+		"attendees":   "email",
+		"website":     "url",
+		"standup_day": `^\d{4}-\d{2}-\d{2}$`,
+		"optional":    "email",
+	}
+
+	valid := map[string]interface{}{
+		"recipient":   "team@example.com",
+		"attendees":   []interface{}{"a@example.com", "b@example.com"},
+		"website":     "https://example.com/report",
+		"standup_day": "2024-03-15",
+	}
+	if fieldErrors := validateUserParameters(rules, valid); len(fieldErrors) != 0 {
+		t.Errorf("Expected no errors, got %v", fieldErrors)
+	}
+
+	invalid := map[string]interface{}{
+		"recipient":   "Team <team@example.com>",
+		"attendees":   []interface{}{"a@example.com", "not-an-email"},
+		"website":     "ftp://example.com",
+		"standup_day": "15/03/2024",
+	}
+	fieldErrors := validateUserParameters(rules, invalid)
+	for _, field := range []string{"recipient", "attendees", "website", "standup_day"} {
+		if _, exists := fieldErrors[field]; !exists {
+			t.Errorf("Expected error for %s, got %v", field, fieldErrors)
+		}
+	}
+	if _, exists := fieldErrors["optional"]; exists {
+		t.Error("Expected omitted parameter to be skipped")
+	}
+
+	if err := validateUserParameterValue("[unclosed", "value"); err == nil {
+		t.Error("Expected error for invalid regex rule")
+	}
+	if err := validateUserParameterValue("phone", "+1 (555) 123-4567"); err != nil {
+		t.Errorf("Expected valid phone number, got %v", err)
+	}
+}
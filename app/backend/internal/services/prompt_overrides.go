@@ -0,0 +1,76 @@
+package services
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// defaultPromptsDir is where dotprompt files are read from unless PROMPTS_DIR is set
+const defaultPromptsDir = "prompts"
+
+// validTenantID limits tenant ids used in prompt file names to safe characters
+var validTenantID = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// promptsDirFromEnv returns PROMPTS_DIR, or the default prompts directory when unset
+func promptsDirFromEnv() string {
+	if dir := os.Getenv("PROMPTS_DIR"); dir != "" {
+		return dir
+	}
+	return defaultPromptsDir
+}
+
+// promptPath returns the dotprompt file for promptName. A tenant's override is named
+// <prompt>.<tenant>.prompt (a dotprompt variant) next to the default; when it doesn't exist,
+// or tenantID is empty or invalid, the default <prompt>.prompt is used.
+func (g *GenkitService) promptPath(promptName, tenantID string) (path string, tenantSpecific bool) {
+	if tenantID != "" && validTenantID.MatchString(tenantID) {
+		tenantPath := filepath.Join(g.promptsDir, promptName+"."+tenantID+".prompt")
+		if _, err := os.Stat(tenantPath); err == nil {
+			return tenantPath, true
+		}
+	}
+	return filepath.Join(g.promptsDir, promptName+".prompt"), false
+}
+
+// promptForTenant returns the tenant's override of a flow's prompt, loading it on first use,
+// or the preloaded default prompt when the tenant has none. Failed or absent overrides are
+// remembered until ReloadPrompts; an override loaded while a reload ran isn't cached.
+func (g *GenkitService) promptForTenant(promptName, tenantID string, defaultPrompt *interface{}) interface{} {
+	if tenantID == "" {
+		return g.currentPrompt(defaultPrompt)
+	}
+
+	key := promptName + "/" + tenantID
+	g.promptsMutex.RLock()
+	prompt, cached := g.tenantPrompts[key]
+	generation := g.tenantPromptsGeneration
+	g.promptsMutex.RUnlock()
+
+	if !cached {
+		if _, tenantSpecific := g.promptPath(promptName, tenantID); tenantSpecific {
+			loaded, err := g.loadPrompt(promptName, tenantID)
+			if err != nil {
+				log.Printf("[GenkitService] Warning: Failed to load %s prompt for tenant %s, using default: %v", promptName, tenantID, err)
+			} else {
+				log.Printf("[GenkitService] Loaded %s prompt override for tenant %s", promptName, tenantID)
+				prompt = loaded
+			}
+		}
+
+		g.promptsMutex.Lock()
+		if existing, stored := g.tenantPrompts[key]; stored {
+			// A concurrent request cached the override first; keep a single version in use
+			prompt = existing
+		} else if generation == g.tenantPromptsGeneration {
+			g.tenantPrompts[key] = prompt
+		}
+		g.promptsMutex.Unlock()
+	}
+
+	if prompt != nil {
+		return prompt
+	}
+	return g.currentPrompt(defaultPrompt)
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestPromptPathTenantOverride verifies tenant prompt files are preferred and fall back to the default
+func TestPromptPathTenantOverride(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"intent_analyst.prompt", "intent_analyst.acme.prompt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("---\n---\nprompt"), 0644); err != nil {
+			t.Fatalf("Failed to write prompt: %v", err)
+		}
+	}
+	g := &GenkitService{promptsDir: dir, tenantPrompts: make(map[string]interface{})}
+
+	testCases := []struct {
+		tenantID       string
+		expected       string
+		tenantSpecific bool
+	}{
+		{"acme", "intent_analyst.acme.prompt", true},
+		{"globex", "intent_analyst.prompt", false},
+		{"", "intent_analyst.prompt", false},
+		{"../acme", "intent_analyst.prompt", false},
+	}
+	for _, tc := range testCases {
+		path, tenantSpecific := g.promptPath("intent_analyst", tc.tenantID)
+		if path != filepath.Join(dir, tc.expected) || tenantSpecific != tc.tenantSpecific {
+			t.Errorf("Tenant %q: expected %s (tenant specific %t), got %s (%t)", tc.tenantID, tc.expected, tc.tenantSpecific, path, tenantSpecific)
+		}
+	}
+
+	// A tenant without an override gets the preloaded default
+	var defaultPrompt interface{} = "default"
+	if prompt := g.promptForTenant("intent_analyst", "globex", &defaultPrompt); prompt != "default" {
+		t.Errorf("Expected the default prompt for a tenant without override, got %v", prompt)
+	}
+	if _, cached := g.tenantPrompts["intent_analyst/globex"]; !cached {
+		t.Error("Expected the missing override to be remembered")
+	}
+}
+
+// TestPromptForTenantCachesUniqueNames verifies tenant overrides are registered under their
+// own versioned names, cached until a reload and registered again under a new name after it
+func TestPromptForTenantCachesUniqueNames(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"intent_analyst.prompt", "intent_analyst.acme.prompt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("---\n---\nprompt"), 0644); err != nil {
+			t.Fatalf("Failed to write prompt: %v", err)
+		}
+	}
+	registered := make(map[string]int)
+	g := &GenkitService{promptsDir: dir, tenantPrompts: make(map[string]interface{})}
+	g.promptRegistrar = func(path, name string) (interface{}, error) {
+		registered[name]++
+		return name, nil
+	}
+
+	var defaultPrompt interface{} = "default"
+	first := g.promptForTenant("intent_analyst", "acme", &defaultPrompt)
+	if name, _ := first.(string); !strings.HasPrefix(name, "intent_analyst.acme.v") {
+		t.Fatalf("Expected a versioned tenant prompt name, got %v", first)
+	}
+	if again := g.promptForTenant("intent_analyst", "acme", &defaultPrompt); again != first {
+		t.Errorf("Expected the cached override %v, got %v", first, again)
+	}
+
+	g.ReloadPrompts()
+	reloaded := g.promptForTenant("intent_analyst", "acme", &defaultPrompt)
+	if reloaded == first {
+		t.Errorf("Expected the override to be loaded again after a reload, got %v", reloaded)
+	}
+	for name, count := range registered {
+		if count > 1 {
+			t.Errorf("Prompt name %s registered %d times", name, count)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newReloadTestService returns a GenkitService whose prompt loads are recorded instead of
+// registered with Genkit; loads of paths in failing return an error
+func newReloadTestService(t *testing.T, failing map[string]bool) (*GenkitService, *[]string) {
+	dir := t.TempDir()
+	for _, name := range []string{"intent_analyst.prompt", "workflow_generator.prompt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("---\n---\nprompt"), 0644); err != nil {
+			t.Fatalf("Failed to write prompt: %v", err)
+		}
+	}
+
+	registered := []string{}
+	g := &GenkitService{promptsDir: dir, tenantPrompts: make(map[string]interface{})}
+	g.promptRegistrar = func(path, name string) (interface{}, error) {
+		if failing[filepath.Base(path)] {
+			return nil, fmt.Errorf("invalid front matter")
+		}
+		registered = append(registered, name)
+		return name, nil
+	}
+	return g, &registered
+}
+
+// TestReloadPromptsRegistersUniqueNames verifies repeated reloads never register a prompt name twice
+func TestReloadPromptsRegistersUniqueNames(t *testing.T) {
+	g, registered := newReloadTestService(t, nil)
+
+	for i := 0; i < 3; i++ {
+		for _, result := range g.ReloadPrompts() {
+			if !result.Reloaded {
+				t.Fatalf("Expected %s to reload, got error %s", result.Name, result.Error)
+			}
+		}
+	}
+
+	if len(*registered) != 6 {
+		t.Fatalf("Expected 6 registrations, got %v", *registered)
+	}
+	seen := make(map[string]bool)
+	for _, name := range *registered {
+		if seen[name] {
+			t.Errorf("Prompt name %s registered twice", name)
+		}
+		seen[name] = true
+	}
+
+	if prompt := g.currentPrompt(&g.intentAnalystPrompt); prompt != (*registered)[4] {
+		t.Errorf("Expected the latest intent_analyst version to be active, got %v", prompt)
+	}
+}
+
+// TestReloadPromptsKeepsPreviousOnFailure verifies a prompt that fails to reload keeps its
+// previous version while the others are swapped in, and tenant overrides are dropped
+func TestReloadPromptsKeepsPreviousOnFailure(t *testing.T) {
+	failing := make(map[string]bool)
+	g, _ := newReloadTestService(t, failing)
+	g.ReloadPrompts()
+	previousAnalyst := g.currentPrompt(&g.intentAnalystPrompt)
+	previousGenerator := g.currentPrompt(&g.workflowGeneratorPrompt)
+	g.tenantPrompts["intent_analyst/acme"] = "tenant"
+
+	failing["intent_analyst.prompt"] = true
+	results := g.ReloadPrompts()
+
+	for _, result := range results {
+		switch result.Name {
+		case "intent_analyst":
+			if result.Reloaded || result.Error == "" {
+				t.Errorf("Expected intent_analyst reload to fail, got %+v", result)
+			}
+		case "workflow_generator":
+			if !result.Reloaded {
+				t.Errorf("Expected workflow_generator to reload, got %+v", result)
+			}
+		}
+	}
+	if prompt := g.currentPrompt(&g.intentAnalystPrompt); prompt != previousAnalyst {
+		t.Errorf("Expected intent_analyst to keep %v, got %v", previousAnalyst, prompt)
+	}
+	if prompt := g.currentPrompt(&g.workflowGeneratorPrompt); prompt == previousGenerator {
+		t.Error("Expected workflow_generator to be swapped for the new version")
+	}
+	if len(g.tenantPrompts) != 0 {
+		t.Errorf("Expected tenant overrides to be dropped, got %v", g.tenantPrompts)
+	}
+}
@@ -29,7 +29,7 @@ func TestPromptSchemaValidation(t *testing.T) {
 			t.Logf("Testing prompt: %s", promptName)
 			
 			// Load prompt
-			prompt, err := genkitService.loadPrompt(promptName)
+			prompt, err := genkitService.loadPrompt(promptName, "")
 			if err != nil {
 				t.Fatalf("Failed to load %s prompt: %v", promptName, err)
 			}
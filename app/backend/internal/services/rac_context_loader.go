@@ -0,0 +1,146 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/parser"
+)
+
+// RaCContextSpec describes a RaC context file an agent passes to its prompt
+type RaCContextSpec struct {
+	Name             string   // cache key, e.g. "intent_analyst"
+	RelativePath     string   // path under the RaC base directory
+	RequiredElements []string // markers the file must contain
+}
+
+// DefaultRaCContexts are the RaC files the agents read at flow time
+var DefaultRaCContexts = []RaCContextSpec{
+	{
+		Name:             "intent_analyst",
+		RelativePath:     filepath.Join("agents", "intent_analyst.cue"),
+		RequiredElements: []string{"IntentAnalystAgent:"},
+	},
+	{
+		Name:             "workflow_prompt",
+		RelativePath:     filepath.Join("agents", "prompts", "workflow-prompt.cue"),
+		RequiredElements: []string{"ParameterReferenceSpec:", "WorkflowGenerationStates:", "WorkflowGenerationEvents:"},
+	},
+}
+
+// RaCContextLoader caches validated RaC context files and supports reloading them
+type RaCContextLoader struct {
+	basePath string
+	specs    []RaCContextSpec
+	contexts map[string]string
+	mutex    sync.RWMutex
+}
+
+// NewRaCContextLoader creates a loader rooted at basePath (RAC_CONTEXT_PATH, default "rac")
+func NewRaCContextLoader(basePath string, specs []RaCContextSpec) *RaCContextLoader {
+	if basePath == "" {
+		basePath = "rac"
+	}
+	return &RaCContextLoader{
+		basePath: basePath,
+		specs:    specs,
+		contexts: make(map[string]string),
+	}
+}
+
+// Load reads and validates every RaC context file. The cache is only replaced when all
+// files are valid, so a failed reload keeps serving the previous contexts.
+func (l *RaCContextLoader) Load() error {
+	loaded := make(map[string]string, len(l.specs))
+	var failures []string
+
+	for _, spec := range l.specs {
+		content, err := l.loadSpec(spec)
+		if err != nil {
+			failures = append(failures, err.Error())
+			continue
+		}
+		loaded[spec.Name] = content
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("invalid RaC context: %s", strings.Join(failures, "; "))
+	}
+
+	l.mutex.Lock()
+	l.contexts = loaded
+	l.mutex.Unlock()
+
+	log.Printf("[RaCContextLoader] Loaded %d RaC context files from %s", len(loaded), l.basePath)
+	return nil
+}
+
+// Get returns a cached RaC context, loading it on first use
+func (l *RaCContextLoader) Get(name string) (string, error) {
+	l.mutex.RLock()
+	content, exists := l.contexts[name]
+	l.mutex.RUnlock()
+	if exists {
+		return content, nil
+	}
+
+	for _, spec := range l.specs {
+		if spec.Name != name {
+			continue
+		}
+		content, err := l.loadSpec(spec)
+		if err != nil {
+			return "", err
+		}
+		l.mutex.Lock()
+		l.contexts[name] = content
+		l.mutex.Unlock()
+		return content, nil
+	}
+	return "", fmt.Errorf("unknown RaC context %q", name)
+}
+
+// loadSpec reads and validates a single RaC context file
+func (l *RaCContextLoader) loadSpec(spec RaCContextSpec) (string, error) {
+	path := filepath.Join(l.basePath, spec.RelativePath)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("%s: failed to read %s: %w", spec.Name, path, err)
+	}
+	if err := ValidateRaCContext(path, string(content), spec.RequiredElements); err != nil {
+		return "", fmt.Errorf("%s: %w", spec.Name, err)
+	}
+	return string(content), nil
+}
+
+// ValidateRaCContext checks that RaC content parses as CUE, compiles when it is
+// self-contained (files with imports are only syntax-checked), and contains the
+// required elements
+func ValidateRaCContext(filename, content string, requiredElements []string) error {
+	file, err := parser.ParseFile(filename, content)
+	if err != nil {
+		return fmt.Errorf("%s does not parse as CUE: %w", filename, err)
+	}
+
+	if len(file.Imports) == 0 {
+		if err := cuecontext.New().CompileString(content).Validate(); err != nil {
+			return fmt.Errorf("%s does not compile: %w", filename, err)
+		}
+	}
+
+	var missing []string
+	for _, element := range requiredElements {
+		if !strings.Contains(content, element) {
+			missing = append(missing, element)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%s is missing expected elements: %s", filename, strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeRaCFile writes a RaC context file under dir for the loader tests
+func writeRaCFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+}
+
+// TestRaCContextLoaderValidation verifies invalid RaC files fail the load
+func TestRaCContextLoaderValidation(t *testing.T) {
+	specs := []RaCContextSpec{{Name: "agent", RelativePath: "agent.cue", RequiredElements: []string{"Agent:"}}}
+
+	tests := []struct {
+		name        string
+		content     string
+		expectError string
+	}{
+		{"valid", "Agent: {\n\tname: \"test\"\n}\n", ""},
+		{"syntax error", "Agent: {\n\tname: \n", "does not parse"},
+		{"conflicting values", "Agent: {\n\tname: \"a\"\n\tname: \"b\"\n}\n", "does not compile"},
+		{"missing element", "Other: {\n\tname: \"test\"\n}\n", "missing expected elements"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeRaCFile(t, dir, "agent.cue", tt.content)
+
+			err := NewRaCContextLoader(dir, specs).Load()
+			if tt.expectError == "" {
+				if err != nil {
+					t.Fatalf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+				t.Errorf("Expected error containing %q, got %v", tt.expectError, err)
+			}
+		})
+	}
+
+	if err := NewRaCContextLoader(t.TempDir(), specs).Load(); err == nil {
+		t.Error("Expected error for missing RaC file")
+	}
+}
+
+// TestRaCContextLoaderReload verifies reloads swap in valid content and keep the old content otherwise
+func TestRaCContextLoaderReload(t *testing.T) {
+	dir := t.TempDir()
+	specs := []RaCContextSpec{{Name: "agent", RelativePath: "agent.cue", RequiredElements: []string{"Agent:"}}}
+	loader := NewRaCContextLoader(dir, specs)
+
+	writeRaCFile(t, dir, "agent.cue", "Agent: version: \"1\"\n")
+	if err := loader.Load(); err != nil {
+		t.Fatalf("Initial load failed: %v", err)
+	}
+
+	writeRaCFile(t, dir, "agent.cue", "Agent: version: \"2\"\n")
+	if err := loader.Load(); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if content, _ := loader.Get("agent"); !strings.Contains(content, `"2"`) {
+		t.Errorf("Expected reloaded content, got %q", content)
+	}
+
+	writeRaCFile(t, dir, "agent.cue", "Agent: {\n")
+	if err := loader.Load(); err == nil {
+		t.Fatal("Expected reload of invalid file to fail")
+	}
+	if content, _ := loader.Get("agent"); !strings.Contains(content, `"2"`) {
+		t.Errorf("Expected previous content after failed reload, got %q", content)
+	}
+
+	if _, err := loader.Get("unknown"); err == nil {
+		t.Error("Expected error for unknown context")
+	}
+}
+
+// TestDefaultRaCContextsAreValid validates the repository's RaC context files
+func TestDefaultRaCContextsAreValid(t *testing.T) {
+	racPath := filepath.Join("..", "..", "..", "..", "rac")
+	if _, err := os.Stat(racPath); err != nil {
+		t.Skipf("RaC directory not found at %s", racPath)
+	}
+	if err := NewRaCContextLoader(racPath, DefaultRaCContexts).Load(); err != nil {
+		t.Errorf("Repository RaC contexts are invalid: %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"sohoaas-backend/internal/workflowparser"
+)
+
+// ErrSecretNotFound is returned when a service binding references a secret the store doesn't have
+var ErrSecretNotFound = errors.New("secret not found")
+
+// AuthTypeAPIKey is the service binding auth type that authenticates with a named secret
+const AuthTypeAPIKey = workflowparser.AuthTypeAPIKey
+
+// DefaultSecretsEnvPrefix prefixes the environment variables EnvSecretStore reads
+const DefaultSecretsEnvPrefix = "SECRET_"
+
+// SecretStore resolves the named secrets (API keys, bot tokens) that service bindings reference
+// with auth.secret_ref. Secrets belong to a user, so a workflow can only read its runner's
+// secrets. They are looked up at execution and never written to workflows.
+type SecretStore interface {
+	GetSecret(userID, name string) (string, error)
+}
+
+// EnvSecretStore reads secrets from environment variables: user abc123's secret
+// "slack_bot_token" is read from <prefix>abc123__SLACK_BOT_TOKEN. Characters of the user ID
+// other than letters and digits are replaced by "_".
+type EnvSecretStore struct {
+	prefix string
+}
+
+// NewEnvSecretStore creates a store reading <prefix><USER>__<NAME> variables; an empty prefix
+// uses DefaultSecretsEnvPrefix
+func NewEnvSecretStore(prefix string) *EnvSecretStore {
+	if prefix == "" {
+		prefix = DefaultSecretsEnvPrefix
+	}
+	return &EnvSecretStore{prefix: prefix}
+}
+
+// GetSecret returns the value of the user's named secret
+func (s *EnvSecretStore) GetSecret(userID, name string) (string, error) {
+	if err := ValidateSecretRef(name); err != nil {
+		return "", err
+	}
+	if userID == "" {
+		return "", fmt.Errorf("%w: %s (no user)", ErrSecretNotFound, name)
+	}
+	value := os.Getenv(s.prefix + secretUserKey(userID) + "__" + strings.ToUpper(name))
+	if value == "" {
+		return "", fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+	}
+	return value, nil
+}
+
+// secretUserKey is the user ID as used in a secret's variable name
+func secretUserKey(userID string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, userID)
+}
+
+// ValidateSecretRef checks that a secret name uses only letters, digits and underscores
+func ValidateSecretRef(name string) error {
+	return workflowparser.ValidateSecretRef(name)
+}
+
+// SetSecretStore enables service bindings that authenticate with a named secret
+func (ee *ExecutionEngine) SetSecretStore(store SecretStore) {
+	ee.secretStore = store
+}
+
+// validateStepSecrets reports steps whose binding references a secret the user doesn't have
+func (ee *ExecutionEngine) validateStepSecrets(userID string, steps []ResolvedStep) []string {
+	var validationErrors []string
+	for _, step := range steps {
+		if step.SecretRef == "" {
+			continue
+		}
+		if _, err := ee.stepSecret(userID, &step); err != nil {
+			validationErrors = append(validationErrors, fmt.Sprintf("Step %s: %v", step.ID, err))
+		}
+	}
+	return validationErrors
+}
+
+// stepSecret resolves the user's secret a step's service binding authenticates with
+func (ee *ExecutionEngine) stepSecret(userID string, step *ResolvedStep) (string, error) {
+	if ee.secretStore == nil {
+		return "", fmt.Errorf("secret %q requested but no secret store is configured", step.SecretRef)
+	}
+	secret, err := ee.secretStore.GetSecret(userID, step.SecretRef)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve secret %q: %w", step.SecretRef, err)
+	}
+	return secret, nil
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// mapSecretStore is an in-memory SecretStore for tests, keyed by "<user>/<name>"
+type mapSecretStore map[string]string
+
+func (s mapSecretStore) GetSecret(userID, name string) (string, error) {
+	if value, exists := s[userID+"/"+name]; exists {
+		return value, nil
+	}
+	return "", ErrSecretNotFound
+}
+
+// TestEnvSecretStore verifies secrets are read from per-user, prefixed environment variables
+func TestEnvSecretStore(t *testing.T) {
+	t.Setenv("TEST_SECRET_user1__SLACK_BOT_TOKEN", "xoxb-123")
+	t.Setenv("TEST_SECRET_service_account_ci__SLACK_BOT_TOKEN", "xoxb-ci")
+	store := NewEnvSecretStore("TEST_SECRET_")
+
+	secret, err := store.GetSecret("user1", "slack_bot_token")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if secret != "xoxb-123" {
+		t.Errorf("Expected the secret value, got %q", secret)
+	}
+	if secret, err := store.GetSecret("service-account:ci", "slack_bot_token"); err != nil || secret != "xoxb-ci" {
+		t.Errorf("Expected the service account's secret, got %q (%v)", secret, err)
+	}
+
+	// Another user can't read the secret by name
+	for _, userID := range []string{"user2", "USER1", ""} {
+		if _, err := store.GetSecret(userID, "slack_bot_token"); !errors.Is(err, ErrSecretNotFound) {
+			t.Errorf("User %q: expected ErrSecretNotFound, got %v", userID, err)
+		}
+	}
+	if _, err := store.GetSecret("user1", "missing_token"); !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("Expected ErrSecretNotFound, got %v", err)
+	}
+	if _, err := store.GetSecret("user1", "../etc"); err == nil {
+		t.Error("Expected an invalid secret name to be rejected")
+	}
+}
+
+// TestParseCUEWorkflowSecretRef verifies api_key bindings give their steps a secret reference
+func TestParseCUEWorkflowSecretRef(t *testing.T) {
+	ee := NewExecutionEngine(nil)
+	workflowWithAuth := func(auth string) string {
+		return `workflow: {
+	name:        "notify"
+	description: "Post to Slack"
+	steps: [{
+		id:     "post"
+		name:   "Post message"
+		action: "slack.post_message"
+		inputs: {channel: "#general", text: "Done"}
+	}]
+	service_bindings: slack: {
+		type: "mcp_service"
+		auth: ` + auth + `
+	}
+}`
+	}
+
+	workflow, err := ee.ParseCUEWorkflow(workflowWithAuth(`{type: "api_key", secret_ref: "slack_bot_token"}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if workflow.Steps[0].SecretRef != "slack_bot_token" {
+		t.Errorf("Expected secret_ref slack_bot_token, got %q", workflow.Steps[0].SecretRef)
+	}
+
+	if _, err := ee.ParseCUEWorkflow(workflowWithAuth(`{type: "oauth2", secret_ref: "slack_bot_token"}`)); err == nil {
+		t.Error("Expected secret_ref on an oauth2 binding to be rejected")
+	}
+	if _, err := ee.ParseCUEWorkflow(workflowWithAuth(`{type: "api_key", secret_ref: "bad name"}`)); err == nil {
+		t.Error("Expected an invalid secret_ref to be rejected")
+	}
+}
+
+// TestStepSecretInjection verifies a step with a secret_ref authenticates with the secret
+func TestStepSecretInjection(t *testing.T) {
+	ee := NewExecutionEngine(nil)
+	step := &ResolvedStep{ID: "post", Service: "slack", Action: "post_message", SecretRef: "slack_bot_token"}
+	paramContext := &ParameterContext{SystemParameters: map[string]interface{}{"oauth_token": "google-token", "user_id": "user-1"}}
+
+	if errs := ee.validateStepSecrets("user-1", []ResolvedStep{*step}); len(errs) != 1 {
+		t.Errorf("Expected a validation error without a secret store, got %v", errs)
+	}
+
+	ee.SetSecretStore(mapSecretStore{"user-1/slack_bot_token": "xoxb-123"})
+	if errs := ee.validateStepSecrets("user-1", []ResolvedStep{*step}); len(errs) != 0 {
+		t.Errorf("Expected no validation errors, got %v", errs)
+	}
+	if errs := ee.validateStepSecrets("user-2", []ResolvedStep{*step}); len(errs) != 1 {
+		t.Errorf("Expected another user's secret to be unavailable, got %v", errs)
+	}
+	token, err := ee.stepOAuthToken(step, paramContext)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if token != "xoxb-123" {
+		t.Errorf("Expected the secret to replace the OAuth token, got %q", token)
+	}
+
+	// Steps without a secret_ref keep using the execution's OAuth token
+	if token, _ := ee.stepOAuthToken(&ResolvedStep{ID: "send"}, paramContext); token != "google-token" {
+		t.Errorf("Expected the OAuth token, got %q", token)
+	}
+
+	errs := ee.validateStepSecrets("user-1", []ResolvedStep{{ID: "post", SecretRef: "other_token"}})
+	if len(errs) != 1 || !strings.Contains(errs[0], "other_token") {
+		t.Errorf("Expected a validation error naming the missing secret, got %v", errs)
+	}
+}
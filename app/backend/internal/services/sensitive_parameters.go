@@ -0,0 +1,264 @@
+package services
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// encryptedParameterPrefix marks a user parameter value encrypted by a ParameterCipher
+const encryptedParameterPrefix = "enc:v1:"
+
+// minMaskedValueLength is the shortest sensitive value masked wherever it appears; shorter
+// values would mask unrelated text and are only masked by field name
+const minMaskedValueLength = 4
+
+// ErrParameterDecryption is returned for encrypted parameter values that can't be decrypted,
+// e.g. when they were encrypted under another key
+var ErrParameterDecryption = errors.New("failed to decrypt parameter")
+
+// ParameterCipher encrypts the values of sensitive user parameters the engine keeps after an
+// execution (AES-256-GCM with a key derived from a configured secret)
+type ParameterCipher struct {
+	aead cipher.AEAD
+}
+
+// NewParameterCipher creates a cipher keyed by the SHA-256 of secret. An empty secret uses a
+// random key, so encrypted values can't be read after a restart.
+func NewParameterCipher(secret string) (*ParameterCipher, error) {
+	var key [32]byte
+	if secret == "" {
+		if _, err := rand.Read(key[:]); err != nil {
+			return nil, fmt.Errorf("failed to generate parameter encryption key: %w", err)
+		}
+	} else {
+		key = sha256.Sum256([]byte(secret))
+	}
+
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create parameter cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create parameter cipher: %w", err)
+	}
+	return &ParameterCipher{aead: aead}, nil
+}
+
+// Encrypt returns value, JSON encoded and encrypted, as an "enc:v1:" string
+func (c *ParameterCipher) Encrypt(value interface{}) (string, error) {
+	plaintext, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode parameter: %w", err)
+	}
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := c.aead.Seal(nonce, nonce, plaintext, nil)
+	return encryptedParameterPrefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt returns the value an Encrypt string holds
+func (c *ParameterCipher) Decrypt(encrypted string) (interface{}, error) {
+	if !IsEncryptedParameter(encrypted) {
+		return nil, fmt.Errorf("%w: not an encrypted value", ErrParameterDecryption)
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(encrypted, encryptedParameterPrefix))
+	if err != nil || len(sealed) < c.aead.NonceSize() {
+		return nil, fmt.Errorf("%w: malformed value", ErrParameterDecryption)
+	}
+	nonce, ciphertext := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	plaintext, err := c.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrParameterDecryption, err)
+	}
+	var value interface{}
+	if err := json.Unmarshal(plaintext, &value); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrParameterDecryption, err)
+	}
+	return value, nil
+}
+
+// IsEncryptedParameter reports whether value is a string produced by ParameterCipher.Encrypt
+func IsEncryptedParameter(value interface{}) bool {
+	text, ok := value.(string)
+	return ok && strings.HasPrefix(text, encryptedParameterPrefix)
+}
+
+// SetParameterCipher enables keeping sensitive parameters of failed executions encrypted, so
+// they can be replayed; without a cipher they are dropped and must be entered again
+func (ee *ExecutionEngine) SetParameterCipher(c *ParameterCipher) {
+	ee.parameterCipher = c
+}
+
+// ProtectFailedExecution encrypts, or without a cipher removes, the sensitive user parameters
+// of a dead-letter record and masks their values in its error, before the record is stored
+func (ee *ExecutionEngine) ProtectFailedExecution(entry *FailedExecution) {
+	if entry == nil || entry.plan == nil {
+		return
+	}
+	entry.Error = maskSensitiveValues(entry.Error, entry.plan.sensitiveValues()).(string)
+	ee.protectSensitiveParameters(entry.UserParameters, entry.plan.SensitiveParameters)
+}
+
+// protectSensitiveParameters encrypts, or without a cipher removes, the sensitive entries of
+// parameters in place
+func (ee *ExecutionEngine) protectSensitiveParameters(parameters map[string]interface{}, sensitive []string) {
+	for _, name := range sensitive {
+		value, exists := parameters[name]
+		if !exists || IsEncryptedParameter(value) {
+			continue
+		}
+		delete(parameters, name)
+		if ee.parameterCipher == nil {
+			continue
+		}
+		encrypted, err := ee.parameterCipher.Encrypt(value)
+		if err != nil {
+			log.Printf("[ExecutionEngine] WARNING: Dropping sensitive parameter %s: %v", name, err)
+			continue
+		}
+		parameters[name] = encrypted
+	}
+}
+
+// ReplayParameters returns a failed execution's user parameters with sensitive values
+// decrypted, to prepare its replay
+func (ee *ExecutionEngine) ReplayParameters(failed *FailedExecution) (map[string]interface{}, error) {
+	parameters := copyMap(failed.UserParameters)
+	for name, value := range parameters {
+		if !IsEncryptedParameter(value) {
+			continue
+		}
+		if ee.parameterCipher == nil {
+			return nil, fmt.Errorf("%w %s: no parameter cipher configured", ErrParameterDecryption, name)
+		}
+		decrypted, err := ee.parameterCipher.Decrypt(value.(string))
+		if err != nil {
+			return nil, fmt.Errorf("parameter %s: %w", name, err)
+		}
+		parameters[name] = decrypted
+	}
+	return parameters, nil
+}
+
+// sensitiveValues returns the text of the plan's sensitive user parameter values (strings,
+// numbers and the leaves of lists and objects), longest first so overlapping values are
+// masked whole
+func (p *ExecutionPlan) sensitiveValues() []string {
+	if p == nil || p.ParameterContext == nil || len(p.SensitiveParameters) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var values []string
+	var collect func(value interface{})
+	collect = func(value interface{}) {
+		switch typed := value.(type) {
+		case nil, bool:
+		case map[string]interface{}:
+			for _, item := range typed {
+				collect(item)
+			}
+		case []interface{}:
+			for _, item := range typed {
+				collect(item)
+			}
+		default:
+			text := fmt.Sprint(typed)
+			if len(text) >= minMaskedValueLength && !seen[text] {
+				seen[text] = true
+				values = append(values, text)
+			}
+		}
+	}
+	for _, name := range p.SensitiveParameters {
+		collect(p.ParameterContext.UserParameters[name])
+	}
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+	return values
+}
+
+// Redact returns a copy of value safe to log or return for this plan: sensitive fields are
+// masked by name and the plan's sensitive parameter values wherever they appear
+func (p *ExecutionPlan) Redact(value interface{}) interface{} {
+	return maskSensitiveValues(redactForLog(value), p.sensitiveValues())
+}
+
+// RedactError returns the message of err with the plan's sensitive parameter values masked,
+// or "" for a nil error; use it wherever an execution error leaves the backend
+func (p *ExecutionPlan) RedactError(err error) string {
+	if err == nil {
+		return ""
+	}
+	return p.Redact(err.Error()).(string)
+}
+
+// MarshalJSON encodes the plan with its sensitive user parameter values masked wherever they
+// appear: parameter context, resolved step inputs and outputs, simulation log and errors
+func (p ExecutionPlan) MarshalJSON() ([]byte, error) {
+	type plainPlan ExecutionPlan
+	data, err := json.Marshal(plainPlan(p))
+	values := p.sensitiveValues()
+	if err != nil || len(values) == 0 {
+		return data, err
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, err
+	}
+	return json.Marshal(maskSensitiveValues(decoded, values))
+}
+
+// maskSensitiveValues returns a copy of value with every occurrence of the secrets inside its
+// strings replaced by redactedLogValue. The value itself is never modified.
+func maskSensitiveValues(value interface{}, secrets []string) interface{} {
+	if len(secrets) == 0 {
+		return value
+	}
+	switch typed := value.(type) {
+	case string:
+		for _, secret := range secrets {
+			typed = strings.ReplaceAll(typed, secret, redactedLogValue)
+		}
+		return typed
+	case map[string]interface{}:
+		masked := make(map[string]interface{}, len(typed))
+		for key, item := range typed {
+			masked[key] = maskSensitiveValues(item, secrets)
+		}
+		return masked
+	case []interface{}:
+		masked := make([]interface{}, len(typed))
+		for i, item := range typed {
+			masked[i] = maskSensitiveValues(item, secrets)
+		}
+		return masked
+	case []string:
+		masked := make([]string, len(typed))
+		for i, item := range typed {
+			masked[i] = maskSensitiveValues(item, secrets).(string)
+		}
+		return masked
+	case nil, bool:
+		return value
+	default:
+		for _, secret := range secrets {
+			if fmt.Sprint(typed) == secret {
+				return redactedLogValue
+			}
+		}
+		return value
+	}
+}
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+const testAPIKey = "sk-live-4f9a2c7e"
+
+// newSensitivePlan returns a plan whose api_key parameter is sensitive and was interpolated
+// into a step input under an innocuous name
+func newSensitivePlan() *ExecutionPlan {
+	return &ExecutionPlan{
+		WorkflowID:          "wf_1",
+		SourceWorkflowID:    "wf_1",
+		SensitiveParameters: []string{"api_key"},
+		ResolvedSteps: []ResolvedStep{
+			{ID: "send", Service: "gmail", Action: "gmail.send_message", Status: StepCompleted,
+				Inputs:  map[string]interface{}{"to": "team@example.com", "subject": "Key " + testAPIKey, "body": testAPIKey},
+				Outputs: map[string]interface{}{"message_id": "msg_1"}},
+			{ID: "share", Service: "drive", Action: "share_file", Status: StepFailed,
+				Inputs: map[string]interface{}{"file_id": testAPIKey, "email": "bob@example.com"}},
+		},
+		ParameterContext: &ParameterContext{
+			UserParameters:    map[string]interface{}{"api_key": testAPIKey, "recipient": "team@example.com"},
+			RuntimeParameters: map[string]interface{}{},
+			SystemParameters:  map[string]interface{}{"oauth_token": "token_1"},
+			StepOutputs:       map[string]interface{}{},
+		},
+	}
+}
+
+// TestParameterCipherRoundTrip verifies values decrypt under the same secret only
+func TestParameterCipherRoundTrip(t *testing.T) {
+	cipher, err := NewParameterCipher("secret")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	encrypted, err := cipher.Encrypt(testAPIKey)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !IsEncryptedParameter(encrypted) || strings.Contains(encrypted, testAPIKey) {
+		t.Fatalf("Expected an encrypted value, got %q", encrypted)
+	}
+	if decrypted, err := cipher.Decrypt(encrypted); err != nil || decrypted != testAPIKey {
+		t.Errorf("Expected %q back, got %v (%v)", testAPIKey, decrypted, err)
+	}
+
+	other, err := NewParameterCipher("")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := other.Decrypt(encrypted); !errors.Is(err, ErrParameterDecryption) {
+		t.Errorf("Expected ErrParameterDecryption under another key, got %v", err)
+	}
+}
+
+// TestExecutionPlanJSONMasksSensitiveValues verifies sensitive values never appear in the plan returned by the API
+func TestExecutionPlanJSONMasksSensitiveValues(t *testing.T) {
+	plan := newSensitivePlan()
+	data, err := json.Marshal(plan)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strings.Contains(string(data), testAPIKey) {
+		t.Fatalf("Expected the API key masked, got %s", data)
+	}
+	if !strings.Contains(string(data), `"subject":"Key [REDACTED]"`) || !strings.Contains(string(data), "team@example.com") {
+		t.Errorf("Expected only the API key masked, got %s", data)
+	}
+
+	// Marshaling leaves the plan itself intact for execution
+	if plan.ParameterContext.UserParameters["api_key"] != testAPIKey {
+		t.Error("Expected the plan's parameters not to be modified")
+	}
+}
+
+// TestRecordAuditMasksSensitiveValues verifies audit entries and errors don't carry sensitive values
+func TestRecordAuditMasksSensitiveValues(t *testing.T) {
+	sink := NewMemoryAuditSink()
+	engine := NewExecutionEngine(NewMCPService("http://localhost"))
+	engine.SetAuditSink(sink)
+
+	plan := newSensitivePlan()
+	ctx := withAuditScope(context.Background(), "exec_1", "user_1")
+	for i := range plan.ResolvedSteps {
+		step := &plan.ResolvedSteps[i]
+		engine.auditStep(ctx, plan, step, step.Inputs)
+	}
+	engine.recordAudit("exec_1", "user_1", plan, errors.New("step share failed: no file "+testAPIKey))
+
+	entries, err := sink.Query(AuditFilter{UserID: "user_1"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	data, _ := json.Marshal(entries)
+	if strings.Contains(string(data), testAPIKey) {
+		t.Fatalf("Expected the API key masked in the audit log, got %s", data)
+	}
+	if len(entries) != 3 || entries[1].Parameters["file_id"] != redactedLogValue {
+		t.Errorf("Expected the masked file ID on the share step, got %+v", entries)
+	}
+}
+
+// TestRecordParametersOmitsSensitive verifies sensitive parameters are never prefilled
+func TestRecordParametersOmitsSensitive(t *testing.T) {
+	engine := NewExecutionEngine(NewMCPService("http://localhost"))
+	engine.SetParameterHistory(NewParameterHistoryStore())
+
+	engine.RecordParameters("user_1", "wf_1", "exec_1", map[string]interface{}{"api_key": testAPIKey, "recipient": "team@example.com"}, "api_key")
+
+	last, err := engine.ParameterHistory().Get("user_1", "wf_1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, exists := last.UserParameters["api_key"]; exists {
+		t.Errorf("Expected api_key to be left out, got %+v", last.UserParameters)
+	}
+	if len(last.RedactedFields) != 1 || last.RedactedFields[0] != "api_key" || last.UserParameters["recipient"] != "team@example.com" {
+		t.Errorf("Expected api_key listed for re-entry, got %+v", last)
+	}
+}
+
+// TestFailedExecutionEncryptsSensitive verifies dead-letter records keep sensitive values encrypted for replay
+func TestFailedExecutionEncryptsSensitive(t *testing.T) {
+	engine := NewExecutionEngine(NewMCPService("http://localhost"))
+	engine.SetDeadLetterStore(NewDeadLetterStore())
+	cipher, err := NewParameterCipher("secret")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	engine.SetParameterCipher(cipher)
+
+	entry := engine.RecordFailure("exec_1", "user_1", newSensitivePlan(), errors.New("step share failed: no file "+testAPIKey))
+	data, _ := json.Marshal(entry)
+	if strings.Contains(string(data), testAPIKey) {
+		t.Fatalf("Expected the API key encrypted in the dead-letter record, got %s", data)
+	}
+	if !IsEncryptedParameter(entry.UserParameters["api_key"]) || entry.UserParameters["recipient"] != "team@example.com" {
+		t.Errorf("Expected only api_key encrypted, got %+v", entry.UserParameters)
+	}
+
+	parameters, err := engine.ReplayParameters(entry)
+	if err != nil || parameters["api_key"] != testAPIKey {
+		t.Errorf("Expected the API key decrypted for replay, got %v (%v)", parameters, err)
+	}
+
+	// Without a cipher the value is dropped rather than kept in plain text
+	plain := NewExecutionEngine(NewMCPService("http://localhost"))
+	plain.SetDeadLetterStore(NewDeadLetterStore())
+	dropped := plain.RecordFailure("exec_2", "user_1", newSensitivePlan(), errors.New("failed"))
+	if _, exists := dropped.UserParameters["api_key"]; exists {
+		t.Errorf("Expected api_key dropped without a cipher, got %+v", dropped.UserParameters)
+	}
+	if _, err := plain.ReplayParameters(entry); !errors.Is(err, ErrParameterDecryption) {
+		t.Errorf("Expected ErrParameterDecryption without a cipher, got %v", err)
+	}
+}
+
+// TestRedactForLogContextMasksSensitiveValues verifies logged step data is masked by value
+func TestRedactForLogContextMasksSensitiveValues(t *testing.T) {
+	var buffer bytes.Buffer
+	log.SetOutput(&buffer)
+	defer log.SetOutput(os.Stderr)
+
+	ctx := withSensitiveValues(context.Background(), newSensitivePlan().sensitiveValues())
+	log.Printf("inputs: %v", redactForLogContext(ctx, map[string]interface{}{"body": "Key " + testAPIKey, "count": 2}))
+
+	if strings.Contains(buffer.String(), testAPIKey) || !strings.Contains(buffer.String(), "Key [REDACTED]") {
+		t.Errorf("Expected the API key masked in the log, got %q", buffer.String())
+	}
+	if redactForLogContext(context.Background(), "Key "+testAPIKey) != "Key "+testAPIKey {
+		t.Error("Expected values to be kept without sensitive parameters in the context")
+	}
+}
+
+// TestExecutionErrorMaskedInWebhookPayload verifies an execution error echoing a sensitive
+// parameter is masked before it is sent to the external callback
+func TestExecutionErrorMaskedInWebhookPayload(t *testing.T) {
+	plan := newSensitivePlan()
+	execErr := errors.New("MCP action drive.share_file failed: invalid file id " + testAPIKey)
+
+	payload := BuildWebhookPayload("exec_1", plan, execErr)
+	if strings.Contains(payload.Error, testAPIKey) || !strings.Contains(payload.Error, redactedLogValue) {
+		t.Errorf("Expected the API key to be masked in the webhook error, got %q", payload.Error)
+	}
+	if plan.RedactError(nil) != "" {
+		t.Error("Expected no message for a nil error")
+	}
+}
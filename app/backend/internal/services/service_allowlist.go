@@ -0,0 +1,92 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"sohoaas-backend/internal/types"
+)
+
+// ErrServiceNotAllowed is returned for workflow steps using a catalog service outside the deployment's allowlist
+var ErrServiceNotAllowed = errors.New("service not allowed")
+
+// SetServiceAllowlist restricts workflows to the given services (e.g. gmail and docs in a
+// limited tier). An empty list allows every catalog service.
+func (m *MCPService) SetServiceAllowlist(services []string) {
+	if len(services) == 0 {
+		m.serviceAllowlist = nil
+		return
+	}
+	m.serviceAllowlist = make(map[string]bool, len(services))
+	for _, service := range services {
+		m.serviceAllowlist[service] = true
+	}
+}
+
+// AllowedServices returns the allowlisted services in sorted order, or nil when every service is allowed
+func (m *MCPService) AllowedServices() []string {
+	if m == nil || m.serviceAllowlist == nil {
+		return nil
+	}
+	services := make([]string, 0, len(m.serviceAllowlist))
+	for service := range m.serviceAllowlist {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+	return services
+}
+
+// CheckServiceAllowed returns ErrServiceNotAllowed, naming the allowed services, when service is not allowlisted
+func (m *MCPService) CheckServiceAllowed(service string) error {
+	if m == nil || m.serviceAllowlist == nil || m.serviceAllowlist[service] {
+		return nil
+	}
+	return fmt.Errorf("%w: '%s' is not enabled for this deployment (allowed: %s)", ErrServiceNotAllowed, service, strings.Join(m.AllowedServices(), ", "))
+}
+
+// DisallowedServices returns the services that are not allowlisted, in the order given
+func (m *MCPService) DisallowedServices(services []string) []string {
+	var disallowed []string
+	for _, service := range services {
+		if m.CheckServiceAllowed(service) != nil {
+			disallowed = append(disallowed, service)
+		}
+	}
+	return disallowed
+}
+
+// GetAllowedServiceCatalog returns the service catalog without the services outside the
+// allowlist; it is what the intent analyst and workflow generator are offered
+func (m *MCPService) GetAllowedServiceCatalog() (*types.MCPServiceCatalog, error) {
+	catalog, err := m.GetServiceCatalog()
+	if err != nil || m.serviceAllowlist == nil {
+		return catalog, err
+	}
+	return filterCatalogServices(catalog, m.serviceAllowlist), nil
+}
+
+// filterCatalogServices returns a copy of catalog keeping only the allowed services of each provider
+func filterCatalogServices(catalog *types.MCPServiceCatalog, allowed map[string]bool) *types.MCPServiceCatalog {
+	filterProvider := func(provider types.MCPProvider) types.MCPProvider {
+		services := make(map[string]types.MCPServiceDefinition)
+		for name, service := range provider.Services {
+			if allowed[name] {
+				services[name] = service
+			}
+		}
+		provider.Services = services
+		return provider
+	}
+
+	filtered := *catalog
+	filtered.Providers.Workspace = filterProvider(catalog.Providers.Workspace)
+	if catalog.Providers.Others != nil {
+		filtered.Providers.Others = make(map[string]types.MCPProvider, len(catalog.Providers.Others))
+		for name, provider := range catalog.Providers.Others {
+			filtered.Providers.Others[name] = filterProvider(provider)
+		}
+	}
+	return &filtered
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestServiceAllowlist verifies generation sees only allowlisted services and validation rejects the rest
+func TestServiceAllowlist(t *testing.T) {
+	mcpService := NewFakeMCPService(MockPipelineCatalog())
+	if err := mcpService.CheckServiceAllowed("gmail"); err != nil {
+		t.Fatalf("Expected every service allowed without an allowlist, got %v", err)
+	}
+
+	mcpService.SetServiceAllowlist([]string{"docs"})
+
+	catalog, err := mcpService.GetAllowedServiceCatalog()
+	if err != nil {
+		t.Fatalf("Failed to get allowed catalog: %v", err)
+	}
+	if _, _, found := catalog.LookupService("gmail"); found {
+		t.Error("Expected gmail removed from the allowed catalog")
+	}
+	if _, _, found := catalog.LookupService("docs"); !found {
+		t.Error("Expected docs kept in the allowed catalog")
+	}
+	if full, _ := mcpService.GetServiceCatalog(); full.Providers.Workspace.Services["gmail"].Functions == nil {
+		t.Error("Expected the full catalog left unfiltered")
+	}
+
+	if disallowed := mcpService.DisallowedServices([]string{"docs", "gmail"}); len(disallowed) != 1 || disallowed[0] != "gmail" {
+		t.Errorf("Expected gmail disallowed, got %v", disallowed)
+	}
+
+	ee := NewExecutionEngine(mcpService)
+	workflow := &ParsedWorkflow{
+		Name: "send",
+		Steps: []WorkflowStep{
+			{ID: "send", Service: "gmail", Action: "send_message", Inputs: map[string]interface{}{}},
+		},
+	}
+	err = ee.ValidateWorkflowServices(workflow)
+	if !errors.Is(err, ErrServiceNotAllowed) {
+		t.Fatalf("Expected ErrServiceNotAllowed for gmail, got %v", err)
+	}
+
+	workflow.Steps[0] = WorkflowStep{ID: "create", Service: "docs", Action: "create_document", Inputs: map[string]interface{}{}}
+	if err := ee.ValidateWorkflowServices(workflow); err != nil {
+		t.Errorf("Expected docs step to validate, got %v", err)
+	}
+}
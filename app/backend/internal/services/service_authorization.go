@@ -0,0 +1,134 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"sohoaas-backend/internal/types"
+)
+
+// ErrServicesNotAuthorized is returned when the user's tokens don't grant the scopes the workflow's services need
+var ErrServicesNotAuthorized = errors.New("workflow services not authorized")
+
+// MissingServiceScope is one service of a workflow the user's token for a connection can't use
+type MissingServiceScope struct {
+	Service    string `json:"service"`
+	Connection string `json:"connection"`
+	Scope      string `json:"scope,omitempty"`  // the scope that is not granted
+	Reason     string `json:"reason,omitempty"` // why the token's scopes couldn't be checked
+}
+
+// ServiceAuthorizationError lists every missing service scope; it wraps ErrServicesNotAuthorized
+type ServiceAuthorizationError struct {
+	Missing []MissingServiceScope
+}
+
+func (e *ServiceAuthorizationError) Error() string {
+	parts := make([]string, 0, len(e.Missing))
+	for _, missing := range e.Missing {
+		if missing.Scope != "" {
+			parts = append(parts, fmt.Sprintf("%s (connection %s) needs %s", missing.Service, missing.Connection, missing.Scope))
+		} else {
+			parts = append(parts, fmt.Sprintf("%s (connection %s): %s", missing.Service, missing.Connection, missing.Reason))
+		}
+	}
+	return fmt.Sprintf("%v: %s", ErrServicesNotAuthorized, strings.Join(parts, "; "))
+}
+
+func (e *ServiceAuthorizationError) Unwrap() error {
+	return ErrServicesNotAuthorized
+}
+
+// SetRequireConnectedServices makes CheckServiceAuthorization verify, before a run, that the
+// user's tokens grant the scopes of every service the workflow touches
+func (ee *ExecutionEngine) SetRequireConnectedServices(required bool) {
+	ee.requireConnectedServices = required
+}
+
+// CheckServiceAuthorization returns a *ServiceAuthorizationError when a token the plan runs
+// with doesn't grant a bound service's scopes. Scopes declared by a service binding are all
+// required; other Google services need any one of their scopes in types.GoogleWorkspaceScopes.
+// Sandboxed steps and steps authenticating with a secret are not checked.
+func (ee *ExecutionEngine) CheckServiceAuthorization(userID string, plan *ExecutionPlan) error {
+	if !ee.requireConnectedServices || ee.tokenManager == nil || plan == nil || plan.Simulate {
+		return nil
+	}
+
+	// Each connection's token must cover the services of the steps using it
+	servicesByConnection := make(map[string]map[string]bool)
+	for i := range plan.ResolvedSteps {
+		step := &plan.ResolvedSteps[i]
+		if step.Sandboxed || step.SecretRef != "" {
+			continue
+		}
+		// Check the token the step will run with; see stepOAuthToken
+		connection := step.Connection
+		if ee.usesProviderConnection(userID, step) {
+			connection = step.Provider
+		}
+		if connection == "" {
+			connection = plan.Connection
+		}
+		connection = connectionOrDefault(connection)
+		if servicesByConnection[connection] == nil {
+			servicesByConnection[connection] = make(map[string]bool)
+		}
+		servicesByConnection[connection][step.Service] = true
+	}
+
+	var missing []MissingServiceScope
+	connections := make([]string, 0, len(servicesByConnection))
+	for connection := range servicesByConnection {
+		connections = append(connections, connection)
+	}
+	sort.Strings(connections)
+	for _, connection := range connections {
+		granted, grantErr := ee.tokenManager.GrantedScopes(userID, connection)
+		services := make([]string, 0, len(servicesByConnection[connection]))
+		for service := range servicesByConnection[connection] {
+			services = append(services, service)
+		}
+		sort.Strings(services)
+		for _, service := range services {
+			declared := normalizeOAuthScopes(plan.OAuthScopes[service])
+			defaults := types.GoogleWorkspaceScopes[service]
+			if len(declared) == 0 && len(defaults) == 0 {
+				continue // not a Google service
+			}
+
+			switch {
+			case grantErr != nil:
+				missing = append(missing, MissingServiceScope{Service: service, Connection: connection, Reason: grantErr.Error()})
+			case granted == nil:
+				missing = append(missing, MissingServiceScope{Service: service, Connection: connection, Reason: "the token's scopes are unknown; reconnect the account"})
+			case len(declared) > 0:
+				for _, scope := range declared {
+					if !scopePermitted(granted, scope) {
+						missing = append(missing, MissingServiceScope{Service: service, Connection: connection, Scope: scope})
+					}
+				}
+			default:
+				if !anyScopePermitted(granted, defaults) {
+					missing = append(missing, MissingServiceScope{Service: service, Connection: connection, Scope: strings.Join(defaults, " or ")})
+				}
+			}
+		}
+	}
+
+	if len(missing) > 0 {
+		return &ServiceAuthorizationError{Missing: missing}
+	}
+	return nil
+}
+
+// anyScopePermitted reports whether granted covers at least one of scopes
+func anyScopePermitted(granted, scopes []string) bool {
+	for _, scope := range scopes {
+		if scopePermitted(granted, scope) {
+			return true
+		}
+	}
+	return false
+}
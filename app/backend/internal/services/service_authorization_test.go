@@ -0,0 +1,137 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestCheckServiceAuthorization verifies runs are rejected when the user's token lacks a service's scope
+func TestCheckServiceAuthorization(t *testing.T) {
+	engine := NewExecutionEngine(NewMCPService("http://localhost"))
+	tokenManager := NewTokenManager()
+	engine.SetTokenManager(tokenManager)
+
+	plan := &ExecutionPlan{
+		ResolvedSteps: []ResolvedStep{
+			{ID: "send", Service: "gmail", Action: "send_message"},
+			{ID: "upload", Service: "drive", Action: "upload_file"},
+		},
+	}
+
+	tokenManager.tokens["user_1"] = map[string]*UserTokens{
+		DefaultConnection: {
+			AccessToken: "token",
+			Expiry:      time.Now().Add(time.Hour),
+			Connection:  DefaultConnection,
+			Scopes:      []string{"https://mail.google.com/"},
+		},
+	}
+
+	if err := engine.CheckServiceAuthorization("user_1", plan); err != nil {
+		t.Fatalf("Expected no check while the preflight is disabled, got %v", err)
+	}
+
+	engine.SetRequireConnectedServices(true)
+	err := engine.CheckServiceAuthorization("user_1", plan)
+	var authErr *ServiceAuthorizationError
+	if !errors.As(err, &authErr) || !errors.Is(err, ErrServicesNotAuthorized) {
+		t.Fatalf("Expected a service authorization error, got %v", err)
+	}
+	if len(authErr.Missing) != 1 || authErr.Missing[0].Service != "drive" || authErr.Missing[0].Scope == "" {
+		t.Errorf("Expected only drive to be missing a scope, got %+v", authErr.Missing)
+	}
+
+	tokenManager.tokens["user_1"][DefaultConnection].Scopes = append(tokenManager.tokens["user_1"][DefaultConnection].Scopes, "https://www.googleapis.com/auth/drive")
+	if err := engine.CheckServiceAuthorization("user_1", plan); err != nil {
+		t.Errorf("Expected granted scopes to cover every service, got %v", err)
+	}
+
+	plan.OAuthScopes = map[string][]string{"drive": {"drive.readonly", "drive.metadata"}}
+	tokenManager.tokens["user_1"][DefaultConnection].Scopes = []string{"https://mail.google.com/", "https://www.googleapis.com/auth/drive.readonly"}
+	err = engine.CheckServiceAuthorization("user_1", plan)
+	if !errors.As(err, &authErr) || len(authErr.Missing) != 1 || authErr.Missing[0].Scope != "https://www.googleapis.com/auth/drive.metadata" {
+		t.Errorf("Expected the undeclared drive.metadata scope to be missing, got %v", err)
+	}
+
+	if err := engine.CheckServiceAuthorization("user_2", plan); !errors.Is(err, ErrServicesNotAuthorized) {
+		t.Errorf("Expected a user without tokens to be rejected, got %v", err)
+	}
+}
+
+// TestCheckServiceAuthorizationUsesProviderConnection verifies steps running on the connection
+// named after their provider are checked against that connection's token
+func TestCheckServiceAuthorizationUsesProviderConnection(t *testing.T) {
+	engine := NewExecutionEngine(NewMCPService("http://localhost"))
+	tokenManager := NewTokenManager()
+	engine.SetTokenManager(tokenManager)
+	engine.SetRequireConnectedServices(true)
+
+	tokenManager.tokens["user_1"] = map[string]*UserTokens{
+		DefaultConnection: {
+			AccessToken: "default-token",
+			Expiry:      time.Now().Add(time.Hour),
+			Connection:  DefaultConnection,
+			Scopes:      []string{"https://mail.google.com/"},
+		},
+		"workspace": {
+			AccessToken: "workspace-token",
+			Expiry:      time.Now().Add(time.Hour),
+			Connection:  "workspace",
+			Scopes:      []string{"https://www.googleapis.com/auth/drive"},
+		},
+	}
+	plan := &ExecutionPlan{
+		ResolvedSteps: []ResolvedStep{
+			{ID: "upload", Service: "drive", Action: "upload_file", Provider: "workspace"},
+		},
+	}
+
+	if err := engine.CheckServiceAuthorization("user_1", plan); err != nil {
+		t.Errorf("Expected the provider connection's drive scope to authorize the step, got %v", err)
+	}
+
+	tokenManager.tokens["user_1"]["workspace"].Scopes = []string{"https://mail.google.com/"}
+	tokenManager.tokens["user_1"][DefaultConnection].Scopes = []string{"https://www.googleapis.com/auth/drive"}
+	err := engine.CheckServiceAuthorization("user_1", plan)
+	var authErr *ServiceAuthorizationError
+	if !errors.As(err, &authErr) || len(authErr.Missing) != 1 || authErr.Missing[0].Connection != "workspace" {
+		t.Errorf("Expected the provider connection to be missing the drive scope, got %v", err)
+	}
+}
+
+// TestFetchGrantedScopesKeepsTokenOutOfURL verifies the token is sent in the form body and
+// network errors don't contain it
+func TestFetchGrantedScopesKeepsTokenOutOfURL(t *testing.T) {
+	const token = "ya29.secret-access-token"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || strings.Contains(r.URL.RawQuery, token) {
+			t.Errorf("Expected the token in a POST body, got %s %s", r.Method, r.URL)
+		}
+		if r.PostFormValue("access_token") != token {
+			t.Errorf("Expected the token in the form body")
+		}
+		w.Write([]byte(`{"scope": "https://mail.google.com/ https://www.googleapis.com/auth/drive"}`))
+	}))
+
+	previousURL := googleTokenInfoURL
+	defer func() { googleTokenInfoURL = previousURL }()
+	googleTokenInfoURL = server.URL
+
+	scopes, err := fetchGrantedScopes(token)
+	if err != nil || len(scopes) != 2 {
+		t.Fatalf("Expected two scopes, got %v (%v)", scopes, err)
+	}
+
+	server.Close()
+	_, err = fetchGrantedScopes(token)
+	if err == nil {
+		t.Fatal("Expected an error once the endpoint is unreachable")
+	}
+	if strings.Contains(err.Error(), token) {
+		t.Errorf("Expected the error to omit the token, got %v", err)
+	}
+}
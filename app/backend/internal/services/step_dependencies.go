@@ -0,0 +1,141 @@
+package services
+
+import (
+	"log"
+	"regexp"
+	"sort"
+)
+
+// stepReferencePattern matches ${steps.<id>.outputs.<field>} references: the step id, and the
+// output expression when the reference is complete
+var stepReferencePattern = regexp.MustCompile(`\$\{steps\.([A-Za-z0-9_-]+)\.(?:outputs\.([^}]+)\})?`)
+
+// forEachStepReference calls visit with every stepReferencePattern match in a parameter value,
+// walking maps in key order
+func forEachStepReference(value interface{}, visit func(match []string)) {
+	switch v := value.(type) {
+	case string:
+		for _, match := range stepReferencePattern.FindAllStringSubmatch(v, -1) {
+			visit(match)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			forEachStepReference(v[key], visit)
+		}
+	case []interface{}:
+		for _, item := range v {
+			forEachStepReference(item, visit)
+		}
+	}
+}
+
+// referencedStepIDs returns the step ids referenced anywhere in a parameter value, in order of appearance
+func referencedStepIDs(value interface{}) []string {
+	var ids []string
+	forEachStepReference(value, func(match []string) {
+		ids = append(ids, match[1])
+	})
+	return ids
+}
+
+// stepOutputRef is one ${steps.<id>.outputs.<field>} reference
+type stepOutputRef struct {
+	stepID, field string
+}
+
+// referencedStepOutputs returns the step output references in a parameter value, in a stable order
+func referencedStepOutputs(value interface{}) []stepOutputRef {
+	var refs []stepOutputRef
+	forEachStepReference(value, func(match []string) {
+		if match[2] != "" {
+			refs = append(refs, stepOutputRef{stepID: match[1], field: stepOutputBaseField(match[2])})
+		}
+	})
+	return refs
+}
+
+// linkReferencedDependencies adds every step referenced through ${steps.X.outputs...} in a step's
+// parameters to its depends_on, so execution order doesn't rely on implicit references.
+// References already covered by depends_on, directly or through other dependencies, are not
+// duplicated; references to the step itself or to unknown steps are ignored.
+func linkReferencedDependencies(steps []interface{}) {
+	stepsByID := make(map[string]map[string]interface{})
+	for _, stepData := range steps {
+		if stepMap, ok := stepData.(map[string]interface{}); ok {
+			if stepID, ok := stepMap["id"].(string); ok {
+				stepsByID[stepID] = stepMap
+			}
+		}
+	}
+
+	for _, stepData := range steps {
+		stepMap, ok := stepData.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		stepID, _ := stepMap["id"].(string)
+		dependsOn := stepDependsOn(stepMap)
+
+		added := false
+		for _, field := range []string{"parameters", "inputs"} {
+			for _, ref := range referencedStepIDs(stepMap[field]) {
+				if ref == stepID || stepsByID[ref] == nil || dependsOnTransitively(stepsByID, dependsOn, ref) {
+					continue
+				}
+				dependsOn = append(dependsOn, ref)
+				added = true
+			}
+		}
+
+		if added {
+			deps := make([]interface{}, len(dependsOn))
+			for i, dep := range dependsOn {
+				deps[i] = dep
+			}
+			stepMap["depends_on"] = deps
+			log.Printf("[GenkitService] Linked step %s dependencies from references: %v", stepID, dependsOn)
+		}
+	}
+}
+
+// stepDependsOn returns a step's explicit depends_on list
+func stepDependsOn(stepMap map[string]interface{}) []string {
+	var dependsOn []string
+	switch deps := stepMap["depends_on"].(type) {
+	case []interface{}:
+		for _, dep := range deps {
+			if depStr, ok := dep.(string); ok {
+				dependsOn = append(dependsOn, depStr)
+			}
+		}
+	case []string:
+		dependsOn = append(dependsOn, deps...)
+	}
+	return dependsOn
+}
+
+// dependsOnTransitively reports whether target is reachable from the given dependencies
+func dependsOnTransitively(stepsByID map[string]map[string]interface{}, dependsOn []string, target string) bool {
+	visited := make(map[string]bool)
+	queue := append([]string(nil), dependsOn...)
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current == target {
+			return true
+		}
+		if visited[current] {
+			continue
+		}
+		visited[current] = true
+		if stepMap := stepsByID[current]; stepMap != nil {
+			queue = append(queue, stepDependsOn(stepMap)...)
+		}
+	}
+	return false
+}
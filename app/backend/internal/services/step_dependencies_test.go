@@ -0,0 +1,58 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestLinkReferencedDependencies verifies depends_on is populated from step output references
+func TestLinkReferencedDependencies(t *testing.T) {
+	steps := []interface{}{
+		map[string]interface{}{
+			"id":         "create_doc",
+			"parameters": map[string]interface{}{"title": "${user.title}"},
+		},
+		map[string]interface{}{
+			"id": "share_doc",
+			"parameters": map[string]interface{}{
+				"file_id": "${steps.create_doc.outputs.document_id}",
+				"emails":  []interface{}{"${steps.lookup.outputs.email}"},
+			},
+		},
+		map[string]interface{}{
+			"id":         "lookup",
+			"parameters": map[string]interface{}{"query": "team"},
+		},
+		map[string]interface{}{
+			"id":         "notify",
+			"depends_on": []interface{}{"share_doc"},
+			"parameters": map[string]interface{}{
+				"body":    "Doc ${steps.create_doc.outputs.document_url | first} shared",
+				"subject": "${steps.share_doc.outputs.title}",
+				"self":    "${steps.notify.outputs.id}",
+				"cc":      "${steps.lookup.outputs.email}",
+			},
+		},
+	}
+
+	linkReferencedDependencies(steps)
+
+	// notify reaches create_doc and lookup through share_doc, so nothing is added
+	expected := map[string][]interface{}{
+		"create_doc": nil,
+		"share_doc":  {"lookup", "create_doc"},
+		"lookup":     nil,
+		"notify":     {"share_doc"},
+	}
+	for _, stepData := range steps {
+		step := stepData.(map[string]interface{})
+		id := step["id"].(string)
+		var dependsOn []interface{}
+		if deps, ok := step["depends_on"].([]interface{}); ok {
+			dependsOn = deps
+		}
+		if !reflect.DeepEqual(dependsOn, expected[id]) {
+			t.Errorf("Step %s: expected depends_on %v, got %v", id, expected[id], dependsOn)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// DefaultStepCacheTTL is how long a cacheable read step's outputs are reused
+const DefaultStepCacheTTL = 5 * time.Minute
+
+// StepOutputCache stores the outputs of read steps marked cacheable, so re-runs and resumes
+// within the TTL reuse them instead of calling MCP again
+type StepOutputCache interface {
+	Get(key string) (map[string]interface{}, bool)
+	Set(key string, outputs map[string]interface{}, ttl time.Duration)
+}
+
+// cachedStepOutputs is one cache entry
+type cachedStepOutputs struct {
+	outputs   []byte
+	expiresAt time.Time
+}
+
+// MemoryStepOutputCache is an in-process StepOutputCache. Outputs are stored as JSON so callers
+// never share maps with the cache.
+type MemoryStepOutputCache struct {
+	entries map[string]cachedStepOutputs
+	mu      sync.Mutex
+}
+
+// NewMemoryStepOutputCache creates an empty in-process cache
+func NewMemoryStepOutputCache() *MemoryStepOutputCache {
+	return &MemoryStepOutputCache{entries: make(map[string]cachedStepOutputs)}
+}
+
+// Get returns the outputs stored under key, unless they expired
+func (c *MemoryStepOutputCache) Get(key string) (map[string]interface{}, bool) {
+	c.mu.Lock()
+	entry, exists := c.entries[key]
+	if exists && !time.Now().Before(entry.expiresAt) {
+		delete(c.entries, key)
+		exists = false
+	}
+	c.mu.Unlock()
+	if !exists {
+		return nil, false
+	}
+
+	var outputs map[string]interface{}
+	if err := json.Unmarshal(entry.outputs, &outputs); err != nil {
+		return nil, false
+	}
+	return outputs, true
+}
+
+// Set stores outputs under key for ttl, dropping expired entries
+func (c *MemoryStepOutputCache) Set(key string, outputs map[string]interface{}, ttl time.Duration) {
+	encoded, err := json.Marshal(outputs)
+	if err != nil {
+		log.Printf("[ExecutionEngine] Step outputs not cached: %v", err)
+		return
+	}
+
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for existingKey, entry := range c.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(c.entries, existingKey)
+		}
+	}
+	c.entries[key] = cachedStepOutputs{outputs: encoded, expiresAt: now.Add(ttl)}
+}
+
+// SetStepOutputCache enables output caching for steps marked cacheable; a ttl <= 0 uses
+// DefaultStepCacheTTL
+func (ee *ExecutionEngine) SetStepOutputCache(cache StepOutputCache, ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = DefaultStepCacheTTL
+	}
+	ee.stepCache = cache
+	ee.stepCacheTTL = ttl
+}
+
+// stepCacheKey keys a cacheable read step's outputs by who reads (user, connection or secret),
+// what is read (provider, service, action) and the resolved inputs. It returns "" when the
+// step must not be cached.
+func (ee *ExecutionEngine) stepCacheKey(step *ResolvedStep, resolvedInputs map[string]interface{}, paramContext *ParameterContext) string {
+	if ee.stepCache == nil || !step.Cacheable || classifyStepOperation(step.Action) != OperationRead {
+		return ""
+	}
+
+	// json.Marshal sorts map keys, so equal inputs always encode the same way
+	encodedInputs, err := json.Marshal(resolvedInputs)
+	if err != nil {
+		log.Printf("[ExecutionEngine] Step %s not cached: inputs can't be encoded: %v", step.ID, err)
+		return ""
+	}
+	userID := ""
+	if paramContext != nil {
+		userID = fmt.Sprint(paramContext.SystemParameters["user_id"])
+	}
+
+	hash := sha256.New()
+	for _, part := range []string{userID, step.Connection, step.SecretRef, step.Provider, step.Service, step.Action} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	hash.Write(encodedInputs)
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newCountingMCPServer serves gmail.search_messages and gmail.send_message, counting action calls
+func newCountingMCPServer(t *testing.T, calls *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`{"providers": {"workspace": {"services": {"gmail": {"functions": {
+				"search_messages": {"name": "search_messages"},
+				"send_message": {"name": "send_message"}
+			}}}}}}`))
+			return
+		}
+		*calls++
+
+		resultText, _ := json.Marshal(map[string]interface{}{"messages": []string{"msg_1"}})
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"result": map[string]interface{}{
+				"content": []map[string]interface{}{{"type": "text", "text": string(resultText)}},
+			},
+		})
+	}))
+}
+
+// runCachedStep executes a single step as userID and reports whether it was served from the cache
+func runCachedStep(t *testing.T, ee *ExecutionEngine, step ResolvedStep, userID string) bool {
+	step.Status = StepPending
+	step.Outputs = map[string]interface{}{}
+	plan := &ExecutionPlan{
+		ResolvedSteps: []ResolvedStep{step},
+		ParameterContext: &ParameterContext{
+			UserParameters:    map[string]interface{}{},
+			StepOutputs:       map[string]interface{}{},
+			SystemParameters:  map[string]interface{}{"oauth_token": "token", "user_id": userID},
+			RuntimeParameters: map[string]interface{}{},
+		},
+	}
+	if err := ee.ExecuteWorkflow(plan); err != nil {
+		t.Fatalf("ExecuteWorkflow failed: %v", err)
+	}
+	if _, exists := plan.ResolvedSteps[0].Outputs["messages"]; !exists {
+		t.Errorf("Expected step outputs, got %v", plan.ResolvedSteps[0].Outputs)
+	}
+	return plan.ResolvedSteps[0].CacheHit
+}
+
+// TestStepOutputCacheReusesReads verifies identical cacheable reads reuse outputs within the TTL
+func TestStepOutputCacheReusesReads(t *testing.T) {
+	calls := 0
+	server := newCountingMCPServer(t, &calls)
+	defer server.Close()
+
+	ee := NewExecutionEngine(NewMCPService(server.URL))
+	ee.SetStepOutputCache(NewMemoryStepOutputCache(), time.Minute)
+	search := ResolvedStep{ID: "search", Service: "gmail", Action: "search_messages", Cacheable: true,
+		Inputs: map[string]interface{}{"query": "from:boss"}}
+
+	if runCachedStep(t, ee, search, "user1") {
+		t.Error("Expected the first read to call MCP")
+	}
+	if !runCachedStep(t, ee, search, "user1") {
+		t.Error("Expected the repeated read to be served from the cache")
+	}
+	if calls != 1 {
+		t.Errorf("Expected 1 MCP call, got %d", calls)
+	}
+
+	// Other inputs and other users miss the cache
+	other := search
+	other.Inputs = map[string]interface{}{"query": "from:team"}
+	if runCachedStep(t, ee, other, "user1") {
+		t.Error("Expected different inputs to miss the cache")
+	}
+	if runCachedStep(t, ee, search, "user2") {
+		t.Error("Expected another user's read to miss the cache")
+	}
+
+	// Steps without the flag and write actions are never cached
+	uncached := search
+	uncached.Cacheable = false
+	write := ResolvedStep{ID: "send", Service: "gmail", Action: "send_message", Cacheable: true,
+		Inputs: map[string]interface{}{"to": "boss@example.com"}}
+	for i := 0; i < 2; i++ {
+		if runCachedStep(t, ee, uncached, "user1") {
+			t.Error("Expected a step without cacheable to call MCP")
+		}
+	}
+	if calls != 5 {
+		t.Errorf("Expected 5 MCP calls, got %d", calls)
+	}
+	if key := ee.stepCacheKey(&write, write.Inputs, nil); key != "" {
+		t.Error("Expected a write action to have no cache key")
+	}
+}
+
+// TestMemoryStepOutputCacheExpires verifies entries are dropped after their TTL
+func TestMemoryStepOutputCacheExpires(t *testing.T) {
+	cache := NewMemoryStepOutputCache()
+	cache.Set("key", map[string]interface{}{"count": 1}, time.Minute)
+
+	outputs, hit := cache.Get("key")
+	if !hit || outputs["count"] != float64(1) {
+		t.Fatalf("Expected a cache hit, got %v %v", outputs, hit)
+	}
+	outputs["count"] = 2
+	if again, _ := cache.Get("key"); again["count"] != float64(1) {
+		t.Error("Expected callers not to share maps with the cache")
+	}
+
+	cache.Set("stale", map[string]interface{}{"count": 1}, -time.Second)
+	if _, hit := cache.Get("stale"); hit {
+		t.Error("Expected an expired entry to miss")
+	}
+}
+
+// TestParseCUEWorkflowCacheable verifies the cacheable flag is parsed and rejected on write actions
+func TestParseCUEWorkflowCacheable(t *testing.T) {
+	ee := NewExecutionEngine(nil)
+	workflowWithAction := func(action string) string {
+		return `workflow: {
+	name:        "digest"
+	description: "Summarize unread mail"
+	steps: [{
+		id:        "step"
+		name:      "Step"
+		action:    "` + action + `"
+		inputs:    {query: "is:unread"}
+		cacheable: true
+	}]
+}`
+	}
+
+	workflow, err := ee.ParseCUEWorkflow(workflowWithAction("gmail.search_messages"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !workflow.Steps[0].Cacheable {
+		t.Error("Expected the read step to be cacheable")
+	}
+
+	if _, err := ee.ParseCUEWorkflow(workflowWithAction("gmail.send_message")); err == nil {
+		t.Error("Expected cacheable on a write action to be rejected")
+	}
+}
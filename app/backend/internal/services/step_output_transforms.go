@@ -0,0 +1,208 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Step output references may pipe the referenced value through transforms:
+//
+//	${steps.fetch.outputs.messages | first | .id}
+//	${steps.fetch.outputs.emails | join(",")}
+//	${steps.fetch.outputs.messages[0].id}
+//	${steps.fetch.outputs.messages | length}
+//
+// Supported transforms are first, last, length, join(sep), [index] and .field paths.
+
+// splitStepOutputExpression splits "field | t1 | t2" into the field path and its transforms,
+// ignoring pipes inside quoted join separators
+func splitStepOutputExpression(expr string) (string, []string) {
+	var parts []string
+	var current strings.Builder
+	inQuotes := false
+	for _, r := range expr {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+			current.WriteRune(r)
+		case r == '|' && !inQuotes:
+			parts = append(parts, strings.TrimSpace(current.String()))
+			current.Reset()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	parts = append(parts, strings.TrimSpace(current.String()))
+	return parts[0], parts[1:]
+}
+
+// stepOutputBaseField returns the top-level output field an expression reads, e.g. "messages"
+// for "messages[0].id | join(",")"
+func stepOutputBaseField(expr string) string {
+	field, _ := splitStepOutputExpression(expr)
+	if idx := strings.IndexAny(field, ".["); idx > 0 {
+		return field[:idx]
+	}
+	return field
+}
+
+// evaluateStepOutputExpression resolves an output expression against a step's outputs.
+// found is false when the base field is not present yet.
+func evaluateStepOutputExpression(outputs map[string]interface{}, expr string) (value interface{}, found bool, err error) {
+	field, transforms := splitStepOutputExpression(expr)
+
+	// Whole-field lookup keeps plain references (and keys containing dots) working as before
+	if value, exists := outputs[field]; exists {
+		found = true
+		return applyStepOutputTransforms(value, transforms, found)
+	}
+
+	base := stepOutputBaseField(field)
+	baseValue, exists := outputs[base]
+	if !exists {
+		return nil, false, nil
+	}
+	value, err = applyPathTransform(baseValue, field[len(base):])
+	if err != nil {
+		return nil, true, fmt.Errorf("%s: %w", field, err)
+	}
+	return applyStepOutputTransforms(value, transforms, true)
+}
+
+// applyStepOutputTransforms runs each transform in order
+func applyStepOutputTransforms(value interface{}, transforms []string, found bool) (interface{}, bool, error) {
+	var err error
+	for _, transform := range transforms {
+		value, err = applyStepOutputTransform(value, transform)
+		if err != nil {
+			return nil, found, fmt.Errorf("transform %q: %w", transform, err)
+		}
+	}
+	return value, found, nil
+}
+
+// applyStepOutputTransform applies a single transform to value
+func applyStepOutputTransform(value interface{}, transform string) (interface{}, error) {
+	switch {
+	case transform == "first":
+		list, err := asList(value)
+		if err != nil {
+			return nil, err
+		}
+		if len(list) == 0 {
+			return nil, fmt.Errorf("list is empty")
+		}
+		return list[0], nil
+	case transform == "last":
+		list, err := asList(value)
+		if err != nil {
+			return nil, err
+		}
+		if len(list) == 0 {
+			return nil, fmt.Errorf("list is empty")
+		}
+		return list[len(list)-1], nil
+	case transform == "length":
+		switch v := value.(type) {
+		case string:
+			return len(v), nil
+		case map[string]interface{}:
+			return len(v), nil
+		}
+		list, err := asList(value)
+		if err != nil {
+			return nil, err
+		}
+		return len(list), nil
+	case strings.HasPrefix(transform, "join(") && strings.HasSuffix(transform, ")"):
+		separator, err := strconv.Unquote(strings.TrimSpace(transform[len("join(") : len(transform)-1]))
+		if err != nil {
+			return nil, fmt.Errorf("join separator must be a quoted string")
+		}
+		list, err := asList(value)
+		if err != nil {
+			return nil, err
+		}
+		items := make([]string, len(list))
+		for i, item := range list {
+			items[i] = fmt.Sprintf("%v", item)
+		}
+		return strings.Join(items, separator), nil
+	case strings.HasPrefix(transform, ".") || strings.HasPrefix(transform, "["):
+		return applyPathTransform(value, transform)
+	default:
+		return nil, fmt.Errorf("unknown transform")
+	}
+}
+
+// applyPathTransform walks a path like ".id", "[0].id" or ".payload.headers[1]"
+func applyPathTransform(value interface{}, path string) (interface{}, error) {
+	for path != "" {
+		switch path[0] {
+		case '.':
+			path = path[1:]
+			end := strings.IndexAny(path, ".[")
+			if end < 0 {
+				end = len(path)
+			}
+			key := path[:end]
+			path = path[end:]
+			object, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("cannot read field %q of %T", key, value)
+			}
+			fieldValue, exists := object[key]
+			if !exists {
+				return nil, fmt.Errorf("field %q not found", key)
+			}
+			value = fieldValue
+		case '[':
+			end := strings.Index(path, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed index in %q", path)
+			}
+			index, err := strconv.Atoi(path[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("invalid index %q", path[1:end])
+			}
+			path = path[end+1:]
+			list, err := asList(value)
+			if err != nil {
+				return nil, err
+			}
+			if index < 0 {
+				index += len(list)
+			}
+			if index < 0 || index >= len(list) {
+				return nil, fmt.Errorf("index %d out of range (length %d)", index, len(list))
+			}
+			value = list[index]
+		default:
+			return nil, fmt.Errorf("invalid path %q", path)
+		}
+	}
+	return value, nil
+}
+
+// asList converts JSON-like arrays to []interface{}
+func asList(value interface{}) ([]interface{}, error) {
+	switch v := value.(type) {
+	case []interface{}:
+		return v, nil
+	case []string:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = item
+		}
+		return list, nil
+	case []map[string]interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = item
+		}
+		return list, nil
+	default:
+		return nil, fmt.Errorf("expected a list, got %T", value)
+	}
+}
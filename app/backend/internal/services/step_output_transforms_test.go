@@ -0,0 +1,96 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestEvaluateStepOutputExpression verifies transforms applied to step output references
+func TestEvaluateStepOutputExpression(t *testing.T) {
+	outputs := map[string]interface{}{
+		"messages": []interface{}{
+			map[string]interface{}{"id": "msg_1", "subject": "Hello"},
+			map[string]interface{}{"id": "msg_2", "subject": "Report"},
+		},
+		"emails":      []interface{}{"a@example.com", "b@example.com"},
+		"document_id": "doc_123",
+	}
+
+	tests := []struct {
+		name     string
+		expr     string
+		expected interface{}
+	}{
+		{"plain field", "document_id", "doc_123"},
+		{"first then field", "messages | first | .id", "msg_1"},
+		{"last then field", "messages | last | .subject", "Report"},
+		{"length", "messages | length", 2},
+		{"join", `emails | join(",")`, "a@example.com,b@example.com"},
+		{"join with pipe separator", `emails | join(" | ")`, "a@example.com | b@example.com"},
+		{"index access", "messages[1].id", "msg_2"},
+		{"negative index", "emails[-1]", "b@example.com"},
+		{"index transform", "messages | [0] | .subject", "Hello"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, found, err := evaluateStepOutputExpression(outputs, tt.expr)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !found {
+				t.Fatalf("Expected %q to be found", tt.expr)
+			}
+			if !reflect.DeepEqual(value, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, value)
+			}
+		})
+	}
+
+	if _, found, _ := evaluateStepOutputExpression(outputs, "missing | first"); found {
+		t.Error("Expected missing field to be reported as not found")
+	}
+
+	for _, expr := range []string{"document_id | first", "messages[5]", "messages | unknown", "emails | join(,)"} {
+		if _, _, err := evaluateStepOutputExpression(outputs, expr); err == nil {
+			t.Errorf("Expected error for %q", expr)
+		}
+	}
+}
+
+// TestResolveStepOutputTransforms verifies transforms inside resolveStringParameter
+func TestResolveStepOutputTransforms(t *testing.T) {
+	ee := NewExecutionEngine(nil)
+	context := &ParameterContext{
+		UserParameters: map[string]interface{}{},
+		StepOutputs: map[string]interface{}{
+			"fetch": map[string]interface{}{
+				"messages": []interface{}{
+					map[string]interface{}{"id": "msg_1"},
+				},
+				"emails": []interface{}{"a@example.com", "b@example.com"},
+			},
+		},
+		SystemParameters:  map[string]interface{}{},
+		RuntimeParameters: map[string]interface{}{},
+	}
+
+	value, err := ee.resolveStringParameter("${steps.fetch.outputs.messages | first | .id}", context)
+	if err != nil || value != "msg_1" {
+		t.Errorf("Expected msg_1, got %v (err: %v)", value, err)
+	}
+
+	value, err = ee.resolveStringParameter("${steps.fetch.outputs.messages | length}", context)
+	if err != nil || value != 1 {
+		t.Errorf("Expected typed length 1, got %v (err: %v)", value, err)
+	}
+
+	value, err = ee.resolveStringParameter(`To: ${steps.fetch.outputs.emails | join(", ")}`, context)
+	if err != nil || value != "To: a@example.com, b@example.com" {
+		t.Errorf("Unexpected interpolated value %v (err: %v)", value, err)
+	}
+
+	if _, err := ee.resolveStringParameter("${steps.fetch.outputs.emails | first | .id}", context); err == nil {
+		t.Error("Expected error when reading a field of a string")
+	}
+}
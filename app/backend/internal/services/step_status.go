@@ -0,0 +1,57 @@
+package services
+
+import (
+	"fmt"
+	"time"
+)
+
+// StepStatus is the lifecycle state of a resolved workflow step
+type StepStatus string
+
+const (
+	StepPending   StepStatus = "pending"
+	StepRunning   StepStatus = "running"
+	StepCompleted StepStatus = "completed"
+	StepFailed    StepStatus = "failed"
+	StepSkipped   StepStatus = "skipped"
+)
+
+// stepStatusTransitions lists the statuses each status may move to. Failed steps return
+// to pending when a dead-lettered execution is resumed; completed and skipped are final.
+var stepStatusTransitions = map[StepStatus][]StepStatus{
+	StepPending: {StepRunning, StepFailed, StepSkipped},
+	StepRunning: {StepCompleted, StepFailed},
+	StepFailed:  {StepPending},
+}
+
+// StepStatusChange records one status transition of a step
+type StepStatusChange struct {
+	From StepStatus `json:"from"`
+	To   StepStatus `json:"to"`
+	At   time.Time  `json:"at"`
+}
+
+// CanTransitionTo reports whether a step in status s may move to next
+func (s StepStatus) CanTransitionTo(next StepStatus) bool {
+	for _, allowed := range stepStatusTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// transitionTo moves the step to next and records the change in its status history.
+// Steps without a status are treated as pending.
+func (s *ResolvedStep) transitionTo(next StepStatus) error {
+	current := s.Status
+	if current == "" {
+		current = StepPending
+	}
+	if !current.CanTransitionTo(next) {
+		return fmt.Errorf("invalid status transition for step %s: %s -> %s", s.ID, current, next)
+	}
+	s.Status = next
+	s.StatusHistory = append(s.StatusHistory, StepStatusChange{From: current, To: next, At: time.Now().UTC()})
+	return nil
+}
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// TestStepStatusTransitions verifies the step state machine accepts only valid transitions
+func TestStepStatusTransitions(t *testing.T) {
+	tests := []struct {
+		from  StepStatus
+		to    StepStatus
+		valid bool
+	}{
+		{StepPending, StepRunning, true},
+		{StepPending, StepSkipped, true},
+		{StepPending, StepFailed, true},
+		{StepRunning, StepCompleted, true},
+		{StepRunning, StepFailed, true},
+		{StepFailed, StepPending, true},
+		{StepPending, StepCompleted, false},
+		{StepCompleted, StepRunning, false},
+		{StepCompleted, StepPending, false},
+		{StepSkipped, StepRunning, false},
+		{StepRunning, StepRunning, false},
+	}
+	for _, tt := range tests {
+		step := &ResolvedStep{ID: "step1", Status: tt.from}
+		err := step.transitionTo(tt.to)
+		if tt.valid != (err == nil) {
+			t.Errorf("%s -> %s: expected valid=%t, got error %v", tt.from, tt.to, tt.valid, err)
+			continue
+		}
+		if err != nil && step.Status != tt.from {
+			t.Errorf("%s -> %s: rejected transition changed status to %s", tt.from, tt.to, step.Status)
+		}
+	}
+}
+
+// TestExecuteWorkflowRecordsStatusHistory verifies the engine records each step's transitions
+func TestExecuteWorkflowRecordsStatusHistory(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"providers": {"workspace": {"services": {"gmail": {"functions": {"send_message": {"name": "send_message"}}}}}}}`))
+	}))
+	defer server.Close()
+
+	ee := NewExecutionEngine(NewMCPService(server.URL))
+	plan := &ExecutionPlan{
+		Simulate: true,
+		ResolvedSteps: []ResolvedStep{
+			{ID: "notify", Service: "gmail", Action: "send_message", Status: StepPending, Inputs: map[string]interface{}{}},
+			{ID: "follow_up", Service: "gmail", Action: "send_message", Status: StepPending, DependsOn: []string{"missing"}},
+		},
+		ParameterContext: &ParameterContext{StepOutputs: map[string]interface{}{}},
+	}
+
+	if err := ee.ExecuteWorkflow(plan); err == nil {
+		t.Fatal("Expected unmet dependency to fail the workflow")
+	}
+
+	expected := map[string][]StepStatus{
+		"notify":    {StepRunning, StepCompleted},
+		"follow_up": {StepFailed},
+	}
+	for _, step := range plan.ResolvedSteps {
+		var history []StepStatus
+		for _, change := range step.StatusHistory {
+			history = append(history, change.To)
+		}
+		if !reflect.DeepEqual(history, expected[step.ID]) {
+			t.Errorf("Step %s: expected status history %v, got %v", step.ID, expected[step.ID], history)
+		}
+	}
+}
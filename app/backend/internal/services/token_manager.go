@@ -2,30 +2,69 @@ package services
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
+
+	"sohoaas-backend/internal/workflowparser"
 )
 
-// TokenManager handles secure storage and management of OAuth2 tokens
+// DefaultConnection is the connection name used when a request or step doesn't name one
+const DefaultConnection = "default"
+
+var (
+	// ErrTokenNotFound is returned when a user has no token stored for a connection
+	ErrTokenNotFound = errors.New("no Google token found")
+	// ErrTokenExpired is returned when a connection's stored token has expired
+	ErrTokenExpired = errors.New("Google token expired")
+)
+
+// googleTokenInfoURL reports the scopes granted to an access token
+var googleTokenInfoURL = "https://oauth2.googleapis.com/tokeninfo"
+
+// ValidateConnectionName checks a named workspace connection; empty means DefaultConnection
+func ValidateConnectionName(connection string) error {
+	return workflowparser.ValidateConnectionName(connection)
+}
+
+// connectionOrDefault maps an empty connection name to DefaultConnection
+func connectionOrDefault(connection string) string {
+	if connection == "" {
+		return DefaultConnection
+	}
+	return connection
+}
+
+// TokenManager handles secure storage and management of OAuth2 tokens.
+// Each user may register several named connections (e.g. "work", "personal"),
+// each backed by its own Google account token.
 type TokenManager struct {
-	tokens    map[string]*UserTokens // userID -> tokens
+	tokens    map[string]map[string]*UserTokens // userID -> connection -> tokens
 	mutex     sync.RWMutex
 	config    *oauth2.Config
+	storePath string // JSON file the tokens are persisted to; empty keeps them in memory only
 }
 
-// UserTokens stores OAuth2 tokens for a user
+// UserTokens stores OAuth2 tokens for one of a user's connections
 type UserTokens struct {
 	AccessToken  string    `json:"access_token"`
 	RefreshToken string    `json:"refresh_token"`
 	TokenType    string    `json:"token_type"`
 	Expiry       time.Time `json:"expiry"`
 	UserID       string    `json:"user_id"`
+	Connection   string    `json:"connection"`
 	Email        string    `json:"email"`
+	Scopes       []string  `json:"scopes,omitempty"` // granted scopes; nil when they could not be determined
 	UpdatedAt    time.Time `json:"updated_at"`
 }
 
@@ -46,62 +85,128 @@ func NewTokenManager() *TokenManager {
 	}
 
 	return &TokenManager{
-		tokens: make(map[string]*UserTokens),
+		tokens: make(map[string]map[string]*UserTokens),
 		config: config,
 	}
 }
 
-// StoreGoogleToken stores a Google OAuth2 access token for a user
-func (tm *TokenManager) StoreGoogleToken(userID, email, accessToken string) error {
-	tm.mutex.Lock()
-	defer tm.mutex.Unlock()
+// SetScopes replaces the default Google Workspace scopes, expanding short names like "gmail.readonly"
+func (tm *TokenManager) SetScopes(scopes []string) {
+	canonical := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if scope = canonicalOAuthScope(scope); scope != "" {
+			canonical = append(canonical, scope)
+		}
+	}
+	tm.config.Scopes = canonical
+}
 
-	// Validate token by making a test API call
+// StoreToken stores a Google OAuth2 access token for one of a user's named connections
+func (tm *TokenManager) StoreToken(userID, connection, email, accessToken string) error {
+	if err := ValidateConnectionName(connection); err != nil {
+		return err
+	}
+	connection = connectionOrDefault(connection)
+
+	// Validate token by making a test API call; Google is called before taking the lock so
+	// token lookups aren't blocked on the network
 	if err := tm.validateGoogleToken(accessToken); err != nil {
 		return fmt.Errorf("invalid Google token: %v", err)
 	}
 
+	// Record the granted scopes so executions can check them; unknown scopes don't block storing
+	scopes, err := fetchGrantedScopes(accessToken)
+	if err != nil {
+		log.Printf("[TokenManager] WARNING: Could not determine scopes of the token for user %s connection %s: %v", userID, connection, err)
+	}
+
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+
+	if tm.tokens[userID] == nil {
+		tm.tokens[userID] = make(map[string]*UserTokens)
+	}
+
 	// Store token with metadata
-	tm.tokens[userID] = &UserTokens{
+	tm.tokens[userID][connection] = &UserTokens{
 		AccessToken:  accessToken,
 		RefreshToken: "", // Firebase doesn't provide refresh tokens directly
 		TokenType:    "Bearer",
 		Expiry:       time.Now().Add(1 * time.Hour), // Google tokens typically expire in 1 hour
 		UserID:       userID,
+		Connection:   connection,
 		Email:        email,
+		Scopes:       scopes,
 		UpdatedAt:    time.Now(),
 	}
 
-	log.Printf("[TokenManager] Stored Google token for user %s (%s)", userID, email)
+	tm.persist()
+
+	log.Printf("[TokenManager] Stored Google token for user %s connection %s (%s)", userID, connection, email)
 	return nil
 }
 
-// GetGoogleToken retrieves a valid Google OAuth2 token for a user
-func (tm *TokenManager) GetGoogleToken(userID string) (string, error) {
+// GetToken retrieves a valid Google OAuth2 token for one of a user's connections
+func (tm *TokenManager) GetToken(userID, connection string) (string, error) {
 	tm.mutex.RLock()
 	defer tm.mutex.RUnlock()
 
-	userTokens, exists := tm.tokens[userID]
-	if !exists {
-		return "", fmt.Errorf("no Google token found for user %s", userID)
+	userTokens, err := tm.lookup(userID, connection)
+	if err != nil {
+		return "", err
 	}
 
 	// Check if token is expired
 	if time.Now().After(userTokens.Expiry) {
-		return "", fmt.Errorf("Google token expired for user %s", userID)
+		return "", fmt.Errorf("%w for user %s connection %s", ErrTokenExpired, userID, userTokens.Connection)
 	}
 
 	return userTokens.AccessToken, nil
 }
 
-// RefreshGoogleToken attempts to refresh an expired Google token
-func (tm *TokenManager) RefreshGoogleToken(userID string) error {
+// GrantedScopes returns the scopes granted to the token of one of a user's connections, or
+// nil when they could not be determined when it was stored
+func (tm *TokenManager) GrantedScopes(userID, connection string) ([]string, error) {
+	tm.mutex.RLock()
+	defer tm.mutex.RUnlock()
+
+	userTokens, err := tm.lookup(userID, connection)
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(userTokens.Expiry) {
+		return nil, fmt.Errorf("%w for user %s connection %s", ErrTokenExpired, userID, userTokens.Connection)
+	}
+	return userTokens.Scopes, nil
+}
+
+// HasConnection reports whether the user has registered the named connection
+func (tm *TokenManager) HasConnection(userID, connection string) bool {
+	tm.mutex.RLock()
+	defer tm.mutex.RUnlock()
+
+	_, err := tm.lookup(userID, connection)
+	return err == nil
+}
+
+// lookup finds a stored connection; callers must hold the mutex
+func (tm *TokenManager) lookup(userID, connection string) (*UserTokens, error) {
+	connection = connectionOrDefault(connection)
+	userTokens, exists := tm.tokens[userID][connection]
+	if !exists {
+		return nil, fmt.Errorf("%w for user %s connection %s", ErrTokenNotFound, userID, connection)
+	}
+	return userTokens, nil
+}
+
+// RefreshToken attempts to refresh an expired Google token for a connection
+func (tm *TokenManager) RefreshToken(userID, connection string) error {
 	tm.mutex.Lock()
 	defer tm.mutex.Unlock()
 
-	userTokens, exists := tm.tokens[userID]
-	if !exists {
-		return fmt.Errorf("no tokens found for user %s", userID)
+	userTokens, err := tm.lookup(userID, connection)
+	if err != nil {
+		return err
 	}
 
 	if userTokens.RefreshToken == "" {
@@ -127,26 +232,28 @@ func (tm *TokenManager) RefreshGoogleToken(userID string) error {
 	userTokens.AccessToken = newToken.AccessToken
 	userTokens.Expiry = newToken.Expiry
 	userTokens.UpdatedAt = time.Now()
+	tm.persist()
 
-	log.Printf("[TokenManager] Refreshed Google token for user %s", userID)
+	log.Printf("[TokenManager] Refreshed Google token for user %s connection %s", userID, userTokens.Connection)
 	return nil
 }
 
-// ValidateUserToken ensures the user owns the provided token
+// ValidateUserToken ensures the user owns the provided token on one of their connections
 func (tm *TokenManager) ValidateUserToken(userID, providedToken string) error {
 	tm.mutex.RLock()
 	defer tm.mutex.RUnlock()
 
-	userTokens, exists := tm.tokens[userID]
-	if !exists {
+	connections, exists := tm.tokens[userID]
+	if !exists || len(connections) == 0 {
 		return fmt.Errorf("no tokens found for user %s", userID)
 	}
 
-	if userTokens.AccessToken != providedToken {
-		return fmt.Errorf("token mismatch for user %s", userID)
+	for _, userTokens := range connections {
+		if userTokens.AccessToken == providedToken {
+			return nil
+		}
 	}
-
-	return nil
+	return fmt.Errorf("token mismatch for user %s", userID)
 }
 
 // CleanupExpiredTokens removes expired tokens from memory
@@ -155,42 +262,75 @@ func (tm *TokenManager) CleanupExpiredTokens() {
 	defer tm.mutex.Unlock()
 
 	now := time.Now()
-	for userID, tokens := range tm.tokens {
-		if now.After(tokens.Expiry.Add(24 * time.Hour)) { // Keep for 24h after expiry
+	removed := false
+	for userID, connections := range tm.tokens {
+		for connection, tokens := range connections {
+			if now.After(tokens.Expiry.Add(24 * time.Hour)) { // Keep for 24h after expiry
+				delete(connections, connection)
+				removed = true
+				log.Printf("[TokenManager] Cleaned up expired token for user %s connection %s", userID, connection)
+			}
+		}
+		if len(connections) == 0 {
 			delete(tm.tokens, userID)
-			log.Printf("[TokenManager] Cleaned up expired token for user %s", userID)
 		}
 	}
+	if removed {
+		tm.persist()
+	}
 }
 
-// GetTokenInfo returns token metadata without exposing the actual token
-func (tm *TokenManager) GetTokenInfo(userID string) (*TokenInfo, error) {
+// GetTokenInfo returns token metadata for a connection without exposing the actual token
+func (tm *TokenManager) GetTokenInfo(userID, connection string) (*TokenInfo, error) {
 	tm.mutex.RLock()
 	defer tm.mutex.RUnlock()
 
-	userTokens, exists := tm.tokens[userID]
-	if !exists {
-		return nil, fmt.Errorf("no tokens found for user %s", userID)
+	userTokens, err := tm.lookup(userID, connection)
+	if err != nil {
+		return nil, err
 	}
+	return userTokens.info(), nil
+}
+
+// ListConnections returns metadata for all of a user's connections, sorted by name
+func (tm *TokenManager) ListConnections(userID string) []*TokenInfo {
+	tm.mutex.RLock()
+	defer tm.mutex.RUnlock()
 
+	connections := make([]*TokenInfo, 0, len(tm.tokens[userID]))
+	for _, userTokens := range tm.tokens[userID] {
+		connections = append(connections, userTokens.info())
+	}
+	sort.Slice(connections, func(i, j int) bool {
+		return connections[i].Connection < connections[j].Connection
+	})
+	return connections
+}
+
+// info converts stored tokens to their public metadata
+func (ut *UserTokens) info() *TokenInfo {
 	return &TokenInfo{
-		UserID:    userTokens.UserID,
-		Email:     userTokens.Email,
-		TokenType: userTokens.TokenType,
-		Expiry:    userTokens.Expiry,
-		IsExpired: time.Now().After(userTokens.Expiry),
-		UpdatedAt: userTokens.UpdatedAt,
-	}, nil
+		UserID:     ut.UserID,
+		Connection: ut.Connection,
+		Email:      ut.Email,
+		TokenType:  ut.TokenType,
+		Expiry:     ut.Expiry,
+		IsExpired:  time.Now().After(ut.Expiry),
+		Scopes:     ut.Scopes,
+		UpdatedAt:  ut.UpdatedAt,
+	}
 }
 
 // TokenInfo provides token metadata without exposing sensitive data
 type TokenInfo struct {
-	UserID    string    `json:"user_id"`
-	Email     string    `json:"email"`
-	TokenType string    `json:"token_type"`
-	Expiry    time.Time `json:"expiry"`
-	IsExpired bool      `json:"is_expired"`
-	UpdatedAt time.Time `json:"updated_at"`
+	UserID     string    `json:"user_id"`
+	Connection string    `json:"connection"`
+	Email      string    `json:"email"`
+	TokenType  string    `json:"token_type"`
+	Expiry     time.Time `json:"expiry"`
+	IsExpired  bool      `json:"is_expired"`
+	Scopes     []string  `json:"scopes,omitempty"`
+	UpdatedAt  time.Time `json:"updated_at"`
 }
 
 // validateGoogleToken validates a Google OAuth2 token by making a test API call
@@ -213,6 +353,28 @@ func (tm *TokenManager) validateGoogleToken(token string) error {
 	return nil
 }
 
+// fetchGrantedScopes asks Google's tokeninfo endpoint which scopes an access token carries.
+// The token is POSTed in the form body so it never appears in a URL, and thus in errors or logs.
+func fetchGrantedScopes(token string) ([]string, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.PostForm(googleTokenInfoURL, url.Values{"access_token": {token}})
+	if err != nil {
+		return nil, fmt.Errorf("tokeninfo request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("tokeninfo returned status %d", resp.StatusCode)
+	}
+
+	var tokenInfo struct {
+		Scope string `json:"scope"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenInfo); err != nil {
+		return nil, fmt.Errorf("failed to decode tokeninfo response: %w", err)
+	}
+	return strings.Fields(tokenInfo.Scope), nil
+}
+
 // StartCleanupRoutine starts a background routine to clean up expired tokens
 func (tm *TokenManager) StartCleanupRoutine() {
 	go func() {
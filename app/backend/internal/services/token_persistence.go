@@ -0,0 +1,88 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// SetStorePath persists stored tokens to a JSON file at path so users' connections survive a
+// restart. Tokens already in the file are loaded, except those past their expiry.
+func (tm *TokenManager) SetStorePath(path string) error {
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read token store %s: %w", path, err)
+	}
+	if len(data) > 0 {
+		var stored map[string]map[string]*UserTokens
+		if err := json.Unmarshal(data, &stored); err != nil {
+			return fmt.Errorf("failed to decode token store %s: %w", path, err)
+		}
+		now := time.Now()
+		for userID, connections := range stored {
+			for connection, tokens := range connections {
+				if tokens == nil || now.After(tokens.Expiry) {
+					continue
+				}
+				if tm.tokens[userID] == nil {
+					tm.tokens[userID] = make(map[string]*UserTokens)
+				}
+				tm.tokens[userID][connection] = tokens
+			}
+		}
+	}
+
+	tm.storePath = path
+	return tm.writeStore()
+}
+
+// Persistent reports whether stored tokens survive a restart
+func (tm *TokenManager) Persistent() bool {
+	tm.mutex.RLock()
+	defer tm.mutex.RUnlock()
+	return tm.storePath != ""
+}
+
+// writeStore writes every stored token to the token store file, if one is set. The file is
+// replaced atomically and readable only by its owner. Callers must hold the mutex.
+func (tm *TokenManager) writeStore() error {
+	if tm.storePath == "" {
+		return nil
+	}
+	data, err := json.Marshal(tm.tokens)
+	if err != nil {
+		return fmt.Errorf("failed to encode token store: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(tm.storePath), ".tokens-*")
+	if err != nil {
+		return fmt.Errorf("failed to write token store %s: %w", tm.storePath, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write token store %s: %w", tm.storePath, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write token store %s: %w", tm.storePath, err)
+	}
+	if err := os.Rename(tmp.Name(), tm.storePath); err != nil {
+		return fmt.Errorf("failed to write token store %s: %w", tm.storePath, err)
+	}
+	return nil
+}
+
+// persist saves the tokens after a change, logging failures; the in-memory tokens stay
+// authoritative. Callers must hold the mutex.
+func (tm *TokenManager) persist() {
+	if err := tm.writeStore(); err != nil {
+		log.Printf("[TokenManager] WARNING: %v", err)
+	}
+}
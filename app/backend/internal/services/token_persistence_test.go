@@ -0,0 +1,61 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestTokenStoreSurvivesRestart verifies a new token manager reloads persisted tokens, skipping expired ones
+func TestTokenStoreSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.json")
+
+	tm := NewTokenManager()
+	if tm.Persistent() {
+		t.Error("Expected tokens in memory only without a store path")
+	}
+	if err := tm.SetStorePath(path); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !tm.Persistent() {
+		t.Error("Expected tokens to be persisted with a store path")
+	}
+	tm.mutex.Lock()
+	tm.tokens["user-1"] = map[string]*UserTokens{
+		DefaultConnection: {AccessToken: "live-token", Expiry: time.Now().Add(30 * time.Minute), UserID: "user-1", Connection: DefaultConnection},
+		"old":             {AccessToken: "old-token", Expiry: time.Now().Add(-time.Minute), UserID: "user-1", Connection: "old"},
+	}
+	tm.persist()
+	tm.mutex.Unlock()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Expected the token store to be written: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected the token store readable only by its owner, got %v", info.Mode().Perm())
+	}
+
+	restarted := NewTokenManager()
+	if err := restarted.SetStorePath(path); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if token, err := restarted.GetToken("user-1", ""); err != nil || token != "live-token" {
+		t.Errorf("Expected the persisted token after a restart, got %q (%v)", token, err)
+	}
+	if restarted.HasConnection("user-1", "old") {
+		t.Error("Expected the expired token not to be reloaded")
+	}
+}
+
+// TestTokenStoreRejectsCorruptFile verifies an unreadable store is reported instead of silently dropped
+func TestTokenStoreRejectsCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewTokenManager().SetStorePath(path); err == nil {
+		t.Error("Expected an error for a corrupt token store")
+	}
+}
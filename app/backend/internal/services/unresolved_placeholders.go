@@ -0,0 +1,51 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ErrUnresolvedPlaceholder is returned when a step's resolved inputs still contain a
+// parameter placeholder, which MCP would otherwise receive as a literal string
+var ErrUnresolvedPlaceholder = errors.New("unresolved placeholder")
+
+// unresolvedPlaceholderPattern matches the placeholders parameter resolution leaves behind:
+// ${RUNTIME:step.field}, ${steps.<id>.outputs.<field>} and ${user.<param>}
+var unresolvedPlaceholderPattern = regexp.MustCompile(`\$\{(?:RUNTIME:[^}]*|steps\.[^}]*|user\.[^}]*)\}`)
+
+// checkResolvedInputs fails a step whose resolved inputs still contain placeholders,
+// naming each offending field (nested fields as "field.key" and "field[0]")
+func checkResolvedInputs(step *ResolvedStep, resolvedInputs map[string]interface{}) error {
+	var problems []string
+	for field, value := range resolvedInputs {
+		problems = append(problems, findUnresolvedPlaceholders(field, value)...)
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	sort.Strings(problems)
+	return fmt.Errorf("%w in step %s (%s.%s) inputs: %s", ErrUnresolvedPlaceholder, step.ID, step.Service, step.Action, strings.Join(problems, ", "))
+}
+
+// findUnresolvedPlaceholders returns "path (placeholder)" for each placeholder in value
+func findUnresolvedPlaceholders(path string, value interface{}) []string {
+	var problems []string
+	switch v := value.(type) {
+	case string:
+		for _, placeholder := range unresolvedPlaceholderPattern.FindAllString(v, -1) {
+			problems = append(problems, fmt.Sprintf("%s (%s)", path, placeholder))
+		}
+	case map[string]interface{}:
+		for key, nested := range v {
+			problems = append(problems, findUnresolvedPlaceholders(path+"."+key, nested)...)
+		}
+	case []interface{}:
+		for i, nested := range v {
+			problems = append(problems, findUnresolvedPlaceholders(fmt.Sprintf("%s[%d]", path, i), nested)...)
+		}
+	}
+	return problems
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestCheckResolvedInputs verifies every leftover placeholder is reported with its field path
+func TestCheckResolvedInputs(t *testing.T) {
+	step := &ResolvedStep{ID: "send", Service: "gmail", Action: "send_message"}
+
+	if err := checkResolvedInputs(step, map[string]interface{}{"to": "team@example.com", "body": "Cost: ${5}"}); err != nil {
+		t.Errorf("Expected resolved inputs to pass, got %v", err)
+	}
+
+	err := checkResolvedInputs(step, map[string]interface{}{
+		"to":   "${user.recipient}",
+		"body": "Link: ${RUNTIME:create.document_url}",
+		"cc":   []interface{}{"ok@example.com", "${steps.lookup.outputs.email}"},
+		"meta": map[string]interface{}{"title": "${steps.create.outputs.title}"},
+	})
+	if !errors.Is(err, ErrUnresolvedPlaceholder) {
+		t.Fatalf("Expected ErrUnresolvedPlaceholder, got %v", err)
+	}
+	for _, expected := range []string{
+		"body (${RUNTIME:create.document_url})",
+		"cc[1] (${steps.lookup.outputs.email})",
+		"meta.title (${steps.create.outputs.title})",
+		"to (${user.recipient})",
+		"step send (gmail.send_message)",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error to contain %q, got %v", expected, err)
+		}
+	}
+}
+
+// TestExecuteStepRejectsRuntimePlaceholder verifies a leftover placeholder fails the step before MCP is called
+func TestExecuteStepRejectsRuntimePlaceholder(t *testing.T) {
+	actionCalls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			actionCalls++
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+	ee := NewExecutionEngine(NewMCPService(server.URL))
+
+	step := &ResolvedStep{
+		ID:      "send",
+		Service: "gmail",
+		Action:  "send_message",
+		Inputs:  map[string]interface{}{"body": "$(create.outputs.document_url)"},
+		Outputs: map[string]interface{}{},
+	}
+	paramContext := &ParameterContext{
+		StepOutputs:      map[string]interface{}{},
+		SystemParameters: map[string]interface{}{"oauth_token": "token"},
+	}
+
+	err := ee.executeStep(step, paramContext)
+	if !errors.Is(err, ErrUnresolvedPlaceholder) || !strings.Contains(err.Error(), "body (${RUNTIME:create.document_url})") {
+		t.Fatalf("Expected the body placeholder to be rejected, got %v", err)
+	}
+	if actionCalls != 0 {
+		t.Errorf("Expected no MCP action call, got %d", actionCalls)
+	}
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"sohoaas-backend/internal/types"
+)
+
+var userParameterName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// SetUserParameterSynthesis controls whether generated workflows get a user_parameters entry
+// for every ${user.*} reference the LLM left undeclared (enabled by default)
+func (g *GenkitService) SetUserParameterSynthesis(enabled bool) {
+	g.synthesizeUserParameters = enabled
+}
+
+// reconcileUserParameters declares the user parameters a generated workflow's steps reference
+// but its user_parameters lack, as required strings prompted for by their humanized name, so
+// the workflow is runnable. It returns the names added, sorted.
+func reconcileUserParameters(output *WorkflowGeneratorOutput) []string {
+	if output.UserParameters == nil {
+		output.UserParameters = make(map[string]types.UserParameter)
+	}
+
+	var added []string
+	for _, step := range output.Steps {
+		for _, name := range referencedUserParameters(step.Parameters) {
+			if _, declared := output.UserParameters[name]; declared {
+				continue
+			}
+			label := parameterLabel(name)
+			output.UserParameters[name] = types.UserParameter{
+				Name:        name,
+				Type:        "string",
+				Required:    true,
+				Description: fmt.Sprintf("%s (used by step %s)", label, step.ID),
+				Prompt:      label,
+			}
+			added = append(added, name)
+		}
+	}
+	sort.Strings(added)
+	return added
+}
+
+// referencedUserParameters returns the parameter names of the ${user.*} references in a
+// step's parameters, in sorted order. ${user.upload.name} references parameter upload;
+// references with a transform (${user.name | upper}) count by the name before the pipe.
+func referencedUserParameters(parameters map[string]interface{}) []string {
+	userRefs, _ := extractParameterRefsFromMap(parameters)
+	names := make(map[string]bool)
+	for _, ref := range userRefs {
+		reference := strings.TrimSpace(strings.Split(ref, "|")[0])
+		name := strings.SplitN(reference, ".", 2)[0]
+		if userParameterName.MatchString(name) {
+			names[name] = true
+		}
+	}
+
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// parameterLabel turns a parameter name into a readable label: recipient_email and
+// recipientEmail both become "Recipient email"
+func parameterLabel(name string) string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, strings.ToLower(string(word)))
+			word = word[:0]
+		}
+	}
+	runes := []rune(name)
+	for i, r := range runes {
+		switch {
+		case r == '_' || r == '-':
+			flush()
+		case unicode.IsUpper(r) && i > 0 && !unicode.IsUpper(runes[i-1]):
+			flush()
+			word = append(word, r)
+		default:
+			word = append(word, r)
+		}
+	}
+	flush()
+
+	if len(words) == 0 {
+		return name
+	}
+	label := []rune(strings.Join(words, " "))
+	label[0] = unicode.ToUpper(label[0])
+	return string(label)
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"sohoaas-backend/internal/types"
+)
+
+func TestReconcileUserParametersAddsMissing(t *testing.T) {
+	output := WorkflowGeneratorOutput{
+		Steps: []types.WorkflowStep{
+			{
+				ID:     "create_doc",
+				Action: "docs.create_document",
+				Parameters: map[string]interface{}{
+					"title": "Report for ${user.clientName}",
+					"template": map[string]interface{}{
+						"sections": []interface{}{"${user.report_period}", "${steps.fetch.outputs.summary}"},
+					},
+				},
+			},
+			{
+				ID:     "send_email",
+				Action: "gmail.send_message",
+				Parameters: map[string]interface{}{
+					"to":         "${user.recipient_email}",
+					"subject":    "${user.clientName | upper}",
+					"attachment": "${user.upload.name}",
+				},
+			},
+		},
+		UserParameters: map[string]types.UserParameter{
+			"recipient_email": {Name: "recipient_email", Type: "email", Required: true, Prompt: "Who should receive it?"},
+		},
+	}
+
+	added := reconcileUserParameters(&output)
+	if want := []string{"clientName", "report_period", "upload"}; !reflect.DeepEqual(added, want) {
+		t.Fatalf("expected %v added, got %v", want, added)
+	}
+
+	client := output.UserParameters["clientName"]
+	if client.Name != "clientName" || client.Type != "string" || !client.Required || client.Prompt != "Client name" {
+		t.Errorf("unexpected synthesized parameter: %+v", client)
+	}
+	if client.Description != "Client name (used by step create_doc)" {
+		t.Errorf("expected the description to name the first referencing step, got %q", client.Description)
+	}
+	if period := output.UserParameters["report_period"]; period.Prompt != "Report period" {
+		t.Errorf("expected prompt %q, got %q", "Report period", period.Prompt)
+	}
+
+	// Declared parameters are left alone
+	if recipient := output.UserParameters["recipient_email"]; recipient.Type != "email" || recipient.Prompt != "Who should receive it?" {
+		t.Errorf("expected the declared parameter unchanged, got %+v", recipient)
+	}
+}
+
+func TestReconcileUserParametersNothingMissing(t *testing.T) {
+	output := WorkflowGeneratorOutput{
+		Steps: []types.WorkflowStep{{
+			ID:         "send_email",
+			Parameters: map[string]interface{}{"to": "${user.recipient}", "body": "Hello"},
+		}},
+	}
+
+	added := reconcileUserParameters(&output)
+	if len(added) != 1 || added[0] != "recipient" {
+		t.Fatalf("expected recipient added to nil user_parameters, got %v", added)
+	}
+	if again := reconcileUserParameters(&output); len(again) != 0 {
+		t.Errorf("expected nothing added on a second pass, got %v", again)
+	}
+}
+
+func TestParameterLabel(t *testing.T) {
+	tests := map[string]string{
+		"recipient_email": "Recipient email",
+		"recipientEmail":  "Recipient email",
+		"CC":              "Cc",
+		"due-date":        "Due date",
+		"name":            "Name",
+	}
+	for name, want := range tests {
+		if got := parameterLabel(name); got != want {
+			t.Errorf("parameterLabel(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
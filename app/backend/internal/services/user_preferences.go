@@ -0,0 +1,74 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// UserPreferences holds per-user settings applied to workflow execution
+type UserPreferences struct {
+	UserID    string    `json:"user_id"`
+	Timezone  string    `json:"timezone,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// UserPreferencesService stores user preferences in memory
+type UserPreferencesService struct {
+	preferences map[string]*UserPreferences
+	mutex       sync.RWMutex
+}
+
+// NewUserPreferencesService creates a new user preferences service
+func NewUserPreferencesService() *UserPreferencesService {
+	return &UserPreferencesService{
+		preferences: make(map[string]*UserPreferences),
+	}
+}
+
+// SetTimezone validates and stores the user's IANA timezone (e.g. "Europe/Sofia")
+func (s *UserPreferencesService) SetTimezone(userID, timezone string) (*UserPreferences, error) {
+	if timezone == "" {
+		return nil, fmt.Errorf("timezone is required")
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", timezone, err)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	prefs, exists := s.preferences[userID]
+	if !exists {
+		prefs = &UserPreferences{UserID: userID}
+		s.preferences[userID] = prefs
+	}
+	prefs.Timezone = timezone
+	prefs.UpdatedAt = time.Now()
+
+	copied := *prefs
+	return &copied, nil
+}
+
+// GetTimezone returns the stored timezone for a user, or empty string if none is set
+func (s *UserPreferencesService) GetTimezone(userID string) string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if prefs, exists := s.preferences[userID]; exists {
+		return prefs.Timezone
+	}
+	return ""
+}
+
+// GetPreferences returns a copy of the user's preferences
+func (s *UserPreferencesService) GetPreferences(userID string) *UserPreferences {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if prefs, exists := s.preferences[userID]; exists {
+		copied := *prefs
+		return &copied
+	}
+	return &UserPreferences{UserID: userID}
+}
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"testing"
+)
+
+// TestUserPreferencesTimezone verifies timezone validation and storage
+func TestUserPreferencesTimezone(t *testing.T) {
+	prefs := NewUserPreferencesService()
+
+	if tz := prefs.GetTimezone("user_1"); tz != "" {
+		t.Errorf("Expected no stored timezone, got %q", tz)
+	}
+
+	if _, err := prefs.SetTimezone("user_1", "Mars/Olympus_Mons"); err == nil {
+		t.Error("Expected error for invalid timezone")
+	}
+	if _, err := prefs.SetTimezone("user_1", ""); err == nil {
+		t.Error("Expected error for empty timezone")
+	}
+
+	stored, err := prefs.SetTimezone("user_1", "Europe/Sofia")
+	if err != nil {
+		t.Fatalf("SetTimezone failed: %v", err)
+	}
+	if stored.Timezone != "Europe/Sofia" {
+		t.Errorf("Expected Europe/Sofia, got %q", stored.Timezone)
+	}
+	if tz := prefs.GetTimezone("user_1"); tz != "Europe/Sofia" {
+		t.Errorf("Expected stored Europe/Sofia, got %q", tz)
+	}
+	if tz := prefs.GetTimezone("user_2"); tz != "" {
+		t.Errorf("Preferences leaked across users: %q", tz)
+	}
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"fmt"
+	"net/mail"
+	"sync"
+	"time"
+
+	"sohoaas-backend/internal/types"
+)
+
+// UserProfile holds per-user values that seed workflow parameters the user would otherwise type
+type UserProfile struct {
+	UserID      string            `json:"user_id"`
+	DisplayName string            `json:"display_name,omitempty"`
+	TeamEmail   string            `json:"team_email,omitempty"` // team distribution list address
+	Values      map[string]string `json:"values,omitempty"`     // further defaults, keyed by parameter name
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+// UserProfileService stores user profiles in memory
+type UserProfileService struct {
+	profiles map[string]*UserProfile
+	mutex    sync.RWMutex
+}
+
+// NewUserProfileService creates a new user profile service
+func NewUserProfileService() *UserProfileService {
+	return &UserProfileService{
+		profiles: make(map[string]*UserProfile),
+	}
+}
+
+// UpdateProfile validates and replaces the user's profile
+func (s *UserProfileService) UpdateProfile(userID string, profile UserProfile) (*UserProfile, error) {
+	if profile.TeamEmail != "" {
+		if _, err := mail.ParseAddress(profile.TeamEmail); err != nil {
+			return nil, fmt.Errorf("invalid team email %q: %w", profile.TeamEmail, err)
+		}
+	}
+
+	stored := &UserProfile{
+		UserID:      userID,
+		DisplayName: profile.DisplayName,
+		TeamEmail:   profile.TeamEmail,
+		Values:      make(map[string]string, len(profile.Values)),
+		UpdatedAt:   time.Now(),
+	}
+	for name, value := range profile.Values {
+		stored.Values[name] = value
+	}
+
+	s.mutex.Lock()
+	s.profiles[userID] = stored
+	s.mutex.Unlock()
+
+	return stored.copy(), nil
+}
+
+// GetProfile returns a copy of the user's profile
+func (s *UserProfileService) GetProfile(userID string) *UserProfile {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if profile, exists := s.profiles[userID]; exists {
+		return profile.copy()
+	}
+	return &UserProfile{UserID: userID}
+}
+
+// copy returns a copy of the profile that doesn't share its values map
+func (p *UserProfile) copy() *UserProfile {
+	copied := *p
+	copied.Values = make(map[string]string, len(p.Values))
+	for name, value := range p.Values {
+		copied.Values[name] = value
+	}
+	return &copied
+}
+
+// SetUserProfiles enables parameter defaults from stored user profiles
+func (ee *ExecutionEngine) SetUserProfiles(profiles *UserProfileService) {
+	ee.userProfiles = profiles
+}
+
+// UserProfiles returns the user profile store, or nil when not configured
+func (ee *ExecutionEngine) UserProfiles() *UserProfileService {
+	return ee.userProfiles
+}
+
+// profileParameterValue returns the user's profile value for a parameter name, if any:
+// display name, team distribution list, stored timezone, or a custom profile value
+func (ee *ExecutionEngine) profileParameterValue(paramName string, user *types.User) (string, bool) {
+	profile := &UserProfile{UserID: user.ID}
+	if ee.userProfiles != nil {
+		profile = ee.userProfiles.GetProfile(user.ID)
+	}
+
+	var value string
+	switch paramName {
+	case "display_name", "user_name", "full_name":
+		value = profile.DisplayName
+		if value == "" {
+			value = user.Name
+		}
+	case "team_email", "team_distribution_list":
+		value = profile.TeamEmail
+	case "timezone", "user_timezone", "default_timezone":
+		if ee.userPreferences != nil {
+			value = ee.userPreferences.GetTimezone(user.ID)
+		}
+	}
+	if custom, exists := profile.Values[paramName]; exists && value == "" {
+		value = custom
+	}
+	return value, value != ""
+}
+
+// applyProfileParameters seeds declared parameters that neither the request nor the
+// workflow's defaults supplied from the user's profile
+func (ee *ExecutionEngine) applyProfileParameters(context *ParameterContext, declared []WorkflowInput, user *types.User) {
+	for _, input := range declared {
+		if value, exists := context.UserParameters[input.Name]; exists && value != nil {
+			continue
+		}
+		if value, found := ee.profileParameterValue(input.Name, user); found {
+			context.UserParameters[input.Name] = value
+		}
+	}
+}
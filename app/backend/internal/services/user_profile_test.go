@@ -0,0 +1,64 @@
+package services
+
+import (
+	"testing"
+
+	"sohoaas-backend/internal/types"
+)
+
+// TestUserProfileUpdate verifies team email validation and that stored profiles are copies
+func TestUserProfileUpdate(t *testing.T) {
+	profiles := NewUserProfileService()
+
+	if _, err := profiles.UpdateProfile("user_1", UserProfile{TeamEmail: "not-an-email"}); err == nil {
+		t.Error("Expected an invalid team email to be rejected")
+	}
+
+	values := map[string]string{"department": "Sales"}
+	if _, err := profiles.UpdateProfile("user_1", UserProfile{DisplayName: "Ana", TeamEmail: "team@example.com", Values: values}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	values["department"] = "Changed"
+
+	profile := profiles.GetProfile("user_1")
+	if profile.DisplayName != "Ana" || profile.TeamEmail != "team@example.com" || profile.Values["department"] != "Sales" {
+		t.Errorf("Unexpected profile: %+v", profile)
+	}
+	if profile := profiles.GetProfile("user_2"); profile.UserID != "user_2" || profile.DisplayName != "" {
+		t.Errorf("Expected an empty profile for an unknown user, got %+v", profile)
+	}
+}
+
+// TestApplyProfileParameters verifies profile values fill only declared parameters left unset
+func TestApplyProfileParameters(t *testing.T) {
+	ee := NewExecutionEngine(nil)
+	prefs := NewUserPreferencesService()
+	prefs.SetTimezone("user_1", "Europe/Sofia")
+	ee.SetUserPreferences(prefs)
+	profiles := NewUserProfileService()
+	profiles.UpdateProfile("user_1", UserProfile{TeamEmail: "team@example.com", Values: map[string]string{"department": "Sales"}})
+	ee.SetUserProfiles(profiles)
+
+	user := &types.User{ID: "user_1", Email: "user@example.com", Name: "Ana Petrova"}
+	context := ee.createParameterContext(map[string]interface{}{
+		"user_parameters": map[string]interface{}{"team_email": "other@example.com"},
+	}, map[string]interface{}{"department": "Support"}, user, "token", "UTC")
+	ee.applyProfileParameters(context, []WorkflowInput{
+		{Name: "team_email"}, {Name: "department"}, {Name: "display_name"}, {Name: "timezone"}, {Name: "subject"},
+	}, user)
+
+	expected := map[string]interface{}{
+		"team_email":   "other@example.com", // provided by the request
+		"department":   "Support",           // the workflow's default
+		"display_name": "Ana Petrova",       // falls back to the signed-in user's name
+		"timezone":     "Europe/Sofia",
+	}
+	for name, value := range expected {
+		if context.UserParameters[name] != value {
+			t.Errorf("Expected %s %v, got %v", name, value, context.UserParameters[name])
+		}
+	}
+	if _, exists := context.UserParameters["subject"]; exists {
+		t.Errorf("Expected subject to stay unset, got %v", context.UserParameters["subject"])
+	}
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"fmt"
+	"log"
+
+	"sohoaas-backend/internal/types"
+)
+
+// collectValidationWarnings returns non-blocking findings about a workflow that passed
+// validation, starting with those found while parsing it. A reference to an output of an
+// action without an output schema can't be checked before the step runs, so it is reported
+// rather than rejected.
+func (ee *ExecutionEngine) collectValidationWarnings(workflow *ParsedWorkflow) []string {
+	warnings := append([]string(nil), workflow.Warnings...)
+	if ee.mcpService == nil {
+		return warnings
+	}
+	catalog, err := ee.mcpService.GetServiceCatalog()
+	if err != nil {
+		log.Printf("[ExecutionEngine] Skipping catalog validation warnings, catalog unavailable: %v", err)
+		return warnings
+	}
+
+	steps := make(map[string]WorkflowStep, len(workflow.Steps))
+	for _, step := range workflow.Steps {
+		steps[step.ID] = step
+	}
+
+	for _, step := range workflow.Steps {
+		for _, ref := range referencedStepOutputs(step.Inputs) {
+			target, exists := steps[ref.stepID]
+			if !exists || hasOutputSchema(catalog, target) {
+				continue
+			}
+			warnings = append(warnings, fmt.Sprintf("Step %s: output %s of step %s can't be verified, %s.%s declares no output schema",
+				step.ID, ref.field, ref.stepID, target.Service, target.Action))
+		}
+	}
+	return warnings
+}
+
+// hasOutputSchema reports whether the catalog declares output properties for a step's action
+func hasOutputSchema(catalog *types.MCPServiceCatalog, step WorkflowStep) bool {
+	_, serviceDefinition, err := catalog.ResolveService(step.Provider, step.Service)
+	if err != nil {
+		return false
+	}
+	functionSchema, exists := serviceDefinition.Functions[step.Action]
+	return exists && functionSchema.OutputSchema != nil && functionSchema.OutputSchema.Properties != nil
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// TestCollectValidationWarnings verifies references to actions without an output schema are warnings
+func TestCollectValidationWarnings(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"providers": {"workspace": {"services": {
+			"docs": {"functions": {"create_document": {"name": "create_document",
+				"output_schema": {"type": "object", "properties": {"document_id": {"type": "string"}}}}}},
+			"gmail": {"functions": {"list_messages": {"name": "list_messages"}, "send_message": {"name": "send_message"}}}}}}}`))
+	}))
+	defer server.Close()
+
+	ee := NewExecutionEngine(NewMCPService(server.URL))
+	workflow := &ParsedWorkflow{
+		Steps: []WorkflowStep{
+			{ID: "fetch", Service: "gmail", Action: "list_messages"},
+			{ID: "doc", Service: "docs", Action: "create_document"},
+			{ID: "notify", Service: "gmail", Action: "send_message", Inputs: map[string]interface{}{
+				"body":    "Doc ${steps.doc.outputs.document_id}",
+				"subject": "${steps.fetch.outputs.messages[0].subject}",
+			}},
+		},
+	}
+
+	expected := []string{"Step notify: output messages of step fetch can't be verified, gmail.list_messages declares no output schema"}
+	if warnings := ee.collectValidationWarnings(workflow); !reflect.DeepEqual(warnings, expected) {
+		t.Errorf("Expected warnings %v, got %v", expected, warnings)
+	}
+}
+
+// TestReferencedStepOutputs verifies output references and step ids come from the same matches
+func TestReferencedStepOutputs(t *testing.T) {
+	value := map[string]interface{}{
+		"body":    "Doc ${steps.doc.outputs.document_id | upper} from ${steps.fetch.outputs.messages[0].subject}",
+		"partial": "${steps.draft.",
+	}
+
+	expectedRefs := []stepOutputRef{{stepID: "doc", field: "document_id"}, {stepID: "fetch", field: "messages"}}
+	if refs := referencedStepOutputs(value); !reflect.DeepEqual(refs, expectedRefs) {
+		t.Errorf("Expected output references %v, got %v", expectedRefs, refs)
+	}
+	expectedIDs := []string{"doc", "fetch", "draft"}
+	if ids := referencedStepIDs(value); !reflect.DeepEqual(ids, expectedIDs) {
+		t.Errorf("Expected step ids %v, got %v", expectedIDs, ids)
+	}
+}
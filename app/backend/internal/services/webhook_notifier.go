@@ -0,0 +1,222 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// WebhookSignatureHeader carries the hex HMAC-SHA256 of the request body, prefixed with "sha256="
+const WebhookSignatureHeader = "X-Sohoaas-Signature"
+
+// WebhookPayload is POSTed to an execution's callback URL once it finishes
+type WebhookPayload struct {
+	ExecutionID string               `json:"execution_id"`
+	WorkflowID  string               `json:"workflow_id"`
+	Status      string               `json:"status"` // completed, failed, timed_out, cancelled
+	Error       string               `json:"error,omitempty"`
+	Steps       []WebhookStepSummary `json:"steps"`
+	FinishedAt  time.Time            `json:"finished_at"`
+}
+
+// WebhookStepSummary reports the outcome of a single step
+type WebhookStepSummary struct {
+	StepID  string     `json:"step_id"`
+	Service string     `json:"service"`
+	Action  string     `json:"action"`
+	Status  StepStatus `json:"status"`
+}
+
+// WebhookNotifier delivers signed completion callbacks with retries
+type WebhookNotifier struct {
+	secret       string
+	httpClient   *http.Client
+	maxAttempts  int
+	retryDelay   time.Duration
+	allowedHosts map[string]bool // callback hosts exempt from the internal address check
+}
+
+// NewWebhookNotifier creates a notifier that signs payloads with secret. Callbacks to
+// loopback, private, link-local and unspecified addresses are refused unless their host is
+// allowed with SetAllowedHosts.
+func NewWebhookNotifier(secret string) *WebhookNotifier {
+	n := &WebhookNotifier{
+		secret:       secret,
+		maxAttempts:  3,
+		retryDelay:   2 * time.Second,
+		allowedHosts: make(map[string]bool),
+	}
+	n.httpClient = &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: &http.Transport{DialContext: n.dialContext},
+	}
+	return n
+}
+
+// SetAllowedHosts exempts callback hosts (names or IPs) from the internal address check,
+// e.g. a receiver on the private network
+func (n *WebhookNotifier) SetAllowedHosts(hosts []string) {
+	n.allowedHosts = make(map[string]bool, len(hosts))
+	for _, host := range hosts {
+		n.allowedHosts[strings.ToLower(host)] = true
+	}
+}
+
+// Enabled reports whether a signing secret is configured
+func (n *WebhookNotifier) Enabled() bool {
+	return n != nil && n.secret != ""
+}
+
+// ValidateCallbackURL checks that a callback URL is an absolute http(s) URL whose host isn't
+// an internal address. Host names are resolved and checked again each time a callback is
+// dialed, so a name that later resolves to an internal address is refused then.
+func (n *WebhookNotifier) ValidateCallbackURL(callbackURL string) error {
+	parsed, err := url.Parse(callbackURL)
+	if err != nil {
+		return fmt.Errorf("invalid callback_url: %w", err)
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("callback_url must be an absolute http or https URL")
+	}
+
+	host := strings.ToLower(parsed.Hostname())
+	if n.allowedHosts[host] {
+		return nil
+	}
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("callback_url must not point to localhost")
+	}
+	if ip := net.ParseIP(host); ip != nil && isInternalIP(ip) {
+		return fmt.Errorf("callback_url must not point to internal address %s", ip)
+	}
+	return nil
+}
+
+// isInternalIP reports whether ip is a loopback, private, link-local or unspecified address,
+// which includes cloud metadata endpoints like 169.254.169.254
+func isInternalIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsUnspecified()
+}
+
+// dialContext dials callback connections, refusing internal addresses after name resolution
+// unless the callback host is allowed
+func (n *WebhookNotifier) dialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil || !n.allowedHosts[strings.ToLower(host)] {
+		dialer.Control = refuseInternalAddress
+	}
+	return dialer.DialContext(ctx, network, address)
+}
+
+// refuseInternalAddress is a net.Dialer Control function rejecting resolved internal addresses
+func refuseInternalAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("invalid callback address %s: %w", address, err)
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || isInternalIP(ip) {
+		return fmt.Errorf("callback address %s is internal", host)
+	}
+	return nil
+}
+
+// BuildWebhookPayload summarizes a finished execution plan
+func BuildWebhookPayload(executionID string, plan *ExecutionPlan, execErr error) WebhookPayload {
+	payload := WebhookPayload{
+		ExecutionID: executionID,
+		WorkflowID:  plan.WorkflowID,
+		Status:      ExecutionStatus(execErr),
+		Steps:       make([]WebhookStepSummary, 0, len(plan.ResolvedSteps)),
+		FinishedAt:  time.Now().UTC(),
+	}
+	payload.Error = plan.RedactError(execErr)
+
+	for _, step := range plan.ResolvedSteps {
+		payload.Steps = append(payload.Steps, WebhookStepSummary{
+			StepID:  step.ID,
+			Service: step.Service,
+			Action:  step.Action,
+			Status:  step.Status,
+		})
+	}
+	return payload
+}
+
+// Sign returns the signature header value for body
+func (n *WebhookNotifier) Sign(body []byte) string {
+	mac := hmac.New(sha256.New, []byte(n.secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Notify POSTs the signed payload, retrying on network errors, 429 and 5xx responses
+func (n *WebhookNotifier) Notify(callbackURL string, payload WebhookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+	signature := n.Sign(body)
+
+	var lastErr error
+	delay := n.retryDelay
+	for attempt := 1; attempt <= n.maxAttempts; attempt++ {
+		retryable, err := n.deliver(callbackURL, body, signature)
+		if err == nil {
+			log.Printf("[Webhook] Delivered %s callback for execution %s (attempt %d)", payload.Status, payload.ExecutionID, attempt)
+			return nil
+		}
+		lastErr = err
+		if !retryable || attempt == n.maxAttempts {
+			break
+		}
+		log.Printf("[Webhook] Delivery attempt %d for execution %s failed: %v, retrying in %v", attempt, payload.ExecutionID, err, delay)
+		time.Sleep(delay)
+		delay *= 2
+	}
+	return fmt.Errorf("webhook delivery to %s failed: %w", callbackURL, lastErr)
+}
+
+// NotifyAsync delivers the payload in the background and logs the outcome
+func (n *WebhookNotifier) NotifyAsync(callbackURL string, payload WebhookPayload) {
+	go func() {
+		if err := n.Notify(callbackURL, payload); err != nil {
+			log.Printf("[Webhook] ERROR: %v", err)
+		}
+	}()
+}
+
+// deliver sends one request and reports whether a failure is worth retrying
+func (n *WebhookNotifier) deliver(callbackURL string, body []byte, signature string) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, callbackURL, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookSignatureHeader, signature)
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retryable, fmt.Errorf("callback returned status %d", resp.StatusCode)
+}
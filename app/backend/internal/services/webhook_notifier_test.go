@@ -0,0 +1,153 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestWebhookNotifierSignsAndRetries verifies the signature header and retry on 5xx
+func TestWebhookNotifierSignsAndRetries(t *testing.T) {
+	notifier := NewWebhookNotifier("test-secret")
+	notifier.retryDelay = time.Millisecond
+
+	var attempts int32
+	var received WebhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if got := r.Header.Get(WebhookSignatureHeader); got != notifier.Sign(body) {
+			t.Errorf("Unexpected signature %q", got)
+		}
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.Unmarshal(body, &received)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	allowTestServer(t, notifier, server)
+
+	plan := &ExecutionPlan{
+		WorkflowID: "weekly_report",
+		ResolvedSteps: []ResolvedStep{
+			{ID: "send", Service: "gmail", Action: "send_message", Status: "failed"},
+		},
+	}
+	payload := BuildWebhookPayload("exec_1", plan, errors.New("step send failed"))
+
+	if err := notifier.Notify(server.URL, payload); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("Expected 2 attempts, got %d", attempts)
+	}
+	if received.Status != "failed" || received.Error != "step send failed" {
+		t.Errorf("Unexpected payload status/error: %+v", received)
+	}
+	if len(received.Steps) != 1 || received.Steps[0].StepID != "send" {
+		t.Errorf("Unexpected step summary: %+v", received.Steps)
+	}
+}
+
+// TestWebhookNotifierStopsOnClientError verifies 4xx responses are not retried
+func TestWebhookNotifierStopsOnClientError(t *testing.T) {
+	notifier := NewWebhookNotifier("test-secret")
+	notifier.retryDelay = time.Millisecond
+
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+	allowTestServer(t, notifier, server)
+
+	payload := BuildWebhookPayload("exec_2", &ExecutionPlan{WorkflowID: "wf"}, nil)
+	if err := notifier.Notify(server.URL, payload); err == nil {
+		t.Fatal("Expected error for 400 response")
+	}
+	if attempts != 1 {
+		t.Errorf("Expected 1 attempt, got %d", attempts)
+	}
+}
+
+// allowTestServer exempts the loopback host of an httptest server from the internal address check
+func allowTestServer(t *testing.T, notifier *WebhookNotifier, server *httptest.Server) {
+	parsed, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("Invalid test server URL: %v", err)
+	}
+	notifier.SetAllowedHosts([]string{parsed.Hostname()})
+}
+
+// TestValidateCallbackURL verifies only absolute http(s) URLs to external hosts are accepted
+func TestValidateCallbackURL(t *testing.T) {
+	notifier := NewWebhookNotifier("test-secret")
+	if err := notifier.ValidateCallbackURL("https://example.com/hooks/sohoaas"); err != nil {
+		t.Errorf("Expected valid URL, got %v", err)
+	}
+	for _, invalid := range []string{
+		"ftp://example.com", "/relative/path", "not a url",
+		"http://localhost:8080/hook", "http://api.localhost/hook",
+		"http://127.0.0.1/hook", "http://[::1]/hook",
+		"http://10.0.0.5/hook", "http://172.16.3.4/hook", "http://192.168.1.10/hook",
+		"http://169.254.169.254/latest/meta-data/", "http://[fe80::1]/hook",
+		"http://0.0.0.0/hook", "http://[::]/hook", "http://[fd00::1]/hook",
+		"http://[::ffff:127.0.0.1]/hook",
+	} {
+		if err := notifier.ValidateCallbackURL(invalid); err == nil {
+			t.Errorf("Expected %q to be rejected", invalid)
+		}
+	}
+
+	notifier.SetAllowedHosts([]string{"10.0.0.5"})
+	if err := notifier.ValidateCallbackURL("http://10.0.0.5/hook"); err != nil {
+		t.Errorf("Expected allowed host to be accepted, got %v", err)
+	}
+}
+
+// TestWebhookNotifierRefusesInternalAddressAtDial verifies callbacks resolving to internal
+// addresses are refused when dialing, so no request reaches them
+func TestWebhookNotifierRefusesInternalAddressAtDial(t *testing.T) {
+	notifier := NewWebhookNotifier("test-secret")
+	notifier.retryDelay = time.Millisecond
+
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	payload := BuildWebhookPayload("exec_3", &ExecutionPlan{WorkflowID: "wf"}, nil)
+	if err := notifier.Notify(server.URL, payload); err == nil {
+		t.Fatal("Expected delivery to a loopback address to be refused")
+	}
+	if attempts != 0 {
+		t.Errorf("Expected no request to reach the internal server, got %d", attempts)
+	}
+}
+
+// TestRefuseInternalAddress verifies the dial-time check on resolved addresses
+func TestRefuseInternalAddress(t *testing.T) {
+	for _, address := range []string{
+		"127.0.0.1:80", "[::1]:443", "10.1.2.3:80", "172.31.255.1:80", "192.168.0.1:80",
+		"169.254.169.254:80", "[fe80::1]:80", "0.0.0.0:80", "[::]:80", "[fc00::1]:80",
+	} {
+		if err := refuseInternalAddress("tcp", address, nil); err == nil {
+			t.Errorf("Expected %s to be refused", address)
+		}
+	}
+	for _, address := range []string{"93.184.216.34:443", "[2606:2800:220:1::]:443"} {
+		if err := refuseInternalAddress("tcp", address, nil); err != nil {
+			t.Errorf("Expected %s to be allowed, got %v", address, err)
+		}
+	}
+}
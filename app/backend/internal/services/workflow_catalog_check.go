@@ -0,0 +1,42 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"sohoaas-backend/internal/types"
+	"sohoaas-backend/internal/workflowparser"
+)
+
+// ErrServiceNotInCatalog is returned for workflows with steps using a service or action the
+// MCP catalog doesn't offer, such as one made up by the LLM
+var ErrServiceNotInCatalog = errors.New("service not in catalog")
+
+// ContainsOnlyCatalogServices returns ErrServiceNotInCatalog, naming every offending step,
+// when a step of the CUE workflow uses a service or action missing from the catalog. Action
+// aliases are accepted the same way execution normalizes them.
+func (wv *WorkflowValidator) ContainsOnlyCatalogServices(workflowCUE string, catalog *types.MCPServiceCatalog) error {
+	if catalog == nil {
+		return fmt.Errorf("%w: no service catalog to check against", ErrServiceNotInCatalog)
+	}
+
+	workflow, err := workflowparser.Parse(workflowCUE)
+	if err != nil {
+		return fmt.Errorf("failed to parse workflow: %w", err)
+	}
+
+	var fictional []string
+	for _, step := range workflow.Steps {
+		service, function, err := wv.mcpParser.NormalizeAction(catalog, step.Service, step.Action)
+		if err == nil && service != "" && catalogHasFunction(catalog, service, function) {
+			continue
+		}
+		fictional = append(fictional, fmt.Sprintf("step %s uses %s", step.ID, qualifiedAction(step.Service, step.Action)))
+	}
+
+	if len(fictional) > 0 {
+		return fmt.Errorf("%w: %s", ErrServiceNotInCatalog, strings.Join(fictional, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestContainsOnlyCatalogServices verifies workflows with fabricated services are rejected
+// without an LLM, as with the investing advice prompt that produced classify_messages
+func TestContainsOnlyCatalogServices(t *testing.T) {
+	validator := NewWorkflowValidator()
+	catalog := MockPipelineCatalog()
+
+	if err := validator.ContainsOnlyCatalogServices(MockPipelineWorkflowCUE, catalog); err != nil {
+		t.Fatalf("Expected the catalog workflow to pass, got %v", err)
+	}
+
+	fictional := `workflow: {
+	name:        "investing_advice"
+	description: "Classify investing emails and send advice"
+	steps: [
+		{
+			id:     "classify"
+			name:   "Classify messages"
+			action: "classify_messages.classify_by_topic"
+			parameters: {topic: "investing"}
+		},
+		{
+			id:     "advise"
+			name:   "Send advice"
+			action: "gmail.generate_advice"
+			parameters: {to: "me@example.com"}
+		},
+		{
+			id:     "send"
+			name:   "Send summary"
+			action: "gmail.send_message"
+			parameters: {to: "me@example.com", subject: "Advice", body: "See above"}
+		},
+	]
+}`
+	err := validator.ContainsOnlyCatalogServices(fictional, catalog)
+	if !errors.Is(err, ErrServiceNotInCatalog) {
+		t.Fatalf("Expected ErrServiceNotInCatalog, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "step classify") || !strings.Contains(err.Error(), "step advise") || strings.Contains(err.Error(), "step send ") {
+		t.Errorf("Expected only the fabricated steps to be named, got %v", err)
+	}
+
+	if err := validator.ContainsOnlyCatalogServices(MockPipelineWorkflowCUE, nil); !errors.Is(err, ErrServiceNotInCatalog) {
+		t.Errorf("Expected no catalog to fail the check, got %v", err)
+	}
+}
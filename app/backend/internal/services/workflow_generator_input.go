@@ -0,0 +1,140 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidGeneratorInput is returned when the workflow generator is missing inputs it needs
+// or got malformed ones, so no LLM call is made on incomplete context
+var ErrInvalidGeneratorInput = errors.New("invalid workflow generator input")
+
+// noServicesAvailable is what the agent manager sends when the catalog offers no services
+const noServicesAvailable = "No services available"
+
+// WorkflowGeneratorInputBuilder assembles a WorkflowGeneratorInput and checks that every
+// required input is present and well-formed before the generator flow runs
+type WorkflowGeneratorInputBuilder struct {
+	input    WorkflowGeneratorInput
+	problems []string
+}
+
+// NewWorkflowGeneratorInputBuilder creates an empty builder
+func NewWorkflowGeneratorInputBuilder() *WorkflowGeneratorInputBuilder {
+	return &WorkflowGeneratorInputBuilder{}
+}
+
+// FromMap reads the agent input map: user_intent (or user_input), validated_intent,
+// available_services and tenant_id
+func (b *WorkflowGeneratorInputBuilder) FromMap(input map[string]interface{}) *WorkflowGeneratorInputBuilder {
+	userIntent := getString(input, "user_intent")
+	if userIntent == "" {
+		userIntent = getString(input, "user_input")
+	}
+	return b.WithUserIntent(userIntent).
+		WithValidatedIntent(input["validated_intent"]).
+		WithAvailableServices(input["available_services"]).
+		WithTenantID(getString(input, "tenant_id"))
+}
+
+// WithUserIntent sets the user's request the workflow is generated from
+func (b *WorkflowGeneratorInputBuilder) WithUserIntent(userIntent string) *WorkflowGeneratorInputBuilder {
+	b.input.UserIntent = strings.TrimSpace(userIntent)
+	return b
+}
+
+// WithValidatedIntent sets the intent analyst's result, given as a map or a ValidatedIntent
+func (b *WorkflowGeneratorInputBuilder) WithValidatedIntent(validatedIntent interface{}) *WorkflowGeneratorInputBuilder {
+	switch intent := validatedIntent.(type) {
+	case ValidatedIntent:
+		b.input.ValidatedIntent = intent
+	case *ValidatedIntent:
+		if intent != nil {
+			b.input.ValidatedIntent = *intent
+		}
+	case map[string]interface{}:
+		b.input.ValidatedIntent = ValidatedIntent{
+			IsAutomationRequest: getBool(intent, "is_automation_request"),
+			RequiredServices:    getStringSlice(intent, "required_services"),
+			CanFulfill:          getBool(intent, "can_fulfill"),
+			MissingInfo:         getStringSlice(intent, "missing_info"),
+			NextAction:          getStringFromMap(intent, "next_action"),
+			Explanation:         getStringFromMap(intent, "explanation"),
+			Confidence:          getFloat64(intent, "confidence"),
+			WorkflowPattern:     getStringFromMap(intent, "workflow_pattern"),
+		}
+	case nil:
+	default:
+		b.problems = append(b.problems, fmt.Sprintf("validated_intent has unsupported type %T", validatedIntent))
+	}
+	return b
+}
+
+// WithAvailableServices sets the service description offered to the LLM. Text is used as
+// is and must parse when it is JSON; maps and lists are encoded as JSON.
+func (b *WorkflowGeneratorInputBuilder) WithAvailableServices(availableServices interface{}) *WorkflowGeneratorInputBuilder {
+	switch services := availableServices.(type) {
+	case string:
+		services = strings.TrimSpace(services)
+		if strings.HasPrefix(services, "{") || strings.HasPrefix(services, "[") {
+			if !json.Valid([]byte(services)) {
+				b.problems = append(b.problems, "available_services looks like JSON but does not parse")
+				return b
+			}
+		}
+		b.input.AvailableServices = services
+	case nil:
+		b.input.AvailableServices = ""
+	default:
+		encoded, err := json.Marshal(services)
+		if err != nil {
+			b.problems = append(b.problems, fmt.Sprintf("available_services can't be encoded: %v", err))
+			return b
+		}
+		b.input.AvailableServices = string(encoded)
+	}
+	return b
+}
+
+// WithRaCContext sets the loaded RaC workflow prompt context
+func (b *WorkflowGeneratorInputBuilder) WithRaCContext(racContext string) *WorkflowGeneratorInputBuilder {
+	b.input.RacContext = racContext
+	return b
+}
+
+// WithTenantID selects the tenant's prompt override
+func (b *WorkflowGeneratorInputBuilder) WithTenantID(tenantID string) *WorkflowGeneratorInputBuilder {
+	b.input.TenantID = tenantID
+	return b
+}
+
+// Build returns the generator input, or ErrInvalidGeneratorInput listing every missing or
+// malformed input
+func (b *WorkflowGeneratorInputBuilder) Build() (WorkflowGeneratorInput, error) {
+	problems := append([]string(nil), b.problems...)
+	if b.input.UserIntent == "" {
+		problems = append(problems, "user_intent is empty")
+	}
+	if len(b.input.ValidatedIntent.RequiredServices) == 0 {
+		problems = append(problems, "validated_intent lists no required_services")
+	}
+	for _, service := range b.input.ValidatedIntent.RequiredServices {
+		if strings.TrimSpace(service) == "" {
+			problems = append(problems, "validated_intent has an empty required service")
+			break
+		}
+	}
+	if services := b.input.AvailableServices; services == "" || services == noServicesAvailable || services == "{}" || services == "[]" {
+		problems = append(problems, "available_services is empty")
+	}
+	if strings.TrimSpace(b.input.RacContext) == "" {
+		problems = append(problems, "RaC workflow context is not loaded")
+	}
+
+	if len(problems) > 0 {
+		return WorkflowGeneratorInput{}, fmt.Errorf("%w: %s", ErrInvalidGeneratorInput, strings.Join(problems, "; "))
+	}
+	return b.input, nil
+}
@@ -0,0 +1,42 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestWorkflowGeneratorInputBuilder verifies complete inputs build and missing or malformed ones are all reported
+func TestWorkflowGeneratorInputBuilder(t *testing.T) {
+	input, err := NewWorkflowGeneratorInputBuilder().FromMap(map[string]interface{}{
+		"user_intent":        "  Email the weekly report to my team  ",
+		"validated_intent":   map[string]interface{}{"required_services": []interface{}{"gmail", "docs"}, "can_fulfill": true},
+		"available_services": map[string]interface{}{"gmail": map[string]interface{}{"actions": []string{"gmail.send_message"}}},
+		"tenant_id":          "acme",
+	}).WithRaCContext("workflow: {}").Build()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if input.UserIntent != "Email the weekly report to my team" || input.TenantID != "acme" {
+		t.Errorf("Expected the trimmed intent and tenant, got %+v", input)
+	}
+	if len(input.ValidatedIntent.RequiredServices) != 2 || !input.ValidatedIntent.CanFulfill {
+		t.Errorf("Expected the validated intent to be converted, got %+v", input.ValidatedIntent)
+	}
+	if !strings.HasPrefix(input.AvailableServices, `{"gmail"`) {
+		t.Errorf("Expected available services encoded as JSON, got %s", input.AvailableServices)
+	}
+
+	_, err = NewWorkflowGeneratorInputBuilder().FromMap(map[string]interface{}{
+		"user_intent":        " ",
+		"available_services": `{"gmail": [`,
+	}).Build()
+	if !errors.Is(err, ErrInvalidGeneratorInput) {
+		t.Fatalf("Expected ErrInvalidGeneratorInput, got %v", err)
+	}
+	for _, problem := range []string{"user_intent is empty", "no required_services", "does not parse", "available_services is empty", "RaC workflow context is not loaded"} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("Expected the error to report %q, got %v", problem, err)
+		}
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"sohoaas-backend/internal/storage"
+	"sohoaas-backend/internal/types"
 )
 
 
@@ -1256,6 +1257,51 @@ func TestJSONToCUEConversion(t *testing.T) {
 	}
 }
 
+// TestAttachWorkflowCUE verifies the generator flow converts its structured output to CUE itself
+func TestAttachWorkflowCUE(t *testing.T) {
+	service := &GenkitService{}
+
+	output := WorkflowGeneratorOutput{
+		Version:     "1.0",
+		Name:        "notify_team",
+		Description: "Email the team",
+		Steps: []types.WorkflowStep{{
+			ID:         "send_email",
+			Action:     "gmail.send_message",
+			Parameters: map[string]interface{}{"to": "${user.recipient_email}"},
+		}},
+		UserParameters: map[string]types.UserParameter{},
+		Services:       map[string]WorkflowServiceBinding{},
+		WorkflowCUE:    "workflow: {llm: \"emitted\"}",
+	}
+
+	service.attachWorkflowCUE(&output)
+	if output.WorkflowJSON["description"] != "Email the team" {
+		t.Errorf("Expected workflow_json to carry the structured workflow, got %v", output.WorkflowJSON)
+	}
+	if _, exists := output.WorkflowJSON["workflow_cue"]; exists {
+		t.Error("Expected workflow_json not to include the LLM's workflow_cue")
+	}
+	if !strings.Contains(output.WorkflowCUE, "gmail.send_message") || strings.Contains(output.WorkflowCUE, "emitted") {
+		t.Errorf("Expected CUE converted from the structured steps, got %q", output.WorkflowCUE)
+	}
+
+	// Both keys survive the map conversion handlers decode the agent output from
+	var outputMap map[string]interface{}
+	data, _ := json.Marshal(output)
+	json.Unmarshal(data, &outputMap)
+	if outputMap["workflow_cue"] != output.WorkflowCUE || outputMap["workflow_json"] == nil {
+		t.Errorf("Expected workflow_cue and workflow_json keys in the output, got keys %v", getInputKeys(outputMap))
+	}
+
+	// A workflow without steps is not converted
+	empty := WorkflowGeneratorOutput{Version: "1.0", Name: "empty", Steps: []types.WorkflowStep{}, UserParameters: map[string]types.UserParameter{}, Services: map[string]WorkflowServiceBinding{}}
+	service.attachWorkflowCUE(&empty)
+	if empty.WorkflowCUE != "" {
+		t.Errorf("Expected no CUE for a workflow without steps, got %q", empty.WorkflowCUE)
+	}
+}
+
 // generateConversionReport creates a detailed markdown report of the JSON→CUE conversion
 func generateConversionReport(testName string, inputJSON map[string]interface{}, cueContent string, expectedElements []string) string {
 	report := fmt.Sprintf(`# JSON→CUE Conversion Report: %s
@@ -1312,6 +1358,61 @@ func countFoundElements(cueContent string, expectedElements []string) int {
 	return count
 }
 
+// TestJSONToCUEConversionIsDeterministic verifies identical input always yields byte-identical CUE
+func TestJSONToCUEConversionIsDeterministic(t *testing.T) {
+	service := &GenkitService{}
+	workflowJSON := map[string]interface{}{
+		"workflow_name": "Stable Workflow",
+		"steps": []interface{}{
+			map[string]interface{}{
+				"id":     "send",
+				"action": "gmail.send_message",
+				"parameters": map[string]interface{}{
+					"to": "${user.recipient}", "subject": "${user.subject}", "body": "Hello",
+					"cc": "${user.cc}", "bcc": "${user.bcc}", "reply_to": "${user.reply_to}",
+				},
+			},
+		},
+		"user_parameters": map[string]interface{}{
+			"recipient": map[string]interface{}{"type": "string"},
+			"subject":   map[string]interface{}{"type": "string"},
+			"cc":        map[string]interface{}{"type": "string"},
+			"bcc":       map[string]interface{}{"type": "string"},
+			"reply_to":  map[string]interface{}{"type": "string"},
+		},
+	}
+
+	expected := service.convertJSONToCUE(workflowJSON)
+	for i := 0; i < 20; i++ {
+		if cueContent := service.convertJSONToCUE(workflowJSON); cueContent != expected {
+			t.Fatalf("Conversion %d differs from the first:\n%s\n---\n%s", i+2, cueContent, expected)
+		}
+	}
+
+	// Only look at the generated workflow; the embedded schema has examples with the same keys
+	start := strings.Index(expected, "\nworkflow: ")
+	if start < 0 {
+		t.Fatalf("Expected a workflow block:\n%s", expected)
+	}
+	generated := expected[start:]
+	assertInOrder := func(what string, lines []string) {
+		t.Helper()
+		previous := -1
+		for _, line := range lines {
+			index := strings.Index(generated, line)
+			if index < 0 || index < previous {
+				t.Errorf("Expected %s in key order, %q is missing or out of place:\n%s", what, line, generated)
+				return
+			}
+			previous = index
+		}
+	}
+	assertInOrder("step parameters", []string{
+		`bcc: "${user.bcc}"`, `body: "Hello"`, `cc: "${user.cc}"`, `reply_to: "${user.reply_to}"`, `subject: "${user.subject}"`, `to: "${user.recipient}"`,
+	})
+	assertInOrder("user parameters", []string{"\t\tbcc: {", "\t\tcc: {", "\t\trecipient: {", "\t\treply_to: {", "\t\tsubject: {"})
+}
+
 // TestDailyStandupWorkflowConversion tests the complete JSON→CUE conversion pipeline
 // for a complex multi-step daily standup automation workflow
 func TestDailyStandupWorkflowConversion(t *testing.T) {
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"fmt"
+	"log"
+)
+
+// Edge kinds in a workflow graph
+const (
+	GraphEdgeDependsOn = "depends_on" // declared in the step's depends_on
+	GraphEdgeReference = "reference"  // implied by a ${steps.<id>...} reference in the step's inputs
+)
+
+// WorkflowGraphNode is a step in the workflow DAG
+type WorkflowGraphNode struct {
+	ID      string `json:"id"`
+	Name    string `json:"name,omitempty"`
+	Service string `json:"service"`
+	Action  string `json:"action"`
+	InCycle bool   `json:"in_cycle,omitempty"`
+}
+
+// WorkflowGraphEdge points from a step to a step that depends on it
+type WorkflowGraphEdge struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Kind    string `json:"kind"` // depends_on or reference
+	InCycle bool   `json:"in_cycle,omitempty"`
+}
+
+// WorkflowGraph is the step DAG of a workflow in a renderer-friendly form
+type WorkflowGraph struct {
+	WorkflowName string              `json:"workflow_name"`
+	Nodes        []WorkflowGraphNode `json:"nodes"`
+	Edges        []WorkflowGraphEdge `json:"edges"`
+	HasCycle     bool                `json:"has_cycle"`
+	Cycles       [][]string          `json:"cycles,omitempty"` // step ids of each cycle, in workflow order
+}
+
+// BuildWorkflowGraph parses a CUE workflow and returns its step graph
+func (ee *ExecutionEngine) BuildWorkflowGraph(cueContent string) (*WorkflowGraph, error) {
+	workflow, err := ee.ParseCUEWorkflow(cueContent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CUE workflow: %w", err)
+	}
+
+	graph := buildWorkflowGraph(workflow)
+	log.Printf("[ExecutionEngine] Built graph for workflow %s: %d nodes, %d edges, cycle: %t",
+		workflow.Name, len(graph.Nodes), len(graph.Edges), graph.HasCycle)
+	return graph, nil
+}
+
+// buildWorkflowGraph computes nodes and edges from explicit depends_on and implicit step
+// references. A reference already declared in depends_on yields a single depends_on edge;
+// dependencies on unknown steps are left to workflow validation.
+func buildWorkflowGraph(workflow *ParsedWorkflow) *WorkflowGraph {
+	graph := &WorkflowGraph{
+		WorkflowName: workflow.Name,
+		Nodes:        make([]WorkflowGraphNode, 0, len(workflow.Steps)),
+		Edges:        []WorkflowGraphEdge{},
+	}
+
+	known := make(map[string]bool, len(workflow.Steps))
+	for _, step := range workflow.Steps {
+		known[step.ID] = true
+		graph.Nodes = append(graph.Nodes, WorkflowGraphNode{
+			ID:      step.ID,
+			Name:    step.Name,
+			Service: step.Service,
+			Action:  step.Action,
+		})
+	}
+
+	for _, step := range workflow.Steps {
+		linked := make(map[string]bool)
+		addEdge := func(from, kind string) {
+			if !known[from] || linked[from] {
+				return
+			}
+			linked[from] = true
+			graph.Edges = append(graph.Edges, WorkflowGraphEdge{From: from, To: step.ID, Kind: kind})
+		}
+
+		for _, dep := range step.DependsOn {
+			addEdge(dep, GraphEdgeDependsOn)
+		}
+		for _, ref := range referencedStepIDs(step.Inputs) {
+			addEdge(ref, GraphEdgeReference)
+		}
+	}
+
+	markGraphCycles(graph)
+	return graph
+}
+
+// markGraphCycles finds the strongly connected components of the graph (Tarjan) and flags
+// the nodes and edges of every component that forms a cycle, including self-references.
+func markGraphCycles(graph *WorkflowGraph) {
+	successors := make(map[string][]string)
+	selfLoops := make(map[string]bool)
+	for _, edge := range graph.Edges {
+		successors[edge.From] = append(successors[edge.From], edge.To)
+		if edge.From == edge.To {
+			selfLoops[edge.From] = true
+		}
+	}
+
+	index := 0
+	indices := make(map[string]int)
+	lowLinks := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	component := make(map[string]string) // node id -> root of its cycle, for nodes in a cycle
+
+	var connect func(id string)
+	connect = func(id string) {
+		indices[id] = index
+		lowLinks[id] = index
+		index++
+		stack = append(stack, id)
+		onStack[id] = true
+
+		for _, next := range successors[id] {
+			if _, visited := indices[next]; !visited {
+				connect(next)
+				lowLinks[id] = min(lowLinks[id], lowLinks[next])
+			} else if onStack[next] {
+				lowLinks[id] = min(lowLinks[id], indices[next])
+			}
+		}
+
+		if lowLinks[id] != indices[id] {
+			return
+		}
+		var members []string
+		for {
+			member := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[member] = false
+			members = append(members, member)
+			if member == id {
+				break
+			}
+		}
+		if len(members) > 1 || selfLoops[id] {
+			for _, member := range members {
+				component[member] = id
+			}
+		}
+	}
+
+	for _, node := range graph.Nodes {
+		if _, visited := indices[node.ID]; !visited {
+			connect(node.ID)
+		}
+	}
+
+	// List cycles and their members in workflow order
+	cycleIndex := make(map[string]int)
+	for i := range graph.Nodes {
+		root, inCycle := component[graph.Nodes[i].ID]
+		if !inCycle {
+			continue
+		}
+		graph.Nodes[i].InCycle = true
+		if _, seen := cycleIndex[root]; !seen {
+			cycleIndex[root] = len(graph.Cycles)
+			graph.Cycles = append(graph.Cycles, nil)
+		}
+		graph.Cycles[cycleIndex[root]] = append(graph.Cycles[cycleIndex[root]], graph.Nodes[i].ID)
+	}
+	for i := range graph.Edges {
+		edge := &graph.Edges[i]
+		fromCycle, fromIn := component[edge.From]
+		toCycle, toIn := component[edge.To]
+		edge.InCycle = fromIn && toIn && fromCycle == toCycle
+	}
+	graph.HasCycle = len(graph.Cycles) > 0
+}
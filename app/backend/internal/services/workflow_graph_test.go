@@ -0,0 +1,67 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestBuildWorkflowGraph verifies explicit and implicit edges and cycle flags
+func TestBuildWorkflowGraph(t *testing.T) {
+	workflow := &ParsedWorkflow{
+		Name: "share_report",
+		Steps: []WorkflowStep{
+			{ID: "create_doc", Service: "docs", Action: "create_document"},
+			{ID: "share", Service: "drive", Action: "share_file", DependsOn: []string{"create_doc"},
+				Inputs: map[string]interface{}{"file_id": "${steps.create_doc.outputs.document_id}"}},
+			{ID: "notify", Service: "gmail", Action: "send_message", DependsOn: []string{"unknown_step"},
+				Inputs: map[string]interface{}{"body": "Shared ${steps.share.outputs.link}"}},
+		},
+	}
+
+	graph := buildWorkflowGraph(workflow)
+	if len(graph.Nodes) != 3 || graph.Nodes[1].Service != "drive" || graph.Nodes[1].Action != "share_file" {
+		t.Errorf("Unexpected nodes: %+v", graph.Nodes)
+	}
+	expectedEdges := []WorkflowGraphEdge{
+		{From: "create_doc", To: "share", Kind: GraphEdgeDependsOn},
+		{From: "share", To: "notify", Kind: GraphEdgeReference},
+	}
+	if !reflect.DeepEqual(graph.Edges, expectedEdges) {
+		t.Errorf("Expected edges %+v, got %+v", expectedEdges, graph.Edges)
+	}
+	if graph.HasCycle {
+		t.Errorf("Expected acyclic graph, got cycles %v", graph.Cycles)
+	}
+}
+
+// TestBuildWorkflowGraphCycles verifies cycles, including self-references, are flagged
+func TestBuildWorkflowGraphCycles(t *testing.T) {
+	workflow := &ParsedWorkflow{
+		Steps: []WorkflowStep{
+			{ID: "a", DependsOn: []string{"c"}},
+			{ID: "b", DependsOn: []string{"a"}},
+			{ID: "c", Inputs: map[string]interface{}{"x": "${steps.b.outputs.id}"}},
+			{ID: "d", DependsOn: []string{"a"}},
+			{ID: "e", Inputs: map[string]interface{}{"x": "${steps.e.outputs.id}"}},
+		},
+	}
+
+	graph := buildWorkflowGraph(workflow)
+	if !graph.HasCycle {
+		t.Fatal("Expected a cycle to be detected")
+	}
+	expectedCycles := [][]string{{"a", "b", "c"}, {"e"}}
+	if !reflect.DeepEqual(graph.Cycles, expectedCycles) {
+		t.Errorf("Expected cycles %v, got %v", expectedCycles, graph.Cycles)
+	}
+	for _, node := range graph.Nodes {
+		if node.InCycle != (node.ID != "d") {
+			t.Errorf("Node %s: unexpected in_cycle %t", node.ID, node.InCycle)
+		}
+	}
+	for _, edge := range graph.Edges {
+		if edge.InCycle != (edge.To != "d") {
+			t.Errorf("Edge %s -> %s: unexpected in_cycle %t", edge.From, edge.To, edge.InCycle)
+		}
+	}
+}
@@ -0,0 +1,6 @@
+package services
+
+import "sohoaas-backend/internal/workflowparser"
+
+// WorkflowInput describes a declared user parameter so clients can build an input form
+type WorkflowInput = workflowparser.WorkflowInput
@@ -0,0 +1,42 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestParseCUEWorkflowUserParameters verifies user_parameters are exposed as form inputs in declaration order
+func TestParseCUEWorkflowUserParameters(t *testing.T) {
+	ee := NewExecutionEngine(nil)
+	workflow, err := ee.ParseCUEWorkflow(`workflow: {
+	name:        "share_report"
+	description: "Share a report"
+	user_parameters: {
+		recipient: {type: "email", required: true, prompt: "Who should receive it?", validation: "email", placeholder: "alice@example.com"}
+		subject: {type: "string", required: false, prompt: "Subject", description: "Email subject line", default: "Weekly Report"}
+		notes: {prompt: "Notes"}
+	}
+	steps: [{
+		id:     "send"
+		name:   "Send"
+		action: "gmail.send_message"
+		inputs: {to: "${user.recipient}", subject: "${user.subject}"}
+	}]
+}`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []WorkflowInput{
+		{Name: "recipient", Type: "email", Required: true, Prompt: "Who should receive it?", Validation: "email", Placeholder: "alice@example.com"},
+		{Name: "subject", Type: "string", Required: false, Prompt: "Subject", Description: "Email subject line", Default: "Weekly Report"},
+		{Name: "notes", Type: "string", Required: true, Prompt: "Notes"},
+	}
+	if !reflect.DeepEqual(workflow.UserParameters, expected) {
+		t.Errorf("Expected inputs %+v, got %+v", expected, workflow.UserParameters)
+	}
+	if workflow.UserParameterDefaults["subject"] != "Weekly Report" || workflow.UserParameterValidation["recipient"] != "email" {
+		t.Errorf("Expected defaults and validation to be derived from the inputs, got %v / %v",
+			workflow.UserParameterDefaults, workflow.UserParameterValidation)
+	}
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// workflowSchemaExcludedFields lists JSON fields set during generation or execution rather
+// than authored, keyed by Go type name
+var workflowSchemaExcludedFields = map[string][]string{
+	"WorkflowGeneratorOutput": {"decision_log", "workflow_json", "workflow_cue"},
+	"WorkflowStep":            {"status", "output", "error", "executed_at"},
+}
+
+// WorkflowJSONSchema returns a JSON Schema (draft-07) of the workflow JSON the generator
+// produces and converts to CUE, derived from WorkflowGeneratorOutput and the types it uses.
+// Fields tagged omitempty, pointers and untyped values are optional; the rest are required.
+func WorkflowJSONSchema() map[string]interface{} {
+	definitions := make(map[string]interface{})
+	schema := jsonSchemaForStruct(reflect.TypeOf(WorkflowGeneratorOutput{}), definitions)
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["title"] = "SOHOAAS workflow"
+	schema["description"] = "Workflow JSON converted to CUE: steps, user_parameters, services (CUE service_bindings) and execution_config"
+	schema["definitions"] = definitions
+	return schema
+}
+
+// jsonSchemaFor returns the schema of a Go type; named structs are added to definitions and referenced
+func jsonSchemaFor(t reflect.Type, definitions map[string]interface{}) map[string]interface{} {
+	if t == reflect.TypeOf(time.Time{}) {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return jsonSchemaFor(t.Elem(), definitions)
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": jsonSchemaFor(t.Elem(), definitions)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": jsonSchemaFor(t.Elem(), definitions)}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return jsonSchemaForStruct(t, definitions)
+		}
+		if _, defined := definitions[t.Name()]; !defined {
+			definitions[t.Name()] = map[string]interface{}{} // placeholder for recursive types
+			definitions[t.Name()] = jsonSchemaForStruct(t, definitions)
+		}
+		return map[string]interface{}{"$ref": "#/definitions/" + t.Name()}
+	default:
+		return map[string]interface{}{} // interface{}: any JSON value
+	}
+}
+
+// jsonSchemaForStruct returns the object schema of a struct's JSON fields
+func jsonSchemaForStruct(t reflect.Type, definitions map[string]interface{}) map[string]interface{} {
+	excluded := make(map[string]bool)
+	for _, name := range workflowSchemaExcludedFields[t.Name()] {
+		excluded[name] = true
+	}
+
+	properties := make(map[string]interface{})
+	required := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if excluded[name] {
+			continue
+		}
+
+		properties[name] = jsonSchemaFor(field.Type, definitions)
+		optional := strings.Contains(options, "omitempty") || field.Type.Kind() == reflect.Ptr || field.Type.Kind() == reflect.Interface
+		if !optional {
+			required = append(required, name)
+		}
+	}
+
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
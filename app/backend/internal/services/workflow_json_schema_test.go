@@ -0,0 +1,109 @@
+package services
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"sohoaas-backend/internal/types"
+)
+
+// TestWorkflowJSONSchema verifies the schema follows the generator's Go types
+func TestWorkflowJSONSchema(t *testing.T) {
+	schema := WorkflowJSONSchema()
+	if _, err := json.Marshal(schema); err != nil {
+		t.Fatalf("Schema is not JSON serializable: %v", err)
+	}
+
+	properties := schema["properties"].(map[string]interface{})
+	for _, field := range []string{"steps", "user_parameters", "services", "execution_config"} {
+		if _, exists := properties[field]; !exists {
+			t.Errorf("Expected property %s", field)
+		}
+	}
+	for _, field := range []string{"decision_log", "workflow_json", "workflow_cue"} {
+		if _, exists := properties[field]; exists {
+			t.Errorf("Expected generated field %s to be excluded", field)
+		}
+	}
+	expectedRequired := []string{"version", "name", "description", "original_intent", "steps", "user_parameters", "services"}
+	if required := schema["required"]; !reflect.DeepEqual(required, expectedRequired) {
+		t.Errorf("Expected required %v, got %v", expectedRequired, required)
+	}
+
+	definitions := schema["definitions"].(map[string]interface{})
+	step, ok := definitions["WorkflowStep"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Expected a WorkflowStep definition, got %v", definitions)
+	}
+	stepProperties := step["properties"].(map[string]interface{})
+	if _, exists := stepProperties["status"]; exists {
+		t.Error("Expected execution field status to be excluded from steps")
+	}
+	if dependsOn := stepProperties["depends_on"]; !reflect.DeepEqual(dependsOn, map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}}) {
+		t.Errorf("Unexpected depends_on schema: %v", dependsOn)
+	}
+	if required := step["required"]; !reflect.DeepEqual(required, []string{"id", "service", "action", "parameters"}) {
+		t.Errorf("Unexpected required step fields: %v", required)
+	}
+
+	userParameter := definitions["UserParameter"].(map[string]interface{})
+	if _, exists := userParameter["properties"].(map[string]interface{})["default"]; !exists {
+		t.Error("Expected UserParameter to describe default")
+	}
+	for _, field := range userParameter["required"].([]string) {
+		if field == "default" || field == "validation" {
+			t.Errorf("Expected %s to be optional", field)
+		}
+	}
+}
+
+// TestWorkflowJSONSchemaServicesRoundTrip verifies service bindings in the shape the schema
+// describes survive the conversion to CUE
+func TestWorkflowJSONSchemaServicesRoundTrip(t *testing.T) {
+	schema := WorkflowJSONSchema()
+	servicesSchema := schema["properties"].(map[string]interface{})["services"]
+	expectedSchema := map[string]interface{}{"type": "object", "additionalProperties": map[string]interface{}{"$ref": "#/definitions/WorkflowServiceBinding"}}
+	if !reflect.DeepEqual(servicesSchema, expectedSchema) {
+		t.Fatalf("Expected services keyed by name, got %v", servicesSchema)
+	}
+	binding := schema["definitions"].(map[string]interface{})["WorkflowServiceBinding"].(map[string]interface{})
+	for _, field := range []string{"provider", "connection", "secret_ref", "oauth_scopes"} {
+		if _, exists := binding["properties"].(map[string]interface{})[field]; !exists {
+			t.Errorf("Expected binding property %s", field)
+		}
+	}
+
+	// Marshalled from the types the schema is derived from, so the JSON is schema-valid
+	data, err := json.Marshal(WorkflowGeneratorOutput{
+		Version:        "1.0.0",
+		Name:           "Notify",
+		Description:    "Email the team",
+		OriginalIntent: "Email the team",
+		Steps: []types.WorkflowStep{
+			{ID: "send", Service: "gmail", Action: "gmail.send_message", Parameters: map[string]interface{}{"to": "team@example.com"}},
+		},
+		UserParameters: map[string]types.UserParameter{},
+		Services: map[string]WorkflowServiceBinding{
+			"gmail": {Provider: "workspace", Connection: "work", OAuthScopes: []string{"https://www.googleapis.com/auth/gmail.send"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var workflowJSON map[string]interface{}
+	if err := json.Unmarshal(data, &workflowJSON); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	workflow, err := NewExecutionEngine(nil).ParseCUEWorkflow((&GenkitService{}).convertJSONToCUE(workflowJSON))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if scopes := workflow.OAuthScopes["gmail"]; !reflect.DeepEqual(scopes, []string{"https://www.googleapis.com/auth/gmail.send"}) {
+		t.Errorf("Expected the gmail binding's scopes, got %v", workflow.OAuthScopes)
+	}
+	if step := workflow.Steps[0]; step.Provider != "workspace" || step.Connection != "work" {
+		t.Errorf("Expected the step to use the binding's provider and connection, got %+v", step)
+	}
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"fmt"
+	"log"
+)
+
+// WorkflowLintReport is the outcome of statically checking a workflow's step graph. Without
+// strict mode reachability findings are warnings; strict mode turns them into errors.
+type WorkflowLintReport struct {
+	WorkflowName string             `json:"workflow_name"`
+	Strict       bool               `json:"strict"`
+	Valid        bool               `json:"valid"`
+	Errors       []string           `json:"errors,omitempty"`
+	Warnings     []string           `json:"warnings,omitempty"`
+	Reachability ReachabilityResult `json:"reachability"`
+}
+
+// LintWorkflow parses a CUE workflow and checks its step dependencies and reachability
+func (ee *ExecutionEngine) LintWorkflow(cueContent string, strict, allowDisconnected bool) (*WorkflowLintReport, error) {
+	workflow, err := ee.ParseCUEWorkflow(cueContent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CUE workflow: %w", err)
+	}
+
+	steps := make([]map[string]interface{}, 0, len(workflow.Steps))
+	for _, step := range workflow.Steps {
+		steps = append(steps, map[string]interface{}{
+			"id":         step.ID,
+			"name":       step.Name,
+			"action":     step.Action,
+			"parameters": step.Inputs,
+			"depends_on": step.DependsOn,
+		})
+	}
+
+	validator := NewWorkflowValidator()
+	report := &WorkflowLintReport{
+		WorkflowName: workflow.Name,
+		Strict:       strict,
+		Reachability: validator.CheckReachability(steps, allowDisconnected),
+	}
+	report.Errors = append(report.Errors, validator.CheckStepDependencies(steps).Errors...)
+	if strict {
+		report.Errors = append(report.Errors, report.Reachability.Errors...)
+	} else {
+		report.Warnings = append(report.Warnings, report.Reachability.Errors...)
+	}
+	report.Valid = len(report.Errors) == 0
+
+	log.Printf("[ExecutionEngine] Linted workflow %s (strict: %t): %d errors, %d warnings",
+		workflow.Name, strict, len(report.Errors), len(report.Warnings))
+	return report, nil
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// ErrWorkflowAlreadyRunning is returned when an exclusive workflow is executed while a previous
+// run of it has not finished
+var ErrWorkflowAlreadyRunning = errors.New("workflow is already running")
+
+// workflowLockGrace is added to a run's time budget before its lock may be taken over, so a run
+// that never released its lock can't block the workflow forever
+const workflowLockGrace = 30 * time.Second
+
+// workflowLock marks a running execution of an exclusive workflow
+type workflowLock struct {
+	acquiredAt time.Time
+	expiresAt  time.Time
+}
+
+// LockWorkflow keeps a second run of an exclusive workflow (execution_config.exclusive) from
+// overlapping the first: it fails with ErrWorkflowAlreadyRunning while another run of the
+// plan's stored workflow holds the lock. The returned release frees the lock once; call it when
+// the run ends. A lock left held past the run's timeout plus a grace period expires by itself.
+// Plans that are not exclusive or not prepared from a stored workflow are never locked.
+func (ee *ExecutionEngine) LockWorkflow(userID string, plan *ExecutionPlan) (func(), error) {
+	if plan == nil || !plan.Exclusive || plan.SourceWorkflowID == "" {
+		return func() {}, nil
+	}
+
+	key := userID + "/" + plan.SourceWorkflowID
+	now := time.Now()
+
+	ee.workflowLocksMu.Lock()
+	defer ee.workflowLocksMu.Unlock()
+
+	if held, exists := ee.workflowLocks[key]; exists {
+		if now.Before(held.expiresAt) {
+			return nil, fmt.Errorf("%w: %s has been running since %s", ErrWorkflowAlreadyRunning, plan.SourceWorkflowID, held.acquiredAt.Format(time.RFC3339))
+		}
+		log.Printf("[ExecutionEngine] Lock of workflow %s for user %s expired at %s, taking it over", plan.SourceWorkflowID, userID, held.expiresAt.Format(time.RFC3339))
+	}
+
+	lock := &workflowLock{
+		acquiredAt: now,
+		expiresAt:  now.Add(ee.workflowTimeout(plan) + workflowLockGrace),
+	}
+	ee.workflowLocks[key] = lock
+
+	released := false
+	return func() {
+		ee.workflowLocksMu.Lock()
+		defer ee.workflowLocksMu.Unlock()
+		// Don't free a lock taken over by a later run after this one expired
+		if !released && ee.workflowLocks[key] == lock {
+			delete(ee.workflowLocks, key)
+		}
+		released = true
+	}, nil
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestLockWorkflow verifies overlapping runs of an exclusive workflow are rejected until released
+func TestLockWorkflow(t *testing.T) {
+	ee := NewExecutionEngine(nil)
+	plan := &ExecutionPlan{SourceWorkflowID: "wf_1", Exclusive: true}
+
+	unlock, err := ee.LockWorkflow("user1", plan)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := ee.LockWorkflow("user1", plan); !errors.Is(err, ErrWorkflowAlreadyRunning) {
+		t.Fatalf("Expected ErrWorkflowAlreadyRunning for an overlapping run, got %v", err)
+	}
+
+	// Other users and other workflows are not affected
+	if release, err := ee.LockWorkflow("user2", plan); err != nil {
+		t.Errorf("Expected another user's run to be allowed, got %v", err)
+	} else {
+		release()
+	}
+	if release, err := ee.LockWorkflow("user1", &ExecutionPlan{SourceWorkflowID: "wf_2", Exclusive: true}); err != nil {
+		t.Errorf("Expected another workflow's run to be allowed, got %v", err)
+	} else {
+		release()
+	}
+
+	unlock()
+	unlock() // releasing twice is harmless
+	relock, err := ee.LockWorkflow("user1", plan)
+	if err != nil {
+		t.Fatalf("Expected the workflow to run again after release, got %v", err)
+	}
+	relock()
+}
+
+// TestLockWorkflowNotExclusive verifies workflows without execution_config.exclusive may overlap
+func TestLockWorkflowNotExclusive(t *testing.T) {
+	ee := NewExecutionEngine(nil)
+	plan := &ExecutionPlan{SourceWorkflowID: "wf_1"}
+
+	for i := 0; i < 2; i++ {
+		if _, err := ee.LockWorkflow("user1", plan); err != nil {
+			t.Fatalf("Run %d: unexpected error: %v", i+1, err)
+		}
+	}
+}
+
+// TestLockWorkflowExpires verifies a lock held past the run's timeout and grace can be taken over
+func TestLockWorkflowExpires(t *testing.T) {
+	ee := NewExecutionEngine(nil)
+	plan := &ExecutionPlan{SourceWorkflowID: "wf_1", Exclusive: true, Timeout: time.Minute}
+
+	staleUnlock, err := ee.LockWorkflow("user1", plan)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	ee.workflowLocks["user1/wf_1"].expiresAt = time.Now().Add(-time.Second)
+
+	unlock, err := ee.LockWorkflow("user1", plan)
+	if err != nil {
+		t.Fatalf("Expected an expired lock to be taken over, got %v", err)
+	}
+
+	// The stale run finishing late must not free the new run's lock
+	staleUnlock()
+	if _, err := ee.LockWorkflow("user1", plan); !errors.Is(err, ErrWorkflowAlreadyRunning) {
+		t.Errorf("Expected the new run to keep its lock, got %v", err)
+	}
+	unlock()
+}
+
+// TestParseCUEWorkflowExclusive verifies execution_config.exclusive is parsed
+func TestParseCUEWorkflowExclusive(t *testing.T) {
+	ee := NewExecutionEngine(nil)
+	workflow, err := ee.ParseCUEWorkflow(`workflow: {
+	name:        "append"
+	description: "Create a folder, then append to its document"
+	steps: [{
+		id:     "create"
+		name:   "Create folder"
+		action: "drive.create_folder"
+		inputs: {name: "Reports"}
+	}]
+	execution_config: {mode: "sequential", exclusive: true}
+}`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !workflow.Exclusive {
+		t.Error("Expected the workflow to be exclusive")
+	}
+}
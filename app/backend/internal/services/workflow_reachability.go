@@ -0,0 +1,117 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"sohoaas-backend/internal/types"
+)
+
+// ReachabilityResult lists the steps of a workflow that don't contribute to its outcome
+type ReachabilityResult struct {
+	Valid           bool       `json:"valid"`
+	Errors          []string   `json:"errors,omitempty"`
+	OrphanSteps     []string   `json:"orphan_steps,omitempty"`     // steps not connected to the subgraph of the final step
+	UnconsumedSteps []string   `json:"unconsumed_steps,omitempty"` // non-terminal steps whose outputs no step references
+	Subgraphs       [][]string `json:"subgraphs,omitempty"`        // connected step groups, only when there is more than one
+}
+
+// CheckReachability checks that every step of a strict workflow feeds its outcome: each
+// non-terminal step's outputs are consumed and all steps form a single connected graph.
+// Disconnected subgraphs are accepted when allowDisconnected is set.
+func (wv *WorkflowValidator) CheckReachability(steps []map[string]interface{}, allowDisconnected bool) ReachabilityResult {
+	typedSteps, err := wv.mcpParser.ParseWorkflowSteps(steps)
+	if err != nil {
+		return ReachabilityResult{
+			Valid:  false,
+			Errors: []string{fmt.Sprintf("Failed to parse workflow steps: %v", err)},
+		}
+	}
+	return wv.ValidateReachabilityTyped(typedSteps, allowDisconnected)
+}
+
+// ValidateReachabilityTyped implements CheckReachability on strongly-typed steps. Steps are
+// linked by depends_on and by ${steps.<id>...} references; dependencies on unknown steps are
+// left to the dependency check. Results are in workflow order.
+func (wv *WorkflowValidator) ValidateReachabilityTyped(steps []types.WorkflowStepValidation, allowDisconnected bool) ReachabilityResult {
+	result := ReachabilityResult{Valid: true}
+	if len(steps) < 2 {
+		return result
+	}
+
+	known := make(map[string]bool, len(steps))
+	for _, step := range steps {
+		known[step.ID] = true
+	}
+
+	neighbours := make(map[string][]string)
+	hasDependents := make(map[string]bool)
+	consumed := make(map[string]bool)
+	for _, step := range steps {
+		dependencies := append([]string{}, step.DependsOn...)
+		dependencies = append(dependencies, referencedStepIDs(step.Parameters)...)
+		for _, dep := range dependencies {
+			if !known[dep] || dep == step.ID {
+				continue
+			}
+			neighbours[step.ID] = append(neighbours[step.ID], dep)
+			neighbours[dep] = append(neighbours[dep], step.ID)
+			hasDependents[dep] = true
+		}
+		for _, ref := range referencedStepOutputs(step.Parameters) {
+			if ref.stepID != step.ID {
+				consumed[ref.stepID] = true
+			}
+		}
+	}
+
+	// Terminal steps are the workflow's results; every other step exists to feed its outputs on
+	for _, step := range steps {
+		if hasDependents[step.ID] && !consumed[step.ID] {
+			result.UnconsumedSteps = append(result.UnconsumedSteps, step.ID)
+			result.Errors = append(result.Errors, fmt.Sprintf("step %s: outputs are never consumed by the steps that depend on it", step.ID))
+		}
+	}
+
+	// Group steps into connected subgraphs, numbered in order of their first step
+	component := make(map[string]int, len(steps))
+	for _, step := range steps {
+		if _, seen := component[step.ID]; seen {
+			continue
+		}
+		index := len(result.Subgraphs)
+		result.Subgraphs = append(result.Subgraphs, nil)
+		component[step.ID] = index
+		queue := []string{step.ID}
+		for len(queue) > 0 {
+			current := queue[0]
+			queue = queue[1:]
+			for _, next := range neighbours[current] {
+				if _, seen := component[next]; !seen {
+					component[next] = index
+					queue = append(queue, next)
+				}
+			}
+		}
+	}
+	for _, step := range steps {
+		index := component[step.ID]
+		result.Subgraphs[index] = append(result.Subgraphs[index], step.ID)
+	}
+
+	if len(result.Subgraphs) == 1 {
+		result.Subgraphs = nil
+	} else if !allowDisconnected {
+		main := component[steps[len(steps)-1].ID]
+		for _, step := range steps {
+			if component[step.ID] != main {
+				result.OrphanSteps = append(result.OrphanSteps, step.ID)
+			}
+		}
+		result.Errors = append(result.Errors, fmt.Sprintf("workflow has %d disconnected subgraphs, steps %s don't reach final step %s",
+			len(result.Subgraphs), strings.Join(result.OrphanSteps, ", "), steps[len(steps)-1].ID))
+	}
+
+	result.Valid = len(result.Errors) == 0
+	return result
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestCheckReachability verifies orphan steps, unconsumed outputs and disconnected subgraphs are reported
+func TestCheckReachability(t *testing.T) {
+	validator := NewWorkflowValidator()
+
+	tests := []struct {
+		name              string
+		steps             []map[string]interface{}
+		allowDisconnected bool
+		expectValid       bool
+		expectOrphans     []string
+		expectUnconsumed  []string
+		expectSubgraphs   int
+	}{
+		{
+			name: "Connected chain consuming every output",
+			steps: []map[string]interface{}{
+				{"id": "create_doc", "parameters": map[string]interface{}{"title": "Report"}},
+				{"id": "share_doc", "parameters": map[string]interface{}{"file_id": "${steps.create_doc.outputs.document_id}"}},
+				{"id": "notify", "parameters": map[string]interface{}{"body": "Shared ${steps.share_doc.outputs.link}"}},
+			},
+			expectValid: true,
+		},
+		{
+			name: "Ordering-only dependency leaves outputs unconsumed",
+			steps: []map[string]interface{}{
+				{"id": "create_doc", "parameters": map[string]interface{}{"title": "Report"}},
+				{"id": "notify", "depends_on": []interface{}{"create_doc"}, "parameters": map[string]interface{}{"body": "Done"}},
+			},
+			expectValid:      false,
+			expectUnconsumed: []string{"create_doc"},
+		},
+		{
+			name: "Orphan step outside the final step's subgraph",
+			steps: []map[string]interface{}{
+				{"id": "list_events", "parameters": map[string]interface{}{}},
+				{"id": "create_doc", "parameters": map[string]interface{}{"title": "Report"}},
+				{"id": "notify", "parameters": map[string]interface{}{"body": "${steps.create_doc.outputs.document_id}"}},
+			},
+			expectValid:     false,
+			expectOrphans:   []string{"list_events"},
+			expectSubgraphs: 2,
+		},
+		{
+			name: "Disconnected subgraphs explicitly allowed",
+			steps: []map[string]interface{}{
+				{"id": "list_events", "parameters": map[string]interface{}{}},
+				{"id": "create_doc", "parameters": map[string]interface{}{"title": "Report"}},
+				{"id": "notify", "parameters": map[string]interface{}{"body": "${steps.create_doc.outputs.document_id}"}},
+			},
+			allowDisconnected: true,
+			expectValid:       true,
+			expectSubgraphs:   2,
+		},
+		{
+			name: "Single step workflow",
+			steps: []map[string]interface{}{
+				{"id": "notify", "parameters": map[string]interface{}{"body": "Hello"}},
+			},
+			expectValid: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := validator.CheckReachability(tt.steps, tt.allowDisconnected)
+			if result.Valid != tt.expectValid {
+				t.Errorf("CheckReachability() valid = %v, expected %v. Errors: %v", result.Valid, tt.expectValid, result.Errors)
+			}
+			if !reflect.DeepEqual(result.OrphanSteps, tt.expectOrphans) {
+				t.Errorf("Expected orphan steps %v, got %v", tt.expectOrphans, result.OrphanSteps)
+			}
+			if !reflect.DeepEqual(result.UnconsumedSteps, tt.expectUnconsumed) {
+				t.Errorf("Expected unconsumed steps %v, got %v", tt.expectUnconsumed, result.UnconsumedSteps)
+			}
+			if len(result.Subgraphs) != tt.expectSubgraphs {
+				t.Errorf("Expected %d subgraphs, got %v", tt.expectSubgraphs, result.Subgraphs)
+			}
+		})
+	}
+}
+
+// TestLintWorkflowStrict verifies reachability findings are warnings unless the workflow is linted strictly
+func TestLintWorkflowStrict(t *testing.T) {
+	cueContent := `
+workflow: {
+	name: "Orphaned report"
+	description: "Create a report next to an unused calendar lookup"
+	steps: [
+		{id: "list_events", action: "calendar.list_events", inputs: {}},
+		{id: "create_doc", action: "docs.create_document", inputs: {title: "Report"}},
+		{id: "notify", action: "gmail.send_message", inputs: {body: "${steps.create_doc.outputs.document_id}"}},
+	]
+}
+`
+	ee := NewExecutionEngine(nil)
+
+	report, err := ee.LintWorkflow(cueContent, false, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !report.Valid || len(report.Warnings) != 1 || !strings.Contains(report.Warnings[0], "list_events") {
+		t.Errorf("Expected the orphan step as a warning, got %+v", report)
+	}
+
+	report, err = ee.LintWorkflow(cueContent, true, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if report.Valid || len(report.Errors) != 1 {
+		t.Errorf("Expected the orphan step as an error in strict mode, got %+v", report)
+	}
+
+	report, err = ee.LintWorkflow(cueContent, true, true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !report.Valid {
+		t.Errorf("Expected allowed disconnected subgraphs to pass strict lint, got %+v", report.Errors)
+	}
+}
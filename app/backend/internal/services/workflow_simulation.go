@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"sohoaas-backend/internal/types"
+)
+
+// ErrStepNotFound is returned when a preview names a step the workflow doesn't have
+var ErrStepNotFound = errors.New("step not found in workflow")
+
+// mockDateTime is the fixed timestamp returned for date-time outputs during simulation
+const mockDateTime = "2025-01-01T09:00:00Z"
+
+// SimulatedCall records one MCP call a workflow would make
+type SimulatedCall struct {
+	Sequence    int                    `json:"sequence"`
+	StepID      string                 `json:"step_id"`
+	Name        string                 `json:"name,omitempty"`
+	Service     string                 `json:"service"`
+	Action      string                 `json:"action"`
+	Inputs      map[string]interface{} `json:"inputs"`                 // fully resolved, as they would be sent to MCP
+	MockOutputs map[string]interface{} `json:"mock_outputs,omitempty"` // derived from the action's output schema
+	Error       string                 `json:"error,omitempty"`        // input resolution failure that would stop execution
+}
+
+// simulateStep resolves a step's inputs exactly as executeStep would and records the call
+// instead of sending it. Mock outputs derived from the catalog's output schema feed the
+// ${steps.*} references of later steps. It returns the resolved inputs.
+func (ee *ExecutionEngine) simulateStep(step *ResolvedStep, plan *ExecutionPlan) (map[string]interface{}, error) {
+	call := SimulatedCall{
+		Sequence: len(plan.SimulationLog) + 1,
+		StepID:   step.ID,
+		Name:     step.Name,
+		Service:  step.Service,
+		Action:   step.Action,
+	}
+
+	resolvedInputs, err := ee.resolveStepInputs(step.Inputs, plan.ParameterContext)
+	if err != nil {
+		call.Error = err.Error()
+		plan.SimulationLog = append(plan.SimulationLog, call)
+		return nil, fmt.Errorf("parameter resolution failed: %w", err)
+	}
+	call.Inputs = resolvedInputs
+
+	catalog, err := ee.mcpService.GetServiceCatalog()
+	if err != nil {
+		return resolvedInputs, fmt.Errorf("failed to get MCP catalog for simulation: %w", err)
+	}
+	call.MockOutputs = mockStepOutputs(catalog, step.Service, step.Action)
+	plan.SimulationLog = append(plan.SimulationLog, call)
+
+	if step.Outputs == nil {
+		step.Outputs = make(map[string]interface{})
+	}
+	for key, value := range call.MockOutputs {
+		step.Outputs[key] = value
+	}
+	plan.ParameterContext.SetStepOutputs(step.ID, call.MockOutputs)
+
+	log.Printf("[ExecutionEngine] Simulated %s.%s for step %s", step.Service, step.Action, step.ID)
+	return resolvedInputs, nil
+}
+
+// mockStepOutputs builds placeholder outputs for every field in an action's output schema
+func mockStepOutputs(catalog *types.MCPServiceCatalog, service, action string) map[string]interface{} {
+	outputs := make(map[string]interface{})
+	if catalog == nil {
+		return outputs
+	}
+	_, serviceDefinition, exists := catalog.LookupService(service)
+	if !exists {
+		return outputs
+	}
+	functionSchema, exists := serviceDefinition.Functions[action]
+	if !exists || functionSchema.OutputSchema == nil {
+		return outputs
+	}
+	for field, property := range functionSchema.OutputSchema.Properties {
+		outputs[field] = mockOutputValue(field, property)
+	}
+	return outputs
+}
+
+// mockOutputValue returns a recognizable value of the property's type. List items have no
+// schema in the catalog, so lists hold a single string.
+func mockOutputValue(field string, property types.MCPParameterProperty) interface{} {
+	if property.Default != nil {
+		return property.Default
+	}
+	if len(property.Enum) > 0 {
+		return property.Enum[0]
+	}
+
+	switch property.Type {
+	case "integer", "number":
+		return 1
+	case "boolean":
+		return true
+	case "array":
+		return []interface{}{fmt.Sprintf("mock_%s_1", field)}
+	case "object":
+		return map[string]interface{}{}
+	}
+
+	switch property.Format {
+	case "email":
+		return "user@example.com"
+	case "date-time":
+		return mockDateTime
+	case "uri", "url":
+		return fmt.Sprintf("https://example.com/mock/%s", field)
+	}
+	return fmt.Sprintf("mock_%s", field)
+}
+
+// PreviewStepInputs returns the string inputs of one step exactly as they would be sent,
+// e.g. an email body. The plan is simulated, so ${steps.*} references resolve to the same
+// mock outputs the simulation uses and nothing is executed. Later steps failing to resolve
+// don't affect the preview.
+func (ee *ExecutionEngine) PreviewStepInputs(plan *ExecutionPlan, stepID string) (map[string]string, error) {
+	found := false
+	for _, step := range plan.ResolvedSteps {
+		if step.ID == stepID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: %s", ErrStepNotFound, stepID)
+	}
+
+	// A preview is not an execution, so it runs outside an audit scope
+	plan.Simulate = true
+	simulationErr := ee.executeWorkflowContext(context.Background(), plan)
+	for _, call := range plan.SimulationLog {
+		if call.StepID != stepID {
+			continue
+		}
+		if call.Error != "" {
+			return nil, fmt.Errorf("inputs of step %s could not be resolved: %s", stepID, call.Error)
+		}
+		preview := make(map[string]string)
+		for name, value := range call.Inputs {
+			if text, ok := value.(string); ok {
+				preview[name] = text
+			}
+		}
+		return preview, nil
+	}
+	if simulationErr != nil {
+		return nil, fmt.Errorf("simulation stopped before step %s: %w", stepID, simulationErr)
+	}
+	return nil, fmt.Errorf("step %s was not simulated", stepID)
+}
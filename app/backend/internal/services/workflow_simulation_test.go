@@ -0,0 +1,155 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestSimulateWorkflowRecordsCallLog verifies simulate mode records resolved calls without executing them
+func TestSimulateWorkflowRecordsCallLog(t *testing.T) {
+	catalogJSON := `{"providers": {"workspace": {"services": {
+		"docs": {"functions": {"create_document": {"name": "create_document", "output_schema": {"type": "object", "properties": {
+			"document_id": {"type": "string"},
+			"document_url": {"type": "string", "format": "uri"}
+		}}}}},
+		"gmail": {"functions": {"send_message": {"name": "send_message", "output_schema": {"type": "object", "properties": {
+			"message_id": {"type": "string"}
+		}}}}}
+	}}}}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Unexpected MCP call in simulate mode: %s %s", r.Method, r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(catalogJSON))
+	}))
+	defer server.Close()
+
+	ee := NewExecutionEngine(NewMCPService(server.URL))
+	plan := &ExecutionPlan{
+		Name:     "share_doc",
+		Simulate: true,
+		ResolvedSteps: []ResolvedStep{
+			{
+				ID: "create_doc", Service: "docs", Action: "create_document", Status: "pending",
+				Inputs:  map[string]interface{}{"title": "${user.title}"},
+				Outputs: map[string]interface{}{},
+			},
+			{
+				ID: "notify", Service: "gmail", Action: "send_message", Status: "pending",
+				DependsOn:            []string{"create_doc"},
+				RequiresConfirmation: true,
+				Inputs: map[string]interface{}{
+					"to":   "team@example.com",
+					"body": "Doc: ${steps.create_doc.outputs.document_url}",
+				},
+				Outputs: map[string]interface{}{},
+			},
+		},
+		ParameterContext: &ParameterContext{
+			UserParameters:    map[string]interface{}{"title": "Weekly Report"},
+			StepOutputs:       map[string]interface{}{},
+			SystemParameters:  map[string]interface{}{},
+			RuntimeParameters: map[string]interface{}{},
+		},
+		PendingConfirmation: []string{"notify"},
+	}
+
+	if err := ee.ExecuteWorkflow(plan); err != nil {
+		t.Fatalf("Simulation failed: %v", err)
+	}
+
+	if len(plan.SimulationLog) != 2 {
+		t.Fatalf("Expected 2 recorded calls, got %d", len(plan.SimulationLog))
+	}
+	first, second := plan.SimulationLog[0], plan.SimulationLog[1]
+	if first.Sequence != 1 || first.Service != "docs" || first.Action != "create_document" || first.Inputs["title"] != "Weekly Report" {
+		t.Errorf("Unexpected first call: %+v", first)
+	}
+	if first.MockOutputs["document_id"] != "mock_document_id" {
+		t.Errorf("Expected schema-derived mock outputs, got %v", first.MockOutputs)
+	}
+	if second.Sequence != 2 || second.Inputs["body"] != "Doc: https://example.com/mock/document_url" {
+		t.Errorf("Expected step reference resolved from mock outputs, got %+v", second)
+	}
+}
+
+// TestSimulateWorkflowRecordsResolutionError verifies the failing call is recorded with its error
+func TestSimulateWorkflowRecordsResolutionError(t *testing.T) {
+	ee := NewExecutionEngine(nil)
+	plan := &ExecutionPlan{
+		Simulate: true,
+		ResolvedSteps: []ResolvedStep{
+			{
+				ID: "notify", Service: "gmail", Action: "send_message", Status: "pending",
+				Inputs:  map[string]interface{}{"to": "${user.recipient}"},
+				Outputs: map[string]interface{}{},
+			},
+		},
+		ParameterContext: &ParameterContext{
+			UserParameters:    map[string]interface{}{},
+			StepOutputs:       map[string]interface{}{},
+			SystemParameters:  map[string]interface{}{},
+			RuntimeParameters: map[string]interface{}{},
+		},
+	}
+
+	if err := ee.ExecuteWorkflow(plan); err == nil {
+		t.Fatal("Expected simulation to stop on an unresolved parameter")
+	}
+	if len(plan.SimulationLog) != 1 || plan.SimulationLog[0].Error == "" {
+		t.Errorf("Expected the failing call to be recorded with its error, got %+v", plan.SimulationLog)
+	}
+}
+
+// TestPreviewStepInputs verifies a step's string inputs are rendered with mock step outputs
+func TestPreviewStepInputs(t *testing.T) {
+	ee := NewExecutionEngine(NewFakeMCPService(MockPipelineCatalog()))
+	newPlan := func() *ExecutionPlan {
+		return &ExecutionPlan{
+			Name: "weekly_report",
+			ResolvedSteps: []ResolvedStep{
+				{
+					ID: "create_report", Service: "docs", Action: "create_document", Status: "pending",
+					Inputs:  map[string]interface{}{"title": "${user.report_title}"},
+					Outputs: map[string]interface{}{},
+				},
+				{
+					ID: "send_report", Service: "gmail", Action: "send_message", Status: "pending",
+					DependsOn: []string{"create_report"},
+					Inputs: map[string]interface{}{
+						"to":          "team@example.com",
+						"body":        "This week's report: ${steps.create_report.outputs.document_url}",
+						"attachments": []interface{}{"report.pdf"},
+					},
+					Outputs: map[string]interface{}{},
+				},
+			},
+			ParameterContext: &ParameterContext{
+				UserParameters:    map[string]interface{}{"report_title": "Weekly Report"},
+				StepOutputs:       map[string]interface{}{},
+				SystemParameters:  map[string]interface{}{},
+				RuntimeParameters: map[string]interface{}{},
+			},
+		}
+	}
+
+	preview, err := ee.PreviewStepInputs(newPlan(), "send_report")
+	if err != nil {
+		t.Fatalf("Preview failed: %v", err)
+	}
+	if preview["body"] != "This week's report: https://example.com/mock/document_url" {
+		t.Errorf("Expected the body rendered with the mock document URL, got %q", preview["body"])
+	}
+	if preview["to"] != "team@example.com" {
+		t.Errorf("Expected the recipient, got %q", preview["to"])
+	}
+	if _, exists := preview["attachments"]; exists {
+		t.Error("Expected only string inputs in the preview")
+	}
+
+	if _, err := ee.PreviewStepInputs(newPlan(), "missing"); err == nil {
+		t.Error("Expected an error for an unknown step")
+	}
+}
@@ -10,6 +10,7 @@ import (
 
 	"cuelang.org/go/cue"
 	"cuelang.org/go/cue/cuecontext"
+	"sohoaas-backend/internal/storage"
 	"sohoaas-backend/internal/types"
 )
 
@@ -373,5 +374,5 @@ func (w *WorkflowStorageService) GetWorkflow(userID string, workflowID string) (
 		}
 	}
 
-	return nil, fmt.Errorf("workflow not found: %s", workflowID)
+	return nil, fmt.Errorf("%w: %s", storage.ErrWorkflowNotFound, workflowID)
 }
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrWorkflowTimedOut is returned when an execution exceeds its workflow-level time budget
+var ErrWorkflowTimedOut = errors.New("workflow execution timed out")
+
+// defaultWorkflowTimeout bounds executions whose workflow declares no execution_config.timeout
+const defaultWorkflowTimeout = 5 * time.Minute
+
+// Final statuses of a workflow execution
+const (
+	ExecutionStatusCompleted = "completed"
+	ExecutionStatusFailed    = "failed"
+	ExecutionStatusTimedOut  = "timed_out"
+	ExecutionStatusCancelled = "cancelled"
+)
+
+// SetDefaultWorkflowTimeout sets the runtime budget of workflows without execution_config.timeout; 0 restores the default
+func (ee *ExecutionEngine) SetDefaultWorkflowTimeout(timeout time.Duration) {
+	ee.defaultWorkflowTimeout = timeout
+}
+
+// workflowTimeout returns the runtime budget of a plan: its own timeout, else the engine default
+func (ee *ExecutionEngine) workflowTimeout(plan *ExecutionPlan) time.Duration {
+	if plan.Timeout > 0 {
+		return plan.Timeout
+	}
+	if ee.defaultWorkflowTimeout > 0 {
+		return ee.defaultWorkflowTimeout
+	}
+	return defaultWorkflowTimeout
+}
+
+// ExecutionStatus returns the final status of an execution that ended with execErr
+func ExecutionStatus(execErr error) string {
+	switch {
+	case execErr == nil:
+		return ExecutionStatusCompleted
+	case errors.Is(execErr, ErrWorkflowTimedOut):
+		return ExecutionStatusTimedOut
+	case errors.Is(execErr, ErrExecutionCancelled):
+		return ExecutionStatusCancelled
+	default:
+		return ExecutionStatusFailed
+	}
+}
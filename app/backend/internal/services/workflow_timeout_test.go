@@ -0,0 +1,145 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestParseCUEWorkflowTimeout verifies execution_config.timeout is parsed, and an unusable one
+// falls back to the default with a warning
+func TestParseCUEWorkflowTimeout(t *testing.T) {
+	ee := NewExecutionEngine(nil)
+
+	workflowWithConfig := func(executionConfig string) string {
+		return `workflow: {
+	name:        "report"
+	description: "Send the weekly report"
+	steps: [{
+		id:     "send"
+		name:   "Send report"
+		action: "gmail.send_message"
+		inputs: {to: "team@example.com"}
+	}]
+	` + executionConfig + `
+}`
+	}
+
+	workflow, err := ee.ParseCUEWorkflow(workflowWithConfig(`execution_config: {mode: "sequential", timeout: "90s"}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if workflow.Timeout != 90*time.Second {
+		t.Errorf("Expected timeout 90s, got %s", workflow.Timeout)
+	}
+
+	workflow, err = ee.ParseCUEWorkflow(workflowWithConfig(""))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if workflow.Timeout != 0 {
+		t.Errorf("Expected no timeout when absent, got %s", workflow.Timeout)
+	}
+
+	for _, invalid := range []string{`"soon"`, `"0s"`, `"-1m"`, `30`} {
+		workflow, err := ee.ParseCUEWorkflow(workflowWithConfig(`execution_config: {mode: "sequential", timeout: ` + invalid + `}`))
+		if err != nil {
+			t.Errorf("Timeout %s: expected the default to apply, got %v", invalid, err)
+			continue
+		}
+		if workflow.Timeout != 0 {
+			t.Errorf("Timeout %s: expected no timeout, got %s", invalid, workflow.Timeout)
+		}
+		if len(workflow.Warnings) != 1 || !strings.Contains(workflow.Warnings[0], "execution_config.timeout ignored") {
+			t.Errorf("Timeout %s: expected a warning, got %v", invalid, workflow.Warnings)
+		}
+		if warnings := ee.collectValidationWarnings(workflow); len(warnings) != 1 {
+			t.Errorf("Timeout %s: expected the warning among the validation warnings, got %v", invalid, warnings)
+		}
+	}
+}
+
+// TestWorkflowTimeoutDefaults verifies the plan's timeout wins over the configured and built-in defaults
+func TestWorkflowTimeoutDefaults(t *testing.T) {
+	ee := NewExecutionEngine(nil)
+	if timeout := ee.workflowTimeout(&ExecutionPlan{}); timeout != defaultWorkflowTimeout {
+		t.Errorf("Expected built-in default %s, got %s", defaultWorkflowTimeout, timeout)
+	}
+
+	ee.SetDefaultWorkflowTimeout(time.Minute)
+	if timeout := ee.workflowTimeout(&ExecutionPlan{}); timeout != time.Minute {
+		t.Errorf("Expected configured default 1m, got %s", timeout)
+	}
+	if timeout := ee.workflowTimeout(&ExecutionPlan{Timeout: 10 * time.Second}); timeout != 10*time.Second {
+		t.Errorf("Expected plan timeout 10s, got %s", timeout)
+	}
+}
+
+// TestExecuteWorkflowTimesOut verifies an exceeded budget aborts the in-flight step and leaves later steps pending
+func TestExecuteWorkflowTimesOut(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`{"providers": {"workspace": {"services": {}}}}`))
+			return
+		}
+		// Hold the call until the engine gives up on it; the request context is only cancelled
+		// once the body has been read
+		io.Copy(io.Discard, r.Body)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"result": map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": `{}`}}},
+		})
+	}))
+	defer server.Close()
+
+	ee := NewExecutionEngine(NewMCPService(server.URL))
+	plan := &ExecutionPlan{
+		Name:    "slow",
+		Timeout: 50 * time.Millisecond,
+		ResolvedSteps: []ResolvedStep{
+			{ID: "first", Service: "gmail", Action: "send_message", Inputs: map[string]interface{}{}, Outputs: map[string]interface{}{}},
+			{ID: "second", Service: "gmail", Action: "send_message", Inputs: map[string]interface{}{}, Outputs: map[string]interface{}{}, DependsOn: []string{"first"}},
+		},
+		ParameterContext: &ParameterContext{
+			StepOutputs:      map[string]interface{}{},
+			SystemParameters: map[string]interface{}{"oauth_token": "token"},
+		},
+	}
+
+	started := time.Now()
+	err := ee.ExecuteWorkflow(plan)
+	if !errors.Is(err, ErrWorkflowTimedOut) {
+		t.Fatalf("Expected ErrWorkflowTimedOut, got %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > 2*time.Second {
+		t.Errorf("Expected the in-flight call to be canceled, execution took %s", elapsed)
+	}
+	if status := ExecutionStatus(err); status != ExecutionStatusTimedOut {
+		t.Errorf("Expected status %s, got %s", ExecutionStatusTimedOut, status)
+	}
+	if plan.ResolvedSteps[0].Status != StepFailed {
+		t.Errorf("Expected the aborted step to fail, got %s", plan.ResolvedSteps[0].Status)
+	}
+	if plan.ResolvedSteps[1].Status != "" && plan.ResolvedSteps[1].Status != StepPending {
+		t.Errorf("Expected the remaining step not to run, got %s", plan.ResolvedSteps[1].Status)
+	}
+}
+
+// TestExecutionStatus verifies the final status reported for an execution error
+func TestExecutionStatus(t *testing.T) {
+	if status := ExecutionStatus(nil); status != ExecutionStatusCompleted {
+		t.Errorf("Expected %s, got %s", ExecutionStatusCompleted, status)
+	}
+	if status := ExecutionStatus(errors.New("step send failed")); status != ExecutionStatusFailed {
+		t.Errorf("Expected %s, got %s", ExecutionStatusFailed, status)
+	}
+}
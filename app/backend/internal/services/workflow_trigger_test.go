@@ -0,0 +1,46 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestConvertJSONToCUEScheduleTrigger verifies a generated schedule is stored with its normalized cron
+func TestConvertJSONToCUEScheduleTrigger(t *testing.T) {
+	g := &GenkitService{}
+	workflowJSON := func(schedule string) map[string]interface{} {
+		return map[string]interface{}{
+			"name":        "Standup reminder",
+			"description": "Email the standup reminder",
+			"trigger":     map[string]interface{}{"type": "schedule", "schedule": schedule},
+			"steps": []interface{}{
+				map[string]interface{}{
+					"id":         "send",
+					"action":     "gmail.send_message",
+					"parameters": map[string]interface{}{"to": "team@example.com", "subject": "Standup"},
+				},
+			},
+		}
+	}
+
+	cueContent := g.convertJSONToCUE(workflowJSON("every weekday at 8 AM"))
+	if !strings.Contains(cueContent, `cron: "0 8 * * 1-5"`) {
+		t.Errorf("Expected the normalized cron in generated CUE:\n%s", cueContent)
+	}
+	workflow, err := NewExecutionEngine(nil).ParseCUEWorkflow(cueContent)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if workflow.Trigger == nil || workflow.Trigger.Cron != "0 8 * * 1-5" {
+		t.Errorf("Expected the scheduled trigger to parse, got %+v", workflow.Trigger)
+	}
+
+	// An invalid schedule fails validation, so the workflow is never saved
+	cueContent = g.convertJSONToCUE(workflowJSON("every blue moon"))
+	if strings.Contains(cueContent, "cron:") {
+		t.Errorf("Expected no cron for an invalid schedule:\n%s", cueContent)
+	}
+	if _, err := NewExecutionEngine(nil).ParseCUEWorkflow(cueContent); err == nil {
+		t.Error("Expected the invalid schedule to be rejected")
+	}
+}
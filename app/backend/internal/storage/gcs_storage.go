@@ -2,6 +2,8 @@ package storage
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -130,6 +132,13 @@ func (gcs *GCSStorage) GetWorkflow(userID string, workflowID string) (*types.Wor
 		Name:     cleanWorkflowID, // Add Name field to match local storage interface
 		CreatedAt: attrs.Created,
 	}
+
+	labelsPath := fmt.Sprintf("%s%s/%s/%s/%s", gcs.workflowsPrefix, userID, cleanWorkflowID, workflowLabelsDir, workflowLabelsFile)
+	if labels, err := gcs.readObject(labelsPath); err == nil {
+		applyWorkflowLabels(workflowFile, labels)
+	} else if !errors.Is(err, storage.ErrObjectNotExist) {
+		log.Printf("Failed to read workflow labels %s: %v", labelsPath, err)
+	}
 	
 	return workflowFile, nil
 }
@@ -139,6 +148,7 @@ func (gcs *GCSStorage) ListUserWorkflows(userID string) ([]*types.WorkflowFile,
 	prefix := fmt.Sprintf("%s%s/", gcs.workflowsPrefix, userID)
 	
 	var workflows []*types.WorkflowFile
+	labelsPaths := make(map[string]string) // workflow ID -> labels object
 	
 	// List objects with the user's workflow prefix
 	it := gcs.client.Bucket(gcs.bucketName).Objects(gcs.ctx, &storage.Query{
@@ -155,6 +165,13 @@ func (gcs *GCSStorage) ListUserWorkflows(userID string) ([]*types.WorkflowFile,
 			return nil, fmt.Errorf("failed to list workflows: %v", err)
 		}
 		
+		// Remember labels files to apply once their workflows are listed
+		labelsSuffix := "/" + workflowLabelsDir + "/" + workflowLabelsFile
+		if strings.HasSuffix(attrs.Name, labelsSuffix) {
+			labelsPaths[strings.TrimSuffix(strings.TrimPrefix(attrs.Name, prefix), labelsSuffix)] = attrs.Name
+			continue
+		}
+
 		// Only include workflow.cue files
 		if !strings.HasSuffix(attrs.Name, "/workflow.cue") {
 			continue
@@ -192,10 +209,32 @@ func (gcs *GCSStorage) ListUserWorkflows(userID string) ([]*types.WorkflowFile,
 		
 		workflows = append(workflows, workflowFile)
 	}
+
+	for _, workflowFile := range workflows {
+		labelsPath, exists := labelsPaths[strings.TrimPrefix(workflowFile.ID, userID+"_")]
+		if !exists {
+			continue
+		}
+		if labels, err := gcs.readObject(labelsPath); err == nil {
+			applyWorkflowLabels(workflowFile, labels)
+		} else {
+			log.Printf("Failed to read workflow labels %s: %v", labelsPath, err)
+		}
+	}
 	
 	return workflows, nil
 }
 
+// readObject returns the content of an object in the bucket
+func (gcs *GCSStorage) readObject(objectPath string) ([]byte, error) {
+	reader, err := gcs.client.Bucket(gcs.bucketName).Object(objectPath).NewReader(gcs.ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
 // SaveWorkflowArtifact saves an artifact to the workflow's artifact directory in GCS
 func (gcs *GCSStorage) SaveWorkflowArtifact(userID string, workflowID string, artifactType string, filename string, content string) error {
 	cleanWorkflowID := strings.TrimPrefix(workflowID, userID+"_")
@@ -253,6 +292,24 @@ func (gcs *GCSStorage) GetStorageInfo() map[string]interface{} {
 	}
 }
 
+// SaveWorkflowLabels writes the workflow's tags and category to metadata/labels.json in GCS
+func (gcs *GCSStorage) SaveWorkflowLabels(userID string, workflowID string, labels types.WorkflowLabels) error {
+	cleanWorkflowID := strings.TrimPrefix(workflowID, userID+"_")
+	objectPath := fmt.Sprintf("%s%s/%s/workflow.cue", gcs.workflowsPrefix, userID, cleanWorkflowID)
+	if _, err := gcs.client.Bucket(gcs.bucketName).Object(objectPath).Attrs(gcs.ctx); err != nil {
+		if errors.Is(err, storage.ErrObjectNotExist) {
+			return fmt.Errorf("%w: %s", ErrWorkflowNotFound, workflowID)
+		}
+		return fmt.Errorf("failed to get workflow attributes: %v", err)
+	}
+
+	content, err := json.MarshalIndent(labels, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal workflow labels: %v", err)
+	}
+	return gcs.SaveWorkflowArtifact(userID, cleanWorkflowID, workflowLabelsDir, workflowLabelsFile, string(content))
+}
+
 // DeleteWorkflow deletes all objects under the workflow prefix for the given user and workflow ID
 func (gcs *GCSStorage) DeleteWorkflow(userID string, workflowID string) error {
 	cleanWorkflowID := strings.TrimPrefix(workflowID, userID+"_")
@@ -280,7 +337,7 @@ func (gcs *GCSStorage) DeleteWorkflow(userID string, workflowID string) error {
 	}
 
 	if !found {
-		return fmt.Errorf("workflow not found: %s", workflowID)
+		return fmt.Errorf("%w: %s", ErrWorkflowNotFound, workflowID)
 	}
 	return nil
 }
@@ -1,9 +1,14 @@
 package storage
 
 import (
+	"errors"
+
 	"sohoaas-backend/internal/types"
 )
 
+// ErrWorkflowNotFound is returned when a user has no workflow with the requested ID
+var ErrWorkflowNotFound = errors.New("workflow not found")
+
 // WorkflowStorage defines the common interface for workflow storage backends
 type WorkflowStorage interface {
 	// Core workflow operations
@@ -12,6 +17,8 @@ type WorkflowStorage interface {
 	ListUserWorkflows(userID string) ([]*types.WorkflowFile, error)
 	// Delete workflow and its folder/prefix for the given user
 	DeleteWorkflow(userID string, workflowID string) error
+	// Replace the tags and category of an existing workflow
+	SaveWorkflowLabels(userID string, workflowID string, labels types.WorkflowLabels) error
 	
 	// Artifact management
 	SaveWorkflowArtifact(userID string, workflowID string, artifactType string, filename string, content string) error
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -72,7 +73,7 @@ func (ls *LocalStorage) GetWorkflow(userID string, workflowID string) (*types.Wo
 	workflowDirName := strings.TrimPrefix(workflowID, userID+"_")
 	workflowPath := filepath.Join(ls.workflowsDir, userID, workflowDirName, "workflow.cue")
 	if _, err := os.Stat(workflowPath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("workflow not found: %s", workflowID)
+		return nil, fmt.Errorf("%w: %s", ErrWorkflowNotFound, workflowID)
 	}
 
 	info, err := os.Stat(workflowPath)
@@ -94,6 +95,7 @@ func (ls *LocalStorage) GetWorkflow(userID string, workflowID string) (*types.Wo
 		Content:   string(content),
 		CreatedAt: info.ModTime(),
 	}
+	ls.loadWorkflowLabels(filepath.Dir(workflowPath), workflowFile)
 
 	// Parse CUE content into structured data
 	if parsed, err := parseCUEWorkflow(string(content), workflowFile); err == nil {
@@ -147,6 +149,7 @@ func (ls *LocalStorage) ListUserWorkflows(userID string) ([]*types.WorkflowFile,
 			Content:   string(content),
 			CreatedAt: info.ModTime(),
 		}
+		ls.loadWorkflowLabels(filepath.Dir(workflowPath), workflow)
 
 		// Parse CUE content into structured data
 		if parsed, err := parseCUEWorkflow(string(content), workflow); err == nil {
@@ -211,6 +214,27 @@ func (ls *LocalStorage) GetStorageInfo() map[string]interface{} {
 	}
 }
 
+// SaveWorkflowLabels writes the workflow's tags and category to metadata/labels.json
+func (ls *LocalStorage) SaveWorkflowLabels(userID string, workflowID string, labels types.WorkflowLabels) error {
+	cleanWorkflowID := strings.TrimPrefix(workflowID, userID+"_")
+	if _, err := os.Stat(filepath.Join(ls.workflowsDir, userID, cleanWorkflowID, "workflow.cue")); os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s", ErrWorkflowNotFound, workflowID)
+	}
+
+	content, err := json.MarshalIndent(labels, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal workflow labels: %v", err)
+	}
+	return ls.SaveWorkflowArtifact(userID, cleanWorkflowID, workflowLabelsDir, workflowLabelsFile, string(content))
+}
+
+// loadWorkflowLabels sets the workflow's tags and category from its labels file, if it has one
+func (ls *LocalStorage) loadWorkflowLabels(workflowDir string, workflow *types.WorkflowFile) {
+	if content, err := os.ReadFile(filepath.Join(workflowDir, workflowLabelsDir, workflowLabelsFile)); err == nil {
+		applyWorkflowLabels(workflow, content)
+	}
+}
+
 // DeleteWorkflow removes the workflow directory for the given user and workflow ID
 func (ls *LocalStorage) DeleteWorkflow(userID string, workflowID string) error {
 	// Support combined IDs in the form userID_workflowID
@@ -218,7 +242,7 @@ func (ls *LocalStorage) DeleteWorkflow(userID string, workflowID string) error {
 	workflowDir := filepath.Join(ls.workflowsDir, userID, cleanWorkflowID)
 
 	if _, err := os.Stat(workflowDir); os.IsNotExist(err) {
-		return fmt.Errorf("workflow not found: %s", workflowID)
+		return fmt.Errorf("%w: %s", ErrWorkflowNotFound, workflowID)
 	}
 
 	if err := os.RemoveAll(workflowDir); err != nil {
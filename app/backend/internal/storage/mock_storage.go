@@ -59,7 +59,7 @@ func (m *MockStorage) GetWorkflow(userID string, workflowID string) (*types.Work
 
 	workflow, exists := m.workflows[workflowID]
 	if !exists {
-		return nil, fmt.Errorf("workflow not found: %s", workflowID)
+		return nil, fmt.Errorf("%w: %s", ErrWorkflowNotFound, workflowID)
 	}
 	return workflow, nil
 }
@@ -117,12 +117,26 @@ func (m *MockStorage) GetStorageInfo() map[string]interface{} {
 	}
 }
 
+// SaveWorkflowLabels sets the tags and category of a workflow in mock storage
+func (m *MockStorage) SaveWorkflowLabels(userID string, workflowID string, labels types.WorkflowLabels) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	workflow, exists := m.workflows[workflowID]
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrWorkflowNotFound, workflowID)
+	}
+	workflow.Tags = labels.Tags
+	workflow.Category = labels.Category
+	return nil
+}
+
 // DeleteWorkflow removes a workflow from mock storage
 func (m *MockStorage) DeleteWorkflow(userID string, workflowID string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	if _, ok := m.workflows[workflowID]; !ok {
-		return fmt.Errorf("workflow not found: %s", workflowID)
+		return fmt.Errorf("%w: %s", ErrWorkflowNotFound, workflowID)
 	}
 	delete(m.workflows, workflowID)
 	// Optionally clean artifacts for this workflow
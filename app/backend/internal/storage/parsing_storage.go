@@ -93,6 +93,11 @@ func (ps *parsingStorage) GetStorageInfo() map[string]interface{} {
 	return ps.inner.GetStorageInfo()
 }
 
+// SaveWorkflowLabels passthrough to inner storage
+func (ps *parsingStorage) SaveWorkflowLabels(userID string, workflowID string, labels types.WorkflowLabels) error {
+	return ps.inner.SaveWorkflowLabels(userID, workflowID, labels)
+}
+
 // DeleteWorkflow passthrough to inner storage
 func (ps *parsingStorage) DeleteWorkflow(userID string, workflowID string) error {
 	return ps.inner.DeleteWorkflow(userID, workflowID)
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"sohoaas-backend/internal/types"
+)
+
+// Workflow labels are stored as metadata/labels.json next to the workflow's CUE
+const (
+	workflowLabelsDir  = "metadata"
+	workflowLabelsFile = "labels.json"
+)
+
+const (
+	maxWorkflowTags        = 20
+	maxWorkflowLabelLength = 50
+)
+
+// ErrInvalidWorkflowLabels is returned when tags or a category are too long or too many
+var ErrInvalidWorkflowLabels = errors.New("invalid workflow labels")
+
+// NormalizeWorkflowLabels trims and lowercases tags, dropping empty and duplicate ones, and
+// trims the category
+func NormalizeWorkflowLabels(labels types.WorkflowLabels) (types.WorkflowLabels, error) {
+	normalized := types.WorkflowLabels{
+		Tags:     []string{},
+		Category: strings.TrimSpace(labels.Category),
+	}
+	if len(normalized.Category) > maxWorkflowLabelLength {
+		return normalized, fmt.Errorf("%w: category is longer than %d characters", ErrInvalidWorkflowLabels, maxWorkflowLabelLength)
+	}
+
+	seen := make(map[string]bool)
+	for _, tag := range labels.Tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		if len(tag) > maxWorkflowLabelLength {
+			return normalized, fmt.Errorf("%w: tag %q is longer than %d characters", ErrInvalidWorkflowLabels, tag, maxWorkflowLabelLength)
+		}
+		seen[tag] = true
+		normalized.Tags = append(normalized.Tags, tag)
+	}
+	if len(normalized.Tags) > maxWorkflowTags {
+		return normalized, fmt.Errorf("%w: at most %d tags are allowed", ErrInvalidWorkflowLabels, maxWorkflowTags)
+	}
+	return normalized, nil
+}
+
+// applyWorkflowLabels sets a workflow's tags and category from the content of its labels file
+func applyWorkflowLabels(workflow *types.WorkflowFile, content []byte) {
+	var labels types.WorkflowLabels
+	if err := json.Unmarshal(content, &labels); err != nil {
+		log.Printf("[Storage] Ignoring unreadable labels of workflow %s: %v", workflow.ID, err)
+		return
+	}
+	workflow.Tags = labels.Tags
+	workflow.Category = labels.Category
+}
+
+// FilterWorkflows returns the workflows carrying tag and in category, ignoring case; an empty
+// tag or category matches every workflow
+func FilterWorkflows(workflows []*types.WorkflowFile, tag, category string) []*types.WorkflowFile {
+	tag = strings.TrimSpace(tag)
+	category = strings.TrimSpace(category)
+
+	filtered := []*types.WorkflowFile{}
+	for _, workflow := range workflows {
+		if category != "" && !strings.EqualFold(workflow.Category, category) {
+			continue
+		}
+		if tag != "" && !hasWorkflowTag(workflow, tag) {
+			continue
+		}
+		filtered = append(filtered, workflow)
+	}
+	return filtered
+}
+
+// hasWorkflowTag reports whether the workflow carries tag, ignoring case
+func hasWorkflowTag(workflow *types.WorkflowFile, tag string) bool {
+	for _, candidate := range workflow.Tags {
+		if strings.EqualFold(candidate, tag) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sohoaas-backend/internal/types"
+)
+
+func TestNormalizeWorkflowLabels(t *testing.T) {
+	labels, err := NormalizeWorkflowLabels(types.WorkflowLabels{
+		Tags:     []string{" Reports ", "reports", "", "Finance"},
+		Category: "  Monthly ",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"reports", "finance"}, labels.Tags)
+	assert.Equal(t, "Monthly", labels.Category)
+
+	_, err = NormalizeWorkflowLabels(types.WorkflowLabels{Tags: []string{strings.Repeat("x", maxWorkflowLabelLength+1)}})
+	assert.True(t, errors.Is(err, ErrInvalidWorkflowLabels), "expected a too long tag to be rejected, got %v", err)
+
+	tooMany := make([]string, maxWorkflowTags+1)
+	for i := range tooMany {
+		tooMany[i] = strings.Repeat("t", i+1)
+	}
+	_, err = NormalizeWorkflowLabels(types.WorkflowLabels{Tags: tooMany})
+	assert.True(t, errors.Is(err, ErrInvalidWorkflowLabels), "expected too many tags to be rejected, got %v", err)
+}
+
+func TestFilterWorkflows(t *testing.T) {
+	workflows := []*types.WorkflowFile{
+		{ID: "a", Tags: []string{"reports", "finance"}, Category: "Monthly"},
+		{ID: "b", Tags: []string{"reports"}},
+		{ID: "c"},
+	}
+
+	ids := func(filtered []*types.WorkflowFile) []string {
+		result := []string{}
+		for _, workflow := range filtered {
+			result = append(result, workflow.ID)
+		}
+		return result
+	}
+
+	assert.Equal(t, []string{"a", "b"}, ids(FilterWorkflows(workflows, "Reports", "")))
+	assert.Equal(t, []string{"a"}, ids(FilterWorkflows(workflows, "", "monthly")))
+	assert.Equal(t, []string{"a"}, ids(FilterWorkflows(workflows, "reports", "Monthly")))
+	assert.Empty(t, ids(FilterWorkflows(workflows, "travel", "")))
+	assert.Len(t, FilterWorkflows(workflows, "", ""), 3)
+}
+
+func TestWorkflowLabelsRoundTrip(t *testing.T) {
+	localStorage, err := NewLocalStorage(LocalStorageConfig{WorkflowsDir: t.TempDir()})
+	require.NoError(t, err)
+
+	storages := []struct {
+		name    string
+		storage WorkflowStorage
+	}{
+		{"LocalStorage", NewParsingStorage(localStorage)},
+		{"MockStorage", NewMockStorage()},
+	}
+
+	for _, s := range storages {
+		t.Run(s.name, func(t *testing.T) {
+			workflow, err := s.storage.SaveWorkflow("test_user", "labelled_workflow", testWorkflowCUE)
+			require.NoError(t, err)
+			assert.Empty(t, workflow.Tags)
+
+			labels := types.WorkflowLabels{Tags: []string{"reports"}, Category: "Monthly"}
+			require.NoError(t, s.storage.SaveWorkflowLabels("test_user", workflow.ID, labels))
+
+			retrieved, err := s.storage.GetWorkflow("test_user", workflow.ID)
+			require.NoError(t, err)
+			assert.Equal(t, labels.Tags, retrieved.Tags)
+			assert.Equal(t, labels.Category, retrieved.Category)
+
+			workflows, err := s.storage.ListUserWorkflows("test_user")
+			require.NoError(t, err)
+			require.Len(t, workflows, 1)
+			assert.Equal(t, labels.Tags, workflows[0].Tags)
+
+			// Labels live outside the CUE, so the workflow definition is unchanged
+			assert.Equal(t, testWorkflowCUE, retrieved.Content)
+
+			err = s.storage.SaveWorkflowLabels("test_user", "test_user_missing", labels)
+			assert.Error(t, err)
+		})
+	}
+}
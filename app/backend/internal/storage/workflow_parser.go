@@ -3,10 +3,12 @@ package storage
 import (
 	"encoding/json"
 	"fmt"
+	"regexp"
 	"strings"
 
 	"cuelang.org/go/cue/cuecontext"
 	"sohoaas-backend/internal/types"
+	"sohoaas-backend/internal/workflowparser"
 )
 
 // parseCUEWorkflow parses CUE content into structured data
@@ -39,6 +41,14 @@ func parseCUEWorkflow(cueContent string, workflow *types.WorkflowFile) (*types.W
 		workflow.ParsedData = parsedData
 	}
 
+	// Surface why the workflow exists in metadata and list responses
+	if originalIntent, ok := workflow.ParsedData["original_intent"].(string); ok {
+		workflow.OriginalIntent = originalIntent
+	}
+
+	// Flag workflows generated under a schema that has since changed
+	workflow.SchemaHash, _, workflow.SchemaOutdated = workflowparser.SchemaOutdated(cueContent)
+
 	return workflow, nil
 }
 
@@ -69,8 +79,13 @@ func sanitizeCUEForParsing(src string) string {
         out = append(out, ln)
     }
     sanitized := strings.Join(out, "\n")
-    // Also neutralize deterministic schema type conjunction to avoid unresolved refs:
-    //   workflow: #DeterministicWorkflow & { ... }  ->  workflow: { ... }
-    sanitized = strings.ReplaceAll(sanitized, "#DeterministicWorkflow &", "")
+    // Also neutralize deterministic schema type conjunction to avoid unresolved refs, whether
+    // the schema is embedded or imported:
+    //   workflow: #DeterministicWorkflow & { ... }          ->  workflow: { ... }
+    //   workflow: schemas.#DeterministicWorkflow & { ... }  ->  workflow: { ... }
+    sanitized = schemaConjunction.ReplaceAllString(sanitized, "")
     return sanitized
 }
+
+// schemaConjunction matches the #DeterministicWorkflow conjunction, optionally through an import
+var schemaConjunction = regexp.MustCompile(`(?:[A-Za-z_][A-Za-z0-9_]*\.)?#DeterministicWorkflow\s*&`)
@@ -0,0 +1,101 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// IntentAnalysisOutput is the output of the Intent Analyst agent
+type IntentAnalysisOutput struct {
+	IsAutomationRequest bool     `json:"is_automation_request"`
+	RequiredServices    []string `json:"required_services"`
+	CanFulfill          bool     `json:"can_fulfill"`
+	MissingInfo         []string `json:"missing_info"`
+	NextAction          string   `json:"next_action"`
+	MissingConnections  []string `json:"missing_connections,omitempty"`   // required services to connect before generation
+	ConnectionsInMemory bool     `json:"connections_in_memory,omitempty"` // with connect_services: connections are lost on restart, so a service connected before one must be reconnected
+	DisallowedServices  []string `json:"disallowed_services,omitempty"`   // required services outside the deployment's allowlist
+}
+
+const (
+	// NextActionConnectServices is the next_action of an intent whose required services aren't all connected
+	NextActionConnectServices = "connect_services"
+	// NextActionServicesNotAllowed is the next_action of an intent requiring services this deployment doesn't allow
+	NextActionServicesNotAllowed = "services_not_allowed"
+)
+
+// IntentAnalysisResponse is the Intent Analyst's response; same JSON shape as AgentResponse
+type IntentAnalysisResponse struct {
+	AgentID  string                 `json:"agent_id"`
+	Output   *IntentAnalysisOutput  `json:"output,omitempty"`
+	Error    string                 `json:"error,omitempty"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// ToMap returns the analysis as the validated_intent map accepted by workflow generation
+func (o *IntentAnalysisOutput) ToMap() map[string]interface{} {
+	if o == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"is_automation_request": o.IsAutomationRequest,
+		"required_services":     o.RequiredServices,
+		"can_fulfill":           o.CanFulfill,
+		"missing_info":          o.MissingInfo,
+		"next_action":           o.NextAction,
+		"missing_connections":   o.MissingConnections,
+		"disallowed_services":   o.DisallowedServices,
+	}
+}
+
+// WorkflowFileRef identifies where a generated workflow was saved
+type WorkflowFileRef struct {
+	ID       string    `json:"id"`
+	Filename string    `json:"filename"`
+	Path     string    `json:"path"`
+	SavedAt  time.Time `json:"saved_at"`
+}
+
+// WorkflowGenerationOutput is the output of the Workflow Generator agent
+type WorkflowGenerationOutput struct {
+	Version        string                   `json:"version"`
+	Name           string                   `json:"name"`
+	Description    string                   `json:"description"`
+	OriginalIntent string                   `json:"original_intent"`
+	Steps          []WorkflowStep           `json:"steps"`
+	UserParameters map[string]UserParameter `json:"user_parameters"`
+	Services       map[string]interface{}   `json:"services"`
+	DecisionLog    interface{}              `json:"decision_log,omitempty"`
+
+	WorkflowJSON     map[string]interface{} `json:"workflow_json,omitempty"` // structured workflow the CUE was converted from
+	WorkflowCUE      string                 `json:"workflow_cue,omitempty"`
+	OriginalCUE      string                 `json:"original_cue,omitempty"`
+	WorkflowFile     *WorkflowFileRef       `json:"workflow_file,omitempty"` // set once the workflow is saved
+	Status           string                 `json:"status,omitempty"`        // "invalid" when validation rejected the workflow
+	ValidationErrors []string               `json:"validation_errors,omitempty"`
+	ErrorDetails     string                 `json:"error_details,omitempty"`
+}
+
+// WorkflowGenerationResponse is the Workflow Generator's response; same JSON shape as AgentResponse
+type WorkflowGenerationResponse struct {
+	AgentID  string                    `json:"agent_id"`
+	Output   *WorkflowGenerationOutput `json:"output,omitempty"`
+	Error    string                    `json:"error,omitempty"`
+	Metadata map[string]interface{}    `json:"metadata,omitempty"`
+}
+
+// DecodeAgentOutput converts an untyped agent output map into a typed output struct
+func DecodeAgentOutput(output map[string]interface{}, target interface{}) error {
+	if output == nil {
+		return nil
+	}
+	data, err := json.Marshal(output)
+	if err != nil {
+		return fmt.Errorf("failed to marshal agent output: %w", err)
+	}
+	if err := json.Unmarshal(data, target); err != nil {
+		return fmt.Errorf("failed to decode agent output: %w", err)
+	}
+	return nil
+}
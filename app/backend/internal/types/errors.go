@@ -0,0 +1,58 @@
+package types
+
+import "net/http"
+
+// Error codes returned in ErrorResponse.Code
+const (
+	ErrorCodeInvalidRequest   = "invalid_request"
+	ErrorCodeValidation       = "validation_failed"
+	ErrorCodeUnauthorized     = "unauthorized"
+	ErrorCodeForbidden        = "forbidden"
+	ErrorCodeReconnectService = "reconnect_service" // the user's Google connection is missing, expired or lacks scopes
+	ErrorCodeNotFound         = "not_found"
+	ErrorCodePayloadTooLarge  = "payload_too_large"
+	ErrorCodeRateLimited      = "rate_limited"
+	ErrorCodeUpstream         = "upstream_error"
+	ErrorCodeTimeout          = "timeout"
+	ErrorCodeCancelled        = "cancelled"
+	ErrorCodeConflict         = "conflict"
+	ErrorCodeInternal         = "internal_error"
+)
+
+// ErrorResponse is the standard error body, returned by all handlers as {"error": ErrorResponse}
+type ErrorResponse struct {
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Details string      `json:"details,omitempty"`
+	Fields  interface{} `json:"fields,omitempty"` // per-field or per-step validation errors
+}
+
+// HTTPStatusForErrorCode returns the HTTP status used for an error code
+func HTTPStatusForErrorCode(code string) int {
+	switch code {
+	case ErrorCodeInvalidRequest:
+		return http.StatusBadRequest
+	case ErrorCodeValidation:
+		return http.StatusUnprocessableEntity
+	case ErrorCodeUnauthorized:
+		return http.StatusUnauthorized
+	case ErrorCodeForbidden:
+		return http.StatusForbidden
+	case ErrorCodeReconnectService:
+		return http.StatusFailedDependency
+	case ErrorCodeNotFound:
+		return http.StatusNotFound
+	case ErrorCodePayloadTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case ErrorCodeRateLimited:
+		return http.StatusTooManyRequests
+	case ErrorCodeUpstream:
+		return http.StatusBadGateway
+	case ErrorCodeTimeout:
+		return http.StatusGatewayTimeout
+	case ErrorCodeCancelled, ErrorCodeConflict:
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
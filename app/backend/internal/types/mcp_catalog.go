@@ -0,0 +1,156 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// WorkspaceProvider is the name of the Google Workspace provider in the catalog
+const WorkspaceProvider = "workspace"
+
+// UnmarshalJSON decodes every provider under "providers", keeping workspace in its own field
+func (p *MCPProviders) UnmarshalJSON(data []byte) error {
+	var providers map[string]MCPProvider
+	if err := json.Unmarshal(data, &providers); err != nil {
+		return err
+	}
+
+	p.Workspace = providers[WorkspaceProvider]
+	p.Others = nil
+	for name, provider := range providers {
+		if name == WorkspaceProvider {
+			continue
+		}
+		if p.Others == nil {
+			p.Others = make(map[string]MCPProvider)
+		}
+		p.Others[name] = provider
+	}
+	return nil
+}
+
+// MarshalJSON encodes all providers back into a single "providers" object
+func (p MCPProviders) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.All())
+}
+
+// All returns every provider in the catalog keyed by name
+func (p MCPProviders) All() map[string]MCPProvider {
+	providers := make(map[string]MCPProvider, len(p.Others)+1)
+	for name, provider := range p.Others {
+		providers[name] = provider
+	}
+	providers[WorkspaceProvider] = p.Workspace
+	return providers
+}
+
+// Names returns the provider names with workspace first and the rest sorted
+func (p MCPProviders) Names() []string {
+	names := make([]string, 0, len(p.Others))
+	for name := range p.Others {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return append([]string{WorkspaceProvider}, names...)
+}
+
+// AllServices returns the services of every provider keyed by service name. When two
+// providers define the same service name, the first provider in Names order wins.
+func (c *MCPServiceCatalog) AllServices() map[string]MCPServiceDefinition {
+	services := make(map[string]MCPServiceDefinition)
+	providers := c.Providers.All()
+	for _, name := range c.Providers.Names() {
+		for serviceName, service := range providers[name].Services {
+			if _, exists := services[serviceName]; !exists {
+				services[serviceName] = service
+			}
+		}
+	}
+	return services
+}
+
+// LookupService finds a service in any provider, returning the provider it belongs to
+func (c *MCPServiceCatalog) LookupService(serviceName string) (string, MCPServiceDefinition, bool) {
+	providers := c.Providers.All()
+	for _, name := range c.Providers.Names() {
+		if service, exists := providers[name].Services[serviceName]; exists {
+			return name, service, true
+		}
+	}
+	return "", MCPServiceDefinition{}, false
+}
+
+// ResolveService finds the provider that defines a step's service. A known provider
+// restricts the lookup to that provider; otherwise every provider is searched and a
+// service name defined by more than one provider is rejected as ambiguous.
+func (c *MCPServiceCatalog) ResolveService(provider, serviceName string) (string, MCPServiceDefinition, error) {
+	providers := c.Providers.All()
+
+	if provider != "" {
+		providerDefinition, exists := providers[provider]
+		if !exists {
+			return "", MCPServiceDefinition{}, fmt.Errorf("provider '%s' not found in MCP catalog", provider)
+		}
+		service, exists := providerDefinition.Services[serviceName]
+		if !exists {
+			if reason, unavailable := providerDefinition.UnavailableServices[serviceName]; unavailable {
+				return "", MCPServiceDefinition{}, fmt.Errorf("service '%s' of provider '%s' is unavailable: %s", serviceName, provider, reason)
+			}
+			return "", MCPServiceDefinition{}, fmt.Errorf("service '%s' not found in provider '%s'", serviceName, provider)
+		}
+		return provider, service, nil
+	}
+
+	var matches []string
+	for _, name := range c.Providers.Names() {
+		if _, exists := providers[name].Services[serviceName]; exists {
+			matches = append(matches, name)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		for _, name := range c.Providers.Names() {
+			if reason, unavailable := providers[name].UnavailableServices[serviceName]; unavailable {
+				return "", MCPServiceDefinition{}, fmt.Errorf("service '%s' of provider '%s' is unavailable: %s", serviceName, name, reason)
+			}
+		}
+		return "", MCPServiceDefinition{}, fmt.Errorf("service '%s' not found in MCP catalog", serviceName)
+	case 1:
+		return matches[0], providers[matches[0]].Services[serviceName], nil
+	default:
+		return "", MCPServiceDefinition{}, fmt.Errorf("service '%s' is ambiguous: defined by providers %s; set the provider on the step or its service binding", serviceName, strings.Join(matches, ", "))
+	}
+}
+
+// FunctionsWithoutOutputSchema lists the functions that declare no output schema, as
+// "provider/service.function" in provider, service and function order. References to
+// their outputs can't be validated before execution.
+func (c *MCPServiceCatalog) FunctionsWithoutOutputSchema() []string {
+	var missing []string
+	providers := c.Providers.All()
+	for _, providerName := range c.Providers.Names() {
+		services := providers[providerName].Services
+		serviceNames := make([]string, 0, len(services))
+		for serviceName := range services {
+			serviceNames = append(serviceNames, serviceName)
+		}
+		sort.Strings(serviceNames)
+
+		for _, serviceName := range serviceNames {
+			functions := services[serviceName].Functions
+			functionNames := make([]string, 0, len(functions))
+			for functionName, function := range functions {
+				if function.OutputSchema == nil {
+					functionNames = append(functionNames, functionName)
+				}
+			}
+			sort.Strings(functionNames)
+			for _, functionName := range functionNames {
+				missing = append(missing, providerName+"/"+serviceName+"."+functionName)
+			}
+		}
+	}
+	return missing
+}
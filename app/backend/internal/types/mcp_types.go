@@ -1,21 +1,34 @@
 package types
 
+// CatalogSchemaVersion is the catalog schema version ("major.minor") this backend parses.
+// A minor bump only adds fields; a major bump changes the shape.
+const CatalogSchemaVersion = "1.2"
+
 // MCPServiceCatalog represents the complete MCP service catalog structure
-// Matches actual MCP server response: providers.workspace[serviceName]
+// Matches actual MCP server response: providers.<provider>.services[serviceName]
 type MCPServiceCatalog struct {
-	Providers MCPProviders `json:"providers"`
+	SchemaVersion string       `json:"schema_version,omitempty"` // empty for catalogs predating versioning
+	Providers     MCPProviders `json:"providers"`
 }
 
-// MCPProviders represents the providers section of MCP catalog
+// MCPProviders represents the providers section of MCP catalog. The workspace provider
+// keeps its own field; any other provider (e.g. slack, http) is kept in Others.
 type MCPProviders struct {
-	Workspace MCPWorkspaceProvider `json:"workspace"`
+	Workspace MCPWorkspaceProvider   `json:"workspace"`
+	Others    map[string]MCPProvider `json:"-"`
 }
 
+// MCPProvider is a provider section of the catalog
+type MCPProvider = MCPWorkspaceProvider
+
 // MCPWorkspaceProvider represents the workspace provider structure
 type MCPWorkspaceProvider struct {
 	Description string                              `json:"description"`
 	DisplayName string                              `json:"display_name"`
 	Services    map[string]MCPServiceDefinition     `json:"services"`
+
+	// Services the MCP backend skipped at startup because their self-check failed, with the reason
+	UnavailableServices map[string]string `json:"unavailable_services,omitempty"`
 }
 
 // MCPServiceDefinition represents a complete service definition in MCP catalog
@@ -35,6 +48,8 @@ type MCPFunctionSchema struct {
 	Description    string                 `json:"description"`
 	ExamplePayload map[string]interface{} `json:"example_payload"`
 	RequiredFields []string               `json:"required_fields"`
+	// Input fields with constrained values such as enums (catalog 1.1+)
+	InputSchema    *MCPParameterSchema    `json:"input_schema,omitempty"`
 	// Response schema information for workflow generation
 	OutputSchema   *MCPResponseSchema     `json:"output_schema,omitempty"`
 	ErrorSchema    *MCPResponseSchema     `json:"error_schema,omitempty"`
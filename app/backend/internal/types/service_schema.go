@@ -34,3 +34,4 @@ type ServiceCatalog struct {
 
 // NOTE: Static service catalogs removed - use live MCP catalog via MCPService.GetServiceCatalog()
 // All service schemas should be retrieved dynamically from the MCP service
+// (MCP_STATIC_CATALOG_PATH provides a file-based fallback for local development only)
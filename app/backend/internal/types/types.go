@@ -25,9 +25,12 @@ type Event struct {
 type User struct {
 	ID           string                 `json:"id"`
 	Email        string                 `json:"email"`
+	EmailVerified bool                 `json:"email_verified,omitempty"` // the identity provider confirmed the user owns Email
 	Name         string                 `json:"name"`
 	OAuthTokens  map[string]interface{} `json:"oauth_tokens,omitempty"`
 	ConnectedServices []string          `json:"connected_services"`
+	ServiceAccount bool                 `json:"service_account,omitempty"` // authenticated by API key rather than Firebase
+	TenantID     string                 `json:"tenant_id,omitempty"`       // Identity Platform tenant; selects prompt overrides
 }
 
 // WorkflowIntent represents a structured workflow intent
@@ -65,6 +68,7 @@ type UserParameter struct {
 	Prompt      string      `json:"prompt"`
 	Default     interface{} `json:"default"`
 	Validation  string      `json:"validation,omitempty"`
+	Sensitive   bool        `json:"sensitive,omitempty"` // secret value such as an API key
 }
 
 // MCPService represents an MCP service capability
@@ -111,6 +115,11 @@ type WorkflowFile struct {
 	ID          string                 `json:"id"`
 	Name        string                 `json:"name"`
 	Description string                 `json:"description"`
+	OriginalIntent string              `json:"original_intent,omitempty"` // the user's request the workflow was generated from
+	SchemaHash     string              `json:"schema_hash,omitempty"`     // schema the workflow was generated under
+	SchemaOutdated bool                `json:"schema_outdated,omitempty"` // generated under a schema other than the current one
+	Tags        []string               `json:"tags,omitempty"`     // user-assigned labels, stored outside the CUE
+	Category    string                 `json:"category,omitempty"` // user-assigned category, stored outside the CUE
 	Status      string                 `json:"status"` // 'draft' | 'active' | 'completed' | 'error'
 	Filename    string                 `json:"filename"`
 	Path        string                 `json:"path"`
@@ -121,6 +130,13 @@ type WorkflowFile struct {
 	ParsedData  map[string]interface{} `json:"parsed_data,omitempty"` // Parsed CUE workflow structure
 }
 
+// WorkflowLabels are the tags and category a user organizes a workflow with. They are stored in
+// the workflow's metadata, not its CUE, so they never affect execution.
+type WorkflowLabels struct {
+	Tags     []string `json:"tags"`
+	Category string   `json:"category,omitempty"`
+}
+
 // WorkflowExecution represents the execution state of a workflow
 type WorkflowExecution struct {
 	ID          string                 `json:"id"`
@@ -140,6 +156,17 @@ type WorkflowStep struct {
 	Service     string                 `json:"service"`
 	Action      string                 `json:"action"`
 	Parameters  map[string]interface{} `json:"parameters"`
+
+	// Authoring fields carried over to the CUE workflow
+	Name                 string   `json:"name,omitempty"`
+	Description          string   `json:"description,omitempty"`
+	DependsOn            []string `json:"depends_on,omitempty"`
+	RequiresConfirmation bool     `json:"requires_confirmation,omitempty"` // hold the step for user approval
+	Connection           string   `json:"connection,omitempty"`            // named workspace connection
+	Provider             string   `json:"provider,omitempty"`              // catalog provider, e.g. a staging proxy
+	Cacheable            bool     `json:"cacheable,omitempty"`             // reuse outputs of identical reads within the cache TTL
+	Timeout              string   `json:"timeout,omitempty"`
+
 	Status      string                 `json:"status"`
 	Output      map[string]interface{} `json:"output,omitempty"`
 	Error       string                 `json:"error,omitempty"`
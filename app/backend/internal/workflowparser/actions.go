@@ -0,0 +1,150 @@
+package workflowparser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// serviceAliases maps service names LLMs commonly produce to catalog service names
+var serviceAliases = map[string]string{
+	"email":           "gmail",
+	"mail":            "gmail",
+	"google_mail":     "gmail",
+	"gcal":            "calendar",
+	"google_calendar": "calendar",
+	"doc":             "docs",
+	"documents":       "docs",
+	"google_docs":     "docs",
+	"google_drive":    "drive",
+}
+
+// actionAliases maps action names LLMs commonly produce to catalog "service.function" names.
+// An alias only applies when the step's service, if any, matches the alias target.
+var actionAliases = map[string]string{
+	// Gmail
+	"send_email":    "gmail.send_message",
+	"send_mail":     "gmail.send_message",
+	"get_messages":  "gmail.list_messages",
+	"get_emails":    "gmail.list_messages",
+	"list_emails":   "gmail.list_messages",
+	"get_email":     "gmail.get_message",
+	"read_email":    "gmail.get_message",
+	"read_message":  "gmail.get_message",
+	"search_emails": "gmail.search_messages",
+	"find_emails":   "gmail.search_messages",
+	"label_email":   "gmail.modify_labels",
+	"add_label":     "gmail.modify_labels",
+	"archive_email": "gmail.archive_message",
+	"archive":       "gmail.archive_message",
+
+	// Calendar
+	"add_event":             "calendar.create_event",
+	"create_calendar_event": "calendar.create_event",
+	"schedule_event":        "calendar.create_event",
+	"schedule_meeting":      "calendar.create_event",
+	"get_events":            "calendar.list_events",
+	"get_calendar_event":    "calendar.get_event",
+	"get_rsvps":             "calendar.get_event",
+	"check_rsvps":           "calendar.get_event",
+	"list_calendar_events":  "calendar.list_events",
+	"update_calendar_event": "calendar.update_event",
+	"delete_calendar_event": "calendar.delete_event",
+
+	// Docs
+	"create_doc":    "docs.create_document",
+	"get_doc":       "docs.get_document",
+	"read_document": "docs.get_document",
+	"update_doc":    "docs.update_document",
+	"append_text":   "docs.insert_text",
+
+	// Drive
+	"upload":            "drive.upload_file",
+	"get_files":         "drive.list_files",
+	"share_document":    "drive.share_file",
+	"create_directory":  "drive.create_folder",
+	"get_permissions":   "drive.list_permissions",
+	"list_sharing":      "drive.list_permissions",
+	"unshare_file":      "drive.revoke_permission",
+	"revoke_access":     "drive.revoke_permission",
+	"remove_permission": "drive.revoke_permission",
+}
+
+// readActionPrefixes lists action name prefixes that never modify user data
+var readActionPrefixes = []string{"get_", "list_", "search_", "read_", "find_", "track_"}
+
+// CanonicalServiceName resolves a service alias ("email") to the catalog service name ("gmail")
+func CanonicalServiceName(service string) string {
+	service = strings.TrimSpace(service)
+	if canonical, exists := serviceAliases[service]; exists {
+		return canonical
+	}
+	return service
+}
+
+// CanonicalizeStepAction splits dotted actions ("gmail.send_message") into service and action.
+// A dotted action whose prefix disagrees with an explicit service is rejected so the
+// engine never calls a different service than the one declared.
+func CanonicalizeStepAction(service, action string) (string, string, error) {
+	dot := strings.Index(action, ".")
+	if dot <= 0 || dot == len(action)-1 {
+		return service, action, nil
+	}
+
+	actionService, actionName := action[:dot], action[dot+1:]
+	if service != "" && service != actionService {
+		return "", "", fmt.Errorf("service %q conflicts with action %q", service, action)
+	}
+	return actionService, actionName, nil
+}
+
+// CanonicalizeAction resolves service aliases in a step's service and dotted action, then splits
+// the action into service and function
+func CanonicalizeAction(service, action string) (string, string, error) {
+	action = strings.TrimSpace(action)
+	if dot := strings.Index(action, "."); dot > 0 {
+		action = CanonicalServiceName(action[:dot]) + action[dot:]
+	}
+	return CanonicalizeStepAction(CanonicalServiceName(service), action)
+}
+
+// ApplyActionAlias maps an aliased function ("send_email") to its service and catalog function,
+// unless the alias belongs to a different service than the one given
+func ApplyActionAlias(service, function string) (string, string) {
+	if alias, exists := actionAliases[function]; exists {
+		aliasService, aliasFunction, _ := strings.Cut(alias, ".")
+		if service == "" || service == aliasService {
+			return aliasService, aliasFunction
+		}
+	}
+	return service, function
+}
+
+// NormalizeAction canonicalizes a step's service and action with the alias tables only; the
+// service is empty when it cannot be determined. Use MCPCatalogParser.NormalizeAction in the
+// services package to also resolve names against a catalog.
+func NormalizeAction(service, action string) (string, string, error) {
+	service, function, err := CanonicalizeAction(service, action)
+	if err != nil {
+		return "", "", err
+	}
+	service, function = ApplyActionAlias(service, function)
+	return service, function, nil
+}
+
+// IsReadAction reports whether an action only reads user data
+func IsReadAction(action string) bool {
+	for _, prefix := range readActionPrefixes {
+		if strings.HasPrefix(action, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateCacheableStep rejects the cacheable flag on actions that may modify user data
+func validateCacheableStep(step *WorkflowStep) error {
+	if step.Cacheable && !IsReadAction(step.Action) {
+		return fmt.Errorf("step %s: cacheable is only allowed on read actions, %s.%s writes", step.ID, step.Service, step.Action)
+	}
+	return nil
+}
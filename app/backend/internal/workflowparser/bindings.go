@@ -0,0 +1,109 @@
+package workflowparser
+
+import (
+	"fmt"
+	"regexp"
+
+	"cuelang.org/go/cue"
+)
+
+// AuthTypeAPIKey is the service binding auth type that authenticates with a named secret
+const AuthTypeAPIKey = "api_key"
+
+// connectionNamePattern restricts connection names to short identifiers like "work" or "personal"
+var connectionNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// validSecretRef limits secret names to characters that are safe in environment variable names
+var validSecretRef = regexp.MustCompile(`^[A-Za-z0-9_]{1,64}$`)
+
+// ValidateConnectionName checks a named workspace connection; empty means the default connection
+func ValidateConnectionName(connection string) error {
+	if connection == "" {
+		return nil
+	}
+	if !connectionNamePattern.MatchString(connection) {
+		return fmt.Errorf("invalid connection name %q: use up to 32 lowercase letters, digits, '-' or '_'", connection)
+	}
+	return nil
+}
+
+// ValidateSecretRef checks that a secret name uses only letters, digits and underscores
+func ValidateSecretRef(name string) error {
+	if !validSecretRef.MatchString(name) {
+		return fmt.Errorf("invalid secret_ref %q: use 1-64 letters, digits or underscores", name)
+	}
+	return nil
+}
+
+// parseBindingSecretRef returns the secret a service's binding authenticates with
+// (auth: {type: "api_key", secret_ref: "..."}), or "" when it uses OAuth
+func parseBindingSecretRef(workflowValue cue.Value, service string) (string, error) {
+	authPath := []cue.Selector{cue.Str("service_bindings"), cue.Str(service), cue.Str("auth")}
+	secretRefValue := workflowValue.LookupPath(cue.MakePath(append(authPath, cue.Str("secret_ref"))...))
+	if !secretRefValue.Exists() {
+		return "", nil
+	}
+	secretRef, err := secretRefValue.String()
+	if err != nil {
+		return "", fmt.Errorf("failed to extract secret_ref of service binding %s: %w", service, err)
+	}
+	if err := ValidateSecretRef(secretRef); err != nil {
+		return "", fmt.Errorf("service binding %s: %w", service, err)
+	}
+
+	// The generator writes auth.method as the RaC schema names it; auth.type is still accepted
+	// for workflows generated before
+	authType := ""
+	for _, field := range []string{"method", "type"} {
+		if typeValue := workflowValue.LookupPath(cue.MakePath(append(authPath, cue.Str(field))...)); typeValue.Exists() {
+			if authType, err = typeValue.String(); err != nil {
+				return "", fmt.Errorf("failed to extract auth %s of service binding %s: %w", field, service, err)
+			}
+			break
+		}
+	}
+	if authType != AuthTypeAPIKey {
+		return "", fmt.Errorf("service binding %s: secret_ref requires auth type %q, got %q", service, AuthTypeAPIKey, authType)
+	}
+	return secretRef, nil
+}
+
+// parseBindingOAuthScopes returns the OAuth scopes each service binding requests, keyed by
+// service. The schema's auth.oauth2.scopes is read, as well as the auth.scopes and
+// auth.oauth_scopes of older workflows.
+func parseBindingOAuthScopes(workflowValue cue.Value) (map[string][]string, error) {
+	bindingsValue := workflowValue.LookupPath(cue.ParsePath("service_bindings"))
+	if !bindingsValue.Exists() {
+		return nil, nil
+	}
+	bindingsIter, err := bindingsValue.Fields()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service_bindings: %w", err)
+	}
+
+	scopes := make(map[string][]string)
+	for bindingsIter.Next() {
+		service := bindingsIter.Selector().Unquoted()
+		for _, field := range []string{"oauth2.scopes", "scopes", "oauth_scopes"} {
+			scopesValue := bindingsIter.Value().LookupPath(cue.ParsePath("auth." + field))
+			if !scopesValue.Exists() {
+				continue
+			}
+			scopesIter, err := scopesValue.List()
+			if err != nil {
+				return nil, fmt.Errorf("auth %s of service binding %s must be a list: %w", field, service, err)
+			}
+			for scopesIter.Next() {
+				scope, err := scopesIter.Value().String()
+				if err != nil {
+					return nil, fmt.Errorf("failed to extract auth %s of service binding %s: %w", field, service, err)
+				}
+				scopes[service] = append(scopes[service], scope)
+			}
+		}
+	}
+	if len(scopes) == 0 {
+		return nil, nil
+	}
+	return scopes, nil
+}
@@ -0,0 +1,53 @@
+package workflowparser
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Execution environments a workflow can declare in execution_config.environment
+const (
+	EnvironmentDevelopment = "development"
+	EnvironmentStaging     = "staging"
+	EnvironmentProduction  = "production"
+)
+
+// environmentAliases maps the short names workflows commonly declare to the canonical environments
+var environmentAliases = map[string]string{
+	"dev":     EnvironmentDevelopment,
+	"local":   EnvironmentDevelopment,
+	"test":    EnvironmentStaging,
+	"testing": EnvironmentStaging,
+	"stage":   EnvironmentStaging,
+	"prod":    EnvironmentProduction,
+}
+
+// NormalizeEnvironment defaults an empty environment to development, maps aliases such as dev
+// and prod to their canonical names and rejects unknown ones
+func NormalizeEnvironment(environment string) (string, error) {
+	normalized := strings.ToLower(strings.TrimSpace(environment))
+	if canonical, ok := environmentAliases[normalized]; ok {
+		return canonical, nil
+	}
+	switch normalized {
+	case "":
+		return EnvironmentDevelopment, nil
+	case EnvironmentDevelopment, EnvironmentStaging, EnvironmentProduction:
+		return normalized, nil
+	default:
+		return "", fmt.Errorf("unknown execution environment %q (expected development, staging or production)", environment)
+	}
+}
+
+// ParseTimeout parses an execution_config.timeout duration such as "30s" or "5m"
+func ParseTimeout(value string) (time.Duration, error) {
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid execution timeout %q: %w", value, err)
+	}
+	if timeout <= 0 {
+		return 0, fmt.Errorf("invalid execution timeout %q: must be positive", value)
+	}
+	return timeout, nil
+}
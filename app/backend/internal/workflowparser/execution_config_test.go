@@ -0,0 +1,27 @@
+package workflowparser
+
+import "testing"
+
+// TestNormalizeEnvironment verifies aliases map to the canonical environments and unknown ones are rejected
+func TestNormalizeEnvironment(t *testing.T) {
+	tests := map[string]string{
+		"":            EnvironmentDevelopment,
+		"development": EnvironmentDevelopment,
+		"dev":         EnvironmentDevelopment,
+		" Local ":     EnvironmentDevelopment,
+		"staging":     EnvironmentStaging,
+		"test":        EnvironmentStaging,
+		"PROD":        EnvironmentProduction,
+		"production":  EnvironmentProduction,
+	}
+	for environment, expected := range tests {
+		normalized, err := NormalizeEnvironment(environment)
+		if err != nil || normalized != expected {
+			t.Errorf("NormalizeEnvironment(%q) = %q (%v), expected %q", environment, normalized, err, expected)
+		}
+	}
+
+	if _, err := NormalizeEnvironment("qa"); err == nil {
+		t.Error("Expected an error for an unknown environment")
+	}
+}
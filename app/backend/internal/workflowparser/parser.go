@@ -0,0 +1,479 @@
+// Package workflowparser parses CUE workflows into ParsedWorkflow. It has no MCP or engine
+// dependency, so validators, CLIs and tests can parse workflows on their own.
+package workflowparser
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+)
+
+// WorkflowStep represents a step in the workflow (simplified CUE parsing)
+type WorkflowStep struct {
+	ID                   string                 `json:"id"`
+	Name                 string                 `json:"name"`
+	Service              string                 `json:"service"`
+	Action               string                 `json:"action"`
+	Inputs               map[string]interface{} `json:"inputs"`
+	Outputs              map[string]interface{} `json:"outputs"`
+	DependsOn            []string               `json:"depends_on,omitempty"`
+	RequiresConfirmation bool                   `json:"requires_confirmation,omitempty"`
+	Connection           string                 `json:"connection,omitempty"` // from the step or its service binding
+	Provider             string                 `json:"provider,omitempty"`   // catalog provider from the step or its service binding
+	SecretRef            string                 `json:"secret_ref,omitempty"` // secret from the service binding's api_key auth
+	Cacheable            bool                   `json:"cacheable,omitempty"`  // read step whose outputs may be reused
+}
+
+// ParsedWorkflow represents a parsed CUE workflow
+type ParsedWorkflow struct {
+	Name                    string                 `json:"name"`
+	Description             string                 `json:"description"`
+	OriginalIntent          string                 `json:"original_intent,omitempty"` // the user's request the workflow was generated from
+	Steps                   []WorkflowStep         `json:"steps"`
+	UserParameterDefaults   map[string]interface{} `json:"user_parameter_defaults,omitempty"`   // declared user_parameters defaults
+	UserParameterValidation map[string]string      `json:"user_parameter_validation,omitempty"` // declared validation rules (email, url, regex)
+	UserParameters          []WorkflowInput        `json:"user_parameters,omitempty"`           // declared user_parameters, in declaration order
+	SensitiveParameters     []string               `json:"sensitive_parameters,omitempty"`      // declared user_parameters marked sensitive
+	Environment             string                 `json:"environment"`                         // execution_config.environment, default development
+	Timeout                 time.Duration          `json:"timeout,omitempty"`                   // execution_config.timeout; 0 when not declared
+	Exclusive               bool                   `json:"exclusive,omitempty"`                 // execution_config.exclusive
+	Trigger                 *WorkflowTrigger       `json:"trigger,omitempty"`                   // nil when the workflow declares no trigger
+	OAuthScopes             map[string][]string    `json:"oauth_scopes,omitempty"`              // scopes requested by each service binding
+	SchemaHash              string                 `json:"schema_hash,omitempty"`               // schema the workflow was generated under
+	SchemaOutdated          bool                   `json:"schema_outdated,omitempty"`           // generated under a schema other than the current one
+	Warnings                []string               `json:"warnings,omitempty"`                  // non-blocking findings, e.g. an ignored execution_config value
+}
+
+// WorkflowInput describes a declared user parameter so clients can build an input form
+type WorkflowInput struct {
+	Name        string      `json:"name"`
+	Type        string      `json:"type"`
+	Required    bool        `json:"required"`
+	Prompt      string      `json:"prompt,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Validation  string      `json:"validation,omitempty"`
+	Placeholder string      `json:"placeholder,omitempty"`
+	Default     interface{} `json:"default,omitempty"`
+	Sensitive   bool        `json:"sensitive,omitempty"` // secret value: masked in logs and output, never prefilled
+}
+
+// parseWorkflowInput reads one entry of a workflow's user_parameters block. Missing fields
+// fall back to the generator's defaults: type "string" and required.
+func parseWorkflowInput(name string, paramValue cue.Value) WorkflowInput {
+	input := WorkflowInput{
+		Name:     name,
+		Type:     "string",
+		Required: true,
+	}
+
+	stringField := func(field string) string {
+		value := paramValue.LookupPath(cue.ParsePath(field))
+		if !value.Exists() {
+			return ""
+		}
+		str, _ := value.String()
+		return str
+	}
+
+	if paramType := stringField("type"); paramType != "" {
+		input.Type = paramType
+	}
+	if requiredValue := paramValue.LookupPath(cue.ParsePath("required")); requiredValue.Exists() {
+		if required, err := requiredValue.Bool(); err == nil {
+			input.Required = required
+		}
+	}
+	input.Prompt = stringField("prompt")
+	input.Description = stringField("description")
+	input.Validation = stringField("validation")
+	input.Placeholder = stringField("placeholder")
+	if sensitiveValue := paramValue.LookupPath(cue.ParsePath("sensitive")); sensitiveValue.Exists() {
+		input.Sensitive, _ = sensitiveValue.Bool()
+	}
+
+	if defaultValue := paramValue.LookupPath(cue.ParsePath("default")); defaultValue.Exists() && defaultValue.IsConcrete() {
+		if goVal, err := ValueToInterface(defaultValue); err == nil {
+			input.Default = goVal
+		}
+	}
+	return input
+}
+
+// Parse parses a CUE workflow, in the generated form (schema embedded or imported) or hand
+// authored, into a ParsedWorkflow. Actions are canonicalized with the alias tables only.
+func Parse(cueContent string) (*ParsedWorkflow, error) {
+	// Sanitize CUE content to remove illegal characters
+	sanitizedContent := SanitizeCUEContent(cueContent)
+
+	// The current schema replaces the one the workflow was generated under
+	schemaHash, currentSchemaHash, schemaOutdated := SchemaOutdated(cueContent)
+	if schemaOutdated {
+		log.Printf("[WorkflowParser] Workflow generated under schema %s is parsed with current schema %s", schemaHash, currentSchemaHash)
+	}
+
+	// Ensure deterministic workflow schema is available by inlining it
+	combinedContent := InlineDeterministicSchema(sanitizedContent)
+
+	// Create CUE context
+	ctx := cuecontext.New()
+
+	// Parse the CUE content with schema inlined
+	value := ctx.CompileString(combinedContent)
+	if err := value.Err(); err != nil {
+		if schemaOutdated {
+			return nil, fmt.Errorf("%w: generated under schema %s, current schema is %s: %w", ErrSchemaOutdated, schemaHash, currentSchemaHash, err)
+		}
+		return nil, fmt.Errorf("failed to compile CUE content: %w", err)
+	}
+
+	// Extract the workflow from the CUE value
+	workflowValue := value.LookupPath(cue.ParsePath("workflow"))
+	if !workflowValue.Exists() {
+		return nil, fmt.Errorf("workflow field not found in CUE content")
+	}
+
+	// Parse workflow name
+	nameValue := workflowValue.LookupPath(cue.ParsePath("name"))
+	name, err := nameValue.String()
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract workflow name: %w", err)
+	}
+
+	// Parse workflow description
+	descValue := workflowValue.LookupPath(cue.ParsePath("description"))
+	description, err := descValue.String()
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract workflow description: %w", err)
+	}
+
+	// Parse the optional natural-language request the workflow was generated from
+	var originalIntent string
+	if intentValue := workflowValue.LookupPath(cue.ParsePath("original_intent")); intentValue.Exists() {
+		if originalIntent, err = intentValue.String(); err != nil {
+			return nil, fmt.Errorf("failed to extract workflow original_intent: %w", err)
+		}
+	}
+
+	// Parse workflow steps
+	stepsValue := workflowValue.LookupPath(cue.ParsePath("steps"))
+	if !stepsValue.Exists() {
+		return nil, fmt.Errorf("steps field not found in workflow")
+	}
+
+	var steps []WorkflowStep
+	stepsIter, err := stepsValue.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to iterate over steps: %w", err)
+	}
+
+	for stepsIter.Next() {
+		stepValue := stepsIter.Value()
+
+		// Parse step fields
+		step := WorkflowStep{
+			Inputs:  make(map[string]interface{}),
+			Outputs: make(map[string]interface{}),
+		}
+
+		// Extract step ID
+		if idValue := stepValue.LookupPath(cue.ParsePath("id")); idValue.Exists() {
+			if id, err := idValue.String(); err != nil {
+				return nil, fmt.Errorf("failed to extract id from step %d: %w", len(steps), err)
+			} else {
+				step.ID = id
+			}
+		}
+
+		// Extract step name
+		if nameValue := stepValue.LookupPath(cue.ParsePath("name")); nameValue.Exists() {
+			if name, err := nameValue.String(); err != nil {
+				return nil, fmt.Errorf("failed to extract name from step %d: %w", len(steps), err)
+			} else {
+				step.Name = name
+			}
+		}
+
+		// Extract service field first (if exists)
+		if serviceValue := stepValue.LookupPath(cue.ParsePath("service")); serviceValue.Exists() {
+			if service, err := serviceValue.String(); err != nil {
+				return nil, fmt.Errorf("failed to extract service from step %d: %w", len(steps), err)
+			} else {
+				step.Service = service
+			}
+		}
+
+		// Extract action field
+		if actionValue := stepValue.LookupPath(cue.ParsePath("action")); actionValue.Exists() {
+			if action, err := actionValue.String(); err != nil {
+				return nil, fmt.Errorf("failed to extract action from step %d: %w", len(steps), err)
+			} else {
+				// Canonicalize "service.action" notation and known aliases against the explicit service field
+				service, canonicalAction, err := NormalizeAction(step.Service, action)
+				if err != nil {
+					return nil, fmt.Errorf("step %d (%s): %w", len(steps), step.ID, err)
+				}
+				step.Service = service
+				step.Action = canonicalAction
+			}
+		}
+
+		// Extract parameters/inputs (try both "parameters" and "inputs" fields)
+		var inputsMap map[string]interface{}
+		if parametersValue := stepValue.LookupPath(cue.ParsePath("parameters")); parametersValue.Exists() {
+			inputsMap = make(map[string]interface{})
+			parametersIter, _ := parametersValue.Fields()
+			for parametersIter.Next() {
+				key := parametersIter.Label()
+				val := parametersIter.Value()
+
+				// Convert CUE value to Go interface{}
+				if goVal, err := ValueToInterface(val); err == nil {
+					inputsMap[key] = goVal
+				}
+			}
+		} else if inputsValue := stepValue.LookupPath(cue.ParsePath("inputs")); inputsValue.Exists() {
+			inputsMap = make(map[string]interface{})
+			inputsIter, _ := inputsValue.Fields()
+			for inputsIter.Next() {
+				key := inputsIter.Label()
+				val := inputsIter.Value()
+
+				// Convert CUE value to Go interface{}
+				if goVal, err := ValueToInterface(val); err == nil {
+					inputsMap[key] = goVal
+				}
+			}
+		}
+		if inputsMap != nil {
+			step.Inputs = inputsMap // Store in Inputs field for execution engine compatibility
+		}
+
+		// Extract outputs (usually empty in workflow definition)
+		if outputsValue := stepValue.LookupPath(cue.ParsePath("outputs")); outputsValue.Exists() {
+			outputsMap := make(map[string]interface{})
+			outputsIter, _ := outputsValue.Fields()
+			for outputsIter.Next() {
+				key := outputsIter.Label()
+				val := outputsIter.Value()
+
+				if goVal, err := ValueToInterface(val); err == nil {
+					outputsMap[key] = goVal
+				}
+			}
+			step.Outputs = outputsMap
+		}
+
+		// Extract dependencies
+		if depsValue := stepValue.LookupPath(cue.ParsePath("depends_on")); depsValue.Exists() {
+			var deps []string
+			depsIter, _ := depsValue.List()
+			for depsIter.Next() {
+				if depStr, err := depsIter.Value().String(); err == nil {
+					deps = append(deps, depStr)
+				}
+			}
+			step.DependsOn = deps
+		}
+
+		// Extract human-in-the-loop confirmation flag
+		if confirmValue := stepValue.LookupPath(cue.ParsePath("requires_confirmation")); confirmValue.Exists() {
+			if requiresConfirmation, err := confirmValue.Bool(); err == nil {
+				step.RequiresConfirmation = requiresConfirmation
+			}
+		}
+
+		// Extract output caching flag; only read actions may be cached
+		if cacheableValue := stepValue.LookupPath(cue.ParsePath("cacheable")); cacheableValue.Exists() {
+			if cacheable, err := cacheableValue.Bool(); err == nil {
+				step.Cacheable = cacheable
+			}
+			if err := validateCacheableStep(&step); err != nil {
+				return nil, err
+			}
+		}
+
+		// Extract connection binding: the step's own connection wins over its service binding
+		connection := ""
+		if connectionValue := workflowValue.LookupPath(cue.MakePath(cue.Str("service_bindings"), cue.Str(step.Service), cue.Str("connection"))); connectionValue.Exists() {
+			if connection, err = connectionValue.String(); err != nil {
+				return nil, fmt.Errorf("failed to extract connection of service binding %s: %w", step.Service, err)
+			}
+		}
+		if connectionValue := stepValue.LookupPath(cue.ParsePath("connection")); connectionValue.Exists() {
+			if connection, err = connectionValue.String(); err != nil {
+				return nil, fmt.Errorf("failed to extract connection from step %d: %w", len(steps), err)
+			}
+		}
+		if err := ValidateConnectionName(connection); err != nil {
+			return nil, fmt.Errorf("step %d (%s): %w", len(steps), step.ID, err)
+		}
+		step.Connection = connection
+
+		// Extract the catalog provider (e.g. "workspace"): the step's own provider wins over its service binding
+		if providerValue := workflowValue.LookupPath(cue.MakePath(cue.Str("service_bindings"), cue.Str(step.Service), cue.Str("provider"))); providerValue.Exists() {
+			if step.Provider, err = providerValue.String(); err != nil {
+				return nil, fmt.Errorf("failed to extract provider of service binding %s: %w", step.Service, err)
+			}
+		}
+		if providerValue := stepValue.LookupPath(cue.ParsePath("provider")); providerValue.Exists() {
+			if step.Provider, err = providerValue.String(); err != nil {
+				return nil, fmt.Errorf("failed to extract provider from step %d: %w", len(steps), err)
+			}
+		}
+
+		// Extract the named secret of an api_key service binding; its value is only fetched at execution
+		if step.SecretRef, err = parseBindingSecretRef(workflowValue, step.Service); err != nil {
+			return nil, err
+		}
+
+		steps = append(steps, step)
+	}
+
+	// Extract declared user parameters with their defaults and validation rules
+	parameterDefaults := make(map[string]interface{})
+	parameterValidation := make(map[string]string)
+	var userParameters []WorkflowInput
+	var sensitiveParameters []string
+	if paramsValue := workflowValue.LookupPath(cue.ParsePath("user_parameters")); paramsValue.Exists() {
+		paramsIter, _ := paramsValue.Fields()
+		for paramsIter.Next() {
+			input := parseWorkflowInput(paramsIter.Label(), paramsIter.Value())
+			userParameters = append(userParameters, input)
+			if input.Default != nil {
+				parameterDefaults[input.Name] = input.Default
+			}
+			if input.Validation != "" {
+				parameterValidation[input.Name] = input.Validation
+			}
+			if input.Sensitive {
+				sensitiveParameters = append(sensitiveParameters, input.Name)
+			}
+		}
+	}
+
+	// Extract the execution environment, which selects the engine's environment policy
+	environment := ""
+	if environmentValue := workflowValue.LookupPath(cue.ParsePath("execution_config.environment")); environmentValue.Exists() {
+		if environment, err = environmentValue.String(); err != nil {
+			return nil, fmt.Errorf("failed to extract execution environment: %w", err)
+		}
+	}
+	if environment, err = NormalizeEnvironment(environment); err != nil {
+		return nil, err
+	}
+
+	// Extract the workflow-level runtime budget; the engine default applies when absent or unusable
+	var timeout time.Duration
+	var warnings []string
+	if timeoutValue := workflowValue.LookupPath(cue.ParsePath("execution_config.timeout")); timeoutValue.Exists() {
+		timeoutString, err := timeoutValue.String()
+		if err == nil {
+			timeout, err = ParseTimeout(timeoutString)
+		}
+		if err != nil {
+			log.Printf("[WorkflowParser] Ignoring execution_config.timeout: %v", err)
+			warnings = append(warnings, fmt.Sprintf("execution_config.timeout ignored, the default timeout applies: %v", err))
+		}
+	}
+
+	// Extract whether runs of this workflow must not overlap
+	exclusive := false
+	if exclusiveValue := workflowValue.LookupPath(cue.ParsePath("execution_config.exclusive")); exclusiveValue.Exists() {
+		if exclusive, err = exclusiveValue.Bool(); err != nil {
+			return nil, fmt.Errorf("failed to extract execution_config.exclusive: %w", err)
+		}
+	}
+
+	// Extract the trigger, validating and normalizing a schedule to cron
+	trigger, err := parseTrigger(workflowValue)
+	if err != nil {
+		return nil, err
+	}
+
+	// Extract the OAuth scopes the service bindings request, checked against the deployment's allowed scopes
+	oauthScopes, err := parseBindingOAuthScopes(workflowValue)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ParsedWorkflow{
+		Name:                    name,
+		Description:             description,
+		OriginalIntent:          originalIntent,
+		Steps:                   steps,
+		UserParameterDefaults:   parameterDefaults,
+		UserParameterValidation: parameterValidation,
+		UserParameters:          userParameters,
+		SensitiveParameters:     sensitiveParameters,
+		Environment:             environment,
+		Timeout:                 timeout,
+		Exclusive:               exclusive,
+		Trigger:                 trigger,
+		OAuthScopes:             oauthScopes,
+		SchemaHash:              schemaHash,
+		SchemaOutdated:          schemaOutdated,
+		Warnings:                warnings,
+	}, nil
+}
+
+// ValueToInterface converts a CUE value to a Go interface{}
+func ValueToInterface(val cue.Value) (interface{}, error) {
+	switch val.Kind() {
+	case cue.StringKind:
+		return val.String()
+	case cue.IntKind:
+		return val.Int64()
+	case cue.FloatKind:
+		return val.Float64()
+	case cue.BoolKind:
+		return val.Bool()
+	case cue.BytesKind:
+		return val.Bytes()
+	case cue.NullKind:
+		return nil, nil
+	case cue.ListKind:
+		list := []interface{}{}
+		iter, _ := val.List()
+		for iter.Next() {
+			if item, err := ValueToInterface(iter.Value()); err == nil {
+				list = append(list, item)
+			}
+		}
+		return list, nil
+	case cue.StructKind:
+		obj := make(map[string]interface{})
+		iter, _ := val.Fields()
+		for iter.Next() {
+			key := iter.Label()
+			if item, err := ValueToInterface(iter.Value()); err == nil {
+				obj[key] = item
+			}
+		}
+		return obj, nil
+	default:
+		// For other types, try to get as string
+		if str, err := val.String(); err == nil {
+			return str, nil
+		}
+		return nil, fmt.Errorf("unsupported CUE value kind: %v", val.Kind())
+	}
+}
+
+// SanitizeCUEContent removes illegal characters and formatting from CUE content
+func SanitizeCUEContent(cueContent string) string {
+	// Remove backticks that cause CUE parsing errors
+	sanitized := strings.ReplaceAll(cueContent, "`", "'")
+
+	// Remove any markdown code block markers that might have been generated
+	sanitized = strings.ReplaceAll(sanitized, "```cue", "")
+	sanitized = strings.ReplaceAll(sanitized, "```", "")
+
+	// Remove any other problematic characters that could cause CUE parsing issues
+	sanitized = strings.ReplaceAll(sanitized, "\u0060", "'") // Unicode backtick
+
+	return sanitized
+}
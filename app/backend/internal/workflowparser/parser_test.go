@@ -0,0 +1,207 @@
+package workflowparser
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestParse verifies a workflow parses without an execution engine or MCP catalog
+func TestParse(t *testing.T) {
+	workflow, err := Parse(`workflow: {
+	name:        "weekly_report"
+	description: "Email the weekly report"
+	steps: [{
+		id:     "find"
+		name:   "Find report"
+		action: "drive.list_files"
+		inputs: {query: "name contains 'report'", limit: 5}
+		cacheable: true
+	}, {
+		id:         "send"
+		name:       "Send report"
+		action:     "send_email"
+		inputs:     {to: "team@example.com", files: ["${steps.find.outputs.files}"]}
+		depends_on: ["find"]
+	}]
+	execution_config: {mode: "sequential", environment: "staging"}
+}`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if workflow.Name != "weekly_report" || len(workflow.Steps) != 2 {
+		t.Fatalf("Expected the weekly_report workflow with two steps, got %+v", workflow)
+	}
+	find, send := workflow.Steps[0], workflow.Steps[1]
+	if find.Service != "drive" || find.Action != "list_files" || !find.Cacheable {
+		t.Errorf("Expected a cacheable drive.list_files step, got %+v", find)
+	}
+	if find.Inputs["limit"] != int64(5) {
+		t.Errorf("Expected limit 5, got %#v", find.Inputs["limit"])
+	}
+	if send.Service != "gmail" || send.Action != "send_message" {
+		t.Errorf("Expected send_email to normalize to gmail.send_message, got %s.%s", send.Service, send.Action)
+	}
+	if len(send.DependsOn) != 1 || send.DependsOn[0] != "find" {
+		t.Errorf("Expected send to depend on find, got %v", send.DependsOn)
+	}
+	if workflow.Environment != EnvironmentStaging {
+		t.Errorf("Expected the staging environment, got %q", workflow.Environment)
+	}
+}
+
+// TestParseRejectsInvalidWorkflows verifies parse errors are reported rather than half-parsed
+func TestParseRejectsInvalidWorkflows(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		contains string
+	}{
+		{"no workflow", `name: "x"`, "workflow"},
+		{"cacheable write", `workflow: {
+	name:        "x"
+	description: "x"
+	steps: [{id: "send", action: "gmail.send_message", inputs: {}, cacheable: true}]
+}`, "cacheable"},
+		{"unknown environment", `workflow: {
+	name:        "x"
+	description: "x"
+	steps: [{id: "send", action: "gmail.send_message", inputs: {}}]
+	execution_config: {environment: "qa"}
+}`, "environment"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.content)
+			if err == nil {
+				t.Fatal("Expected an error")
+			}
+			if !strings.Contains(err.Error(), tt.contains) {
+				t.Errorf("Expected error mentioning %q, got %v", tt.contains, err)
+			}
+		})
+	}
+}
+
+// TestParseTrigger verifies schedules are normalized to cron and invalid schedules rejected
+func TestParseTrigger(t *testing.T) {
+	workflowWithTrigger := func(trigger string) string {
+		return `workflow: {
+	name:        "standup"
+	description: "Post the standup reminder"
+	steps: [{id: "send", action: "gmail.send_message", inputs: {}}]
+	` + trigger + `
+}`
+	}
+
+	workflow, err := Parse(workflowWithTrigger(`trigger: {type: "schedule", schedule: "every weekday at 8 AM"}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if workflow.Trigger == nil || workflow.Trigger.Cron != "0 8 * * 1-5" || workflow.Trigger.Schedule != "every weekday at 8 AM" {
+		t.Errorf("Expected the schedule normalized to 0 8 * * 1-5, got %+v", workflow.Trigger)
+	}
+
+	workflow, err = Parse(workflowWithTrigger(""))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if workflow.Trigger != nil {
+		t.Errorf("Expected no trigger when absent, got %+v", workflow.Trigger)
+	}
+
+	for _, invalid := range []string{
+		`trigger: {type: "schedule", schedule: "whenever"}`,
+		`trigger: {type: "schedule", schedule: "61 * * * *"}`,
+		`trigger: {type: "schedule"}`,
+		`trigger: {type: "schedule", schedule: "daily", cron: "0 9 * * *"}`,
+		`trigger: {type: "manual", schedule: "daily"}`,
+	} {
+		if _, err := Parse(workflowWithTrigger(invalid)); err == nil {
+			t.Errorf("Expected %s to be rejected", invalid)
+		}
+	}
+}
+
+// TestParseStepProvider verifies a step's provider overrides the provider of its service binding
+func TestParseStepProvider(t *testing.T) {
+	workflow, err := Parse(`workflow: {
+	name:        "notify"
+	description: "Email through production and the staging proxy"
+	steps: [{
+		id:     "production"
+		action: "gmail.send_message"
+		inputs: {}
+	}, {
+		id:       "staging"
+		action:   "gmail.send_message"
+		provider: "workspace_staging"
+		inputs:   {}
+	}]
+	service_bindings: gmail: {provider: "workspace"}
+}`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if provider := workflow.Steps[0].Provider; provider != "workspace" {
+		t.Errorf("Expected the service binding's provider, got %q", provider)
+	}
+	if provider := workflow.Steps[1].Provider; provider != "workspace_staging" {
+		t.Errorf("Expected the step's provider, got %q", provider)
+	}
+}
+
+// TestParseBindingOAuthScopes verifies the scopes of each service binding are read under either field name
+func TestParseBindingOAuthScopes(t *testing.T) {
+	workflow, err := Parse(`workflow: {
+	name:        "digest"
+	description: "Read mail and file a summary"
+	steps: [{
+		id:     "read"
+		action: "gmail.list_messages"
+		inputs: {}
+	}]
+	service_bindings: {
+		gmail: {auth: {type: "oauth2", scopes: ["https://www.googleapis.com/auth/gmail.readonly"]}}
+		docs: {auth: {method: "oauth2", oauth_scopes: ["https://www.googleapis.com/auth/documents"]}}
+	}
+}`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if scopes := workflow.OAuthScopes["gmail"]; len(scopes) != 1 || scopes[0] != "https://www.googleapis.com/auth/gmail.readonly" {
+		t.Errorf("Expected the gmail binding's scopes, got %v", scopes)
+	}
+	if scopes := workflow.OAuthScopes["docs"]; len(scopes) != 1 || scopes[0] != "https://www.googleapis.com/auth/documents" {
+		t.Errorf("Expected the docs binding's oauth_scopes, got %v", scopes)
+	}
+}
+
+// TestParseSensitiveParameters verifies user parameters flagged sensitive are listed
+func TestParseSensitiveParameters(t *testing.T) {
+	workflow, err := Parse(`workflow: {
+	name:        "post_update"
+	description: "Post an update with an API key"
+	user_parameters: {
+		channel: {type: "string", required: true}
+		api_key: {type: "string", required: true, sensitive: true}
+	}
+	steps: [{
+		id:     "post"
+		action: "gmail.send_message"
+		inputs: {to: "${user.channel}", body: "key ${user.api_key}"}
+	}]
+}`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(workflow.SensitiveParameters) != 1 || workflow.SensitiveParameters[0] != "api_key" {
+		t.Errorf("Expected api_key to be sensitive, got %v", workflow.SensitiveParameters)
+	}
+	for _, input := range workflow.UserParameters {
+		if input.Sensitive != (input.Name == "api_key") {
+			t.Errorf("Unexpected sensitive flag on %s: %v", input.Name, input.Sensitive)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package workflowparser
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ErrInvalidSchedule is returned for trigger schedules that are neither cron nor a known phrase
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+// cronMacros maps the supported cron shorthands to their 5-field form
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// cronField describes one field of a 5-field cron expression
+type cronField struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var cronFields = []cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}},
+	{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}},
+}
+
+// weekdayNumbers maps day names used in schedule phrases to cron day-of-week numbers
+var weekdayNumbers = map[string]int{
+	"sunday": 0, "sun": 0,
+	"monday": 1, "mon": 1,
+	"tuesday": 2, "tue": 2, "tues": 2,
+	"wednesday": 3, "wed": 3,
+	"thursday": 4, "thu": 4, "thurs": 4,
+	"friday": 5, "fri": 5,
+	"saturday": 6, "sat": 6,
+}
+
+var (
+	scheduleInterval = regexp.MustCompile(`^every (\d+) (minute|hour)s?$`)
+	scheduleTime     = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))? ?(am|pm)?$`)
+	scheduleMonthDay = regexp.MustCompile(`^(?:on )?(?:the )?(\d{1,2})(?:st|nd|rd|th)?$`)
+)
+
+// NormalizeSchedule turns a trigger schedule into a 5-field cron expression the scheduler can
+// register directly. It accepts cron expressions (with month and weekday names, and @daily
+// style macros) and common phrases such as "every weekday at 8 AM", "every monday and
+// friday at 17:30", "every 15 minutes" or "monthly on the 1st at 9am".
+func NormalizeSchedule(schedule string) (string, error) {
+	schedule = strings.TrimSpace(schedule)
+	if schedule == "" {
+		return "", fmt.Errorf("%w: schedule is empty", ErrInvalidSchedule)
+	}
+
+	if macro, exists := cronMacros[strings.ToLower(schedule)]; exists {
+		return macro, nil
+	}
+	if fields := strings.Fields(schedule); looksLikeCron(fields) {
+		return normalizeCron(fields, schedule)
+	}
+
+	if cron, ok := parseSchedulePhrase(schedule); ok {
+		return cron, nil
+	}
+	return "", fmt.Errorf("%w %q: expected a 5-field cron expression or a phrase like \"every weekday at 8 AM\"", ErrInvalidSchedule, schedule)
+}
+
+// looksLikeCron reports whether fields are a cron expression rather than a phrase: five fields
+// whose minute, hour and day of month hold no letters (only month and weekday may be names)
+func looksLikeCron(fields []string) bool {
+	if len(fields) != len(cronFields) {
+		return false
+	}
+	for _, field := range fields[:3] {
+		if strings.IndexFunc(field, unicode.IsLetter) >= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeCron validates each cron field and rewrites month and weekday names to numbers
+func normalizeCron(fields []string, schedule string) (string, error) {
+	normalized := make([]string, len(fields))
+	for i, field := range fields {
+		value, err := normalizeCronField(strings.ToLower(field), cronFields[i])
+		if err != nil {
+			return "", fmt.Errorf("%w %q: %s field %q: %v", ErrInvalidSchedule, schedule, cronFields[i].name, field, err)
+		}
+		normalized[i] = value
+	}
+	return strings.Join(normalized, " "), nil
+}
+
+// normalizeCronField validates a comma-separated list of "*", values and ranges with optional steps
+func normalizeCronField(field string, spec cronField) (string, error) {
+	items := strings.Split(field, ",")
+	for i, item := range items {
+		rangePart, step, hasStep := strings.Cut(item, "/")
+		if hasStep {
+			stepValue, err := strconv.Atoi(step)
+			if err != nil || stepValue <= 0 || stepValue > spec.max {
+				return "", fmt.Errorf("invalid step %q", step)
+			}
+		}
+
+		if rangePart == "*" {
+			continue
+		}
+		low, high, isRange := strings.Cut(rangePart, "-")
+		lowValue, err := cronFieldValue(low, spec)
+		if err != nil {
+			return "", err
+		}
+		if !isRange {
+			items[i] = strconv.Itoa(lowValue) + strings.TrimPrefix(item, rangePart)
+			continue
+		}
+		highValue, err := cronFieldValue(high, spec)
+		if err != nil {
+			return "", err
+		}
+		if lowValue > highValue {
+			return "", fmt.Errorf("range %q is reversed", rangePart)
+		}
+		items[i] = strconv.Itoa(lowValue) + "-" + strconv.Itoa(highValue) + strings.TrimPrefix(item, rangePart)
+	}
+	return strings.Join(items, ","), nil
+}
+
+// cronFieldValue parses a number or name and checks it is within the field's range
+func cronFieldValue(value string, spec cronField) (int, error) {
+	if number, exists := spec.names[value]; exists {
+		return number, nil
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a number", value)
+	}
+	if number < spec.min || number > spec.max {
+		return 0, fmt.Errorf("%d is outside %d-%d", number, spec.min, spec.max)
+	}
+	return number, nil
+}
+
+// parseSchedulePhrase converts a natural-language schedule to cron. Runs without a time of day
+// start at midnight, like the @daily macro.
+func parseSchedulePhrase(schedule string) (string, bool) {
+	phrase := strings.Join(strings.Fields(strings.ToLower(strings.TrimSuffix(schedule, "."))), " ")
+
+	switch phrase {
+	case "every minute":
+		return "* * * * *", true
+	case "every hour", "hourly":
+		return "0 * * * *", true
+	}
+	if match := scheduleInterval.FindStringSubmatch(phrase); match != nil {
+		interval, _ := strconv.Atoi(match[1])
+		if match[2] == "minute" && interval >= 1 && interval <= 59 {
+			return fmt.Sprintf("*/%d * * * *", interval), true
+		}
+		if match[2] == "hour" && interval >= 1 && interval <= 23 {
+			return fmt.Sprintf("0 */%d * * *", interval), true
+		}
+		return "", false
+	}
+
+	minute, hour := 0, 0
+	if period, timeOfDay, found := strings.Cut(phrase, " at "); found {
+		var ok bool
+		if hour, minute, ok = parseTimeOfDay(timeOfDay); !ok {
+			return "", false
+		}
+		phrase = period
+	}
+
+	dayOfMonth, dayOfWeek := "*", "*"
+	switch {
+	case phrase == "daily" || phrase == "every day":
+	case phrase == "every weekday" || phrase == "weekdays" || phrase == "on weekdays":
+		dayOfWeek = "1-5"
+	case phrase == "every weekend" || phrase == "weekends" || phrase == "on weekends":
+		dayOfWeek = "0,6"
+	case phrase == "weekly" || phrase == "every week":
+		dayOfWeek = "0"
+	case strings.HasPrefix(phrase, "monthly") || strings.HasPrefix(phrase, "every month"):
+		dayOfMonth = "1"
+		rest := strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(phrase, "monthly"), "every month"))
+		if rest != "" {
+			match := scheduleMonthDay.FindStringSubmatch(rest)
+			if match == nil {
+				return "", false
+			}
+			day, _ := strconv.Atoi(match[1])
+			if day < 1 || day > 31 {
+				return "", false
+			}
+			dayOfMonth = strconv.Itoa(day)
+		}
+	default:
+		days := strings.TrimPrefix(strings.TrimPrefix(strings.TrimPrefix(phrase, "weekly on "), "every week on "), "every ")
+		days = strings.TrimPrefix(days, "on ")
+		var ok bool
+		if dayOfWeek, ok = parseWeekdays(days); !ok {
+			return "", false
+		}
+	}
+	return fmt.Sprintf("%d %d %s * %s", minute, hour, dayOfMonth, dayOfWeek), true
+}
+
+// parseTimeOfDay parses "8 am", "8:30pm", "17:00", "noon" or "midnight" into hour and minute
+func parseTimeOfDay(value string) (int, int, bool) {
+	value = strings.ReplaceAll(strings.TrimSpace(value), ".", "")
+	switch value {
+	case "noon":
+		return 12, 0, true
+	case "midnight":
+		return 0, 0, true
+	}
+
+	match := scheduleTime.FindStringSubmatch(value)
+	if match == nil {
+		return 0, 0, false
+	}
+	hour, _ := strconv.Atoi(match[1])
+	minute := 0
+	if match[2] != "" {
+		minute, _ = strconv.Atoi(match[2])
+	}
+	switch match[3] {
+	case "am", "pm":
+		if hour < 1 || hour > 12 {
+			return 0, 0, false
+		}
+		hour %= 12
+		if match[3] == "pm" {
+			hour += 12
+		}
+	}
+	if hour > 23 || minute > 59 {
+		return 0, 0, false
+	}
+	return hour, minute, true
+}
+
+// parseWeekdays parses "monday", "mondays", "monday and friday" or "mon, wed, fri" into a
+// sorted cron day-of-week list
+func parseWeekdays(value string) (string, bool) {
+	value = strings.NewReplacer(",", " ", " and ", " ").Replace(value)
+	seen := make(map[int]bool)
+	for _, name := range strings.Fields(value) {
+		day, exists := weekdayNumbers[name]
+		if !exists {
+			day, exists = weekdayNumbers[strings.TrimSuffix(name, "s")]
+		}
+		if !exists {
+			return "", false
+		}
+		seen[day] = true
+	}
+	if len(seen) == 0 {
+		return "", false
+	}
+
+	days := make([]int, 0, len(seen))
+	for day := range seen {
+		days = append(days, day)
+	}
+	sort.Ints(days)
+	parts := make([]string, len(days))
+	for i, day := range days {
+		parts[i] = strconv.Itoa(day)
+	}
+	return strings.Join(parts, ","), true
+}
@@ -0,0 +1,96 @@
+package workflowparser
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestNormalizeScheduleCron verifies valid cron expressions are kept, with names and macros rewritten
+func TestNormalizeScheduleCron(t *testing.T) {
+	tests := map[string]string{
+		"0 8 * * 1-5":        "0 8 * * 1-5",
+		"  */15   *  * * * ": "*/15 * * * *",
+		"30 9 1,15 * *":      "30 9 1,15 * *",
+		"0 9 * * MON-FRI":    "0 9 * * 1-5",
+		"0 0 1 jan,jul *":    "0 0 1 1,7 *",
+		"0 12 * * sun":       "0 12 * * 0",
+		"0 0-23/2 * * *":     "0 0-23/2 * * *",
+		"@daily":             "0 0 * * *",
+		"@Weekly":            "0 0 * * 0",
+		"0 18 * * 7":         "0 18 * * 7",
+		"05 08 * * *":        "5 8 * * *",
+	}
+	for schedule, expected := range tests {
+		cron, err := NormalizeSchedule(schedule)
+		if err != nil {
+			t.Errorf("NormalizeSchedule(%q): unexpected error: %v", schedule, err)
+			continue
+		}
+		if cron != expected {
+			t.Errorf("NormalizeSchedule(%q) = %q, expected %q", schedule, cron, expected)
+		}
+	}
+}
+
+// TestNormalizeScheduleInvalid verifies malformed cron and unknown phrases are rejected
+func TestNormalizeScheduleInvalid(t *testing.T) {
+	for _, schedule := range []string{
+		"",
+		"60 * * * *",
+		"0 24 * * *",
+		"0 8 0 * *",
+		"0 8 * 13 *",
+		"0 8 * * 8",
+		"0 8 * * fun",
+		"0 17-9 * * *",
+		"*/0 * * * *",
+		"0 8 * *",
+		"0 8 * * * *",
+		"@sometimes",
+		"every now and then",
+		"every weekday at 25:00",
+		"every monday at 13 pm",
+		"every 90 minutes",
+		"monthly on the 32nd",
+	} {
+		if cron, err := NormalizeSchedule(schedule); err == nil {
+			t.Errorf("NormalizeSchedule(%q) = %q, expected an error", schedule, cron)
+		} else if !errors.Is(err, ErrInvalidSchedule) {
+			t.Errorf("NormalizeSchedule(%q): expected ErrInvalidSchedule, got %v", schedule, err)
+		}
+	}
+}
+
+// TestNormalizeSchedulePhrases verifies common natural-language schedules map to cron
+func TestNormalizeSchedulePhrases(t *testing.T) {
+	tests := map[string]string{
+		"every weekday at 8 AM":              "0 8 * * 1-5",
+		"Every weekday at 8:30am.":           "30 8 * * 1-5",
+		"every day at 17:00":                 "0 17 * * *",
+		"daily at 9 p.m.":                    "0 21 * * *",
+		"daily":                              "0 0 * * *",
+		"every monday at 9am":                "0 9 * * 1",
+		"every Monday and Friday at 5:30 PM": "30 17 * * 1,5",
+		"every mon, wed and fri at noon":     "0 12 * * 1,3,5",
+		"mondays at 10am":                    "0 10 * * 1",
+		"weekly on friday at 4pm":            "0 16 * * 5",
+		"every weekend at 10 am":             "0 10 * * 0,6",
+		"every month on the 1st at 9am":      "0 9 1 * *",
+		"monthly on the 15th":                "0 0 15 * *",
+		"every hour":                         "0 * * * *",
+		"every 15 minutes":                   "*/15 * * * *",
+		"every 2 hours":                      "0 */2 * * *",
+		"every day at midnight":              "0 0 * * *",
+		"every sunday at 12 am":              "0 0 * * 0",
+	}
+	for schedule, expected := range tests {
+		cron, err := NormalizeSchedule(schedule)
+		if err != nil {
+			t.Errorf("NormalizeSchedule(%q): unexpected error: %v", schedule, err)
+			continue
+		}
+		if cron != expected {
+			t.Errorf("NormalizeSchedule(%q) = %q, expected %q", schedule, cron, expected)
+		}
+	}
+}
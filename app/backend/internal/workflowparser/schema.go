@@ -0,0 +1,215 @@
+package workflowparser
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Markers around the schema embedded in generated CUE, so parsing can swap in the current schema
+const (
+	EmbeddedSchemaStart = "// === EMBEDDED RAC SCHEMAS ==="
+	EmbeddedSchemaEnd   = "// === END EMBEDDED SCHEMAS ==="
+)
+
+// ErrSchemaOutdated is returned when a workflow generated under an older schema doesn't
+// compile with the current one; regenerating the workflow fixes it
+var ErrSchemaOutdated = errors.New("workflow schema outdated")
+
+// SchemaHashComment starts the comment recording the hash of the schema a workflow was generated under
+const SchemaHashComment = "// schema-hash: "
+
+var (
+	cueIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	// An import spec, either a whole declaration (import alias "path") or a line of an import block
+	cueImportSpec = regexp.MustCompile(`^(?:import\s+)?(?:([A-Za-z_][A-Za-z0-9_]*)\s+)?"([^"]+)"\s*$`)
+)
+
+// InlineDeterministicSchema prepends the deterministic workflow schema to the provided CUE
+// content so references like #DeterministicWorkflow resolve. Workflows may embed the schema
+// (replaced by the current one) or import it.
+func InlineDeterministicSchema(cueContent string) string {
+	// Imports can't be resolved here; their schema references resolve against the inlined schema
+	cueContent = removeCUEImports(cueContent)
+
+	schemaContent := LoadDeterministicSchema()
+	if schemaContent == "" {
+		log.Printf("[WorkflowParser] Warning: deterministic_workflow.cue not found; proceeding without inlined schema")
+		return cueContent
+	}
+
+	// Remove package declarations and any embedded copy of the schema to avoid conflicts
+	cleanedSchema := RemovePackageClause(schemaContent)
+	cleanedContent := RemovePackageClause(removeEmbeddedSchema(cueContent))
+
+	// Concatenate schema then content
+	return cleanedSchema + "\n\n" + cleanedContent
+}
+
+// LoadDeterministicSchema reads rac/schemas/deterministic_workflow.cue. RAC_CONTEXT_PATH may
+// point at the rac directory or the repository root; otherwise the working directory, the
+// executable's directory and their parents are searched, so the process may run from anywhere.
+// It returns "" when the schema is not found.
+func LoadDeterministicSchema() string {
+	schemaPath := filepath.Join("schemas", "deterministic_workflow.cue")
+
+	var roots []string
+	if racPath := os.Getenv("RAC_CONTEXT_PATH"); racPath != "" {
+		roots = append(roots, racPath, filepath.Join(racPath, "rac"))
+	}
+	var bases []string
+	if workingDir, err := os.Getwd(); err == nil {
+		bases = append(bases, workingDir)
+	}
+	if executable, err := os.Executable(); err == nil {
+		bases = append(bases, filepath.Dir(executable))
+	}
+	for _, dir := range bases {
+		for {
+			roots = append(roots, filepath.Join(dir, "rac"))
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				break
+			}
+			dir = parent
+		}
+	}
+
+	for _, root := range roots {
+		if content, err := os.ReadFile(filepath.Join(root, schemaPath)); err == nil && len(content) > 0 {
+			return string(content)
+		}
+	}
+	return ""
+}
+
+// RemovePackageClause drops package declarations so schema and workflow share one file
+func RemovePackageClause(cueContent string) string {
+	lines := strings.Split(cueContent, "\n")
+	filtered := lines[:0:0]
+	for _, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "package ") {
+			continue
+		}
+		filtered = append(filtered, line)
+	}
+	return strings.Join(filtered, "\n")
+}
+
+// removeCUEImports drops import declarations, which CompileString can't resolve, and rewrites
+// references through them (schemas.#DeterministicWorkflow) to the inlined definitions
+func removeCUEImports(cueContent string) string {
+	lines := strings.Split(cueContent, "\n")
+	filtered := lines[:0:0]
+	var aliases []string
+	inImportBlock := false
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case inImportBlock:
+			if trimmed == ")" {
+				inImportBlock = false
+			} else if match := cueImportSpec.FindStringSubmatch(trimmed); match != nil {
+				aliases = append(aliases, cueImportAlias(match[1], match[2]))
+			}
+			continue
+		case trimmed == "import (":
+			inImportBlock = true
+			continue
+		case strings.HasPrefix(trimmed, "import "):
+			if match := cueImportSpec.FindStringSubmatch(trimmed); match != nil {
+				aliases = append(aliases, cueImportAlias(match[1], match[2]))
+			}
+			continue
+		}
+		filtered = append(filtered, line)
+	}
+
+	result := strings.Join(filtered, "\n")
+	for _, alias := range aliases {
+		if alias == "" {
+			continue
+		}
+		qualified := regexp.MustCompile(`\b` + regexp.QuoteMeta(alias) + `\.#`)
+		result = qualified.ReplaceAllString(result, "#")
+	}
+	return result
+}
+
+// cueImportAlias returns the name an import is referenced by: its explicit alias, the package
+// qualifier of "path:pkg", or the last path element; "" when that is not an identifier
+func cueImportAlias(alias, importPath string) string {
+	if alias != "" {
+		return alias
+	}
+	if colon := strings.LastIndex(importPath, ":"); colon >= 0 {
+		alias = importPath[colon+1:]
+	} else {
+		alias = importPath[strings.LastIndex(importPath, "/")+1:]
+	}
+	if !cueIdentifier.MatchString(alias) {
+		return ""
+	}
+	return alias
+}
+
+// removeEmbeddedSchema drops a schema embedded between the generator's markers
+func removeEmbeddedSchema(cueContent string) string {
+	start := strings.Index(cueContent, EmbeddedSchemaStart)
+	if start < 0 {
+		return cueContent
+	}
+	end := strings.Index(cueContent[start:], EmbeddedSchemaEnd)
+	if end < 0 {
+		return cueContent
+	}
+	return cueContent[:start] + cueContent[start+end+len(EmbeddedSchemaEnd):]
+}
+
+// SchemaHash identifies a schema by its content, ignoring the package clause and surrounding whitespace
+func SchemaHash(schemaContent string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(RemovePackageClause(schemaContent))))
+	return hex.EncodeToString(sum[:8])
+}
+
+// CurrentSchemaHash returns the hash of the schema LoadDeterministicSchema finds, or "" when there is none
+func CurrentSchemaHash() string {
+	schemaContent := LoadDeterministicSchema()
+	if schemaContent == "" {
+		return ""
+	}
+	return SchemaHash(schemaContent)
+}
+
+// WorkflowSchemaHash returns the hash of the schema a workflow was generated under: the
+// recorded schema-hash comment, else the hash of its embedded schema, else ""
+func WorkflowSchemaHash(cueContent string) string {
+	for _, line := range strings.Split(cueContent, "\n") {
+		if hash, found := strings.CutPrefix(strings.TrimSpace(line), SchemaHashComment); found {
+			return strings.TrimSpace(hash)
+		}
+	}
+
+	start := strings.Index(cueContent, EmbeddedSchemaStart)
+	if start < 0 {
+		return ""
+	}
+	end := strings.Index(cueContent[start:], EmbeddedSchemaEnd)
+	if end < 0 {
+		return ""
+	}
+	return SchemaHash(cueContent[start+len(EmbeddedSchemaStart) : start+end])
+}
+
+// SchemaOutdated reports whether a workflow was generated under a schema other than the
+// current one. Workflows whose schema is unknown, or without a current schema, are not outdated.
+func SchemaOutdated(cueContent string) (workflowHash, currentHash string, outdated bool) {
+	workflowHash = WorkflowSchemaHash(cueContent)
+	currentHash = CurrentSchemaHash()
+	return workflowHash, currentHash, workflowHash != "" && currentHash != "" && workflowHash != currentHash
+}
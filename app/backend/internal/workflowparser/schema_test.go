@@ -0,0 +1,66 @@
+package workflowparser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestRemoveCUEImports verifies imports are dropped and references through them unqualified
+func TestRemoveCUEImports(t *testing.T) {
+	cueContent := `package workflow
+
+import (
+	"strings"
+	rac "example.com/rac/schemas"
+	"example.com/other:defs"
+)
+import "../../rac/schemas.cue"
+
+workflow: rac.#DeterministicWorkflow & {
+	step: defs.#WorkflowStep
+	name: strings.ToUpper("x")
+}`
+
+	result := removeCUEImports(cueContent)
+	if strings.Contains(result, "import") || strings.Contains(result, "example.com") {
+		t.Errorf("Expected imports to be removed:\n%s", result)
+	}
+	if !strings.Contains(result, "workflow: #DeterministicWorkflow & {") || !strings.Contains(result, "step: #WorkflowStep") {
+		t.Errorf("Expected schema references to be unqualified:\n%s", result)
+	}
+}
+
+// TestSchemaOutdated verifies workflows are compared with the current schema by their recorded
+// hash or, failing that, their embedded schema
+func TestSchemaOutdated(t *testing.T) {
+	racDir := t.TempDir()
+	schema := "package schemas\n\n#DeterministicWorkflow: {name: string}\n"
+	if err := os.MkdirAll(filepath.Join(racDir, "schemas"), 0o755); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(racDir, "schemas", "deterministic_workflow.cue"), []byte(schema), 0o644); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	t.Setenv("RAC_CONTEXT_PATH", racDir)
+
+	current := SchemaHash(schema)
+	if CurrentSchemaHash() != current {
+		t.Fatalf("Expected current schema hash %s, got %s", current, CurrentSchemaHash())
+	}
+
+	embedded := "package workflow\n\n" + EmbeddedSchemaStart + "\n" + RemovePackageClause(schema) + "\n" + EmbeddedSchemaEnd + "\n\nworkflow: #DeterministicWorkflow & {name: \"x\"}\n"
+	if hash, _, outdated := SchemaOutdated(embedded); hash != current || outdated {
+		t.Errorf("Expected the embedded current schema to be up to date, got %s (outdated: %t)", hash, outdated)
+	}
+
+	recorded := "package workflow\n\n" + SchemaHashComment + "0123456789abcdef\n\nworkflow: {name: \"x\"}\n"
+	if hash, currentHash, outdated := SchemaOutdated(recorded); hash != "0123456789abcdef" || currentHash != current || !outdated {
+		t.Errorf("Expected the recorded older schema to be outdated, got %s vs %s (outdated: %t)", hash, currentHash, outdated)
+	}
+
+	if hash, _, outdated := SchemaOutdated("workflow: {name: \"x\"}"); hash != "" || outdated {
+		t.Errorf("Expected a hand-authored workflow's schema to be unknown, got %s (outdated: %t)", hash, outdated)
+	}
+}
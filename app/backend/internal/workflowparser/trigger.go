@@ -0,0 +1,83 @@
+package workflowparser
+
+import (
+	"fmt"
+
+	"cuelang.org/go/cue"
+)
+
+// Trigger types a workflow can declare in trigger.type
+const (
+	TriggerManual   = "manual"
+	TriggerSchedule = "schedule"
+	TriggerEvent    = "event"
+)
+
+// WorkflowTrigger describes when a workflow runs. For scheduled workflows Cron holds the
+// normalized 5-field cron of Schedule, which the scheduler registers as-is.
+type WorkflowTrigger struct {
+	Type     string `json:"type"`
+	Schedule string `json:"schedule,omitempty"` // as written, cron or a phrase like "every weekday at 8 AM"
+	Cron     string `json:"cron,omitempty"`
+	Event    string `json:"event,omitempty"`
+}
+
+// parseTrigger reads a workflow's optional trigger block and normalizes its schedule; nil when
+// the workflow declares no trigger. A declared cron must agree with the schedule it was
+// normalized from.
+func parseTrigger(workflowValue cue.Value) (*WorkflowTrigger, error) {
+	triggerValue := workflowValue.LookupPath(cue.ParsePath("trigger"))
+	if !triggerValue.Exists() {
+		return nil, nil
+	}
+
+	trigger := &WorkflowTrigger{Type: TriggerManual}
+	for field, target := range map[string]*string{
+		"type":     &trigger.Type,
+		"schedule": &trigger.Schedule,
+		"cron":     &trigger.Cron,
+		"event":    &trigger.Event,
+	} {
+		if value := triggerValue.LookupPath(cue.ParsePath(field)); value.Exists() {
+			str, err := value.String()
+			if err != nil {
+				return nil, fmt.Errorf("failed to extract trigger.%s: %w", field, err)
+			}
+			*target = str
+		}
+	}
+
+	switch trigger.Type {
+	case TriggerSchedule:
+	case TriggerManual, TriggerEvent:
+		if trigger.Schedule != "" || trigger.Cron != "" {
+			return nil, fmt.Errorf("trigger.schedule requires trigger type %q, got %q", TriggerSchedule, trigger.Type)
+		}
+		return trigger, nil
+	default:
+		return nil, fmt.Errorf("unknown trigger type %q (expected manual, schedule or event)", trigger.Type)
+	}
+
+	if trigger.Schedule == "" && trigger.Cron == "" {
+		return nil, fmt.Errorf("scheduled trigger requires a schedule")
+	}
+	declaredCron := trigger.Cron
+	if trigger.Schedule == "" {
+		trigger.Schedule = declaredCron
+	}
+	cron, err := NormalizeSchedule(trigger.Schedule)
+	if err != nil {
+		return nil, fmt.Errorf("trigger.schedule: %w", err)
+	}
+	if declaredCron != "" {
+		normalizedDeclared, err := NormalizeSchedule(declaredCron)
+		if err != nil {
+			return nil, fmt.Errorf("trigger.cron: %w", err)
+		}
+		if normalizedDeclared != cron {
+			return nil, fmt.Errorf("trigger.cron %q does not match schedule %q (%s)", declaredCron, trigger.Schedule, cron)
+		}
+	}
+	trigger.Cron = cron
+	return trigger, nil
+}
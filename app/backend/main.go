@@ -31,8 +31,55 @@ func main() {
 	log.Printf("Initialized workflow storage: %s", workflowStorage.GetStorageType())
 
 	// Initialize services
+	services.SetLogRedactedFields(cfg.LogRedactFields)
 	mcpService := services.NewMCPService(cfg.MCP.BaseURL)
+	mcpService.SetRequestTimeout(cfg.MCP.RequestTimeout)
+	mcpService.SetRetryPolicy(services.MCPRetryPolicy{
+		MaxAttempts: cfg.MCP.RetryAttempts,
+		BaseDelay:   cfg.MCP.RetryBaseDelay,
+		MaxDelay:    cfg.MCP.RetryMaxDelay,
+		Jitter:      cfg.MCP.RetryJitter,
+	})
+	if len(cfg.MCP.AllowedServices) > 0 {
+		mcpService.SetServiceAllowlist(cfg.MCP.AllowedServices)
+		log.Printf("Workflows restricted to services: %v", mcpService.AllowedServices())
+	}
+	if len(cfg.OAuth2.Scopes) > 0 {
+		mcpService.SetAllowedOAuthScopes(cfg.OAuth2.Scopes)
+		log.Printf("Workflows restricted to OAuth scopes: %v", mcpService.AllowedOAuthScopes())
+	}
+	if cfg.MCP.CatalogCacheTTL > 0 {
+		mcpService.SetCatalogCacheTTL(cfg.MCP.CatalogCacheTTL)
+		log.Printf("MCP service catalog cached for %s", cfg.MCP.CatalogCacheTTL)
+	}
+	if cfg.MCP.StaticCatalogPath != "" {
+		mcpService.SetStaticCatalogPath(cfg.MCP.StaticCatalogPath)
+		log.Printf("Static MCP catalog fallback: %s", cfg.MCP.StaticCatalogPath)
+	}
+	if len(cfg.MCP.ProviderURLs) > 0 {
+		if err := mcpService.SetProviderURLs(cfg.MCP.ProviderURLs); err != nil {
+			log.Fatalf("Invalid MCP_PROVIDER_URLS: %v", err)
+		}
+		log.Printf("MCP providers routed to their own endpoints: %v", cfg.MCP.ProviderURLs)
+	}
 	genkitService := services.NewGenkitService(cfg.OpenAI.APIKey, mcpService, workflowStorage)
+	for flow, generation := range map[string]config.GenerationConfig{
+		"intent-gatherer":    cfg.Genkit.IntentGatherer,
+		"intent-analyst":     cfg.Genkit.IntentAnalyst,
+		"workflow-generator": cfg.Genkit.WorkflowGenerator,
+	} {
+		genkitService.SetFlowGenerationConfig(flow, services.FlowGenerationConfig{
+			Temperature:     generation.Temperature,
+			MaxOutputTokens: generation.MaxOutputTokens,
+		})
+	}
+	if err := genkitService.SetCUEOutput(cfg.Genkit.CUEPackageName, cfg.Genkit.CUESchemaImportPath); err != nil {
+		log.Fatalf("Invalid generated CUE settings: %v", err)
+	}
+	genkitService.SetUserParameterSynthesis(cfg.Genkit.SynthesizeUserParameters)
+	if err := genkitService.LoadRaCContexts(); err != nil {
+		log.Fatalf("Failed to load RaC context: %v", err)
+	}
 
 	// Initialize Firebase Authentication using environment variables
 	firebaseAuth, err := services.NewFirebaseAuthService()
@@ -52,17 +99,93 @@ func main() {
 	router.Use(gin.Logger())
 	router.Use(gin.Recovery())
 	router.Use(middleware.CORS())
+	router.Use(middleware.BodyLimit(cfg.Limits.MaxBodyBytes, cfg.Limits.MaxJSONDepth, nil))
 
 	// Initialize execution engine
 	executionEngine := services.NewExecutionEngine(mcpService)
+	executionEngine.SetExecutionLimiter(services.NewExecutionLimiter(cfg.Execution.MaxConcurrentPerUser, cfg.Execution.MaxQueuedPerUser))
+	executionEngine.SetMaxBinaryOutputBytes(cfg.Execution.MaxBinaryOutputBytes)
+	executionEngine.SetDefaultWorkflowTimeout(cfg.Execution.DefaultTimeout)
+	executionEngine.SetWarnOnEmptyStepOutputs(cfg.Execution.WarnOnEmptyOutputs)
+	for environment, policyConfig := range cfg.Execution.Environments {
+		policy := services.EnvironmentPolicy{
+			ConfirmDestructiveSteps: policyConfig.ConfirmDestructiveSteps,
+			SandboxWrites:           policyConfig.SandboxWrites,
+		}
+		if policyConfig.MaxConcurrentPerUser > 0 {
+			policy.Limiter = services.NewExecutionLimiter(policyConfig.MaxConcurrentPerUser, policyConfig.MaxQueuedPerUser)
+		}
+		executionEngine.SetEnvironmentPolicy(environment, policy)
+	}
+	webhookNotifier := services.NewWebhookNotifier(cfg.Webhook.Secret)
+	webhookNotifier.SetAllowedHosts(cfg.Webhook.AllowedHosts)
+	executionEngine.SetWebhookNotifier(webhookNotifier)
+	executionEngine.SetDeadLetterStore(services.NewDeadLetterStore())
+	parameterCipher, err := services.NewParameterCipher(cfg.Execution.ParameterKey)
+	if err != nil {
+		log.Fatalf("Failed to initialize parameter encryption: %v", err)
+	}
+	executionEngine.SetParameterCipher(parameterCipher)
+	if cfg.Execution.AuditLogPath != "" {
+		auditSink, err := services.NewFileAuditSink(cfg.Execution.AuditLogPath)
+		if err != nil {
+			log.Fatalf("Failed to initialize audit log: %v", err)
+		}
+		executionEngine.SetAuditSink(auditSink)
+		log.Printf("Audit log: %s", cfg.Execution.AuditLogPath)
+	} else {
+		executionEngine.SetAuditSink(services.NewMemoryAuditSink())
+	}
+	executionEngine.SetParameterHistory(services.NewParameterHistoryStore())
+	executionEngine.SetSecretStore(services.NewEnvSecretStore(cfg.Execution.SecretsEnvPrefix))
+	executionEngine.SetStepOutputCache(services.NewMemoryStepOutputCache(), cfg.Execution.StepCacheTTL)
+
+	// Initialize user preferences (default timezone, etc.)
+	userPreferences := services.NewUserPreferencesService()
+	executionEngine.SetUserPreferences(userPreferences)
+
+	// Initialize user profiles (display name, team list) that seed declared parameters
+	executionEngine.SetUserProfiles(services.NewUserProfileService())
 
 	// Initialize token manager
 	tokenManager := services.NewTokenManager()
+	if len(cfg.OAuth2.Scopes) > 0 {
+		tokenManager.SetScopes(cfg.OAuth2.Scopes)
+	}
+	if cfg.OAuth2.TokenStorePath != "" {
+		if err := tokenManager.SetStorePath(cfg.OAuth2.TokenStorePath); err != nil {
+			log.Fatalf("Failed to load token store: %v", err)
+		}
+		log.Printf("Token store: %s", cfg.OAuth2.TokenStorePath)
+	} else {
+		log.Printf("TOKEN_STORE_PATH is not set; users must reconnect Google after a restart")
+	}
 	tokenManager.StartCleanupRoutine()
+	executionEngine.SetTokenManager(tokenManager)
+	executionEngine.SetRequireConnectedServices(cfg.Execution.RequireConnected)
+	agentManager.SetTokenManager(tokenManager)
 
 	// Initialize API handler
-	apiHandler := api.NewHandler(agentManager, mcpService, workflowStorage, executionEngine, tokenManager)
-	api.SetupRoutes(router, apiHandler, middleware.FirebaseAuthMiddleware(firebaseAuth))
+	apiHandler := api.NewHandler(agentManager, mcpService, workflowStorage, executionEngine, tokenManager, userPreferences)
+	if cfg.Execution.AllowInlineWorkflows {
+		if cfg.Environment == "production" {
+			log.Printf("EXECUTION_ALLOW_INLINE_WORKFLOWS is ignored in production")
+		} else {
+			log.Printf("Inline workflow execution is enabled; posted CUE runs without being saved")
+			apiHandler.SetInlineExecution(true)
+		}
+	}
+
+	// Accept service account API keys alongside Firebase tokens when any are configured
+	authenticators := []middleware.Authenticator{middleware.NewFirebaseAuthenticator(firebaseAuth)}
+	if len(cfg.Auth.ServiceAccountKeys) > 0 {
+		apiKeyAuth, err := services.NewAPIKeyAuthService(cfg.Auth.ServiceAccountKeys)
+		if err != nil {
+			log.Fatalf("Failed to initialize API key auth: %v", err)
+		}
+		authenticators = append([]middleware.Authenticator{middleware.NewAPIKeyAuthenticator(apiKeyAuth)}, authenticators...)
+	}
+	api.SetupRoutes(router, apiHandler, middleware.AuthMiddleware(authenticators...), middleware.InternalAuth(cfg.Auth.InternalToken), middleware.AdminOnly(cfg.Auth.AdminUsers))
 
 	// Start server
 	port := cfg.Port
@@ -91,6 +214,11 @@ func logEndpoints(port string) {
 	log.Println("  GET  /api/v1/health")
 	log.Println("")
 	log.Println("Protected endpoints (require authentication):")
+	log.Println("Workspace connections:")
+	log.Println("  POST /api/v1/auth/store-google-token")
+	log.Println("  GET  /api/v1/auth/token-info")
+	log.Println("  GET  /api/v1/auth/connections")
+	log.Println("")
 	log.Println("Agent management:")
 	log.Println("  GET  /api/v1/agents")
 	log.Println("")
@@ -109,16 +237,31 @@ func logEndpoints(port string) {
 	log.Println("")
 	log.Println("Workflow execution:")
 	log.Println("  POST /api/v1/workflow/execute")
+	log.Println("  POST /api/v1/workflow/execute/confirm")
+	log.Println("  POST /api/v1/workflow/estimate")
+	log.Println("  POST /api/v1/workflow/simulate")
+	log.Println("")
+	log.Println("Failed executions:")
+	log.Println("  GET  /api/v1/executions/failed")
+	log.Println("  POST /api/v1/executions/:id/replay")
 	log.Println("")
 	log.Println("User services:")
 	log.Println("  GET  /api/v1/services")
+	log.Println("  GET  /api/v1/user/preferences")
+	log.Println("  PUT  /api/v1/user/preferences")
 	log.Println("")
 	log.Println("Workflow management:")
 	log.Println("  GET  /api/v1/workflows")
 	log.Println("  GET  /api/v1/workflows/:id")
+	log.Println("  GET  /api/v1/workflows/:id/inputs")
+	log.Println("  GET  /api/v1/workflows/:id/graph")
+	log.Println("")
+	log.Println("Administration (ADMIN_USERS only):")
+	log.Println("  POST /api/v1/admin/prompts/reload")
+	log.Println("  POST /api/v1/admin/rac/reload")
 	log.Println("")
 	log.Println("Testing and validation:")
-	log.Println("  POST /api/v1/test/pipeline")
+	log.Println("  POST /api/v1/test/pipeline (?mock=true for a canned intent and workflow)")
 	log.Println("  GET  /api/v1/validate/catalog")
 	log.Println("")
 	log.Printf("Server running at: http://localhost:%s", port)
@@ -298,7 +298,7 @@ onAuthStateChanged(auth, async (firebaseUser: FirebaseUser | null) => {
               }
             })
             if (resp.ok) { ok = true; break }
-            if (resp.status === 404 || resp.status === 401) { ok = false; break }
+            if (resp.status === 404 || resp.status === 401 || resp.status === 424) { ok = false; break }
           } catch (_) {
             continue
           }
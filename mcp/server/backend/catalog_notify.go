@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// catalogNotifyAttempts bounds how often the backend is told about a changed catalog
+const catalogNotifyAttempts = 3
+
+// notifyCatalogChanged asks the SOHOAAS backend to drop its cached service catalog so it
+// validates against the functions registered by this process. Failures are only logged:
+// the backend's cache still expires on its own.
+func notifyCatalogChanged(url, token string) {
+	client := &http.Client{Timeout: 5 * time.Second}
+	for attempt := 1; attempt <= catalogNotifyAttempts; attempt++ {
+		err := postCatalogInvalidation(client, url, token)
+		if err == nil {
+			log.Printf("Notified backend of catalog change: %s", url)
+			return
+		}
+		log.Printf("WARNING: Catalog change notification %d/%d failed: %v", attempt, catalogNotifyAttempts, err)
+		time.Sleep(time.Duration(attempt) * 2 * time.Second)
+	}
+}
+
+// postCatalogInvalidation sends one invalidation request with the shared internal token
+func postCatalogInvalidation(client *http.Client, url, token string) error {
+	req, err := http.NewRequest(http.MethodPost, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Internal-Token", token)
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("backend returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bodyLimitMiddleware rejects request bodies larger than maxBytes (413) and JSON bodies
+// nested deeper than maxDepth (400). Routes listed in overrides (by gin full
+// path, e.g. "/api/mcp/tools/call") use their own byte limit instead. It mirrors the app
+// backend's middleware.BodyLimit, as the two modules share no code; keep them in step.
+func bodyLimitMiddleware(maxBytes int64, maxDepth int, overrides map[string]int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+
+		limit := maxBytes
+		if override, exists := overrides[c.FullPath()]; exists {
+			limit = override
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, limit))
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{
+					"error":   "Request body too large",
+					"details": fmt.Sprintf("request body must not exceed %d bytes", limit),
+				})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error":   "Failed to read request body",
+				"details": err.Error(),
+			})
+			return
+		}
+
+		if maxDepth > 0 && jsonDepthExceeds(body, maxDepth) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{
+				"error":   "Request JSON too deeply nested",
+				"details": fmt.Sprintf("JSON nesting must not exceed %d levels", maxDepth),
+			})
+			return
+		}
+
+		// Hand the buffered body to the handler
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Next()
+	}
+}
+
+// jsonDepthExceeds reports whether body nests objects/arrays deeper than maxDepth.
+// Malformed JSON is left for the handler's binding to report.
+func jsonDepthExceeds(body []byte, maxDepth int) bool {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	depth := 0
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return false
+		}
+		if delim, ok := token.(json.Delim); ok {
+			switch delim {
+			case '{', '[':
+				depth++
+				if depth > maxDepth {
+					return true
+				}
+			case '}', ']':
+				depth--
+			}
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestBodyLimitMiddleware verifies oversized and deeply nested bodies are rejected before the handler runs
+func TestBodyLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(bodyLimitMiddleware(32, 3, map[string]int64{"/api/mcp/tools/call": 128}))
+	echo := func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, string(body))
+	}
+	router.POST("/api/services", echo)
+	router.POST("/api/mcp/tools/call", echo)
+
+	tests := []struct {
+		name       string
+		path       string
+		body       string
+		wantStatus int
+		wantError  string
+	}{
+		{"within limits", "/api/services", `{"a":{"b":[1]}}`, http.StatusOK, ""},
+		{"over byte limit", "/api/services", strings.Repeat("x", 33), http.StatusRequestEntityTooLarge, "Request body too large"},
+		{"route override", "/api/mcp/tools/call", strings.Repeat("x", 100), http.StatusOK, ""},
+		{"over route override", "/api/mcp/tools/call", strings.Repeat("x", 129), http.StatusRequestEntityTooLarge, "Request body too large"},
+		{"too deeply nested", "/api/services", `{"a":{"b":[[1]]}}`, http.StatusBadRequest, "Request JSON too deeply nested"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body)))
+
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, recorder.Code, recorder.Body.String())
+			}
+			if tt.wantError == "" {
+				if recorder.Body.String() != tt.body {
+					t.Errorf("Expected the handler to receive the full body, got %q", recorder.Body.String())
+				}
+				return
+			}
+			var response map[string]interface{}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil || response["error"] != tt.wantError {
+				t.Errorf("Expected error %q, got %s", tt.wantError, recorder.Body.String())
+			}
+		})
+	}
+}
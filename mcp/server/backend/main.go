@@ -8,6 +8,8 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/oauth2"
@@ -18,6 +20,10 @@ import (
 	"github.com/dimitar-trifonov/sohoaas/service-proxies/workflow"
 )
 
+// catalogSchemaVersion is the "major.minor" shape of the /api/services catalog. Bump the
+// minor version for added fields and the major version for changes that break parsers.
+const catalogSchemaVersion = "1.2"
+
 func main() {
 	fmt.Println("Service Proxies - Multi-Provider Workflow Engine")
 	fmt.Println("================================================")
@@ -25,41 +31,68 @@ func main() {
 	// Create workflow engine
 	engine := workflow.NewMultiProviderWorkflowEngine()
 
+	// Throttle concurrent Google API calls; further tool calls queue for a free slot (0 = unlimited)
+	engine.SetMaxConcurrency(int(getEnvInt64OrDefault("MAX_CONCURRENT_TOOL_CALLS", 10)))
+	fmt.Printf("Max concurrent tool calls: %d\n", engine.MaxConcurrency())
+
 	// Load OAuth2 credentials from environment variables
 	creds, err := loadGoogleCredentialsFromEnv()
 	if err != nil {
 		log.Fatalf("Failed to load Google credentials: %v", err)
 	}
 
+	// OAuth scopes for every proxy; narrow them (e.g. OAUTH_SCOPES=gmail.readonly) for least-privilege deployments
+	scopes, err := workspace.ParseScopes(os.Getenv("OAUTH_SCOPES"))
+	if err != nil {
+		log.Fatalf("Invalid OAUTH_SCOPES: %v", err)
+	}
+	fmt.Printf("OAuth scopes: %v\n", scopes)
+
 	// Initialize OAuth2 configuration with loaded credentials
 	oauthConfig := &oauth2.Config{
 		ClientID:     creds.Web.ClientID,
 		ClientSecret: creds.Web.ClientSecret,
 		RedirectURL:  getEnvOrDefault("OAUTH_REDIRECT_URL", "http://localhost:8080/api/v1/auth/google/callback"), // Match your Google Cloud Console config
-		Scopes: []string{
-			"https://www.googleapis.com/auth/gmail.modify",
-			"https://www.googleapis.com/auth/documents",
-			"https://www.googleapis.com/auth/drive",
-			"https://www.googleapis.com/auth/calendar",
-		},
-		Endpoint: google.Endpoint,
+		Scopes:       scopes,
+		Endpoint:     google.Endpoint,
 	}
 
+	// Mask extra fields (e.g. email bodies) in payload and result logs; token, password and secret keys always are
+	workflow.SetRedactedFields(strings.Split(os.Getenv("LOG_REDACT_FIELDS"), ","))
+
 	// Initialize workspace proxies
 	gmailProxy := workspace.NewGmailProxy(oauthConfig)
+	gmailProxy.SetAttachmentLimits(getEnvInt64OrDefault("GMAIL_MAX_ATTACHMENT_BYTES", 25<<20), strings.Split(os.Getenv("GMAIL_ATTACHMENT_MIME_TYPES"), ","))
+	gmailProxy.SetListLimit(getEnvInt64OrDefault("GMAIL_MAX_LIST_MESSAGES", 1000))
 	docsProxy := workspace.NewDocsProxy(oauthConfig)
 	driveProxy := workspace.NewDriveProxy(oauthConfig)
 	calendarProxy := workspace.NewCalendarProxy(oauthConfig)
 
-	// Register workspace services
-	engine.RegisterServiceProxy("workspace", "gmail", gmailProxy)
-	engine.RegisterServiceProxy("workspace", "docs", docsProxy)
-	engine.RegisterServiceProxy("workspace", "drive", driveProxy)
-	engine.RegisterServiceProxy("workspace", "calendar", calendarProxy)
+	// Register workspace services that pass their self-check; the others are reported as unavailable
+	serviceHealth := make(map[string]workspace.ServiceHealth)
+	for service, proxy := range map[string]workflow.ServiceProxy{
+		workspace.ServiceTypeGmail:    gmailProxy,
+		workspace.ServiceTypeDocs:     docsProxy,
+		workspace.ServiceTypeDrive:    driveProxy,
+		workspace.ServiceTypeCalendar: calendarProxy,
+	} {
+		health := workspace.CheckServiceConfig(service, oauthConfig)
+		serviceHealth[service] = health
+		if !health.Healthy {
+			log.Printf("WARNING: Skipping workspace service %s: %s", service, health.Reason)
+			continue
+		}
+		engine.RegisterServiceProxy("workspace", service, proxy)
+	}
 
 	fmt.Printf("Registered providers: %v\n", engine.GetSupportedProviders())
 	fmt.Printf("Workspace services: %v\n", engine.GetSupportedServices("workspace"))
 
+	// Tell the backend to refetch the catalog, which may have changed with this deploy
+	if invalidateURL := os.Getenv("BACKEND_CATALOG_INVALIDATE_URL"); invalidateURL != "" {
+		go notifyCatalogChanged(invalidateURL, os.Getenv("INTERNAL_API_TOKEN"))
+	}
+
 	fmt.Println("\nService proxy backend initialized successfully!")
 	fmt.Println("Ready to execute multi-provider workflows.")
 
@@ -68,22 +101,36 @@ func main() {
 		ClientID:     os.Getenv("GOOGLE_CLIENT_ID"),
 		ClientSecret: os.Getenv("GOOGLE_CLIENT_SECRET"),
 		RedirectURL:  os.Getenv("OAUTH_REDIRECT_URL"),
-		GmailScopes:  []string{"https://www.googleapis.com/auth/gmail.send"},
-		DocsScopes:   []string{"https://www.googleapis.com/auth/documents"},
-		DriveScopes:  []string{"https://www.googleapis.com/auth/drive"},
-		CalendarScopes: []string{"https://www.googleapis.com/auth/calendar"},
+		GmailScopes:  workspace.ScopesForService(workspace.ServiceTypeGmail, scopes),
+		DocsScopes:   workspace.ScopesForService(workspace.ServiceTypeDocs, scopes),
+		DriveScopes:  workspace.ScopesForService(workspace.ServiceTypeDrive, scopes),
+		CalendarScopes: workspace.ScopesForService(workspace.ServiceTypeCalendar, scopes),
 	})
 
 	// Create MCP server
 	mcpServer := mcp.NewMCPServer(workspaceManager, engine)
+	mcpServer.SetFeatures(mcp.FeatureCapability{
+		WorkflowExecute: getEnvOrDefault("MCP_FEATURE_WORKFLOW_EXECUTE", "true") == "true",
+	})
+	fmt.Printf("MCP features: %+v\n", mcpServer.Features())
+	mcpServer.SetMaxMessageBytes(getEnvInt64OrDefault("MAX_UPLOAD_BODY_BYTES", 25<<20))
 
 	// Start HTTP server for proxy API endpoints and MCP WebSocket
-	startHTTPServer(engine, oauthConfig, gmailProxy, docsProxy, driveProxy, calendarProxy, mcpServer)
+	startHTTPServer(engine, oauthConfig, gmailProxy, docsProxy, driveProxy, calendarProxy, serviceHealth, mcpServer)
 }
 
-func startHTTPServer(engine *workflow.MultiProviderWorkflowEngine, oauthConfig *oauth2.Config, gmailProxy *workspace.GmailProxy, docsProxy *workspace.DocsProxy, driveProxy *workspace.DriveProxy, calendarProxy *workspace.CalendarProxy, mcpServer *mcp.MCPServer) {
+func startHTTPServer(engine *workflow.MultiProviderWorkflowEngine, oauthConfig *oauth2.Config, gmailProxy *workspace.GmailProxy, docsProxy *workspace.DocsProxy, driveProxy *workspace.DriveProxy, calendarProxy *workspace.CalendarProxy, serviceHealth map[string]workspace.ServiceHealth, mcpServer *mcp.MCPServer) {
 	r := gin.Default()
 
+	// Cap request sizes; tool calls may carry Drive upload content so they get a larger limit
+	r.Use(bodyLimitMiddleware(
+		getEnvInt64OrDefault("MAX_REQUEST_BODY_BYTES", 1<<20),
+		int(getEnvInt64OrDefault("MAX_JSON_DEPTH", 32)),
+		map[string]int64{
+			"/api/mcp/tools/call": getEnvInt64OrDefault("MAX_UPLOAD_BODY_BYTES", 25<<20),
+		},
+	))
+
 	// Store OAuth2 state and token - COMMENTED OUT (using Firebase Auth instead)
 	var currentToken *oauth2.Token
 	// oauthStates := make(map[string]bool)
@@ -133,8 +180,8 @@ func startHTTPServer(engine *workflow.MultiProviderWorkflowEngine, oauthConfig *
 		}
 		delete(oauthStates, state)
 
-		// Exchange code for token
-		token, err := oauthConfig.Exchange(context.Background(), code)
+		// Exchange code for token, retrying transient Google and network failures
+		token, err := exchangeOAuthCode(c.Request.Context(), oauthConfig, code)
 		if err != nil {
 			// Redirect back to frontend with error
 			c.Redirect(http.StatusTemporaryRedirect, frontendURL+"/?auth_error=token_exchange_failed")
@@ -172,8 +219,8 @@ func startHTTPServer(engine *workflow.MultiProviderWorkflowEngine, oauthConfig *
 		}
 		delete(oauthStates, state)
 
-		// Exchange code for token
-		token, err := oauthConfig.Exchange(context.Background(), code)
+		// Exchange code for token, retrying transient Google and network failures
+		token, err := exchangeOAuthCode(c.Request.Context(), oauthConfig, code)
 		if err != nil {
 			// Redirect back to frontend with error
 			c.Redirect(http.StatusTemporaryRedirect, frontendURL+"/?auth_error=token_exchange_failed")
@@ -203,16 +250,28 @@ func startHTTPServer(engine *workflow.MultiProviderWorkflowEngine, oauthConfig *
 		})
 	})
 
-	// Health endpoint
+	// Health endpoint; degraded when a service failed its self-check at registration
 	r.GET("/health", func(c *gin.Context) {
+		status := "healthy"
+		for _, health := range serviceHealth {
+			if !health.Healthy {
+				status = "degraded"
+			}
+		}
 		c.JSON(http.StatusOK, gin.H{
-			"status":   "healthy",
+			"status":   status,
 			"providers": engine.GetSupportedProviders(),
+			"services":  serviceHealth,
 		})
 	})
 
 	// Workflow execution endpoint
 	r.POST("/api/workflow/execute", func(c *gin.Context) {
+		if !mcpServer.Features().WorkflowExecute {
+			c.JSON(http.StatusNotImplemented, gin.H{"error": "workflow execution is disabled on this server"})
+			return
+		}
+
 		var request struct {
 			Steps []workflow.WorkflowStep `json:"steps"`
 			Input map[string]interface{}  `json:"input"`
@@ -228,7 +287,7 @@ func startHTTPServer(engine *workflow.MultiProviderWorkflowEngine, oauthConfig *
 		for i, step := range request.Steps {
 			fmt.Printf("[DEBUG] Step %d: ID=%s, Provider=%s, Service=%s, Function=%s\n", i, step.ID, step.Provider, step.Service, step.Function)
 		}
-		fmt.Printf("[DEBUG] Input: %+v\n", request.Input)
+		fmt.Printf("[DEBUG] Input: %+v\n", workflow.Redact(request.Input))
 
 		// Use current token if available
 		if currentToken != nil {
@@ -270,49 +329,40 @@ func startHTTPServer(engine *workflow.MultiProviderWorkflowEngine, oauthConfig *
 		// Build service metadata for all providers
 		providersMetadata := make(map[string]map[string]interface{})
 		
-		// For workspace provider, get metadata from all registered services
+		// For workspace provider, get metadata from all registered services; services that
+		// failed their self-check are listed with the reason instead
 		workspaceServices := make(map[string]interface{})
-		
-		// Get Gmail service metadata
-		gmailMetadata := gmailProxy.GetServiceMetadata()
-		workspaceServices[gmailMetadata.ServiceType] = map[string]interface{}{
-			"display_name": gmailMetadata.DisplayName,
-			"description":  gmailMetadata.Description,
-			"functions":    gmailMetadata.Functions,
-		}
-		
-		// Get Docs service metadata
-		docsMetadata := docsProxy.GetServiceMetadata()
-		workspaceServices[docsMetadata.ServiceType] = map[string]interface{}{
-			"display_name": docsMetadata.DisplayName,
-			"description":  docsMetadata.Description,
-			"functions":    docsMetadata.Functions,
-		}
-		
-		// Get Drive service metadata
-		driveMetadata := driveProxy.GetServiceMetadata()
-		workspaceServices[driveMetadata.ServiceType] = map[string]interface{}{
-			"display_name": driveMetadata.DisplayName,
-			"description":  driveMetadata.Description,
-			"functions":    driveMetadata.Functions,
-		}
-		
-		// Get Calendar service metadata
-		calendarMetadata := calendarProxy.GetServiceMetadata()
-		workspaceServices[calendarMetadata.ServiceType] = map[string]interface{}{
-			"display_name": calendarMetadata.DisplayName,
-			"description":  calendarMetadata.Description,
-			"functions":    calendarMetadata.Functions,
+		unavailableServices := make(map[string]string)
+		for _, metadata := range []workspace.ServiceMetadata{
+			gmailProxy.GetServiceMetadata(),
+			docsProxy.GetServiceMetadata(),
+			driveProxy.GetServiceMetadata(),
+			calendarProxy.GetServiceMetadata(),
+		} {
+			if health := serviceHealth[metadata.ServiceType]; !health.Healthy {
+				unavailableServices[metadata.ServiceType] = health.Reason
+				continue
+			}
+			workspaceServices[metadata.ServiceType] = map[string]interface{}{
+				"display_name": metadata.DisplayName,
+				"description":  metadata.Description,
+				"functions":    metadata.Functions,
+			}
 		}
 		
-		providersMetadata["workspace"] = map[string]interface{}{
+		workspaceProvider := map[string]interface{}{
 			"display_name": "Google Workspace",
 			"description":  "Google Workspace services including Gmail, Docs, Drive, and Calendar",
 			"services":     workspaceServices,
 		}
+		if len(unavailableServices) > 0 {
+			workspaceProvider["unavailable_services"] = unavailableServices
+		}
+		providersMetadata["workspace"] = workspaceProvider
 
 		c.JSON(http.StatusOK, gin.H{
-			"providers": providersMetadata,
+			"schema_version": catalogSchemaVersion,
+			"providers":      providersMetadata,
 		})
 	})
 
@@ -335,9 +385,16 @@ func startHTTPServer(engine *workflow.MultiProviderWorkflowEngine, oauthConfig *
 		// Minimal per-function OAuth scopes for PoC
 		scopesMap := map[string][]string{
 			"gmail.send_message":    {"https://www.googleapis.com/auth/gmail.send"},
+			"gmail.list_messages":   {"https://www.googleapis.com/auth/gmail.readonly"},
+			"gmail.get_message":     {"https://www.googleapis.com/auth/gmail.readonly"},
+			"gmail.modify_labels":   {"https://www.googleapis.com/auth/gmail.modify"},
+			"gmail.archive_message": {"https://www.googleapis.com/auth/gmail.modify"},
 			"docs.create_document":   {"https://www.googleapis.com/auth/documents", "https://www.googleapis.com/auth/drive.file"},
 			"drive.share_file":       {"https://www.googleapis.com/auth/drive"},
+			"drive.list_permissions": {"https://www.googleapis.com/auth/drive"},
+			"drive.revoke_permission": {"https://www.googleapis.com/auth/drive"},
 			"calendar.create_event":  {"https://www.googleapis.com/auth/calendar.events"},
+			"calendar.get_event":     {"https://www.googleapis.com/auth/calendar.events.readonly"},
 		}
 
 		// Helpers to infer JSON Schema from example payloads (recursive literal requires var then assign)
@@ -410,9 +467,17 @@ func startHTTPServer(engine *workflow.MultiProviderWorkflowEngine, oauthConfig *
 			return tool
 		}
 
+		// Services that failed their self-check offer no tools
+		availableFunctions := func(metadata workspace.ServiceMetadata) map[string]workspace.FunctionMetadata {
+			if !serviceHealth[metadata.ServiceType].Healthy {
+				return nil
+			}
+			return metadata.Functions
+		}
+
 		// Gmail
 		gmailMetadata := gmailProxy.GetServiceMetadata()
-		for functionName, functionInfo := range gmailMetadata.Functions {
+		for functionName, functionInfo := range availableFunctions(gmailMetadata) {
 			// FunctionMetadata is a struct; convert to map for generic handling above
 			fi := map[string]interface{}{
 				"description":     functionInfo.Description,
@@ -424,7 +489,7 @@ func startHTTPServer(engine *workflow.MultiProviderWorkflowEngine, oauthConfig *
 
 		// Docs
 		docsMetadata := docsProxy.GetServiceMetadata()
-		for functionName, functionInfo := range docsMetadata.Functions {
+		for functionName, functionInfo := range availableFunctions(docsMetadata) {
 			fi := map[string]interface{}{
 				"description":     functionInfo.Description,
 				"example_payload": functionInfo.ExamplePayload,
@@ -435,7 +500,7 @@ func startHTTPServer(engine *workflow.MultiProviderWorkflowEngine, oauthConfig *
 
 		// Drive
 		driveMetadata := driveProxy.GetServiceMetadata()
-		for functionName, functionInfo := range driveMetadata.Functions {
+		for functionName, functionInfo := range availableFunctions(driveMetadata) {
 			fi := map[string]interface{}{
 				"description":     functionInfo.Description,
 				"example_payload": functionInfo.ExamplePayload,
@@ -446,7 +511,7 @@ func startHTTPServer(engine *workflow.MultiProviderWorkflowEngine, oauthConfig *
 
 		// Calendar
 		calendarMetadata := calendarProxy.GetServiceMetadata()
-		for functionName, functionInfo := range calendarMetadata.Functions {
+		for functionName, functionInfo := range availableFunctions(calendarMetadata) {
 			fi := map[string]interface{}{
 				"description":     functionInfo.Description,
 				"example_payload": functionInfo.ExamplePayload,
@@ -475,6 +540,15 @@ func startHTTPServer(engine *workflow.MultiProviderWorkflowEngine, oauthConfig *
 			return
 		}
 
+		if problems := mcpServer.ValidateToolArguments(request.Name, request.Arguments); len(problems) > 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid params",
+				"code":    -32602,
+				"details": problems,
+			})
+			return
+		}
+
 		result, err := mcpServer.ExecuteTool(request.Name, request.Arguments)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{
@@ -590,3 +664,13 @@ func getEnvOrDefault(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvInt64OrDefault returns an integer environment variable or a default value
+func getEnvInt64OrDefault(key string, defaultValue int64) int64 {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
@@ -21,6 +21,24 @@ type MCPServer struct {
 	upgrader         websocket.Upgrader
 	connections      map[string]*websocket.Conn
 	connMutex        sync.RWMutex
+	features         FeatureCapability         // optional features enabled in config
+	sessions         map[string]*clientSession // negotiated state per connection
+	maxMessageBytes  int64                     // largest accepted WebSocket message, 0 = unlimited
+}
+
+// clientSession holds what was negotiated with a client during initialize
+type clientSession struct {
+	conn         *websocket.Conn
+	writeMutex   sync.Mutex
+	capabilities ClientCapabilities
+	negotiated   FeatureCapability
+}
+
+// writeJSON serializes writes to the session's connection
+func (cs *clientSession) writeJSON(v interface{}) error {
+	cs.writeMutex.Lock()
+	defer cs.writeMutex.Unlock()
+	return cs.conn.WriteJSON(v)
 }
 
 // NewMCPServer creates a new MCP server instance
@@ -36,9 +54,25 @@ func NewMCPServer(workspaceManager *workspace.ProxyManager, workflowEngine *work
 			},
 		},
 		connections: make(map[string]*websocket.Conn),
+		sessions:    make(map[string]*clientSession),
 	}
 }
 
+// SetFeatures configures which optional features the server advertises
+func (s *MCPServer) SetFeatures(features FeatureCapability) {
+	s.features = features
+}
+
+// SetMaxMessageBytes caps the size of incoming WebSocket messages
+func (s *MCPServer) SetMaxMessageBytes(maxBytes int64) {
+	s.maxMessageBytes = maxBytes
+}
+
+// Features returns the optional features enabled on this server
+func (s *MCPServer) Features() FeatureCapability {
+	return s.features
+}
+
 // HandleWebSocket handles WebSocket connections for MCP
 func (s *MCPServer) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 	log.Printf("MCP WebSocket connection attempt from %s", r.RemoteAddr)
@@ -50,16 +84,22 @@ func (s *MCPServer) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	defer conn.Close()
+	if s.maxMessageBytes > 0 {
+		conn.SetReadLimit(s.maxMessageBytes)
+	}
 
 	// Generate connection ID
 	connID := fmt.Sprintf("conn_%d", len(s.connections))
+	session := &clientSession{conn: conn}
 	s.connMutex.Lock()
 	s.connections[connID] = conn
+	s.sessions[connID] = session
 	s.connMutex.Unlock()
 
 	defer func() {
 		s.connMutex.Lock()
 		delete(s.connections, connID)
+		delete(s.sessions, connID)
 		s.connMutex.Unlock()
 	}()
 
@@ -74,9 +114,9 @@ func (s *MCPServer) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 			break
 		}
 
-		response := s.handleRequest(request)
+		response := s.handleRequest(session, request)
 		
-		err = conn.WriteJSON(response)
+		err = session.writeJSON(response)
 		if err != nil {
 			log.Printf("Error writing response: %v", err)
 			break
@@ -85,10 +125,10 @@ func (s *MCPServer) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 }
 
 // handleRequest processes incoming JSON-RPC requests
-func (s *MCPServer) handleRequest(request JSONRPCRequest) JSONRPCResponse {
+func (s *MCPServer) handleRequest(session *clientSession, request JSONRPCRequest) JSONRPCResponse {
 	switch request.Method {
 	case "initialize":
-		return s.handleInitialize(request)
+		return s.handleInitialize(session, request)
 	case "resources/list":
 		return s.handleListResources(request)
 	case "resources/read":
@@ -111,7 +151,7 @@ func (s *MCPServer) handleRequest(request JSONRPCRequest) JSONRPCResponse {
 }
 
 // handleInitialize handles the MCP initialize request
-func (s *MCPServer) handleInitialize(request JSONRPCRequest) JSONRPCResponse {
+func (s *MCPServer) handleInitialize(session *clientSession, request JSONRPCRequest) JSONRPCResponse {
 	var initReq InitializeRequest
 	if err := json.Unmarshal(request.Params, &initReq); err != nil {
 		return JSONRPCResponse{
@@ -125,6 +165,21 @@ func (s *MCPServer) handleInitialize(request JSONRPCRequest) JSONRPCResponse {
 		}
 	}
 
+	// Remember what the client can handle; notifications are only sent for
+	// features both sides support
+	clientFeatures := FeatureCapability{}
+	if initReq.Capabilities.Features != nil {
+		clientFeatures = *initReq.Capabilities.Features
+	}
+	if session != nil {
+		s.connMutex.Lock()
+		session.capabilities = initReq.Capabilities
+		session.negotiated = s.features.Intersect(clientFeatures)
+		s.connMutex.Unlock()
+		log.Printf("[MCP] Client %s negotiated features: %+v", initReq.ClientInfo.Name, session.negotiated)
+	}
+
+	features := s.features
 	result := InitializeResult{
 		ProtocolVersion: "2024-11-05",
 		Capabilities: ServerCapabilities{
@@ -135,6 +190,7 @@ func (s *MCPServer) handleInitialize(request JSONRPCRequest) JSONRPCResponse {
 			Tools: &ToolCapability{
 				ListChanged: false,
 			},
+			Features: &features,
 		},
 		ServerInfo: ServerInfo{
 			Name:    "Workspace MCP Server",
@@ -264,6 +320,19 @@ func (s *MCPServer) handleCallTool(request JSONRPCRequest) JSONRPCResponse {
 		}
 	}
 
+	// Reject arguments that don't match the tool's input schema before calling Google
+	if problems := s.ValidateToolArguments(callReq.Name, callReq.Arguments); len(problems) > 0 {
+		return JSONRPCResponse{
+			JSONRPC: "2.0",
+			ID:      request.ID,
+			Error: &RPCError{
+				Code:    -32602,
+				Message: "Invalid params",
+				Data:    problems,
+			},
+		}
+	}
+
 	result, err := s.executeTool(callReq.Name, callReq.Arguments)
 	if err != nil {
 		return JSONRPCResponse{
@@ -357,6 +426,26 @@ func (s *MCPServer) getServiceFunctions(serviceType string) []map[string]interfa
 				"description": "Track email responses",
 				"required_fields": []string{"message_id"},
 			},
+			{
+				"name": "get_message",
+				"description": "Get an email with full body and attachment metadata",
+				"required_fields": []string{"message_id"},
+			},
+			{
+				"name": "list_messages",
+				"description": "List emails matching a Gmail search query",
+				"required_fields": []string{},
+			},
+			{
+				"name": "modify_labels",
+				"description": "Add or remove labels on an email",
+				"required_fields": []string{"message_id"},
+			},
+			{
+				"name": "archive_message",
+				"description": "Archive an email (remove it from the inbox)",
+				"required_fields": []string{"message_id"},
+			},
 		}
 	case "docs":
 		return []map[string]interface{}{
@@ -393,6 +482,16 @@ func (s *MCPServer) getServiceFunctions(serviceType string) []map[string]interfa
 				"description": "Track document status",
 				"required_fields": []string{"file_id"},
 			},
+			{
+				"name": "list_permissions",
+				"description": "List who has access to a file",
+				"required_fields": []string{"file_id"},
+			},
+			{
+				"name": "revoke_permission",
+				"description": "Revoke access to a file",
+				"required_fields": []string{"file_id", "permission_id"},
+			},
 		}
 	case "calendar":
 		return []map[string]interface{}{
@@ -406,6 +505,11 @@ func (s *MCPServer) getServiceFunctions(serviceType string) []map[string]interfa
 				"description": "Create a calendar event",
 				"required_fields": []string{"title", "start_time", "end_time"},
 			},
+			{
+				"name": "get_event",
+				"description": "Get a calendar event with attendee responses",
+				"required_fields": []string{"event_id"},
+			},
 		}
 	default:
 		return []map[string]interface{}{}
@@ -437,10 +541,112 @@ func (s *MCPServer) getAvailableTools() []Tool {
 						"type":        "string",
 						"description": "Email body content",
 					},
+					"body_html": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional HTML body, sanitized to safe formatting; body is sent as the plain text alternative",
+					},
+					"attachments": map[string]interface{}{
+						"type":        "array",
+						"description": "Optional attachments: objects with filename, content (base64 or data URL) and mime_type; limited in total size and to allowed MIME types",
+					},
 				},
 				"required": []string{"token", "to", "subject", "body"},
 			},
 		},
+		{
+			Name:        "gmail.list_messages",
+			Description: "List Gmail messages matching a search query",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"token": map[string]interface{}{
+						"type":        "string",
+						"description": "OAuth2 access token",
+					},
+					"query": map[string]interface{}{
+						"type":        "string",
+						"description": "Gmail search query (e.g. from:alerts@broker.com newer_than:7d)",
+					},
+					"max_results": map[string]interface{}{
+						"type":        "number",
+						"description": "Maximum number of messages to return (default 10); more than 500 are fetched in pages",
+					},
+					"max_total": map[string]interface{}{
+						"type":        "number",
+						"description": "Safeguard on the messages fetched across pages (server limit GMAIL_MAX_LIST_MESSAGES, default 1000)",
+					},
+					"page_token": map[string]interface{}{
+						"type":        "string",
+						"description": "Page token from a previous next_page_token",
+					},
+				},
+				"required": []string{"token"},
+			},
+		},
+		{
+			Name:        "gmail.get_message",
+			Description: "Get a Gmail message with decoded bodies and attachment metadata",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"token": map[string]interface{}{
+						"type":        "string",
+						"description": "OAuth2 access token",
+					},
+					"message_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Gmail message ID",
+					},
+				},
+				"required": []string{"token", "message_id"},
+			},
+		},
+		{
+			Name:        "gmail.modify_labels",
+			Description: "Add and remove label IDs on a Gmail message, e.g. to mark it processed",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"token": map[string]interface{}{
+						"type":        "string",
+						"description": "OAuth2 access token",
+					},
+					"message_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Gmail message ID",
+					},
+					"add_label_ids": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Label IDs to add",
+					},
+					"remove_label_ids": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Label IDs to remove (e.g. UNREAD)",
+					},
+				},
+				"required": []string{"token", "message_id"},
+			},
+		},
+		{
+			Name:        "gmail.archive_message",
+			Description: "Archive a Gmail message by removing it from the inbox",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"token": map[string]interface{}{
+						"type":        "string",
+						"description": "OAuth2 access token",
+					},
+					"message_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Gmail message ID",
+					},
+				},
+				"required": []string{"token", "message_id"},
+			},
+		},
 		{
 			Name:        "docs.create_document",
 			Description: "Create a Google Doc from a template",
@@ -494,6 +700,46 @@ func (s *MCPServer) getAvailableTools() []Tool {
 				"required": []string{"token", "file_id", "email", "role"},
 			},
 		},
+		{
+			Name:        "drive.list_permissions",
+			Description: "List who has access to a Google Drive file and their roles",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"token": map[string]interface{}{
+						"type":        "string",
+						"description": "OAuth2 access token",
+					},
+					"file_id": map[string]interface{}{
+						"type":        "string",
+						"description": "File ID to audit",
+					},
+				},
+				"required": []string{"token", "file_id"},
+			},
+		},
+		{
+			Name:        "drive.revoke_permission",
+			Description: "Revoke a permission on a Google Drive file",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"token": map[string]interface{}{
+						"type":        "string",
+						"description": "OAuth2 access token",
+					},
+					"file_id": map[string]interface{}{
+						"type":        "string",
+						"description": "File ID",
+					},
+					"permission_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Permission ID from drive.list_permissions or drive.share_file",
+					},
+				},
+				"required": []string{"token", "file_id", "permission_id"},
+			},
+		},
 		{
 			Name:        "calendar.create_event",
 			Description: "Create a calendar reminder",
@@ -520,10 +766,41 @@ func (s *MCPServer) getAvailableTools() []Tool {
 						"type":        "string",
 						"description": "End time in RFC3339 format",
 					},
+					"event_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Optional stable event ID (e.g. workflow, step and date); re-running updates the event instead of creating a duplicate",
+					},
+					"attendees": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Attendee email addresses",
+					},
+					"send_notifications": map[string]interface{}{
+						"type":        "boolean",
+						"description": "Email the attendees an invitation (default false)",
+					},
 				},
 				"required": []string{"token", "title", "startTime", "endTime"},
 			},
 		},
+		{
+			Name:        "calendar.get_event",
+			Description: "Get a calendar event with its attendees' current responses (RSVPs) and the attendees who haven't responded",
+			InputSchema: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"token": map[string]interface{}{
+						"type":        "string",
+						"description": "OAuth2 access token",
+					},
+					"event_id": map[string]interface{}{
+						"type":        "string",
+						"description": "Event ID returned by calendar.create_event, or the event_id it was given",
+					},
+				},
+				"required": []string{"token", "event_id"},
+			},
+		},
 	}
 
 	return tools
@@ -572,7 +849,7 @@ func (s *MCPServer) executeToolViaWorkflow(ctx context.Context, service, functio
 	// Debug logging
 	log.Printf("[MCP] Executing %s.%s via workflow engine", service, function)
 	log.Printf("[MCP] Token length: %d", len(token))
-	log.Printf("[MCP] Arguments: %+v", arguments)
+	log.Printf("[MCP] Arguments: %+v", workflow.Redact(arguments))
 
 	// Set provider token (same as REST API)
 	s.workflowEngine.SetProviderToken("workspace", token)
@@ -591,7 +868,7 @@ func (s *MCPServer) executeToolViaWorkflow(ctx context.Context, service, functio
 	}
 
 	// Debug logging for result
-	log.Printf("[MCP] %s.%s workflow result: %+v", service, function, result)
+	log.Printf("[MCP] %s.%s workflow result: %+v", service, function, workflow.RedactExecution(result))
 
 	// Extract result from the step
 	stepID := fmt.Sprintf("%s_%s", service, function)
@@ -614,6 +891,10 @@ func (s *MCPServer) executeToolViaWorkflow(ctx context.Context, service, functio
 			if stepResult.Error.Details != "" {
 				errorMsg += ": " + stepResult.Error.Details
 			}
+			// Lead with the proxy error code so clients can classify the failure
+			if stepResult.Error.Code != "" {
+				errorMsg = stepResult.Error.Code + ": " + errorMsg
+			}
 		}
 		log.Printf("[MCP] %s.%s step FAILED: %s", service, function, errorMsg)
 		return ToolResult{
@@ -642,21 +923,8 @@ func (s *MCPServer) executeToolViaWorkflow(ctx context.Context, service, functio
 		}
 	}
 
-	// Convert response data to JSON string
-	responseJSON, err := json.Marshal(responseData)
-	if err != nil {
-		log.Printf("[MCP] Failed to marshal response data: %v", err)
-		responseJSON = []byte("{}")
-	}
-
-	log.Printf("[MCP] %s.%s workflow SUCCESS - Response: %s", service, function, string(responseJSON))
-	return ToolResult{
-		Content: []ToolContent{{
-			Type: "text",
-			Text: string(responseJSON),
-		}},
-		IsError: false,
-	}, nil
+	log.Printf("[MCP] %s.%s workflow SUCCESS - Response: %+v", service, function, workflow.Redact(responseData))
+	return successToolResult(summarizeToolData(service, function, responseData), responseData), nil
 }
 
 // Tool execution methods
@@ -702,7 +970,7 @@ func (s *MCPServer) executeGmailSendEmail(ctx context.Context, token string, arg
 	}
 
 	// Debug logging for result
-	log.Printf("[MCP] Gmail send_email workflow result: %+v", result)
+	log.Printf("[MCP] Gmail send_email workflow result: %+v", workflow.RedactExecution(result))
 
 	// Extract result from the step
 	stepResult, exists := result.StepResults["gmail_send_email"]
@@ -724,6 +992,10 @@ func (s *MCPServer) executeGmailSendEmail(ctx context.Context, token string, arg
 			if stepResult.Error.Details != "" {
 				errorMsg += ": " + stepResult.Error.Details
 			}
+			// Lead with the proxy error code so clients can classify the failure
+			if stepResult.Error.Code != "" {
+				errorMsg = stepResult.Error.Code + ": " + errorMsg
+			}
 		}
 		log.Printf("[MCP] Gmail send_email step FAILED: %s", errorMsg)
 		return ToolResult{
@@ -744,13 +1016,7 @@ func (s *MCPServer) executeGmailSendEmail(ctx context.Context, token string, arg
 	}
 
 	log.Printf("[MCP] Gmail send_email workflow SUCCESS: Message ID = %s", messageID)
-	return ToolResult{
-		Content: []ToolContent{{
-			Type: "text",
-			Text: fmt.Sprintf("Email sent successfully. Message ID: %s", messageID),
-		}},
-		IsError: false,
-	}, nil
+	return successToolResult(fmt.Sprintf("Email sent successfully. Message ID: %s", messageID), stepResult.Data), nil
 }
 
 func (s *MCPServer) executeDocsCreateFromTemplate(ctx context.Context, token string, args map[string]interface{}) (ToolResult, error) {
@@ -776,13 +1042,7 @@ func (s *MCPServer) executeDocsCreateFromTemplate(ctx context.Context, token str
 		}, nil
 	}
 
-	return ToolResult{
-		Content: []ToolContent{{
-			Type: "text",
-			Text: fmt.Sprintf("Document created successfully. Document ID: %v", response.Data),
-		}},
-		IsError: false,
-	}, nil
+	return successToolResult(fmt.Sprintf("Document created successfully. Document ID: %v", response.Data["document_id"]), response.Data), nil
 }
 
 func (s *MCPServer) executeDriveShareDocument(ctx context.Context, token string, args map[string]interface{}) (ToolResult, error) {
@@ -808,13 +1068,7 @@ func (s *MCPServer) executeDriveShareDocument(ctx context.Context, token string,
 		}, nil
 	}
 
-	return ToolResult{
-		Content: []ToolContent{{
-			Type: "text",
-			Text: fmt.Sprintf("Document shared successfully: %v", response.Data),
-		}},
-		IsError: false,
-	}, nil
+	return successToolResult(summarizeToolData("drive", "share_file", response.Data), response.Data), nil
 }
 
 func (s *MCPServer) executeCalendarCreateReminder(ctx context.Context, token string, args map[string]interface{}) (ToolResult, error) {
@@ -841,13 +1095,7 @@ func (s *MCPServer) executeCalendarCreateReminder(ctx context.Context, token str
 		}, nil
 	}
 
-	return ToolResult{
-		Content: []ToolContent{{
-			Type: "text",
-			Text: fmt.Sprintf("Calendar event created successfully: %v", response.Data),
-		}},
-		IsError: false,
-	}, nil
+	return successToolResult(summarizeToolData("calendar", "create_event", response.Data), response.Data), nil
 }
 
 // Public REST API methods for external access
@@ -0,0 +1,57 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxSummaryValueLength bounds each value quoted in a tool result's text summary
+const maxSummaryValueLength = 80
+
+// successToolResult returns a tool result with the step's data as structuredContent, so clients
+// read outputs like document_id without parsing text. The content holds a human summary and,
+// for clients without structured content support, the data serialized as JSON text.
+func successToolResult(summary string, data map[string]interface{}) ToolResult {
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+	content := []ToolContent{{Type: "text", Text: summary}}
+	if serialized, err := json.Marshal(data); err == nil {
+		content = append(content, ToolContent{Type: "text", Text: string(serialized)})
+	}
+	return ToolResult{
+		Content:           content,
+		StructuredContent: data,
+		IsError:           false,
+	}
+}
+
+// summarizeToolData describes a step's scalar outputs in one line, in field order; nested
+// values are left to the structured content and long values are shortened to whole characters
+func summarizeToolData(service, function string, data map[string]interface{}) string {
+	fields := make([]string, 0, len(data))
+	for field, value := range data {
+		switch value.(type) {
+		case map[string]interface{}, []interface{}, nil:
+			continue
+		}
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	if len(fields) == 0 {
+		return fmt.Sprintf("%s.%s executed successfully", service, function)
+	}
+	parts := make([]string, 0, len(fields))
+	for _, field := range fields {
+		value := fmt.Sprint(data[field])
+		if utf8.RuneCountInString(value) > maxSummaryValueLength {
+			value = string([]rune(value)[:maxSummaryValueLength]) + "..."
+		}
+		parts = append(parts, fmt.Sprintf("%s: %s", field, value))
+	}
+	return fmt.Sprintf("%s.%s executed successfully. %s", service, function, strings.Join(parts, ", "))
+}
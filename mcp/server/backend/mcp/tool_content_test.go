@@ -0,0 +1,58 @@
+package mcp
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// TestSummarizeToolData verifies scalar outputs are listed in field order and long values
+// are cut at a character boundary
+func TestSummarizeToolData(t *testing.T) {
+	summary := summarizeToolData("docs", "create_document", map[string]interface{}{
+		"title":       "Report",
+		"document_id": "doc_1",
+		"sections":    []interface{}{"a"},
+		"owner":       map[string]interface{}{"email": "a@example.com"},
+	})
+	if summary != "docs.create_document executed successfully. document_id: doc_1, title: Report" {
+		t.Errorf("Unexpected summary %q", summary)
+	}
+
+	if summary := summarizeToolData("drive", "list_files", nil); summary != "drive.list_files executed successfully" {
+		t.Errorf("Unexpected summary without outputs %q", summary)
+	}
+
+	long := strings.Repeat("é", 79) + "日本語"
+	summary = summarizeToolData("gmail", "get_message", map[string]interface{}{"subject": long})
+	if !utf8.ValidString(summary) {
+		t.Fatalf("Expected valid UTF-8, got %q", summary)
+	}
+	if want := "subject: " + strings.Repeat("é", 79) + "日..."; !strings.HasSuffix(summary, want) {
+		t.Errorf("Expected the value cut after 80 characters, got %q", summary)
+	}
+}
+
+// TestSuccessToolResult verifies the outputs are returned as structuredContent and as JSON text
+func TestSuccessToolResult(t *testing.T) {
+	data := map[string]interface{}{"document_id": "doc_1"}
+	result := successToolResult("created", data)
+
+	if !reflect.DeepEqual(result.StructuredContent, data) || result.IsError {
+		t.Errorf("Expected the data as structured content, got %+v", result)
+	}
+	if len(result.Content) != 2 || result.Content[0].Text != "created" {
+		t.Fatalf("Expected the summary followed by the JSON text, got %+v", result.Content)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal([]byte(result.Content[1].Text), &decoded); err != nil || !reflect.DeepEqual(decoded, data) {
+		t.Errorf("Expected the data serialized as JSON text, got %q", result.Content[1].Text)
+	}
+	for _, item := range result.Content {
+		if item.Type != "text" {
+			t.Errorf("Expected only standard text content, got type %q", item.Type)
+		}
+	}
+}
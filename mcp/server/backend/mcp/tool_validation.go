@@ -0,0 +1,152 @@
+package mcp
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// ValidateToolArguments checks a tool call's arguments against the tool's InputSchema
+// (required fields, types and enums) and returns every problem found. Tools without a
+// listed schema are not checked and fail downstream as before.
+func (s *MCPServer) ValidateToolArguments(toolName string, arguments map[string]interface{}) []string {
+	for _, tool := range s.getAvailableTools() {
+		if tool.Name == toolName {
+			schema, _ := tool.InputSchema.(map[string]interface{})
+			return validateObject(schema, arguments, "")
+		}
+	}
+	return nil
+}
+
+// validateObject validates an object value against an object schema; path prefixes field names
+func validateObject(schema map[string]interface{}, value map[string]interface{}, path string) []string {
+	var problems []string
+	for _, field := range schemaStrings(schema["required"]) {
+		if argument, ok := value[field]; !ok || argument == nil {
+			problems = append(problems, fmt.Sprintf("%s%s is required", path, field))
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	fields := make([]string, 0, len(value))
+	for field := range value {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		propertySchema, ok := properties[field].(map[string]interface{})
+		if !ok || value[field] == nil {
+			continue
+		}
+		problems = append(problems, validateValue(propertySchema, value[field], path+field)...)
+	}
+	return problems
+}
+
+// validateValue validates one argument against its property schema
+func validateValue(schema map[string]interface{}, value interface{}, path string) []string {
+	expected, _ := schema["type"].(string)
+	if expected != "" && !matchesType(expected, value) {
+		return []string{fmt.Sprintf("%s must be %s, got %s", path, articleType(expected), jsonType(value))}
+	}
+
+	if enum := schemaStrings(schema["enum"]); len(enum) > 0 {
+		if text, ok := value.(string); !ok || !containsString(enum, text) {
+			return []string{fmt.Sprintf("%s must be one of %s, got %v", path, strings.Join(enum, ", "), value)}
+		}
+	}
+
+	var problems []string
+	switch typed := value.(type) {
+	case []interface{}:
+		if itemSchema, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range typed {
+				problems = append(problems, validateValue(itemSchema, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	case map[string]interface{}:
+		if _, ok := schema["properties"]; ok {
+			problems = append(problems, validateObject(schema, typed, path+".")...)
+		}
+	}
+	return problems
+}
+
+// matchesType reports whether a decoded JSON value has the given JSON Schema type
+func matchesType(expected string, value interface{}) bool {
+	switch expected {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		number, ok := value.(float64)
+		return ok && number == math.Trunc(number)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	}
+	return true
+}
+
+// jsonType names the JSON type of a decoded value for error messages
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case string:
+		return "a string"
+	case float64:
+		return "a number"
+	case bool:
+		return "a boolean"
+	case []interface{}:
+		return "an array"
+	case map[string]interface{}:
+		return "an object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// articleType prefixes a JSON Schema type with its article, e.g. "an array"
+func articleType(schemaType string) string {
+	if strings.ContainsAny(schemaType[:1], "aeiou") {
+		return "an " + schemaType
+	}
+	return "a " + schemaType
+}
+
+// schemaStrings reads a schema's string list, declared in Go or decoded from JSON
+func schemaStrings(value interface{}) []string {
+	switch typed := value.(type) {
+	case []string:
+		return typed
+	case []interface{}:
+		values := make([]string, 0, len(typed))
+		for _, item := range typed {
+			if text, ok := item.(string); ok {
+				values = append(values, text)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package mcp
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestValidateObject verifies required fields, types, enums and nested schemas are checked
+// and every problem is reported
+func TestValidateObject(t *testing.T) {
+	schema := map[string]interface{}{
+		"type":     "object",
+		"required": []string{"to", "subject"},
+		"properties": map[string]interface{}{
+			"to":          map[string]interface{}{"type": "string"},
+			"subject":     map[string]interface{}{"type": "string"},
+			"max_results": map[string]interface{}{"type": "integer"},
+			"priority":    map[string]interface{}{"type": "string", "enum": []interface{}{"low", "high"}},
+			"label_ids":   map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+			"options": map[string]interface{}{
+				"type":       "object",
+				"required":   []string{"mode"},
+				"properties": map[string]interface{}{"mode": map[string]interface{}{"type": "string"}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		arguments map[string]interface{}
+		want      []string
+	}{
+		{
+			name:      "valid",
+			arguments: map[string]interface{}{"to": "a@example.com", "subject": "Hi", "max_results": float64(5), "priority": "low", "label_ids": []interface{}{"INBOX"}, "options": map[string]interface{}{"mode": "fast"}, "extra": true},
+		},
+		{
+			name:      "missing and null required fields",
+			arguments: map[string]interface{}{"subject": nil},
+			want:      []string{"to is required", "subject is required"},
+		},
+		{
+			name:      "wrong types",
+			arguments: map[string]interface{}{"to": float64(1), "subject": "Hi", "max_results": float64(2.5), "options": "fast"},
+			want:      []string{"max_results must be an integer, got a number", "options must be an object, got a string", "to must be a string, got a number"},
+		},
+		{
+			name:      "enum and array items",
+			arguments: map[string]interface{}{"to": "a", "subject": "b", "priority": "urgent", "label_ids": []interface{}{"INBOX", true}},
+			want:      []string{"label_ids[1] must be a string, got a boolean", "priority must be one of low, high, got urgent"},
+		},
+		{
+			name:      "nested object",
+			arguments: map[string]interface{}{"to": "a", "subject": "b", "options": map[string]interface{}{"mode": false}},
+			want:      []string{"options.mode must be a string, got a boolean"},
+		},
+		{
+			name:      "nested required field",
+			arguments: map[string]interface{}{"to": "a", "subject": "b", "options": map[string]interface{}{}},
+			want:      []string{"options.mode is required"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validateObject(schema, tt.arguments, ""); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -37,6 +37,20 @@ type ServerCapabilities struct {
 	Resources *ResourceCapability `json:"resources,omitempty"`
 	Tools     *ToolCapability     `json:"tools,omitempty"`
 	Prompts   *PromptCapability   `json:"prompts,omitempty"`
+	Features  *FeatureCapability  `json:"features,omitempty"`
+}
+
+// FeatureCapability advertises optional server features (or, from a client,
+// the features it is able to handle)
+type FeatureCapability struct {
+	WorkflowExecute bool `json:"workflow_execute"`
+}
+
+// Intersect returns the features enabled on both sides
+func (f FeatureCapability) Intersect(other FeatureCapability) FeatureCapability {
+	return FeatureCapability{
+		WorkflowExecute: f.WorkflowExecute && other.WorkflowExecute,
+	}
 }
 
 // ResourceCapability defines resource handling capabilities
@@ -66,6 +80,7 @@ type InitializeRequest struct {
 type ClientCapabilities struct {
 	Roots    *RootCapability    `json:"roots,omitempty"`
 	Sampling *SamplingCapability `json:"sampling,omitempty"`
+	Features *FeatureCapability  `json:"features,omitempty"`
 }
 
 // RootCapability defines root handling capabilities
@@ -151,8 +166,9 @@ type CallToolRequest struct {
 
 // ToolResult represents the result of calling a tool
 type ToolResult struct {
-	Content []ToolContent `json:"content"`
-	IsError bool          `json:"isError,omitempty"`
+	Content           []ToolContent          `json:"content"`
+	StructuredContent map[string]interface{} `json:"structuredContent,omitempty"` // the step's outputs, e.g. document_id
+	IsError           bool                   `json:"isError,omitempty"`
 }
 
 // ToolContent represents content returned by a tool
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// exchangeOAuthCode exchanges an authorization code for a token, retrying transient failures
+// (network errors, Google 5xx and 429 responses) with exponential backoff. Rejections such as
+// invalid_grant or access_denied are returned at once: retrying can't fix them. A code that
+// Google consumed before a network error fails the retry with invalid_grant.
+// OAUTH_EXCHANGE_ATTEMPTS and OAUTH_EXCHANGE_BACKOFF_MS tune the retries.
+func exchangeOAuthCode(ctx context.Context, oauthConfig *oauth2.Config, code string) (*oauth2.Token, error) {
+	attempts := int(getEnvInt64OrDefault("OAUTH_EXCHANGE_ATTEMPTS", 3))
+	if attempts < 1 {
+		attempts = 1
+	}
+	backoff := time.Duration(getEnvInt64OrDefault("OAUTH_EXCHANGE_BACKOFF_MS", 500)) * time.Millisecond
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		var token *oauth2.Token
+		token, err = oauthConfig.Exchange(ctx, code)
+		if err == nil {
+			if attempt > 1 {
+				log.Printf("OAuth token exchange succeeded on attempt %d/%d", attempt, attempts)
+			}
+			return token, nil
+		}
+		if !isTransientExchangeError(err) {
+			log.Printf("OAuth token exchange rejected, not retrying: %v", err)
+			return nil, err
+		}
+		log.Printf("WARNING: OAuth token exchange attempt %d/%d failed with a transient error: %v", attempt, attempts, err)
+		if attempt == attempts {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("token exchange cancelled: %w", ctx.Err())
+		case <-time.After(backoff << (attempt - 1)):
+		}
+	}
+	return nil, fmt.Errorf("token exchange failed after %d attempts: %w", attempts, err)
+}
+
+// isTransientExchangeError reports whether a token exchange failure may succeed when retried
+func isTransientExchangeError(err error) bool {
+	var retrieveErr *oauth2.RetrieveError
+	if errors.As(err, &retrieveErr) {
+		if retrieveErr.Response == nil {
+			return false
+		}
+		status := retrieveErr.Response.StatusCode
+		return status >= http.StatusInternalServerError || status == http.StatusTooManyRequests
+	}
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+// newExchangeTestConfig returns an OAuth config whose token endpoint answers with statuses in
+// order, then with a token, and the number of requests it served
+func newExchangeTestConfig(t *testing.T, statuses ...int) (*oauth2.Config, *int) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests <= len(statuses) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(statuses[requests-1])
+			fmt.Fprint(w, `{"error":"server_error"}`)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token":"ya29.token","token_type":"Bearer","expires_in":3600}`)
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("OAUTH_EXCHANGE_ATTEMPTS", "3")
+	t.Setenv("OAUTH_EXCHANGE_BACKOFF_MS", "1")
+	return &oauth2.Config{ClientID: "client", Endpoint: oauth2.Endpoint{TokenURL: server.URL, AuthStyle: oauth2.AuthStyleInParams}}, &requests
+}
+
+// TestExchangeOAuthCode verifies transient failures are retried and rejections are not
+func TestExchangeOAuthCode(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		wantErr      bool
+		wantRequests int
+	}{
+		{"first attempt", nil, false, 1},
+		{"retried after 503 and 429", []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}, false, 3},
+		{"gives up after the attempts", []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway}, true, 3},
+		{"invalid_grant not retried", []int{http.StatusBadRequest}, true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, requests := newExchangeTestConfig(t, tt.statuses...)
+			token, err := exchangeOAuthCode(context.Background(), config, "code")
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("Expected error %t, got %v", tt.wantErr, err)
+			}
+			if !tt.wantErr && token.AccessToken != "ya29.token" {
+				t.Errorf("Expected the exchanged token, got %+v", token)
+			}
+			if *requests != tt.wantRequests {
+				t.Errorf("Expected %d requests, got %d", tt.wantRequests, *requests)
+			}
+		})
+	}
+}
+
+// TestIsTransientExchangeError verifies which exchange failures are worth retrying
+func TestIsTransientExchangeError(t *testing.T) {
+	retrieveError := func(status int) error {
+		return &oauth2.RetrieveError{Response: &http.Response{StatusCode: status}}
+	}
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"server error", retrieveError(http.StatusInternalServerError), true},
+		{"rate limited", retrieveError(http.StatusTooManyRequests), true},
+		{"invalid grant", retrieveError(http.StatusBadRequest), false},
+		{"no response", &oauth2.RetrieveError{}, false},
+		{"network error", fmt.Errorf("post: %w", &net.OpError{Op: "dial", Err: errors.New("connection refused")}), true},
+		{"truncated response", fmt.Errorf("read: %w", io.ErrUnexpectedEOF), true},
+		{"canceled", fmt.Errorf("post: %w", context.Canceled), false},
+		{"other", errors.New("invalid client configuration"), false},
+	}
+	for _, tt := range tests {
+		if got := isTransientExchangeError(tt.err); got != tt.want {
+			t.Errorf("%s: expected %t, got %t", tt.name, tt.want, got)
+		}
+	}
+}
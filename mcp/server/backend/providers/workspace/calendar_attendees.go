@@ -0,0 +1,73 @@
+package workspace
+
+import (
+	"google.golang.org/api/calendar/v3"
+)
+
+// Attendee response statuses reported by Google Calendar
+const (
+	attendeeNeedsAction = "needsAction"
+	attendeeDeclined    = "declined"
+	attendeeTentative   = "tentative"
+	attendeeAccepted    = "accepted"
+)
+
+// calendarSendUpdates maps the send_notifications payload flag to the Calendar API's
+// sendUpdates: "all" emails the attendees, "none" (the default) doesn't
+func calendarSendUpdates(payload map[string]interface{}) string {
+	switch v := payload["send_notifications"].(type) {
+	case bool:
+		if v {
+			return "all"
+		}
+	case string:
+		if v == "true" {
+			return "all"
+		}
+	}
+	return "none"
+}
+
+// attendeeResponses reports each attendee's RSVP, plus the count per response status and the
+// attendees who haven't responded yet (the organizer and optional attendees are left out of
+// non_responders), so conditional steps can follow up with them
+func attendeeResponses(attendees []*calendar.EventAttendee) map[string]interface{} {
+	list := make([]map[string]interface{}, 0, len(attendees))
+	counts := map[string]interface{}{
+		attendeeAccepted:    0,
+		attendeeDeclined:    0,
+		attendeeTentative:   0,
+		attendeeNeedsAction: 0,
+	}
+	nonResponders := make([]string, 0)
+
+	for _, attendee := range attendees {
+		if attendee == nil || attendee.Resource {
+			continue
+		}
+		status := attendee.ResponseStatus
+		if status == "" {
+			status = attendeeNeedsAction
+		}
+		list = append(list, map[string]interface{}{
+			"email":           attendee.Email,
+			"display_name":    attendee.DisplayName,
+			"response_status": status,
+			"optional":        attendee.Optional,
+			"organizer":       attendee.Organizer,
+		})
+		if count, ok := counts[status].(int); ok {
+			counts[status] = count + 1
+		}
+		if status == attendeeNeedsAction && !attendee.Organizer && !attendee.Optional {
+			nonResponders = append(nonResponders, attendee.Email)
+		}
+	}
+
+	return map[string]interface{}{
+		"attendees":           list,
+		"response_counts":     counts,
+		"non_responders":      nonResponders,
+		"non_responder_count": len(nonResponders),
+	}
+}
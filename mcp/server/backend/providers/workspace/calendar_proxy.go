@@ -2,12 +2,18 @@ package workspace
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"net/http"
+	"regexp"
 	"time"
 
 	"github.com/dimitar-trifonov/sohoaas/service-proxies/workflow"
 	"golang.org/x/oauth2"
 	"google.golang.org/api/calendar/v3"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/option"
 )
 
@@ -74,21 +80,27 @@ func (p *CalendarProxy) Execute(ctx context.Context, function string, token stri
 
 	// Execute the function
 	var result map[string]interface{}
-	var execErr error
-
-	switch function {
-	case CalendarFunctionCreateEvent:
-		result, execErr = p.createEvent(ctx, service, payload)
-	case CalendarFunctionGetEvent:
-		result, execErr = p.getEvent(ctx, service, payload)
-	case CalendarFunctionListEvents:
-		result, execErr = p.listEvents(ctx, service, payload)
-	case CalendarFunctionUpdateEvent:
-		result, execErr = p.updateEvent(ctx, service, payload)
-	case CalendarFunctionDeleteEvent:
-		result, execErr = p.deleteEvent(ctx, service, payload)
-	default:
-		execErr = fmt.Errorf("function not implemented: %s", function)
+	execErr := withRateLimitBackoff(ctx, ServiceTypeCalendar, function, func() error {
+		var err error
+		switch function {
+		case CalendarFunctionCreateEvent:
+			result, err = p.createEvent(ctx, service, payload)
+		case CalendarFunctionGetEvent:
+			result, err = p.getEvent(ctx, service, payload)
+		case CalendarFunctionListEvents:
+			result, err = p.listEvents(ctx, service, payload)
+		case CalendarFunctionUpdateEvent:
+			result, err = p.updateEvent(ctx, service, payload)
+		case CalendarFunctionDeleteEvent:
+			result, err = p.deleteEvent(ctx, service, payload)
+		default:
+			err = fmt.Errorf("function not implemented: %s", function)
+		}
+		return err
+	})
+
+	if rlErr := asRateLimitError(ServiceTypeCalendar, execErr); rlErr != nil {
+		return rateLimitedResponse(rlErr), rlErr
 	}
 
 	if execErr != nil {
@@ -153,13 +165,15 @@ func (p *CalendarProxy) GetServiceMetadata() ServiceMetadata {
 			CalendarFunctionCreateEvent: {
 				Name:        CalendarFunctionCreateEvent,
 				DisplayName: "Create Event",
-				Description: "Create a new calendar event",
+				Description: "Create a new calendar event; with an event_id, re-running updates the event instead of duplicating it. send_notifications emails the attendees an invitation",
 				ExamplePayload: map[string]interface{}{
-					"title":       "Meeting with client",
-					"description": "Discuss project requirements",
-					"startTime":   "2025-07-30T14:00:00Z",
-					"endTime":     "2025-07-30T15:00:00Z",
-					"attendees":   []string{"client@example.com"},
+					"title":              "Meeting with client",
+					"description":        "Discuss project requirements",
+					"startTime":          "2025-07-30T14:00:00Z",
+					"endTime":            "2025-07-30T15:00:00Z",
+					"attendees":          []string{"client@example.com"},
+					"event_id":           "daily-standup-2025-07-30",
+					"send_notifications": true,
 				},
 				RequiredFields: []string{"title", "startTime", "endTime"},
 				OutputSchema: &ResponseSchema{
@@ -202,8 +216,32 @@ func (p *CalendarProxy) GetServiceMetadata() ServiceMetadata {
 							Type:        "string",
 							Description: "ISO timestamp when updated",
 						},
+						"operation": {
+							Type:        "string",
+							Description: "created, or updated when an event with the given event_id already existed",
+						},
+						"notifications_sent": {
+							Type:        "boolean",
+							Description: "Whether attendees were emailed, as requested with send_notifications",
+						},
+						"attendees": {
+							Type:        "array",
+							Description: "Attendees, each with email, display_name, response_status (needsAction, accepted, declined or tentative), optional and organizer",
+						},
+						"response_counts": {
+							Type:        "object",
+							Description: "Number of attendees per response status",
+						},
+						"non_responders": {
+							Type:        "array",
+							Description: "Emails of required attendees who haven't responded yet",
+						},
+						"non_responder_count": {
+							Type:        "number",
+							Description: "Number of non_responders",
+						},
 					},
-					Required: []string{"event_id", "title", "start_time", "end_time", "status"},
+					Required: []string{"event_id", "title", "start_time", "end_time", "status", "operation"},
 				},
 				ErrorSchema: &ResponseSchema{
 					Type:        "object",
@@ -228,11 +266,58 @@ func (p *CalendarProxy) GetServiceMetadata() ServiceMetadata {
 			CalendarFunctionGetEvent: {
 				Name:        CalendarFunctionGetEvent,
 				DisplayName: "Get Event",
-				Description: "Retrieve details of a specific calendar event",
+				Description: "Retrieve a calendar event with its attendees' current responses (RSVPs)",
 				ExamplePayload: map[string]interface{}{
 					"event_id": "event123456",
 				},
 				RequiredFields: []string{"event_id"},
+				OutputSchema: &ResponseSchema{
+					Type:        "object",
+					Description: "Calendar get event response",
+					Properties: map[string]PropertySchema{
+						"event_id": {
+							Type:        "string",
+							Description: "Google Calendar event ID",
+						},
+						"html_link": {
+							Type:        "string",
+							Description: "Event HTML link",
+						},
+						"title": {
+							Type:        "string",
+							Description: "Event title",
+						},
+						"start_time": {
+							Type:        "string",
+							Description: "Event start time",
+						},
+						"end_time": {
+							Type:        "string",
+							Description: "Event end time",
+						},
+						"status": {
+							Type:        "string",
+							Description: "Event status",
+						},
+						"attendees": {
+							Type:        "array",
+							Description: "Attendees, each with email, display_name, response_status (needsAction, accepted, declined or tentative), optional and organizer",
+						},
+						"response_counts": {
+							Type:        "object",
+							Description: "Number of attendees per response status",
+						},
+						"non_responders": {
+							Type:        "array",
+							Description: "Emails of required attendees who haven't responded yet",
+						},
+						"non_responder_count": {
+							Type:        "number",
+							Description: "Number of non_responders",
+						},
+					},
+					Required: []string{"event_id", "title", "status", "attendees", "non_responders", "non_responder_count"},
+				},
 			},
 			CalendarFunctionListEvents: {
 				Name:        CalendarFunctionListEvents,
@@ -353,29 +438,67 @@ func (p *CalendarProxy) createEvent(ctx context.Context, service *calendar.Servi
 		}
 	}
 
-	createdEvent, err := service.Events.Insert("primary", event).Do()
+	// A client-supplied id makes re-runs idempotent: inserting it again conflicts, and the
+	// existing event is updated instead
+	operation := "created"
+	if requestedID, ok := payload["event_id"].(string); ok && requestedID != "" {
+		event.Id = calendarEventID(requestedID)
+		fmt.Printf("[Calendar] createEvent - Using event ID %s\n", event.Id)
+	}
+
+	// Attendees are only emailed an invitation when send_notifications is set
+	sendUpdates := calendarSendUpdates(payload)
+
+	createdEvent, err := service.Events.Insert("primary", event).SendUpdates(sendUpdates).Do()
+	var apiErr *googleapi.Error
+	if err != nil && event.Id != "" && errors.As(err, &apiErr) && apiErr.Code == http.StatusConflict {
+		fmt.Printf("[Calendar] createEvent - Event %s exists, updating it\n", event.Id)
+		event.Status = "confirmed" // restores the event if it was deleted since
+		createdEvent, err = service.Events.Update("primary", event.Id, event).SendUpdates(sendUpdates).Do()
+		operation = "updated"
+	}
 	if err != nil {
 		fmt.Printf("[Calendar] createEvent - Calendar API Error: %v\n", err)
 		return nil, fmt.Errorf("failed to create event: %w", err)
 	}
 
-	fmt.Printf("[Calendar] createEvent - Success! Event created: %s\n", createdEvent.Id)
+	fmt.Printf("[Calendar] createEvent - Success! Event %s: %s (notifications: %s)\n", operation, createdEvent.Id, sendUpdates)
+
+	result := map[string]interface{}{
+		"event_id":           createdEvent.Id,
+		"html_link":          createdEvent.HtmlLink,
+		"title":              createdEvent.Summary,
+		"description":        createdEvent.Description,
+		"start_time":         createdEvent.Start.DateTime,
+		"end_time":           createdEvent.End.DateTime,
+		"status":             createdEvent.Status,
+		"created_at":         createdEvent.Created,
+		"updated_at":         createdEvent.Updated,
+		"operation":          operation,
+		"notifications_sent": sendUpdates == "all",
+	}
+	for field, value := range attendeeResponses(createdEvent.Attendees) {
+		result[field] = value
+	}
+	return result, nil
+}
 
-	return map[string]interface{}{
-		"event_id":    createdEvent.Id,
-		"html_link":   createdEvent.HtmlLink,
-		"title":       createdEvent.Summary,
-		"description": createdEvent.Description,
-		"start_time":  createdEvent.Start.DateTime,
-		"end_time":    createdEvent.End.DateTime,
-		"status":      createdEvent.Status,
-		"created_at":  createdEvent.Created,
-		"updated_at":  createdEvent.Updated,
-	}, nil
+// Google Calendar event IDs use base32hex characters (0-9, a-v) and are 5 to 1024 characters long
+var calendarEventIDPattern = regexp.MustCompile(`^[0-9a-v]+$`)
+
+// calendarEventID returns a client-supplied event ID usable with Google Calendar: valid IDs are
+// kept, any other string (e.g. "standup-${user.date}") is hashed into a stable valid ID
+func calendarEventID(requested string) string {
+	if len(requested) >= 5 && len(requested) <= 1024 && calendarEventIDPattern.MatchString(requested) {
+		return requested
+	}
+	sum := sha256.Sum256([]byte(requested))
+	return hex.EncodeToString(sum[:])
 }
 
 func (p *CalendarProxy) getEvent(ctx context.Context, service *calendar.Service, payload map[string]interface{}) (map[string]interface{}, error) {
-	eventID := payload["event_id"].(string)
+	// The same id create_event was given finds the event, hashed or not
+	eventID := calendarEventID(payload["event_id"].(string))
 
 	// Debug logging
 	fmt.Printf("[Calendar] getEvent - Event ID: %s\n", eventID)
@@ -389,7 +512,7 @@ func (p *CalendarProxy) getEvent(ctx context.Context, service *calendar.Service,
 
 	fmt.Printf("[Calendar] getEvent - Success! Event retrieved: %s\n", event.Id)
 
-	return map[string]interface{}{
+	result := map[string]interface{}{
 		"event_id":    event.Id,
 		"html_link":   event.HtmlLink,
 		"title":       event.Summary,
@@ -399,8 +522,11 @@ func (p *CalendarProxy) getEvent(ctx context.Context, service *calendar.Service,
 		"status":      event.Status,
 		"created_at":  event.Created,
 		"updated_at":  event.Updated,
-		"attendees":   event.Attendees,
-	}, nil
+	}
+	for field, value := range attendeeResponses(event.Attendees) {
+		result[field] = value
+	}
+	return result, nil
 }
 
 func (p *CalendarProxy) listEvents(ctx context.Context, service *calendar.Service, payload map[string]interface{}) (map[string]interface{}, error) {
@@ -35,10 +35,9 @@ func (p *DocsProxy) Execute(ctx context.Context, function string, token string,
 	log.Printf("[Docs] [%s] Function: %s\n", requestID, function)
 	log.Printf("[Docs] [%s] Request Time: %s\n", requestID, startTime.Format(time.RFC3339Nano))
 	log.Printf("[Docs] [%s] OAuth Token Length: %d characters\n", requestID, len(token))
-	log.Printf("[Docs] [%s] OAuth Token Prefix: %s...\n", requestID, token[:min(20, len(token))])
 	
 	// Log payload with JSON formatting
-	payloadJSON, _ := json.MarshalIndent(payload, "", "  ")
+	payloadJSON, _ := json.MarshalIndent(workflow.Redact(payload), "", "  ")
 	log.Printf("[Docs] [%s] Request Payload:\n%s\n", requestID, string(payloadJSON))
 
 	// Validate function
@@ -91,27 +90,33 @@ func (p *DocsProxy) Execute(ctx context.Context, function string, token string,
 	functionStartTime := time.Now()
 	
 	var result map[string]interface{}
-	var execErr error
-
-	switch function {
-	case DocsFunctionCreateDocument:
-		result, execErr = p.createDocumentWithLogging(ctx, service, payload, requestID)
-	case DocsFunctionGetDocument:
-		result, execErr = p.getDocumentWithLogging(ctx, service, payload, requestID)
-	case DocsFunctionInsertText:
-		result, execErr = p.insertTextWithLogging(ctx, service, payload, requestID)
-	case DocsFunctionUpdateDocument:
-		result, execErr = p.updateDocumentWithLogging(ctx, service, payload, requestID)
-	case DocsFunctionBatchUpdate:
-		result, execErr = p.batchUpdateWithLogging(ctx, service, payload, requestID)
-	default:
-		execErr = fmt.Errorf("function not implemented: %s", function)
-		log.Printf("[Docs] [%s] ❌ Function not implemented: %s\n", requestID, function)
-	}
+	execErr := withRateLimitBackoff(ctx, ServiceTypeDocs, requestID, func() error {
+		var err error
+		switch function {
+		case DocsFunctionCreateDocument:
+			result, err = p.createDocumentWithLogging(ctx, service, payload, requestID)
+		case DocsFunctionGetDocument:
+			result, err = p.getDocumentWithLogging(ctx, service, payload, requestID)
+		case DocsFunctionInsertText:
+			result, err = p.insertTextWithLogging(ctx, service, payload, requestID)
+		case DocsFunctionUpdateDocument:
+			result, err = p.updateDocumentWithLogging(ctx, service, payload, requestID)
+		case DocsFunctionBatchUpdate:
+			result, err = p.batchUpdateWithLogging(ctx, service, payload, requestID)
+		default:
+			err = fmt.Errorf("function not implemented: %s", function)
+			log.Printf("[Docs] [%s] ❌ Function not implemented: %s\n", requestID, function)
+		}
+		return err
+	})
 
 	functionDuration := time.Since(functionStartTime)
 	totalDuration := time.Since(startTime)
 
+	if rlErr := asRateLimitError(ServiceTypeDocs, execErr); rlErr != nil {
+		return rateLimitedResponse(rlErr), rlErr
+	}
+
 	if execErr != nil {
 		log.Printf("[Docs] [%s] ❌ Function execution FAILED after %v (total: %v): %v\n", requestID, functionDuration, totalDuration, execErr)
 		log.Printf("[Docs] [%s] ========== REQUEST END (FAILED) ==========\n", requestID)
@@ -127,7 +132,7 @@ func (p *DocsProxy) Execute(ctx context.Context, function string, token string,
 	}
 
 	// Log successful response
-	resultJSON, _ := json.MarshalIndent(result, "", "  ")
+	resultJSON, _ := json.MarshalIndent(workflow.Redact(result), "", "  ")
 	log.Printf("[Docs] [%s] ✅ Function executed successfully in %v (total: %v)\n", requestID, functionDuration, totalDuration)
 	log.Printf("[Docs] [%s] Response Data:\n%s\n", requestID, string(resultJSON))
 	log.Printf("[Docs] [%s] ========== REQUEST END (SUCCESS) ==========\n", requestID)
@@ -14,6 +14,9 @@ import (
 	"google.golang.org/api/option"
 )
 
+// driveShareRoles are the roles share_file grants
+var driveShareRoles = []string{"reader", "commenter", "writer"}
+
 // DriveProxy implements WorkspaceProxy for Google Drive service
 type DriveProxy struct {
 	config *oauth2.Config
@@ -72,23 +75,33 @@ func (p *DriveProxy) Execute(ctx context.Context, function string, token string,
 
 	// Execute the function
 	var result map[string]interface{}
-	var execErr error
+	execErr := withRateLimitBackoff(ctx, ServiceTypeDrive, function, func() error {
+		var err error
+		switch function {
+		case DriveFunctionCreateFolder:
+			result, err = p.createFolder(ctx, service, payload)
+		case DriveFunctionUploadFile:
+			result, err = p.uploadFile(ctx, service, payload)
+		case DriveFunctionGetFile:
+			result, err = p.getFile(ctx, service, payload)
+		case DriveFunctionListFiles:
+			result, err = p.listFiles(ctx, service, payload)
+		case DriveFunctionShareFile:
+			result, err = p.shareFile(ctx, service, payload)
+		case DriveFunctionMoveFile:
+			result, err = p.moveFile(ctx, service, payload)
+		case DriveFunctionListPermissions:
+			result, err = p.listPermissions(ctx, service, payload)
+		case DriveFunctionRevokePermission:
+			result, err = p.revokePermission(ctx, service, payload)
+		default:
+			err = fmt.Errorf("function not implemented: %s", function)
+		}
+		return err
+	})
 
-	switch function {
-	case DriveFunctionCreateFolder:
-		result, execErr = p.createFolder(ctx, service, payload)
-	case DriveFunctionUploadFile:
-		result, execErr = p.uploadFile(ctx, service, payload)
-	case DriveFunctionGetFile:
-		result, execErr = p.getFile(ctx, service, payload)
-	case DriveFunctionListFiles:
-		result, execErr = p.listFiles(ctx, service, payload)
-	case DriveFunctionShareFile:
-		result, execErr = p.shareFile(ctx, service, payload)
-	case DriveFunctionMoveFile:
-		result, execErr = p.moveFile(ctx, service, payload)
-	default:
-		execErr = fmt.Errorf("function not implemented: %s", function)
+	if rlErr := asRateLimitError(ServiceTypeDrive, execErr); rlErr != nil {
+		return rateLimitedResponse(rlErr), rlErr
 	}
 
 	if execErr != nil {
@@ -123,6 +136,8 @@ func (p *DriveProxy) GetSupportedFunctions() []string {
 		DriveFunctionListFiles,
 		DriveFunctionShareFile,
 		DriveFunctionMoveFile,
+		DriveFunctionListPermissions,
+		DriveFunctionRevokePermission,
 	}
 }
 
@@ -215,6 +230,16 @@ func (p *DriveProxy) GetServiceMetadata() ServiceMetadata {
 					"role":    "reader",
 				},
 				RequiredFields: []string{"file_id", "email", "role"},
+				InputSchema: &ResponseSchema{
+					Type:        "object",
+					Description: "Share file request",
+					Properties: map[string]PropertySchema{
+						"file_id": {Type: "string", Description: "ID of the file to share"},
+						"email":   {Type: "string", Description: "Email address to share with"},
+						"role":    {Type: "string", Description: "Access role", Enum: driveShareRoles},
+					},
+					Required: []string{"file_id", "email", "role"},
+				},
 			},
 			DriveFunctionMoveFile: {
 				Name:        DriveFunctionMoveFile,
@@ -226,6 +251,47 @@ func (p *DriveProxy) GetServiceMetadata() ServiceMetadata {
 				},
 				RequiredFields: []string{"file_id", "new_parent_id"},
 			},
+			DriveFunctionListPermissions: {
+				Name:        DriveFunctionListPermissions,
+				DisplayName: "List Permissions",
+				Description: "List who has access to a file and their roles",
+				ExamplePayload: map[string]interface{}{
+					"file_id": "1234567890abcdef",
+				},
+				RequiredFields: []string{"file_id"},
+				OutputSchema: &ResponseSchema{
+					Type:        "object",
+					Description: "File permissions response",
+					Properties: map[string]PropertySchema{
+						"file_id":           {Type: "string", Description: "Google Drive file ID"},
+						"permissions":       {Type: "array", Description: "Permissions, each with permission_id, type, role, email, domain, display_name and expiration_time"},
+						"total_permissions": {Type: "integer", Description: "Number of permissions on the file"},
+						"listed_at":         {Type: "string", Description: "ISO timestamp when listed"},
+					},
+					Required: []string{"file_id", "permissions", "total_permissions"},
+				},
+			},
+			DriveFunctionRevokePermission: {
+				Name:        DriveFunctionRevokePermission,
+				DisplayName: "Revoke Permission",
+				Description: "Remove a permission from a file, e.g. to un-share it",
+				ExamplePayload: map[string]interface{}{
+					"file_id":       "1234567890abcdef",
+					"permission_id": "01234567890123456789",
+				},
+				RequiredFields: []string{"file_id", "permission_id"},
+				OutputSchema: &ResponseSchema{
+					Type:        "object",
+					Description: "Permission revocation response",
+					Properties: map[string]PropertySchema{
+						"file_id":       {Type: "string", Description: "Google Drive file ID"},
+						"permission_id": {Type: "string", Description: "Revoked permission ID"},
+						"status":        {Type: "string", Description: "Revocation status"},
+						"revoked_at":    {Type: "string", Description: "ISO timestamp when revoked"},
+					},
+					Required: []string{"file_id", "permission_id", "status"},
+				},
+			},
 		},
 	}
 }
@@ -275,6 +341,9 @@ func (p *DriveProxy) ValidatePayload(function string, payload map[string]interfa
 		if _, ok := payload[PayloadFieldRole]; !ok {
 			return fmt.Errorf("missing required field: %s", PayloadFieldRole)
 		}
+		if role, _ := payload[PayloadFieldRole].(string); !isDriveShareRole(role) {
+			return fmt.Errorf("invalid %s %q, expected one of: %s", PayloadFieldRole, role, strings.Join(driveShareRoles, ", "))
+		}
 	case DriveFunctionMoveFile:
 		if _, ok := payload[PayloadFieldFileID]; !ok {
 			return fmt.Errorf("missing required field: %s", PayloadFieldFileID)
@@ -282,6 +351,17 @@ func (p *DriveProxy) ValidatePayload(function string, payload map[string]interfa
 		if _, ok := payload["new_parent_id"]; !ok {
 			return fmt.Errorf("missing required field: new_parent_id")
 		}
+	case DriveFunctionListPermissions:
+		if _, ok := payload[PayloadFieldFileID]; !ok {
+			return fmt.Errorf("missing required field: %s", PayloadFieldFileID)
+		}
+	case DriveFunctionRevokePermission:
+		if _, ok := payload[PayloadFieldFileID]; !ok {
+			return fmt.Errorf("missing required field: %s", PayloadFieldFileID)
+		}
+		if _, ok := payload["permission_id"]; !ok {
+			return fmt.Errorf("missing required field: permission_id")
+		}
 	}
 	return nil
 }
@@ -530,3 +610,77 @@ func (p *DriveProxy) moveFile(ctx context.Context, service *drive.Service, paylo
 		"moved_at":         time.Now().Format(time.RFC3339),
 	}, nil
 }
+
+func (p *DriveProxy) listPermissions(ctx context.Context, service *drive.Service, payload map[string]interface{}) (map[string]interface{}, error) {
+	fileID := payload[PayloadFieldFileID].(string)
+
+	// Collect every page so audits see all grants
+	permissions := make([]map[string]interface{}, 0)
+	pageToken := ""
+	for {
+		listCall := service.Permissions.List(fileID).
+			SupportsAllDrives(true).
+			Fields("nextPageToken,permissions(id,type,role,emailAddress,domain,displayName,expirationTime)")
+		if pageToken != "" {
+			listCall = listCall.PageToken(pageToken)
+		}
+
+		permissionList, err := listCall.Do()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list permissions: %w", err)
+		}
+
+		for _, permission := range permissionList.Permissions {
+			permissions = append(permissions, map[string]interface{}{
+				"permission_id":   permission.Id,
+				"type":            permission.Type,
+				"role":            permission.Role,
+				"email":           permission.EmailAddress,
+				"domain":          permission.Domain,
+				"display_name":    permission.DisplayName,
+				"expiration_time": permission.ExpirationTime,
+			})
+		}
+
+		pageToken = permissionList.NextPageToken
+		if pageToken == "" {
+			break
+		}
+	}
+
+	return map[string]interface{}{
+		"file_id":           fileID,
+		"permissions":       permissions,
+		"total_permissions": len(permissions),
+		"listed_at":         time.Now().Format(time.RFC3339),
+	}, nil
+}
+
+func (p *DriveProxy) revokePermission(ctx context.Context, service *drive.Service, payload map[string]interface{}) (map[string]interface{}, error) {
+	fileID := payload[PayloadFieldFileID].(string)
+	permissionID, ok := payload["permission_id"].(string)
+	if !ok || permissionID == "" {
+		return nil, fmt.Errorf("permission_id must be a non-empty string")
+	}
+
+	if err := service.Permissions.Delete(fileID, permissionID).SupportsAllDrives(true).Do(); err != nil {
+		return nil, fmt.Errorf("failed to revoke permission %s: %w", permissionID, err)
+	}
+
+	return map[string]interface{}{
+		"file_id":       fileID,
+		"permission_id": permissionID,
+		"status":        "revoked",
+		"revoked_at":    time.Now().Format(time.RFC3339),
+	}, nil
+}
+
+// isDriveShareRole reports whether role is one share_file can grant
+func isDriveShareRole(role string) bool {
+	for _, allowed := range driveShareRoles {
+		if role == allowed {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,189 @@
+package workspace
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"path/filepath"
+	"strings"
+)
+
+// defaultMaxAttachmentBytes caps the decoded size of all attachments of one email (Gmail's limit)
+const defaultMaxAttachmentBytes = 25 << 20
+
+// defaultAttachmentMimeTypes are the attachment types send_message accepts unless configured otherwise
+var defaultAttachmentMimeTypes = []string{
+	"application/pdf",
+	"application/json",
+	"application/msword",
+	"application/vnd.ms-excel",
+	"application/vnd.ms-powerpoint",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation",
+	"application/vnd.oasis.opendocument.text",
+	"application/vnd.oasis.opendocument.spreadsheet",
+	"text/plain",
+	"text/csv",
+	"text/calendar",
+	"image/png",
+	"image/jpeg",
+	"image/gif",
+	"image/webp",
+}
+
+// blockedAttachmentExtensions are executable file types rejected whatever MIME type they claim
+var blockedAttachmentExtensions = map[string]bool{
+	".exe": true, ".msi": true, ".bat": true, ".cmd": true, ".com": true, ".scr": true,
+	".js": true, ".vbs": true, ".ps1": true, ".sh": true, ".jar": true, ".apk": true,
+}
+
+// emailAttachment is a decoded send_message attachment
+type emailAttachment struct {
+	Filename string
+	MimeType string
+	Data     []byte
+}
+
+// SetAttachmentLimits configures send_message's guardrails: the largest total decoded size of
+// an email's attachments and the allowed attachment MIME types. A non-positive size or an empty
+// type list keeps the corresponding default.
+func (p *GmailProxy) SetAttachmentLimits(maxBytes int64, mimeTypes []string) {
+	p.maxAttachmentBytes = defaultMaxAttachmentBytes
+	if maxBytes > 0 {
+		p.maxAttachmentBytes = maxBytes
+	}
+
+	allowed := make(map[string]bool)
+	for _, mimeType := range mimeTypes {
+		if mimeType = strings.ToLower(strings.TrimSpace(mimeType)); mimeType != "" {
+			allowed[mimeType] = true
+		}
+	}
+	if len(allowed) == 0 {
+		for _, mimeType := range defaultAttachmentMimeTypes {
+			allowed[mimeType] = true
+		}
+	}
+	p.allowedAttachmentTypes = allowed
+}
+
+// parseAttachments decodes the optional attachments of a send_message payload, each an object
+// with filename, content (base64 or a data URL) and mime_type (else taken from the data URL or
+// the file extension), and enforces the size limit and MIME type allowlist
+func (p *GmailProxy) parseAttachments(payload map[string]interface{}) ([]emailAttachment, error) {
+	raw, exists := payload[PayloadFieldAttachments]
+	if !exists || raw == nil {
+		return nil, nil
+	}
+	items, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s must be an array of attachment objects", PayloadFieldAttachments)
+	}
+
+	var attachments []emailAttachment
+	var totalBytes int64
+	for i, item := range items {
+		fields, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("attachment %d must be an object with filename and content", i)
+		}
+		filename, _ := fields["filename"].(string)
+		content, _ := fields[PayloadFieldContent].(string)
+		if filename == "" || content == "" {
+			return nil, fmt.Errorf("attachment %d requires filename and content", i)
+		}
+		if blockedAttachmentExtensions[strings.ToLower(filepath.Ext(filename))] {
+			return nil, fmt.Errorf("attachment %q is an executable file type and is not allowed", filename)
+		}
+
+		mimeType, _ := fields["mime_type"].(string)
+		if header, data, isDataURL := strings.Cut(content, ","); isDataURL && strings.HasPrefix(header, "data:") && strings.HasSuffix(header, ";base64") {
+			if mimeType == "" {
+				mimeType = strings.TrimSuffix(strings.TrimPrefix(header, "data:"), ";base64")
+			}
+			content = data
+		}
+		if mimeType == "" {
+			mimeType = mime.TypeByExtension(filepath.Ext(filename))
+		}
+		if mediaType, _, err := mime.ParseMediaType(mimeType); err == nil {
+			mimeType = mediaType
+		} else {
+			mimeType = "application/octet-stream"
+		}
+		if !p.allowedAttachmentTypes[mimeType] {
+			return nil, fmt.Errorf("attachment %q has type %s, which is not allowed", filename, mimeType)
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(content)
+		if err != nil {
+			return nil, fmt.Errorf("attachment %q has invalid base64 content: %w", filename, err)
+		}
+		totalBytes += int64(len(decoded))
+		if totalBytes > p.maxAttachmentBytes {
+			return nil, fmt.Errorf("attachments exceed the %d byte limit", p.maxAttachmentBytes)
+		}
+		attachments = append(attachments, emailAttachment{Filename: filename, MimeType: mimeType, Data: decoded})
+	}
+	return attachments, nil
+}
+
+// createRawMessageWithAttachments builds a base64url multipart/mixed message: the plain text
+// body (or text and HTML alternatives when htmlBody is set) followed by one base64 part per attachment
+func (p *GmailProxy) createRawMessageWithAttachments(to, subject, body, htmlBody string, attachments []emailAttachment) (string, error) {
+	var parts bytes.Buffer
+	writer := multipart.NewWriter(&parts)
+
+	if htmlBody == "" {
+		bodyPart, err := writer.CreatePart(textproto.MIMEHeader{"Content-Type": {"text/plain; charset=UTF-8"}})
+		if err != nil {
+			return "", fmt.Errorf("failed to build message body: %w", err)
+		}
+		bodyPart.Write([]byte(body))
+	} else {
+		boundary := multipart.NewWriter(nil).Boundary()
+		bodyPart, err := writer.CreatePart(textproto.MIMEHeader{"Content-Type": {"multipart/alternative; boundary=" + boundary}})
+		if err != nil {
+			return "", fmt.Errorf("failed to build message body: %w", err)
+		}
+		if err := writeAlternativeBody(bodyPart, boundary, body, htmlBody); err != nil {
+			return "", fmt.Errorf("failed to build message body: %w", err)
+		}
+	}
+
+	for _, attachment := range attachments {
+		attachmentPart, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {mime.FormatMediaType(attachment.MimeType, map[string]string{"name": attachment.Filename})},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename})},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to build attachment %q: %w", attachment.Filename, err)
+		}
+		// Wrap the encoded content at 76 characters per line as MIME requires
+		encoded := base64.StdEncoding.EncodeToString(attachment.Data)
+		for len(encoded) > 76 {
+			attachmentPart.Write([]byte(encoded[:76] + "\r\n"))
+			encoded = encoded[76:]
+		}
+		attachmentPart.Write([]byte(encoded))
+	}
+	if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("failed to build message: %w", err)
+	}
+
+	message := fmt.Sprintf(
+		"From: me\r\n"+
+			"To: %s\r\n"+
+			"Subject: %s\r\n"+
+			"MIME-Version: 1.0\r\n"+
+			"Content-Type: multipart/mixed; boundary=%s\r\n"+
+			"\r\n"+
+			"%s",
+		to, subject, writer.Boundary(), parts.String())
+	return base64.URLEncoding.EncodeToString([]byte(message)), nil
+}
@@ -0,0 +1,258 @@
+package workspace
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// allowedHTMLTags are the formatting elements kept by sanitizeEmailHTML, with the attributes
+// each may carry; other elements are dropped but their text is kept
+var allowedHTMLTags = map[string]map[string]bool{
+	"a":          {"href": true, "title": true},
+	"b":          {},
+	"blockquote": {},
+	"br":         {},
+	"code":       {},
+	"div":        {},
+	"em":         {},
+	"h1":         {},
+	"h2":         {},
+	"h3":         {},
+	"h4":         {},
+	"h5":         {},
+	"h6":         {},
+	"hr":         {},
+	"i":          {},
+	"img":        {"src": true, "alt": true, "width": true, "height": true},
+	"li":         {},
+	"ol":         {},
+	"p":          {},
+	"pre":        {},
+	"span":       {},
+	"strong":     {},
+	"table":      {},
+	"tbody":      {},
+	"td":         {"colspan": true, "rowspan": true},
+	"th":         {"colspan": true, "rowspan": true},
+	"thead":      {},
+	"tr":         {},
+	"u":          {},
+	"ul":         {},
+}
+
+// droppedHTMLElements are removed together with their content; void elements such as embed
+// must not be listed, as they have no end tag to stop skipping at
+var droppedHTMLElements = map[string]bool{
+	"script": true, "style": true, "iframe": true, "object": true,
+	"head": true, "title": true, "template": true, "noscript": true, "svg": true, "math": true,
+}
+
+// voidHTMLElements have no end tag
+var voidHTMLElements = map[string]bool{"br": true, "hr": true, "img": true}
+
+// allowedURLSchemes are the schemes href and src may use; relative URLs are dropped as
+// they have no meaning in an email
+var allowedURLSchemes = map[string]bool{"http": true, "https": true, "mailto": true}
+
+// blockHTMLElements start a new line in the plain text derived from an HTML body
+var blockHTMLElements = map[string]bool{
+	"p": true, "div": true, "br": true, "li": true, "tr": true, "blockquote": true, "pre": true, "hr": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+}
+
+// sanitizeEmailHTML rewrites an HTML body keeping only allowlisted elements and attributes,
+// with http(s) and mailto links only, so workflow parameters can't inject scripts, styles or
+// event handlers. Unclosed elements are closed and stray end tags dropped.
+func sanitizeEmailHTML(input string) string {
+	var out strings.Builder
+	var open []string
+	skipDepth := 0
+
+	tokenizer := html.NewTokenizer(strings.NewReader(input))
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			break
+		}
+		token := tokenizer.Token()
+		name := token.Data
+
+		switch tokenType {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if droppedHTMLElements[name] {
+				if tokenType == html.StartTagToken {
+					skipDepth++
+				}
+				continue
+			}
+			attributes, allowed := allowedHTMLTags[name]
+			if skipDepth > 0 || !allowed {
+				continue
+			}
+			out.WriteString("<" + name)
+			for _, attr := range token.Attr {
+				if !attributes[attr.Key] || attr.Namespace != "" {
+					continue
+				}
+				if (attr.Key == "href" || attr.Key == "src") && !isAllowedHTMLURL(attr.Val) {
+					continue
+				}
+				fmt.Fprintf(&out, ` %s="%s"`, attr.Key, html.EscapeString(attr.Val))
+			}
+			out.WriteString(">")
+			if !voidHTMLElements[name] {
+				open = append(open, name)
+			}
+		case html.EndTagToken:
+			if droppedHTMLElements[name] {
+				if skipDepth > 0 {
+					skipDepth--
+				}
+				continue
+			}
+			if skipDepth > 0 {
+				continue
+			}
+			// Close elements left open inside this one; ignore end tags that were never opened
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] == name {
+					for j := len(open) - 1; j >= i; j-- {
+						out.WriteString("</" + open[j] + ">")
+					}
+					open = open[:i]
+					break
+				}
+			}
+		case html.TextToken:
+			if skipDepth == 0 {
+				out.WriteString(html.EscapeString(token.Data))
+			}
+		}
+	}
+	for i := len(open) - 1; i >= 0; i-- {
+		out.WriteString("</" + open[i] + ">")
+	}
+	return out.String()
+}
+
+// isAllowedHTMLURL reports whether an href or src value is an absolute URL with an allowed scheme
+func isAllowedHTMLURL(value string) bool {
+	parsed, err := url.Parse(strings.TrimSpace(value))
+	if err != nil {
+		return false
+	}
+	return allowedURLSchemes[strings.ToLower(parsed.Scheme)]
+}
+
+// htmlToPlainText derives a plain text alternative from an HTML body for when no body is given
+func htmlToPlainText(input string) string {
+	var out strings.Builder
+	skipDepth := 0
+
+	tokenizer := html.NewTokenizer(strings.NewReader(input))
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			break
+		}
+		token := tokenizer.Token()
+		switch tokenType {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if droppedHTMLElements[token.Data] && tokenType == html.StartTagToken {
+				skipDepth++
+			} else if blockHTMLElements[token.Data] && skipDepth == 0 {
+				out.WriteString("\n")
+			}
+		case html.EndTagToken:
+			if droppedHTMLElements[token.Data] && skipDepth > 0 {
+				skipDepth--
+			}
+		case html.TextToken:
+			if skipDepth == 0 {
+				out.WriteString(token.Data)
+			}
+		}
+	}
+
+	// Collapse the whitespace of each line and drop blank lines
+	var lines []string
+	for _, line := range strings.Split(out.String(), "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// parseHTMLBody returns send_message's sanitized body_html, or "" when it is not given
+func parseHTMLBody(payload map[string]interface{}) (string, error) {
+	raw, exists := payload[PayloadFieldBodyHTML]
+	if !exists || raw == nil {
+		return "", nil
+	}
+	htmlBody, ok := raw.(string)
+	if !ok {
+		return "", fmt.Errorf("%s must be a string", PayloadFieldBodyHTML)
+	}
+	return sanitizeEmailHTML(htmlBody), nil
+}
+
+// writeAlternativeBody writes a multipart/alternative body with the plain text part first,
+// so clients that can render HTML show the last, richer part
+func writeAlternativeBody(w io.Writer, boundary, textBody, htmlBody string) error {
+	writer := multipart.NewWriter(w)
+	if boundary != "" {
+		if err := writer.SetBoundary(boundary); err != nil {
+			return err
+		}
+	}
+
+	textPart, err := writer.CreatePart(textproto.MIMEHeader{"Content-Type": {"text/plain; charset=UTF-8"}})
+	if err != nil {
+		return err
+	}
+	textPart.Write([]byte(textBody))
+
+	htmlPart, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/html; charset=UTF-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return err
+	}
+	encoder := quotedprintable.NewWriter(htmlPart)
+	encoder.Write([]byte(htmlBody))
+	if err := encoder.Close(); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// createRawHTMLMessage builds a base64url multipart/alternative message with text and HTML bodies
+func (p *GmailProxy) createRawHTMLMessage(to, subject, body, htmlBody string) (string, error) {
+	var parts bytes.Buffer
+	boundary := multipart.NewWriter(nil).Boundary()
+	if err := writeAlternativeBody(&parts, boundary, body, htmlBody); err != nil {
+		return "", fmt.Errorf("failed to build message body: %w", err)
+	}
+
+	message := fmt.Sprintf(
+		"From: me\r\n"+
+			"To: %s\r\n"+
+			"Subject: %s\r\n"+
+			"MIME-Version: 1.0\r\n"+
+			"Content-Type: multipart/alternative; boundary=%s\r\n"+
+			"\r\n"+
+			"%s",
+		to, subject, boundary, parts.String())
+	return base64.URLEncoding.EncodeToString([]byte(message)), nil
+}
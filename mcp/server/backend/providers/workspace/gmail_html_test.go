@@ -0,0 +1,77 @@
+package workspace
+
+import "testing"
+
+// TestSanitizeEmailHTML verifies scripts, styles, event handlers and unsafe URLs are stripped
+// while formatting is kept and malformed markup is balanced
+func TestSanitizeEmailHTML(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"formatting kept", `<p>Hello <b>World</b></p>`, `<p>Hello <b>World</b></p>`},
+		{"script dropped with content", `<p>Hi<script>alert(1)</script></p>`, `<p>Hi</p>`},
+		{"uppercase script", `<SCRIPT type="text/javascript">alert(1)</SCRIPT>ok`, `ok`},
+		{"self-closing script", `<script src="https://evil.example/x.js"/>ok`, `ok`},
+		{"onclick dropped", `<a href="https://example.com" onclick="steal()">link</a>`, `<a href="https://example.com">link</a>`},
+		{"onerror dropped", `<img src="https://example.com/a.png" onerror="alert(1)" alt="a">`, `<img src="https://example.com/a.png" alt="a">`},
+		{"onload on allowed tag", `<div onload="alert(1)" onmouseover="alert(2)">x</div>`, `<div>x</div>`},
+		{"javascript href", `<a href="javascript:alert(1)">x</a>`, `<a>x</a>`},
+		{"mixed case javascript href", `<a href="  JaVaScRiPt:alert(1)">x</a>`, `<a>x</a>`},
+		{"entity-obfuscated javascript href", `<a href="jav&#x09;ascript:alert(1)">x</a>`, `<a>x</a>`},
+		{"javascript img src", `<img src="javascript:alert(1)">`, `<img>`},
+		{"data URL", `<img src="data:text/html;base64,PHNjcmlwdD4=">`, `<img>`},
+		{"relative URL", `<a href="/reset">x</a>`, `<a>x</a>`},
+		{"mailto kept", `<a href="mailto:a@example.com">mail</a>`, `<a href="mailto:a@example.com">mail</a>`},
+		{"style element dropped", `<style>p{color:red}</style><p>x</p>`, `<p>x</p>`},
+		{"style attribute dropped", `<p style="background:url(javascript:alert(1))">x</p>`, `<p>x</p>`},
+		{"iframe dropped", `<iframe src="https://evil.example"></iframe>ok`, `ok`},
+		{"object dropped", `<object data="x.swf"><param name="movie"></object>ok`, `ok`},
+		{"embed dropped", `<embed src="x.swf"><p>after</p>`, `<p>after</p>`},
+		{"script nested in svg", `<svg><script>alert(1)</script><text>t</text></svg>after`, `after`},
+		{"iframe nested in dropped element", `<noscript><iframe src="https://evil.example"></iframe>hidden</noscript>shown`, `shown`},
+		{"comment dropped", `<!--[if IE]><script>alert(1)</script><![endif]-->ok`, `ok`},
+		{"unknown element unwrapped", `<font color="red">hi</font>`, `hi`},
+		{"unclosed elements closed", `<p><b>bold`, `<p><b>bold</b></p>`},
+		{"misnested elements", `<b><i>x</b>y</i>`, `<b><i>x</i></b>y`},
+		{"stray end tag", `</div>text`, `text`},
+		{"void elements not closed", `a<br>b<hr/>`, `a<br>b<hr>`},
+		{"text escaped", `a < b & "c"`, `a &lt; b &amp; &#34;c&#34;`},
+		{"attribute escaped", `<a title='"><script>'>x</a>`, `<a title="&#34;&gt;&lt;script&gt;">x</a>`},
+		{"encoded tags stay text", `&lt;script&gt;alert(1)&lt;/script&gt;`, `&lt;script&gt;alert(1)&lt;/script&gt;`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitizeEmailHTML(tt.input); got != tt.want {
+				t.Errorf("sanitizeEmailHTML(%q)\n got: %q\nwant: %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestHTMLToPlainText verifies the text alternative keeps visible text, one block per line
+func TestHTMLToPlainText(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"paragraphs", `<p>Hello <b>World</b></p><p>Second</p>`, "Hello World\nSecond"},
+		{"line breaks", `Line<br>Next<br/>Last`, "Line\nNext\nLast"},
+		{"list items", `<ul><li>one</li><li>two</li></ul>`, "one\ntwo"},
+		{"whitespace collapsed and blank lines dropped", "  a \t  b  \n\n  c ", "a b\nc"},
+		{"entities decoded", `Tom &amp; Jerry &lt;3`, "Tom & Jerry <3"},
+		{"scripts and styles dropped", `<style>p{}</style><p>Text</p><script>alert(1)</script>`, "Text"},
+		{"head dropped", `<html><head><title>Subject</title></head><body><div>Body</div></body></html>`, "Body"},
+		{"unclosed markup", `<div><p>open`, "open"},
+		{"empty", ``, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := htmlToPlainText(tt.input); got != tt.want {
+				t.Errorf("htmlToPlainText(%q)\n got: %q\nwant: %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
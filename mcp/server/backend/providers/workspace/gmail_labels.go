@@ -0,0 +1,79 @@
+package workspace
+
+import (
+	"fmt"
+
+	"google.golang.org/api/gmail/v1"
+)
+
+// inboxLabelID is the system label Gmail removes to archive a message
+const inboxLabelID = "INBOX"
+
+// modifyLabelsRequest builds the Users.Messages.Modify request of a modify_labels payload
+func modifyLabelsRequest(payload map[string]interface{}) (string, *gmail.ModifyMessageRequest, error) {
+	messageID, ok := payload["message_id"].(string)
+	if !ok || messageID == "" {
+		return "", nil, fmt.Errorf("message_id must be a non-empty string")
+	}
+	addLabelIDs, err := labelIDsField(payload, "add_label_ids")
+	if err != nil {
+		return "", nil, err
+	}
+	removeLabelIDs, err := labelIDsField(payload, "remove_label_ids")
+	if err != nil {
+		return "", nil, err
+	}
+	if len(addLabelIDs) == 0 && len(removeLabelIDs) == 0 {
+		return "", nil, fmt.Errorf("at least one of add_label_ids or remove_label_ids is required")
+	}
+	for _, added := range addLabelIDs {
+		for _, removed := range removeLabelIDs {
+			if added == removed {
+				return "", nil, fmt.Errorf("label %s can't be both added and removed", added)
+			}
+		}
+	}
+	return messageID, &gmail.ModifyMessageRequest{AddLabelIds: addLabelIDs, RemoveLabelIds: removeLabelIDs}, nil
+}
+
+// archiveRequest archives a message by removing its INBOX label; archiving twice is a no-op
+func archiveRequest() *gmail.ModifyMessageRequest {
+	return &gmail.ModifyMessageRequest{RemoveLabelIds: []string{inboxLabelID}}
+}
+
+// isArchived reports whether a message with these labels is out of the inbox
+func isArchived(labelIDs []string) bool {
+	for _, labelID := range labelIDs {
+		if labelID == inboxLabelID {
+			return false
+		}
+	}
+	return true
+}
+
+// labelIDsField reads an optional list of label IDs from a payload field
+func labelIDsField(payload map[string]interface{}, field string) ([]string, error) {
+	var items []interface{}
+	switch v := payload[field].(type) {
+	case nil:
+		return []string{}, nil
+	case []string:
+		for _, item := range v {
+			items = append(items, item)
+		}
+	case []interface{}:
+		items = v
+	default:
+		return nil, fmt.Errorf("%s must be an array of label IDs", field)
+	}
+
+	labelIDs := make([]string, 0, len(items))
+	for _, item := range items {
+		labelID, ok := item.(string)
+		if !ok || labelID == "" {
+			return nil, fmt.Errorf("%s must contain only non-empty label ID strings", field)
+		}
+		labelIDs = append(labelIDs, labelID)
+	}
+	return labelIDs, nil
+}
@@ -0,0 +1,77 @@
+package workspace
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestModifyLabelsRequest verifies modify_labels payloads become Modify requests or are rejected
+func TestModifyLabelsRequest(t *testing.T) {
+	tests := []struct {
+		name       string
+		payload    map[string]interface{}
+		wantAdd    []string
+		wantRemove []string
+		wantErr    string
+	}{
+		{
+			name:       "add and remove from JSON arrays",
+			payload:    map[string]interface{}{"message_id": "msg_1", "add_label_ids": []interface{}{"Label_7"}, "remove_label_ids": []interface{}{"UNREAD"}},
+			wantAdd:    []string{"Label_7"},
+			wantRemove: []string{"UNREAD"},
+		},
+		{
+			name:       "add only from a string slice",
+			payload:    map[string]interface{}{"message_id": "msg_1", "add_label_ids": []string{"STARRED", "Label_7"}},
+			wantAdd:    []string{"STARRED", "Label_7"},
+			wantRemove: []string{},
+		},
+		{
+			name:       "remove only",
+			payload:    map[string]interface{}{"message_id": "msg_1", "remove_label_ids": []interface{}{"UNREAD"}},
+			wantAdd:    []string{},
+			wantRemove: []string{"UNREAD"},
+		},
+		{"missing message_id", map[string]interface{}{"add_label_ids": []interface{}{"STARRED"}}, nil, nil, "message_id"},
+		{"no label changes", map[string]interface{}{"message_id": "msg_1", "add_label_ids": []interface{}{}}, nil, nil, "at least one of"},
+		{"label not a list", map[string]interface{}{"message_id": "msg_1", "add_label_ids": "STARRED"}, nil, nil, "must be an array"},
+		{"non-string label", map[string]interface{}{"message_id": "msg_1", "add_label_ids": []interface{}{42}}, nil, nil, "non-empty label ID strings"},
+		{"empty label", map[string]interface{}{"message_id": "msg_1", "remove_label_ids": []string{""}}, nil, nil, "non-empty label ID strings"},
+		{"added and removed", map[string]interface{}{"message_id": "msg_1", "add_label_ids": []interface{}{"UNREAD"}, "remove_label_ids": []interface{}{"UNREAD"}}, nil, nil, "both added and removed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messageID, request, err := modifyLabelsRequest(tt.payload)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if messageID != "msg_1" {
+				t.Errorf("Expected message msg_1, got %q", messageID)
+			}
+			if !reflect.DeepEqual(request.AddLabelIds, tt.wantAdd) || !reflect.DeepEqual(request.RemoveLabelIds, tt.wantRemove) {
+				t.Errorf("Expected add %v and remove %v, got %v and %v", tt.wantAdd, tt.wantRemove, request.AddLabelIds, request.RemoveLabelIds)
+			}
+		})
+	}
+}
+
+// TestArchiveRequest verifies archiving removes only the INBOX label and is detected from the result
+func TestArchiveRequest(t *testing.T) {
+	request := archiveRequest()
+	if len(request.AddLabelIds) != 0 || !reflect.DeepEqual(request.RemoveLabelIds, []string{"INBOX"}) {
+		t.Errorf("Expected only INBOX to be removed, got %+v", request)
+	}
+	if !isArchived([]string{"UNREAD", "Label_7"}) || !isArchived(nil) {
+		t.Error("Expected a message without INBOX to be archived")
+	}
+	if isArchived([]string{"INBOX", "UNREAD"}) {
+		t.Error("Expected a message still labeled INBOX not to be archived")
+	}
+}
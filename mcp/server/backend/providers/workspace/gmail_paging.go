@@ -0,0 +1,187 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+
+	"google.golang.org/api/gmail/v1"
+)
+
+const (
+	// gmailListPageSize is the most messages the Gmail API returns per page
+	gmailListPageSize = 500
+	// defaultMaxListMessages caps the messages one list or search call fetches across pages
+	defaultMaxListMessages = 1000
+	// gmailMetadataConcurrency caps the message metadata requests one list call has in flight
+	gmailMetadataConcurrency = 10
+)
+
+// SetListLimit caps how many messages one list_messages or search_messages call fetches
+// across Gmail API pages, whatever max_results asks for. A non-positive limit keeps the default.
+func (p *GmailProxy) SetListLimit(maxMessages int64) {
+	p.maxListMessages = defaultMaxListMessages
+	if maxMessages > 0 {
+		p.maxListMessages = maxMessages
+	}
+}
+
+// messagePages is what one list call fetched across Gmail API pages
+type messagePages struct {
+	messages           []*gmail.Message
+	nextPageToken      string // continues after the last fetched message; empty when none are left
+	resultSizeEstimate int64
+	pages              int
+	truncated          bool // more messages were requested than the max_total safeguard allows
+}
+
+// listMessageLimit returns how many messages a list call fetches: max_results (default 10),
+// capped by the call's max_total and the proxy's limit. capped reports whether a cap applied.
+func (p *GmailProxy) listMessageLimit(payload map[string]interface{}) (limit int64, capped bool) {
+	limit = int64Field(payload, "max_results", 10)
+	maxTotal := p.maxListMessages
+	if maxTotal <= 0 {
+		maxTotal = defaultMaxListMessages
+	}
+	if requested := int64Field(payload, "max_total", 0); requested > 0 && requested < maxTotal {
+		maxTotal = requested
+	}
+	if limit > maxTotal {
+		return maxTotal, true
+	}
+	if limit < 1 {
+		limit = 1
+	}
+	return limit, false
+}
+
+// messagePageFetcher fetches one page of at most maxResults messages starting at pageToken
+type messagePageFetcher func(pageToken string, maxResults int64) (*gmail.ListMessagesResponse, error)
+
+// gmailMessagePages returns a fetcher listing the user's messages matching query
+func gmailMessagePages(ctx context.Context, service *gmail.Service, query string) messagePageFetcher {
+	return func(pageToken string, maxResults int64) (*gmail.ListMessagesResponse, error) {
+		listCall := service.Users.Messages.List("me").MaxResults(maxResults).Context(ctx)
+		if query != "" {
+			listCall = listCall.Q(query)
+		}
+		if pageToken != "" {
+			listCall = listCall.PageToken(pageToken)
+		}
+		return listCall.Do()
+	}
+}
+
+// listMessagePages fetches up to limit messages, starting at pageToken and following next page
+// tokens in batches of at most gmailListPageSize. capped says limit came from the max_total
+// safeguard, so stopping at it with messages left marks the result truncated.
+func listMessagePages(fetch messagePageFetcher, pageToken string, limit int64, capped bool, requestID string) (*messagePages, error) {
+	result := &messagePages{}
+	for int64(len(result.messages)) < limit {
+		batch := limit - int64(len(result.messages))
+		if batch > gmailListPageSize {
+			batch = gmailListPageSize
+		}
+
+		page, err := fetch(pageToken, batch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list messages (page %d): %w", result.pages+1, err)
+		}
+
+		result.pages++
+		result.messages = append(result.messages, page.Messages...)
+		result.resultSizeEstimate = page.ResultSizeEstimate
+		pageToken = page.NextPageToken
+		log.Printf("[Gmail] [%s] 📄 Page %d: %d messages (%d fetched so far)\n", requestID, result.pages, len(page.Messages), len(result.messages))
+		if pageToken == "" || len(page.Messages) == 0 {
+			break
+		}
+	}
+	result.nextPageToken = pageToken
+	result.truncated = capped && pageToken != ""
+	return result, nil
+}
+
+// messageMetadataFetcher fetches the headers and snippet of one message
+type messageMetadataFetcher func(ctx context.Context, messageID string) (*gmail.Message, error)
+
+// gmailMessageMetadata returns a fetcher reading the From, Subject and Date headers of the user's messages
+func gmailMessageMetadata(service *gmail.Service) messageMetadataFetcher {
+	return func(ctx context.Context, messageID string) (*gmail.Message, error) {
+		return service.Users.Messages.Get("me", messageID).
+			Format("metadata").
+			MetadataHeaders("From", "Subject", "Date").
+			Context(ctx).
+			Do()
+	}
+}
+
+// fetchMessageMetadata fetches the metadata of messages, in their order, with at most
+// gmailMetadataConcurrency requests in flight. Each request backs off on its own when rate
+// limited, so one limited message doesn't refetch the rest; the first failure cancels the others.
+func fetchMessageMetadata(ctx context.Context, fetch messageMetadataFetcher, messages []*gmail.Message, requestID string) ([]*gmail.Message, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]*gmail.Message, len(messages))
+	var (
+		wg       sync.WaitGroup
+		failOnce sync.Once
+		failErr  error
+	)
+	indexes := make(chan int)
+	for worker := 0; worker < min(gmailMetadataConcurrency, len(messages)); worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				messageID := messages[i].Id
+				err := withRateLimitBackoff(ctx, ServiceTypeGmail, requestID, func() error {
+					meta, err := fetch(ctx, messageID)
+					results[i] = meta
+					return err
+				})
+				if err != nil {
+					failOnce.Do(func() {
+						log.Printf("[Gmail] [%s] ❌ Failed to fetch metadata for message %s: %v\n", requestID, messageID, err)
+						failErr = fmt.Errorf("failed to get metadata for message %s: %w", messageID, err)
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+
+dispatch:
+	for i := range messages {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	if failErr != nil {
+		return nil, failErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// int64Field reads an integer payload field decoded from JSON or set in Go, or returns def
+func int64Field(payload map[string]interface{}, field string, def int64) int64 {
+	switch v := payload[field].(type) {
+	case float64:
+		return int64(v)
+	case int:
+		return int64(v)
+	case int64:
+		return v
+	}
+	return def
+}
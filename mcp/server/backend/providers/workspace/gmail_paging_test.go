@@ -0,0 +1,186 @@
+package workspace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/api/gmail/v1"
+)
+
+// fakeMailbox serves total messages in pages like the Gmail API, recording the page sizes asked for
+type fakeMailbox struct {
+	total      int
+	batches    []int64
+	failOnPage int
+}
+
+func (m *fakeMailbox) fetch(pageToken string, maxResults int64) (*gmail.ListMessagesResponse, error) {
+	m.batches = append(m.batches, maxResults)
+	if m.failOnPage == len(m.batches) {
+		return nil, errors.New("backend error")
+	}
+	start := 0
+	if pageToken != "" {
+		start, _ = strconv.Atoi(pageToken)
+	}
+	end := start + int(maxResults)
+	if end > m.total {
+		end = m.total
+	}
+	page := &gmail.ListMessagesResponse{ResultSizeEstimate: int64(m.total)}
+	for i := start; i < end; i++ {
+		page.Messages = append(page.Messages, &gmail.Message{Id: fmt.Sprintf("msg_%d", i)})
+	}
+	if end < m.total {
+		page.NextPageToken = strconv.Itoa(end)
+	}
+	return page, nil
+}
+
+// TestListMessagePages verifies pages are followed up to the limit in batches of at most 500
+func TestListMessagePages(t *testing.T) {
+	tests := []struct {
+		name          string
+		total         int
+		limit         int64
+		capped        bool
+		wantMessages  int
+		wantBatches   []int64
+		wantNextToken string
+		wantTruncated bool
+	}{
+		{"single page", 30, 10, false, 10, []int64{10}, "10", false},
+		{"several pages", 1500, 1200, false, 1200, []int64{500, 500, 200}, "1200", false},
+		{"mailbox exhausted", 700, 1000, false, 700, []int64{500, 500}, "", false},
+		{"cut by max_total", 1500, 1000, true, 1000, []int64{500, 500}, "1000", true},
+		{"cap not reached", 700, 1000, true, 700, []int64{500, 500}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mailbox := &fakeMailbox{total: tt.total}
+			result, err := listMessagePages(mailbox.fetch, "", tt.limit, tt.capped, "test")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(result.messages) != tt.wantMessages || result.pages != len(tt.wantBatches) {
+				t.Errorf("Expected %d messages in %d pages, got %d in %d", tt.wantMessages, len(tt.wantBatches), len(result.messages), result.pages)
+			}
+			if !reflect.DeepEqual(mailbox.batches, tt.wantBatches) {
+				t.Errorf("Expected page sizes %v, got %v", tt.wantBatches, mailbox.batches)
+			}
+			if result.nextPageToken != tt.wantNextToken || result.truncated != tt.wantTruncated {
+				t.Errorf("Expected next token %q and truncated %t, got %q and %t", tt.wantNextToken, tt.wantTruncated, result.nextPageToken, result.truncated)
+			}
+		})
+	}
+}
+
+// TestListMessagePagesStartsAtPageToken verifies a page token from an earlier call continues the listing
+func TestListMessagePagesStartsAtPageToken(t *testing.T) {
+	mailbox := &fakeMailbox{total: 100}
+	result, err := listMessagePages(mailbox.fetch, "90", 50, false, "test")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(result.messages) != 10 || result.messages[0].Id != "msg_90" || result.nextPageToken != "" {
+		t.Errorf("Expected the last 10 messages, got %d starting at %s", len(result.messages), result.messages[0].Id)
+	}
+}
+
+// TestListMessagePagesReportsFailedPage verifies an API error names the page that failed
+func TestListMessagePagesReportsFailedPage(t *testing.T) {
+	mailbox := &fakeMailbox{total: 1500, failOnPage: 2}
+	if _, err := listMessagePages(mailbox.fetch, "", 1500, false, "test"); err == nil || !strings.Contains(err.Error(), "page 2") {
+		t.Errorf("Expected the failure of page 2, got %v", err)
+	}
+}
+
+// TestListMessageLimit verifies max_results is capped by max_total and the proxy's limit
+func TestListMessageLimit(t *testing.T) {
+	proxy := &GmailProxy{}
+	proxy.SetListLimit(1000)
+
+	tests := []struct {
+		name       string
+		payload    map[string]interface{}
+		wantLimit  int64
+		wantCapped bool
+	}{
+		{"default", map[string]interface{}{}, 10, false},
+		{"within limit", map[string]interface{}{"max_results": float64(800)}, 800, false},
+		{"above proxy limit", map[string]interface{}{"max_results": float64(5000)}, 1000, true},
+		{"below max_total", map[string]interface{}{"max_results": float64(40), "max_total": float64(50)}, 40, false},
+		{"above max_total", map[string]interface{}{"max_results": float64(100), "max_total": float64(50)}, 50, true},
+		{"max_total above proxy limit", map[string]interface{}{"max_results": float64(5000), "max_total": float64(3000)}, 1000, true},
+		{"non-positive", map[string]interface{}{"max_results": float64(0)}, 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if limit, capped := proxy.listMessageLimit(tt.payload); limit != tt.wantLimit || capped != tt.wantCapped {
+				t.Errorf("Expected limit %d (capped %t), got %d (capped %t)", tt.wantLimit, tt.wantCapped, limit, capped)
+			}
+		})
+	}
+}
+
+// TestFetchMessageMetadata verifies metadata is fetched concurrently, within the bound, and
+// returned in the listed order
+func TestFetchMessageMetadata(t *testing.T) {
+	listed, _ := (&fakeMailbox{total: 35}).fetch("", 35)
+
+	var inFlight, maxInFlight int32
+	fetch := func(ctx context.Context, messageID string) (*gmail.Message, error) {
+		current := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			seen := atomic.LoadInt32(&maxInFlight)
+			if current <= seen || atomic.CompareAndSwapInt32(&maxInFlight, seen, current) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		return &gmail.Message{Id: messageID, Snippet: "snippet of " + messageID}, nil
+	}
+
+	metadata, err := fetchMessageMetadata(context.Background(), fetch, listed.Messages, "test")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for i, meta := range metadata {
+		if meta.Snippet != "snippet of "+listed.Messages[i].Id {
+			t.Errorf("Expected metadata %d for %s, got %q", i, listed.Messages[i].Id, meta.Snippet)
+		}
+	}
+	if maxInFlight < 2 || maxInFlight > gmailMetadataConcurrency {
+		t.Errorf("Expected between 2 and %d requests in flight, got %d", gmailMetadataConcurrency, maxInFlight)
+	}
+}
+
+// TestFetchMessageMetadataFails verifies the first failure is reported and stops the remaining fetches
+func TestFetchMessageMetadataFails(t *testing.T) {
+	listed, _ := (&fakeMailbox{total: 200}).fetch("", 200)
+
+	var calls int32
+	fetch := func(ctx context.Context, messageID string) (*gmail.Message, error) {
+		atomic.AddInt32(&calls, 1)
+		if messageID == "msg_3" {
+			return nil, errors.New("backend error")
+		}
+		return &gmail.Message{Id: messageID}, nil
+	}
+
+	_, err := fetchMessageMetadata(context.Background(), fetch, listed.Messages, "test")
+	if err == nil || !strings.Contains(err.Error(), "msg_3") {
+		t.Errorf("Expected the failure of msg_3, got %v", err)
+	}
+	if calls == 200 {
+		t.Error("Expected the remaining fetches to stop after the failure")
+	}
+}
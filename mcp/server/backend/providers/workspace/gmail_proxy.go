@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/dimitar-trifonov/sohoaas/service-proxies/workflow"
@@ -17,13 +18,23 @@ import (
 // GmailProxy implements WorkspaceProxy for Gmail service
 type GmailProxy struct {
 	config *oauth2.Config
+
+	// send_message guardrails, see SetAttachmentLimits
+	maxAttachmentBytes     int64
+	allowedAttachmentTypes map[string]bool
+
+	// most messages one list or search call fetches across pages, see SetListLimit
+	maxListMessages int64
 }
 
 // NewGmailProxy creates a new Gmail proxy instance
 func NewGmailProxy(config *oauth2.Config) *GmailProxy {
-	return &GmailProxy{
+	proxy := &GmailProxy{
 		config: config,
 	}
+	proxy.SetAttachmentLimits(0, nil)
+	proxy.SetListLimit(0)
+	return proxy
 }
 
 // Execute calls a Gmail function with the given payload
@@ -36,10 +47,9 @@ func (p *GmailProxy) Execute(ctx context.Context, function string, token string,
 	log.Printf("[Gmail] [%s] Function: %s\n", requestID, function)
 	log.Printf("[Gmail] [%s] Request Time: %s\n", requestID, startTime.Format(time.RFC3339Nano))
 	log.Printf("[Gmail] [%s] OAuth Token Length: %d characters\n", requestID, len(token))
-	log.Printf("[Gmail] [%s] OAuth Token Prefix: %s...\n", requestID, token[:min(20, len(token))])
 	
 	// Log payload with JSON formatting
-	payloadJSON, _ := json.MarshalIndent(payload, "", "  ")
+	payloadJSON, _ := json.MarshalIndent(workflow.Redact(payload), "", "  ")
 	log.Printf("[Gmail] [%s] Request Payload:\n%s\n", requestID, string(payloadJSON))
 
 	// Validate function
@@ -92,25 +102,35 @@ func (p *GmailProxy) Execute(ctx context.Context, function string, token string,
 	functionStartTime := time.Now()
 	
 	var result map[string]interface{}
-	var execErr error
-
-	switch function {
-	case GmailFunctionSendMessage:
-		result, execErr = p.sendMessageWithLogging(ctx, service, payload, requestID)
-	case GmailFunctionGetMessage:
-		result, execErr = p.getMessageWithLogging(ctx, service, payload, requestID)
-	case GmailFunctionListMessages:
-		result, execErr = p.listMessagesWithLogging(ctx, service, payload, requestID)
-	case GmailFunctionSearchMessages:
-		result, execErr = p.searchMessagesWithLogging(ctx, service, payload, requestID)
-	default:
-		execErr = fmt.Errorf("function not implemented: %s", function)
-		log.Printf("[Gmail] [%s] ❌ Function not implemented: %s\n", requestID, function)
-	}
+	execErr := withRateLimitBackoff(ctx, ServiceTypeGmail, requestID, func() error {
+		var err error
+		switch function {
+		case GmailFunctionSendMessage:
+			result, err = p.sendMessageWithLogging(ctx, service, payload, requestID)
+		case GmailFunctionGetMessage:
+			result, err = p.getMessageWithLogging(ctx, service, payload, requestID)
+		case GmailFunctionListMessages:
+			result, err = p.listMessagesWithLogging(ctx, service, payload, requestID)
+		case GmailFunctionSearchMessages:
+			result, err = p.searchMessagesWithLogging(ctx, service, payload, requestID)
+		case GmailFunctionModifyLabels:
+			result, err = p.modifyLabelsWithLogging(ctx, service, payload, requestID)
+		case GmailFunctionArchiveMessage:
+			result, err = p.archiveMessageWithLogging(ctx, service, payload, requestID)
+		default:
+			err = fmt.Errorf("function not implemented: %s", function)
+			log.Printf("[Gmail] [%s] ❌ Function not implemented: %s\n", requestID, function)
+		}
+		return err
+	})
 
 	functionDuration := time.Since(functionStartTime)
 	totalDuration := time.Since(startTime)
 
+	if rlErr := asRateLimitError(ServiceTypeGmail, execErr); rlErr != nil {
+		return rateLimitedResponse(rlErr), rlErr
+	}
+
 	if execErr != nil {
 		log.Printf("[Gmail] [%s] ❌ Function execution FAILED after %v (total: %v): %v\n", requestID, functionDuration, totalDuration, execErr)
 		log.Printf("[Gmail] [%s] ========== REQUEST END (FAILED) ==========\n", requestID)
@@ -126,7 +146,7 @@ func (p *GmailProxy) Execute(ctx context.Context, function string, token string,
 	}
 
 	// Log successful response
-	resultJSON, _ := json.MarshalIndent(result, "", "  ")
+	resultJSON, _ := json.MarshalIndent(workflow.Redact(result), "", "  ")
 	log.Printf("[Gmail] [%s] ✅ Function executed successfully in %v (total: %v)\n", requestID, functionDuration, totalDuration)
 	log.Printf("[Gmail] [%s] Response Data:\n%s\n", requestID, string(resultJSON))
 	log.Printf("[Gmail] [%s] ========== REQUEST END (SUCCESS) ==========\n", requestID)
@@ -149,6 +169,8 @@ func (p *GmailProxy) GetSupportedFunctions() []string {
 		GmailFunctionGetMessage,
 		GmailFunctionListMessages,
 		GmailFunctionSearchMessages,
+		GmailFunctionModifyLabels,
+		GmailFunctionArchiveMessage,
 	}
 }
 
@@ -187,6 +209,18 @@ func (p *GmailProxy) GetServiceMetadata() ServiceMetadata {
 					"body":    "Test email body",
 				},
 				RequiredFields: []string{"to", "subject", "body"},
+				InputSchema: &ResponseSchema{
+					Type:        "object",
+					Description: "Send message request",
+					Properties: map[string]PropertySchema{
+						"to":          {Type: "string", Description: "Recipient email address"},
+						"subject":     {Type: "string", Description: "Email subject"},
+						"body":        {Type: "string", Description: "Plain text body; the fallback for clients that don't render body_html"},
+						"body_html":   {Type: "string", Description: "Optional HTML body, sanitized to basic formatting, lists, tables, images and http(s)/mailto links"},
+						"attachments": {Type: "array", Description: "Optional attachments: objects with filename, content (base64 or data URL) and mime_type"},
+					},
+					Required: []string{"to", "subject", "body"},
+				},
 				OutputSchema: &ResponseSchema{
 					Type:        "object",
 					Description: "Gmail send message response",
@@ -227,6 +261,10 @@ func (p *GmailProxy) GetServiceMetadata() ServiceMetadata {
 							Type:        "number",
 							Description: "API call duration in milliseconds",
 						},
+						"attachment_count": {
+							Type:        "number",
+							Description: "Number of attachments sent",
+						},
 					},
 					Required: []string{"message_id", "thread_id", "status", "sent_at"},
 				},
@@ -263,21 +301,191 @@ func (p *GmailProxy) GetServiceMetadata() ServiceMetadata {
 			GmailFunctionGetMessage: {
 				Name:        GmailFunctionGetMessage,
 				DisplayName: "Get Email",
-				Description: "Retrieve a specific email message by ID",
+				Description: "Retrieve a specific email message by ID with decoded bodies and attachment metadata",
 				ExamplePayload: map[string]interface{}{
 					"message_id": "1234567890abcdef",
 				},
 				RequiredFields: []string{"message_id"},
+				OutputSchema: &ResponseSchema{
+					Type:        "object",
+					Description: "Gmail get message response",
+					Properties: map[string]PropertySchema{
+						"message_id": {
+							Type:        "string",
+							Description: "Gmail message ID",
+						},
+						"thread_id": {
+							Type:        "string",
+							Description: "Gmail thread ID",
+						},
+						"label_ids": {
+							Type:        "array",
+							Description: "Message label IDs",
+						},
+						"snippet": {
+							Type:        "string",
+							Description: "Message snippet",
+						},
+						"headers": {
+							Type:        "object",
+							Description: "All message headers keyed by name",
+						},
+						"from": {
+							Type:        "string",
+							Description: "Sender address",
+						},
+						"to": {
+							Type:        "string",
+							Description: "Recipient addresses",
+						},
+						"subject": {
+							Type:        "string",
+							Description: "Email subject",
+						},
+						"date": {
+							Type:        "string",
+							Description: "Date header",
+						},
+						"body_text": {
+							Type:        "string",
+							Description: "Decoded plain-text body",
+						},
+						"body_html": {
+							Type:        "string",
+							Description: "Decoded HTML body",
+						},
+						"attachments": {
+							Type:        "array",
+							Description: "Attachment metadata: filename, mime_type, size, attachment_id",
+						},
+					},
+					Required: []string{"message_id", "thread_id", "headers", "body_text", "attachments"},
+				},
 			},
 			GmailFunctionListMessages: {
 				Name:        GmailFunctionListMessages,
 				DisplayName: "List Emails",
-				Description: "List email messages with optional filtering",
+				Description: "List email messages matching a Gmail search query; large requests are fetched in pages up to max_total",
 				ExamplePayload: map[string]interface{}{
+					"query":       "from:alerts@broker.com newer_than:7d",
 					"max_results": 10,
-					"query":       "is:unread",
+					"max_total":   1000,
+					"page_token":  "",
 				},
 				RequiredFields: []string{},
+				OutputSchema: &ResponseSchema{
+					Type:        "object",
+					Description: "Gmail list messages response",
+					Properties: map[string]PropertySchema{
+						"messages": {
+							Type:        "array",
+							Description: "Matching messages, each with message_id (also as id), thread_id, from, subject, snippet and date",
+						},
+						"next_page_token": {
+							Type:        "string",
+							Description: "Token for fetching the next page (empty when no more results)",
+						},
+						"result_size_estimate": {
+							Type:        "number",
+							Description: "Estimated total number of matching messages",
+						},
+						"total_messages": {
+							Type:        "number",
+							Description: "Number of messages returned by this call",
+						},
+						"pages": {
+							Type:        "number",
+							Description: "Number of Gmail API pages fetched",
+						},
+						"truncated": {
+							Type:        "boolean",
+							Description: "True when max_results was cut down by the max_total safeguard",
+						},
+						"api_duration_ms": {
+							Type:        "number",
+							Description: "API call duration in milliseconds",
+						},
+					},
+					Required: []string{"messages", "total_messages"},
+				},
+			},
+			GmailFunctionModifyLabels: {
+				Name:        GmailFunctionModifyLabels,
+				DisplayName: "Modify Email Labels",
+				Description: "Add and remove label IDs on an email, e.g. to mark it processed",
+				ExamplePayload: map[string]interface{}{
+					"message_id":       "1234567890abcdef",
+					"add_label_ids":    []string{"Label_123"},
+					"remove_label_ids": []string{"UNREAD"},
+				},
+				RequiredFields: []string{"message_id"},
+				OutputSchema: &ResponseSchema{
+					Type:        "object",
+					Description: "Gmail modify labels response",
+					Properties: map[string]PropertySchema{
+						"message_id": {
+							Type:        "string",
+							Description: "Gmail message ID",
+						},
+						"thread_id": {
+							Type:        "string",
+							Description: "Gmail thread ID",
+						},
+						"label_ids": {
+							Type:        "array",
+							Description: "Message label IDs after the change",
+						},
+						"added_label_ids": {
+							Type:        "array",
+							Description: "Label IDs that were requested to be added",
+						},
+						"removed_label_ids": {
+							Type:        "array",
+							Description: "Label IDs that were requested to be removed",
+						},
+						"api_duration_ms": {
+							Type:        "number",
+							Description: "API call duration in milliseconds",
+						},
+					},
+					Required: []string{"message_id", "thread_id", "label_ids"},
+				},
+			},
+			GmailFunctionArchiveMessage: {
+				Name:        GmailFunctionArchiveMessage,
+				DisplayName: "Archive Email",
+				Description: "Archive an email by removing it from the inbox",
+				ExamplePayload: map[string]interface{}{
+					"message_id": "1234567890abcdef",
+				},
+				RequiredFields: []string{"message_id"},
+				OutputSchema: &ResponseSchema{
+					Type:        "object",
+					Description: "Gmail archive message response",
+					Properties: map[string]PropertySchema{
+						"message_id": {
+							Type:        "string",
+							Description: "Gmail message ID",
+						},
+						"thread_id": {
+							Type:        "string",
+							Description: "Gmail thread ID",
+						},
+						"label_ids": {
+							Type:        "array",
+							Description: "Message label IDs after archiving",
+						},
+						"archived": {
+							Type:        "boolean",
+							Description: "Whether the message is no longer in the inbox",
+						},
+						"api_duration_ms": {
+							Type:        "number",
+							Description: "API call duration in milliseconds",
+						},
+					},
+					Required: []string{"message_id", "thread_id", "label_ids", "archived"},
+				},
 			},
 		},
 	}
@@ -308,9 +516,16 @@ func (p *GmailProxy) ValidatePayload(function string, payload map[string]interfa
 		if _, ok := payload[PayloadFieldSubject]; !ok {
 			return fmt.Errorf("missing required field: %s", PayloadFieldSubject)
 		}
-		if _, ok := payload[PayloadFieldBody]; !ok {
+		// body may be left out when body_html is given; the text alternative is then derived from it
+		if _, ok := payload[PayloadFieldBody]; !ok && payload[PayloadFieldBodyHTML] == nil {
 			return fmt.Errorf("missing required field: %s", PayloadFieldBody)
 		}
+		if _, err := parseHTMLBody(payload); err != nil {
+			return err
+		}
+		if _, err := p.parseAttachments(payload); err != nil {
+			return err
+		}
 	case GmailFunctionGetMessage:
 		if _, ok := payload["message_id"]; !ok {
 			return fmt.Errorf("missing required field: message_id")
@@ -321,6 +536,25 @@ func (p *GmailProxy) ValidatePayload(function string, payload map[string]interfa
 		if _, ok := payload["query"]; !ok {
 			return fmt.Errorf("missing required field: query")
 		}
+	case GmailFunctionModifyLabels:
+		if _, ok := payload["message_id"]; !ok {
+			return fmt.Errorf("missing required field: message_id")
+		}
+		addLabelIDs, err := labelIDsField(payload, "add_label_ids")
+		if err != nil {
+			return err
+		}
+		removeLabelIDs, err := labelIDsField(payload, "remove_label_ids")
+		if err != nil {
+			return err
+		}
+		if len(addLabelIDs) == 0 && len(removeLabelIDs) == 0 {
+			return fmt.Errorf("at least one of add_label_ids or remove_label_ids is required")
+		}
+	case GmailFunctionArchiveMessage:
+		if _, ok := payload["message_id"]; !ok {
+			return fmt.Errorf("missing required field: message_id")
+		}
 	}
 	return nil
 }
@@ -344,15 +578,37 @@ func (p *GmailProxy) sendMessage(ctx context.Context, service *gmail.Service, pa
 func (p *GmailProxy) sendMessageWithLogging(ctx context.Context, service *gmail.Service, payload map[string]interface{}, requestID string) (map[string]interface{}, error) {
 	to := payload[PayloadFieldTo].(string)
 	subject := payload[PayloadFieldSubject].(string)
-	body := payload[PayloadFieldBody].(string)
+	body, _ := payload[PayloadFieldBody].(string)
+	htmlBody, err := parseHTMLBody(payload)
+	if err != nil {
+		return nil, err
+	}
+	if htmlBody != "" && body == "" {
+		body = htmlToPlainText(htmlBody)
+	}
 
 	log.Printf("[Gmail] [%s] 📧 Preparing to send email\n", requestID)
 	log.Printf("[Gmail] [%s]    To: %s\n", requestID, to)
 	log.Printf("[Gmail] [%s]    Subject: %s\n", requestID, subject)
-	log.Printf("[Gmail] [%s]    Body Length: %d characters\n", requestID, len(body))
+	log.Printf("[Gmail] [%s]    Body Length: %d characters, HTML body: %d characters\n", requestID, len(body), len(htmlBody))
+
+	attachments, err := p.parseAttachments(payload)
+	if err != nil {
+		return nil, err
+	}
 
-	// Create email message
+	// Create email message, multipart when there are attachments or an HTML body
 	rawMessage := p.createRawMessage(to, subject, body)
+	if len(attachments) > 0 {
+		log.Printf("[Gmail] [%s]    Attachments: %d\n", requestID, len(attachments))
+		if rawMessage, err = p.createRawMessageWithAttachments(to, subject, body, htmlBody, attachments); err != nil {
+			return nil, err
+		}
+	} else if htmlBody != "" {
+		if rawMessage, err = p.createRawHTMLMessage(to, subject, body, htmlBody); err != nil {
+			return nil, err
+		}
+	}
 	log.Printf("[Gmail] [%s] 📝 Raw message created (length: %d)\n", requestID, len(rawMessage))
 	
 	message := &gmail.Message{
@@ -388,6 +644,7 @@ func (p *GmailProxy) sendMessageWithLogging(ctx context.Context, service *gmail.
 		"status":     "sent",
 		"sent_at":    time.Now().Format(time.RFC3339),
 		"api_duration_ms": apiDuration.Milliseconds(),
+		"attachment_count": len(attachments),
 	}, nil
 }
 
@@ -396,13 +653,16 @@ func (p *GmailProxy) getMessage(ctx context.Context, service *gmail.Service, pay
 }
 
 func (p *GmailProxy) getMessageWithLogging(ctx context.Context, service *gmail.Service, payload map[string]interface{}, requestID string) (map[string]interface{}, error) {
-	messageID := payload["message_id"].(string)
+	messageID, ok := payload["message_id"].(string)
+	if !ok || messageID == "" {
+		return nil, fmt.Errorf("message_id must be a non-empty string")
+	}
 
 	log.Printf("[Gmail] [%s] 📬 Retrieving message: %s\n", requestID, messageID)
 	log.Printf("[Gmail] [%s] 🚀 Calling Gmail API: Users.Messages.Get\n", requestID)
 	apiStartTime := time.Now()
 
-	message, err := service.Users.Messages.Get("me", messageID).Do()
+	message, err := service.Users.Messages.Get("me", messageID).Format("full").Do()
 	apiDuration := time.Since(apiStartTime)
 	
 	if err != nil {
@@ -419,10 +679,19 @@ func (p *GmailProxy) getMessageWithLogging(ctx context.Context, service *gmail.S
 
 	// Extract headers
 	headers := make(map[string]string)
-	for _, header := range message.Payload.Headers {
-		headers[header.Name] = header.Value
+	content := &gmailMessageContent{Attachments: []map[string]interface{}{}}
+	if message.Payload != nil {
+		for _, header := range message.Payload.Headers {
+			headers[header.Name] = header.Value
+		}
+		if err := content.collect(message.Payload); err != nil {
+			return nil, fmt.Errorf("failed to decode message %s: %w", messageID, err)
+		}
 	}
 
+	log.Printf("[Gmail] [%s]    Text body: %d chars, HTML body: %d chars, attachments: %d\n",
+		requestID, len(content.Text), len(content.HTML), len(content.Attachments))
+
 	return map[string]interface{}{
 		"message_id":    message.Id,
 		"thread_id":     message.ThreadId,
@@ -436,60 +705,142 @@ func (p *GmailProxy) getMessageWithLogging(ctx context.Context, service *gmail.S
 		"from":          headers["From"],
 		"to":            headers["To"],
 		"date":          headers["Date"],
+		"body_text":     content.Text,
+		"body_html":     content.HTML,
+		"attachments":   content.Attachments,
 	}, nil
 }
 
+// gmailMessageContent accumulates decoded bodies and attachment metadata from a MIME tree
+type gmailMessageContent struct {
+	Text        string
+	HTML        string
+	Attachments []map[string]interface{}
+}
+
+// collect walks a message part recursively, decoding text/plain and text/html bodies
+// and recording attachment metadata (attachment data itself is not downloaded)
+func (c *gmailMessageContent) collect(part *gmail.MessagePart) error {
+	if part == nil {
+		return nil
+	}
+
+	if part.Filename != "" {
+		attachment := map[string]interface{}{
+			"filename":  part.Filename,
+			"mime_type": part.MimeType,
+			"size":      int64(0),
+		}
+		if part.Body != nil {
+			attachment["size"] = part.Body.Size
+			attachment["attachment_id"] = part.Body.AttachmentId
+		}
+		c.Attachments = append(c.Attachments, attachment)
+		return nil
+	}
+
+	if part.Body != nil && part.Body.Data != "" {
+		decoded, err := decodeGmailBody(part.Body.Data)
+		if err != nil {
+			return err
+		}
+		switch {
+		case strings.HasPrefix(part.MimeType, "text/plain"):
+			c.Text += decoded
+		case strings.HasPrefix(part.MimeType, "text/html"):
+			c.HTML += decoded
+		}
+	}
+
+	for _, child := range part.Parts {
+		if err := c.collect(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeGmailBody decodes Gmail's base64url body data, tolerating missing padding
+func decodeGmailBody(data string) (string, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(data, "="))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode body: %w", err)
+	}
+	return string(decoded), nil
+}
+
 func (p *GmailProxy) listMessages(ctx context.Context, service *gmail.Service, payload map[string]interface{}) (map[string]interface{}, error) {
 	return p.listMessagesWithLogging(ctx, service, payload, "legacy")
 }
 
 func (p *GmailProxy) listMessagesWithLogging(ctx context.Context, service *gmail.Service, payload map[string]interface{}, requestID string) (map[string]interface{}, error) {
-	// Optional parameters
-	maxResults := int64(10) // default
-	if mr, ok := payload["max_results"]; ok {
-		if mrInt, ok := mr.(float64); ok {
-			maxResults = int64(mrInt)
-		}
-	}
+	// Optional parameters; large requests are paged and capped by max_total
+	limit, capped := p.listMessageLimit(payload)
 
 	query := ""
-	if q, ok := payload["query"]; ok {
-		query = q.(string)
+	if q, ok := payload["query"].(string); ok {
+		query = q
 	}
 
-	log.Printf("[Gmail] [%s] 📋 Listing messages (maxResults: %d, query: '%s')\n", requestID, maxResults, query)
+	pageToken := ""
+	if pt, ok := payload["page_token"].(string); ok {
+		pageToken = pt
+	}
+
+	log.Printf("[Gmail] [%s] 📋 Listing messages (limit: %d, query: '%s', pageToken: '%s')\n", requestID, limit, query, pageToken)
 	log.Printf("[Gmail] [%s] 🚀 Calling Gmail API: Users.Messages.List\n", requestID)
 	apiStartTime := time.Now()
 
-	listCall := service.Users.Messages.List("me").MaxResults(maxResults)
-	if query != "" {
-		listCall = listCall.Q(query)
+	messageList, err := listMessagePages(gmailMessagePages(ctx, service, query), pageToken, limit, capped, requestID)
+	if err != nil {
+		log.Printf("[Gmail] [%s] ❌ Gmail API call FAILED after %v: %v\n", requestID, time.Since(apiStartTime), err)
+		return nil, err
 	}
 
-	messageList, err := listCall.Do()
-	apiDuration := time.Since(apiStartTime)
-	
+	log.Printf("[Gmail] [%s] 📋 Messages listed: %d messages found, fetching headers\n", requestID, len(messageList.messages))
+
+	// Fetch lightweight metadata for each message so downstream steps get sender/subject/date
+	metadata, err := fetchMessageMetadata(ctx, gmailMessageMetadata(service), messageList.messages, requestID)
 	if err != nil {
-		log.Printf("[Gmail] [%s] ❌ Gmail API call FAILED after %v: %v\n", requestID, apiDuration, err)
-		return nil, fmt.Errorf("failed to list messages: %w", err)
+		return nil, err
 	}
 
-	log.Printf("[Gmail] [%s] ✅ Gmail API call SUCCESS in %v\n", requestID, apiDuration)
-	log.Printf("[Gmail] [%s] 📋 Messages listed successfully: %d messages found\n", requestID, len(messageList.Messages))
+	messages := make([]map[string]interface{}, 0, len(messageList.messages))
+	for i, msg := range messageList.messages {
+		meta := metadata[i]
+		headers := make(map[string]string)
+		if meta.Payload != nil {
+			for _, header := range meta.Payload.Headers {
+				headers[header.Name] = header.Value
+			}
+		}
 
-	messages := make([]map[string]interface{}, 0, len(messageList.Messages))
-	for _, msg := range messageList.Messages {
+		// message_id matches the other Gmail functions; id is kept for workflows written against it
 		messages = append(messages, map[string]interface{}{
 			"message_id": msg.Id,
+			"id":         msg.Id,
 			"thread_id":  msg.ThreadId,
+			"from":       headers["From"],
+			"subject":    headers["Subject"],
+			"snippet":    meta.Snippet,
+			"date":       headers["Date"],
 		})
 	}
 
+	apiDuration := time.Since(apiStartTime)
+	log.Printf("[Gmail] [%s] ✅ Gmail API calls SUCCESS in %v\n", requestID, apiDuration)
+
+	if messageList.truncated {
+		log.Printf("[Gmail] [%s] ⚠️ Listing stopped at the max_total safeguard of %d messages\n", requestID, limit)
+	}
+
 	return map[string]interface{}{
 		"messages":             messages,
-		"next_page_token":      messageList.NextPageToken,
-		"result_size_estimate": messageList.ResultSizeEstimate,
+		"next_page_token":      messageList.nextPageToken,
+		"result_size_estimate": messageList.resultSizeEstimate,
 		"total_messages":       len(messages),
+		"pages":                messageList.pages,
+		"truncated":            messageList.truncated,
 		"api_duration_ms":      apiDuration.Milliseconds(),
 	}, nil
 }
@@ -501,18 +852,18 @@ func (p *GmailProxy) searchMessages(ctx context.Context, service *gmail.Service,
 func (p *GmailProxy) searchMessagesWithLogging(ctx context.Context, service *gmail.Service, payload map[string]interface{}, requestID string) (map[string]interface{}, error) {
 	query := payload["query"].(string)
 
-	maxResults := int64(10) // default
-	if mr, ok := payload["max_results"]; ok {
-		if mrInt, ok := mr.(float64); ok {
-			maxResults = int64(mrInt)
-		}
+	// Large requests are paged and capped by max_total
+	limit, capped := p.listMessageLimit(payload)
+	pageToken := ""
+	if pt, ok := payload["page_token"].(string); ok {
+		pageToken = pt
 	}
 
-	log.Printf("[Gmail] [%s] 🔍 Searching messages (query: '%s', maxResults: %d)\n", requestID, query, maxResults)
+	log.Printf("[Gmail] [%s] 🔍 Searching messages (query: '%s', limit: %d)\n", requestID, query, limit)
 	log.Printf("[Gmail] [%s] 🚀 Calling Gmail API: Users.Messages.List (with query)\n", requestID)
 	apiStartTime := time.Now()
 
-	messageList, err := service.Users.Messages.List("me").Q(query).MaxResults(maxResults).Do()
+	messageList, err := listMessagePages(gmailMessagePages(ctx, service, query), pageToken, limit, capped, requestID)
 	apiDuration := time.Since(apiStartTime)
 	
 	if err != nil {
@@ -521,10 +872,10 @@ func (p *GmailProxy) searchMessagesWithLogging(ctx context.Context, service *gma
 	}
 
 	log.Printf("[Gmail] [%s] ✅ Gmail API call SUCCESS in %v\n", requestID, apiDuration)
-	log.Printf("[Gmail] [%s] 🔍 Search completed: %d matches found\n", requestID, len(messageList.Messages))
+	log.Printf("[Gmail] [%s] 🔍 Search completed: %d matches found\n", requestID, len(messageList.messages))
 
-	messages := make([]map[string]interface{}, 0, len(messageList.Messages))
-	for _, msg := range messageList.Messages {
+	messages := make([]map[string]interface{}, 0, len(messageList.messages))
+	for _, msg := range messageList.messages {
 		messages = append(messages, map[string]interface{}{
 			"message_id": msg.Id,
 			"thread_id":  msg.ThreadId,
@@ -534,13 +885,76 @@ func (p *GmailProxy) searchMessagesWithLogging(ctx context.Context, service *gma
 	return map[string]interface{}{
 		"query":                query,
 		"messages":             messages,
-		"next_page_token":      messageList.NextPageToken,
-		"result_size_estimate": messageList.ResultSizeEstimate,
+		"next_page_token":      messageList.nextPageToken,
+		"result_size_estimate": messageList.resultSizeEstimate,
 		"total_matches":        len(messages),
+		"pages":                messageList.pages,
+		"truncated":            messageList.truncated,
 		"api_duration_ms":      apiDuration.Milliseconds(),
 	}, nil
 }
 
+func (p *GmailProxy) modifyLabelsWithLogging(ctx context.Context, service *gmail.Service, payload map[string]interface{}, requestID string) (map[string]interface{}, error) {
+	messageID, request, err := modifyLabelsRequest(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("[Gmail] [%s] 🏷️ Modifying labels on message %s (add: %v, remove: %v)\n", requestID, messageID, request.AddLabelIds, request.RemoveLabelIds)
+	result, err := p.modifyMessageLabels(service, messageID, request, requestID)
+	if err != nil {
+		return nil, err
+	}
+	result["added_label_ids"] = request.AddLabelIds
+	result["removed_label_ids"] = request.RemoveLabelIds
+	return result, nil
+}
+
+func (p *GmailProxy) archiveMessageWithLogging(ctx context.Context, service *gmail.Service, payload map[string]interface{}, requestID string) (map[string]interface{}, error) {
+	messageID, ok := payload["message_id"].(string)
+	if !ok || messageID == "" {
+		return nil, fmt.Errorf("message_id must be a non-empty string")
+	}
+
+	log.Printf("[Gmail] [%s] 🗄️ Archiving message %s\n", requestID, messageID)
+	result, err := p.modifyMessageLabels(service, messageID, archiveRequest(), requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	labelIDs, _ := result["label_ids"].([]string)
+	result["archived"] = isArchived(labelIDs)
+	return result, nil
+}
+
+// modifyMessageLabels calls Users.Messages.Modify and returns the message's resulting labels
+func (p *GmailProxy) modifyMessageLabels(service *gmail.Service, messageID string, request *gmail.ModifyMessageRequest, requestID string) (map[string]interface{}, error) {
+	log.Printf("[Gmail] [%s] 🚀 Calling Gmail API: Users.Messages.Modify\n", requestID)
+	apiStartTime := time.Now()
+
+	message, err := service.Users.Messages.Modify("me", messageID, request).Do()
+	apiDuration := time.Since(apiStartTime)
+
+	if err != nil {
+		log.Printf("[Gmail] [%s] ❌ Gmail API call FAILED after %v: %v\n", requestID, apiDuration, err)
+		return nil, fmt.Errorf("failed to modify labels of message %s: %w", messageID, err)
+	}
+
+	log.Printf("[Gmail] [%s] ✅ Gmail API call SUCCESS in %v\n", requestID, apiDuration)
+	log.Printf("[Gmail] [%s]    Label IDs: %v\n", requestID, message.LabelIds)
+
+	labelIDs := message.LabelIds
+	if labelIDs == nil {
+		labelIDs = []string{}
+	}
+	return map[string]interface{}{
+		"message_id":      message.Id,
+		"thread_id":       message.ThreadId,
+		"label_ids":       labelIDs,
+		"api_duration_ms": apiDuration.Milliseconds(),
+	}, nil
+}
+
 func (p *GmailProxy) createRawMessage(to, subject, body string) string {
 	// Create RFC 2822 compliant email message with proper headers
 	message := fmt.Sprintf(
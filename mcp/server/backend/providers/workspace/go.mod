@@ -5,6 +5,7 @@ go 1.23.2
 require (
 	github.com/dimitar-trifonov/sohoaas/service-proxies/workflow v0.0.0-00010101000000-000000000000
 	github.com/gin-gonic/gin v1.10.1
+	golang.org/x/net v0.42.0
 	golang.org/x/oauth2 v0.30.0
 	google.golang.org/api v0.243.0
 )
@@ -46,7 +47,6 @@ require (
 	go.opentelemetry.io/otel/trace v1.36.0 // indirect
 	golang.org/x/arch v0.8.0 // indirect
 	golang.org/x/crypto v0.40.0 // indirect
-	golang.org/x/net v0.42.0 // indirect
 	golang.org/x/sys v0.34.0 // indirect
 	golang.org/x/text v0.27.0 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20250715232539-7130f93afb79 // indirect
@@ -0,0 +1,61 @@
+package workspace
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/oauth2"
+)
+
+// serviceScopes lists, per service, the OAuth scopes any one of which lets its proxy call the API
+var serviceScopes = map[string][]string{
+	ServiceTypeGmail: {
+		"https://mail.google.com/",
+		"https://www.googleapis.com/auth/gmail.modify",
+		"https://www.googleapis.com/auth/gmail.send",
+		"https://www.googleapis.com/auth/gmail.readonly",
+	},
+	ServiceTypeDocs: {
+		"https://www.googleapis.com/auth/documents",
+		"https://www.googleapis.com/auth/documents.readonly",
+	},
+	ServiceTypeDrive: {
+		"https://www.googleapis.com/auth/drive",
+		"https://www.googleapis.com/auth/drive.file",
+		"https://www.googleapis.com/auth/drive.readonly",
+	},
+	ServiceTypeCalendar: {
+		"https://www.googleapis.com/auth/calendar",
+		"https://www.googleapis.com/auth/calendar.events",
+		"https://www.googleapis.com/auth/calendar.readonly",
+	},
+}
+
+// ServiceHealth is the outcome of a service proxy's self-check at registration
+type ServiceHealth struct {
+	Healthy bool   `json:"healthy"`
+	Reason  string `json:"reason,omitempty"` // why the service is unusable, when it isn't healthy
+}
+
+// CheckServiceConfig verifies the OAuth client and scopes a service proxy needs are configured.
+// It makes no API calls, so a healthy service can still fail on an invalid token.
+func CheckServiceConfig(serviceType string, config *oauth2.Config) ServiceHealth {
+	accepted, known := serviceScopes[serviceType]
+	switch {
+	case !known:
+		return ServiceHealth{Reason: fmt.Sprintf("unknown service type %s", serviceType)}
+	case config == nil:
+		return ServiceHealth{Reason: "no OAuth2 configuration"}
+	case config.ClientID == "" || config.ClientSecret == "":
+		return ServiceHealth{Reason: "OAuth2 client ID or secret is not configured"}
+	}
+
+	for _, scope := range config.Scopes {
+		for _, acceptedScope := range accepted {
+			if scope == acceptedScope {
+				return ServiceHealth{Healthy: true}
+			}
+		}
+	}
+	return ServiceHealth{Reason: fmt.Sprintf("none of the scopes %s is configured", strings.Join(accepted, ", "))}
+}
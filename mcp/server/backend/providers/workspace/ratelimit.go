@@ -0,0 +1,161 @@
+package workspace
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dimitar-trifonov/sohoaas/service-proxies/workflow"
+	"google.golang.org/api/googleapi"
+)
+
+// Backoff settings for Google API rate-limit responses
+const (
+	rateLimitMaxAttempts = 4
+	rateLimitBaseDelay   = 1 * time.Second
+	rateLimitMaxDelay    = 30 * time.Second
+)
+
+// rateLimitReasons are the 403 error reasons Google uses for quota exhaustion
+var rateLimitReasons = map[string]bool{
+	"userRateLimitExceeded": true,
+	"rateLimitExceeded":     true,
+	"quotaExceeded":         true,
+}
+
+// withRateLimitBackoff runs call and retries it with exponential backoff while
+// Google reports a rate limit, honoring Retry-After when provided. The whole call
+// is retried, so it should only wrap work whose failing API request had no effect.
+// Returns a *workflow.RateLimitError once attempts are exhausted.
+func withRateLimitBackoff(ctx context.Context, serviceType, requestID string, call func() error) error {
+	delay := rateLimitBaseDelay
+
+	for attempt := 1; ; attempt++ {
+		err := call()
+		rlErr := asRateLimitError(serviceType, err)
+		if rlErr == nil {
+			return err
+		}
+		// A nested backoff, such as one per listed message, already used up its attempts
+		if rlErr.Attempts >= rateLimitMaxAttempts {
+			return err
+		}
+		rlErr.Attempts = attempt
+
+		if attempt >= rateLimitMaxAttempts {
+			log.Printf("[%s] [%s] ❌ Rate limit persisted after %d attempts: %v\n", serviceType, requestID, attempt, rlErr)
+			return rlErr
+		}
+
+		wait := delay
+		if rlErr.RetryAfter > wait {
+			wait = rlErr.RetryAfter
+		}
+		if wait > rateLimitMaxDelay {
+			wait = rateLimitMaxDelay
+		}
+		log.Printf("[%s] [%s] ⏳ Rate limited (HTTP %d, %s), retrying in %v (attempt %d/%d)\n",
+			serviceType, requestID, rlErr.StatusCode, rlErr.Reason, wait, attempt, rateLimitMaxAttempts)
+
+		// Queued tool calls may use the call slot while this one waits
+		if err := workflow.WaitOutsideSlot(ctx, wait); err != nil {
+			return rlErr
+		}
+		delay *= 2
+	}
+}
+
+// asRateLimitError converts a Google API 429 or 403 rate-limit error into a
+// *workflow.RateLimitError; returns nil for any other error
+func asRateLimitError(serviceType string, err error) *workflow.RateLimitError {
+	if err == nil {
+		return nil
+	}
+
+	var existing *workflow.RateLimitError
+	if errors.As(err, &existing) {
+		return existing
+	}
+
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return nil
+	}
+
+	reason := ""
+	for _, item := range apiErr.Errors {
+		if rateLimitReasons[item.Reason] {
+			reason = item.Reason
+			break
+		}
+	}
+
+	switch {
+	case apiErr.Code == http.StatusTooManyRequests:
+		if reason == "" {
+			reason = "tooManyRequests"
+		}
+	case apiErr.Code == http.StatusForbidden && reason != "":
+		// 403 only counts as a rate limit when Google says so
+	default:
+		return nil
+	}
+
+	return &workflow.RateLimitError{
+		ServiceType:    serviceType,
+		StatusCode:     apiErr.Code,
+		Reason:         reason,
+		RetryAfter:     parseRetryAfter(apiErr.Header),
+		RemainingQuota: extractQuotaHeaders(apiErr.Header),
+	}
+}
+
+// parseRetryAfter reads a Retry-After header in either seconds or HTTP-date form
+func parseRetryAfter(header http.Header) time.Duration {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if when, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(when); wait > 0 {
+			return wait
+		}
+	}
+	return 0
+}
+
+// extractQuotaHeaders collects rate-limit/quota headers when Google provides them
+func extractQuotaHeaders(header http.Header) map[string]string {
+	quota := make(map[string]string)
+	for name, values := range header {
+		lower := strings.ToLower(name)
+		if strings.Contains(lower, "ratelimit") || strings.Contains(lower, "quota") {
+			quota[name] = strings.Join(values, ",")
+		}
+	}
+	if len(quota) == 0 {
+		return nil
+	}
+	return quota
+}
+
+// rateLimitedResponse builds the proxy response returned alongside a RateLimitError
+func rateLimitedResponse(rlErr *workflow.RateLimitError) *workflow.ProxyResponse {
+	return &workflow.ProxyResponse{
+		Success: false,
+		Error: &workflow.ProxyError{
+			Code:        ErrorCodeRateLimited,
+			Message:     "Google API rate limit exceeded",
+			Details:     rlErr.Error(),
+			ServiceType: rlErr.ServiceType,
+			Retryable:   true,
+		},
+	}
+}
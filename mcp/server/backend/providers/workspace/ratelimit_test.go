@@ -0,0 +1,126 @@
+package workspace
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/dimitar-trifonov/sohoaas/service-proxies/workflow"
+	"google.golang.org/api/googleapi"
+)
+
+// TestAsRateLimitError verifies only 429s and 403s with a quota reason count as rate limits
+func TestAsRateLimitError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantReason string
+	}{
+		{"nil", nil, ""},
+		{"plain error", errors.New("connection reset"), ""},
+		{"429", &googleapi.Error{Code: http.StatusTooManyRequests}, "tooManyRequests"},
+		{"429 with reason", &googleapi.Error{Code: http.StatusTooManyRequests, Errors: []googleapi.ErrorItem{{Reason: "rateLimitExceeded"}}}, "rateLimitExceeded"},
+		{"403 user rate limit", &googleapi.Error{Code: http.StatusForbidden, Errors: []googleapi.ErrorItem{{Reason: "userRateLimitExceeded"}}}, "userRateLimitExceeded"},
+		{"403 permission denied", &googleapi.Error{Code: http.StatusForbidden, Errors: []googleapi.ErrorItem{{Reason: "insufficientPermissions"}}}, ""},
+		{"500", &googleapi.Error{Code: http.StatusInternalServerError}, ""},
+		{"already converted", &workflow.RateLimitError{ServiceType: "gmail", Reason: "quotaExceeded"}, "quotaExceeded"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rlErr := asRateLimitError("gmail", tt.err)
+			if tt.wantReason == "" {
+				if rlErr != nil {
+					t.Errorf("Expected no rate limit, got %v", rlErr)
+				}
+				return
+			}
+			if rlErr == nil || rlErr.Reason != tt.wantReason || rlErr.ServiceType != "gmail" {
+				t.Errorf("Expected a gmail rate limit with reason %s, got %+v", tt.wantReason, rlErr)
+			}
+		})
+	}
+}
+
+// TestParseRetryAfter verifies both Retry-After forms and ignores unusable values
+func TestParseRetryAfter(t *testing.T) {
+	header := func(value string) http.Header {
+		return http.Header{"Retry-After": []string{value}}
+	}
+	if got := parseRetryAfter(header("30")); got != 30*time.Second {
+		t.Errorf("Expected 30s, got %v", got)
+	}
+	if got := parseRetryAfter(header(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))); got <= 55*time.Second || got > time.Minute {
+		t.Errorf("Expected about a minute, got %v", got)
+	}
+	for _, value := range []string{"", "0", "-5", "soon", time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat)} {
+		if got := parseRetryAfter(header(value)); got != 0 {
+			t.Errorf("Retry-After %q: expected no hint, got %v", value, got)
+		}
+	}
+}
+
+// TestExtractQuotaHeaders verifies only rate-limit and quota headers are collected
+func TestExtractQuotaHeaders(t *testing.T) {
+	quota := extractQuotaHeaders(http.Header{
+		"X-Ratelimit-Remaining": []string{"0"},
+		"X-Goog-Quota-User":     []string{"u1", "u2"},
+		"Content-Type":          []string{"application/json"},
+	})
+	if len(quota) != 2 || quota["X-Ratelimit-Remaining"] != "0" || quota["X-Goog-Quota-User"] != "u1,u2" {
+		t.Errorf("Unexpected quota headers: %v", quota)
+	}
+	if quota := extractQuotaHeaders(http.Header{"Content-Type": []string{"text/plain"}}); quota != nil {
+		t.Errorf("Expected nil without quota headers, got %v", quota)
+	}
+}
+
+// TestWithRateLimitBackoff verifies rate limits are retried and other results returned as they are
+func TestWithRateLimitBackoff(t *testing.T) {
+	rateLimited := &googleapi.Error{Code: http.StatusTooManyRequests}
+
+	calls := 0
+	err := withRateLimitBackoff(context.Background(), "gmail", "test", func() error {
+		calls++
+		return errors.New("not found")
+	})
+	if err == nil || err.Error() != "not found" || calls != 1 {
+		t.Errorf("Expected other errors to be returned without retrying, got %v after %d calls", err, calls)
+	}
+
+	calls = 0
+	err = withRateLimitBackoff(context.Background(), "gmail", "test", func() error {
+		calls++
+		if calls == 1 {
+			return rateLimited
+		}
+		return nil
+	})
+	if err != nil || calls != 2 {
+		t.Errorf("Expected success on the retry, got %v after %d calls", err, calls)
+	}
+
+	// A canceled context stops the backoff after the first attempt
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	calls = 0
+	err = withRateLimitBackoff(ctx, "gmail", "test", func() error {
+		calls++
+		return rateLimited
+	})
+	var rlErr *workflow.RateLimitError
+	if !errors.As(err, &rlErr) || rlErr.Attempts != 1 || calls != 1 {
+		t.Errorf("Expected a RateLimitError after 1 attempt, got %v after %d calls", err, calls)
+	}
+
+	// A rate limit a nested backoff gave up on is not retried again
+	calls = 0
+	err = withRateLimitBackoff(context.Background(), "gmail", "test", func() error {
+		calls++
+		return &workflow.RateLimitError{ServiceType: "gmail", StatusCode: http.StatusTooManyRequests, Attempts: rateLimitMaxAttempts}
+	})
+	if !errors.As(err, &rlErr) || calls != 1 {
+		t.Errorf("Expected the exhausted RateLimitError without retrying, got %v after %d calls", err, calls)
+	}
+}
@@ -0,0 +1,73 @@
+package workspace
+
+import (
+	"fmt"
+	"strings"
+)
+
+// googleScopePrefix is prepended to short scope names such as "gmail.readonly"
+const googleScopePrefix = "https://www.googleapis.com/auth/"
+
+// DefaultScopes are requested when no scopes are configured; each grants a service full access
+var DefaultScopes = []string{
+	googleScopePrefix + "gmail.modify",
+	googleScopePrefix + "documents",
+	googleScopePrefix + "drive",
+	googleScopePrefix + "calendar",
+}
+
+// ParseScopes reads a comma-separated scope list such as "gmail.readonly,drive.file",
+// expanding short names. An empty list yields DefaultScopes; scopes no workspace service
+// accepts are rejected so a typo can't silently disable a service.
+func ParseScopes(raw string) ([]string, error) {
+	var scopes []string
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(raw, ",") {
+		scope := strings.TrimSpace(entry)
+		if scope == "" {
+			continue
+		}
+		if !strings.Contains(scope, "://") {
+			scope = googleScopePrefix + scope
+		}
+		if ScopeServices(scope) == nil {
+			return nil, fmt.Errorf("OAuth scope %s is not accepted by any workspace service", scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+	if len(scopes) == 0 {
+		return append([]string(nil), DefaultScopes...), nil
+	}
+	return scopes, nil
+}
+
+// ScopeServices returns the workspace services that accept scope
+func ScopeServices(scope string) []string {
+	var services []string
+	for _, service := range []string{ServiceTypeGmail, ServiceTypeDocs, ServiceTypeDrive, ServiceTypeCalendar} {
+		for _, accepted := range serviceScopes[service] {
+			if accepted == scope {
+				services = append(services, service)
+				break
+			}
+		}
+	}
+	return services
+}
+
+// ScopesForService returns the configured scopes serviceType accepts, in configured order
+func ScopesForService(serviceType string, scopes []string) []string {
+	var configured []string
+	for _, scope := range scopes {
+		for _, accepted := range serviceScopes[serviceType] {
+			if scope == accepted {
+				configured = append(configured, scope)
+				break
+			}
+		}
+	}
+	return configured
+}
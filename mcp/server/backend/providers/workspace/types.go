@@ -18,7 +18,7 @@ type ProxyRequest struct {
 // Note: ProxyResponse, ProxyError, and ResponseMetadata types have been moved to the workflow package
 // for unified cross-provider compatibility. All workspace proxies now use workflow.ProxyResponse.
 
-// ResponseSchema represents the schema for function outputs and errors
+// ResponseSchema represents the schema for function inputs, outputs and errors
 type ResponseSchema struct {
 	Type        string                           `json:"type"`
 	Description string                           `json:"description,omitempty"`
@@ -28,8 +28,9 @@ type ResponseSchema struct {
 
 // PropertySchema represents individual property schema
 type PropertySchema struct {
-	Type        string `json:"type"`
-	Description string `json:"description,omitempty"`
+	Type        string   `json:"type"`
+	Description string   `json:"description,omitempty"`
+	Enum        []string `json:"enum,omitempty"` // allowed values, for fields that take one of a fixed set
 }
 
 // FunctionMetadata contains metadata about a service function
@@ -39,6 +40,8 @@ type FunctionMetadata struct {
 	Description    string                 `json:"description"`
 	ExamplePayload map[string]interface{} `json:"example_payload"`
 	RequiredFields []string               `json:"required_fields"`
+	// Input fields with constrained values (e.g. enums); fields not listed take any value
+	InputSchema    *ResponseSchema        `json:"input_schema,omitempty"`
 	// Response schema information for workflow generation
 	OutputSchema   *ResponseSchema        `json:"output_schema,omitempty"`
 	ErrorSchema    *ResponseSchema        `json:"error_schema,omitempty"`
@@ -99,6 +102,8 @@ const (
 	GmailFunctionGetMessage     = "get_message"
 	GmailFunctionListMessages   = "list_messages"
 	GmailFunctionSearchMessages = "search_messages"
+	GmailFunctionModifyLabels   = "modify_labels"
+	GmailFunctionArchiveMessage = "archive_message"
 )
 
 // Docs function names
@@ -112,12 +117,14 @@ const (
 
 // Drive function names
 const (
-	DriveFunctionCreateFolder = "create_folder"
-	DriveFunctionUploadFile   = "upload_file"
-	DriveFunctionGetFile      = "get_file"
-	DriveFunctionListFiles    = "list_files"
-	DriveFunctionShareFile    = "share_file"
-	DriveFunctionMoveFile     = "move_file"
+	DriveFunctionCreateFolder     = "create_folder"
+	DriveFunctionUploadFile       = "upload_file"
+	DriveFunctionGetFile          = "get_file"
+	DriveFunctionListFiles        = "list_files"
+	DriveFunctionShareFile        = "share_file"
+	DriveFunctionMoveFile         = "move_file"
+	DriveFunctionListPermissions  = "list_permissions"
+	DriveFunctionRevokePermission = "revoke_permission"
 )
 
 // Calendar function names
@@ -147,4 +154,6 @@ const (
 	PayloadFieldEndTime     = "end_time"
 	PayloadFieldAttendees   = "attendees"
 	PayloadFieldDescription = "description"
+	PayloadFieldAttachments = "attachments"
+	PayloadFieldBodyHTML    = "body_html"
 )
@@ -1,9 +0,0 @@
-package workspace
-
-// Helper function for minimum of two integers
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
-}
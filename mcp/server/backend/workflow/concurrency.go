@@ -0,0 +1,103 @@
+package workflow
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// SetMaxConcurrency caps how many proxy calls the engine runs at once, across all workflows
+// and MCP tool calls. Calls beyond the cap queue until a slot frees up or their context ends.
+// A limit of zero or less removes the cap. Configure it before serving requests.
+func (e *MultiProviderWorkflowEngine) SetMaxConcurrency(limit int) {
+	if limit <= 0 {
+		e.slots = nil
+		return
+	}
+	e.slots = make(chan struct{}, limit)
+}
+
+// MaxConcurrency returns the configured cap on concurrent proxy calls, 0 when unlimited
+func (e *MultiProviderWorkflowEngine) MaxConcurrency() int {
+	return cap(e.slots)
+}
+
+// acquireSlot waits for a free call slot and returns the function that releases it
+func (e *MultiProviderWorkflowEngine) acquireSlot(ctx context.Context) (func(), error) {
+	slots := e.slots
+	if slots == nil {
+		return func() {}, nil
+	}
+	select {
+	case slots <- struct{}{}:
+		return func() { <-slots }, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("waiting for a free call slot: %w", ctx.Err())
+	}
+}
+
+// heldSlot is the slot of one proxy call, given up while the proxy backs off between retries
+type heldSlot struct {
+	engine  *MultiProviderWorkflowEngine
+	mu      sync.Mutex
+	release func() // nil while the slot is given up
+}
+
+type heldSlotKey struct{}
+
+// WaitOutsideSlot sleeps for d, or until ctx ends, with the calling proxy call's slot given
+// up so queued calls can run during a retry backoff. The slot is taken back before it
+// returns; an error means ctx ended and the call should stop. Outside a capped proxy call
+// it only sleeps.
+func WaitOutsideSlot(ctx context.Context, d time.Duration) error {
+	slot, _ := ctx.Value(heldSlotKey{}).(*heldSlot)
+	released := false
+	if slot != nil {
+		slot.mu.Lock()
+		if slot.release != nil {
+			slot.release()
+			slot.release = nil
+			released = true
+		}
+		slot.mu.Unlock()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	if released {
+		release, err := slot.engine.acquireSlot(ctx)
+		if err != nil {
+			return err
+		}
+		slot.mu.Lock()
+		slot.release = release
+		slot.mu.Unlock()
+	}
+	return nil
+}
+
+// callProxy executes one proxy call once a slot is free. The proxy gives the slot up while it
+// backs off between retries (see WaitOutsideSlot), and the engine's own retry delays are spent
+// outside it, so a rate-limited call doesn't hold up queued calls.
+func (e *MultiProviderWorkflowEngine) callProxy(ctx context.Context, proxy ServiceProxy, step WorkflowStep, token string, payload map[string]interface{}) (*ProxyResponse, error) {
+	release, err := e.acquireSlot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	slot := &heldSlot{engine: e, release: release}
+	defer func() {
+		slot.mu.Lock()
+		defer slot.mu.Unlock()
+		if slot.release != nil {
+			slot.release()
+		}
+	}()
+	return proxy.Execute(context.WithValue(ctx, heldSlotKey{}, slot), step.Function, token, payload)
+}
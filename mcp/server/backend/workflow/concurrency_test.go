@@ -0,0 +1,113 @@
+package workflow
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestAcquireSlot verifies calls beyond the cap wait for a release or give up with their context
+func TestAcquireSlot(t *testing.T) {
+	engine := NewMultiProviderWorkflowEngine()
+	engine.SetMaxConcurrency(1)
+	if engine.MaxConcurrency() != 1 {
+		t.Fatalf("Expected a cap of 1, got %d", engine.MaxConcurrency())
+	}
+
+	release, err := engine.acquireSlot(context.Background())
+	if err != nil {
+		t.Fatalf("First acquire failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := engine.acquireSlot(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the queued call to give up with its context, got %v", err)
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		releaseNext, err := engine.acquireSlot(context.Background())
+		if err != nil {
+			t.Errorf("Queued acquire failed: %v", err)
+			return
+		}
+		releaseNext()
+		close(acquired)
+	}()
+	release()
+
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("Queued call never acquired the released slot")
+	}
+}
+
+// TestAcquireSlotUnlimited verifies a cap of zero never blocks
+func TestAcquireSlotUnlimited(t *testing.T) {
+	engine := NewMultiProviderWorkflowEngine()
+	engine.SetMaxConcurrency(0)
+	for i := 0; i < 100; i++ {
+		if _, err := engine.acquireSlot(context.Background()); err != nil {
+			t.Fatalf("Acquire %d failed: %v", i, err)
+		}
+	}
+	if engine.MaxConcurrency() != 0 {
+		t.Errorf("Expected no cap, got %d", engine.MaxConcurrency())
+	}
+}
+
+// slotWaitingProxy backs off once inside its call, the way the Google proxies do on a rate limit
+type slotWaitingProxy struct {
+	waiting chan struct{}
+	wait    time.Duration
+}
+
+func (p *slotWaitingProxy) Execute(ctx context.Context, function string, token string, payload map[string]interface{}) (*ProxyResponse, error) {
+	close(p.waiting)
+	if err := WaitOutsideSlot(ctx, p.wait); err != nil {
+		return nil, err
+	}
+	return &ProxyResponse{Success: true}, nil
+}
+
+func (p *slotWaitingProxy) GetSupportedFunctions() []string                      { return nil }
+func (p *slotWaitingProxy) GetServiceCapabilities() map[string]interface{}       { return nil }
+func (p *slotWaitingProxy) ValidateRequest(string, map[string]interface{}) error { return nil }
+
+// TestWaitOutsideSlot verifies a proxy backing off gives up its slot and takes it back afterwards
+func TestWaitOutsideSlot(t *testing.T) {
+	engine := NewMultiProviderWorkflowEngine()
+	engine.SetMaxConcurrency(1)
+	proxy := &slotWaitingProxy{waiting: make(chan struct{}), wait: 200 * time.Millisecond}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := engine.callProxy(context.Background(), proxy, WorkflowStep{Function: "list"}, "token", nil)
+		done <- err
+	}()
+	<-proxy.waiting
+
+	// The backing-off call doesn't hold the only slot
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+	release, err := engine.acquireSlot(ctx)
+	if err != nil {
+		t.Fatalf("Expected the slot to be free during the backoff, got %v", err)
+	}
+	release()
+
+	if err := <-done; err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(engine.slots) != 0 {
+		t.Errorf("Expected the slot released after the call, %d still held", len(engine.slots))
+	}
+
+	// Outside a capped call it only waits
+	if err := WaitOutsideSlot(context.Background(), time.Millisecond); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
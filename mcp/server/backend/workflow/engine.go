@@ -2,6 +2,7 @@ package workflow
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -60,20 +61,22 @@ type WorkflowStep struct {
 
 // WorkflowExecution represents the execution state of a workflow
 type WorkflowExecution struct {
-	ID           string                    `json:"id"`
-	Steps        []WorkflowStep            `json:"steps"`
-	StepResults  map[string]*ProxyResponse `json:"step_results"`
-	Input        map[string]interface{}    `json:"input"`
-	Status       string                    `json:"status"`
-	StartTime    time.Time                 `json:"start_time"`
-	EndTime      *time.Time                `json:"end_time,omitempty"`
-	ErrorMessage string                    `json:"error_message,omitempty"`
+	ID           string                            `json:"id"`
+	Steps        []WorkflowStep                    `json:"steps"`
+	StepResults  map[string]*ProxyResponse         `json:"step_results"`
+	StepOutputs  map[string]map[string]interface{} `json:"step_outputs"` // step_id -> data of each successful step, for ${steps.<id>.outputs.<field>}
+	Input        map[string]interface{}            `json:"input"`
+	Status       string                            `json:"status"`
+	StartTime    time.Time                         `json:"start_time"`
+	EndTime      *time.Time                        `json:"end_time,omitempty"`
+	ErrorMessage string                            `json:"error_message,omitempty"`
 }
 
 // MultiProviderWorkflowEngine orchestrates workflows across multiple service providers
 type MultiProviderWorkflowEngine struct {
 	serviceProxies map[string]ServiceProxy // provider_service -> proxy (e.g., "workspace_gmail", "office365_outlook")
 	tokens         map[string]string       // provider -> oauth_token (e.g., "workspace" -> token, "office365" -> token)
+	slots          chan struct{}           // one entry per running proxy call; nil when unlimited
 }
 
 // NewMultiProviderWorkflowEngine creates a new provider-agnostic workflow engine
@@ -101,6 +104,7 @@ func (e *MultiProviderWorkflowEngine) ExecuteWorkflow(ctx context.Context, steps
 		ID:          fmt.Sprintf("workflow_%d", time.Now().Unix()),
 		Steps:       steps,
 		StepResults: make(map[string]*ProxyResponse),
+		StepOutputs: make(map[string]map[string]interface{}),
 		Input:       input,
 		Status:      "running",
 		StartTime:   time.Now(),
@@ -118,7 +122,14 @@ func (e *MultiProviderWorkflowEngine) ExecuteWorkflow(ctx context.Context, steps
 		}
 
 		// Resolve payload with data from previous steps
-		resolvedPayload := e.resolvePayload(step.Payload, execution)
+		resolvedPayload, err := e.resolvePayload(step.Payload, execution)
+		if err != nil {
+			execution.Status = "failed"
+			execution.ErrorMessage = fmt.Sprintf("Step %s payload resolution failed: %v", step.ID, err)
+			endTime := time.Now()
+			execution.EndTime = &endTime
+			return execution, fmt.Errorf("failed to resolve payload for step %s: %w", step.ID, err)
+		}
 
 		// Execute the step using the appropriate service proxy
 		response, err := e.executeStep(ctx, step, resolvedPayload)
@@ -130,8 +141,11 @@ func (e *MultiProviderWorkflowEngine) ExecuteWorkflow(ctx context.Context, steps
 			return execution, err
 		}
 
-		// Store the result
+		// Store the result and capture its outputs for later steps, whatever their provider
 		execution.StepResults[step.ID] = response
+		if response != nil && response.Success {
+			execution.StepOutputs[step.ID] = response.Data
+		}
 	}
 
 	execution.Status = "completed"
@@ -163,7 +177,7 @@ func (e *MultiProviderWorkflowEngine) executeStep(ctx context.Context, step Work
 	}
 
 	// Execute without retry
-	return proxy.Execute(ctx, step.Function, token, payload)
+	return e.callProxy(ctx, proxy, step, token, payload)
 }
 
 // executeWithRetry executes a step with retry logic
@@ -183,13 +197,19 @@ func (e *MultiProviderWorkflowEngine) executeWithRetry(ctx context.Context, prox
 			delay = time.Duration(float64(delay) * step.RetryPolicy.BackoffFactor)
 		}
 
-		response, err := proxy.Execute(ctx, step.Function, token, payload)
+		response, err := e.callProxy(ctx, proxy, step, token, payload)
 		if err == nil {
 			return response, nil
 		}
 
 		lastErr = err
 
+		// Honor provider rate-limit hints before the next attempt
+		var rlErr *RateLimitError
+		if errors.As(err, &rlErr) && rlErr.RetryAfter > delay {
+			delay = rlErr.RetryAfter
+		}
+
 		// Check if the error is retryable
 		if response != nil && response.Error != nil && !response.Error.Retryable {
 			break
@@ -214,53 +234,125 @@ func (e *MultiProviderWorkflowEngine) areDependenciesSatisfied(step WorkflowStep
 }
 
 // resolvePayload resolves payload references to data from previous steps
-func (e *MultiProviderWorkflowEngine) resolvePayload(payload map[string]interface{}, execution *WorkflowExecution) map[string]interface{} {
+func (e *MultiProviderWorkflowEngine) resolvePayload(payload map[string]interface{}, execution *WorkflowExecution) (map[string]interface{}, error) {
 	resolved := make(map[string]interface{})
 
 	for key, value := range payload {
-		resolved[key] = e.resolveValue(value, execution)
+		resolvedValue, err := e.resolveValue(value, execution)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %s: %w", key, err)
+		}
+		resolved[key] = resolvedValue
 	}
 
-	return resolved
+	return resolved, nil
 }
 
-// resolveValue resolves a single value, handling references to previous step results
-func (e *MultiProviderWorkflowEngine) resolveValue(value interface{}, execution *WorkflowExecution) interface{} {
+// resolveValue resolves a single value, handling references to previous step results.
+// A string that is exactly one reference keeps the referenced value's type; references
+// embedded in a longer string are interpolated as text.
+func (e *MultiProviderWorkflowEngine) resolveValue(value interface{}, execution *WorkflowExecution) (interface{}, error) {
 	switch v := value.(type) {
 	case string:
-		// Handle step result references like "${step_id.field_name}"
-		if strings.HasPrefix(v, "${") && strings.HasSuffix(v, "}") {
-			ref := v[2 : len(v)-1] // Remove ${ and }
-			parts := strings.Split(ref, ".")
-			if len(parts) >= 2 {
-				stepID := parts[0]
-				fieldPath := strings.Join(parts[1:], ".")
-
-				if result, exists := execution.StepResults[stepID]; exists && result.Success {
-					if resolvedValue := e.getNestedValue(result.Data, fieldPath); resolvedValue != nil {
-						return resolvedValue
-					}
-					// If field not found, return original template for debugging
-					return fmt.Sprintf("[UNRESOLVED: %s]", v)
-				}
-			}
+		if strings.HasPrefix(v, "${") && strings.HasSuffix(v, "}") && strings.Count(v, "${") == 1 {
+			return e.resolveReference(v, execution)
 		}
-		return v
+		return e.interpolateStepOutputs(v, execution)
 	case map[string]interface{}:
 		resolved := make(map[string]interface{})
 		for k, val := range v {
-			resolved[k] = e.resolveValue(val, execution)
+			resolvedValue, err := e.resolveValue(val, execution)
+			if err != nil {
+				return nil, err
+			}
+			resolved[k] = resolvedValue
 		}
-		return resolved
+		return resolved, nil
 	case []interface{}:
 		resolved := make([]interface{}, len(v))
 		for i, val := range v {
-			resolved[i] = e.resolveValue(val, execution)
+			resolvedValue, err := e.resolveValue(val, execution)
+			if err != nil {
+				return nil, err
+			}
+			resolved[i] = resolvedValue
 		}
-		return resolved
+		return resolved, nil
 	default:
-		return v
+		return v, nil
+	}
+}
+
+// resolveReference resolves a whole-string reference, either "${steps.<step_id>.outputs.<field>}"
+// or the legacy "${step_id.field_name}"
+func (e *MultiProviderWorkflowEngine) resolveReference(template string, execution *WorkflowExecution) (interface{}, error) {
+	ref := template[2 : len(template)-1] // Remove ${ and }
+	if strings.HasPrefix(ref, "steps.") {
+		return e.resolveStepOutput(ref, execution)
+	}
+
+	// Handle step result references like "${step_id.field_name}"
+	parts := strings.Split(ref, ".")
+	if len(parts) >= 2 {
+		stepID := parts[0]
+		fieldPath := strings.Join(parts[1:], ".")
+
+		if result, exists := execution.StepResults[stepID]; exists && result.Success {
+			if resolvedValue := e.getNestedValue(result.Data, fieldPath); resolvedValue != nil {
+				return resolvedValue, nil
+			}
+			// If field not found, return original template for debugging
+			return fmt.Sprintf("[UNRESOLVED: %s]", template), nil
+		}
+	}
+	return template, nil
+}
+
+// resolveStepOutput looks up "steps.<step_id>.outputs.<field>" in the captured step outputs
+func (e *MultiProviderWorkflowEngine) resolveStepOutput(ref string, execution *WorkflowExecution) (interface{}, error) {
+	parts := strings.SplitN(ref, ".", 4)
+	if len(parts) != 4 || parts[1] == "" || parts[2] != "outputs" || parts[3] == "" {
+		return nil, fmt.Errorf("invalid step reference ${%s}, expected ${steps.<step_id>.outputs.<field>}", ref)
+	}
+	stepID, fieldPath := parts[1], parts[3]
+
+	outputs, exists := execution.StepOutputs[stepID]
+	if !exists {
+		return nil, fmt.Errorf("reference ${%s}: step %s has no outputs (not executed yet or failed)", ref, stepID)
+	}
+	resolvedValue := e.getNestedValue(outputs, fieldPath)
+	if resolvedValue == nil {
+		return nil, fmt.Errorf("reference ${%s}: step %s has no output %s", ref, stepID, fieldPath)
+	}
+	return resolvedValue, nil
+}
+
+// interpolateStepOutputs replaces each ${steps.<step_id>.outputs.<field>} in a string with the
+// output's text form; other ${...} expressions are left untouched
+func (e *MultiProviderWorkflowEngine) interpolateStepOutputs(value string, execution *WorkflowExecution) (string, error) {
+	var builder strings.Builder
+	rest := value
+	for {
+		start := strings.Index(rest, "${steps.")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(rest[start:], "}")
+		if end < 0 {
+			break
+		}
+		end += start
+
+		resolvedValue, err := e.resolveStepOutput(rest[start+2:end], execution)
+		if err != nil {
+			return "", err
+		}
+		builder.WriteString(rest[:start])
+		builder.WriteString(fmt.Sprint(resolvedValue))
+		rest = rest[end+1:]
 	}
+	builder.WriteString(rest)
+	return builder.String(), nil
 }
 
 // getNestedValue retrieves a nested value from a map using dot notation
@@ -0,0 +1,81 @@
+package workflow
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newReferenceTestExecution returns an execution where step "fetch" produced outputs
+func newReferenceTestExecution() *WorkflowExecution {
+	data := map[string]interface{}{
+		"message_id": "msg_1",
+		"count":      3,
+		"labels":     []interface{}{"INBOX", "UNREAD"},
+		"sender":     map[string]interface{}{"email": "a@example.com"},
+	}
+	return &WorkflowExecution{
+		StepResults: map[string]*ProxyResponse{"fetch": {Success: true, Data: data}},
+		StepOutputs: map[string]map[string]interface{}{"fetch": data},
+	}
+}
+
+// TestResolvePayload verifies ${steps.*} references are resolved with their type when they are
+// the whole value and as text when embedded
+func TestResolvePayload(t *testing.T) {
+	engine := NewMultiProviderWorkflowEngine()
+	payload := map[string]interface{}{
+		"message_id": "${steps.fetch.outputs.message_id}",
+		"count":      "${steps.fetch.outputs.count}",
+		"labels":     "${steps.fetch.outputs.labels}",
+		"to":         "${steps.fetch.outputs.sender.email}",
+		"subject":    "Re: ${steps.fetch.outputs.message_id} (${steps.fetch.outputs.count} replies)",
+		"nested":     map[string]interface{}{"ids": []interface{}{"${steps.fetch.outputs.message_id}", "fixed"}},
+		"legacy":     "${fetch.message_id}",
+		"other":      "${user.name}",
+		"max":        10,
+	}
+
+	resolved, err := engine.resolvePayload(payload, newReferenceTestExecution())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := map[string]interface{}{
+		"message_id": "msg_1",
+		"count":      3,
+		"labels":     []interface{}{"INBOX", "UNREAD"},
+		"to":         "a@example.com",
+		"subject":    "Re: msg_1 (3 replies)",
+		"nested":     map[string]interface{}{"ids": []interface{}{"msg_1", "fixed"}},
+		"legacy":     "msg_1",
+		"other":      "${user.name}",
+		"max":        10,
+	}
+	if !reflect.DeepEqual(resolved, expected) {
+		t.Errorf("Expected %v, got %v", expected, resolved)
+	}
+}
+
+// TestResolvePayloadRejectsBadReferences verifies unknown steps, missing outputs and malformed
+// references fail naming the parameter
+func TestResolvePayloadRejectsBadReferences(t *testing.T) {
+	engine := NewMultiProviderWorkflowEngine()
+	tests := []struct {
+		name    string
+		value   string
+		wantErr string
+	}{
+		{"step not run", "${steps.send.outputs.id}", "step send has no outputs"},
+		{"missing output", "${steps.fetch.outputs.thread_id}", "has no output thread_id"},
+		{"missing outputs segment", "${steps.fetch.message_id}", "invalid step reference"},
+		{"embedded bad reference", "id: ${steps.send.outputs.id}", "step send has no outputs"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := engine.resolvePayload(map[string]interface{}{"field": tt.value}, newReferenceTestExecution())
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !strings.Contains(err.Error(), "parameter field") {
+				t.Errorf("Expected an error for parameter field containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package workflow
+
+import (
+	"fmt"
+	"time"
+)
+
+// RateLimitError is returned by service proxies when the upstream provider
+// rejected a call because a quota or rate limit was exceeded
+type RateLimitError struct {
+	ServiceType    string            `json:"service_type"`
+	StatusCode     int               `json:"status_code"`
+	Reason         string            `json:"reason"`                    // e.g. userRateLimitExceeded
+	RetryAfter     time.Duration     `json:"retry_after"`               // zero when the provider gave no hint
+	Attempts       int               `json:"attempts"`                  // calls made before giving up
+	RemainingQuota map[string]string `json:"remaining_quota,omitempty"` // provider quota headers, when present
+}
+
+func (e *RateLimitError) Error() string {
+	msg := fmt.Sprintf("%s rate limit exceeded (HTTP %d, reason: %s) after %d attempts", e.ServiceType, e.StatusCode, e.Reason, e.Attempts)
+	if e.RetryAfter > 0 {
+		msg += fmt.Sprintf(", retry after %v", e.RetryAfter)
+	}
+	return msg
+}
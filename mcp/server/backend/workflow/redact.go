@@ -0,0 +1,109 @@
+package workflow
+
+import (
+	"strings"
+	"sync"
+)
+
+// RedactedValue replaces sensitive values in logged payloads and results
+const RedactedValue = "[REDACTED]"
+
+// sensitiveKeyMarkers mask any field whose name contains one of them (case-insensitive)
+var sensitiveKeyMarkers = []string{"token", "password", "secret", "authorization", "api_key", "apikey"}
+
+var (
+	// Extra field names masked in logs regardless of their value, e.g. email bodies
+	redactedFields   = map[string]bool{}
+	redactedFieldsMu sync.RWMutex
+)
+
+// SetRedactedFields sets additional field names (case-insensitive) whose values are masked in logs
+func SetRedactedFields(fields []string) {
+	redacted := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		if field = strings.ToLower(strings.TrimSpace(field)); field != "" {
+			redacted[field] = true
+		}
+	}
+	redactedFieldsMu.Lock()
+	redactedFields = redacted
+	redactedFieldsMu.Unlock()
+}
+
+// IsSensitiveField reports whether the value of a field must not be logged
+func IsSensitiveField(name string) bool {
+	name = strings.ToLower(name)
+	for _, marker := range sensitiveKeyMarkers {
+		if strings.Contains(name, marker) {
+			return true
+		}
+	}
+	redactedFieldsMu.RLock()
+	defer redactedFieldsMu.RUnlock()
+	return redactedFields[name]
+}
+
+// Redact returns a copy of value safe to log, with sensitive fields of nested maps masked.
+// The value itself is never modified.
+func Redact(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		redacted := make(map[string]interface{}, len(v))
+		for key, field := range v {
+			if IsSensitiveField(key) {
+				redacted[key] = RedactedValue
+			} else {
+				redacted[key] = Redact(field)
+			}
+		}
+		return redacted
+	case []interface{}:
+		redacted := make([]interface{}, len(v))
+		for i, item := range v {
+			redacted[i] = Redact(item)
+		}
+		return redacted
+	case []map[string]interface{}:
+		redacted := make([]interface{}, len(v))
+		for i, item := range v {
+			redacted[i] = Redact(item)
+		}
+		return redacted
+	default:
+		return value
+	}
+}
+
+// RedactExecution returns a copy of a workflow execution safe to log: the input (which
+// carries the OAuth token), step payloads and step outputs are redacted
+func RedactExecution(execution *WorkflowExecution) *WorkflowExecution {
+	if execution == nil {
+		return nil
+	}
+	redacted := *execution
+	redacted.Input, _ = Redact(execution.Input).(map[string]interface{})
+	redacted.Steps = make([]WorkflowStep, len(execution.Steps))
+	for i, step := range execution.Steps {
+		step.Payload, _ = Redact(step.Payload).(map[string]interface{})
+		redacted.Steps[i] = step
+	}
+	if execution.StepOutputs != nil {
+		redacted.StepOutputs = make(map[string]map[string]interface{}, len(execution.StepOutputs))
+		for stepID, outputs := range execution.StepOutputs {
+			redacted.StepOutputs[stepID], _ = Redact(outputs).(map[string]interface{})
+		}
+	}
+	if execution.StepResults != nil {
+		redacted.StepResults = make(map[string]*ProxyResponse, len(execution.StepResults))
+		for stepID, result := range execution.StepResults {
+			if result == nil {
+				redacted.StepResults[stepID] = nil
+				continue
+			}
+			stepResult := *result
+			stepResult.Data, _ = Redact(result.Data).(map[string]interface{})
+			redacted.StepResults[stepID] = &stepResult
+		}
+	}
+	return &redacted
+}
@@ -21,6 +21,9 @@ package schemas
 	// 4. EXECUTION CONFIGURATION (PoC: Sequential only)
 	execution_config?: #ExecutionConfig
 
+	// 5. TRIGGER (manual when omitted)
+	trigger?: #WorkflowTrigger
+
 	// Optional execution metadata
 	execution_order?: [...string] // Computed dependency order
 	validation_schema?: {...} // Additional validation rules
@@ -48,6 +51,18 @@ package schemas
 	description?: string
 	timeout?:     string // e.g., "30s", "5m", "1h"
 
+	// Human-in-the-loop gate: execution pauses until the user approves this step
+	requires_confirmation?: bool
+
+	// Reuse the outputs of an identical read within the step cache TTL; read actions only
+	cacheable?: bool
+
+	// Named workspace connection (e.g. "work", "personal"); overrides the service binding's connection
+	connection?: string
+
+	// Catalog provider (e.g. a staging proxy); overrides the service binding's provider
+	provider?: string
+
 	// MCP service metadata (derived from MCP tool definition)
 	_mcp_service_type?: string // e.g., "gmail", "docs", "drive", "calendar"
 }
@@ -79,11 +94,15 @@ package schemas
 	// UI hints
 	placeholder?: string
 	help_text?:   string
+
+	// Secret value (e.g. an API key): masked in logs and execution output, never prefilled
+	sensitive?: bool
 }
 
 #ServiceBinding: {
-	type:      "mcp_service" | "api_service" | "webhook"
-	provider?: string // e.g., "workspace"
+	type:        "mcp_service" | "api_service" | "webhook"
+	provider?:   string // e.g., "workspace"
+	connection?: string // named workspace connection used by steps of this service
 
 	// Authentication configuration
 	auth: #AuthConfig
@@ -107,7 +126,15 @@ package schemas
 #ExecutionConfig: {
 	mode:         "sequential" // PoC: Sequential execution only
 	timeout?:     string
-	environment?: "development" | "staging" | "production"
+	environment?: "development" | "staging" | "production" | "dev" | "local" | "test" | "testing" | "stage" | "prod" // aliases map to the canonical names
+	exclusive?:   bool // reject a new run of this workflow while one is running
+}
+
+#WorkflowTrigger: {
+	type:      "manual" | "schedule" | "event"
+	schedule?: string // cron or a phrase like "every weekday at 8 AM"
+	cron?:     string // normalized 5-field cron of schedule, registered by the scheduler
+	event?:    string
 }
 
 #AuthConfig: {
@@ -120,6 +147,9 @@ package schemas
 		header_name:  string
 		token_source: "user" | "service"
 	}
+	// Named secret for api_key auth, resolved from the backend's secret store at execution.
+	// Reference secrets by name only; never put the secret value in a workflow.
+	secret_ref?: string
 }
 
 #RateLimit: {